@@ -0,0 +1,266 @@
+/*
+Package archive exports and imports drand beacons as newline delimited JSON,
+one beacon per line in the format served by the drand HTTP API, e.g.
+
+	{"round":1,"randomness":"…","signature":"…","previous_signature":"…"}
+
+Archives hold the signatures of the beacons, so that they can be verified again
+against the chain info without any network access, e.g. by auditors, or when
+restoring them into an offline beacon store or another cache.
+
+Ranges of beacons can also be exported as CSV or Parquet, e.g. for analytics
+tools, with ExportFormat.
+*/
+package archive
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/csv"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"strconv"
+	"time"
+
+	"github.com/drand/drand/v2/common"
+	"github.com/drand/drand/v2/common/chain"
+	"github.com/drand/drand/v2/crypto"
+	"github.com/drand/go-clients/client"
+	"github.com/drand/go-clients/drand"
+	"github.com/drand/go-clients/verify"
+	"github.com/parquet-go/parquet-go"
+)
+
+// maxLineSize bounds the size of a line of an archive, which is far above the
+// size of a beacon of any scheme.
+const maxLineSize = 64 * 1024
+
+// Format is the encoding of the beacons written by ExportFormat.
+type Format string
+
+const (
+	// FormatNDJSON writes one beacon per line in the JSON format served by the
+	// drand HTTP API. It is the format of the archives read by Read and Import.
+	FormatNDJSON Format = "ndjson"
+	// FormatCSV writes the CSVHeader columns, for analytics tools, and is not
+	// meant to be imported back.
+	FormatCSV Format = "csv"
+	// FormatParquet writes the rows of ParquetRow, for analytics tools, and is
+	// not meant to be imported back.
+	FormatParquet Format = "parquet"
+)
+
+// CSVHeader is the first row of the CSV exports. The time of each round is
+// RFC 3339 encoded, in UTC, and the randomness and signatures hex encoded.
+var CSVHeader = []string{"round", "time", "randomness", "signature", "previous_signature"}
+
+// ParquetRow is a row of the Parquet exports, with the columns of CSVHeader. The
+// randomness and signatures are raw bytes rather than hex encoded.
+type ParquetRow struct {
+	Round             uint64    `parquet:"round"`
+	Time              time.Time `parquet:"time,timestamp(millisecond)"`
+	Randomness        []byte    `parquet:"randomness"`
+	Signature         []byte    `parquet:"signature"`
+	PreviousSignature []byte    `parquet:"previous_signature,optional"`
+}
+
+// parquetRowGroupSize bounds how many rows of a Parquet export are buffered
+// before being written as a row group.
+const parquetRowGroupSize = 4096
+
+// Export writes the rounds from to to (included) fetched from c to w, and returns
+// the number of beacons written. A to of 0 means the latest round. Every beacon is
+// verified against the chain info of c before being written, so that archives
+// only hold valid beacons even when c is insecure.
+func Export(ctx context.Context, c drand.Client, w io.Writer, from, to uint64) (int, error) {
+	return ExportFormat(ctx, c, w, from, to, FormatNDJSON)
+}
+
+// ExportFormat is Export writing the beacons in the given format. Beacons are
+// written as they are fetched, rather than once the whole range is, so that
+// large ranges can be exported.
+func ExportFormat(ctx context.Context, c drand.Client, w io.Writer, from, to uint64, format Format) (int, error) {
+	info, err := c.Info(ctx)
+	if err != nil {
+		return 0, fmt.Errorf("getting chain info: %w", err)
+	}
+
+	bw := bufio.NewWriter(w)
+	var write func(*client.RandomData) error
+	// closeWriter completes the encoding once all the beacons are written
+	closeWriter := func() error { return nil }
+	switch format {
+	case FormatNDJSON, "":
+		enc := json.NewEncoder(bw)
+		write = func(beacon *client.RandomData) error {
+			return enc.Encode(beacon)
+		}
+	case FormatCSV:
+		cw := csv.NewWriter(bw)
+		if err := cw.Write(CSVHeader); err != nil {
+			return 0, fmt.Errorf("writing header: %w", err)
+		}
+		row := make([]string, len(CSVHeader))
+		write = func(beacon *client.RandomData) error {
+			row[0] = strconv.FormatUint(beacon.GetRound(), 10)
+			row[1] = time.Unix(common.TimeOfRound(info.Period, info.GenesisTime, beacon.GetRound()), 0).UTC().Format(time.RFC3339)
+			row[2] = hex.EncodeToString(crypto.RandomnessFromSignature(beacon.GetSignature()))
+			row[3] = hex.EncodeToString(beacon.GetSignature())
+			row[4] = hex.EncodeToString(beacon.GetPreviousSignature())
+			if err := cw.Write(row); err != nil {
+				return err
+			}
+			// csv.Writer buffers the rows, which are then flushed to bw
+			cw.Flush()
+			return cw.Error()
+		}
+	case FormatParquet:
+		pw := parquet.NewGenericWriter[ParquetRow](bw, parquet.MaxRowsPerRowGroup(parquetRowGroupSize))
+		row := make([]ParquetRow, 1)
+		write = func(beacon *client.RandomData) error {
+			row[0] = ParquetRow{
+				Round:             beacon.GetRound(),
+				Time:              time.Unix(common.TimeOfRound(info.Period, info.GenesisTime, beacon.GetRound()), 0).UTC(),
+				Randomness:        crypto.RandomnessFromSignature(beacon.GetSignature()),
+				Signature:         beacon.GetSignature(),
+				PreviousSignature: beacon.GetPreviousSignature(),
+			}
+			_, err := pw.Write(row)
+			return err
+		}
+		// the footer of the file is written even after an error, so that the
+		// rows exported until then can be read
+		closeWriter = pw.Close
+	default:
+		return 0, fmt.Errorf("unsupported format %q, expected %s, %s or %s", format, FormatNDJSON, FormatCSV, FormatParquet)
+	}
+
+	n, err := export(ctx, c, info, from, to, write)
+	if cerr := closeWriter(); err == nil && cerr != nil {
+		err = fmt.Errorf("writing archive: %w", cerr)
+	}
+	if ferr := bw.Flush(); err == nil && ferr != nil {
+		err = fmt.Errorf("writing archive: %w", ferr)
+	}
+	return n, err
+}
+
+// export verifies the rounds from to to (included) fetched from c with
+// client.GetRange, and calls write with each of them in order.
+func export(ctx context.Context, c drand.Client, info *chain.Info, from, to uint64, write func(*client.RandomData) error) (int, error) {
+	if from == 0 {
+		from = 1
+	}
+	if to == 0 {
+		latest, err := c.Get(ctx, 0)
+		if err != nil {
+			return 0, fmt.Errorf("getting latest round: %w", err)
+		}
+		to = latest.GetRound()
+	}
+	if from > to {
+		return 0, fmt.Errorf("invalid range: round %d is after round %d", from, to)
+	}
+	v, err := verify.NewVerifier(info)
+	if err != nil {
+		return 0, err
+	}
+
+	n := 0
+	for r, err := range client.GetRange(ctx, c, from, to) {
+		if err != nil {
+			return n, err
+		}
+		beacon := toRandomData(r)
+		if err := v.Beacon(beacon); err != nil {
+			return n, err
+		}
+		if err := write(beacon); err != nil {
+			return n, fmt.Errorf("writing round %d: %w", beacon.GetRound(), err)
+		}
+		n++
+	}
+	return n, nil
+}
+
+// Read verifies the beacons of the archive r against info, and calls fn with
+// each of them in order. It stops at the first invalid beacon, or error of fn,
+// and returns the number of beacons read until then. Beacons of chained schemes
+// must also carry the signature of the previous beacon of the archive, if any.
+func Read(r io.Reader, info *chain.Info, fn func(*client.RandomData) error) (int, error) {
+	sc := bufio.NewScanner(r)
+	sc.Buffer(make([]byte, 0, 4096), maxLineSize)
+
+	var prev *client.RandomData
+	n, line := 0, 0
+	for sc.Scan() {
+		line++
+		b := bytes.TrimSpace(sc.Bytes())
+		if len(b) == 0 {
+			continue
+		}
+		beacon := new(client.RandomData)
+		if err := json.Unmarshal(b, beacon); err != nil {
+			return n, fmt.Errorf("line %d: decoding beacon: %w", line, err)
+		}
+		if err := verify.Beacon(info, beacon); err != nil {
+			return n, fmt.Errorf("line %d: %w", line, err)
+		}
+		if prev != nil && prev.GetRound()+1 == beacon.GetRound() && len(beacon.GetPreviousSignature()) > 0 &&
+			!bytes.Equal(prev.GetSignature(), beacon.GetPreviousSignature()) {
+			return n, fmt.Errorf("line %d: round %d does not chain on round %d", line, beacon.GetRound(), prev.GetRound())
+		}
+		if err := fn(beacon); err != nil {
+			return n, fmt.Errorf("line %d: %w", line, err)
+		}
+		prev = beacon
+		n++
+	}
+	if err := sc.Err(); err != nil {
+		return n, fmt.Errorf("reading archive: %w", err)
+	}
+	return n, nil
+}
+
+// Import verifies the beacons of the archive r against info, and adds them to
+// cache, e.g. to warm up the cache of a client with client.WithCache. It returns
+// the number of beacons imported.
+func Import(r io.Reader, info *chain.Info, cache client.Cache) (int, error) {
+	if cache == nil {
+		return 0, errors.New("a cache is required")
+	}
+	return Read(r, info, func(beacon *client.RandomData) error {
+		cache.Add(beacon.GetRound(), beacon)
+		return nil
+	})
+}
+
+type resultWithPreviousSignature interface {
+	GetPreviousSignature() []byte
+}
+
+type resultWithBeaconID interface {
+	GetBeaconID() string
+}
+
+func toRandomData(r drand.Result) *client.RandomData {
+	if rd, ok := r.(*client.RandomData); ok {
+		return rd
+	}
+	rd := &client.RandomData{
+		Rnd:    r.GetRound(),
+		Random: r.GetRandomness(),
+		Sig:    r.GetSignature(),
+	}
+	if rp, ok := r.(resultWithPreviousSignature); ok {
+		rd.PreviousSignature = rp.GetPreviousSignature()
+	}
+	if rb, ok := r.(resultWithBeaconID); ok {
+		rd.BeaconID = rb.GetBeaconID()
+	}
+	return rd
+}
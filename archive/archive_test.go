@@ -0,0 +1,146 @@
+package archive_test
+
+import (
+	"bytes"
+	"context"
+	"encoding/csv"
+	"encoding/hex"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/parquet-go/parquet-go"
+	"github.com/stretchr/testify/require"
+
+	"github.com/drand/drand/v2/common"
+	"github.com/drand/drand/v2/crypto"
+	"github.com/drand/go-clients/archive"
+	"github.com/drand/go-clients/client"
+	clientMock "github.com/drand/go-clients/client/mock"
+	"github.com/drand/go-clients/client/test/result/mock"
+	"github.com/drand/go-clients/drand"
+)
+
+type mapCache map[uint64]drand.Result
+
+func (m mapCache) TryGet(round uint64) drand.Result { return m[round] }
+
+func (m mapCache) Add(round uint64, r drand.Result) { m[round] = r }
+
+func TestExportImport(t *testing.T) {
+	ctx := context.Background()
+	sch, err := crypto.GetSchemeFromEnv()
+	require.NoError(t, err)
+	info, results := mock.VerifiableResults(4, sch)
+	c := &clientMock.Client{OptionalInfo: info, Results: results, StrictRounds: true}
+
+	var buf bytes.Buffer
+	n, err := archive.Export(ctx, c, &buf, 2, 4)
+	require.NoError(t, err)
+	require.Equal(t, 3, n)
+	require.Equal(t, 3, strings.Count(buf.String(), "\n"))
+
+	cache := make(mapCache)
+	n, err = archive.Import(bytes.NewReader(buf.Bytes()), info, cache)
+	require.NoError(t, err)
+	require.Equal(t, 3, n)
+	for _, r := range results[1:] {
+		got := cache.TryGet(r.GetRound())
+		require.NotNil(t, got)
+		require.Equal(t, r.GetSignature(), got.GetSignature())
+	}
+	require.Nil(t, cache.TryGet(1))
+
+	_, err = archive.Export(ctx, c, &buf, 3, 2)
+	require.Error(t, err)
+}
+
+func TestExportCSV(t *testing.T) {
+	ctx := context.Background()
+	sch, err := crypto.GetSchemeFromEnv()
+	require.NoError(t, err)
+	info, results := mock.VerifiableResults(4, sch)
+	c := &clientMock.Client{OptionalInfo: info, Results: results, StrictRounds: true}
+
+	var buf bytes.Buffer
+	n, err := archive.ExportFormat(ctx, c, &buf, 2, 3, archive.FormatCSV)
+	require.NoError(t, err)
+	require.Equal(t, 2, n)
+
+	rows, err := csv.NewReader(&buf).ReadAll()
+	require.NoError(t, err)
+	require.Len(t, rows, 3)
+	require.Equal(t, archive.CSVHeader, rows[0])
+	for i, r := range results[1:3] {
+		row := rows[i+1]
+		require.Equal(t, strconv.FormatUint(r.GetRound(), 10), row[0])
+		roundTime, err := time.Parse(time.RFC3339, row[1])
+		require.NoError(t, err)
+		require.Equal(t, common.TimeOfRound(info.Period, info.GenesisTime, r.GetRound()), roundTime.Unix())
+		require.Equal(t, hex.EncodeToString(r.GetRandomness()), row[2])
+		require.Equal(t, hex.EncodeToString(r.GetSignature()), row[3])
+		require.Equal(t, hex.EncodeToString(r.GetPreviousSignature()), row[4])
+	}
+
+	_, err = archive.ExportFormat(ctx, c, &buf, 2, 3, "xml")
+	require.Error(t, err)
+}
+
+func TestExportParquet(t *testing.T) {
+	ctx := context.Background()
+	sch, err := crypto.GetSchemeFromEnv()
+	require.NoError(t, err)
+	info, results := mock.VerifiableResults(4, sch)
+	c := &clientMock.Client{OptionalInfo: info, Results: results, StrictRounds: true}
+
+	var buf bytes.Buffer
+	n, err := archive.ExportFormat(ctx, c, &buf, 2, 4, archive.FormatParquet)
+	require.NoError(t, err)
+	require.Equal(t, 3, n)
+
+	rows, err := parquet.Read[archive.ParquetRow](bytes.NewReader(buf.Bytes()), int64(buf.Len()))
+	require.NoError(t, err)
+	require.Len(t, rows, 3)
+	for i, r := range results[1:] {
+		row := rows[i]
+		require.Equal(t, r.GetRound(), row.Round)
+		require.Equal(t, common.TimeOfRound(info.Period, info.GenesisTime, r.GetRound()), row.Time.Unix())
+		require.Equal(t, r.GetRandomness(), row.Randomness)
+		require.Equal(t, r.GetSignature(), row.Signature)
+		require.Equal(t, r.GetPreviousSignature(), row.PreviousSignature)
+	}
+}
+
+func TestImportInvalid(t *testing.T) {
+	sch, err := crypto.GetSchemeFromEnv()
+	require.NoError(t, err)
+	info, results := mock.VerifiableResults(3, sch)
+
+	var buf bytes.Buffer
+	for _, r := range results {
+		b, err := (&client.RandomData{
+			Rnd:               r.GetRound(),
+			Sig:               r.GetSignature(),
+			PreviousSignature: r.GetPreviousSignature(),
+		}).MarshalJSON()
+		require.NoError(t, err)
+		buf.Write(b)
+		buf.WriteString("\n")
+	}
+	valid := buf.String()
+
+	n, err := archive.Read(strings.NewReader(valid), info, func(*client.RandomData) error { return nil })
+	require.NoError(t, err)
+	require.Equal(t, 3, n)
+
+	// the signature of round 2 is swapped with the one of round 1
+	tampered := strings.Replace(valid, `"signature":"`+hex.EncodeToString(results[1].GetSignature()),
+		`"signature":"`+hex.EncodeToString(results[0].GetSignature()), 1)
+	n, err = archive.Read(strings.NewReader(tampered), info, func(*client.RandomData) error { return nil })
+	require.Error(t, err)
+	require.Equal(t, 1, n)
+
+	_, err = archive.Read(strings.NewReader("not json\n"), info, func(*client.RandomData) error { return nil })
+	require.ErrorContains(t, err, "line 1")
+}
@@ -0,0 +1,329 @@
+/*
+Package ipfs archives drand beacons to IPFS, through the RPC API of an IPFS
+node such as Kubo, for the long-term decentralized archiving of chains, e.g.
+with the --ipfs-api flag of the gossip relay:
+
+	a, _ := ipfs.New(ipfs.Config{API: "http://127.0.0.1:5001"})
+	go a.Run(ctx, c)
+
+Each beacon is added, and pinned, as a file holding its JSON encoding in the
+format of the drand HTTP API. The CIDs of the beacons of each UTC day are then
+listed in a manifest, added and pinned once the first beacon of the next day is
+archived, and linked to the manifest of the previous day archived:
+
+	{"chain_hash":"…","beacon_id":"quicknet","day":"2026-10-16","from":1,"to":28800,
+	 "beacons":[{"round":1,"cid":"bafk…"},…],"previous":"bafy…"}
+
+Manifests failing to be archived are retried, with a growing delay, as beacons
+keep being archived, and the reference of the latest one can be saved to a file
+(see Config.StatePath) so that the manifests of successive runs stay linked.
+
+Beacons are archived as given: they should be watched from a client verifying
+them, such as the clients made with client.New.
+*/
+package ipfs
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"mime/multipart"
+	nhttp "net/http"
+	"net/url"
+	"os"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/drand/drand/v2/common"
+	"github.com/drand/drand/v2/common/chain"
+	"github.com/drand/drand/v2/common/log"
+	"github.com/drand/go-clients/client"
+	"github.com/drand/go-clients/drand"
+)
+
+const (
+	// dayLayout is the layout of the days of the manifests.
+	dayLayout = "2006-01-02"
+	// maxResponseSize bounds the size of the responses read from the API.
+	maxResponseSize = 64 << 10
+	// minManifestRetry and maxManifestRetry bound the delay before archiving
+	// again manifests which failed to be archived.
+	minManifestRetry = 5 * time.Second
+	maxManifestRetry = 10 * time.Minute
+)
+
+// Config configures an Archiver.
+type Config struct {
+	// API is the URL of the RPC API of the IPFS node, e.g.
+	// "http://127.0.0.1:5001".
+	API string
+	// HTTPClient is the client requests are made with. It defaults to
+	// http.DefaultClient.
+	HTTPClient *nhttp.Client
+	// Logger logs the beacons which failed to be archived.
+	Logger log.Logger
+	// StatePath is the file the reference of the latest manifest archived is
+	// saved to, and read back from by New, so that the first manifest of a run
+	// is linked to the last one of the previous run. It is optional.
+	StatePath string
+}
+
+// Archiver adds and pins beacons, and their daily manifests, to IPFS.
+type Archiver struct {
+	api       string
+	client    *nhttp.Client
+	log       log.Logger
+	statePath string
+	minRetry  time.Duration
+	maxRetry  time.Duration
+
+	lk     sync.Mutex
+	latest ManifestRef
+}
+
+// Manifest lists the beacons of a chain archived during a UTC day.
+type Manifest struct {
+	ChainHash string `json:"chain_hash"`
+	BeaconID  string `json:"beacon_id,omitempty"`
+	// Day is the UTC day of the times of the rounds, as YYYY-MM-DD.
+	Day string `json:"day"`
+	// From and To are the first and last rounds archived during the day.
+	From    uint64          `json:"from"`
+	To      uint64          `json:"to"`
+	Beacons []ArchivedRound `json:"beacons"`
+	// Previous is the CID of the manifest of the previous day archived, if any.
+	Previous string `json:"previous,omitempty"`
+}
+
+// ArchivedRound is the CID of an archived beacon.
+type ArchivedRound struct {
+	Round uint64 `json:"round"`
+	CID   string `json:"cid"`
+}
+
+// ManifestRef is the CID of a manifest, and its day.
+type ManifestRef struct {
+	CID string `json:"cid"`
+	Day string `json:"day"`
+}
+
+// New returns an Archiver adding beacons to the IPFS node of cfg.API.
+func New(cfg Config) (*Archiver, error) {
+	u, err := url.Parse(cfg.API)
+	if err != nil {
+		return nil, fmt.Errorf("invalid IPFS API URL: %w", errors.Unwrap(err))
+	}
+	if u.Scheme != "http" && u.Scheme != "https" || u.Host == "" {
+		return nil, fmt.Errorf("invalid IPFS API URL %q: an http(s) URL is required", u.Redacted())
+	}
+	if cfg.HTTPClient == nil {
+		cfg.HTTPClient = nhttp.DefaultClient
+	}
+	if cfg.Logger == nil {
+		cfg.Logger = log.DefaultLogger()
+	}
+	a := &Archiver{
+		api:       strings.TrimSuffix(u.String(), "/"),
+		client:    cfg.HTTPClient,
+		log:       cfg.Logger,
+		statePath: cfg.StatePath,
+		minRetry:  minManifestRetry,
+		maxRetry:  maxManifestRetry,
+	}
+	if cfg.StatePath != "" {
+		b, err := os.ReadFile(cfg.StatePath)
+		switch {
+		case errors.Is(err, fs.ErrNotExist):
+		case err != nil:
+			return nil, fmt.Errorf("reading IPFS state: %w", err)
+		default:
+			if err := json.Unmarshal(b, &a.latest); err != nil {
+				return nil, fmt.Errorf("decoding IPFS state %s: %w", cfg.StatePath, err)
+			}
+		}
+	}
+	return a, nil
+}
+
+// LatestManifest returns the reference of the latest manifest archived, whose
+// CID is empty until the first day is complete, unless it was read from
+// Config.StatePath.
+func (a *Archiver) LatestManifest() ManifestRef {
+	a.lk.Lock()
+	defer a.lk.Unlock()
+	return a.latest
+}
+
+// Run archives every beacon watched from c, and the manifest of each day,
+// until ctx is done or the watch ends. Beacons failing to be archived are
+// logged, skipped and left out of the manifests, while the manifests failing
+// to be archived are logged and archived again, in order, after a delay
+// growing from a few seconds to a few minutes. The manifest of the day in
+// progress, and those still failing to be archived, are lost when Run ends.
+func (a *Archiver) Run(ctx context.Context, c drand.Client) error {
+	info, err := c.Info(ctx)
+	if err != nil {
+		return fmt.Errorf("getting chain info: %w", err)
+	}
+	var day *Manifest
+	var pending []*Manifest
+	var retryAt time.Time
+	retry := a.minRetry
+	for r := range c.Watch(ctx) {
+		d := roundDay(info, r.GetRound())
+		if day != nil && d != day.Day {
+			pending = append(pending, day)
+			day = nil
+			retryAt = time.Time{}
+		}
+		if len(pending) > 0 && !time.Now().Before(retryAt) {
+			pending = a.archiveManifests(ctx, pending)
+			if len(pending) > 0 {
+				retryAt = time.Now().Add(retry)
+				retry = min(2*retry, a.maxRetry)
+			} else {
+				retry = a.minRetry
+			}
+		}
+		if day == nil {
+			day = &Manifest{
+				ChainHash: info.HashString(),
+				BeaconID:  common.GetCanonicalBeaconID(info.ID),
+				Day:       d,
+				From:      r.GetRound(),
+			}
+		}
+
+		cid, err := a.AddBeacon(ctx, r)
+		if err != nil {
+			a.log.Warnw("", "ipfs", "failed to archive round", "round", r.GetRound(), "err", err)
+			continue
+		}
+		day.Beacons = append(day.Beacons, ArchivedRound{Round: r.GetRound(), CID: cid})
+		day.To = r.GetRound()
+	}
+	return ctx.Err()
+}
+
+// archiveManifests archives manifests in order, and returns those which were
+// not archived.
+func (a *Archiver) archiveManifests(ctx context.Context, ms []*Manifest) []*Manifest {
+	for i, m := range ms {
+		if err := a.archiveManifest(ctx, m); err != nil {
+			a.log.Warnw("", "ipfs", "failed to archive manifest, retrying later", "day", m.Day, "err", err)
+			return ms[i:]
+		}
+	}
+	return nil
+}
+
+// archiveManifest adds and pins the manifest of a day, linked to the latest
+// one, and which becomes the latest one.
+func (a *Archiver) archiveManifest(ctx context.Context, m *Manifest) error {
+	m.Previous = a.LatestManifest().CID
+	body, err := json.Marshal(m)
+	if err != nil {
+		return err
+	}
+	cid, err := a.add(ctx, fmt.Sprintf("%s-%s.json", m.ChainHash, m.Day), body)
+	if err != nil {
+		return fmt.Errorf("archiving manifest of %s: %w", m.Day, err)
+	}
+	latest := ManifestRef{CID: cid, Day: m.Day}
+	a.lk.Lock()
+	a.latest = latest
+	a.lk.Unlock()
+	a.log.Infow("", "ipfs", "archived manifest", "day", m.Day, "cid", cid, "beacons", len(m.Beacons))
+	if err := a.saveState(latest); err != nil {
+		a.log.Warnw("", "ipfs", "failed to save the latest manifest", "path", a.statePath, "err", err)
+	}
+	return nil
+}
+
+// saveState saves the reference of the latest manifest to the state file, if
+// any, replacing it atomically.
+func (a *Archiver) saveState(latest ManifestRef) error {
+	if a.statePath == "" {
+		return nil
+	}
+	b, err := json.Marshal(latest)
+	if err != nil {
+		return err
+	}
+	tmp := a.statePath + ".tmp"
+	if err := os.WriteFile(tmp, b, 0o644); err != nil {
+		return err
+	}
+	return os.Rename(tmp, a.statePath)
+}
+
+// AddBeacon adds and pins a beacon, and returns its CID.
+func (a *Archiver) AddBeacon(ctx context.Context, r drand.Result) (string, error) {
+	body, err := json.Marshal(&client.RandomData{
+		Rnd:               r.GetRound(),
+		Random:            r.GetRandomness(),
+		Sig:               r.GetSignature(),
+		PreviousSignature: r.GetPreviousSignature(),
+	})
+	if err != nil {
+		return "", err
+	}
+	return a.add(ctx, fmt.Sprintf("%d.json", r.GetRound()), body)
+}
+
+// addResponse is the response of the add method of the RPC API.
+type addResponse struct {
+	Hash string `json:"Hash"`
+}
+
+// add adds and pins a file, and returns its CID.
+func (a *Archiver) add(ctx context.Context, name string, content []byte) (string, error) {
+	var body bytes.Buffer
+	mw := multipart.NewWriter(&body)
+	fw, err := mw.CreateFormFile("file", name)
+	if err != nil {
+		return "", err
+	}
+	if _, err := fw.Write(content); err != nil {
+		return "", err
+	}
+	if err := mw.Close(); err != nil {
+		return "", err
+	}
+
+	req, err := nhttp.NewRequestWithContext(ctx, nhttp.MethodPost, a.api+"/api/v0/add?pin=true&cid-version=1", &body)
+	if err != nil {
+		return "", fmt.Errorf("creating request: %w", err)
+	}
+	req.Header.Set("Content-Type", mw.FormDataContentType())
+	resp, err := a.client.Do(req)
+	if err != nil {
+		return "", fmt.Errorf("adding %s: %w", name, err)
+	}
+	defer resp.Body.Close()
+	b, err := io.ReadAll(io.LimitReader(resp.Body, maxResponseSize))
+	if err != nil {
+		return "", fmt.Errorf("reading response: %w", err)
+	}
+	if resp.StatusCode != nhttp.StatusOK {
+		return "", fmt.Errorf("adding %s: status %d: %s", name, resp.StatusCode, bytes.TrimSpace(b))
+	}
+	var added addResponse
+	if err := json.Unmarshal(b, &added); err != nil {
+		return "", fmt.Errorf("decoding response: %w", err)
+	}
+	if added.Hash == "" {
+		return "", errors.New("no CID in response")
+	}
+	return added.Hash, nil
+}
+
+// roundDay returns the UTC day of the time of a round.
+func roundDay(info *chain.Info, round uint64) string {
+	return time.Unix(common.TimeOfRound(info.Period, info.GenesisTime, round), 0).UTC().Format(dayLayout)
+}
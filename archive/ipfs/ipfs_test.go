@@ -0,0 +1,152 @@
+package ipfs
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	nhttp "net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/drand/drand/v2/crypto"
+	"github.com/drand/go-clients/client"
+	clientMock "github.com/drand/go-clients/client/mock"
+	"github.com/drand/go-clients/client/test/result/mock"
+	"github.com/drand/go-clients/drand"
+)
+
+// node is the add method of the RPC API of an IPFS node, keeping the files
+// added by CID.
+type node struct {
+	lk    sync.Mutex
+	files map[string][]byte
+	names map[string]string
+	// failManifests is the number of manifests to fail to add.
+	failManifests int
+}
+
+func newNode(t *testing.T) (*node, string) {
+	t.Helper()
+	n := &node{files: make(map[string][]byte), names: make(map[string]string)}
+	srv := httptest.NewServer(nhttp.HandlerFunc(func(w nhttp.ResponseWriter, r *nhttp.Request) {
+		if r.URL.Path != "/api/v0/add" || r.URL.Query().Get("pin") != "true" {
+			w.WriteHeader(nhttp.StatusBadRequest)
+			return
+		}
+		f, h, err := r.FormFile("file")
+		if err != nil {
+			w.WriteHeader(nhttp.StatusBadRequest)
+			return
+		}
+		content, _ := io.ReadAll(f)
+		n.lk.Lock()
+		if strings.Contains(h.Filename, "-") && n.failManifests > 0 {
+			n.failManifests--
+			n.lk.Unlock()
+			w.WriteHeader(nhttp.StatusInternalServerError)
+			return
+		}
+		cid := fmt.Sprintf("bafy%d", len(n.files))
+		n.files[cid] = content
+		n.names[cid] = h.Filename
+		n.lk.Unlock()
+		_ = json.NewEncoder(w).Encode(map[string]string{"Name": h.Filename, "Hash": cid, "Size": "1"})
+	}))
+	t.Cleanup(srv.Close)
+	return n, srv.URL
+}
+
+// dailyClient returns a client watching n beacons, one a day.
+func dailyClient(t *testing.T, n int) (*clientMock.Client, []mock.Result) {
+	t.Helper()
+	sch, err := crypto.GetSchemeFromEnv()
+	require.NoError(t, err)
+	info, results := mock.VerifiableResults(n, sch)
+	// one round a day, from the start of a day
+	info.Period = 24 * time.Hour
+	info.GenesisTime = time.Date(2026, 10, 14, 0, 0, 0, 0, time.UTC).Unix()
+	c := &clientMock.Client{
+		OptionalInfo: info,
+		WatchF: func(context.Context) <-chan drand.Result {
+			ch := make(chan drand.Result, len(results))
+			for i := range results {
+				ch <- &results[i]
+			}
+			close(ch)
+			return ch
+		},
+	}
+	return c, results
+}
+
+func TestArchiver(t *testing.T) {
+	c, results := dailyClient(t, 3)
+	info := c.OptionalInfo
+
+	n, api := newNode(t)
+	a, err := New(Config{API: api})
+	require.NoError(t, err)
+	require.Empty(t, a.LatestManifest().CID)
+	require.NoError(t, a.Run(context.Background(), c))
+
+	// the manifests of the two complete days are archived, and linked
+	latest := a.LatestManifest()
+	require.Equal(t, "2026-10-15", latest.Day)
+	var m Manifest
+	require.NoError(t, json.Unmarshal(n.files[latest.CID], &m))
+	require.Equal(t, info.HashString(), m.ChainHash)
+	require.Equal(t, uint64(2), m.From)
+	require.Equal(t, uint64(2), m.To)
+	require.Len(t, m.Beacons, 1)
+
+	var beacon client.RandomData
+	require.NoError(t, json.Unmarshal(n.files[m.Beacons[0].CID], &beacon))
+	require.Equal(t, results[1].GetSignature(), beacon.GetSignature())
+	require.Equal(t, "2.json", n.names[m.Beacons[0].CID])
+
+	var previous Manifest
+	require.NoError(t, json.Unmarshal(n.files[m.Previous], &previous))
+	require.Equal(t, "2026-10-14", previous.Day)
+	require.Equal(t, uint64(1), previous.Beacons[0].Round)
+	require.Empty(t, previous.Previous)
+
+	_, err = New(Config{API: "ipfs://localhost"})
+	require.Error(t, err)
+}
+
+func TestArchiverRetriesManifests(t *testing.T) {
+	c, _ := dailyClient(t, 4)
+
+	n, api := newNode(t)
+	n.failManifests = 1
+	state := filepath.Join(t.TempDir(), "ipfs.json")
+	a, err := New(Config{API: api, StatePath: state})
+	require.NoError(t, err)
+	a.minRetry = 0
+	require.NoError(t, a.Run(context.Background(), c))
+
+	// the manifest of the first day is archived along with the second one,
+	// and the beacons archived meanwhile are not lost
+	latest := a.LatestManifest()
+	require.Equal(t, "2026-10-16", latest.Day)
+	for _, day := range []string{"2026-10-16", "2026-10-15", "2026-10-14"} {
+		var m Manifest
+		require.NoError(t, json.Unmarshal(n.files[latest.CID], &m))
+		require.Equal(t, day, m.Day)
+		require.Len(t, m.Beacons, 1)
+		latest.CID = m.Previous
+	}
+	require.Empty(t, latest.CID)
+
+	// the latest manifest is resumed from the state file
+	resumed, err := New(Config{API: api, StatePath: state})
+	require.NoError(t, err)
+	require.Equal(t, a.LatestManifest(), resumed.LatestManifest())
+}
@@ -0,0 +1,162 @@
+/*
+Package bridge publishes the beacons watched from a drand client to a message
+queue, so that event-driven backends can consume drand as any other stream of
+events, e.g. with the --bridge-url flag of the gossip relay:
+
+	pub, _ := bridge.Dial(ctx, "kafka-rest+http://127.0.0.1:8082")
+	defer pub.Close()
+	go bridge.Run(ctx, c, pub, bridge.Config{Encoding: bridge.JSON})
+
+The v2 API of Kafka REST Proxies, e.g. the Confluent REST Proxy, is reached
+over "kafka-rest+http://" or "kafka-rest+https://" URLs, with a plain HTTP
+client, as the native Kafka protocol is out of scope. NATS servers and MQTT
+brokers are reached with the publishers of the bridge/nats and bridge/mqtt
+packages, built on the nats.go and paho.mqtt.golang clients, which are only
+dependencies of the programs importing them. Other queues can be used by
+implementing Publisher.
+
+Beacons are published as given: they should be watched from a client
+verifying them, such as the clients made with client.New.
+*/
+package bridge
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/url"
+	"strings"
+	"time"
+
+	"google.golang.org/protobuf/proto"
+
+	"github.com/drand/drand/v2/common"
+	"github.com/drand/drand/v2/common/chain"
+	"github.com/drand/drand/v2/common/log"
+	protod "github.com/drand/drand/v2/protobuf/drand"
+	"github.com/drand/go-clients/client"
+	"github.com/drand/go-clients/drand"
+)
+
+// DefaultPublishTimeout bounds the time spent publishing a beacon.
+const DefaultPublishTimeout = 10 * time.Second
+
+// Publisher publishes messages to the topics of a message queue.
+type Publisher interface {
+	// Publish publishes msg to topic, e.g. a NATS subject or a Kafka topic.
+	Publish(ctx context.Context, topic string, msg []byte) error
+	// Close releases the connections of the publisher.
+	Close() error
+}
+
+// Dial returns the publisher of the Kafka REST Proxy at rawURL, a
+// "kafka-rest+http://" or "kafka-rest+https://" URL.
+func Dial(_ context.Context, rawURL string) (Publisher, error) {
+	u, err := url.Parse(rawURL)
+	if err != nil {
+		return nil, fmt.Errorf("invalid message queue URL: %w", errors.Unwrap(err))
+	}
+	var pub Publisher
+	switch u.Scheme {
+	case "kafka-rest+http", "kafka-rest+https":
+		u.Scheme = strings.TrimPrefix(u.Scheme, "kafka-rest+")
+		pub, err = NewKafkaREST(u.String(), nil)
+	default:
+		return nil, fmt.Errorf("unsupported message queue URL %q: expected kafka-rest+http:// or kafka-rest+https://", u.Redacted())
+	}
+	if err != nil {
+		return nil, err
+	}
+	return pub, nil
+}
+
+// Encoding is the format of the beacons published.
+type Encoding string
+
+const (
+	// JSON encodes beacons as the drand HTTP API does.
+	JSON Encoding = "json"
+	// Proto encodes beacons as the PublicRandResponse protobuf messages of the
+	// drand gRPC API, with the chain hash and beacon ID as metadata.
+	Proto Encoding = "proto"
+)
+
+// ParseEncoding returns the encoding of the given name, "json" or "proto".
+func ParseEncoding(name string) (Encoding, error) {
+	switch e := Encoding(name); e {
+	case JSON, Proto:
+		return e, nil
+	default:
+		return "", fmt.Errorf("unknown encoding %q: expected %q or %q", name, JSON, Proto)
+	}
+}
+
+// Encode encodes a beacon of the given chain.
+func (e Encoding) Encode(info *chain.Info, r drand.Result) ([]byte, error) {
+	rd := &client.RandomData{
+		Rnd:               r.GetRound(),
+		Random:            r.GetRandomness(),
+		Sig:               r.GetSignature(),
+		PreviousSignature: r.GetPreviousSignature(),
+	}
+	switch e {
+	case JSON:
+		return json.Marshal(rd)
+	case Proto:
+		p := rd.ToProto()
+		p.Metadata = &protod.Metadata{BeaconID: common.GetCanonicalBeaconID(info.ID), ChainHash: info.Hash()}
+		return proto.Marshal(p)
+	default:
+		return nil, fmt.Errorf("unknown encoding %q", e)
+	}
+}
+
+// Config configures the publication of the beacons of a chain.
+type Config struct {
+	// Topic is the topic the beacons are published to. It defaults to
+	// "drand.<chain hash>".
+	Topic string
+	// Encoding is the format of the beacons published, JSON by default.
+	Encoding Encoding
+	// Timeout bounds the time spent publishing a beacon, DefaultPublishTimeout
+	// by default.
+	Timeout time.Duration
+	// Logger logs the beacons which failed to be published.
+	Logger log.Logger
+}
+
+// Run publishes every beacon watched from c to pub, until ctx is done or the
+// watch ends. Failed publications are logged and skipped.
+func Run(ctx context.Context, c drand.Client, pub Publisher, cfg Config) error {
+	info, err := c.Info(ctx)
+	if err != nil {
+		return fmt.Errorf("getting chain info: %w", err)
+	}
+	if cfg.Topic == "" {
+		cfg.Topic = "drand." + info.HashString()
+	}
+	if cfg.Encoding == "" {
+		cfg.Encoding = JSON
+	}
+	if cfg.Timeout <= 0 {
+		cfg.Timeout = DefaultPublishTimeout
+	}
+	if cfg.Logger == nil {
+		cfg.Logger = log.DefaultLogger()
+	}
+
+	for r := range c.Watch(ctx) {
+		msg, err := cfg.Encoding.Encode(info, r)
+		if err != nil {
+			return fmt.Errorf("encoding round %d: %w", r.GetRound(), err)
+		}
+		pctx, cancel := context.WithTimeout(ctx, cfg.Timeout)
+		err = pub.Publish(pctx, cfg.Topic, msg)
+		cancel()
+		if err != nil {
+			cfg.Logger.Warnw("", "bridge", "failed to publish round", "round", r.GetRound(), "topic", cfg.Topic, "err", err)
+		}
+	}
+	return ctx.Err()
+}
@@ -0,0 +1,111 @@
+package bridge
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	nhttp "net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	"google.golang.org/protobuf/proto"
+
+	"github.com/drand/drand/v2/crypto"
+	protod "github.com/drand/drand/v2/protobuf/drand"
+	"github.com/drand/go-clients/client"
+	clientMock "github.com/drand/go-clients/client/mock"
+	"github.com/drand/go-clients/client/test/result/mock"
+	"github.com/drand/go-clients/drand"
+)
+
+func TestKafkaREST(t *testing.T) {
+	ctx := context.Background()
+	var got [][]byte
+	srv := httptest.NewServer(nhttp.HandlerFunc(func(w nhttp.ResponseWriter, r *nhttp.Request) {
+		if r.URL.Path != "/topics/drand.test" {
+			w.WriteHeader(nhttp.StatusNotFound)
+			_, _ = io.WriteString(w, `{"error_code":40401,"message":"Topic not found."}`)
+			return
+		}
+		user, pass, _ := r.BasicAuth()
+		if r.Header.Get("Content-Type") != kafkaContentType || user != "user" || pass != "secret" {
+			w.WriteHeader(nhttp.StatusBadRequest)
+			return
+		}
+		var req kafkaProduceRequest
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			w.WriteHeader(nhttp.StatusBadRequest)
+			return
+		}
+		for _, rec := range req.Records {
+			got = append(got, rec.Value)
+		}
+		_, _ = io.WriteString(w, `{"offsets":[{"partition":0,"offset":1,"error_code":null,"error":null}]}`)
+	}))
+	defer srv.Close()
+
+	pub, err := Dial(ctx, strings.Replace(srv.URL, "http://", "kafka-rest+http://user:secret@", 1))
+	require.NoError(t, err)
+	defer pub.Close()
+	require.NoError(t, pub.Publish(ctx, "drand.test", []byte("hello")))
+	require.Equal(t, [][]byte{[]byte("hello")}, got)
+	require.ErrorContains(t, pub.Publish(ctx, "other", []byte("hello")), "Topic not found")
+
+	_, err = Dial(ctx, "amqp://127.0.0.1")
+	require.Error(t, err)
+}
+
+// recorder is a Publisher recording the messages published.
+type recorder struct {
+	topics []string
+	msgs   [][]byte
+}
+
+func (r *recorder) Publish(_ context.Context, topic string, msg []byte) error {
+	r.topics = append(r.topics, topic)
+	r.msgs = append(r.msgs, msg)
+	return nil
+}
+
+func (r *recorder) Close() error {
+	return nil
+}
+
+func TestRun(t *testing.T) {
+	sch, err := crypto.GetSchemeFromEnv()
+	require.NoError(t, err)
+	info, results := mock.VerifiableResults(3, sch)
+	c := &clientMock.Client{
+		OptionalInfo: info,
+		WatchF: func(context.Context) <-chan drand.Result {
+			ch := make(chan drand.Result, len(results))
+			for i := range results {
+				ch <- &results[i]
+			}
+			close(ch)
+			return ch
+		},
+	}
+
+	rec := &recorder{}
+	require.NoError(t, Run(context.Background(), c, rec, Config{}))
+	require.Len(t, rec.msgs, len(results))
+	require.Equal(t, "drand."+info.HashString(), rec.topics[0])
+	var rd client.RandomData
+	require.NoError(t, json.Unmarshal(rec.msgs[1], &rd))
+	require.Equal(t, results[1].GetRound(), rd.GetRound())
+	require.Equal(t, results[1].GetSignature(), rd.GetSignature())
+
+	rec = &recorder{}
+	require.NoError(t, Run(context.Background(), c, rec, Config{Topic: "beacons", Encoding: Proto}))
+	require.Equal(t, "beacons", rec.topics[0])
+	var p protod.PublicRandResponse
+	require.NoError(t, proto.Unmarshal(rec.msgs[2], &p))
+	require.Equal(t, results[2].GetRound(), p.GetRound())
+	require.Equal(t, info.Hash(), p.GetMetadata().GetChainHash())
+
+	_, err = ParseEncoding("xml")
+	require.Error(t, err)
+}
@@ -0,0 +1,79 @@
+package mqtt
+
+import (
+	"context"
+	"io"
+	"log/slog"
+	"testing"
+	"time"
+
+	mochi "github.com/mochi-mqtt/server/v2"
+	"github.com/mochi-mqtt/server/v2/hooks/auth"
+	"github.com/mochi-mqtt/server/v2/listeners"
+	"github.com/mochi-mqtt/server/v2/packets"
+	"github.com/stretchr/testify/require"
+)
+
+// runBroker runs a broker accepting user:secret, and returns its address and
+// the messages published to drand/#.
+func runBroker(t *testing.T) (string, <-chan packets.Packet) {
+	t.Helper()
+	s := mochi.New(&mochi.Options{
+		InlineClient: true,
+		Logger:       slog.New(slog.NewTextHandler(io.Discard, nil)),
+	})
+	require.NoError(t, s.AddHook(new(auth.Hook), &auth.Options{
+		Ledger: &auth.Ledger{Auth: auth.AuthRules{{Username: "user", Password: "secret", Allow: true}}},
+	}))
+	l := listeners.NewTCP(listeners.Config{ID: "tcp", Address: "127.0.0.1:0"})
+	require.NoError(t, s.AddListener(l))
+	require.NoError(t, s.Serve())
+	t.Cleanup(func() { _ = s.Close() })
+
+	msgs := make(chan packets.Packet, 8)
+	require.NoError(t, s.Subscribe("drand/#", 1, func(_ *mochi.Client, _ packets.Subscription, pk packets.Packet) {
+		msgs <- pk
+	}))
+	return l.Address(), msgs
+}
+
+func TestPublish(t *testing.T) {
+	ctx := context.Background()
+	addr, msgs := runBroker(t)
+
+	for _, cfg := range []Config{{QoS: 0}, {QoS: 1}, {QoS: 2, Retain: true}} {
+		pub, err := Dial(ctx, "mqtt://user:secret@"+addr, cfg)
+		require.NoError(t, err)
+		require.Equal(t, `MQTT("`+addr+`")`, pub.String())
+
+		require.NoError(t, pub.Publish(ctx, "drand/beacons", []byte("beacon")))
+		select {
+		case pk := <-msgs:
+			require.Equal(t, "drand/beacons", pk.TopicName)
+			require.Equal(t, "beacon", string(pk.Payload))
+			require.Equal(t, cfg.QoS, pk.FixedHeader.Qos)
+			require.Equal(t, cfg.Retain, pk.FixedHeader.Retain)
+		case <-time.After(5 * time.Second):
+			t.Fatalf("message of QoS %d not received", cfg.QoS)
+		}
+
+		require.ErrorContains(t, pub.Publish(ctx, "drand/+", []byte("beacon")), "invalid topic")
+		require.ErrorContains(t, pub.Publish(ctx, "", []byte("beacon")), "invalid topic")
+		require.NoError(t, pub.Close())
+	}
+}
+
+func TestDial(t *testing.T) {
+	ctx := context.Background()
+	addr, _ := runBroker(t)
+
+	_, err := Dial(ctx, "mqtt://user:wrong@"+addr, Config{})
+	require.ErrorContains(t, err, "connecting to MQTT broker")
+
+	_, err = Dial(ctx, "mqtt://user:secret@"+addr, Config{QoS: 3})
+	require.ErrorContains(t, err, "invalid MQTT QoS")
+	_, err = Dial(ctx, "http://"+addr, Config{})
+	require.ErrorContains(t, err, "invalid MQTT URL")
+	_, err = Dial(ctx, "mqtt://", Config{})
+	require.ErrorContains(t, err, "invalid MQTT URL")
+}
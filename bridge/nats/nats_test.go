@@ -0,0 +1,80 @@
+package nats
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/nats-io/nats-server/v2/server"
+	natsgo "github.com/nats-io/nats.go"
+	"github.com/stretchr/testify/require"
+)
+
+func runServer(t *testing.T) *server.Server {
+	t.Helper()
+	s, err := server.NewServer(&server.Options{
+		Host:     "127.0.0.1",
+		Port:     server.RANDOM_PORT,
+		Username: "user",
+		Password: "secret",
+		NoLog:    true,
+		NoSigs:   true,
+	})
+	require.NoError(t, err)
+	go s.Start()
+	require.True(t, s.ReadyForConnections(5*time.Second))
+	t.Cleanup(s.Shutdown)
+	return s
+}
+
+func TestPublish(t *testing.T) {
+	ctx := context.Background()
+	s := runServer(t)
+	addr := s.Addr().String()
+
+	sub, err := natsgo.Connect("nats://"+addr, natsgo.UserInfo("user", "secret"))
+	require.NoError(t, err)
+	defer sub.Close()
+	msgs := make(chan *natsgo.Msg, 4)
+	_, err = sub.ChanSubscribe("drand.>", msgs)
+	require.NoError(t, err)
+	require.NoError(t, sub.Flush())
+
+	pub, err := Dial(ctx, "nats://user:secret@"+addr)
+	require.NoError(t, err)
+	defer pub.Close()
+	require.Equal(t, `NATS("`+addr+`")`, pub.String())
+
+	require.NoError(t, pub.Publish(ctx, "drand.beacons", []byte("beacon 1")))
+	require.NoError(t, pub.Publish(ctx, "drand.beacons", []byte("beacon 2")))
+	for _, want := range []string{"beacon 1", "beacon 2"} {
+		select {
+		case m := <-msgs:
+			require.Equal(t, "drand.beacons", m.Subject)
+			require.Equal(t, want, string(m.Data))
+		case <-time.After(5 * time.Second):
+			t.Fatalf("%q not received", want)
+		}
+	}
+
+	require.Error(t, pub.Publish(ctx, "", []byte("beacon 3")))
+}
+
+func TestDial(t *testing.T) {
+	ctx := context.Background()
+	s := runServer(t)
+	addr := s.Addr().String()
+
+	_, err := Dial(ctx, "nats://user:wrong@"+addr)
+	require.ErrorContains(t, err, "connecting to NATS server")
+
+	_, err = Dial(ctx, "http://"+addr)
+	require.ErrorContains(t, err, "invalid NATS URL")
+	_, err = Dial(ctx, "nats://")
+	require.ErrorContains(t, err, "invalid NATS URL")
+
+	cctx, cancel := context.WithCancel(ctx)
+	cancel()
+	_, err = Dial(cctx, "nats://user:secret@"+addr)
+	require.ErrorIs(t, err, context.Canceled)
+}
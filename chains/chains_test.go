@@ -0,0 +1,26 @@
+package chains_test
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/drand/go-clients/chains"
+)
+
+func TestKnownChains(t *testing.T) {
+	q := chains.Quicknet()
+	require.Equal(t, chains.QuicknetHash, q.HashString())
+	require.Equal(t, "quicknet", q.ID)
+
+	d := chains.Default()
+	require.Equal(t, chains.DefaultHash, d.HashString())
+	require.Equal(t, "default", d.ID)
+
+	require.Equal(t, q.HashString(), chains.ByHash(chains.QuicknetHash).HashString())
+	require.Nil(t, chains.ByHash("00"))
+
+	// accessors return copies that callers are free to modify
+	q.ID = "modified"
+	require.Equal(t, "quicknet", chains.Quicknet().ID)
+}
@@ -2,12 +2,19 @@ package client
 
 import (
 	"context"
+	"errors"
 	"fmt"
+	"maps"
+	"slices"
 	"sync"
 	"time"
 
+	clock "github.com/jonboulle/clockwork"
+
+	"github.com/drand/drand/v2/common/chain"
 	"github.com/drand/drand/v2/common/log"
 	"github.com/drand/go-clients/drand"
+	"github.com/drand/go-clients/internal/metrics"
 )
 
 const (
@@ -15,6 +22,21 @@ const (
 	// defaultAutoWatchRetry is the time after which the watch channel
 	// created by the autoWatch is re-opened when no context error occurred.
 	defaultAutoWatchRetry = time.Second * 30
+	// webhookDeliveryTimeout bounds the time spent delivering a result to a webhook, retries included.
+	webhookDeliveryTimeout = time.Minute
+	// webhookQueueSize bounds the results waiting to be delivered to each
+	// webhook, the next ones being dropped while it is full.
+	webhookQueueSize = 16
+	// maxGapFill bounds the number of missed rounds backfilled when a watch
+	// skips rounds, so that a long outage does not stall the watch.
+	maxGapFill = 100
+	// defaultGapFillTimeout bounds the time spent backfilling missed rounds,
+	// during which the new rounds are held, so that a gap does not stall the
+	// watch: the rounds not backfilled in time are reported as lags.
+	defaultGapFillTimeout = 5 * time.Second
+	// defaultDedupWindow is the number of recently delivered rounds remembered
+	// to suppress duplicates.
+	defaultDedupWindow = 16
 )
 
 // newWatchAggregator maintains state of consumers calling `Watch` so that a
@@ -28,13 +50,21 @@ func newWatchAggregator(l log.Logger, c, wc drand.Client, autoWatch bool, autoWa
 	if autoWatchRetry == 0 {
 		autoWatchRetry = defaultAutoWatchRetry
 	}
+	stopCtx, cancelStop := context.WithCancel(context.Background())
 	aggregator := &watchAggregator{
 		Client:         c,
 		passiveClient:  wc,
 		autoWatch:      autoWatch,
 		autoWatchRetry: autoWatchRetry,
+		dedupWindow:    defaultDedupWindow,
+		gapFillTimeout: defaultGapFillTimeout,
+		clock:          clock.NewRealClock(),
 		log:            l,
-		subscribers:    make([]subscriber, 0),
+		subscribers:    make([]*subscriber, 0),
+		stopCtx:        stopCtx,
+		cancelStop:     cancelStop,
+		stopping:       stopCtx.Done(),
+		stopped:        make(chan struct{}),
 	}
 	return aggregator
 }
@@ -42,6 +72,21 @@ func newWatchAggregator(l log.Logger, c, wc drand.Client, autoWatch bool, autoWa
 type subscriber struct {
 	ctx context.Context
 	c   chan drand.Result
+	// closed is set once c is closed.
+	closed bool
+	// pending is set while the latest round is fetched for a watch of a client
+	// made with WithImmediateFirstResult, the results distributed meanwhile
+	// being held until it is sent.
+	pending bool
+	held    []drand.Result
+	// last is the last round sent, the rounds up to it being skipped so
+	// that rounds are sent in increasing order. missed is the first round
+	// dropped since then, if any, and dropped the last one.
+	last    uint64
+	missed  uint64
+	dropped uint64
+	// onLag is notified of the rounds not sent, see OnLag.
+	onLag func(Lag)
 }
 
 type watchAggregator struct {
@@ -49,22 +94,104 @@ type watchAggregator struct {
 	passiveClient   drand.Client
 	autoWatch       bool
 	autoWatchRetry  time.Duration
+	dedupWindow     int
+	clock           clock.Clock
 	log             log.Logger
 	cancelAutoWatch context.CancelFunc
 
 	subscriberLock sync.Mutex
-	subscribers    []subscriber
+	subscribers    []*subscriber
 	cancelPassive  context.CancelFunc
+	// closed is set, under subscriberLock, once the client is stopping.
+	closed bool
+
+	// wg tracks the auto watch, distribution and webhook goroutines, waited for by Stop.
+	wg       sync.WaitGroup
+	stopOnce sync.Once
+	// stopCtx is canceled when Stop is first called, closing stopping, and
+	// stopped is closed once it is done.
+	stopCtx    context.Context
+	cancelStop context.CancelFunc
+	stopping   <-chan struct{}
+	stopped    chan struct{}
+	stopErr    error
+
+	// webhooks are notified of every result distributed to subscribers,
+	// through the queues of their workers, started with the first result.
+	webhooks      []*WebhookNotifier
+	webhookQueues []chan drand.Result
+	startWebhooks sync.Once
+	// immediateFirst makes Watch send the latest round before the new ones.
+	immediateFirst bool
+	// noGapFilling disables the backfilling of rounds skipped by the watch,
+	// and gapFillTimeout bounds it.
+	noGapFilling   bool
+	gapFillTimeout time.Duration
+	// optimizer collects the statistics of the upstreams of the client.
+	optimizer *optimizingClient
+	// info is the chain info the client trusts, used to schedule rounds.
+	info *chain.Info
+	// verifiers report the verification checkpoint of the client, and relays
+	// are the ones of the relays of relayList, by URL, guarded by verifiersLk.
+	verifiersLk sync.RWMutex
+	verifiers   []*verifyingClient
+	relays      map[string]*verifyingClient
+	// relayList, if set, is fetched periodically to replace the relays of
+	// the optimizer, whose clients are made by newVerifier.
+	relayList   *relayList
+	newVerifier func(drand.Client) *verifyingClient
+	// infoRefresh is the interval at which the verifiers re-validate the chain
+	// info of their source, if positive, notifying onChainChange of changes.
+	infoRefresh   time.Duration
+	onChainChange func(*ChainChangedError)
+	// forkCheck is the interval at which the sources are checked to serve the
+	// same signatures, if positive, notifying onFork of forks.
+	forkCheck time.Duration
+	onFork    func(*ForkError)
+
+	statusLk  sync.Mutex
+	latest    uint64
+	lastFetch time.Time
+
+	// lags are the lags of the subscribers to report, under subscriberLock,
+	// and lagLk keeps them reported in order.
+	lags  []lagReport
+	lagLk sync.Mutex
 }
 
-// Start initiates auto watching if configured to do so.
-// SetLog should not be called after Start.
+// lagReport is a lag to report to the handler of a subscriber.
+type lagReport struct {
+	onLag func(Lag)
+	lag   Lag
+}
+
+var (
+	_ drand.StatusProvider   = (*watchAggregator)(nil)
+	_ drand.FilteredWatcher  = (*watchAggregator)(nil)
+	_ drand.Stopper          = (*watchAggregator)(nil)
+	_ drand.Scheduler        = (*watchAggregator)(nil)
+	_ drand.UpstreamReporter = (*watchAggregator)(nil)
+	_ drand.SourceManager    = (*watchAggregator)(nil)
+	_ drand.ForkChecker      = (*watchAggregator)(nil)
+)
+
+// Start initiates auto watching and chain info refreshes if configured to do so.
+// SetLog and SetClock should not be called after Start.
 func (c *watchAggregator) Start() {
 	if c.autoWatch {
 		c.startAutoWatch(true)
 	} else if c.passiveClient != nil {
 		c.startAutoWatch(false)
 	}
+	if c.infoRefresh > 0 {
+		c.startInfoRefresh(c.infoRefresh, c.onChainChange)
+	}
+	if c.forkCheck > 0 {
+		c.startForkCheck(c.forkCheck, c.onFork)
+	}
+	if c.relayList != nil && c.relayList.interval > 0 {
+		c.startRelayListRefresh()
+	}
 }
 
 // SetLog configures the client log output
@@ -72,6 +199,107 @@ func (c *watchAggregator) SetLog(l log.Logger) {
 	c.log = l
 }
 
+// SetClock configures the clock used to schedule auto watch retries.
+func (c *watchAggregator) SetClock(clk clock.Clock) {
+	c.clock = clk
+}
+
+// Get returns the randomness at `round` or an error, keeping track of the latest round fetched.
+func (c *watchAggregator) Get(ctx context.Context, round uint64) (drand.Result, error) {
+	r, err := c.Client.Get(ctx, round)
+	if err != nil {
+		return nil, err
+	}
+	c.observe(r)
+	if err := callOptions(ctx).checkFreshness(ctx, c.Client, c.clock.Now(), r); err != nil {
+		return nil, err
+	}
+	return r, nil
+}
+
+// Status returns the latest round known to the client, when it was last
+// fetched and the verification checkpoint.
+func (c *watchAggregator) Status() drand.Status {
+	var checkpoint uint64
+	for _, v := range c.currentVerifiers() {
+		checkpoint = max(checkpoint, v.checkpoint())
+	}
+	c.statusLk.Lock()
+	defer c.statusLk.Unlock()
+	return drand.Status{
+		LatestRound: c.latest,
+		LastFetch:   c.lastFetch,
+		Checkpoint:  checkpoint,
+	}
+}
+
+// currentVerifiers returns the verifiers of the sources of the client.
+func (c *watchAggregator) currentVerifiers() []*verifyingClient {
+	c.verifiersLk.RLock()
+	defer c.verifiersLk.RUnlock()
+	return slices.Clone(c.verifiers)
+}
+
+// UpstreamStats returns the statistics of the requests made to each upstream
+// of the client, in the order they were given.
+func (c *watchAggregator) UpstreamStats() []drand.UpstreamStats {
+	if c.optimizer == nil {
+		return nil
+	}
+	return c.optimizer.UpstreamStats()
+}
+
+// AddSource adds source to the sources of the client, verified and
+// configured as the ones given with From, and speed tests it right away
+// unless speed tests are disabled.
+func (c *watchAggregator) AddSource(source drand.Client) error {
+	if c.optimizer == nil || c.newVerifier == nil {
+		return errors.New("adding sources is not supported")
+	}
+	nv := c.newVerifier(source)
+	if err := c.optimizer.AddSource(nv); err != nil {
+		return err
+	}
+	c.verifiersLk.Lock()
+	c.verifiers = append(c.verifiers, nv)
+	c.verifiersLk.Unlock()
+	c.log.Infow("", "watch_aggregator", "source added", "source", upstreamName(source))
+	return nil
+}
+
+// RemoveSource removes the sources named name, as reported by UpstreamStats,
+// and closes them. The watcher given with WithWatcher cannot be removed, and
+// the relays of WithRelayList still listed are added back at the next refresh.
+func (c *watchAggregator) RemoveSource(name string) error {
+	if c.optimizer == nil {
+		return fmt.Errorf("%w: %s", drand.ErrUnknownSource, name)
+	}
+	removed, err := c.optimizer.removeSource(name)
+	if err != nil {
+		return err
+	}
+	isRemoved := func(v *verifyingClient) bool { return slices.Contains(removed, drand.Client(v)) }
+	c.verifiersLk.Lock()
+	c.verifiers = slices.DeleteFunc(c.verifiers, isRemoved)
+	maps.DeleteFunc(c.relays, func(_ string, v *verifyingClient) bool { return isRemoved(v) })
+	c.verifiersLk.Unlock()
+
+	c.log.Infow("", "watch_aggregator", "source removed", "source", name)
+	var errs error
+	for _, r := range removed {
+		errs = errors.Join(errs, r.Close())
+	}
+	return errs
+}
+
+// observe records a result fetched or received by the client.
+func (c *watchAggregator) observe(r drand.Result) {
+	c.statusLk.Lock()
+	defer c.statusLk.Unlock()
+	c.latest = max(c.latest, r.GetRound())
+	c.lastFetch = c.clock.Now()
+}
+
 // String returns the name of this client.
 func (c *watchAggregator) String() string {
 	return fmt.Sprintf("%s.(+aggregator)", c.Client)
@@ -80,11 +308,15 @@ func (c *watchAggregator) String() string {
 func (c *watchAggregator) startAutoWatch(full bool) {
 	ctx, cancel := context.WithCancel(context.Background())
 	c.cancelAutoWatch = cancel
+	c.wg.Add(1)
 	go func() {
+		defer c.wg.Done()
 		for {
 			var results <-chan drand.Result
 			if full {
-				results = c.Watch(ctx)
+				c.subscriberLock.Lock()
+				results = c.subscribe(ctx).c
+				c.subscriberLock.Unlock()
 			} else if c.passiveClient != nil {
 				results = c.passiveWatch(ctx)
 			}
@@ -103,13 +335,11 @@ func (c *watchAggregator) startAutoWatch(full bool) {
 			if c.autoWatchRetry < 0 {
 				return
 			}
-			t := time.NewTimer(c.autoWatchRetry)
+			t := c.clock.NewTimer(c.autoWatchRetry)
 			select {
-			case <-t.C:
+			case <-t.Chan():
 			case <-ctx.Done():
-				if !t.Stop() {
-					<-t.C
-				}
+				t.Stop()
 			}
 			c.log.Infow("", "watch_aggregator", "retrying auto watch")
 		}
@@ -128,10 +358,14 @@ func (c *watchAggregator) passiveWatch(ctx context.Context) <-chan drand.Result
 	}
 
 	wc := make(chan drand.Result)
-	if len(c.subscribers) == 0 {
+	if len(c.subscribers) == 0 && !c.closed {
 		ctx, cancel := context.WithCancel(ctx)
 		c.cancelPassive = cancel
-		go c.sink(c.passiveClient.Watch(ctx), wc)
+		c.wg.Add(1)
+		go func() {
+			defer c.wg.Done()
+			c.sink(c.passiveClient.Watch(ctx), wc)
+		}()
 	} else {
 		// trigger the startAutowatch to retry on backoff
 		close(wc)
@@ -139,11 +373,63 @@ func (c *watchAggregator) passiveWatch(ctx context.Context) <-chan drand.Result
 	return wc
 }
 
+// Watch returns new randomness as it becomes available, preceded by the latest
+// round when the client is configured with WithImmediateFirstResult. Rounds
+// are delivered in increasing order, the rounds missed by the underlying
+// watch being fetched, and the rounds dropped because the channel is full,
+// or which could not be fetched, being reported to the handler of OnLag.
 func (c *watchAggregator) Watch(ctx context.Context) <-chan drand.Result {
 	c.subscriberLock.Lock()
 	defer c.subscriberLock.Unlock()
 
-	sub := subscriber{ctx, make(chan drand.Result, aggregatorWatchBuffer)}
+	sub := c.subscribe(ctx)
+	if !c.immediateFirst || c.closed {
+		return sub.c
+	}
+	sub.pending = true
+	c.wg.Add(1)
+	go func() {
+		defer c.wg.Done()
+		c.sendLatestFirst(ctx, sub)
+	}()
+	return sub.c
+}
+
+// sendLatestFirst sends the latest round to a subscriber, followed by the
+// newer rounds distributed to it in the meantime.
+func (c *watchAggregator) sendLatestFirst(ctx context.Context, sub *subscriber) {
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+	defer context.AfterFunc(c.stopCtx, cancel)()
+
+	r, err := c.Get(ctx, 0)
+
+	defer c.reportLags()
+	c.subscriberLock.Lock()
+	defer c.subscriberLock.Unlock()
+	sub.pending = false
+	if sub.closed {
+		return
+	}
+	if err != nil {
+		c.log.Warnw("", "watch_aggregator", "failed to get the latest round to watch from", "err", err)
+	} else {
+		c.send(sub, r)
+	}
+	for _, r := range sub.held {
+		c.send(sub, r)
+	}
+	sub.held = nil
+}
+
+// subscribe adds a subscriber to the results of the watch of the underlying
+// client, starting the watch if needed. It must be called with subscriberLock held.
+func (c *watchAggregator) subscribe(ctx context.Context) *subscriber {
+	sub := &subscriber{ctx: ctx, c: make(chan drand.Result, aggregatorWatchBuffer), onLag: callOptions(ctx).onLag}
+	if c.closed {
+		sub.close()
+		return sub
+	}
 	c.subscribers = append(c.subscribers, sub)
 
 	if len(c.subscribers) == 1 {
@@ -152,57 +438,131 @@ func (c *watchAggregator) Watch(ctx context.Context) <-chan drand.Result {
 			c.cancelPassive = nil
 		}
 		ctx, cancel := context.WithCancel(ctx)
-		go c.distribute(c.Client.Watch(ctx), cancel)
+		c.wg.Add(1)
+		go func() {
+			defer c.wg.Done()
+			c.distribute(c.Client.Watch(ctx), cancel)
+		}()
 	}
-	return sub.c
+	return sub
+}
+
+func (s *subscriber) close() {
+	close(s.c)
+	s.closed = true
+}
+
+// WatchFiltered returns new randomness as it becomes available, for the rounds
+// selected by filter. Unlike Watch, it runs its own watch on the underlying
+// client, whose verifying clients skip the rejected rounds before verifying
+// them, unless full chain verification is enabled, since it requires every
+// round to be verified.
+func (c *watchAggregator) WatchFiltered(ctx context.Context, filter drand.RoundFilter) <-chan drand.Result {
+	in := c.Client.Watch(context.WithValue(ctx, roundFilterKey{}, filter))
+	return filterResults(ctx, in, filter, c.observe)
 }
 
 func (c *watchAggregator) sink(in <-chan drand.Result, out chan drand.Result) {
 	defer close(out)
-	for range in {
-		continue
+	for {
+		select {
+		case _, ok := <-in:
+			if !ok {
+				return
+			}
+		case <-c.stopping:
+			return
+		}
 	}
 }
 
 func (c *watchAggregator) distribute(in <-chan drand.Result, cancel context.CancelFunc) {
 	defer cancel()
+	var last uint64
+	seen := newRoundWindow(c.dedupWindow)
+	// batch is reused from one result to the next, so that distributing a
+	// result does not allocate whatever the number of subscribers
+	batch := make([]drand.Result, 0, 1)
+	// while missed rounds are backfilled in the background, the new rounds
+	// are held, to be delivered after them
+	var fill *gapFill
+	var held []drand.Result
 	for {
 		c.subscriberLock.Lock()
 		if len(c.subscribers) == 0 {
 			c.subscriberLock.Unlock()
 			c.log.Warnw("", "watch_aggregator", "no subscribers to distribute results to")
+			if fill != nil {
+				fill.cancel()
+			}
 			return
 		}
 		aCtx := c.subscribers[0].ctx
 		c.subscriberLock.Unlock()
 
 		var m drand.Result
-		var ok bool
+		var filled []drand.Result
+		var fillDone <-chan []drand.Result
+		if fill != nil {
+			fillDone = fill.results
+		}
+		ok, backfilled, canceled := true, false, false
 
 		select {
 		case m, ok = <-in:
+		case filled = <-fillDone:
+			backfilled = true
 		case <-aCtx.Done():
+			ok, canceled = false, true
+		case <-c.stopping:
+			ok, canceled = false, true
 		}
 
-		c.subscriberLock.Lock()
-		curr := c.subscribers
-		c.subscribers = c.subscribers[:0]
-
-		for _, s := range curr {
-			if ok && s.ctx.Err() == nil {
-				c.subscribers = append(c.subscribers, s)
-				if m != nil {
-					select {
-					case s.c <- m:
-					default:
-						c.log.Warnw("", "watch_aggregator", "dropped watch message to subscriber. full channel")
-					}
-				}
-			} else {
-				close(s.c)
+		clear(batch[:cap(batch)])
+		batch = batch[:0]
+		switch {
+		case backfilled:
+			fill.cancel()
+			batch = append(append(batch, filled...), held...)
+			fill, held = nil, nil
+		case !ok && fill != nil:
+			// the backfill completes before the end of the watch is
+			// delivered, unless the watch is canceled
+			if canceled {
+				fill.cancel()
 			}
+			batch = append(append(batch, <-fill.results...), held...)
+			fill.cancel()
+			fill, held = nil, nil
+		case ok && m != nil && fill != nil:
+			held = append(held, m)
+			last = max(last, m.GetRound())
+			continue
+		case ok && m != nil && c.gapMissed(last, m.GetRound()):
+			fill = c.startFillGap(last, m.GetRound())
+			held = append(held, m)
+			last = m.GetRound()
+			continue
+		case ok && m != nil:
+			batch = append(batch, m)
+			last = max(last, m.GetRound())
+		}
+
+		batch = c.dedup(seen, batch)
+		for _, r := range batch {
+			c.observe(r)
+			c.notifyWebhooks(r)
+		}
+
+		c.subscriberLock.Lock()
+		if !ok && len(batch) > 0 {
+			// the rounds backfilled are delivered before the end of the watch
+			c.deliver(batch, false)
+			batch = batch[:0]
 		}
+		c.deliver(batch, !ok)
 		c.subscriberLock.Unlock()
+		c.reportLags()
 
 		if !ok {
 			return
@@ -210,10 +570,305 @@ func (c *watchAggregator) distribute(in <-chan drand.Result, cancel context.Canc
 	}
 }
 
+// deliver sends a batch of results to the subscribers, and closes the
+// subscriptions whose context is done, or all of them once the watch ended.
+// It must be called with subscriberLock held.
+func (c *watchAggregator) deliver(batch []drand.Result, ended bool) {
+	curr := c.subscribers
+	c.subscribers = c.subscribers[:0]
+	for _, s := range curr {
+		if ended || s.ctx.Err() != nil {
+			if s.missed != 0 {
+				c.lagged(s, s.dropped)
+			}
+			s.close()
+			continue
+		}
+		c.subscribers = append(c.subscribers, s)
+		if s.pending {
+			s.held = append(s.held, batch...)
+			continue
+		}
+		for _, r := range batch {
+			c.send(s, r)
+		}
+	}
+	// the subscribers removed are not to be retained by the backing array
+	clear(curr[len(c.subscribers):])
+}
+
+// send sends a result to a subscriber, unless a later round was already sent,
+// dropping it if the subscriber is not keeping up. It must be called with
+// subscriberLock held.
+func (c *watchAggregator) send(s *subscriber, r drand.Result) {
+	round := r.GetRound()
+	if round <= s.last {
+		return
+	}
+	select {
+	case s.c <- r:
+		if s.missed != 0 || (s.last != 0 && round > s.last+1) {
+			c.lagged(s, round-1)
+		}
+		s.last = round
+	default:
+		metrics.ClientWatchDropped.WithLabelValues("aggregator").Inc()
+		c.log.Warnw("", "watch_aggregator", "dropped watch message to subscriber. full channel", "round", round)
+		if s.missed == 0 {
+			s.missed = round
+		}
+		s.dropped = round
+	}
+}
+
+// lagged records that the rounds after the last one sent to a subscriber, up
+// to round, were not sent, to report them with reportLags. It must be called
+// with subscriberLock held.
+func (c *watchAggregator) lagged(s *subscriber, round uint64) {
+	from := s.last + 1
+	if s.last == 0 {
+		from = s.missed
+	}
+	s.missed, s.dropped = 0, 0
+	if s.onLag != nil {
+		c.lags = append(c.lags, lagReport{onLag: s.onLag, lag: Lag{From: from, To: round}})
+	}
+}
+
+// reportLags calls the lag handlers of the subscribers with the lags recorded
+// since the last call, in order.
+func (c *watchAggregator) reportLags() {
+	c.lagLk.Lock()
+	defer c.lagLk.Unlock()
+	c.subscriberLock.Lock()
+	lags := c.lags
+	c.lags = nil
+	c.subscriberLock.Unlock()
+	for _, l := range lags {
+		l.onLag(l.lag)
+	}
+}
+
+// gapMissed reports whether rounds were missed by the watch between the last
+// round it delivered and round, and are to be backfilled.
+func (c *watchAggregator) gapMissed(last, round uint64) bool {
+	return !c.noGapFilling && last != 0 && round > last+1
+}
+
+// gapFill is the backfill of missed rounds running in the background.
+type gapFill struct {
+	// results receives the rounds backfilled, once fetched or canceled.
+	results <-chan []drand.Result
+	cancel  context.CancelFunc
+}
+
+// startFillGap backfills in the background the rounds missed by the watch
+// between last and round, see fillGap, within gapFillTimeout, or until the
+// client is stopped or the backfill canceled.
+func (c *watchAggregator) startFillGap(last, round uint64) *gapFill {
+	ctx, cancel := context.WithTimeout(c.stopCtx, c.gapFillTimeout)
+	out := make(chan []drand.Result, 1)
+	c.wg.Add(1)
+	go func() {
+		defer c.wg.Done()
+		out <- c.fillGap(ctx, last, round)
+	}()
+	return &gapFill{results: out, cancel: cancel}
+}
+
+// fillGap returns the rounds missed by the watch after last and before round,
+// in order, fetched with Get, unless resumeGap streams them. Rounds that
+// cannot be fetched before ctx is done are skipped, and reported as lags to
+// the subscribers.
+func (c *watchAggregator) fillGap(ctx context.Context, last, round uint64) []drand.Result {
+	from := last + 1
+	if round-from > maxGapFill {
+		c.log.Warnw("", "watch_aggregator", "too many missed rounds, only backfilling the latest ones",
+			"from", from, "to", round-1, "max", maxGapFill)
+		from = round - maxGapFill
+	}
+	c.log.Infow("", "watch_aggregator", "backfilling missed rounds", "from", from, "to", round-1)
+
+	var batch []drand.Result
+	resumed := c.resumeGap(ctx, from, round-1)
+	for r := from; r < round; r++ {
+		if res, ok := resumed[r]; ok {
+			batch = append(batch, res)
+			continue
+		}
+		if ctx.Err() != nil {
+			c.log.Warnw("", "watch_aggregator", "gave up backfilling missed rounds", "from", r, "to", round-1)
+			break
+		}
+		res, err := c.Client.Get(ctx, r)
+		if err != nil {
+			c.log.Warnw("", "watch_aggregator", "failed to backfill missed round", "round", r, "err", err)
+			continue
+		}
+		batch = append(batch, res)
+	}
+	return batch
+}
+
+// resumeGap streams the missed rounds from from to to, verified, from the
+// fastest source able to resume watches, such as a gRPC client, rather than
+// getting them one by one. It returns the rounds received, keyed by round,
+// which may not all be, or none if no source can resume watches.
+func (c *watchAggregator) resumeGap(ctx context.Context, from, to uint64) map[uint64]drand.Result {
+	var sources []drand.Client
+	if c.optimizer != nil {
+		sources = c.optimizer.availableClients(c.optimizer.fastestClients())
+	}
+	for _, s := range sources {
+		v, ok := s.(*verifyingClient)
+		if !ok {
+			continue
+		}
+		sctx, cancel := context.WithCancel(ctx)
+		ch, ok := v.watchFrom(sctx, from)
+		if !ok {
+			cancel()
+			continue
+		}
+		resumed := make(map[uint64]drand.Result, to-from+1)
+		for r := range ch {
+			if r.GetRound() >= from && r.GetRound() <= to {
+				resumed[r.GetRound()] = r
+			}
+			if r.GetRound() >= to {
+				break
+			}
+		}
+		cancel()
+		for range ch {
+			// drain the stream, which ends once canceled
+		}
+		c.log.Debugw("", "watch_aggregator", "resumed missed rounds", "source", v, "from", from, "to", to,
+			"received", len(resumed))
+		return resumed
+	}
+	return nil
+}
+
+// dedup filters out the results of the rounds already delivered.
+func (c *watchAggregator) dedup(seen *roundWindow, batch []drand.Result) []drand.Result {
+	out := batch[:0]
+	for _, r := range batch {
+		if !seen.add(r.GetRound()) {
+			metrics.ClientWatchDuplicates.Inc()
+			c.log.Debugw("", "watch_aggregator", "suppressed duplicate round", "round", r.GetRound())
+			continue
+		}
+		out = append(out, r)
+	}
+	return out
+}
+
+// notifyWebhooks queues a result for delivery to the webhooks in the
+// background, dropping it for the webhooks whose queue is full.
+func (c *watchAggregator) notifyWebhooks(m drand.Result) {
+	c.startWebhooks.Do(c.startWebhookWorkers)
+	for i, q := range c.webhookQueues {
+		select {
+		case q <- m:
+		default:
+			c.log.Warnw("", "watch_aggregator", "webhook queue full, dropping round", "webhook", i, "round", m.GetRound())
+		}
+	}
+}
+
+// startWebhookWorkers starts a worker per webhook, delivering the results of
+// its queue in order until the client is stopped.
+func (c *watchAggregator) startWebhookWorkers() {
+	for _, n := range c.webhooks {
+		q := make(chan drand.Result, webhookQueueSize)
+		c.webhookQueues = append(c.webhookQueues, q)
+		c.wg.Add(1)
+		go func() {
+			defer c.wg.Done()
+			for {
+				select {
+				case m := <-q:
+					if c.stopCtx.Err() != nil {
+						return
+					}
+					ctx, cancel := context.WithTimeout(c.stopCtx, webhookDeliveryTimeout)
+					_ = n.Notify(ctx, m)
+					cancel()
+				case <-c.stopping:
+					return
+				}
+			}
+		}()
+	}
+}
+
+// Stop stops the background watches, closes the underlying client and waits
+// for the background goroutines to exit, or for ctx to be done. It can be
+// called several times, e.g. to wait again after ctx expired.
+func (c *watchAggregator) Stop(ctx context.Context) error {
+	c.stopOnce.Do(func() {
+		c.subscriberLock.Lock()
+		c.closed = true
+		if c.cancelPassive != nil {
+			c.cancelPassive()
+			c.cancelPassive = nil
+		}
+		c.subscriberLock.Unlock()
+		c.cancelStop()
+		if c.cancelAutoWatch != nil {
+			c.cancelAutoWatch()
+		}
+		go func() {
+			c.stopErr = c.Client.Close()
+			c.wg.Wait()
+			close(c.stopped)
+		}()
+	})
+	select {
+	case <-c.stopped:
+		return c.stopErr
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// Close stops the client and waits for its background goroutines to exit, see Stop.
 func (c *watchAggregator) Close() error {
-	err := c.Client.Close()
-	if c.cancelAutoWatch != nil {
-		c.cancelAutoWatch()
+	return c.Stop(context.Background())
+}
+
+// roundWindow remembers the last rounds added to it, up to its size.
+type roundWindow struct {
+	rounds []uint64
+	next   int
+	set    map[uint64]struct{}
+}
+
+func newRoundWindow(size int) *roundWindow {
+	return &roundWindow{
+		rounds: make([]uint64, 0, size),
+		set:    make(map[uint64]struct{}, size),
+	}
+}
+
+// add records a round, evicting the oldest one if the window is full, and
+// reports whether it was not already in the window. It always returns true
+// for a window of size 0.
+func (w *roundWindow) add(round uint64) bool {
+	if cap(w.rounds) == 0 {
+		return true
+	}
+	if _, ok := w.set[round]; ok {
+		return false
+	}
+	if len(w.rounds) < cap(w.rounds) {
+		w.rounds = append(w.rounds, round)
+	} else {
+		delete(w.set, w.rounds[w.next])
+		w.rounds[w.next] = round
+		w.next = (w.next + 1) % len(w.rounds)
 	}
-	return err
+	w.set[round] = struct{}{}
+	return true
 }
@@ -1,6 +1,8 @@
 package client
 
 import (
+	"context"
+	"slices"
 	"sync"
 	"testing"
 	"time"
@@ -82,3 +84,195 @@ func TestAggregatorPassive(t *testing.T) {
 
 	wg.Wait()
 }
+
+func TestAggregatorGapFilling(t *testing.T) {
+	for _, tc := range []struct {
+		name   string
+		noFill bool
+		want   []uint64
+	}{
+		{"fill", false, []uint64{1, 2, 3, 4, 5}},
+		{"no fill", true, []uint64{1, 2, 5}},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			c := &clientMock.Client{
+				WatchCh:      make(chan drand.Result, 3),
+				Results:      []mock.Result{{Rnd: 3}, {Rnd: 4}},
+				StrictRounds: true,
+			}
+			for _, r := range []uint64{1, 2, 5} {
+				c.WatchCh <- &mock.Result{Rnd: r}
+			}
+			close(c.WatchCh)
+
+			ac := newWatchAggregator(log.New(nil, log.DebugLevel, true), c, nil, false, 0)
+			ac.noGapFilling = tc.noFill
+
+			var got []uint64
+			for r := range ac.Watch(context.Background()) {
+				got = append(got, r.GetRound())
+			}
+			if len(got) != len(tc.want) {
+				t.Fatalf("expected rounds %v, got %v", tc.want, got)
+			}
+			for i := range got {
+				if got[i] != tc.want[i] {
+					t.Fatalf("expected rounds %v, got %v", tc.want, got)
+				}
+			}
+		})
+	}
+}
+
+func TestAggregatorGapFillTimeout(t *testing.T) {
+	// the missed rounds take longer to get than the backfill may last
+	c := &clientMock.Client{
+		WatchCh:      make(chan drand.Result, 3),
+		Results:      []mock.Result{{Rnd: 3}, {Rnd: 4}},
+		StrictRounds: true,
+		Delay:        time.Minute,
+	}
+	for _, r := range []uint64{1, 2, 5} {
+		c.WatchCh <- &mock.Result{Rnd: r}
+	}
+
+	ac := newWatchAggregator(log.New(nil, log.DebugLevel, true), c, nil, false, 0)
+	ac.gapFillTimeout = 10 * time.Millisecond
+	defer ac.Close()
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	lags := make(chan Lag, 1)
+	results := Watch(ctx, ac, OnLag(func(l Lag) { lags <- l }))
+
+	var got []uint64
+	for range 3 {
+		select {
+		case r := <-results:
+			got = append(got, r.GetRound())
+		case <-time.After(5 * time.Second):
+			t.Fatalf("the backfill stalled the watch, got rounds %v", got)
+		}
+	}
+	if !slices.Equal(got, []uint64{1, 2, 5}) {
+		t.Fatalf("expected rounds [1 2 5], got %v", got)
+	}
+	if l := <-lags; l != (Lag{From: 3, To: 4}) {
+		t.Fatalf("expected the missed rounds to be reported as a lag, got %v", l)
+	}
+}
+
+func TestAggregatorDedup(t *testing.T) {
+	for _, tc := range []struct {
+		name   string
+		window int
+		want   []uint64
+	}{
+		{"dedup", defaultDedupWindow, []uint64{1, 2, 3}},
+		// watches deliver rounds in increasing order anyway
+		{"disabled", 0, []uint64{1, 2, 3}},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			c := &clientMock.Client{WatchCh: make(chan drand.Result, 6)}
+			for _, r := range []uint64{1, 2, 2, 1, 3, 3} {
+				c.WatchCh <- &mock.Result{Rnd: r}
+			}
+			close(c.WatchCh)
+
+			ac := newWatchAggregator(log.New(nil, log.DebugLevel, true), c, nil, false, 0)
+			ac.dedupWindow = tc.window
+
+			var got []uint64
+			for r := range ac.Watch(context.Background()) {
+				got = append(got, r.GetRound())
+			}
+			if len(got) != len(tc.want) {
+				t.Fatalf("expected rounds %v, got %v", tc.want, got)
+			}
+			for i := range got {
+				if got[i] != tc.want[i] {
+					t.Fatalf("expected rounds %v, got %v", tc.want, got)
+				}
+			}
+		})
+	}
+}
+
+func TestAggregatorLag(t *testing.T) {
+	for _, tc := range []struct {
+		name   string
+		rounds []uint64
+		want   []uint64
+		lags   []Lag
+	}{
+		{"keeping up", []uint64{1, 2, 3}, []uint64{1, 2, 3}, nil},
+		{"full channel", []uint64{1, 2, 3, 4, 5, 6, 7, 8}, []uint64{1, 2, 3, 4, 5}, []Lag{{From: 6, To: 8}}},
+		{"missed rounds", []uint64{1, 2, 5, 6}, []uint64{1, 2, 5, 6}, []Lag{{From: 3, To: 4}}},
+		{"out of order", []uint64{1, 3, 2, 4}, []uint64{1, 3, 4}, []Lag{{From: 2, To: 2}}},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			c := &clientMock.Client{WatchCh: make(chan drand.Result, len(tc.rounds))}
+			for _, r := range tc.rounds {
+				c.WatchCh <- &mock.Result{Rnd: r}
+			}
+			close(c.WatchCh)
+
+			ac := newWatchAggregator(log.New(nil, log.DebugLevel, true), c, nil, false, 0)
+			ac.noGapFilling = true
+			var lags []Lag
+			ended := make(chan struct{})
+			results := Watch(context.Background(), ac, OnLag(func(l Lag) { lags = append(lags, l) }))
+			go func() {
+				// the consumer only reads the channel once the watch ended
+				ac.wg.Wait()
+				close(ended)
+			}()
+			<-ended
+
+			var got []uint64
+			for r := range results {
+				got = append(got, r.GetRound())
+			}
+			if !slices.Equal(got, tc.want) {
+				t.Fatalf("expected rounds %v, got %v", tc.want, got)
+			}
+			if !slices.Equal(lags, tc.lags) {
+				t.Fatalf("expected lags %v, got %v", tc.lags, lags)
+			}
+		})
+	}
+}
+
+func TestRoundWindow(t *testing.T) {
+	w := newRoundWindow(2)
+	for _, step := range []struct {
+		round uint64
+		added bool
+	}{
+		{1, true}, {1, false}, {2, true}, {3, true}, {2, false}, {1, true}, {3, false},
+	} {
+		if added := w.add(step.round); added != step.added {
+			t.Fatalf("adding round %d: expected %t, got %t", step.round, step.added, added)
+		}
+	}
+}
+
+func TestAggregatorDeliverAllocations(t *testing.T) {
+	ac := newWatchAggregator(log.New(nil, log.DebugLevel, true), &clientMock.Client{}, nil, false, 0)
+	for range 100 {
+		ac.subscribers = append(ac.subscribers, &subscriber{ctx: context.Background(), c: make(chan drand.Result, 1)})
+	}
+	r := mock.NewMockResult(1)
+	batch := []drand.Result{&r}
+
+	allocs := testing.AllocsPerRun(100, func() {
+		// a later round each time, since rounds are delivered in increasing order
+		r.Rnd++
+		ac.deliver(batch, false)
+		for _, s := range ac.subscribers {
+			<-s.c
+		}
+	})
+	if allocs != 0 {
+		t.Fatalf("expected no allocation to deliver a result to 100 subscribers, got %v", allocs)
+	}
+}
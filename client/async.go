@@ -0,0 +1,134 @@
+package client
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/drand/drand/v2/common/chain"
+	"github.com/drand/go-clients/drand"
+)
+
+// NewAsync returns a client made with New in the background, so that
+// applications can start while the chain info is fetched from the sources,
+// e.g. to serve their other features during an outage of the relays. The
+// calls made to the client wait for it to be set up, and fail with the error
+// of New if it fails. The returned channel receives that error, or nil once
+// the client is ready, and is then closed.
+//
+// The setup is bounded by ClientStartupTimeout, unless WithSetupCtx is given,
+// and closing the client cancels it. The client implements
+// drand.ReadyWaiter, but none of the other optional interfaces of the
+// clients made with New.
+func NewAsync(options ...Option) (drand.Client, <-chan error) {
+	ctx, cancel := context.WithTimeout(context.Background(), ClientStartupTimeout)
+	a := &asyncClient{
+		ready:  make(chan struct{}),
+		cancel: cancel,
+	}
+	errCh := make(chan error, 1)
+	go func() {
+		defer cancel()
+		a.c, a.err = New(append([]Option{WithSetupCtx(ctx)}, options...)...)
+		close(a.ready)
+		errCh <- a.err
+		close(errCh)
+	}()
+	return a, errCh
+}
+
+// asyncClient is a client made with New in the background.
+type asyncClient struct {
+	ready  chan struct{}
+	cancel context.CancelFunc
+	// c and err are the results of New, set once ready is closed.
+	c   drand.Client
+	err error
+}
+
+var _ drand.ReadyWaiter = (*asyncClient)(nil)
+
+// WaitReady waits for the client to be set up, and returns the error of New
+// if it failed, or ctx.Err() if ctx is done first.
+func (a *asyncClient) WaitReady(ctx context.Context) error {
+	select {
+	case <-a.ready:
+		if a.err != nil {
+			return fmt.Errorf("setting up client: %w", a.err)
+		}
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// Get returns the randomness at `round` once the client is set up.
+func (a *asyncClient) Get(ctx context.Context, round uint64) (drand.Result, error) {
+	if err := a.WaitReady(ctx); err != nil {
+		return nil, err
+	}
+	return a.c.Get(ctx, round)
+}
+
+// Watch returns new randomness as it becomes available, once the client is
+// set up. The channel is closed if the setup fails.
+func (a *asyncClient) Watch(ctx context.Context) <-chan drand.Result {
+	out := make(chan drand.Result)
+	go func() {
+		defer close(out)
+		if err := a.WaitReady(ctx); err != nil {
+			return
+		}
+		for r := range a.c.Watch(ctx) {
+			select {
+			case out <- r:
+			case <-ctx.Done():
+				return
+			}
+		}
+	}()
+	return out
+}
+
+// Info returns the chain info once the client is set up.
+func (a *asyncClient) Info(ctx context.Context) (*chain.Info, error) {
+	if err := a.WaitReady(ctx); err != nil {
+		return nil, err
+	}
+	return a.c.Info(ctx)
+}
+
+// RoundAt returns the round at time t, or 0 while the client is set up.
+func (a *asyncClient) RoundAt(t time.Time) uint64 {
+	select {
+	case <-a.ready:
+		if a.err != nil {
+			return 0
+		}
+		return a.c.RoundAt(t)
+	default:
+		return 0
+	}
+}
+
+// Close cancels the setup of the client, and closes it once set up.
+func (a *asyncClient) Close() error {
+	a.cancel()
+	<-a.ready
+	if a.err != nil {
+		return nil
+	}
+	return a.c.Close()
+}
+
+// String returns the name of this client.
+func (a *asyncClient) String() string {
+	select {
+	case <-a.ready:
+		if a.err == nil {
+			return fmt.Sprint(a.c)
+		}
+	default:
+	}
+	return "Async"
+}
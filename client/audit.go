@@ -0,0 +1,143 @@
+package client
+
+import (
+	"bufio"
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"sync"
+	"time"
+
+	clock "github.com/jonboulle/clockwork"
+
+	"github.com/drand/drand/v2/common"
+	"github.com/drand/drand/v2/common/chain"
+	"github.com/drand/drand/v2/common/log"
+	"github.com/drand/go-clients/drand"
+)
+
+// Outcomes of the verification of the results recorded in the audit log.
+const (
+	AuditVerified = "verified"
+	AuditFailed   = "failed"
+	AuditSkipped  = "skipped"
+)
+
+// ErrAuditLogTampered is returned by VerifyAuditLog when an entry does not
+// chain on the previous one.
+var ErrAuditLogTampered = errors.New("audit log was tampered with")
+
+// AuditEntry is a line of the audit log, see WithAuditLog.
+type AuditEntry struct {
+	// Round and Signature identify the result, the signature hex encoded.
+	Round     uint64 `json:"round"`
+	Signature string `json:"signature"`
+	// Outcome is the outcome of its verification: AuditVerified, AuditFailed
+	// or AuditSkipped, and Error the reason of a failure.
+	Outcome string `json:"outcome"`
+	Error   string `json:"error,omitempty"`
+	// Source is the name of the client which served the result.
+	Source string `json:"source"`
+	// RoundTime is when the round was due, and Time when it was verified.
+	RoundTime time.Time `json:"round_time"`
+	Time      time.Time `json:"time"`
+	// Prev is the hex encoded SHA-256 of the previous line of the log, or
+	// empty for the first entry of the log.
+	Prev string `json:"prev"`
+}
+
+// auditLog writes AuditEntry lines, each chaining on the previous one.
+type auditLog struct {
+	lk    sync.Mutex
+	w     io.Writer
+	prev  string
+	clock clock.Clock
+	log   log.Logger
+}
+
+func newAuditLog(w io.Writer, clk clock.Clock, l log.Logger) *auditLog {
+	return &auditLog{w: w, clock: clk, log: l}
+}
+
+// record appends the outcome of the verification of r, served by source, to
+// the log. Write errors are logged, and do not fail the verification.
+func (a *auditLog) record(info *chain.Info, source string, r drand.Result, outcome string, verr error) {
+	e := AuditEntry{
+		Round:     r.GetRound(),
+		Signature: hex.EncodeToString(r.GetSignature()),
+		Outcome:   outcome,
+		Source:    source,
+		RoundTime: time.Unix(common.TimeOfRound(info.Period, info.GenesisTime, r.GetRound()), 0).UTC(),
+		Time:      a.clock.Now().UTC(),
+	}
+	if verr != nil {
+		e.Error = verr.Error()
+	}
+
+	a.lk.Lock()
+	defer a.lk.Unlock()
+	e.Prev = a.prev
+	line, err := json.Marshal(e)
+	if err != nil {
+		a.log.Errorw("", "audit_log", "failed to encode entry", "round", e.Round, "err", err)
+		return
+	}
+	if _, err := a.w.Write(append(line, '\n')); err != nil {
+		a.log.Errorw("", "audit_log", "failed to write entry", "round", e.Round, "err", err)
+		return
+	}
+	sum := sha256.Sum256(line)
+	a.prev = hex.EncodeToString(sum[:])
+}
+
+// VerifyAuditLog checks that each entry of an audit log written with
+// WithAuditLog chains on the previous one, so that entries cannot have been
+// modified, inserted or removed, but at the end of the log. Only the first
+// entry starts the chain, with an empty Prev: clients appending to the log
+// after a restart chain on its last line, see WithAuditLogFrom. It returns the
+// number of entries read, or ErrAuditLogTampered at the first broken link.
+func VerifyAuditLog(r io.Reader) (int, error) {
+	n, _, err := verifyAuditLog(r)
+	return n, err
+}
+
+// AuditLogHead verifies the audit log read from r, see VerifyAuditLog, and
+// returns the hash of its last line, to append to it with WithAuditLogFrom, or
+// an empty string if it has no entry.
+func AuditLogHead(r io.Reader) (string, error) {
+	_, head, err := verifyAuditLog(r)
+	if err != nil {
+		return "", err
+	}
+	return head, nil
+}
+
+// verifyAuditLog verifies the audit log read from r, and returns its number of
+// entries and the hash of its last line.
+func verifyAuditLog(r io.Reader) (int, string, error) {
+	s := bufio.NewScanner(r)
+	s.Buffer(make([]byte, 0, 64*1024), 1024*1024)
+	n := 0
+	prev := ""
+	for s.Scan() {
+		line := bytes.TrimSpace(s.Bytes())
+		if len(line) == 0 {
+			continue
+		}
+		n++
+		var e AuditEntry
+		if err := json.Unmarshal(line, &e); err != nil {
+			return n, "", fmt.Errorf("decoding entry %d: %w", n, err)
+		}
+		if e.Prev != prev {
+			return n, "", fmt.Errorf("%w: entry %d, round %d, does not chain on the previous entry", ErrAuditLogTampered, n, e.Round)
+		}
+		sum := sha256.Sum256(line)
+		prev = hex.EncodeToString(sum[:])
+	}
+	return n, prev, s.Err()
+}
@@ -3,10 +3,14 @@ package client
 import (
 	"context"
 	"fmt"
+	"math/rand"
+	"time"
 
 	lru "github.com/hashicorp/golang-lru"
+	clock "github.com/jonboulle/clockwork"
 
 	"github.com/drand/go-clients/drand"
+	"github.com/drand/go-clients/internal/metrics"
 
 	"github.com/drand/drand/v2/common/log"
 )
@@ -19,6 +23,52 @@ type Cache interface {
 	Add(uint64, drand.Result)
 }
 
+// ChainCache is a Cache which can be shared by clients of different chains,
+// its results being keyed by chain hash as well as round. Clients made with
+// New only use the results of their own chain from a ChainCache given with
+// WithCache.
+type ChainCache interface {
+	Cache
+	// TryGetChain provides a round beacon of the given chain or nil if it is
+	// not cached.
+	TryGetChain(chainHash []byte, round uint64) drand.Result
+	// AddChain adds an item of the given chain to the cache
+	AddChain(chainHash []byte, round uint64, result drand.Result)
+}
+
+// ForChain returns the Cache of the results of a single chain in a ChainCache.
+func ForChain(c ChainCache, chainHash []byte) Cache {
+	return &chainView{c, chainHash, string(chainHash)}
+}
+
+// NewSharedCache creates an LRU cache of a given size, which clients of
+// different chains can share.
+func NewSharedCache(size int) (ChainCache, error) {
+	c, err := lru.NewARC(size)
+	if err != nil {
+		return nil, err
+	}
+	return &typedCache{c}, nil
+}
+
+// scopeCache restricts a ChainCache to the results of the given chain, other
+// caches being returned as is.
+func scopeCache(c Cache, chainHash []byte) Cache {
+	if cc, ok := c.(ChainCache); ok {
+		return ForChain(cc, chainHash)
+	}
+	return c
+}
+
+// Locker provides a lock shared by the replicas of a fleet using the same
+// Cache (e.g. backed by Redis), so that a single replica refreshes the latest
+// round from the relays each period.
+type Locker interface {
+	// TryLock attempts to acquire the lock named key for the duration ttl. It
+	// returns false without waiting if the lock is already held.
+	TryLock(ctx context.Context, key string, ttl time.Duration) (bool, error)
+}
+
 // makeCache creates a cache of a given size
 func makeCache(size int) (Cache, error) {
 	if size == 0 {
@@ -31,24 +81,71 @@ func makeCache(size int) (Cache, error) {
 	return &typedCache{c}, nil
 }
 
-// typedCache wraps an ARCCache containing beacon results.
+// typedCache wraps an ARCCache containing beacon results, keyed by cacheKey.
 type typedCache struct {
 	*lru.ARCCache
 }
 
+// cacheKey identifies a result in a typedCache. The chain is empty for the
+// results added through the Cache interface.
+type cacheKey struct {
+	chain string
+	round uint64
+}
+
 // Add a result to the cache
 func (t *typedCache) Add(round uint64, result drand.Result) {
-	t.ARCCache.Add(round, result)
+	t.ARCCache.Add(cacheKey{round: round}, result)
 }
 
 // TryGet attempts to get a result from the cache
 func (t *typedCache) TryGet(round uint64) drand.Result {
-	if val, ok := t.ARCCache.Get(round); ok {
+	return t.tryGet(cacheKey{round: round})
+}
+
+// AddChain adds a result of the given chain to the cache
+func (t *typedCache) AddChain(chainHash []byte, round uint64, result drand.Result) {
+	t.ARCCache.Add(cacheKey{string(chainHash), round}, result)
+}
+
+// TryGetChain attempts to get a result of the given chain from the cache
+func (t *typedCache) TryGetChain(chainHash []byte, round uint64) drand.Result {
+	return t.tryGet(cacheKey{string(chainHash), round})
+}
+
+func (t *typedCache) tryGet(key cacheKey) drand.Result {
+	if val, ok := t.ARCCache.Get(key); ok {
 		return val.(drand.Result)
 	}
 	return nil
 }
 
+// chainView is the Cache of the results of a single chain in a ChainCache.
+// The chain hash is kept as a string too, so that a typedCache is accessed
+// without converting it on each call.
+type chainView struct {
+	ChainCache
+	hash  []byte
+	chain string
+}
+
+// Add a result to the cache
+func (v *chainView) Add(round uint64, result drand.Result) {
+	if t, ok := v.ChainCache.(*typedCache); ok {
+		t.ARCCache.Add(cacheKey{v.chain, round}, result)
+		return
+	}
+	v.AddChain(v.hash, round, result)
+}
+
+// TryGet attempts to get a result from the cache
+func (v *chainView) TryGet(round uint64) drand.Result {
+	if t, ok := v.ChainCache.(*typedCache); ok {
+		return t.tryGet(cacheKey{v.chain, round})
+	}
+	return v.TryGetChain(v.hash, round)
+}
+
 // nilCache implements a cache with size 0
 type nilCache struct{}
 
@@ -67,6 +164,7 @@ func NewCachingClient(l log.Logger, c drand.Client, cache Cache) (drand.Client,
 	return &cachingClient{
 		Client: c,
 		cache:  cache,
+		clock:  clock.NewRealClock(),
 		log:    l,
 	}, nil
 }
@@ -75,7 +173,14 @@ type cachingClient struct {
 	drand.Client
 
 	cache Cache
+	clock clock.Clock
 	log   log.Logger
+	// locker, if set, coordinates refreshes of the latest round with other
+	// replicas sharing the cache.
+	locker Locker
+	// validate, if set, checks the results found in the cache, e.g. when it
+	// is shared with other clients. Invalid results are fetched again.
+	validate func(ctx context.Context, r drand.Result) error
 }
 
 // SetLog configures the client log output
@@ -83,9 +188,18 @@ func (c *cachingClient) SetLog(l log.Logger) {
 	c.log = l
 }
 
+// SetClock configures the clock used to determine the latest round.
+func (c *cachingClient) SetClock(clk clock.Clock) {
+	c.clock = clk
+}
+
 // String returns the name of this client.
 func (c *cachingClient) String() string {
-	if arc, ok := c.cache.(*typedCache); ok {
+	cache := c.cache
+	if v, ok := cache.(*chainView); ok {
+		cache = v.ChainCache
+	}
+	if arc, ok := cache.(*typedCache); ok {
 		return fmt.Sprintf("%s.(+%d el cache)", c.Client, arc.ARCCache.Len())
 	}
 	return fmt.Sprintf("%s.(+nil cache)", c.Client)
@@ -93,8 +207,17 @@ func (c *cachingClient) String() string {
 
 // Get returns the randomness at `round` or an error.
 func (c *cachingClient) Get(ctx context.Context, round uint64) (res drand.Result, err error) {
-	if val := c.cache.TryGet(round); val != nil {
-		return val, nil
+	opts := callOptions(ctx)
+	if opts.skipVerification {
+		return c.Client.Get(ctx, round)
+	}
+	if !opts.skipCache {
+		if round == 0 && c.locker != nil {
+			return c.getLatest(ctx)
+		}
+		if val := c.tryGet(ctx, round); val != nil {
+			return val, nil
+		}
 	}
 	val, err := c.Client.Get(ctx, round)
 	if err == nil && val != nil {
@@ -103,18 +226,85 @@ func (c *cachingClient) Get(ctx context.Context, round uint64) (res drand.Result
 	return val, err
 }
 
+// getLatest returns the latest round, letting a single replica sharing the
+// cache fetch it from upstream each period while the others wait for it to
+// show up in the cache.
+func (c *cachingClient) getLatest(ctx context.Context) (drand.Result, error) {
+	round := c.RoundAt(c.clock.Now())
+	if val := c.tryGet(ctx, round); val != nil {
+		return val, nil
+	}
+
+	info, err := c.Info(ctx)
+	if err != nil {
+		return nil, err
+	}
+	acquired, err := c.locker.TryLock(ctx, fmt.Sprintf("%x/latest/%d", info.Hash(), round), info.Period)
+	switch {
+	case err != nil:
+		c.log.Warnw("", "caching_client", "failed to acquire latest round lock", "round", round, "err", err)
+	case acquired:
+		metrics.ClientLatestLockAcquired.Inc()
+	default:
+		metrics.ClientLatestLockContended.Inc()
+		// wait a jittered fraction of the period for the lock holder to
+		// populate the shared cache, before falling back to fetching it ourselves.
+		//nolint:gosec // jitter does not need to be cryptographically secure
+		t := c.clock.NewTimer(time.Duration(rand.Int63n(int64(info.Period)/2 + 1)))
+		select {
+		case <-t.Chan():
+		case <-ctx.Done():
+			t.Stop()
+			return nil, ctx.Err()
+		}
+		if val := c.tryGet(ctx, round); val != nil {
+			return val, nil
+		}
+	}
+
+	val, err := c.Client.Get(ctx, 0)
+	if err == nil && val != nil {
+		c.cache.Add(val.GetRound(), val)
+	}
+	return val, err
+}
+
+// tryGet returns the given round from the cache, or nil if it is not cached
+// or the cached result does not pass validation.
+func (c *cachingClient) tryGet(ctx context.Context, round uint64) drand.Result {
+	val := c.cache.TryGet(round)
+	if val == nil {
+		return nil
+	}
+	if val.GetRound() != round {
+		c.log.Warnw("", "caching_client", "ignoring cached result of another round", "round", round, "cached", val.GetRound())
+		return nil
+	}
+	if c.validate != nil {
+		if err := c.validate(ctx, val); err != nil {
+			c.log.Warnw("", "caching_client", "ignoring invalid cached result", "round", round, "err", err)
+			return nil
+		}
+	}
+	return val
+}
+
 func (c *cachingClient) Watch(ctx context.Context) <-chan drand.Result {
 	in := c.Client.Watch(ctx)
 	out := make(chan drand.Result)
 	go func() {
+		defer close(out)
 		for result := range in {
 			if ctx.Err() != nil {
-				break
+				return
 			}
 			c.cache.Add(result.GetRound(), result)
-			out <- result
+			select {
+			case out <- result:
+			case <-ctx.Done():
+				return
+			}
 		}
-		close(out)
 	}()
 	return out
 }
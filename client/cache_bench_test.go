@@ -0,0 +1,50 @@
+package client
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/drand/drand/v2/common/log"
+	clientMock "github.com/drand/go-clients/client/mock"
+	"github.com/drand/go-clients/client/test/result/mock"
+)
+
+func BenchmarkCachingClient(b *testing.B) {
+	const rounds = 64
+	results := make([]mock.Result, rounds)
+	for i := range results {
+		results[i] = mock.NewMockResult(uint64(i + 1))
+	}
+	upstream := &clientMock.Client{Results: results, StrictRounds: true}
+	l := log.New(nil, log.ErrorLevel, false)
+	ctx := context.Background()
+
+	shared, err := NewSharedCache(rounds)
+	require.NoError(b, err)
+	for _, bc := range []struct {
+		name  string
+		cache func() Cache
+	}{
+		{"hit", func() Cache { c, _ := makeCache(rounds); return c }},
+		{"miss", func() Cache { c, _ := makeCache(0); return c }},
+		{"shared hit", func() Cache { return ForChain(shared, []byte("chain")) }},
+	} {
+		b.Run(bc.name, func(b *testing.B) {
+			c, err := NewCachingClient(l, upstream, bc.cache())
+			require.NoError(b, err)
+			b.ReportAllocs()
+			b.RunParallel(func(pb *testing.PB) {
+				i := 0
+				for pb.Next() {
+					if _, err := c.Get(ctx, uint64(i%rounds)+1); err != nil {
+						b.Error(err)
+						return
+					}
+					i++
+				}
+			})
+		})
+	}
+}
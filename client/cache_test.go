@@ -2,11 +2,16 @@ package client
 
 import (
 	"context"
+	"fmt"
 	"sync"
 	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
 
 	"github.com/drand/drand/v2/common/log"
 	clientMock "github.com/drand/go-clients/client/mock"
+	"github.com/drand/go-clients/client/test/cache"
 	"github.com/drand/go-clients/client/test/result/mock"
 	"github.com/drand/go-clients/drand"
 )
@@ -133,3 +138,65 @@ func TestCacheClose(t *testing.T) {
 
 	wg.Wait() // wait for underlying client to close
 }
+
+type testLocker struct {
+	sync.Mutex
+	held map[string]bool
+}
+
+func (l *testLocker) TryLock(_ context.Context, key string, _ time.Duration) (bool, error) {
+	l.Lock()
+	defer l.Unlock()
+	if l.held[key] {
+		return false, nil
+	}
+	l.held[key] = true
+	return true, nil
+}
+
+func TestCacheCoordinatedLatest(t *testing.T) {
+	lg := log.New(nil, log.DebugLevel, true)
+	info := fakeChainInfo(t)
+	shared := cache.NewMapCache()
+	locker := &testLocker{held: make(map[string]bool)}
+
+	m := clientMock.ClientWithResults(0, 3)
+	m.OptionalInfo = info
+	a, err := NewCachingClient(lg, m, shared)
+	require.NoError(t, err)
+	a.(*cachingClient).locker = locker
+	b, err := NewCachingClient(lg, m, shared)
+	require.NoError(t, err)
+	b.(*cachingClient).locker = locker
+
+	// the first replica takes the lock and fetches from upstream
+	res, err := a.Get(context.Background(), 0)
+	require.NoError(t, err)
+	require.Equal(t, uint64(0), res.GetRound())
+	require.Len(t, m.Results, 2)
+
+	// the second one is served from the shared cache
+	res, err = b.Get(context.Background(), 0)
+	require.NoError(t, err)
+	require.Equal(t, uint64(0), res.GetRound())
+	require.Len(t, m.Results, 2)
+}
+
+func TestCacheCoordinatedLatestFallback(t *testing.T) {
+	lg := log.New(nil, log.DebugLevel, true)
+	info := fakeChainInfo(t)
+	locker := &testLocker{held: make(map[string]bool)}
+	locker.held[fmt.Sprintf("%x/latest/%d", info.Hash(), 0)] = true
+
+	m := clientMock.ClientWithResults(0, 3)
+	m.OptionalInfo = info
+	c, err := NewCachingClient(lg, m, cache.NewMapCache())
+	require.NoError(t, err)
+	c.(*cachingClient).locker = locker
+
+	// the lock holder never populates the cache, so we fetch it ourselves
+	res, err := c.Get(context.Background(), 0)
+	require.NoError(t, err)
+	require.Equal(t, uint64(0), res.GetRound())
+	require.Len(t, m.Results, 2)
+}
@@ -0,0 +1,121 @@
+package client
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/drand/drand/v2/common"
+	"github.com/drand/go-clients/drand"
+)
+
+// CallOption configures a single call made with Get or Watch.
+type CallOption func(cfg *callConfig)
+
+type callConfig struct {
+	// freshWithin is the maximum age of the result, if positive.
+	freshWithin time.Duration
+	// skipCache bypasses the cache lookup.
+	skipCache bool
+	// skipVerification returns the result without verifying it.
+	skipVerification bool
+	// onLag is notified of the rounds a watch did not deliver.
+	onLag func(Lag)
+}
+
+// callConfigKey carries the call options of a call down to the layers of the client.
+type callConfigKey struct{}
+
+// RequireFreshWithin makes the call fail with drand.ErrStaleResult if the
+// returned round was produced more than d ago, e.g. when a lagging relay
+// answers a request for the latest round.
+func RequireFreshWithin(d time.Duration) CallOption {
+	return func(cfg *callConfig) {
+		cfg.freshWithin = d
+	}
+}
+
+// SkipCache fetches the round from the sources of the client even if it is
+// cached. The result is cached as usual.
+func SkipCache() CallOption {
+	return func(cfg *callConfig) {
+		cfg.skipCache = true
+	}
+}
+
+// SkipVerification returns the round as received from the sources of the
+// client, without verifying its signature. Unverified results are not cached.
+//
+// WARNING: the randomness of an unverified result cannot be trusted, this is
+// only meant for applications verifying results on their own, or displaying
+// them before they are verified.
+func SkipVerification() CallOption {
+	return func(cfg *callConfig) {
+		cfg.skipVerification = true
+	}
+}
+
+// Lag is a range of rounds a watch did not deliver, from From to To included,
+// see OnLag.
+type Lag struct {
+	From, To uint64
+}
+
+// OnLag makes a watch call f with the rounds it skips. Rounds are skipped when
+// the consumer does not keep up with the channel of the watch, whose full
+// buffer then drops them, and when the sources of the client miss rounds that
+// cannot be fetched, or are not fetched because of WithoutGapFilling.
+//
+// Lags are reported in order, once the watch delivers a later round or ends,
+// from the goroutine delivering the rounds: f must not block.
+func OnLag(f func(Lag)) CallOption {
+	return func(cfg *callConfig) {
+		cfg.onLag = f
+	}
+}
+
+// Get returns the randomness at round from c as c.Get does, configured by the
+// call options. The options are honored by the clients made with New, and
+// ignored by other clients.
+func Get(ctx context.Context, c drand.Client, round uint64, opts ...CallOption) (drand.Result, error) {
+	cfg := &callConfig{}
+	for _, opt := range opts {
+		opt(cfg)
+	}
+	return c.Get(context.WithValue(ctx, callConfigKey{}, cfg), round)
+}
+
+// Watch returns new randomness from c as c.Watch does, configured by the call
+// options. The options are honored by the clients made with New, and ignored
+// by other clients.
+func Watch(ctx context.Context, c drand.Client, opts ...CallOption) <-chan drand.Result {
+	cfg := &callConfig{}
+	for _, opt := range opts {
+		opt(cfg)
+	}
+	return c.Watch(context.WithValue(ctx, callConfigKey{}, cfg))
+}
+
+// callOptions returns the call options of a call, the defaults if none was set.
+func callOptions(ctx context.Context) *callConfig {
+	if cfg, ok := ctx.Value(callConfigKey{}).(*callConfig); ok {
+		return cfg
+	}
+	return &callConfig{}
+}
+
+// checkFreshness fails if the round r was produced more than the maximum age ago.
+func (cfg *callConfig) checkFreshness(ctx context.Context, c drand.Client, now time.Time, r drand.Result) error {
+	if cfg.freshWithin <= 0 {
+		return nil
+	}
+	info, err := c.Info(ctx)
+	if err != nil {
+		return err
+	}
+	produced := time.Unix(common.TimeOfRound(info.Period, info.GenesisTime, r.GetRound()), 0)
+	if age := now.Sub(produced); age > cfg.freshWithin {
+		return fmt.Errorf("%w: round %d was produced %s ago, more than %s", drand.ErrStaleResult, r.GetRound(), age, cfg.freshWithin)
+	}
+	return nil
+}
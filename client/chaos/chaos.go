@@ -0,0 +1,220 @@
+/*
+Package chaos wraps drand clients to inject faults, so that applications can be
+tested against degraded drand connectivity: latency, errors, duplicated rounds,
+out-of-order delivery and stale results.
+
+A chaos client can be used anywhere a client is, e.g. as a source of a client
+made with client.New, to check how the whole stack copes with a flaky relay:
+
+	flaky := chaos.New(http.ForURLs(ctx, nil, urls, chainHash)[0],
+		chaos.WithLatency(200*time.Millisecond, time.Second),
+		chaos.WithErrorRate(0.2),
+		chaos.WithDuplicateRate(0.1))
+	c, err := client.New(client.From(flaky), client.WithChainHash(chainHash))
+
+Faults are drawn at random, from a seed that can be fixed with WithSeed for
+reproducible tests.
+*/
+package chaos
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math/rand"
+	"sync"
+	"time"
+
+	"github.com/drand/go-clients/drand"
+)
+
+// ErrInjected is the error returned by the calls failed on purpose.
+var ErrInjected = errors.New("chaos: injected error")
+
+// Option configures the faults injected by a Client.
+type Option func(c *Client)
+
+// WithLatency delays every Get and every result of Watch by a random duration
+// between min and max.
+func WithLatency(minDelay, maxDelay time.Duration) Option {
+	return func(c *Client) {
+		c.minLatency, c.maxLatency = minDelay, max(minDelay, maxDelay)
+	}
+}
+
+// WithErrorRate fails the given fraction of Gets with ErrInjected, and drops
+// the same fraction of the results of Watch.
+func WithErrorRate(rate float64) Option {
+	return func(c *Client) {
+		c.errorRate = rate
+	}
+}
+
+// WithDuplicateRate delivers the given fraction of the results of Watch twice.
+func WithDuplicateRate(rate float64) Option {
+	return func(c *Client) {
+		c.duplicateRate = rate
+	}
+}
+
+// WithReorderRate holds back the given fraction of the results of Watch, to
+// deliver them after the next result.
+func WithReorderRate(rate float64) Option {
+	return func(c *Client) {
+		c.reorderRate = rate
+	}
+}
+
+// WithStaleRate makes the given fraction of the Gets of the latest round
+// return the round before it, and re-delivers the same fraction of the results
+// of Watch later, after the next result.
+func WithStaleRate(rate float64) Option {
+	return func(c *Client) {
+		c.staleRate = rate
+	}
+}
+
+// WithSeed sets the seed faults are drawn from.
+func WithSeed(seed int64) Option {
+	return func(c *Client) {
+		//nolint:gosec // faults do not need to be cryptographically random
+		c.rng = rand.New(rand.NewSource(seed))
+	}
+}
+
+// Client is a client injecting faults in the results of the client it wraps.
+type Client struct {
+	drand.Client
+
+	minLatency, maxLatency time.Duration
+	errorRate              float64
+	duplicateRate          float64
+	reorderRate            float64
+	staleRate              float64
+
+	rngLk sync.Mutex
+	rng   *rand.Rand
+}
+
+// New wraps c to inject the faults configured by opts. Without options, the
+// results of c are passed through unchanged.
+func New(c drand.Client, opts ...Option) *Client {
+	cc := &Client{
+		Client: c,
+		//nolint:gosec // faults do not need to be cryptographically random
+		rng: rand.New(rand.NewSource(time.Now().UnixNano())),
+	}
+	for _, opt := range opts {
+		opt(cc)
+	}
+	return cc
+}
+
+// String returns the name of the wrapped client.
+func (c *Client) String() string {
+	return fmt.Sprintf("Chaos(%v)", c.Client)
+}
+
+// chance returns true with probability rate.
+func (c *Client) chance(rate float64) bool {
+	if rate <= 0 {
+		return false
+	}
+	c.rngLk.Lock()
+	defer c.rngLk.Unlock()
+	return c.rng.Float64() < rate
+}
+
+// delay waits for the injected latency, or until ctx is done.
+func (c *Client) delay(ctx context.Context) error {
+	if c.maxLatency <= 0 {
+		return nil
+	}
+	d := c.minLatency
+	if c.maxLatency > c.minLatency {
+		c.rngLk.Lock()
+		d += time.Duration(c.rng.Int63n(int64(c.maxLatency - c.minLatency)))
+		c.rngLk.Unlock()
+	}
+	t := time.NewTimer(d)
+	defer t.Stop()
+	select {
+	case <-t.C:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// Get returns the result of the wrapped client, after the injected latency,
+// unless an error or a stale result is injected.
+func (c *Client) Get(ctx context.Context, round uint64) (drand.Result, error) {
+	if err := c.delay(ctx); err != nil {
+		return nil, err
+	}
+	if c.chance(c.errorRate) {
+		return nil, fmt.Errorf("getting round %d: %w", round, ErrInjected)
+	}
+	r, err := c.Client.Get(ctx, round)
+	if err != nil || round != 0 || r.GetRound() <= 1 || !c.chance(c.staleRate) {
+		return r, err
+	}
+	return c.Client.Get(ctx, r.GetRound()-1)
+}
+
+// Watch relays the results of the wrapped client, with the injected latency,
+// drops, duplicates, reorderings and stale results.
+func (c *Client) Watch(ctx context.Context) <-chan drand.Result {
+	in := c.Client.Watch(ctx)
+	out := make(chan drand.Result, 1)
+	go func() {
+		defer close(out)
+		send := func(r drand.Result) bool {
+			select {
+			case out <- r:
+				return true
+			case <-ctx.Done():
+				return false
+			}
+		}
+
+		var held, stale drand.Result
+		for r := range in {
+			if c.delay(ctx) != nil {
+				return
+			}
+			if c.chance(c.errorRate) {
+				continue
+			}
+			if held == nil && c.chance(c.reorderRate) {
+				held = r
+				continue
+			}
+
+			batch := []drand.Result{r}
+			if c.chance(c.duplicateRate) {
+				batch = append(batch, r)
+			}
+			if held != nil {
+				batch = append(batch, held)
+				held = nil
+			}
+			if stale != nil {
+				batch = append(batch, stale)
+				stale = nil
+			}
+			if c.chance(c.staleRate) {
+				stale = r
+			}
+			for _, b := range batch {
+				if !send(b) {
+					return
+				}
+			}
+		}
+		if held != nil {
+			send(held)
+		}
+	}()
+	return out
+}
@@ -0,0 +1,82 @@
+package chaos_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/drand/go-clients/client/chaos"
+	clientMock "github.com/drand/go-clients/client/mock"
+	"github.com/drand/go-clients/client/test/result/mock"
+	"github.com/drand/go-clients/drand"
+)
+
+func watchClient(rounds ...uint64) *clientMock.Client {
+	return &clientMock.Client{WatchF: func(context.Context) <-chan drand.Result {
+		ch := make(chan drand.Result, len(rounds))
+		for _, r := range rounds {
+			res := mock.NewMockResult(r)
+			ch <- &res
+		}
+		close(ch)
+		return ch
+	}}
+}
+
+func watchedRounds(t *testing.T, c drand.Client) []uint64 {
+	t.Helper()
+	var rounds []uint64
+	for r := range c.Watch(context.Background()) {
+		rounds = append(rounds, r.GetRound())
+	}
+	return rounds
+}
+
+func TestChaosPassThrough(t *testing.T) {
+	c := chaos.New(watchClient(1, 2, 3))
+	require.Equal(t, []uint64{1, 2, 3}, watchedRounds(t, c))
+}
+
+func TestChaosWatch(t *testing.T) {
+	require.Equal(t, []uint64{1, 1, 2, 2, 3, 3},
+		watchedRounds(t, chaos.New(watchClient(1, 2, 3), chaos.WithDuplicateRate(1))))
+	require.Equal(t, []uint64{2, 1, 4, 3},
+		watchedRounds(t, chaos.New(watchClient(1, 2, 3, 4), chaos.WithReorderRate(1))))
+	require.Equal(t, []uint64{1, 2, 1, 3, 2},
+		watchedRounds(t, chaos.New(watchClient(1, 2, 3), chaos.WithStaleRate(1))))
+	require.Empty(t, watchedRounds(t, chaos.New(watchClient(1, 2, 3), chaos.WithErrorRate(1))))
+
+	// some results are dropped, reproducibly for a given seed
+	dropped := watchedRounds(t, chaos.New(watchClient(1, 2, 3, 4, 5, 6, 7, 8), chaos.WithErrorRate(0.5), chaos.WithSeed(1)))
+	require.Less(t, len(dropped), 8)
+	require.Equal(t, dropped,
+		watchedRounds(t, chaos.New(watchClient(1, 2, 3, 4, 5, 6, 7, 8), chaos.WithErrorRate(0.5), chaos.WithSeed(1))))
+}
+
+func TestChaosGet(t *testing.T) {
+	ctx := context.Background()
+	src := clientMock.ClientWithResults(1, 6)
+	src.StrictRounds = true
+
+	_, err := chaos.New(src, chaos.WithErrorRate(1)).Get(ctx, 1)
+	require.ErrorIs(t, err, chaos.ErrInjected)
+
+	start := time.Now()
+	r, err := chaos.New(src, chaos.WithLatency(50*time.Millisecond, 60*time.Millisecond)).Get(ctx, 3)
+	require.NoError(t, err)
+	require.Equal(t, uint64(3), r.GetRound())
+	require.GreaterOrEqual(t, time.Since(start), 50*time.Millisecond)
+
+	cctx, cancel := context.WithCancel(ctx)
+	cancel()
+	_, err = chaos.New(src, chaos.WithLatency(time.Minute, time.Minute)).Get(cctx, 3)
+	require.ErrorIs(t, err, context.Canceled)
+
+	// the mock returns its first result, round 5, as the latest one
+	src = &clientMock.Client{Results: []mock.Result{mock.NewMockResult(5), mock.NewMockResult(4)}, StrictRounds: true}
+	r, err = chaos.New(src, chaos.WithStaleRate(1)).Get(ctx, 0)
+	require.NoError(t, err)
+	require.Equal(t, uint64(4), r.GetRound())
+}
@@ -5,12 +5,17 @@ import (
 	"context"
 	"errors"
 	"fmt"
+	"io"
+	"strings"
+	"sync"
 	"time"
 
+	clock "github.com/jonboulle/clockwork"
 	"github.com/prometheus/client_golang/prometheus"
 
 	"github.com/drand/go-clients/drand"
 
+	"github.com/drand/drand/v2/common"
 	"github.com/drand/drand/v2/common/chain"
 	"github.com/drand/drand/v2/common/log"
 	"github.com/drand/drand/v2/crypto"
@@ -22,9 +27,12 @@ const ClientStartupTimeout = time.Second * 5
 // It expects to be provided at least 1 valid client, or more using From().
 // If not specified, a default context with a timeout of ClientStartupTimeout
 // will be used when fetching chain information during client setup.
+// Closing the client stops its background goroutines and waits for them to
+// exit; the returned client implements drand.Stopper to bound that wait.
 func New(options ...Option) (drand.Client, error) {
 	cfg := clientConfig{
 		cacheSize: 32,
+		clockSkew: DefaultClockSkew,
 	}
 
 	for _, opt := range options {
@@ -35,6 +43,9 @@ func New(options ...Option) (drand.Client, error) {
 	if cfg.log == nil {
 		cfg.log = log.DefaultLogger()
 	}
+	if cfg.clock == nil {
+		cfg.clock = clock.NewRealClock()
+	}
 	if cfg.setupCtx == nil {
 		ctx, cancel := context.WithTimeout(context.Background(), ClientStartupTimeout)
 		cfg.setupCtx = ctx
@@ -56,6 +67,30 @@ func trySetLog(c any, l log.Logger) {
 	}
 }
 
+// ClockedClient is implemented by clients whose timing can be driven by a
+// custom clock, see WithClock.
+type ClockedClient interface {
+	SetClock(clock.Clock)
+}
+
+func trySetClock(c any, clk clock.Clock) {
+	if cc, ok := c.(ClockedClient); ok {
+		cc.SetClock(clk)
+	}
+}
+
+// UserAgentClient is implemented by clients identifying themselves to the
+// relays they query, see WithUserAgent.
+type UserAgentClient interface {
+	SetUserAgent(string)
+}
+
+func trySetUserAgent(c any, ua string) {
+	if uc, ok := c.(UserAgentClient); ok {
+		uc.SetUserAgent(ua)
+	}
+}
+
 // makeClient creates a watching verifying optimizing client from a configuration.
 func makeClient(cfg *clientConfig) (drand.Client, error) {
 	l := cfg.log
@@ -63,6 +98,24 @@ func makeClient(cfg *clientConfig) (drand.Client, error) {
 		l.Errorw("no root of trust specified")
 		return nil, errors.New("no root of trust specified")
 	}
+	// the relays of the list are added to the given clients
+	listed := make(map[drand.Client]string)
+	if cfg.relayList != nil {
+		chainHash := cfg.chainHash
+		if cfg.chainInfo != nil {
+			chainHash = cfg.chainInfo.Hash()
+		}
+		relays, urls, err := cfg.relayList.fetch(cfg.setupCtx, l, chainHash)
+		if err != nil && len(cfg.clients) == 0 {
+			return nil, err
+		} else if err != nil {
+			l.Warnw("", "client", "starting without the relay list", "err", err)
+		}
+		for i, c := range relays {
+			listed[c] = urls[i]
+		}
+		cfg.clients = append(cfg.clients, relays...)
+	}
 	if len(cfg.clients) == 0 && cfg.watcher == nil {
 		l.Errorw("no points of contact specified")
 		return nil, errors.New("no points of contact specified")
@@ -70,16 +123,45 @@ func makeClient(cfg *clientConfig) (drand.Client, error) {
 
 	var err error
 
+	if cfg.userAgent != "" {
+		for _, c := range cfg.clients {
+			trySetUserAgent(c, cfg.userAgent)
+		}
+	}
+
 	// provision cache
-	cache, err := makeCache(cfg.cacheSize)
-	if err != nil {
-		return nil, err
+	cache := cfg.cache
+	if cache == nil {
+		cache, err = makeCache(cfg.cacheSize)
+		if err != nil {
+			return nil, err
+		}
 	}
 
 	// try to populate chain info
-	if err := cfg.tryPopulateInfo(cfg.setupCtx, cfg.clients...); err != nil {
+	if cfg.infoConsensus && cfg.chainInfo == nil {
+		cfg.clients, err = cfg.populateInfoByConsensus(cfg.setupCtx, cfg.clients...)
+		if err != nil {
+			return nil, err
+		}
+	} else if err := cfg.tryPopulateInfo(cfg.setupCtx, cfg.clients...); err != nil {
 		return nil, err
 	}
+	if cfg.crossCheckInfo {
+		if err := cfg.checkInfoConsistency(cfg.setupCtx, cfg.clients...); err != nil {
+			return nil, err
+		}
+	}
+	if cfg.beaconID != "" && cfg.chainInfo != nil && !common.CompareBeaconIDs(cfg.beaconID, cfg.chainInfo.ID) {
+		return nil, fmt.Errorf("%w: chain is for beacon %q instead of %q", drand.ErrBeaconIDMismatch, cfg.chainInfo.ID, cfg.beaconID)
+	}
+	if cfg.requiredScheme != "" && cfg.chainInfo != nil && cfg.chainInfo.Scheme != cfg.requiredScheme {
+		return nil, fmt.Errorf("%w: chain uses scheme %q instead of %q", drand.ErrSchemeMismatch, cfg.chainInfo.Scheme, cfg.requiredScheme)
+	}
+
+	if cfg.chainInfo != nil {
+		cache = scopeCache(cache, cfg.chainInfo.Hash())
+	}
 
 	// provision watcher client
 	var wc drand.Client
@@ -93,30 +175,92 @@ func makeClient(cfg *clientConfig) (drand.Client, error) {
 
 	for _, c := range cfg.clients {
 		trySetLog(c, cfg.log)
+		trySetClock(c, cfg.clock)
 	}
 
 	var c drand.Client
 
+	var audit *auditLog
+	if cfg.auditLog != nil {
+		audit = newAuditLog(cfg.auditLog, cfg.clock, l)
+		audit.prev = cfg.auditHead
+	}
+	sch, err := crypto.GetSchemeByID(cfg.chainInfo.Scheme)
+	if err != nil {
+		return nil, fmt.Errorf("invalid scheme name in makeClient: %w", err)
+	}
+	newVerifier := func(source drand.Client) *verifyingClient {
+		upstream := source
+		if cfg.rateLimit > 0 {
+			upstream = newRateLimitedClient(cfg.clock, source, cfg.rateLimit, cfg.rateBurst)
+		}
+		nv := newVerifyingClient(upstream, cfg.previousResult, cfg.fullVerify, sch)
+		if cfg.catchUpConcurrency > 0 {
+			nv.catchUpConcurrency = cfg.catchUpConcurrency
+		}
+		nv.catchUpProgress = cfg.catchUpProgress
+		if cfg.verifyConcurrency > 0 {
+			nv.verifyConcurrency = cfg.verifyConcurrency
+		}
+		nv.clock = cfg.clock
+		nv.clockSkew = cfg.clockSkew
+		if r, ok := source.(InfoRefresher); ok {
+			nv.refresher = r
+		}
+		nv.requiredScheme = cfg.requiredScheme
+		nv.audit = audit
+		nv.upstreamTimeout = cfg.upstreamTimeout
+		nv.verifyTimeout = cfg.verifyTimeout
+		return nv
+	}
 	verifiers := make([]drand.Client, 0, len(cfg.clients))
+	relays := make(map[string]*verifyingClient, len(listed))
 	for _, source := range cfg.clients {
-		sch, err := crypto.GetSchemeByID(cfg.chainInfo.Scheme)
-		if err != nil {
-			return nil, fmt.Errorf("invalid scheme name in makeClient: %w", err)
-		}
-
-		nv := newVerifyingClient(source, cfg.previousResult, cfg.fullVerify, sch)
+		nv := newVerifier(source)
 		verifiers = append(verifiers, nv)
 		if source == wc {
 			wc = nv
 		}
+		if url, ok := listed[source]; ok {
+			relays[url] = nv
+		}
 	}
 
-	c, err = makeOptimizingClient(l, cfg, verifiers, wc, cache)
+	c, oc, err := makeOptimizingClient(l, cfg, verifiers, wc, cache)
 	if err != nil {
 		return nil, err
 	}
 
 	wa := newWatchAggregator(l, c, wc, cfg.autoWatch, cfg.autoWatchRetry)
+	wa.webhooks = cfg.webhooks
+	wa.noGapFilling = cfg.noGapFilling
+	wa.immediateFirst = cfg.immediateFirst
+	for _, v := range verifiers {
+		wa.verifiers = append(wa.verifiers, v.(*verifyingClient))
+	}
+	if cfg.dedupWindow != nil {
+		wa.dedupWindow = *cfg.dedupWindow
+	}
+	wa.info = cfg.chainInfo
+	wa.optimizer = oc
+	wa.infoRefresh = cfg.infoRefresh
+	wa.onChainChange = cfg.onChainChange
+	wa.forkCheck = cfg.forkCheck
+	wa.onFork = cfg.onFork
+	wa.relayList = cfg.relayList
+	wa.relays = relays
+	wa.newVerifier = func(source drand.Client) *verifyingClient {
+		if cfg.userAgent != "" {
+			trySetUserAgent(source, cfg.userAgent)
+		}
+		trySetLog(source, cfg.log)
+		trySetClock(source, cfg.clock)
+		nv := newVerifier(source)
+		trySetLog(nv, cfg.log)
+		nv.indirectClient = wa.Client
+		return nv
+	}
+	wa.SetClock(cfg.clock)
 	c = wa
 	trySetLog(c, cfg.log)
 
@@ -126,23 +270,39 @@ func makeClient(cfg *clientConfig) (drand.Client, error) {
 }
 
 //nolint:lll // This function has nicely named parameters, so it's long.
-func makeOptimizingClient(l log.Logger, cfg *clientConfig, verifiers []drand.Client, watcher drand.Client, cache Cache) (drand.Client, error) {
-	oc, err := newOptimizingClient(l, verifiers, 0, 0, 0, 0)
+func makeOptimizingClient(l log.Logger, cfg *clientConfig, verifiers []drand.Client, watcher drand.Client, cache Cache) (drand.Client, *optimizingClient, error) {
+	oc, err := newOptimizingClient(l, verifiers, cfg.requestTimeout, 0, cfg.speedTestInterval, 0)
 	if err != nil {
-		return nil, err
+		return nil, nil, err
+	}
+	if cfg.infoTimeout > 0 {
+		oc.infoTimeout = cfg.infoTimeout
 	}
 	if watcher != nil {
 		oc.MarkPassive(watcher)
 	}
+	oc.SetClock(cfg.clock)
+	oc.breakerThreshold = cfg.breakerThreshold
+	oc.breakerCooldown = cfg.breakerCooldown
+	oc.hedging = cfg.hedging
+	oc.hedgeDelay = cfg.hedgeDelay
+	oc.watchFailoverGrace = cfg.watchFailoverGrace
+	oc.onWatchSource = cfg.onWatchSource
 	c := drand.Client(oc)
 	trySetLog(c, cfg.log)
 
 	if cfg.cacheSize > 0 {
 		c, err = NewCachingClient(l, c, cache)
 		if err != nil {
-			return nil, err
+			return nil, nil, err
+		}
+		c.(*cachingClient).locker = cfg.latestLocker
+		if cfg.cache != nil && len(verifiers) > 0 {
+			// results added by other clients sharing the cache are not trusted
+			c.(*cachingClient).validate = verifiers[0].(*verifyingClient).verifyCached
 		}
 		trySetLog(c, cfg.log)
+		trySetClock(c, cfg.clock)
 	}
 	for _, v := range verifiers {
 		trySetLog(v, cfg.log)
@@ -150,7 +310,7 @@ func makeOptimizingClient(l log.Logger, cfg *clientConfig, verifiers []drand.Cli
 	}
 
 	oc.Start()
-	return c, nil
+	return c, oc, nil
 }
 
 func makeWatcherClient(cfg *clientConfig, cache Cache) (drand.Client, error) {
@@ -181,6 +341,13 @@ type clientConfig struct {
 	// chain signature verification back to the 1st round, or to a know result to ensure
 	// determinism in the event of a compromised chain.
 	fullVerify bool
+	// catchUpConcurrency bounds how many rounds are fetched in parallel when
+	// catching up on a chained scheme during full chain verification.
+	catchUpConcurrency int
+	// verifyConcurrency is the number of results verified in parallel, 1 if 0.
+	verifyConcurrency int
+	// catchUpProgress is notified of the progress of full chain verification catch-ups.
+	catchUpProgress CatchUpProgressFunc
 	// insecure indicates the root of trust does not need to be present.
 	insecure bool
 	// autoWatch causes the client to start watching immediately in the background so that new randomness
@@ -188,6 +355,10 @@ type clientConfig struct {
 	autoWatch bool
 	// cache size - how large of a cache to keep locally.
 	cacheSize int
+	// cache overrides the local cache, e.g. to share it between replicas.
+	cache Cache
+	// latestLocker coordinates refreshes of the latest round between replicas sharing the cache.
+	latestLocker Locker
 	// customized client log.
 	log log.Logger
 
@@ -199,13 +370,82 @@ type clientConfig struct {
 	autoWatchRetry time.Duration
 	// prometheus is an interface to a Prometheus system
 	prometheus prometheus.Registerer
+	// webhooks are notified of each new verified result.
+	webhooks []*WebhookNotifier
+	// rateLimit is the maximum average number of requests per second made to each upstream, if positive.
+	rateLimit float64
+	// rateBurst is the number of requests that can be made to an upstream in a burst.
+	rateBurst int
+	// noGapFilling disables the backfilling of the rounds skipped by watches.
+	noGapFilling bool
+	// immediateFirst makes watches start with the latest round.
+	immediateFirst bool
+	// beaconID is the ID of the beacon the chain must belong to, if set.
+	beaconID string
+	// requiredScheme is the scheme the chain must use, if set.
+	requiredScheme string
+	// userAgent identifies the client to the relays it queries, if set.
+	userAgent string
+	// crossCheckInfo requires all the sources to advertise the same chain info.
+	crossCheckInfo bool
+	// infoConsensus takes the chain info advertised by a majority of the
+	// sources instead of the first one, see WithInfoConsensus.
+	infoConsensus bool
+	// dedupWindow overrides the number of recent rounds remembered to suppress duplicate watch results.
+	dedupWindow *int
+	// watchFailoverGrace is how late the watcher can be before the other
+	// sources are polled, if positive.
+	watchFailoverGrace time.Duration
+	// onWatchSource is notified of the source of each new watched round.
+	onWatchSource WatchSourceFunc
+	// clock times polling, speed tests and retries, and can be faked in tests.
+	clock clock.Clock
+	// speedTestInterval is the interval between the speed tests of the upstreams, the default one if 0.
+	speedTestInterval time.Duration
+	// clockSkew is how far in the future of the clock a round can be before
+	// its beacons are rejected.
+	clockSkew time.Duration
+	// infoRefresh is the interval at which the chain info served by the sources is re-validated, if positive.
+	infoRefresh time.Duration
+	// onChainChange is notified when a source starts serving another chain.
+	onChainChange func(*ChainChangedError)
+	// forkCheck is the interval at which the sources are checked to serve
+	// the same signatures, if positive, notifying onFork of forks.
+	forkCheck time.Duration
+	onFork    func(*ForkError)
+	// relayList provides relays in addition to clients, see WithRelayList.
+	relayList *relayList
+	// auditLog, if set, receives an AuditEntry line for each verified result.
+	auditLog io.Writer
+	// auditHead is the hash of the last line of the audit log, when appending
+	// to an existing one.
+	auditHead string
+	// requestTimeout bounds each attempt to get a round from a source,
+	// verification included, the default one if 0.
+	requestTimeout time.Duration
+	// upstreamTimeout bounds each Get of a source, verification excluded, if positive.
+	upstreamTimeout time.Duration
+	// verifyTimeout bounds the verification of each result, if positive.
+	verifyTimeout time.Duration
+	// infoTimeout bounds each Info call to a source, the request timeout if 0.
+	infoTimeout time.Duration
+	// breakerThreshold is the number of consecutive failures after which a
+	// source is skipped for breakerCooldown, if positive.
+	breakerThreshold int
+	breakerCooldown  time.Duration
+	// hedging makes Gets hedge their request after hedgeDelay, or the 95th
+	// percentile of the latency of the source if 0, see WithHedging.
+	hedging    bool
+	hedgeDelay time.Duration
 }
 
 func (c *clientConfig) tryPopulateInfo(ctx context.Context, clients ...drand.Client) (err error) {
 	if c.chainInfo == nil {
 		var cerr error
 		for _, cli := range clients {
-			c.chainInfo, cerr = cli.Info(ctx)
+			ictx, cancel := c.infoContext(ctx)
+			c.chainInfo, cerr = cli.Info(ictx)
+			cancel()
 			if cerr == nil {
 				return
 			}
@@ -216,6 +456,123 @@ func (c *clientConfig) tryPopulateInfo(ctx context.Context, clients ...drand.Cli
 	return
 }
 
+// populateInfoByConsensus sets the chain info of the config to the one
+// advertised by a strict majority of the clients, and returns the clients
+// without the ones advertising another chain, which are reported and closed.
+func (c *clientConfig) populateInfoByConsensus(ctx context.Context, clients ...drand.Client) ([]drand.Client, error) {
+	infos := make([]*chain.Info, len(clients))
+	errs := make([]error, len(clients))
+	var wg sync.WaitGroup
+	for i, cli := range clients {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			ictx, cancel := c.infoContext(ctx)
+			defer cancel()
+			infos[i], errs[i] = cli.Info(ictx)
+		}()
+	}
+	wg.Wait()
+
+	var err error
+	votes := make(map[string]int)
+	for i, info := range infos {
+		if errs[i] != nil {
+			c.log.Warnw("", "drand_client", "could not get chain info", "client", fmt.Sprint(clients[i]), "err", errs[i])
+			err = errors.Join(err, errs[i])
+			continue
+		}
+		votes[string(info.Hash())]++
+	}
+
+	// the sources failing to answer count against every chain, so that a
+	// minority of the sources cannot set the chain while the others are down
+	var majority *chain.Info
+	for _, info := range infos {
+		if info != nil && 2*votes[string(info.Hash())] > len(clients) {
+			majority = info
+			break
+		}
+	}
+	agreeing := make([]drand.Client, 0, len(clients))
+	var disagreeing []drand.Client
+	var advertised []string
+	for i, info := range infos {
+		if info != nil && (majority == nil || !bytes.Equal(info.Hash(), majority.Hash())) {
+			disagreeing = append(disagreeing, clients[i])
+			advertised = append(advertised, fmt.Sprintf("%s advertises %x", clients[i], info.Hash()))
+			continue
+		}
+		agreeing = append(agreeing, clients[i])
+	}
+	if majority == nil {
+		return nil, errors.Join(fmt.Errorf("%w: no chain is advertised by a majority of the %d sources: %s",
+			drand.ErrInconsistentChainInfo, len(clients), strings.Join(advertised, ", ")), err, ctx.Err())
+	}
+	for i, d := range disagreeing {
+		c.log.Warnw("", "drand_client", "dropping source disagreeing with the majority on the chain",
+			"chain", majority.HashString(), "source", advertised[i])
+		if cerr := d.Close(); cerr != nil {
+			c.log.Warnw("", "drand_client", "failed to close dropped source", "source", fmt.Sprint(d), "err", cerr)
+		}
+	}
+	c.chainInfo = majority
+	return agreeing, nil
+}
+
+// infoContext bounds ctx by the Info timeout, if any.
+func (c *clientConfig) infoContext(ctx context.Context) (context.Context, context.CancelFunc) {
+	if c.infoTimeout > 0 {
+		return context.WithTimeout(ctx, c.infoTimeout)
+	}
+	return context.WithCancel(ctx)
+}
+
+// checkInfoConsistency checks that all the clients able to provide chain info
+// agree with the chain info of the config.
+func (c *clientConfig) checkInfoConsistency(ctx context.Context, clients ...drand.Client) error {
+	if c.chainInfo == nil {
+		return nil
+	}
+	for _, cli := range clients {
+		ictx, cancel := c.infoContext(ctx)
+		info, err := cli.Info(ictx)
+		cancel()
+		if err != nil {
+			c.log.Warnw("", "drand_client", "could not cross-check chain info", "client", fmt.Sprint(cli), "err", err)
+			continue
+		}
+		if diff := infoDiff(c.chainInfo, info); len(diff) > 0 {
+			return fmt.Errorf("%w: %s advertises a different %s", drand.ErrInconsistentChainInfo, cli, strings.Join(diff, ", "))
+		}
+	}
+	return nil
+}
+
+// infoDiff returns the names of the fields that differ between two chain infos.
+func infoDiff(a, b *chain.Info) []string {
+	var diff []string
+	if !bytes.Equal(a.Hash(), b.Hash()) {
+		diff = append(diff, "chain hash")
+	}
+	if a.GenesisTime != b.GenesisTime {
+		diff = append(diff, "genesis time")
+	}
+	if a.Period != b.Period {
+		diff = append(diff, "period")
+	}
+	if a.Scheme != b.Scheme {
+		diff = append(diff, "scheme")
+	}
+	if !a.PublicKey.Equal(b.PublicKey) {
+		diff = append(diff, "public key")
+	}
+	if !common.CompareBeaconIDs(a.ID, b.ID) {
+		diff = append(diff, "beacon ID")
+	}
+	return diff
+}
+
 // Option is an option configuring a client.
 type Option func(cfg *clientConfig) error
 
@@ -245,6 +602,29 @@ func WithCacheSize(size int) Option {
 	}
 }
 
+// WithCache replaces the local LRU cache with the given one, for instance a
+// cache shared by several replicas. WithCacheSize must not be set to 0 for
+// the cache to be used. A ChainCache, e.g. made with NewSharedCache, can be
+// shared by clients of different chains. Results found in the given cache are
+// checked before being returned, as other clients may have added them.
+func WithCache(cache Cache) Option {
+	return func(cfg *clientConfig) error {
+		cfg.cache = cache
+		return nil
+	}
+}
+
+// WithCoordinatedLatest makes replicas sharing a cache (see WithCache)
+// coordinate through the given lock, so that a single one of them fetches the
+// latest round from upstream each period while the others pick it up from the
+// shared cache.
+func WithCoordinatedLatest(l Locker) Option {
+	return func(cfg *clientConfig) error {
+		cfg.latestLocker = l
+		return nil
+	}
+}
+
 // WithChainHash configures the client to root trust with a given randomness
 // chain hash, the chain parameters will be fetched from an HTTP endpoint.
 func WithChainHash(chainHash []byte) Option {
@@ -270,6 +650,19 @@ func WithChainInfo(chainInfo *chain.Info) Option {
 	}
 }
 
+// WithKnownChain roots trust in the chain information returned by one of the
+// accessors of the chains package, e.g. `WithKnownChain(chains.Quicknet)`, so
+// that no chain information has to be fetched from the remotes.
+func WithKnownChain(known func() *chain.Info) Option {
+	return func(cfg *clientConfig) error {
+		info := known()
+		if info == nil {
+			return errors.New("unknown chain")
+		}
+		return WithChainInfo(info)(cfg)
+	}
+}
+
 // WithLogger overrides the logging options for the client,
 // allowing specification of additional tags, or redirection / configuration
 // of logging level and output. If it is not used to set a specific logger,
@@ -319,6 +712,45 @@ func WithFullChainVerification() Option {
 	}
 }
 
+// WithCatchUpConcurrency sets how many rounds are fetched concurrently when
+// `WithFullChainVerification` needs to walk the chain from the point of trust
+// up to a requested round. Rounds are still verified sequentially. Default 8.
+func WithCatchUpConcurrency(n int) Option {
+	return func(cfg *clientConfig) error {
+		if n <= 0 {
+			return errors.New("catch-up concurrency must be positive")
+		}
+		cfg.catchUpConcurrency = n
+		return nil
+	}
+}
+
+// WithVerificationConcurrency verifies up to n results in parallel, on
+// separate goroutines, instead of verifying them one at a time on the watch
+// goroutine. Watches still deliver the results in the order they were
+// received. It speeds up catching up on chained schemes and watching
+// high-frequency chains on multi-core machines. Watches with full chain
+// verification keep verifying each result after the previous one. It
+// defaults to 1.
+func WithVerificationConcurrency(n int) Option {
+	return func(cfg *clientConfig) error {
+		if n < 1 {
+			return errors.New("verification concurrency must be at least 1")
+		}
+		cfg.verifyConcurrency = n
+		return nil
+	}
+}
+
+// WithCatchUpProgress registers a callback notified each time a batch of
+// rounds has been verified while catching up on a chained scheme.
+func WithCatchUpProgress(f CatchUpProgressFunc) Option {
+	return func(cfg *clientConfig) error {
+		cfg.catchUpProgress = f
+		return nil
+	}
+}
+
 // Watcher supplies the `Watch` portion of the drand client interface.
 type Watcher interface {
 	Watch(ctx context.Context) <-chan drand.Result
@@ -356,6 +788,366 @@ func WithAutoWatchRetry(interval time.Duration) Option {
 	}
 }
 
+// WithWebhook posts each new verified beacon to the endpoints of the
+// notifier. It implies WithAutoWatch so that beacons are delivered as soon as
+// they are produced. Beacons are delivered in order, in the background until
+// the client is closed, and are dropped while 16 of them are waiting already.
+func WithWebhook(n *WebhookNotifier) Option {
+	return func(cfg *clientConfig) error {
+		cfg.webhooks = append(cfg.webhooks, n)
+		cfg.autoWatch = true
+		return nil
+	}
+}
+
+// WithoutGapFilling disables the backfilling of rounds skipped by Watch. By
+// default, when a watch delivers a round that is not consecutive to the last
+// one, the missing rounds are streamed from a source implementing
+// drand.ResumableWatcher, if any, or else fetched with Get, and emitted first,
+// in order. The new rounds are held for at most a few seconds meanwhile, the
+// missed rounds not backfilled by then being reported as lags, see OnLag.
+func WithoutGapFilling() Option {
+	return func(cfg *clientConfig) error {
+		cfg.noGapFilling = true
+		return nil
+	}
+}
+
+// WithImmediateFirstResult makes Watch send the latest round as soon as it is
+// called, from the cache or fetched, and then the rounds after it as they are
+// produced. This replaces calling Get before Watch, which can miss or repeat a
+// round produced in between. If the latest round cannot be fetched, Watch only
+// sends the new rounds.
+func WithImmediateFirstResult() Option {
+	return func(cfg *clientConfig) error {
+		cfg.immediateFirst = true
+		return nil
+	}
+}
+
+// WithBeaconID requires the chain followed by the client to be the one of the
+// given beacon, e.g. "quicknet", and results advertising another beacon ID are
+// always rejected. Sources of multi-beacon relays can be addressed by beacon ID
+// with http.WithBeaconID.
+func WithBeaconID(id string) Option {
+	return func(cfg *clientConfig) error {
+		if id == "" {
+			return errors.New("beacon ID cannot be empty")
+		}
+		cfg.beaconID = id
+		return nil
+	}
+}
+
+// WithRequiredScheme requires the chain followed by the client to use the
+// given signature scheme, e.g. "bls-unchained-g1-rfc9380" for quicknet, so
+// that the client fails to start with drand.ErrSchemeMismatch when its
+// sources serve a chain of another scheme, e.g. a chained one, instead of
+// verifying its beacons with that scheme. With WithInfoRefresh, the sources
+// are also rejected when they start serving a chain of another scheme.
+func WithRequiredScheme(name string) Option {
+	return func(cfg *clientConfig) error {
+		if _, err := crypto.GetSchemeByID(name); err != nil {
+			return fmt.Errorf("invalid required scheme: %w", err)
+		}
+		cfg.requiredScheme = name
+		return nil
+	}
+}
+
+// WithUserAgent sets the user agent the sources of the client identify
+// themselves with, as the User-Agent header of HTTP requests and the
+// x-user-agent metadata of gRPC calls.
+func WithUserAgent(ua string) Option {
+	return func(cfg *clientConfig) error {
+		cfg.userAgent = ua
+		return nil
+	}
+}
+
+// WithInfoCrossCheck checks during setup that all the sources of the client
+// advertise the same chain info, failing with drand.ErrInconsistentChainInfo
+// when they disagree. Sources that cannot provide their chain info are
+// skipped. It protects clients that trust the chain advertised by their
+// sources, e.g. using Insecurely with several relays, from a single
+// compromised relay.
+func WithInfoCrossCheck() Option {
+	return func(cfg *clientConfig) error {
+		cfg.crossCheckInfo = true
+		return nil
+	}
+}
+
+// WithInfoConsensus takes the chain info advertised by a strict majority of
+// all the sources during setup, querying all of them, instead of the first one
+// provided. The sources advertising another chain are logged, closed and
+// dropped, while the ones failing to provide a chain info are kept, but count
+// against every chain. It fails with drand.ErrInconsistentChainInfo when no
+// chain has a majority, catching misconfigured lists of sources when the
+// client is created rather than by its first requests. It has no effect when
+// the chain info is known, e.g. with WithChainInfo.
+func WithInfoConsensus() Option {
+	return func(cfg *clientConfig) error {
+		cfg.infoConsensus = true
+		return nil
+	}
+}
+
+// WithDedupWindow sets the number of recently delivered rounds remembered by
+// Watch to suppress duplicates, e.g. when both a watcher and a polling client
+// deliver the same beacon, before notifying the webhooks. It defaults to 16,
+// and 0 disables deduplication. The channels of Watch never deliver a round
+// twice, since they deliver rounds in increasing order.
+func WithDedupWindow(rounds int) Option {
+	return func(cfg *clientConfig) error {
+		if rounds < 0 {
+			return errors.New("deduplication window cannot be negative")
+		}
+		cfg.dedupWindow = &rounds
+		return nil
+	}
+}
+
+// WithWatchFailoverGracePeriod makes watches rely on the watcher (see
+// WithWatcher), e.g. libp2p pubsub, and only poll the other sources once it
+// did not deliver a new round for a period plus the grace period, to lower
+// the load on public HTTP relays. Watches switch back to the watcher alone
+// once it delivers the current round again. By default, the sources are
+// polled alongside the watcher for the lowest latency.
+func WithWatchFailoverGracePeriod(d time.Duration) Option {
+	return func(cfg *clientConfig) error {
+		if d < 0 {
+			return errors.New("watch failover grace period cannot be negative")
+		}
+		cfg.watchFailoverGrace = d
+		return nil
+	}
+}
+
+// WithWatchSourceHook calls f with each new round received by the watches,
+// and the source which delivered it first, e.g. to tune
+// WithWatchFailoverGracePeriod.
+func WithWatchSourceHook(f WatchSourceFunc) Option {
+	return func(cfg *clientConfig) error {
+		cfg.onWatchSource = f
+		return nil
+	}
+}
+
+// WithClock sets the clock used by the client and the clients it wraps for
+// polling, speed tests, retries and rate limiting, so that time can be
+// simulated, e.g. with clockwork.NewFakeClock.
+func WithClock(clk clock.Clock) Option {
+	return func(cfg *clientConfig) error {
+		if clk == nil {
+			return errors.New("clock cannot be nil")
+		}
+		cfg.clock = clk
+		return nil
+	}
+}
+
+// WithClockSkew sets how far ahead of the local clock the time of a round can
+// be before its beacons are rejected with drand.ErrFutureRound, whatever the
+// source they come from. It defaults to DefaultClockSkew, and should cover the
+// drift between the local clock and the clocks of the drand nodes.
+func WithClockSkew(d time.Duration) Option {
+	return func(cfg *clientConfig) error {
+		if d < 0 {
+			return errors.New("clock skew cannot be negative")
+		}
+		cfg.clockSkew = d
+		return nil
+	}
+}
+
+// WithInfoRefresh re-validates the chain info served by each source every
+// interval, bypassing the info fetched at setup. When a source starts serving
+// another chain than the trusted one, e.g. after a reshare or a migration of
+// the relay, its Gets fail with a *ChainChangedError until it serves the
+// trusted chain again, and onChange, if not nil, is called with that error.
+// Only the sources implementing InfoRefresher, such as the HTTP clients, are
+// re-validated.
+func WithInfoRefresh(interval time.Duration, onChange func(*ChainChangedError)) Option {
+	return func(cfg *clientConfig) error {
+		if interval <= 0 {
+			return errors.New("info refresh interval must be positive")
+		}
+		cfg.infoRefresh = interval
+		cfg.onChainChange = onChange
+		return nil
+	}
+}
+
+// WithForkCheck fetches the round preceding the current one from every source
+// every interval, bypassing verification and caching, and checks that they
+// all serve the same signature. When they do not, which means a source serves
+// a fork of the chain or corrupted beacons, the client_forked_rounds metric is
+// incremented and onFork, if not nil, is called with the *ForkError. The same
+// check can be run for any round with the drand.ForkChecker implemented by the
+// client.
+func WithForkCheck(interval time.Duration, onFork func(*ForkError)) Option {
+	return func(cfg *clientConfig) error {
+		if interval <= 0 {
+			return errors.New("fork check interval must be positive")
+		}
+		cfg.forkCheck = interval
+		cfg.onFork = onFork
+		return nil
+	}
+}
+
+// WithRelayList adds the relays listed by list to the clients given with
+// From, creating a client for each of them with ctor. The list is fetched
+// again every interval, if positive, and the relays added to or removed from
+// it are added to or removed from the client, so that long-running processes
+// follow the changes of a relay fleet without restarting. Removed relays are
+// closed. The client only uses the new relays serving the trusted chain, and
+// never removes its last relay. The relays of the list are only optional
+// when clients are given with From: the client fails to start if it cannot
+// fetch the list otherwise. See http.WithRelayList for lists of HTTP relays.
+func WithRelayList(list RelayListFunc, ctor RelayCtor, interval time.Duration) Option {
+	return func(cfg *clientConfig) error {
+		if list == nil || ctor == nil {
+			return errors.New("relay list and constructor are required")
+		}
+		cfg.relayList = &relayList{list: list, ctor: ctor, interval: interval}
+		return nil
+	}
+}
+
+// WithAuditLog appends a JSON line to w, see AuditEntry, for each result
+// verified by the client: its round and signature, the outcome of the
+// verification and the source which served it, with timestamps. Each line
+// holds the hash of the previous one, so that the log is tamper-evident, see
+// VerifyAuditLog. Writes are serialized, and should not block. w must be
+// empty: a client appending to an existing log is made with WithAuditLogFrom.
+func WithAuditLog(w io.Writer) Option {
+	return WithAuditLogFrom(w, "")
+}
+
+// WithAuditLogFrom is WithAuditLog appending to an existing log whose last
+// line has the hash head, as returned by AuditLogHead, e.g. after a restart of
+// the client, so that the new entries chain on the previous ones.
+func WithAuditLogFrom(w io.Writer, head string) Option {
+	return func(cfg *clientConfig) error {
+		cfg.auditLog = w
+		cfg.auditHead = head
+		return nil
+	}
+}
+
+// WithSpeedTestInterval sets how often all the upstreams are probed, to rank
+// them by speed and collect their statistics, see drand.UpstreamReporter. It
+// defaults to 5 minutes, and a negative interval disables the probes.
+func WithSpeedTestInterval(interval time.Duration) Option {
+	return func(cfg *clientConfig) error {
+		cfg.speedTestInterval = interval
+		return nil
+	}
+}
+
+// WithRequestTimeout bounds each attempt to get a round from a source,
+// fetching and verifying it, after which the next fastest source is tried.
+// It defaults to 5 seconds.
+func WithRequestTimeout(d time.Duration) Option {
+	return func(cfg *clientConfig) error {
+		if d < 0 {
+			return errors.New("request timeout cannot be negative")
+		}
+		cfg.requestTimeout = d
+		return nil
+	}
+}
+
+// WithUpstreamTimeout bounds each Get made to a source, verification excluded,
+// e.g. so that a slow relay does not leave too little of the request timeout
+// to verify its result. By default, only the request timeout applies.
+func WithUpstreamTimeout(d time.Duration) Option {
+	return func(cfg *clientConfig) error {
+		if d < 0 {
+			return errors.New("upstream timeout cannot be negative")
+		}
+		cfg.upstreamTimeout = d
+		return nil
+	}
+}
+
+// WithVerifyTimeout bounds the verification of each result, including the
+// fetching of the previous rounds it depends on with WithFullChainVerification.
+// By default, only the request timeout and the context of the caller apply.
+func WithVerifyTimeout(d time.Duration) Option {
+	return func(cfg *clientConfig) error {
+		if d < 0 {
+			return errors.New("verify timeout cannot be negative")
+		}
+		cfg.verifyTimeout = d
+		return nil
+	}
+}
+
+// WithInfoTimeout bounds each call made to a source for the chain info, at
+// setup and afterwards. It defaults to the request timeout.
+func WithInfoTimeout(d time.Duration) Option {
+	return func(cfg *clientConfig) error {
+		if d < 0 {
+			return errors.New("info timeout cannot be negative")
+		}
+		cfg.infoTimeout = d
+		return nil
+	}
+}
+
+// WithCircuitBreaker skips a source for the cool-down period after the given
+// number of consecutive failed Gets, instead of trying it again on each Get
+// and speed test. Once the cool-down period is over, the outcome of the next
+// Get made to the source decides whether it is used again or skipped for
+// another period. Sources are used regardless of their circuit when all of
+// them are skipped. The state of the circuits is reported by UpstreamStats
+// and the client_upstream_circuit metric.
+func WithCircuitBreaker(failures int, cooldown time.Duration) Option {
+	return func(cfg *clientConfig) error {
+		if failures < 1 || cooldown <= 0 {
+			return errors.New("circuit breaker needs a positive number of failures and cool-down period")
+		}
+		cfg.breakerThreshold = failures
+		cfg.breakerCooldown = cooldown
+		return nil
+	}
+}
+
+// WithHedging makes Get ask the sources one at a time, fastest first, moving
+// on to the next one when the previous one fails or has not answered within
+// delay, and taking the first result, instead of asking the two fastest
+// sources at once. It halves the requests made to the sources, while bounding
+// the latency of Gets when the fastest one slows down. A zero delay is the
+// 95th percentile of the latency of the source, see UpstreamStats.
+func WithHedging(delay time.Duration) Option {
+	return func(cfg *clientConfig) error {
+		if delay < 0 {
+			return errors.New("hedge delay cannot be negative")
+		}
+		cfg.hedging = true
+		cfg.hedgeDelay = delay
+		return nil
+	}
+}
+
+// WithRateLimit limits the Get requests made to each upstream client to rps
+// requests per second on average, allowing bursts of up to burst requests, so
+// as to comply with the quotas of public relays. Info requests are not limited,
+// since the sources only fetch the chain info once.
+func WithRateLimit(rps float64, burst int) Option {
+	return func(cfg *clientConfig) error {
+		if rps <= 0 || burst < 1 {
+			return errors.New("rate limit must be positive, with a burst of at least 1")
+		}
+		cfg.rateLimit = rps
+		cfg.rateBurst = burst
+		return nil
+	}
+}
+
 // WithPrometheus specifies a registry into which to report metrics
 func WithPrometheus(r prometheus.Registerer) Option {
 	return func(cfg *clientConfig) error {
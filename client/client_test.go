@@ -1,17 +1,27 @@
 package client_test
 
 import (
+	"bytes"
 	"context"
+	"encoding/hex"
+	"encoding/json"
 	"errors"
+	"fmt"
+	nhttp "net/http"
+	"net/http/httptest"
+	"sync/atomic"
 	"testing"
 	"time"
 
 	clock "github.com/jonboulle/clockwork"
 	"github.com/stretchr/testify/require"
 
+	"github.com/drand/drand/v2/common"
 	"github.com/drand/drand/v2/common/key"
 	"github.com/drand/drand/v2/common/log"
+	"github.com/drand/go-clients/chains"
 	clientMock "github.com/drand/go-clients/client/mock"
+	"github.com/drand/go-clients/client/test/cache"
 	"github.com/drand/go-clients/client/test/result/mock"
 	"github.com/drand/go-clients/drand"
 
@@ -20,6 +30,7 @@ import (
 	"github.com/drand/go-clients/client"
 	"github.com/drand/go-clients/client/http"
 	httpmock "github.com/drand/go-clients/client/test/http/mock"
+	"github.com/drand/go-clients/clienttest"
 )
 
 func TestClientConstraints(t *testing.T) {
@@ -383,6 +394,55 @@ func TestClientAutoWatchRetry(t *testing.T) {
 	}
 }
 
+func TestClientAutoWatchRetryWithClock(t *testing.T) {
+	sch, err := crypto.GetSchemeFromEnv()
+	require.NoError(t, err)
+	info, results := mock.VerifiableResults(1, sch)
+
+	retried := make(chan struct{})
+	var failer clientMock.Client
+	failer = clientMock.Client{
+		WatchF: func(ctx context.Context) <-chan drand.Result {
+			// First call returns a closed channel
+			ch := make(chan drand.Result)
+			close(ch)
+			// Second call signals the retry
+			failer.WatchF = func(ctx context.Context) <-chan drand.Result {
+				close(retried)
+				ch := make(chan drand.Result, 1)
+				ch <- &results[0]
+				return ch
+			}
+			return ch
+		},
+	}
+
+	clk := clock.NewFakeClock()
+	c, err := client.New(
+		client.From(&failer, clientMock.ClientWithInfo(info)),
+		client.WithChainInfo(info),
+		client.WithAutoWatch(),
+		client.WithAutoWatchRetry(time.Hour),
+		client.WithClock(clk),
+	)
+	require.NoError(t, err)
+	defer c.Close()
+
+	// the retry only happens once the simulated time has passed
+	select {
+	case <-retried:
+		t.Fatal("auto watch retried before the retry interval")
+	case <-time.After(100 * time.Millisecond):
+	}
+
+	clk.Advance(time.Hour)
+	select {
+	case <-retried:
+	case <-time.After(10 * time.Second):
+		t.Fatal("timed out waiting for the auto watch retry")
+	}
+}
+
 // compareResults asserts that two results are the same.
 func compareResults(t *testing.T, expected, actual drand.Result) {
 	t.Helper()
@@ -408,3 +468,873 @@ func fakeChainInfo(t *testing.T) *chain.Info {
 		Scheme:      sch.Name,
 	}
 }
+
+func TestClientWithKnownChain(t *testing.T) {
+	info := chains.Quicknet()
+	c, err := client.New(
+		client.From(clientMock.ClientWithInfo(info)),
+		client.WithKnownChain(chains.Quicknet),
+	)
+	require.NoError(t, err)
+	defer c.Close()
+
+	got, err := c.Info(context.Background())
+	require.NoError(t, err)
+	require.Equal(t, chains.QuicknetHash, got.HashString())
+
+	_, err = client.New(
+		client.From(clientMock.ClientWithInfo(info)),
+		client.WithChainHash(info.Hash()),
+		client.WithKnownChain(chains.Default),
+	)
+	require.Error(t, err)
+}
+
+func TestClientStatus(t *testing.T) {
+	ctx := context.Background()
+	relay := clienttest.NewRelay(t, clienttest.WithRounds(5))
+	hc, err := http.NewWithInfo(nil, relay.URL(), relay.Info(), nil)
+	require.NoError(t, err)
+
+	clk := clock.NewFakeClockAt(time.Unix(relay.Info().GenesisTime, 0).Add(time.Hour))
+	c, err := client.New(
+		client.From(hc),
+		client.WithChainInfo(relay.Info()),
+		client.WithTrustedResult(relay.Result(2)),
+		client.WithFullChainVerification(),
+		client.WithClock(clk),
+	)
+	require.NoError(t, err)
+	defer c.Close()
+
+	sp, ok := c.(drand.StatusProvider)
+	require.True(t, ok)
+	status := sp.Status()
+	require.Zero(t, status.LatestRound)
+	require.True(t, status.LastFetch.IsZero())
+	require.Equal(t, uint64(2), status.Checkpoint)
+
+	_, err = c.Get(ctx, 4)
+	require.NoError(t, err)
+	status = sp.Status()
+	require.Equal(t, uint64(4), status.LatestRound)
+	require.Equal(t, clk.Now(), status.LastFetch)
+	require.GreaterOrEqual(t, status.Checkpoint, uint64(3))
+
+	clk.Advance(time.Minute)
+	_, err = c.Get(ctx, 1)
+	require.NoError(t, err)
+	status = sp.Status()
+	require.Equal(t, uint64(4), status.LatestRound)
+	require.Equal(t, clk.Now(), status.LastFetch)
+}
+
+func TestNewAsync(t *testing.T) {
+	ctx := context.Background()
+	relay := clienttest.NewRelay(t, clienttest.WithRounds(3))
+	hc, err := http.New(ctx, nil, relay.URL(), relay.Info().Hash(), nil, http.WithLazyInfo())
+	require.NoError(t, err)
+
+	c, ready := client.NewAsync(client.From(hc), client.WithChainHash(relay.Info().Hash()))
+	defer c.Close()
+	r, err := c.Get(ctx, 2)
+	require.NoError(t, err)
+	require.Equal(t, relay.Result(2).GetSignature(), r.GetSignature())
+	require.NoError(t, <-ready)
+	require.NoError(t, c.(drand.ReadyWaiter).WaitReady(ctx))
+
+	// the setup fails without any source serving the chain
+	down, err := http.New(ctx, nil, "http://127.0.0.1:1", relay.Info().Hash(), nil, http.WithLazyInfo())
+	require.NoError(t, err)
+	c, ready = client.NewAsync(client.From(down), client.WithChainHash(relay.Info().Hash()))
+	require.Error(t, <-ready)
+	_, err = c.Get(ctx, 2)
+	require.Error(t, err)
+	_, ok := <-c.Watch(ctx)
+	require.False(t, ok)
+	require.NoError(t, c.Close())
+
+	// closing the client cancels its setup
+	stuck := httptest.NewServer(nhttp.HandlerFunc(func(_ nhttp.ResponseWriter, r *nhttp.Request) {
+		<-r.Context().Done()
+	}))
+	defer stuck.Close()
+	slow, err := http.New(ctx, nil, stuck.URL, relay.Info().Hash(), nil, http.WithLazyInfo())
+	require.NoError(t, err)
+	c, ready = client.NewAsync(client.From(slow), client.WithChainHash(relay.Info().Hash()))
+	wctx, cancel := context.WithTimeout(ctx, 10*time.Millisecond)
+	defer cancel()
+	require.ErrorIs(t, c.(drand.ReadyWaiter).WaitReady(wctx), context.DeadlineExceeded)
+	require.Zero(t, c.RoundAt(time.Now()))
+	require.NoError(t, c.Close())
+	require.Error(t, <-ready)
+}
+
+func TestResultMeta(t *testing.T) {
+	ctx := context.Background()
+	relay := clienttest.NewRelay(t, clienttest.WithRounds(5))
+	hc, err := http.NewWithInfo(nil, relay.URL(), relay.Info(), nil)
+	require.NoError(t, err)
+
+	clk := clock.NewFakeClockAt(time.Unix(relay.Info().GenesisTime, 0).Add(time.Hour))
+	c, err := client.New(client.From(hc), client.WithChainInfo(relay.Info()), client.WithClock(clk))
+	require.NoError(t, err)
+	defer c.Close()
+
+	r, err := c.Get(ctx, 4)
+	require.NoError(t, err)
+	meta, ok := client.ResultMeta(r)
+	require.True(t, ok)
+	require.Equal(t, fmt.Sprint(hc), meta.Source)
+	require.Equal(t, "http", meta.Transport)
+	require.Equal(t, clk.Now(), meta.Received)
+	timeOfRound := time.Unix(relay.Info().GenesisTime, 0).Add(3 * relay.Info().Period)
+	require.Equal(t, clk.Now().Sub(timeOfRound), meta.Latency)
+
+	// the results of the cache keep the metadata of their first delivery
+	clk.Advance(time.Minute)
+	r, err = c.Get(ctx, 4)
+	require.NoError(t, err)
+	cached, ok := client.ResultMeta(r)
+	require.True(t, ok)
+	require.Equal(t, meta, cached)
+
+	_, ok = client.ResultMeta(relay.Result(4))
+	require.False(t, ok)
+}
+
+func TestResultMetaWatch(t *testing.T) {
+	sch, err := crypto.GetSchemeFromEnv()
+	require.NoError(t, err)
+	info, results := mock.VerifiableResults(3, sch)
+	watch := func(context.Context) <-chan drand.Result {
+		ch := make(chan drand.Result, len(results))
+		for i := range results {
+			ch <- &results[i]
+		}
+		close(ch)
+		return ch
+	}
+	c, err := client.Wrap(
+		[]drand.Client{&clientMock.Client{WatchF: watch, OptionalInfo: info}},
+		client.WithChainInfo(info),
+	)
+	require.NoError(t, err)
+	defer c.Close()
+
+	var rounds int
+	for r := range c.Watch(context.Background()) {
+		meta, ok := client.ResultMeta(r)
+		require.True(t, ok)
+		require.Equal(t, "Mock", meta.Source)
+		require.Empty(t, meta.Transport)
+		rounds++
+	}
+	require.Equal(t, len(results), rounds)
+}
+
+func TestClientBeaconID(t *testing.T) {
+	ctx := context.Background()
+	relay := clienttest.NewRelay(t, clienttest.WithRounds(3), clienttest.WithBeaconID("quicknet"))
+	hc, err := http.NewWithInfo(nil, relay.URL(), relay.Info(), nil)
+	require.NoError(t, err)
+
+	_, err = client.New(client.From(hc), client.WithChainInfo(relay.Info()), client.WithBeaconID("evmnet"))
+	require.ErrorIs(t, err, drand.ErrBeaconIDMismatch)
+
+	c, err := client.New(client.From(hc), client.WithChainInfo(relay.Info()), client.WithBeaconID("quicknet"))
+	require.NoError(t, err)
+	defer c.Close()
+	_, err = c.Get(ctx, 2)
+	require.NoError(t, err)
+}
+
+func TestClientRequiredScheme(t *testing.T) {
+	ctx := context.Background()
+	relay := clienttest.NewRelay(t, clienttest.WithRounds(3))
+	hc, err := http.NewWithInfo(nil, relay.URL(), relay.Info(), nil)
+	require.NoError(t, err)
+
+	_, err = client.New(client.From(hc), client.WithChainInfo(relay.Info()), client.WithRequiredScheme("no-such-scheme"))
+	require.Error(t, err)
+
+	other := crypto.DefaultSchemeID
+	if relay.Info().Scheme == other {
+		other = crypto.UnchainedSchemeID
+	}
+	_, err = client.New(client.From(hc), client.WithChainInfo(relay.Info()), client.WithRequiredScheme(other))
+	require.ErrorIs(t, err, drand.ErrSchemeMismatch)
+
+	c, err := client.New(client.From(hc), client.WithChainInfo(relay.Info()), client.WithRequiredScheme(relay.Info().Scheme))
+	require.NoError(t, err)
+	defer c.Close()
+	_, err = c.Get(ctx, 2)
+	require.NoError(t, err)
+}
+
+func TestClientInfoCrossCheck(t *testing.T) {
+	ctx := context.Background()
+	a := clienttest.NewRelay(t)
+	b := clienttest.NewRelay(t)
+	newHTTP := func(relay *clienttest.Relay) drand.Client {
+		hc, err := http.New(ctx, nil, relay.URL(), nil, nil)
+		require.NoError(t, err)
+		return hc
+	}
+
+	c, err := client.New(client.From(newHTTP(a), newHTTP(a)), client.Insecurely(), client.WithInfoCrossCheck())
+	require.NoError(t, err)
+	_ = c.Close()
+
+	_, err = client.New(client.From(newHTTP(a), newHTTP(b)), client.Insecurely(), client.WithInfoCrossCheck())
+	require.ErrorIs(t, err, drand.ErrInconsistentChainInfo)
+	require.ErrorContains(t, err, "public key")
+
+	c, err = client.New(client.From(newHTTP(a), newHTTP(b)), client.Insecurely())
+	require.NoError(t, err)
+	_ = c.Close()
+}
+
+func TestClientInfoConsensus(t *testing.T) {
+	ctx := context.Background()
+	a := clienttest.NewRelay(t)
+	b := clienttest.NewRelay(t)
+	newHTTP := func(relay *clienttest.Relay) drand.Client {
+		hc, err := http.New(ctx, nil, relay.URL(), nil, nil)
+		require.NoError(t, err)
+		return hc
+	}
+
+	// the beacons of the relays only verify against the chain info of their own relay
+	var dropped atomic.Bool
+	disagreeing := &clientMock.Client{OptionalInfo: b.Info(), CloseF: func() error {
+		dropped.Store(true)
+		return nil
+	}}
+	c, err := client.New(client.From(disagreeing, newHTTP(a), newHTTP(a)), client.Insecurely(), client.WithInfoConsensus())
+	require.NoError(t, err)
+	require.True(t, dropped.Load())
+	r, err := c.Get(ctx, 1)
+	require.NoError(t, err)
+	require.Equal(t, a.Result(1).GetRandomness(), r.GetRandomness())
+	_ = c.Close()
+
+	unreachable := clientMock.ClientWithResults(1, 2)
+	c, err = client.New(client.From(unreachable, newHTTP(b), newHTTP(b)), client.Insecurely(), client.WithInfoConsensus())
+	require.NoError(t, err)
+	r, err = c.Get(ctx, 1)
+	require.NoError(t, err)
+	require.Equal(t, b.Result(1).GetRandomness(), r.GetRandomness())
+	_ = c.Close()
+
+	// the sources failing to answer count against the chain of the others
+	_, err = client.New(client.From(unreachable, newHTTP(b)), client.Insecurely(), client.WithInfoConsensus())
+	require.ErrorIs(t, err, drand.ErrInconsistentChainInfo)
+
+	_, err = client.New(client.From(newHTTP(a), newHTTP(b), unreachable), client.Insecurely(), client.WithInfoConsensus())
+	require.ErrorIs(t, err, drand.ErrInconsistentChainInfo)
+	require.ErrorContains(t, err, b.Info().HashString())
+
+	_, err = client.New(client.From(unreachable), client.Insecurely(), client.WithInfoConsensus())
+	require.Error(t, err)
+}
+
+func TestClientUserAgent(t *testing.T) {
+	relay := clienttest.NewRelay(t)
+	agents := make(chan string, 10)
+	server := httptest.NewServer(nhttp.HandlerFunc(func(w nhttp.ResponseWriter, r *nhttp.Request) {
+		select {
+		case agents <- r.UserAgent():
+		default:
+		}
+		relay.ServeHTTP(w, r)
+	}))
+	defer server.Close()
+
+	hc, err := http.NewWithInfo(nil, server.URL, relay.Info(), nil)
+	require.NoError(t, err)
+	c, err := client.New(client.From(hc), client.WithChainInfo(relay.Info()), client.WithUserAgent("monitor/1.0"))
+	require.NoError(t, err)
+	defer c.Close()
+
+	_, err = c.Get(context.Background(), 1)
+	require.NoError(t, err)
+	require.Equal(t, "monitor/1.0", <-agents)
+}
+
+func TestClientWatchFiltered(t *testing.T) {
+	sch, err := crypto.GetSchemeFromEnv()
+	require.NoError(t, err)
+	info, results := mock.VerifiableResults(6, sch)
+
+	var expected []uint64
+	for i := range results {
+		if results[i].GetRound()%2 == 0 {
+			expected = append(expected, results[i].GetRound())
+		}
+	}
+
+	// the passive watch of the client and the filtered one each get all the results
+	watch := func(context.Context) <-chan drand.Result {
+		ch := make(chan drand.Result, len(results))
+		for i := range results {
+			ch <- &results[i]
+		}
+		close(ch)
+		return ch
+	}
+	watcherCtor := func(log.Logger, *chain.Info, client.Cache) (client.Watcher, error) {
+		return &clientMock.Client{WatchF: watch}, nil
+	}
+	c, err := client.New(client.WithChainInfo(info), client.WithWatcher(watcherCtor))
+	require.NoError(t, err)
+	defer c.Close()
+
+	var rounds []uint64
+	for r := range client.WatchFiltered(context.Background(), c, client.EveryNthRound(2)) {
+		rounds = append(rounds, r.GetRound())
+	}
+	require.Equal(t, expected, rounds)
+	require.Equal(t, expected[len(expected)-1], c.(drand.StatusProvider).Status().LatestRound)
+}
+
+// resumableMock is a mock source able to resume watches from a round.
+type resumableMock struct {
+	*clientMock.Client
+	results []mock.Result
+	from    atomic.Uint64
+}
+
+func (m *resumableMock) WatchFrom(_ context.Context, round uint64) <-chan drand.Result {
+	m.from.Store(round)
+	ch := make(chan drand.Result, len(m.results))
+	for i := range m.results {
+		if m.results[i].GetRound() >= round {
+			ch <- &m.results[i]
+		}
+	}
+	close(ch)
+	return ch
+}
+
+func TestClientWatchResumesGaps(t *testing.T) {
+	sch, err := crypto.GetSchemeFromEnv()
+	require.NoError(t, err)
+	info, results := mock.VerifiableResults(5, sch)
+
+	// the watch misses rounds 3 and 4, which the source cannot Get
+	watch := make(chan drand.Result, 3)
+	for _, i := range []int{0, 1, 4} {
+		watch <- &results[i]
+	}
+	close(watch)
+	source := &resumableMock{Client: &clientMock.Client{OptionalInfo: info, WatchCh: watch}, results: results}
+
+	c, err := client.New(client.From(source), client.WithChainInfo(info))
+	require.NoError(t, err)
+	defer c.Close()
+
+	var rounds []uint64
+	for r := range c.Watch(context.Background()) {
+		rounds = append(rounds, r.GetRound())
+	}
+	require.Equal(t, []uint64{1, 2, 3, 4, 5}, rounds)
+	require.Equal(t, uint64(3), source.from.Load())
+}
+
+func TestRoundFilters(t *testing.T) {
+	every := client.EveryNthRound(20)
+	require.True(t, every(40))
+	require.False(t, every(41))
+	require.True(t, client.EveryNthRound(0)(41))
+
+	in := client.RoundsIn(3, 5)
+	require.True(t, in(3))
+	require.True(t, in(5))
+	require.False(t, in(4))
+
+	// clients not implementing FilteredWatcher are filtered as results are received
+	for _, tc := range []struct {
+		filter   drand.RoundFilter
+		expected []uint64
+	}{
+		{client.RoundsIn(1), []uint64{1}},
+		{in, nil},
+	} {
+		var rounds []uint64
+		for r := range client.WatchFiltered(context.Background(), clientMock.ClientWithResults(1, 5), tc.filter) {
+			rounds = append(rounds, r.GetRound())
+		}
+		require.Equal(t, tc.expected, rounds)
+	}
+}
+
+func TestChainProperties(t *testing.T) {
+	c, err := client.New(client.From(clientMock.ClientWithInfo(chains.Quicknet())), client.WithKnownChain(chains.Quicknet))
+	require.NoError(t, err)
+	defer c.Close()
+
+	props, err := client.ChainProperties(context.Background(), c)
+	require.NoError(t, err)
+	require.Equal(t, &client.Properties{
+		Scheme:         crypto.SigsOnG1ID,
+		SignatureGroup: client.G1,
+		SignatureSize:  48,
+		Period:         3 * time.Second,
+	}, props)
+
+	props, err = client.PropertiesOf(chains.Default())
+	require.NoError(t, err)
+	require.Equal(t, &client.Properties{
+		Scheme:            crypto.DefaultSchemeID,
+		Chained:           true,
+		SignatureGroup:    client.G2,
+		SignatureSize:     96,
+		Period:            30 * time.Second,
+		PreviousSignature: true,
+	}, props)
+
+	_, err = client.PropertiesOf(&chain.Info{Scheme: "unknown"})
+	require.Error(t, err)
+}
+
+func TestClientStop(t *testing.T) {
+	sch, err := crypto.GetSchemeFromEnv()
+	require.NoError(t, err)
+	info, results := mock.VerifiableResults(3, sch)
+
+	var closed int
+	source := &clientMock.Client{
+		OptionalInfo: info,
+		Results:      results,
+		StrictRounds: true,
+		// a watch ignoring cancellation, which must not block stopping the client
+		WatchF: func(context.Context) <-chan drand.Result {
+			return make(chan drand.Result)
+		},
+		CloseF: func() error {
+			closed++
+			return nil
+		},
+	}
+	c, err := client.New(client.From(source), client.WithChainInfo(info), client.WithAutoWatch())
+	require.NoError(t, err)
+
+	w := c.Watch(context.Background())
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	require.NoError(t, c.(drand.Stopper).Stop(ctx))
+	_, ok := <-w
+	require.False(t, ok, "watches end when the client is stopped")
+
+	// closing again is a no-op
+	require.NoError(t, c.Close())
+	require.Equal(t, 1, closed)
+
+	_, ok = <-c.Watch(context.Background())
+	require.False(t, ok, "watches of a stopped client are closed")
+}
+
+func TestClientCloseErrors(t *testing.T) {
+	sch, err := crypto.GetSchemeFromEnv()
+	require.NoError(t, err)
+	info, results := mock.VerifiableResults(3, sch)
+
+	errA := errors.New("leaked connection a")
+	errB := errors.New("leaked connection b")
+	newSource := func(closeErr error) drand.Client {
+		return &clientMock.Client{
+			OptionalInfo: info,
+			Results:      results,
+			StrictRounds: true,
+			CloseF: func() error {
+				return closeErr
+			},
+		}
+	}
+	c, err := client.New(client.From(newSource(errA), newSource(nil), newSource(errB)),
+		client.WithChainInfo(info), client.WithCacheSize(8))
+	require.NoError(t, err)
+
+	err = c.Close()
+	require.ErrorIs(t, err, errA)
+	require.ErrorIs(t, err, errB)
+}
+
+// countingClient counts the calls to Get for a round of the wrapped client.
+type countingClient struct {
+	drand.Client
+	round uint64
+	gets  atomic.Int64
+}
+
+func (c *countingClient) Get(ctx context.Context, round uint64) (drand.Result, error) {
+	if round == c.round {
+		c.gets.Add(1)
+	}
+	return c.Client.Get(ctx, round)
+}
+
+func TestClientCallOptions(t *testing.T) {
+	ctx := context.Background()
+	sch, err := crypto.GetSchemeFromEnv()
+	require.NoError(t, err)
+	info, results := mock.VerifiableResults(3, sch)
+	results[1].Sig = results[0].Sig
+
+	source := &countingClient{Client: &clientMock.Client{OptionalInfo: info, Results: results, StrictRounds: true}, round: 3}
+	// round 3 was produced 1 second ago
+	clk := clock.NewFakeClockAt(time.Unix(info.GenesisTime+3, 0))
+	c, err := client.New(client.From(source), client.WithChainInfo(info), client.WithClock(clk))
+	require.NoError(t, err)
+	defer c.Close()
+
+	_, err = client.Get(ctx, c, 3, client.RequireFreshWithin(2*info.Period))
+	require.NoError(t, err)
+	_, err = client.Get(ctx, c, 3, client.RequireFreshWithin(info.Period/2))
+	require.ErrorIs(t, err, drand.ErrStaleResult)
+
+	gets := source.gets.Load()
+	_, err = c.Get(ctx, 3)
+	require.NoError(t, err)
+	require.Equal(t, gets, source.gets.Load(), "round 3 is cached")
+	_, err = client.Get(ctx, c, 3, client.SkipCache())
+	require.NoError(t, err)
+	require.Equal(t, gets+1, source.gets.Load())
+
+	// round 2 has an invalid signature
+	_, err = c.Get(ctx, 2)
+	require.Error(t, err)
+	r, err := client.Get(ctx, c, 2, client.SkipVerification())
+	require.NoError(t, err)
+	require.Equal(t, uint64(2), r.GetRound())
+	_, err = c.Get(ctx, 2)
+	require.Error(t, err, "unverified results are not cached")
+}
+
+// refreshingClient serves a chain info which can be changed, as a relay
+// migrating to another chain would.
+type refreshingClient struct {
+	drand.Client
+	served    atomic.Pointer[chain.Info]
+	refreshes atomic.Int64
+}
+
+func (c *refreshingClient) String() string {
+	return "Refreshing"
+}
+
+func (c *refreshingClient) RefreshChainInfo(_ context.Context) (*chain.Info, error) {
+	c.refreshes.Add(1)
+	return c.served.Load(), nil
+}
+
+func TestClientInfoRefresh(t *testing.T) {
+	ctx := context.Background()
+	sch, err := crypto.GetSchemeFromEnv()
+	require.NoError(t, err)
+	info, results := mock.VerifiableResults(3, sch)
+
+	source := &refreshingClient{Client: &clientMock.Client{OptionalInfo: info, Results: results, StrictRounds: true}}
+	source.served.Store(info)
+	clk := clock.NewFakeClockAt(time.Unix(info.GenesisTime+3, 0))
+	changes := make(chan *client.ChainChangedError, 4)
+	c, err := client.New(client.From(source), client.WithChainInfo(info), client.WithClock(clk),
+		client.WithInfoRefresh(time.Minute, func(err *client.ChainChangedError) { changes <- err }))
+	require.NoError(t, err)
+	defer c.Close()
+
+	// refresh waits for the client to refresh the chain info of its source n more times.
+	refresh := func(n int64) {
+		target := source.refreshes.Load() + n
+		require.Eventually(t, func() bool {
+			clk.Advance(time.Minute)
+			return source.refreshes.Load() >= target
+		}, 5*time.Second, 10*time.Millisecond)
+	}
+
+	refresh(2)
+	require.Empty(t, changes)
+	_, err = client.Get(ctx, c, 1, client.SkipCache())
+	require.NoError(t, err)
+
+	other := fakeChainInfo(t)
+	source.served.Store(other)
+	refresh(1)
+	var changed *client.ChainChangedError
+	select {
+	case changed = <-changes:
+	case <-time.After(5 * time.Second):
+		t.Fatal("chain change not notified")
+	}
+	require.ErrorIs(t, changed, drand.ErrInvalidChainHash)
+	require.Equal(t, info.Hash(), changed.Expected.Hash())
+	require.Equal(t, other.Hash(), changed.Served.Hash())
+
+	_, err = client.Get(ctx, c, 1, client.SkipCache())
+	require.ErrorAs(t, err, &changed)
+
+	refresh(2)
+	require.Empty(t, changes, "a change is notified once")
+
+	source.served.Store(info)
+	refresh(1)
+	_, err = client.Get(ctx, c, 1, client.SkipCache())
+	require.NoError(t, err)
+}
+
+func TestClientSchedule(t *testing.T) {
+	sch, err := crypto.GetSchemeFromEnv()
+	require.NoError(t, err)
+	info, results := mock.VerifiableResults(3, sch)
+	clk := clock.NewFakeClockAt(time.Unix(info.GenesisTime+3, 0))
+	c, err := client.New(client.From(&clientMock.Client{OptionalInfo: info, Results: results}),
+		client.WithChainInfo(info), client.WithClock(clk))
+	require.NoError(t, err)
+	defer c.Close()
+	s, ok := c.(drand.Scheduler)
+	require.True(t, ok)
+
+	round, at := s.NextRoundTime(time.Unix(info.GenesisTime, 0).Add(info.Period / 2))
+	require.Equal(t, uint64(2), round)
+	require.Equal(t, time.Unix(info.GenesisTime, 0).Add(info.Period), at)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	ticks := s.Schedule(ctx)
+	next := func() drand.RoundTick {
+		var tick drand.RoundTick
+		require.Eventually(t, func() bool {
+			select {
+			case tick = <-ticks:
+				return true
+			default:
+				clk.Advance(info.Period / 2)
+				return false
+			}
+		}, 5*time.Second, 10*time.Millisecond)
+		return tick
+	}
+	first := next()
+	require.Equal(t, time.Unix(common.TimeOfRound(info.Period, info.GenesisTime, first.Round), 0), first.Time)
+	second := next()
+	require.Greater(t, second.Round, first.Round)
+
+	cancel()
+	require.Eventually(t, func() bool {
+		_, ok := <-ticks
+		return !ok
+	}, 5*time.Second, 10*time.Millisecond)
+
+	require.NoError(t, c.Close())
+	_, ok = <-s.Schedule(context.Background())
+	require.False(t, ok, "schedules of a stopped client are closed")
+}
+
+func TestClientImmediateFirstResult(t *testing.T) {
+	sch, err := crypto.GetSchemeFromEnv()
+	require.NoError(t, err)
+	info, results := mock.VerifiableResults(4, sch)
+
+	// round 3 is the latest, and the watch starts from round 2
+	watchCh := make(chan drand.Result, len(results))
+	source := &clientMock.Client{
+		OptionalInfo: info,
+		Results:      append([]mock.Result{results[2]}, results...),
+		StrictRounds: true,
+		WatchCh:      watchCh,
+	}
+	c, err := client.New(client.From(source), client.WithChainInfo(info), client.WithImmediateFirstResult())
+	require.NoError(t, err)
+	defer c.Close()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	w := c.Watch(ctx)
+	next := func() drand.Result {
+		select {
+		case r := <-w:
+			return r
+		case <-time.After(5 * time.Second):
+			t.Fatal("timed out waiting for result")
+			return nil
+		}
+	}
+	compareResults(t, &results[2], next())
+
+	for i := 1; i < len(results); i++ {
+		watchCh <- &results[i]
+	}
+	compareResults(t, &results[3], next())
+	select {
+	case r := <-w:
+		t.Fatalf("unexpected round %d", r.GetRound())
+	case <-time.After(50 * time.Millisecond):
+	}
+
+	cancel()
+	require.Eventually(t, func() bool {
+		_, ok := <-w
+		return !ok
+	}, 5*time.Second, 10*time.Millisecond)
+}
+
+func TestClientAuditLog(t *testing.T) {
+	ctx := context.Background()
+	sch, err := crypto.GetSchemeFromEnv()
+	require.NoError(t, err)
+	info, results := mock.VerifiableResults(3, sch)
+	bad := results[2]
+	bad.Sig = bytes.Clone(bad.Sig)
+	bad.Sig[0] ^= 0xff
+	source := &clientMock.Client{OptionalInfo: info, Results: append(results[:2:2], bad), StrictRounds: true}
+
+	var auditLog bytes.Buffer
+	// the speed tests would verify rounds too
+	c, err := client.New(client.From(source), client.WithChainInfo(info), client.WithAuditLog(&auditLog),
+		client.WithSpeedTestInterval(-1))
+	require.NoError(t, err)
+	defer c.Close()
+
+	_, err = c.Get(ctx, 1)
+	require.NoError(t, err)
+	_, err = client.Get(ctx, c, 2, client.SkipVerification())
+	require.NoError(t, err)
+	_, err = c.Get(ctx, 3)
+	require.Error(t, err)
+
+	var entries []client.AuditEntry
+	for _, line := range bytes.Split(bytes.TrimSpace(auditLog.Bytes()), []byte("\n")) {
+		var e client.AuditEntry
+		require.NoError(t, json.Unmarshal(line, &e))
+		entries = append(entries, e)
+	}
+	require.Len(t, entries, 3)
+	for i, outcome := range []string{client.AuditVerified, client.AuditSkipped, client.AuditFailed} {
+		require.Equal(t, uint64(i+1), entries[i].Round)
+		require.Equal(t, outcome, entries[i].Outcome)
+		require.Equal(t, fmt.Sprint(source), entries[i].Source)
+	}
+	require.Equal(t, hex.EncodeToString(results[0].Sig), entries[0].Signature)
+	require.NotEmpty(t, entries[2].Error)
+	require.Empty(t, entries[0].Prev)
+	require.NotEmpty(t, entries[1].Prev)
+
+	n, err := client.VerifyAuditLog(bytes.NewReader(auditLog.Bytes()))
+	require.NoError(t, err)
+	require.Equal(t, 3, n)
+
+	tampered := bytes.Replace(auditLog.Bytes(), []byte(`"outcome":"skipped"`), []byte(`"outcome":"verified"`), 1)
+	_, err = client.VerifyAuditLog(bytes.NewReader(tampered))
+	require.ErrorIs(t, err, client.ErrAuditLogTampered)
+
+	// blanking the link of the entry following an edited one does not hide the edit
+	tampered = bytes.Replace(tampered, []byte(`"prev":"`+entries[2].Prev+`"`), []byte(`"prev":""`), 1)
+	_, err = client.VerifyAuditLog(bytes.NewReader(tampered))
+	require.ErrorIs(t, err, client.ErrAuditLogTampered)
+
+	// a restarted client chains on the last line of the log
+	head, err := client.AuditLogHead(bytes.NewReader(auditLog.Bytes()))
+	require.NoError(t, err)
+	c2, err := client.New(client.From(source), client.WithChainInfo(info), client.WithAuditLogFrom(&auditLog, head),
+		client.WithSpeedTestInterval(-1))
+	require.NoError(t, err)
+	defer c2.Close()
+	_, err = c2.Get(ctx, 1)
+	require.NoError(t, err)
+	n, err = client.VerifyAuditLog(bytes.NewReader(auditLog.Bytes()))
+	require.NoError(t, err)
+	require.Equal(t, 4, n)
+}
+
+func TestClientSharedCacheChains(t *testing.T) {
+	ctx := context.Background()
+	sch, err := crypto.GetSchemeFromEnv()
+	require.NoError(t, err)
+	infoA, resultsA := mock.VerifiableResults(1, sch)
+	infoB, resultsB := mock.VerifiableResults(1, sch)
+	sourceA := &clientMock.Client{OptionalInfo: infoA, Results: resultsA, StrictRounds: true}
+	sourceB := &clientMock.Client{OptionalInfo: infoB, Results: resultsB, StrictRounds: true}
+
+	shared, err := client.NewSharedCache(8)
+	require.NoError(t, err)
+	a, err := client.New(client.From(sourceA), client.WithChainInfo(infoA), client.WithCache(shared))
+	require.NoError(t, err)
+	defer a.Close()
+	b, err := client.New(client.From(sourceB), client.WithChainInfo(infoB), client.WithCache(shared))
+	require.NoError(t, err)
+	defer b.Close()
+
+	res, err := a.Get(ctx, 1)
+	require.NoError(t, err)
+	require.Equal(t, resultsA[0].Sig, res.GetSignature())
+	res, err = b.Get(ctx, 1)
+	require.NoError(t, err)
+	require.Equal(t, resultsB[0].Sig, res.GetSignature())
+	require.Equal(t, resultsA[0].Sig, client.ForChain(shared, infoA.Hash()).TryGet(1).GetSignature())
+	require.Equal(t, resultsB[0].Sig, client.ForChain(shared, infoB.Hash()).TryGet(1).GetSignature())
+
+	// a cache which does not key its results by chain holds a result of
+	// chain A, which chain B does not verify
+	poisoned := cache.NewMapCache()
+	poisoned.Add(1, &resultsA[0])
+	c, err := client.New(client.From(sourceB), client.WithChainInfo(infoB), client.WithCache(poisoned))
+	require.NoError(t, err)
+	defer c.Close()
+	res, err = c.Get(ctx, 1)
+	require.NoError(t, err)
+	require.Equal(t, resultsB[0].Sig, res.GetSignature())
+}
+
+func TestClientUpstreamTimeout(t *testing.T) {
+	ctx := context.Background()
+	sch, err := crypto.GetSchemeFromEnv()
+	require.NoError(t, err)
+	info, results := mock.VerifiableResults(1, sch)
+	source := &clientMock.Client{OptionalInfo: info, Results: results, StrictRounds: true, Delay: time.Minute}
+
+	_, err = client.New(client.From(source), client.WithChainInfo(info), client.WithUpstreamTimeout(-time.Second))
+	require.Error(t, err)
+
+	c, err := client.New(client.From(source), client.WithChainInfo(info),
+		client.WithUpstreamTimeout(50*time.Millisecond), client.WithVerifyTimeout(time.Second), client.WithInfoTimeout(time.Second))
+	require.NoError(t, err)
+	defer c.Close()
+
+	start := time.Now()
+	_, err = c.Get(ctx, 1)
+	require.ErrorIs(t, err, context.DeadlineExceeded)
+	require.Less(t, time.Since(start), time.Second)
+}
+
+func TestClientAddRemoveSource(t *testing.T) {
+	ctx := context.Background()
+	relay := clienttest.NewRelay(t, clienttest.WithRounds(5))
+	mirror := httptest.NewServer(relay)
+	defer mirror.Close()
+
+	hc, err := http.NewWithInfo(nil, relay.URL(), relay.Info(), nil)
+	require.NoError(t, err)
+	c, err := client.New(client.From(hc), client.WithChainInfo(relay.Info()))
+	require.NoError(t, err)
+	defer c.Close()
+	sm, ok := c.(drand.SourceManager)
+	require.True(t, ok)
+
+	mc, err := http.NewWithInfo(nil, mirror.URL, relay.Info(), nil)
+	require.NoError(t, err)
+	require.NoError(t, sm.AddSource(mc))
+	require.Error(t, sm.AddSource(mc))
+
+	stats := c.(drand.UpstreamReporter).UpstreamStats()
+	require.Len(t, stats, 2)
+	require.NoError(t, sm.RemoveSource(stats[0].Upstream))
+	require.ErrorIs(t, sm.RemoveSource(stats[0].Upstream), drand.ErrUnknownSource)
+
+	_, err = c.Get(ctx, 3)
+	require.NoError(t, err)
+	remaining := c.(drand.UpstreamReporter).UpstreamStats()
+	require.Len(t, remaining, 1)
+	require.Equal(t, stats[1].Upstream, remaining[0].Upstream)
+	require.Positive(t, remaining[0].RoundsServed)
+}
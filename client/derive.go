@@ -0,0 +1,84 @@
+package client
+
+import (
+	"crypto/hkdf"
+	"crypto/sha256"
+	"crypto/sha512"
+	"fmt"
+
+	"github.com/drand/go-clients/drand"
+)
+
+// Derivation is a function deriving randomness from the signature of a round.
+type Derivation int
+
+const (
+	// SHA256Derivation is the legacy derivation of drand, returning the
+	// SHA-256 hash of the signature, as GetRandomness does.
+	SHA256Derivation Derivation = iota
+	// SHA512Derivation returns the SHA-512 hash of the signature, for
+	// applications needing 64 bytes.
+	SHA512Derivation
+	// HKDFDerivation expands the signature with HKDF-SHA256, for any output
+	// length up to 8160 bytes and domain separation through its info context.
+	HKDFDerivation
+)
+
+func (d Derivation) String() string {
+	switch d {
+	case SHA256Derivation:
+		return "sha256"
+	case SHA512Derivation:
+		return "sha512"
+	case HKDFDerivation:
+		return "hkdf-sha256"
+	default:
+		return fmt.Sprintf("Derivation(%d)", int(d))
+	}
+}
+
+// DeriveRandomness derives n bytes of randomness from the signature of r with
+// d. The info context is only used by HKDFDerivation, and must be distinct for
+// each use of the randomness of a round that should be independent from the
+// others. For the hash derivations, n may be 0 to get the full hash, and the
+// hash is truncated to n bytes otherwise.
+func DeriveRandomness(r drand.Result, d Derivation, info []byte, n int) ([]byte, error) {
+	sig := r.GetSignature()
+	if len(sig) == 0 {
+		return nil, fmt.Errorf("round %d has no signature to derive randomness from", r.GetRound())
+	}
+	if n < 0 {
+		return nil, fmt.Errorf("invalid randomness length %d", n)
+	}
+
+	var out []byte
+	switch d {
+	case SHA256Derivation:
+		h := sha256.Sum256(sig)
+		out = h[:]
+	case SHA512Derivation:
+		h := sha512.Sum512(sig)
+		out = h[:]
+	case HKDFDerivation:
+		if n == 0 {
+			return nil, fmt.Errorf("a randomness length is required with %s", d)
+		}
+		return hkdf.Key(sha256.New, sig, nil, string(info), n)
+	default:
+		return nil, fmt.Errorf("unknown derivation %s", d)
+	}
+
+	if n > len(out) {
+		return nil, fmt.Errorf("%s derives at most %d bytes, %d requested", d, len(out), n)
+	}
+	if n > 0 {
+		out = out[:n]
+	}
+	return out, nil
+}
+
+// DeriveRandomness derives n bytes of randomness from the round for the
+// application context info with HKDF-SHA256, see HKDFDerivation.
+func (r *RandomData) DeriveRandomness(info []byte, n int) ([]byte, error) {
+	return DeriveRandomness(r, HKDFDerivation, info, n)
+}
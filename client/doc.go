@@ -13,11 +13,51 @@ multiple clients of the same type or of different types. The base client will
 periodically "speed test" it's clients, failover, cache results and aggregate
 calls to "Watch" to reduce requests.
 
-WARNING: When using the client you should use the "WithChainHash" or
-"WithChainInfo" option in order for your client to validate the randomness it
-receives is from the correct chain. You may use the "Insecurely" option to
+WARNING: When using the client you should use the "WithChainHash",
+"WithChainInfo" or "WithKnownChain" option in order for your client to
+validate the randomness it receives is from the correct chain. You may use the "Insecurely" option to
 bypass this validation but it is not recommended.
 
+Applications which should not wait for the relays to start, e.g. to serve
+their other features during an outage of the relays, can use NewAsync, which
+sets the client up in the background and reports when it is ready.
+
+Applications only needing some rounds, e.g. one a minute on a 3 second chain,
+can use WatchFiltered with a filter such as EveryNthRound or RoundsIn, so that
+the other rounds are not verified nor delivered.
+
+Applications consuming values derived from the new rounds rather than the
+rounds themselves can use Map and Reduce, e.g. Map(ctx, c, IntN(6)) to roll a
+die each round, instead of transforming the results of Watch themselves.
+
+Applications only needing the timing of the chain, e.g. to schedule work at
+round boundaries, can use the drand.Scheduler methods of the client, which
+tick at the start of each round without fetching it.
+
+Applications discovering their relays dynamically, e.g. from a service
+discovery system, can add and remove the sources of a running client with its
+drand.SourceManager methods.
+
+Options of a single Get can be given with the Get function, e.g. to fail with
+drand.ErrStaleResult when the result is older than RequireFreshWithin, or to
+bypass the cache with SkipCache.
+
+The channels returned by Watch deliver rounds in increasing order, each of them
+at most once. The rounds the sources skipped are fetched, or streamed from a
+source implementing drand.ResumableWatcher such as a gRPC client, and
+delivered in order, unless WithoutGapFilling is set, and no round is dropped
+as long as the application keeps up with the channel. Otherwise, rounds are dropped rather
+than delivered late, and the Watch function reports them with OnLag, e.g.
+
+	results := client.Watch(ctx, c, client.OnLag(func(l client.Lag) {
+		log.Printf("missed rounds %d to %d", l.From, l.To)
+	}))
+
+The results delivered by the client carry the source which served them, how
+late after the time of their round they were received, and how long verifying
+them took, which ResultMeta returns, e.g. to track the latency of the relays
+from the application.
+
 In an application that uses the drand client, the following options are likely
 to be needed/customized:
 
@@ -29,10 +69,62 @@ to be needed/customized:
 		both should be set for increased security if you have
 		persistent state and expect to be following the chain.
 
+	WithInfoCrossCheck()
+		makes sure all the sources advertise the same chain when
+		relying on them for the chain info, e.g. with Insecurely().
+
+	WithInfoConsensus()
+		takes the chain advertised by a majority of the sources, and
+		drops the others, rather than the one of the first source.
+
+	WithRequiredScheme()
+		fails when the sources serve a chain of another signature
+		scheme than the expected one, e.g. a chained one for quicknet.
+
+	WithInfoRefresh()
+		periodically checks that the sources still serve the trusted
+		chain, e.g. for long-running processes outliving a migration
+		of their relays, and notifies the application otherwise.
+
+	WithForkCheck()
+		periodically checks that all the sources serve the same
+		signatures, to detect a relay serving a fork or corrupted
+		beacons, and notifies the application otherwise.
+
+	WithRelayList()
+		follows a list of relays published by their operator, e.g.
+		with http.WithRelayList, for long-running processes to pick
+		up the changes of a relay fleet without restarting.
+
+	WithImmediateFirstResult()
+		makes watches start with the latest round, instead of
+		calling Get before Watch.
+
 	WithAutoWatch()
 		will pre-load new results as they become available adding them
 		to the cache for speedy retreival when you need them.
 
+	WithWatchFailoverGracePeriod()
+		makes watches rely on a pubsub watcher, and only poll the
+		HTTP relays when it falls behind, to lower their load.
+
+	WithVerificationConcurrency()
+		verifies results on several cores, to keep up with
+		high-frequency chains and to catch up faster.
+
+	WithAuditLog()
+		keeps a tamper-evident trail of the verified results, and of
+		the sources which served them, for compliance purposes.
+
+	WithHedging()
+		asks the sources one at a time, and the next one only when
+		the previous one is late, to lower the load of the relays
+		while bounding the latency during their brownouts.
+
+	WithRateLimit()
+		limits the requests made to each relay, to comply with the
+		quotas of public endpoints.
+
 	WithPrometheus()
 		enables metrics reporting on speed and performance to a
 		provided prometheus registry.
@@ -0,0 +1,65 @@
+package client
+
+import (
+	"context"
+
+	"github.com/drand/go-clients/drand"
+)
+
+// roundFilterKey carries the round filter of a watch down to the verifying
+// clients, so that they skip the rounds it rejects before verifying them.
+type roundFilterKey struct{}
+
+// EveryNthRound selects the rounds that are a multiple of n, e.g. one round a
+// minute on a 3 second chain with n = 20. It selects every round if n is 0.
+func EveryNthRound(n uint64) drand.RoundFilter {
+	return func(round uint64) bool {
+		return n == 0 || round%n == 0
+	}
+}
+
+// RoundsIn selects the given rounds.
+func RoundsIn(rounds ...uint64) drand.RoundFilter {
+	set := make(map[uint64]struct{}, len(rounds))
+	for _, r := range rounds {
+		set[r] = struct{}{}
+	}
+	return func(round uint64) bool {
+		_, ok := set[round]
+		return ok
+	}
+}
+
+// WatchFiltered returns the new randomness of c for the rounds selected by
+// filter. Clients implementing drand.FilteredWatcher skip the other rounds
+// before verifying them; the results of other clients are filtered as they
+// are received.
+func WatchFiltered(ctx context.Context, c drand.Client, filter drand.RoundFilter) <-chan drand.Result {
+	if fw, ok := c.(drand.FilteredWatcher); ok {
+		return fw.WatchFiltered(ctx, filter)
+	}
+	return filterResults(ctx, c.Watch(ctx), filter, nil)
+}
+
+// filterResults forwards the results of in selected by filter, calling
+// observe, if set, on each of them.
+func filterResults(ctx context.Context, in <-chan drand.Result, filter drand.RoundFilter, observe func(drand.Result)) <-chan drand.Result {
+	out := make(chan drand.Result, aggregatorWatchBuffer)
+	go func() {
+		defer close(out)
+		for r := range in {
+			if !filter(r.GetRound()) {
+				continue
+			}
+			if observe != nil {
+				observe(r)
+			}
+			select {
+			case out <- r:
+			case <-ctx.Done():
+				return
+			}
+		}
+	}()
+	return out
+}
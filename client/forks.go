@@ -0,0 +1,136 @@
+package client
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/drand/drand/v2/common"
+	"github.com/drand/go-clients/drand"
+	"github.com/drand/go-clients/internal/metrics"
+)
+
+// ForkError means the sources of a client serve different signatures for the
+// same round, i.e. a source serves a fork of the chain or corrupted beacons.
+// It wraps drand.ErrForkedRound.
+type ForkError struct {
+	// Round is the round served with different signatures.
+	Round uint64
+	// Sources are the names of the sources which served the round, and
+	// Signatures the signature served by each of them.
+	Sources    []string
+	Signatures [][]byte
+}
+
+func (e *ForkError) Error() string {
+	served := make([]string, len(e.Sources))
+	for i, s := range e.Sources {
+		served[i] = fmt.Sprintf("%s serves %.8x", s, e.Signatures[i])
+	}
+	return fmt.Sprintf("%s: round %d: %s", drand.ErrForkedRound, e.Round, strings.Join(served, ", "))
+}
+
+func (e *ForkError) Unwrap() error {
+	return drand.ErrForkedRound
+}
+
+// CheckRound fetches round from the source of every verifier of the client,
+// bypassing verification and caching, and returns how many sources served it,
+// or a *ForkError if they do not all serve the same signature. Sources
+// failing to serve the round are skipped, and their errors returned when no
+// source served it.
+func (c *watchAggregator) CheckRound(ctx context.Context, round uint64) (int, error) {
+	if round == 0 {
+		return 0, errors.New("cannot check round 0")
+	}
+	verifiers := c.currentVerifiers()
+	sigs := make([][]byte, len(verifiers))
+	errs := make([]error, len(verifiers))
+	var wg sync.WaitGroup
+	for i, v := range verifiers {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			r, err := v.Client.Get(ctx, round)
+			if err != nil {
+				errs[i] = err
+				return
+			}
+			sigs[i] = r.GetSignature()
+		}()
+	}
+	wg.Wait()
+
+	var err error
+	var first []byte
+	forked := false
+	fork := &ForkError{Round: round}
+	for i, v := range verifiers {
+		if errs[i] != nil {
+			c.log.Debugw("", "watch_aggregator", "source did not serve round to check", "source", fmt.Sprint(v.Client), "round", round, "err", errs[i])
+			err = errors.Join(err, errs[i])
+			continue
+		}
+		if first == nil {
+			first = sigs[i]
+		} else if !bytes.Equal(first, sigs[i]) {
+			forked = true
+		}
+		fork.Sources = append(fork.Sources, fmt.Sprint(v.Client))
+		fork.Signatures = append(fork.Signatures, sigs[i])
+	}
+	if len(fork.Sources) == 0 {
+		return 0, err
+	}
+	if forked {
+		metrics.ClientForkedRounds.Inc()
+		return len(fork.Sources), fork
+	}
+	return len(fork.Sources), nil
+}
+
+// startForkCheck periodically checks that the sources of the client serve the
+// same signature for the round preceding the current one, until the client is
+// stopped.
+func (c *watchAggregator) startForkCheck(interval time.Duration, onFork func(*ForkError)) {
+	ctx, cancel := context.WithCancel(context.Background())
+	c.wg.Add(2)
+	go func() {
+		defer c.wg.Done()
+		<-c.stopping
+		cancel()
+	}()
+	go func() {
+		defer c.wg.Done()
+		for {
+			t := c.clock.NewTimer(interval)
+			select {
+			case <-t.Chan():
+			case <-ctx.Done():
+				t.Stop()
+				return
+			}
+			// the previous round should be served by all the sources by now
+			round := common.CurrentRound(c.clock.Now().Unix(), c.info.Period, c.info.GenesisTime)
+			if round < 2 {
+				continue
+			}
+			cctx, ccancel := context.WithTimeout(ctx, interval)
+			_, err := c.CheckRound(cctx, round-1)
+			ccancel()
+			var fork *ForkError
+			if errors.As(err, &fork) {
+				c.log.Errorw("", "watch_aggregator", "sources serve different signatures", "err", fork)
+				if onFork != nil {
+					onFork(fork)
+				}
+			} else if err != nil {
+				c.log.Warnw("", "watch_aggregator", "failed to check round", "round", round-1, "err", err)
+			}
+		}
+	}()
+}
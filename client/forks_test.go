@@ -0,0 +1,83 @@
+package client
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	clock "github.com/jonboulle/clockwork"
+	"github.com/stretchr/testify/require"
+
+	"github.com/drand/drand/v2/crypto"
+	clientMock "github.com/drand/go-clients/client/mock"
+	"github.com/drand/go-clients/client/test/result/mock"
+	"github.com/drand/go-clients/drand"
+)
+
+// forkedSources returns three sources serving results, the last of which
+// serves the signature of the first round for all the later rounds.
+func forkedSources(results []mock.Result) []drand.Client {
+	forked := make([]mock.Result, len(results))
+	copy(forked, results)
+	for i := 1; i < len(forked); i++ {
+		forked[i].Sig = forked[0].Sig
+	}
+	return []drand.Client{
+		&namedClient{&clientMock.Client{Results: results, StrictRounds: true}, "a"},
+		&namedClient{&clientMock.Client{Results: results, StrictRounds: true}, "b"},
+		&namedClient{&clientMock.Client{Results: forked, StrictRounds: true}, "forked"},
+	}
+}
+
+func TestClientCheckRound(t *testing.T) {
+	ctx := context.Background()
+	sch, err := crypto.GetSchemeFromEnv()
+	require.NoError(t, err)
+	info, results := mock.VerifiableResults(3, sch)
+
+	c, err := New(From(forkedSources(results)...), WithChainInfo(info))
+	require.NoError(t, err)
+	defer c.Close()
+	checker, ok := c.(drand.ForkChecker)
+	require.True(t, ok)
+
+	n, err := checker.CheckRound(ctx, 1)
+	require.NoError(t, err)
+	require.Equal(t, 3, n)
+
+	n, err = checker.CheckRound(ctx, 2)
+	require.ErrorIs(t, err, drand.ErrForkedRound)
+	require.Equal(t, 3, n)
+	var fork *ForkError
+	require.ErrorAs(t, err, &fork)
+	require.Equal(t, uint64(2), fork.Round)
+	require.Equal(t, []string{"a", "b", "forked"}, fork.Sources)
+	require.Equal(t, results[1].Sig, fork.Signatures[0])
+	require.Equal(t, results[0].Sig, fork.Signatures[2])
+
+	_, err = checker.CheckRound(ctx, 0)
+	require.Error(t, err)
+}
+
+func TestClientForkCheck(t *testing.T) {
+	sch, err := crypto.GetSchemeFromEnv()
+	require.NoError(t, err)
+	info, results := mock.VerifiableResults(100, sch)
+
+	clk := clock.NewFakeClockAt(time.Unix(info.GenesisTime+3, 0))
+	forks := make(chan *ForkError, 4)
+	c, err := New(From(forkedSources(results)...), WithChainInfo(info), WithClock(clk),
+		WithForkCheck(time.Second, func(f *ForkError) { forks <- f }))
+	require.NoError(t, err)
+	defer c.Close()
+
+	require.Eventually(t, func() bool {
+		clk.Advance(time.Second)
+		return len(forks) > 0
+	}, 5*time.Second, 10*time.Millisecond)
+	fork := <-forks
+	require.Equal(t, []string{"a", "b", "forked"}, fork.Sources)
+
+	_, err = New(From(forkedSources(results)...), WithChainInfo(info), WithForkCheck(0, nil))
+	require.Error(t, err)
+}
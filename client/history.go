@@ -0,0 +1,126 @@
+package client
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"sort"
+
+	"github.com/BurntSushi/toml"
+	"github.com/drand/kyber"
+
+	"github.com/drand/drand/v2/common"
+	"github.com/drand/drand/v2/common/chain"
+	"github.com/drand/drand/v2/common/key"
+	"github.com/drand/go-clients/drand"
+)
+
+// ErrGroupHistoryUnsupported means the client does not expose the group transitions of its chain.
+var ErrGroupHistoryUnsupported = errors.New("client does not expose group history")
+
+// GroupHistoryProvider is implemented by clients able to retrieve the successive
+// group files a chain went through, for instance after resharings, such as the
+// clients of HTTP relays made with the http package.
+type GroupHistoryProvider interface {
+	GroupHistory(ctx context.Context) ([]*key.Group, error)
+}
+
+// GroupHistory is a verified sequence of groups which successively produced
+// the beacons of a chain.
+type GroupHistory struct {
+	info   *chain.Info
+	groups []*key.Group
+}
+
+// NewGroupHistory verifies that the given groups are a valid transition history
+// for the chain described by info: every group must advertise the same chain
+// parameters and distributed public key as the chain info, and their transition
+// times must be distinct. Groups can be passed in any order.
+func NewGroupHistory(info *chain.Info, groups ...*key.Group) (*GroupHistory, error) {
+	if info == nil {
+		return nil, errors.New("chain info cannot be nil")
+	}
+	if len(groups) == 0 {
+		return nil, errors.New("no group provided")
+	}
+
+	sorted := make([]*key.Group, len(groups))
+	copy(sorted, groups)
+	sort.SliceStable(sorted, func(i, j int) bool {
+		return sorted[i].TransitionTime < sorted[j].TransitionTime
+	})
+
+	for i, g := range sorted {
+		if g.PublicKey == nil {
+			return nil, fmt.Errorf("group %d has no distributed public key", i)
+		}
+		if !bytes.Equal(chain.NewChainInfo(g).Hash(), info.Hash()) {
+			return nil, fmt.Errorf("%w: group %d (transition time %d) does not belong to chain %x",
+				drand.ErrInvalidChainHash, i, g.TransitionTime, info.Hash())
+		}
+		if i > 0 && g.TransitionTime == sorted[i-1].TransitionTime {
+			return nil, fmt.Errorf("groups %d and %d share transition time %d", i-1, i, g.TransitionTime)
+		}
+	}
+
+	return &GroupHistory{info: info, groups: sorted}, nil
+}
+
+// LoadGroupHistory reads drand group files (TOML encoded) and verifies them as a GroupHistory.
+func LoadGroupHistory(info *chain.Info, paths ...string) (*GroupHistory, error) {
+	groups := make([]*key.Group, 0, len(paths))
+	for _, p := range paths {
+		gt := &key.GroupTOML{}
+		if _, err := toml.DecodeFile(p, gt); err != nil {
+			return nil, fmt.Errorf("decoding group file %q: %w", p, err)
+		}
+		g := &key.Group{}
+		if err := g.FromTOML(gt); err != nil {
+			return nil, fmt.Errorf("parsing group file %q: %w", p, err)
+		}
+		groups = append(groups, g)
+	}
+	return NewGroupHistory(info, groups...)
+}
+
+// FetchGroupHistory retrieves and verifies the group history of a chain from a
+// client implementing GroupHistoryProvider.
+func FetchGroupHistory(ctx context.Context, c drand.Client) (*GroupHistory, error) {
+	p, ok := c.(GroupHistoryProvider)
+	if !ok {
+		return nil, ErrGroupHistoryUnsupported
+	}
+	info, err := c.Info(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("fetching chain info: %w", err)
+	}
+	groups, err := p.GroupHistory(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("fetching group history: %w", err)
+	}
+	return NewGroupHistory(info, groups...)
+}
+
+// Groups returns the groups of the history, ordered by transition time.
+func (h *GroupHistory) Groups() []*key.Group {
+	return h.groups
+}
+
+// GroupAt returns the group that was in charge of producing the given round.
+func (h *GroupHistory) GroupAt(round uint64) *key.Group {
+	t := common.TimeOfRound(h.info.Period, h.info.GenesisTime, round)
+	current := h.groups[0]
+	for _, g := range h.groups[1:] {
+		if g.TransitionTime > t {
+			break
+		}
+		current = g
+	}
+	return current
+}
+
+// PublicKeyAt returns the distributed public key under which the given round was signed.
+func (h *GroupHistory) PublicKeyAt(round uint64) kyber.Point {
+	return h.GroupAt(round).PublicKey.Key()
+}
@@ -0,0 +1,62 @@
+package client_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/drand/kyber"
+	"github.com/stretchr/testify/require"
+
+	"github.com/drand/drand/v2/common/chain"
+	"github.com/drand/drand/v2/common/key"
+	"github.com/drand/drand/v2/crypto"
+	"github.com/drand/go-clients/client"
+	clientMock "github.com/drand/go-clients/client/mock"
+	"github.com/drand/go-clients/drand"
+)
+
+func fakeGroup(t *testing.T, sch *crypto.Scheme, pub kyber.Point, transition int64) *key.Group {
+	t.Helper()
+	return &key.Group{
+		Threshold:      1,
+		Period:         time.Second,
+		Scheme:         sch,
+		ID:             "default",
+		GenesisTime:    100,
+		GenesisSeed:    []byte("seed"),
+		TransitionTime: transition,
+		PublicKey:      &key.DistPublic{Coefficients: []kyber.Point{pub}},
+	}
+}
+
+func TestGroupHistory(t *testing.T) {
+	sch, err := crypto.GetSchemeFromEnv()
+	require.NoError(t, err)
+	pair, err := key.NewKeyPair("history.test:1234", sch)
+	require.NoError(t, err)
+
+	genesis := fakeGroup(t, sch, pair.Public.Key, 100)
+	reshared := fakeGroup(t, sch, pair.Public.Key, 200)
+	reshared.Threshold = 2
+	info := chain.NewChainInfo(genesis)
+
+	h, err := client.NewGroupHistory(info, reshared, genesis)
+	require.NoError(t, err)
+	require.Equal(t, []*key.Group{genesis, reshared}, h.Groups())
+	require.Equal(t, genesis, h.GroupAt(1))
+	require.Equal(t, genesis, h.GroupAt(100))
+	require.Equal(t, reshared, h.GroupAt(101))
+	require.True(t, h.PublicKeyAt(500).Equal(info.PublicKey))
+
+	other, err := key.NewKeyPair("other.test:1234", sch)
+	require.NoError(t, err)
+	_, err = client.NewGroupHistory(info, genesis, fakeGroup(t, sch, other.Public.Key, 200))
+	require.ErrorIs(t, err, drand.ErrInvalidChainHash)
+
+	_, err = client.NewGroupHistory(info, genesis, fakeGroup(t, sch, pair.Public.Key, 100))
+	require.Error(t, err)
+
+	_, err = client.FetchGroupHistory(context.Background(), clientMock.ClientWithInfo(info))
+	require.ErrorIs(t, err, client.ErrGroupHistoryUnsupported)
+}
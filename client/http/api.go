@@ -0,0 +1,150 @@
+package http
+
+import (
+	"context"
+	"fmt"
+	nhttp "net/http"
+	"time"
+
+	"github.com/drand/drand/v2/common"
+)
+
+// APIVersion is a version of the drand HTTP API.
+type APIVersion int
+
+const (
+	// APIAuto uses the v2 API if the relay supports it, and the v1 API otherwise.
+	APIAuto APIVersion = iota
+	// APIv1 uses the original API, where chains are addressed by hash.
+	APIv1
+	// APIv2 uses the v2 API, where chains are addressed by hash or beacon ID
+	// under the /v2/chains and /v2/beacons paths.
+	APIv2
+)
+
+func (v APIVersion) String() string {
+	switch v {
+	case APIv1:
+		return "v1"
+	case APIv2:
+		return "v2"
+	default:
+		return "auto"
+	}
+}
+
+// WithAPIVersion sets the version of the drand HTTP API used by the client.
+// By default, the client negotiates it with the relay, falling back to v1 when
+// the relay does not serve the v2 API.
+func WithAPIVersion(v APIVersion) Option {
+	return func(h *httpClient) {
+		h.api = v
+	}
+}
+
+const (
+	// apiProbeTimeout bounds the probes of the API version of the relay.
+	apiProbeTimeout = 10 * time.Second
+	// minAPIBackoff and maxAPIBackoff bound the delay before probing again a
+	// relay whose probe failed.
+	minAPIBackoff = time.Second
+	maxAPIBackoff = 5 * time.Minute
+)
+
+// apiVersion returns the version of the API to use with the relay. When
+// negotiating, the relay is probed for v2 support, the concurrent calls
+// sharing the same probe and waiting for it until their context is done. The
+// v1 API is used when the relay does not serve the v2 one, or until a relay
+// which could not be probed answers, in which case it is probed again after a
+// delay growing with the failures.
+func (h *httpClient) apiVersion(ctx context.Context) APIVersion {
+	h.apiLk.Lock()
+	v, retryAt := h.api, h.apiRetryAt
+	h.apiLk.Unlock()
+	if v != APIAuto {
+		return v
+	}
+	if h.clock.Now().Before(retryAt) {
+		return APIv1
+	}
+
+	probe := h.apiProbe.DoChan("", func() (any, error) {
+		// the probe is shared, so that it outlives the call starting it
+		ctx, cancel := context.WithTimeout(context.WithoutCancel(ctx), apiProbeTimeout)
+		defer cancel()
+		return h.probeAPI(ctx), nil
+	})
+	select {
+	case res := <-probe:
+		return res.Val.(APIVersion)
+	case <-ctx.Done():
+		return APIv1
+	}
+}
+
+// probeAPI probes the relay for v2 support, and records the version to use
+// with it, or the failure of the probe.
+func (h *httpClient) probeAPI(ctx context.Context) APIVersion {
+	url := h.root + "v2/chains"
+	req, err := h.newRequest(ctx, url)
+	if err != nil {
+		return APIv1
+	}
+	resp, err := h.client.Do(req)
+	if err == nil {
+		_ = resp.Body.Close()
+	}
+
+	h.apiLk.Lock()
+	defer h.apiLk.Unlock()
+	switch {
+	case err != nil:
+		h.l.Debugw("", "http_client", "failed to probe v2 API", "url", url, "err", err)
+	case resp.StatusCode == nhttp.StatusOK:
+		h.api = APIv2
+	case resp.StatusCode == nhttp.StatusNotFound:
+		h.api = APIv1
+	default:
+		h.l.Debugw("", "http_client", "failed to probe v2 API", "url", url, "status", resp.StatusCode)
+	}
+	if h.api == APIAuto {
+		h.apiBackoff = min(max(2*h.apiBackoff, minAPIBackoff), maxAPIBackoff)
+		h.apiRetryAt = h.clock.Now().Add(h.apiBackoff)
+		return APIv1
+	}
+	h.l.Debugw("", "http_client", "negotiated API version", "root", h.root, "version", h.api)
+	return h.api
+}
+
+// infoURL returns the URL of the chain info of the given chain hash or, when
+// it is empty, of the beacon of the client.
+func (h *httpClient) infoURL(v APIVersion, chainHash []byte) string {
+	switch {
+	case v == APIv2 && len(chainHash) > 0:
+		return fmt.Sprintf("%sv2/chains/%x/info", h.root, chainHash)
+	case v == APIv2:
+		return fmt.Sprintf("%sv2/beacons/%s/info", h.root, common.GetCanonicalBeaconID(h.beaconID))
+	case len(chainHash) > 0:
+		return fmt.Sprintf("%s%x/info", h.root, chainHash)
+	default:
+		return fmt.Sprintf("%sinfo", h.root)
+	}
+}
+
+// beaconURL returns the URL of a round of the chain of the client, 0 meaning the latest one.
+func (h *httpClient) beaconURL(v APIVersion, round uint64) string {
+	r := "latest"
+	if round != 0 {
+		r = fmt.Sprintf("%d", round)
+	}
+	switch {
+	case v == APIv2 && h.beaconID != "":
+		return fmt.Sprintf("%sv2/beacons/%s/rounds/%s", h.root, common.GetCanonicalBeaconID(h.beaconID), r)
+	case v == APIv2:
+		return fmt.Sprintf("%sv2/chains/%x/rounds/%s", h.root, h.chainInfo.Hash(), r)
+	case round == 0:
+		return fmt.Sprintf("%s%x/public/latest", h.root, h.chainInfo.Hash())
+	default:
+		return fmt.Sprintf("%s%x/public/%d", h.root, h.chainInfo.Hash(), round)
+	}
+}
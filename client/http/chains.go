@@ -0,0 +1,112 @@
+package http
+
+import (
+	"context"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	nhttp "net/http"
+	"strings"
+
+	json "github.com/nikkolasg/hexjson"
+
+	"github.com/drand/drand/v2/common/log"
+	"github.com/drand/go-clients/client"
+	"github.com/drand/go-clients/drand"
+)
+
+// Chains returns the hex encoded hashes of the chains served by the relay at url.
+func Chains(ctx context.Context, url string) ([]string, error) {
+	if !strings.HasSuffix(url, "/") {
+		url += "/"
+	}
+	url += "chains"
+
+	req, err := nhttp.NewRequestWithContext(ctx, nhttp.MethodGet, url, nhttp.NoBody)
+	if err != nil {
+		return nil, fmt.Errorf("creating request: %w", err)
+	}
+	resp, err := nhttp.DefaultClient.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("doing request: %w", err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != nhttp.StatusOK {
+		return nil, fmt.Errorf("got invalid status %d doing GET request to %q", resp.StatusCode, url)
+	}
+
+	body, err := io.ReadAll(io.LimitReader(resp.Body, defaultMaxResponseSize))
+	if err != nil {
+		return nil, fmt.Errorf("reading response: %w", err)
+	}
+	return decodeChains(url, body)
+}
+
+// decodeChains decodes a list of chain hashes, as served on the /chains route.
+func decodeChains(url string, body []byte) ([]string, error) {
+	var chains []string
+	if err := json.Unmarshal(body, &chains); err != nil {
+		return nil, &MalformedResponseError{URL: url, Err: fmt.Errorf("decoding response: %w", err)}
+	}
+	for _, hash := range chains {
+		if _, err := hex.DecodeString(hash); err != nil {
+			return nil, &MalformedResponseError{URL: url, Err: fmt.Errorf("invalid chain hash %q: %w", hash, err)}
+		}
+	}
+	return chains, nil
+}
+
+// ForAllChains makes a client for every chain advertised by the relays at
+// urls, each using all the relays serving its chain. The chains are trusted
+// as advertised by the relays. Chains for which no client can be made are
+// skipped, and an error is only returned when no client can be made at all.
+func ForAllChains(ctx context.Context, l log.Logger, urls ...string) (*client.MultiClient, error) {
+	if l == nil {
+		l = log.DefaultLogger()
+	}
+
+	var hashes []string
+	relays := make(map[string][]string)
+	var errs error
+	for _, u := range urls {
+		chains, err := Chains(ctx, u)
+		if err != nil {
+			l.Warnw("", "http_client", "failed to list chains", "url", u, "err", err)
+			errs = errors.Join(errs, err)
+			continue
+		}
+		for _, hash := range chains {
+			if _, ok := relays[hash]; !ok {
+				hashes = append(hashes, hash)
+			}
+			relays[hash] = append(relays[hash], u)
+		}
+	}
+
+	clients := make(map[string]drand.Client, len(hashes))
+	for _, hash := range hashes {
+		chainHash, _ := hex.DecodeString(hash)
+		hcs := ForURLs(ctx, l, relays[hash], chainHash)
+		if len(hcs) == 0 {
+			l.Warnw("", "http_client", "no relay could be used for chain", "chainHash", hash)
+			errs = errors.Join(errs, fmt.Errorf("no relay could be used for chain %s", hash))
+			continue
+		}
+		c, err := client.New(client.From(hcs...), client.WithChainHash(chainHash), client.WithLogger(l))
+		if err != nil {
+			l.Warnw("", "http_client", "failed to make client for chain", "chainHash", hash, "err", err)
+			errs = errors.Join(errs, err)
+			continue
+		}
+		clients[hash] = c
+	}
+
+	if len(clients) == 0 {
+		if errs == nil {
+			errs = errors.New("no chain advertised")
+		}
+		return nil, fmt.Errorf("no chain could be followed: %w", errs)
+	}
+	return client.NewMultiClient(clients), nil
+}
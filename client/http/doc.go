@@ -6,8 +6,38 @@ The HTTP client uses drand's JSON HTTP API
 implemented by polling the endpoint at the expected round time.
 
 The "ForURLs" helper creates multiple HTTP clients from a list of
-URLs. Alternatively you can use the "New" or "NewWithInfo" constructor to
-create clients.
+URLs, fetching the chain info from all of them at once and sharing the first
+one served. Alternatively you can use the "New" or "NewWithInfo" constructor to
+create clients. "Chains" lists the chains served by a relay, and
+"ForAllChains" creates a client.MultiClient following all the chains of a set
+of relays.
+
+URLs naming DNS SRV records, e.g. "srv+https://_drand._tcp.example.org", are
+expanded by "ForURLs" into a client for each relay listed, see "ExpandSRV".
+These clients have the priority and weight of their record, which the clients
+made with client.New honor when ranking their sources, see
+client.WeightedClient. The "WithWeight" option sets them explicitly.
+
+Relay responses are size limited and validated before use: malformed responses
+are reported as a *MalformedResponseError wrapping the cause, e.g.
+ErrMissingField. The "WithStrictDecoding" option additionally rejects beacons
+with unknown fields.
+
+The client uses the v2 API of the relays that serve it, and falls back to
+the v1 API otherwise. The "WithAPIVersion" option forces a version.
+
+Relays serving several chains can be addressed by beacon ID with the
+"WithBeaconID" option, in which case the chain is looked up among the chains
+of the relay when no chain hash is given.
+
+Clients created without a transport honor the proxy configured by the
+environment, see ProxyFromEnvironment. The "WithProxy" option sets an HTTP or
+SOCKS5 proxy explicitly, e.g. "socks5://127.0.0.1:9050" to reach relays over
+Tor.
+
+"New" fetches the chain info of the relay, unless the "WithLazyInfo" option is
+given, in which case it is fetched on the first use of the client, e.g. to
+construct clients offline or without blocking the start of an application.
 
 Tip: Provide multiple URLs to enable failover and speed optimized URL
 selection.
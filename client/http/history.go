@@ -0,0 +1,77 @@
+package http
+
+import (
+	"context"
+	"fmt"
+	nhttp "net/http"
+
+	"github.com/BurntSushi/toml"
+	json "github.com/nikkolasg/hexjson"
+
+	"github.com/drand/drand/v2/common/key"
+	"github.com/drand/go-clients/client"
+)
+
+// maxGroupHistorySize bounds the size of the group history served by a relay,
+// whose group files hold an entry per node.
+const maxGroupHistorySize = 4 * 1024 * 1024
+
+var _ client.GroupHistoryProvider = (*httpClient)(nil)
+
+// groupsURL returns the URL of the group history of the chain of the client.
+func (h *httpClient) groupsURL(v APIVersion) string {
+	if v == APIv2 {
+		return fmt.Sprintf("%sv2/chains/%x/groups", h.root, h.chainInfo.Hash())
+	}
+	return fmt.Sprintf("%s%x/groups", h.root, h.chainInfo.Hash())
+}
+
+// GroupHistory fetches the group files the chain of the client went through,
+// from relays exposing them at <chain hash>/groups, or v2/chains/<chain
+// hash>/groups for the v2 API, as a JSON array of the group files in the TOML
+// format of drand. The groups are not verified: use client.FetchGroupHistory to
+// check them against the chain info. It returns client.ErrGroupHistoryUnsupported
+// if the relay does not serve the group history.
+func (h *httpClient) GroupHistory(ctx context.Context) ([]*key.Group, error) {
+	if _, err := h.info(ctx); err != nil {
+		return nil, err
+	}
+	url := h.groupsURL(h.apiVersion(ctx))
+	req, err := h.newRequest(ctx, url)
+	if err != nil {
+		return nil, err
+	}
+	resp, err := h.client.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("doing request: %w", err)
+	}
+	defer resp.Body.Close()
+	switch {
+	case resp.StatusCode == nhttp.StatusNotFound:
+		return nil, fmt.Errorf("%w: %s does not serve %q", client.ErrGroupHistoryUnsupported, h.root, url)
+	case resp.StatusCode != nhttp.StatusOK:
+		return nil, fmt.Errorf("got invalid status %d doing GET request to %q", resp.StatusCode, url)
+	}
+
+	body, err := readBodyLimit(url, resp.Body, max(h.maxResponseSize, maxGroupHistorySize))
+	if err != nil {
+		return nil, err
+	}
+	var files []string
+	if err := json.Unmarshal(body, &files); err != nil {
+		return nil, &MalformedResponseError{URL: url, Err: err}
+	}
+	groups := make([]*key.Group, 0, len(files))
+	for i, f := range files {
+		gt := &key.GroupTOML{}
+		if _, err := toml.Decode(f, gt); err != nil {
+			return nil, &MalformedResponseError{URL: url, Err: fmt.Errorf("decoding group %d: %w", i, err)}
+		}
+		g := &key.Group{}
+		if err := g.FromTOML(gt); err != nil {
+			return nil, &MalformedResponseError{URL: url, Err: fmt.Errorf("parsing group %d: %w", i, err)}
+		}
+		groups = append(groups, g)
+	}
+	return groups, nil
+}
@@ -3,15 +3,18 @@ package http
 import (
 	"bytes"
 	"context"
+	"crypto/tls"
 	"encoding/hex"
+	"errors"
 	"fmt"
 	nhttp "net/http"
+	nurl "net/url"
 	"os"
 	"path"
 	"strings"
+	"sync"
 	"time"
 
-	"github.com/drand/drand/v2/crypto"
 	"github.com/drand/go-clients/client"
 	"github.com/drand/go-clients/drand"
 
@@ -20,12 +23,20 @@ import (
 	"github.com/drand/drand/v2/common"
 	chain2 "github.com/drand/drand/v2/common/chain"
 	"github.com/drand/drand/v2/common/log"
+
+	clock "github.com/jonboulle/clockwork"
+	"golang.org/x/sync/singleflight"
 )
 
 var _ drand.Client = &httpClient{}
 var _ drand.LoggingClient = &httpClient{}
+var _ client.ClockedClient = &httpClient{}
+var _ client.UserAgentClient = &httpClient{}
+var _ client.InfoRefresher = &httpClient{}
+var _ client.WeightedClient = &httpClient{}
 
 var errClientClosed = fmt.Errorf("client closed")
+var errNotFound = errors.New("not found")
 
 const defaultClientExec = "unknown"
 const defaultHTTTPTimeout = 60 * time.Second
@@ -34,6 +45,9 @@ const httpWaitMaxCounter = 20
 const httpWaitInterval = 2 * time.Second
 const maxTimeoutHTTPRequest = 5 * time.Second
 
+// forURLsTimeout bounds the time spent by ForURLs fetching the chain info.
+const forURLsTimeout = 10 * time.Second
+
 // NewSimpleClient creates a client using the default logger, default transport and a background context
 // to instantiate a new Client for that remote host for that specific chainhash.
 func NewSimpleClient(host, chainhash string) (*httpClient, error) {
@@ -44,15 +58,94 @@ func NewSimpleClient(host, chainhash string) (*httpClient, error) {
 	return New(context.Background(), nil, host, chb, nil)
 }
 
-// New creates a new client pointing to an HTTP endpoint
-func New(ctx context.Context, l log.Logger, url string, chainHash []byte, transport nhttp.RoundTripper) (*httpClient, error) {
+// Option configures an HTTP client.
+type Option func(h *httpClient)
+
+// WithStrictDecoding rejects beacon responses with fields that are not part
+// of the drand HTTP API, see ErrUnknownField, or for another round than the
+// requested one, see ErrImplausibleRound.
+func WithStrictDecoding() Option {
+	return func(h *httpClient) {
+		h.strict = true
+	}
+}
+
+// WithBeaconID sets the ID of the beacon followed by the client, e.g.
+// "quicknet". When no chain hash is given to New, the chain is looked up by
+// beacon ID among the chains served by the relay. Chains and results of other
+// beacons are rejected with drand.ErrBeaconIDMismatch.
+func WithBeaconID(id string) Option {
+	return func(h *httpClient) {
+		h.beaconID = id
+	}
+}
+
+// WithUserAgent sets the User-Agent header of the requests made by the client.
+func WithUserAgent(ua string) Option {
+	return func(h *httpClient) {
+		h.Agent = ua
+	}
+}
+
+// WithRequestHook calls f on every request made by the client before it is
+// sent, e.g. to add authentication headers required by a private relay.
+func WithRequestHook(f func(req *nhttp.Request)) Option {
+	return func(h *httpClient) {
+		h.requestHook = f
+	}
+}
+
+// WithMaxResponseSize bounds the size of the responses read from the relay,
+// see ErrResponseTooLarge. It defaults to 64KiB.
+func WithMaxResponseSize(n int64) Option {
+	return func(h *httpClient) {
+		if n > 0 {
+			h.maxResponseSize = n
+		}
+	}
+}
+
+// WithLazyInfo makes New return without fetching the chain info of the
+// relay, e.g. to construct clients offline. The chain info is fetched, and
+// checked against the chain hash, on the first use of the client instead, with
+// the context of that call, and RoundAt returns 0 until then.
+func WithLazyInfo() Option {
+	return func(h *httpClient) {
+		h.lazy = true
+	}
+}
+
+// WithWeight gives the client a priority and a weight, which the clients made
+// with client.New honor when ranking their sources, see client.WeightedClient.
+// The clients of the relays listed in SRV records get the priority and weight
+// of their record, see ExpandSRV.
+func WithWeight(priority, weight uint16) Option {
+	return func(h *httpClient) {
+		h.weighted = true
+		h.priority, h.weight = priority, weight
+	}
+}
+
+// New creates a new client pointing to an HTTP endpoint. The URL of a relay
+// listening on a unix domain socket is the path of the socket prefixed with
+// "unix://", e.g. "unix:///run/drand/relay.sock", and its requests are not
+// sent through any proxy.
+//
+//nolint:lll // This function has nicely named parameters, so it's long.
+func New(ctx context.Context, l log.Logger, url string, chainHash []byte, transport nhttp.RoundTripper, opts ...Option) (*httpClient, error) {
 	if l == nil {
 		l = log.DefaultLogger()
 	}
 	if transport == nil {
-		transport = nhttp.DefaultTransport
+		transport = defaultTransport()
+	}
+	socket, unix, err := unixSocket(url)
+	if err != nil {
+		return nil, err
 	}
-	if !strings.HasSuffix(url, "/") {
+	if unix {
+		url = unixRoot
+	} else if !strings.HasSuffix(url, "/") {
 		url += "/"
 	}
 	pn, err := os.Executable()
@@ -62,30 +155,56 @@ func New(ctx context.Context, l log.Logger, url string, chainHash []byte, transp
 	agent := fmt.Sprintf("go-client-%s/2.0", path.Base(pn))
 	c := &httpClient{
 		root:   url,
+		socket: socket,
 		client: createClient(transport),
 		l:      l,
 		Agent:  agent,
+		clock:  clock.NewRealClock(),
 		done:   make(chan struct{}),
-	}
 
-	chainInfo, err := c.FetchChainInfo(ctx, chainHash)
-	if err != nil {
-		return nil, fmt.Errorf("FetchChainInfo err: %w", err)
+		maxResponseSize: defaultMaxResponseSize,
+	}
+	for _, opt := range opts {
+		opt(c)
+	}
+	if err := c.setProxy(transport); err != nil {
+		return nil, err
+	}
+	if err := c.setTLS(); err != nil {
+		return nil, err
+	}
+	if err := c.setResolver(); err != nil {
+		return nil, err
+	}
+	if err := c.setSocket(transport); err != nil {
+		return nil, err
 	}
-	c.chainInfo = chainInfo
 
+	c.chainHash = bytes.Clone(chainHash)
+	if c.lazy {
+		return c, nil
+	}
+	if _, err := c.info(ctx); err != nil {
+		return nil, err
+	}
 	return c, nil
 }
 
 // NewWithInfo constructs an http client when the group parameters are already known.
-func NewWithInfo(l log.Logger, url string, info *chain2.Info, transport nhttp.RoundTripper) (*httpClient, error) {
+func NewWithInfo(l log.Logger, url string, info *chain2.Info, transport nhttp.RoundTripper, opts ...Option) (*httpClient, error) {
 	if l == nil {
 		l = log.DefaultLogger()
 	}
 	if transport == nil {
-		transport = nhttp.DefaultTransport
+		transport = defaultTransport()
+	}
+	socket, unix, err := unixSocket(url)
+	if err != nil {
+		return nil, err
 	}
-	if !strings.HasSuffix(url, "/") {
+	if unix {
+		url = unixRoot
+	} else if !strings.HasSuffix(url, "/") {
 		url += "/"
 	}
 
@@ -96,42 +215,105 @@ func NewWithInfo(l log.Logger, url string, info *chain2.Info, transport nhttp.Ro
 	agent := fmt.Sprintf("drand-client-%s/1.0", path.Base(pn))
 	c := &httpClient{
 		root:      url,
+		socket:    socket,
 		chainInfo: info,
 		client:    createClient(transport),
 		l:         l,
 		Agent:     agent,
+		clock:     clock.NewRealClock(),
 		done:      make(chan struct{}),
+
+		maxResponseSize: defaultMaxResponseSize,
+	}
+	for _, opt := range opts {
+		opt(c)
+	}
+	if err := c.setProxy(transport); err != nil {
+		return nil, err
+	}
+	if err := c.setTLS(); err != nil {
+		return nil, err
+	}
+	if err := c.setResolver(); err != nil {
+		return nil, err
+	}
+	if err := c.setSocket(transport); err != nil {
+		return nil, err
+	}
+	if c.beaconID != "" && info != nil && !common.CompareBeaconIDs(c.beaconID, info.ID) {
+		return nil, fmt.Errorf("%w: chain is for beacon %q instead of %q", drand.ErrBeaconIDMismatch, info.ID, c.beaconID)
 	}
 	return c, nil
 }
 
-// ForURLs provides a shortcut for creating a set of HTTP clients for a set of URLs.
-func ForURLs(ctx context.Context, l log.Logger, urls []string, chainHash []byte) []drand.Client {
-	clients := make([]drand.Client, 0)
-	var info *chain2.Info
-	var skipped []string
-	for _, u := range urls {
-		if info == nil {
-			if c, err := New(ctx, l, u, chainHash, nil); err == nil {
-				// Note: this wrapper assumes the current behavior that if `New` succeeds,
-				// Info will have been fetched.
-				info, _ = c.Info(ctx)
-				clients = append(clients, c)
-			} else {
-				skipped = append(skipped, u)
+// ForURLs provides a shortcut for creating a set of HTTP clients for a set of URLs,
+// configured with the given options. The chain info is fetched from all the
+// relays at once, for 10 seconds at most, and the first relay serving it gives
+// it to the others, so that slow or unreachable relays do not delay the
+// creation of the clients. With WithLazyInfo, every client fetches the chain
+// info on its first use instead.
+//
+// URLs naming SRV records, e.g. "srv+https://_drand._tcp.example.org", are
+// expanded into a client for each relay listed, with the priority and weight
+// of its record, see ExpandSRV.
+func ForURLs(ctx context.Context, l log.Logger, urls []string, chainHash []byte, opts ...Option) []drand.Client {
+	type created struct {
+		i int
+		c *httpClient
+	}
+	if l == nil {
+		l = log.DefaultLogger()
+	}
+	ctx, cancel := context.WithTimeout(ctx, forURLsTimeout)
+	defer cancel()
+	relays := ExpandURLs(ctx, l, urls)
+	resC := make(chan created, len(relays))
+	for i, r := range relays {
+		go func() {
+			c, err := New(ctx, l, r.URL, chainHash, nil, r.Options(opts...)...)
+			if err != nil {
+				c = nil
 			}
-		} else {
-			if c, err := NewWithInfo(l, u, info, nil); err == nil {
-				clients = append(clients, c)
+			resC <- created{i, c}
+		}()
+	}
+
+	first := -1
+	made := make([]*httpClient, len(relays))
+	for range relays {
+		res := <-resC
+		made[res.i] = res.c
+		if res.c != nil && first < 0 {
+			first = res.i
+			// the other relays are given the chain info of the first one
+			if !res.c.lazy {
+				cancel()
 			}
 		}
 	}
-	if info != nil {
-		for _, u := range skipped {
-			if c, err := NewWithInfo(l, u, info, nil); err == nil {
+
+	clients := make([]drand.Client, 0, len(relays))
+	if first < 0 {
+		return clients
+	}
+	if made[first].lazy {
+		for _, c := range made {
+			if c != nil {
 				clients = append(clients, c)
 			}
 		}
+		return clients
+	}
+	info := made[first].chainInfo
+	clients = append(clients, made[first])
+	for k := 1; k < len(relays); k++ {
+		i := (first + k) % len(relays)
+		if made[i] != nil {
+			_ = made[i].Close()
+		}
+		if c, err := NewWithInfo(l, relays[i].URL, info, nil, relays[i].Options(opts...)...); err == nil {
+			clients = append(clients, c)
+		}
 	}
 	return clients
 }
@@ -188,12 +370,122 @@ func IsServerReady(ctx context.Context, addr string) error {
 
 // httpClient implements Client through http requests to a Drand relay.
 type httpClient struct {
-	root      string
+	root string
+	// socket is the path of the unix domain socket of the relay, if any.
+	socket    string
 	client    *nhttp.Client
 	Agent     string
 	chainInfo *chain2.Info
 	l         log.Logger
+	clock     clock.Clock
 	done      chan struct{}
+
+	// strict rejects unknown fields in beacon responses.
+	strict bool
+	// maxResponseSize bounds the size of the responses read from the relay.
+	maxResponseSize int64
+	// beaconID is the ID of the beacon followed by the client, if set.
+	beaconID string
+
+	// chainHash is the root of trust of the chain info, fetched on first
+	// use when lazy is set, once at a time by infoFetch, and infoLk guards
+	// chainInfo.
+	chainHash []byte
+	lazy      bool
+	infoLk    sync.Mutex
+	infoFetch singleflight.Group
+
+	// proxy is the proxy requests are sent through, if set with WithProxy.
+	proxy *nurl.URL
+
+	// tlsConfig and spkiPins secure the connections to the relay, if set
+	// with WithTLSConfig and WithPinnedSPKI.
+	tlsConfig *tls.Config
+	spkiPins  [][]byte
+
+	// resolver resolves the hostnames dialed by the client, if configured
+	// with WithHosts, WithIPPreference or WithResolveInterval.
+	resolver *resolver
+
+	// requestHook is called on every request before it is sent.
+	requestHook func(req *nhttp.Request)
+
+	// priority and weight rank the client among the sources of a client, if
+	// weighted is set with WithWeight.
+	weighted         bool
+	priority, weight uint16
+
+	// api is the version of the drand HTTP API used, APIAuto until negotiated.
+	// The relay is probed once at a time, and not again before apiRetryAt
+	// after a failure, apiBackoff growing with the failures.
+	api        APIVersion
+	apiLk      sync.Mutex
+	apiProbe   singleflight.Group
+	apiRetryAt time.Time
+	apiBackoff time.Duration
+}
+
+// setProxy makes the client use the proxy set with WithProxy, if any.
+func (h *httpClient) setProxy(transport nhttp.RoundTripper) error {
+	if h.proxy == nil {
+		return nil
+	}
+	t, err := proxiedTransport(transport, h.proxy)
+	if err != nil {
+		return err
+	}
+	h.client.Transport = t
+	return nil
+}
+
+// setTLS makes the client use the TLS configuration and the public key pins
+// set with WithTLSConfig and WithPinnedSPKI, if any.
+func (h *httpClient) setTLS() error {
+	if h.tlsConfig == nil && len(h.spkiPins) == 0 {
+		return nil
+	}
+	t, err := tlsTransport(h.client.Transport, h.tlsConfig, h.spkiPins)
+	if err != nil {
+		return err
+	}
+	h.client.Transport = t
+	return nil
+}
+
+// resolverConfig returns the resolver of the client, creating it if needed.
+func (h *httpClient) resolverConfig() *resolver {
+	if h.resolver == nil {
+		h.resolver = newResolver()
+	}
+	return h.resolver
+}
+
+// setResolver makes the client dial the addresses resolved by its resolver,
+// if any, through the proxy set with WithProxy if any.
+func (h *httpClient) setResolver() error {
+	if h.resolver == nil {
+		return nil
+	}
+	t, err := resolvingTransport(h.client.Transport, h.resolver)
+	if err != nil {
+		return err
+	}
+	h.client.Transport = t
+	return nil
+}
+
+// setSocket makes the client connect to the unix domain socket of its URL,
+// if any.
+func (h *httpClient) setSocket(transport nhttp.RoundTripper) error {
+	if h.socket == "" {
+		return nil
+	}
+	t, err := unixTransport(transport, h.socket)
+	if err != nil {
+		return err
+	}
+	h.client.Transport = t
+	return nil
 }
 
 // SetLog configures the client log output
@@ -201,13 +493,31 @@ func (h *httpClient) SetLog(l log.Logger) {
 	h.l = l
 }
 
+// SetClock configures the clock used to poll for new rounds when watching.
+func (h *httpClient) SetClock(clk clock.Clock) {
+	h.clock = clk
+}
+
 // SetUserAgent sets the user agent used by the client
 func (h *httpClient) SetUserAgent(ua string) {
 	h.Agent = ua
 }
 
+// Transport returns the name of the transport of this client.
+func (h *httpClient) Transport() string {
+	return "http"
+}
+
+// Weight returns the priority and weight set with WithWeight, if any.
+func (h *httpClient) Weight() (priority, weight uint16, ok bool) {
+	return h.priority, h.weight, h.weighted
+}
+
 // String returns the name of this client.
 func (h *httpClient) String() string {
+	if h.socket != "" {
+		return fmt.Sprintf("HTTP(%q)", unixScheme+h.socket)
+	}
 	return fmt.Sprintf("HTTP(%q)", h.root)
 }
 
@@ -225,8 +535,8 @@ type httpInfoResponse struct {
 // it does not know the full group parameters for a drand group. The chain hash
 // is the hash of the chain info.
 func (h *httpClient) FetchChainInfo(ctx context.Context, chainHash []byte) (*chain2.Info, error) {
-	if h.chainInfo != nil {
-		return h.chainInfo, nil
+	if info := h.knownInfo(); info != nil {
+		return info, nil
 	}
 
 	resC := make(chan httpInfoResponse, 1)
@@ -234,63 +544,154 @@ func (h *httpClient) FetchChainInfo(ctx context.Context, chainHash []byte) (*cha
 	defer cancel()
 
 	go func() {
-		var url string
-		if len(chainHash) > 0 {
-			url = fmt.Sprintf("%s%x/info", h.root, chainHash)
-		} else {
-			url = fmt.Sprintf("%sinfo", h.root)
-		}
+		chainInfo, err := h.fetchChainInfo(ctx, chainHash)
+		resC <- httpInfoResponse{chainInfo, err}
+	}()
 
-		req, err := nhttp.NewRequestWithContext(ctx, nhttp.MethodGet, url, nhttp.NoBody)
-		if err != nil {
-			resC <- httpInfoResponse{nil, fmt.Errorf("creating request: %w", err)}
-			return
+	select {
+	case res := <-resC:
+		if res.err != nil {
+			return nil, res.err
 		}
-		req.Header.Set("User-Agent", h.Agent)
+		return res.chainInfo, nil
+	case <-h.done:
+		return nil, errClientClosed
+	}
+}
 
-		infoBody, err := h.client.Do(req)
-		if err != nil {
-			resC <- httpInfoResponse{nil, fmt.Errorf("doing request: %w", err)}
-			return
+func (h *httpClient) fetchChainInfo(ctx context.Context, chainHash []byte) (*chain2.Info, error) {
+	lookup := len(chainHash) == 0 && !common.IsDefaultBeaconID(h.beaconID)
+	v := h.apiVersion(ctx)
+
+	var chainInfo *chain2.Info
+	var err error
+	if lookup && v == APIv1 {
+		chainInfo, err = h.findChainInfo(ctx)
+	} else {
+		chainInfo, err = h.getChainInfo(ctx, v, h.infoURL(v, chainHash))
+	}
+	if lookup && errors.Is(err, errNotFound) {
+		return nil, fmt.Errorf("%w: %s does not serve beacon %q", drand.ErrBeaconIDMismatch, h.root, h.beaconID)
+	} else if err != nil {
+		return nil, err
+	}
+
+	switch {
+	case h.beaconID != "" && !common.CompareBeaconIDs(h.beaconID, chainInfo.ID):
+		return nil, fmt.Errorf("%w: %s advertises beacon %q instead of %q", drand.ErrBeaconIDMismatch, h.root, chainInfo.ID, h.beaconID)
+	case len(chainHash) == 0:
+		h.l.Warnw("", "http_client", "instantiated without trustroot", "chainHash", hex.EncodeToString(chainInfo.Hash()))
+		if !lookup && !common.IsDefaultBeaconID(chainInfo.ID) {
+			return nil, fmt.Errorf("%s does not advertise the default drand for the default chainHash (got %x)", h.root, chainInfo.Hash())
 		}
-		defer infoBody.Body.Close()
+	case !bytes.Equal(chainInfo.Hash(), chainHash):
+		return nil, fmt.Errorf("%s does not advertise the expected drand group (%x vs %x)", h.root, chainInfo.Hash(), chainHash)
+	}
+	return chainInfo, nil
+}
+
+// RefreshChainInfo fetches the chain info currently served by the relay,
+// bypassing the info the client was set up with, so that callers can detect
+// when the relay starts serving another chain. The chain of the beacon ID of
+// the client, or the default chain, is fetched when the relay no longer serves
+// the chain of the client under its hash.
+func (h *httpClient) RefreshChainInfo(ctx context.Context) (*chain2.Info, error) {
+	if _, err := h.info(ctx); err != nil {
+		return nil, err
+	}
+	v := h.apiVersion(ctx)
+	if v == APIv2 && h.beaconID != "" {
+		return h.getChainInfo(ctx, v, h.infoURL(v, nil))
+	}
+	info, err := h.getChainInfo(ctx, v, h.infoURL(v, h.chainInfo.Hash()))
+	switch {
+	case !errors.Is(err, errNotFound):
+		return info, err
+	case v == APIv1 && !common.IsDefaultBeaconID(h.beaconID):
+		return h.findChainInfo(ctx)
+	default:
+		return h.getChainInfo(ctx, v, h.infoURL(v, nil))
+	}
+}
 
-		chainInfo, err := chain2.InfoFromJSON(infoBody.Body)
+// findChainInfo looks up the chain of the beacon ID of the client among the
+// chains served by the relay.
+func (h *httpClient) findChainInfo(ctx context.Context) (*chain2.Info, error) {
+	url := fmt.Sprintf("%schains", h.root)
+	body, err := h.getBody(ctx, url)
+	if err != nil {
+		return nil, err
+	}
+	chains, err := decodeChains(url, body)
+	if err != nil {
+		return nil, err
+	}
+	for _, hash := range chains {
+		chainInfo, err := h.getChainInfo(ctx, APIv1, fmt.Sprintf("%s%s/info", h.root, hash))
 		if err != nil {
-			resC <- httpInfoResponse{nil, fmt.Errorf("decoding response [InfoFromJSON]: %w", err)}
-			return
+			h.l.Warnw("", "http_client", "failed to fetch chain info", "chainHash", hash, "err", err)
+			continue
 		}
-
-		if chainInfo.PublicKey == nil {
-			resC <- httpInfoResponse{nil, fmt.Errorf("group does not have a valid key for validation")}
-			return
+		if common.CompareBeaconIDs(chainInfo.ID, h.beaconID) {
+			return chainInfo, nil
 		}
+	}
+	return nil, fmt.Errorf("%w: %s does not serve beacon %q", drand.ErrBeaconIDMismatch, h.root, h.beaconID)
+}
 
-		if len(chainHash) == 0 {
-			h.l.Warnw("", "http_client", "instantiated without trustroot", "chainHash", hex.EncodeToString(chainInfo.Hash()))
-			if !common.IsDefaultBeaconID(chainInfo.ID) {
-				err := fmt.Errorf("%s does not advertise the default drand for the default chainHash (got %x)", h.root, chainInfo.Hash())
-				resC <- httpInfoResponse{nil, err}
-				return
-			}
-		} else if !bytes.Equal(chainInfo.Hash(), chainHash) {
-			err := fmt.Errorf("%s does not advertise the expected drand group (%x vs %x)", h.root, chainInfo.Hash(), chainHash)
-			resC <- httpInfoResponse{nil, err}
-			return
-		}
+// getChainInfo fetches and decodes the chain info served at url, in the format of the given API version.
+func (h *httpClient) getChainInfo(ctx context.Context, v APIVersion, url string) (*chain2.Info, error) {
+	body, err := h.getBody(ctx, url)
+	if err != nil {
+		return nil, err
+	}
+	var chainInfo *chain2.Info
+	if v == APIv2 {
+		chainInfo = new(chain2.Info)
+		err = json.Unmarshal(body, chainInfo)
+	} else {
+		chainInfo, err = chain2.InfoFromJSON(bytes.NewReader(body))
+	}
+	if err != nil {
+		return nil, fmt.Errorf("decoding response [InfoFromJSON]: %w", err)
+	}
+	if chainInfo.PublicKey == nil {
+		return nil, fmt.Errorf("group does not have a valid key for validation")
+	}
+	return chainInfo, nil
+}
 
-		resC <- httpInfoResponse{chainInfo, nil}
-	}()
+// newRequest makes a GET request to url, with the user agent of the client
+// and the changes of its request hook.
+func (h *httpClient) newRequest(ctx context.Context, url string) (*nhttp.Request, error) {
+	req, err := nhttp.NewRequestWithContext(ctx, nhttp.MethodGet, url, nhttp.NoBody)
+	if err != nil {
+		return nil, fmt.Errorf("creating request: %w", err)
+	}
+	req.Header.Set("User-Agent", h.Agent)
+	if h.requestHook != nil {
+		h.requestHook(req)
+	}
+	return req, nil
+}
 
-	select {
-	case res := <-resC:
-		if res.err != nil {
-			return nil, res.err
-		}
-		return res.chainInfo, nil
-	case <-h.done:
-		return nil, errClientClosed
+// getBody returns the body of a GET request to url.
+func (h *httpClient) getBody(ctx context.Context, url string) ([]byte, error) {
+	req, err := h.newRequest(ctx, url)
+	if err != nil {
+		return nil, err
+	}
+
+	resp, err := h.client.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("doing request: %w", err)
 	}
+	defer resp.Body.Close()
+	if resp.StatusCode == nhttp.StatusNotFound {
+		return nil, fmt.Errorf("%w: %q", errNotFound, url)
+	}
+
+	return h.readBody(url, resp.Body)
 }
 
 type httpGetResponse struct {
@@ -300,24 +701,21 @@ type httpGetResponse struct {
 
 // Get returns the randomness at `round` or an error.
 func (h *httpClient) Get(ctx context.Context, round uint64) (drand.Result, error) {
-	var url string
-	if round == 0 {
-		url = fmt.Sprintf("%s%x/public/latest", h.root, h.chainInfo.Hash())
-	} else {
-		url = fmt.Sprintf("%s%x/public/%d", h.root, h.chainInfo.Hash(), round)
+	if _, err := h.info(ctx); err != nil {
+		return nil, err
 	}
+	url := h.beaconURL(h.apiVersion(ctx), round)
 
 	resC := make(chan httpGetResponse, 1)
 	ctx, cancel := context.WithCancel(ctx)
 	defer cancel()
 
 	go func() {
-		req, err := nhttp.NewRequestWithContext(ctx, nhttp.MethodGet, url, nhttp.NoBody)
+		req, err := h.newRequest(ctx, url)
 		if err != nil {
-			resC <- httpGetResponse{nil, fmt.Errorf("creating request: %w", err)}
+			resC <- httpGetResponse{nil, err}
 			return
 		}
-		req.Header.Set("User-Agent", h.Agent)
 
 		randResponse, err := h.client.Do(req)
 		if err != nil {
@@ -330,20 +728,18 @@ func (h *httpClient) Get(ctx context.Context, round uint64) (drand.Result, error
 		}
 		defer randResponse.Body.Close()
 
-		randResp := client.RandomData{}
-		if err := json.NewDecoder(randResponse.Body).Decode(&randResp); err != nil {
-			resC <- httpGetResponse{nil, fmt.Errorf("decoding response: %w", err)}
+		body, err := h.readBody(url, randResponse.Body)
+		if err != nil {
+			resC <- httpGetResponse{nil, err}
 			return
 		}
-
-		if len(randResp.Sig) == 0 {
-			resC <- httpGetResponse{nil, fmt.Errorf("insufficient response - signature is not present")}
+		randResp, err := h.decodeBeacon(url, body, round)
+		if err != nil {
+			resC <- httpGetResponse{nil, err}
 			return
 		}
 
-		randResp.Random = crypto.RandomnessFromSignature(randResp.GetSignature())
-
-		resC <- httpGetResponse{&randResp, nil}
+		resC <- httpGetResponse{randResp, nil}
 	}()
 
 	select {
@@ -365,7 +761,12 @@ func (h *httpClient) Watch(ctx context.Context) <-chan drand.Result {
 		defer cancel()
 		defer close(out)
 
-		in := client.PollingWatcher(ctx, h, h.chainInfo, h.l)
+		info, err := h.info(ctx)
+		if err != nil {
+			h.l.Errorw("", "http_client", "failed to fetch chain info to watch", "err", err)
+			return
+		}
+		in := client.PollingWatcherWithClock(ctx, h, info, h.l, h.clock)
 		for {
 			select {
 			case res, ok := <-in:
@@ -382,14 +783,57 @@ func (h *httpClient) Watch(ctx context.Context) <-chan drand.Result {
 }
 
 // Info returns information about the chain.
-func (h *httpClient) Info(_ context.Context) (*chain2.Info, error) {
-	return h.chainInfo, nil
+func (h *httpClient) Info(ctx context.Context) (*chain2.Info, error) {
+	return h.info(ctx)
+}
+
+// info returns the chain info of the client, fetching it if it is not known
+// yet. The concurrent calls share the same fetch, made without holding infoLk,
+// and wait for it until their context is done.
+func (h *httpClient) info(ctx context.Context) (*chain2.Info, error) {
+	if info := h.knownInfo(); info != nil {
+		return info, nil
+	}
+	fetch := h.infoFetch.DoChan("", func() (any, error) {
+		// the fetch is shared, so that it outlives the call starting it
+		ctx, cancel := context.WithTimeout(context.WithoutCancel(ctx), defaultHTTTPTimeout)
+		defer cancel()
+		chainInfo, err := h.FetchChainInfo(ctx, h.chainHash)
+		if err != nil {
+			return nil, err
+		}
+		h.infoLk.Lock()
+		defer h.infoLk.Unlock()
+		h.chainInfo = chainInfo
+		return chainInfo, nil
+	})
+	select {
+	case res := <-fetch:
+		if res.Err != nil {
+			return nil, fmt.Errorf("FetchChainInfo err: %w", res.Err)
+		}
+		return res.Val.(*chain2.Info), nil
+	case <-ctx.Done():
+		return nil, fmt.Errorf("FetchChainInfo err: %w", ctx.Err())
+	}
+}
+
+// knownInfo returns the chain info of the client, or nil if it is not fetched yet.
+func (h *httpClient) knownInfo() *chain2.Info {
+	h.infoLk.Lock()
+	defer h.infoLk.Unlock()
+	return h.chainInfo
 }
 
 // RoundAt will return the most recent round of randomness that will be available
-// at time for the current client.
+// at time for the current client, or 0 if its chain info is not fetched yet,
+// without waiting for a fetch in progress.
 func (h *httpClient) RoundAt(t time.Time) uint64 {
-	return common.CurrentRound(t.Unix(), h.chainInfo.Period, h.chainInfo.GenesisTime)
+	info := h.knownInfo()
+	if info == nil {
+		return 0
+	}
+	return common.CurrentRound(t.Unix(), info.Period, info.GenesisTime)
 }
 
 func (h *httpClient) Close() error {
@@ -1,21 +1,41 @@
 package http
 
 import (
+	"bytes"
 	"context"
+	"crypto/ed25519"
+	"crypto/sha256"
+	"crypto/tls"
+	"crypto/x509"
+	"encoding/json"
 	"errors"
+	"fmt"
+	"net"
 	"net/http"
+	"net/http/httptest"
+	"net/netip"
+	"net/url"
+	"path/filepath"
+	"strconv"
+	"strings"
 	"sync"
 	"testing"
 	"time"
 
+	"github.com/BurntSushi/toml"
+	"github.com/drand/kyber"
 	clock "github.com/jonboulle/clockwork"
 	"github.com/stretchr/testify/require"
 
+	"github.com/drand/drand/v2/common/key"
 	"github.com/drand/drand/v2/common/log"
 
 	"github.com/drand/drand/v2/crypto"
 	"github.com/drand/go-clients/client"
 	"github.com/drand/go-clients/client/test/http/mock"
+	resultMock "github.com/drand/go-clients/client/test/result/mock"
+	"github.com/drand/go-clients/clienttest"
+	"github.com/drand/go-clients/drand"
 )
 
 func TestHTTPClient(t *testing.T) {
@@ -123,6 +143,169 @@ func TestForURLsCreation(t *testing.T) {
 	_ = clients[1].Close()
 }
 
+func TestForURLsSlowRelay(t *testing.T) {
+	ctx := context.Background()
+	relay := clienttest.NewRelay(t, clienttest.WithRounds(3))
+	release := make(chan struct{})
+	slow := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		select {
+		case <-release:
+		case <-r.Context().Done():
+		}
+	}))
+	defer slow.Close()
+	defer close(release)
+
+	start := time.Now()
+	clients := ForURLs(ctx, nil, []string{slow.URL, relay.URL()}, relay.Info().Hash())
+	require.Less(t, time.Since(start), forURLsTimeout)
+	require.Len(t, clients, 2)
+	for _, c := range clients {
+		info, err := c.Info(ctx)
+		require.NoError(t, err)
+		require.True(t, relay.Info().Equal(info))
+		_ = c.Close()
+	}
+	require.Equal(t, fmt.Sprintf("HTTP(%q)", relay.URL()+"/"), fmt.Sprint(clients[0]), "the first relay serving the chain comes first")
+}
+
+func TestSRV(t *testing.T) {
+	ctx := context.Background()
+	relay := clienttest.NewRelay(t, clienttest.WithRounds(3))
+	u, err := url.Parse(relay.URL())
+	require.NoError(t, err)
+	port, err := strconv.Atoi(u.Port())
+	require.NoError(t, err)
+
+	defer func(lookup func(context.Context, string, string, string) (string, []*net.SRV, error)) {
+		lookupSRV = lookup
+	}(lookupSRV)
+	lookupSRV = func(_ context.Context, service, proto, name string) (string, []*net.SRV, error) {
+		if service != "" || proto != "" || name != "_drand._tcp.example.org" {
+			return "", nil, &net.DNSError{Err: "no such host", Name: name, IsNotFound: true}
+		}
+		return name, []*net.SRV{
+			{Target: "127.0.0.1.", Port: uint16(port), Priority: 10, Weight: 60},
+			{Target: "localhost.", Port: uint16(port), Priority: 20, Weight: 0},
+			{Target: ".", Port: 0},
+		}, nil
+	}
+
+	targets, err := ExpandSRV(ctx, "srv+http://_drand._tcp.example.org/relay")
+	require.NoError(t, err)
+	require.Equal(t, []SRVTarget{
+		{URL: "http://127.0.0.1:" + u.Port() + "/relay", Priority: 10, Weight: 60},
+		{URL: "http://localhost:" + u.Port() + "/relay", Priority: 20, Weight: 0},
+	}, targets)
+
+	// the relays are expanded with the priority and weight of their records
+	clients := ForURLs(ctx, nil, []string{"srv+http://_drand._tcp.example.org", "srv+http://missing.example.org"},
+		relay.Info().Hash())
+	require.Len(t, clients, 2)
+	weights := make(map[string][2]uint16)
+	for _, c := range clients {
+		p, w, ok := c.(client.WeightedClient).Weight()
+		require.True(t, ok)
+		weights[fmt.Sprint(c)] = [2]uint16{p, w}
+		_, err := c.Get(ctx, 1)
+		require.NoError(t, err)
+		_ = c.Close()
+	}
+	require.Equal(t, [2]uint16{10, 60}, weights[fmt.Sprintf("HTTP(%q)", "http://127.0.0.1:"+u.Port()+"/")])
+	require.Equal(t, [2]uint16{20, 0}, weights[fmt.Sprintf("HTTP(%q)", "http://localhost:"+u.Port()+"/")])
+
+	for _, bad := range []string{"https://example.org", "srv+unix:///run/drand.sock", "srv+https://example.org:443"} {
+		_, err := ExpandSRV(ctx, bad)
+		require.Error(t, err, bad)
+	}
+}
+
+func TestHTTPLazyInfo(t *testing.T) {
+	ctx := context.Background()
+	relay := clienttest.NewRelay(t, clienttest.WithRounds(3))
+
+	// no request is made until the client is used
+	offline, err := New(ctx, nil, "http://127.0.0.1:1", relay.Info().Hash(), nil, WithLazyInfo())
+	require.NoError(t, err)
+	defer offline.Close()
+	require.Zero(t, offline.RoundAt(time.Now()))
+	_, err = offline.Get(ctx, 1)
+	require.Error(t, err)
+
+	c, err := New(ctx, nil, relay.URL(), relay.Info().Hash(), nil, WithLazyInfo())
+	require.NoError(t, err)
+	defer c.Close()
+	r, err := c.Get(ctx, 2)
+	require.NoError(t, err)
+	require.Equal(t, relay.Result(2).GetSignature(), r.GetSignature())
+	info, err := c.Info(ctx)
+	require.NoError(t, err)
+	require.True(t, relay.Info().Equal(info))
+	require.NotZero(t, c.RoundAt(time.Now()))
+
+	// the chain info is still checked against the chain hash
+	other := clienttest.NewRelay(t)
+	c, err = New(ctx, nil, relay.URL(), other.Info().Hash(), nil, WithLazyInfo())
+	require.NoError(t, err)
+	defer c.Close()
+	_, err = c.Info(ctx)
+	require.Error(t, err)
+
+	clients := ForURLs(ctx, nil, []string{"http://127.0.0.1:1", relay.URL()}, relay.Info().Hash(), WithLazyInfo())
+	require.Len(t, clients, 2)
+	for _, c := range clients {
+		_ = c.Close()
+	}
+}
+
+func TestHTTPLazyInfoRoundAt(t *testing.T) {
+	ctx := context.Background()
+	relay := clienttest.NewRelay(t)
+	requested := make(chan struct{}, 1)
+	release := make(chan struct{})
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if strings.HasSuffix(r.URL.Path, "/info") {
+			select {
+			case requested <- struct{}{}:
+			default:
+			}
+			<-release
+		}
+		relay.ServeHTTP(w, r)
+	}))
+	defer server.Close()
+	var releaseOnce sync.Once
+	releaseInfo := func() { releaseOnce.Do(func() { close(release) }) }
+	defer releaseInfo()
+
+	c, err := New(ctx, nil, server.URL, relay.Info().Hash(), nil, WithLazyInfo(), WithAPIVersion(APIv1))
+	require.NoError(t, err)
+	defer c.Close()
+	infoErr := make(chan error, 2)
+	for range 2 {
+		go func() {
+			_, err := c.Info(ctx)
+			infoErr <- err
+		}()
+	}
+	<-requested
+
+	// RoundAt does not wait for the fetch in progress
+	roundAt := make(chan uint64)
+	go func() { roundAt <- c.RoundAt(time.Now()) }()
+	select {
+	case round := <-roundAt:
+		require.Zero(t, round)
+	case <-time.After(time.Second):
+		t.Fatal("RoundAt waited for the chain info fetch")
+	}
+
+	releaseInfo()
+	require.NoError(t, <-infoErr)
+	require.NoError(t, <-infoErr)
+	require.NotZero(t, c.RoundAt(time.Now()))
+}
+
 func TestHTTPWatch(t *testing.T) {
 	if testing.Short() {
 		t.Skip("skipping test in short mode.")
@@ -206,3 +389,589 @@ func TestHTTPClientClose(t *testing.T) {
 
 	wg.Wait() // wait for the watch to close
 }
+
+func TestHTTPMalformedResponses(t *testing.T) {
+	ctx := context.Background()
+	sch, err := crypto.GetSchemeByID(crypto.DefaultSchemeID)
+	require.NoError(t, err)
+	info, results := resultMock.VerifiableResults(3, sch)
+	valid, err := json.Marshal(&client.RandomData{
+		Rnd:               results[1].GetRound(),
+		Sig:               results[1].GetSignature(),
+		PreviousSignature: results[1].GetPreviousSignature(),
+	})
+	require.NoError(t, err)
+
+	var body []byte
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		_, _ = w.Write(body)
+	}))
+	defer server.Close()
+
+	tests := []struct {
+		name     string
+		body     string
+		opts     []Option
+		round    uint64
+		expected error
+	}{
+		{"valid", string(valid), nil, 2, nil},
+		{"extra field", `{"extra":1,` + string(valid[1:]), nil, 2, nil},
+		{"strict extra field", `{"extra":1,` + string(valid[1:]), []Option{WithStrictDecoding()}, 2, ErrUnknownField},
+		{"missing round", `{"signature":"abcd","previous_signature":"abcd"}`, nil, 0, ErrMissingField},
+		{"missing signature", `{"round":2,"previous_signature":"abcd"}`, nil, 2, ErrMissingField},
+		{"missing previous signature", `{"round":2,"signature":"abcd"}`, nil, 2, ErrMissingField},
+		{"future round", `{"round":1000000,"signature":"abcd","previous_signature":"abcd"}`, nil, 0, ErrImplausibleRound},
+		{"unexpected round", string(valid), nil, 3, nil},
+		{"strict unexpected round", string(valid), []Option{WithStrictDecoding()}, 3, ErrImplausibleRound},
+		{"too large", string(valid), []Option{WithMaxResponseSize(16)}, 2, ErrResponseTooLarge},
+		{"default beacon", `{"beacon_id":"default",` + string(valid[1:]), nil, 2, nil},
+		{"other beacon", `{"beacon_id":"other",` + string(valid[1:]), nil, 2, drand.ErrBeaconIDMismatch},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			body = []byte(tt.body)
+			c, err := NewWithInfo(nil, server.URL, info, nil, tt.opts...)
+			require.NoError(t, err)
+			defer c.Close()
+
+			r, err := c.Get(ctx, tt.round)
+			if tt.expected == nil {
+				require.NoError(t, err)
+				require.Equal(t, results[1].GetRandomness(), r.GetRandomness())
+				return
+			}
+			require.ErrorIs(t, err, tt.expected)
+			var merr *MalformedResponseError
+			require.ErrorAs(t, err, &merr)
+		})
+	}
+}
+
+func TestHTTPBeaconID(t *testing.T) {
+	ctx := context.Background()
+	relay := clienttest.NewRelay(t, clienttest.WithBeaconID("quicknet"))
+
+	c, err := New(ctx, nil, relay.URL(), nil, nil, WithBeaconID("quicknet"))
+	require.NoError(t, err)
+	defer c.Close()
+	require.True(t, relay.Info().Equal(c.chainInfo))
+	_, err = c.Get(ctx, 1)
+	require.NoError(t, err)
+
+	_, err = New(ctx, nil, relay.URL(), nil, nil, WithBeaconID("evmnet"))
+	require.ErrorIs(t, err, drand.ErrBeaconIDMismatch)
+
+	_, err = New(ctx, nil, relay.URL(), relay.Info().Hash(), nil, WithBeaconID("evmnet"))
+	require.ErrorIs(t, err, drand.ErrBeaconIDMismatch)
+
+	_, err = NewWithInfo(nil, relay.URL(), relay.Info(), nil, WithBeaconID("evmnet"))
+	require.ErrorIs(t, err, drand.ErrBeaconIDMismatch)
+}
+
+func TestHTTPAPINegotiation(t *testing.T) {
+	ctx := context.Background()
+	for _, tt := range []struct {
+		name     string
+		relay    []clienttest.Option
+		opts     []Option
+		expected string
+	}{
+		{"v2 relay", nil, nil, "/v2/chains/"},
+		{"v2 relay by beacon ID", []clienttest.Option{clienttest.WithBeaconID("quicknet")}, []Option{WithBeaconID("quicknet")}, "/v2/beacons/quicknet/"},
+		{"v1 relay", []clienttest.Option{clienttest.WithoutV2()}, nil, "/public/"},
+		{"v1 relay by beacon ID", []clienttest.Option{clienttest.WithoutV2(), clienttest.WithBeaconID("quicknet")}, []Option{WithBeaconID("quicknet")}, "/public/"},
+		{"forced v1", nil, []Option{WithAPIVersion(APIv1)}, "/public/"},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			relay := clienttest.NewRelay(t, tt.relay...)
+			var lk sync.Mutex
+			var paths []string
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				lk.Lock()
+				paths = append(paths, r.URL.Path)
+				lk.Unlock()
+				relay.ServeHTTP(w, r)
+			}))
+			defer server.Close()
+
+			c, err := New(ctx, nil, server.URL, nil, nil, tt.opts...)
+			require.NoError(t, err)
+			defer c.Close()
+			require.True(t, relay.Info().Equal(c.chainInfo))
+
+			for _, round := range []uint64{0, 3} {
+				r, err := c.Get(ctx, round)
+				require.NoError(t, err)
+				require.Equal(t, relay.Result(r.GetRound()).GetRandomness(), r.GetRandomness())
+			}
+
+			lk.Lock()
+			defer lk.Unlock()
+			require.Contains(t, paths[len(paths)-1], tt.expected)
+		})
+	}
+
+	relay := clienttest.NewRelay(t, clienttest.WithoutV2())
+	_, err := New(ctx, nil, relay.URL(), nil, nil, WithAPIVersion(APIv2))
+	require.Error(t, err)
+}
+
+func TestHTTPAPIProbeFailure(t *testing.T) {
+	ctx := context.Background()
+	relay := clienttest.NewRelay(t)
+	var lk sync.Mutex
+	var paths []string
+	failing := true
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		lk.Lock()
+		paths = append(paths, r.URL.Path)
+		fail := failing && r.URL.Path == "/v2/chains"
+		lk.Unlock()
+		if fail {
+			w.WriteHeader(http.StatusServiceUnavailable)
+			return
+		}
+		relay.ServeHTTP(w, r)
+	}))
+	defer server.Close()
+	lastPath := func() string {
+		lk.Lock()
+		defer lk.Unlock()
+		return paths[len(paths)-1]
+	}
+
+	clk := clock.NewFakeClockAt(time.Now())
+	c, err := New(ctx, nil, server.URL, relay.Info().Hash(), nil)
+	require.NoError(t, err)
+	defer c.Close()
+	c.SetClock(clk)
+
+	// a relay failing to answer the probe is not pinned to v1, but only probed
+	// again after a delay
+	_, err = c.Get(ctx, 1)
+	require.NoError(t, err)
+	require.Contains(t, lastPath(), "/public/")
+	lk.Lock()
+	failing = false
+	lk.Unlock()
+	_, err = c.Get(ctx, 2)
+	require.NoError(t, err)
+	require.Contains(t, lastPath(), "/public/")
+
+	clk.Advance(maxAPIBackoff)
+	_, err = c.Get(ctx, 3)
+	require.NoError(t, err)
+	require.Contains(t, lastPath(), "/v2/chains/")
+}
+
+func TestForAllChains(t *testing.T) {
+	ctx := context.Background()
+	a := clienttest.NewRelay(t)
+	b := clienttest.NewRelay(t, clienttest.WithBeaconID("quicknet"), clienttest.WithoutV2())
+
+	chains, err := Chains(ctx, a.URL())
+	require.NoError(t, err)
+	require.Equal(t, []string{a.Info().HashString()}, chains)
+
+	mc, err := ForAllChains(ctx, nil, a.URL(), b.URL(), "http://nxdomain.local")
+	require.NoError(t, err)
+	defer mc.Close()
+	require.ElementsMatch(t, []string{a.Info().HashString(), b.Info().HashString()}, mc.Chains())
+
+	for _, relay := range []*clienttest.Relay{a, b} {
+		c, ok := mc.Client(relay.Info().HashString())
+		require.True(t, ok)
+		r, err := c.Get(ctx, 2)
+		require.NoError(t, err)
+		require.Equal(t, relay.Result(2).GetRandomness(), r.GetRandomness())
+	}
+
+	_, err = ForAllChains(ctx, nil, "http://nxdomain.local")
+	require.Error(t, err)
+}
+
+func TestHTTPRequestHeaders(t *testing.T) {
+	ctx := context.Background()
+	relay := clienttest.NewRelay(t)
+	var lk sync.Mutex
+	var headers []http.Header
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		lk.Lock()
+		headers = append(headers, r.Header.Clone())
+		lk.Unlock()
+		relay.ServeHTTP(w, r)
+	}))
+	defer server.Close()
+
+	clients := ForURLs(ctx, nil, []string{server.URL}, relay.Info().Hash(),
+		WithUserAgent("monitor/1.0"),
+		WithRequestHook(func(req *http.Request) {
+			req.Header.Set("Authorization", "Bearer token")
+		}),
+	)
+	require.Len(t, clients, 1)
+	defer clients[0].Close()
+	_, err := clients[0].Get(ctx, 1)
+	require.NoError(t, err)
+
+	lk.Lock()
+	defer lk.Unlock()
+	require.NotEmpty(t, headers)
+	for _, h := range headers {
+		require.Equal(t, "monitor/1.0", h.Get("User-Agent"))
+		require.Equal(t, "Bearer token", h.Get("Authorization"))
+	}
+}
+
+func TestHTTPRefreshChainInfo(t *testing.T) {
+	ctx := context.Background()
+	for _, tt := range []struct {
+		name  string
+		relay []clienttest.Option
+		opts  []Option
+	}{
+		{"v2 relay", nil, nil},
+		{"v2 relay by beacon ID", []clienttest.Option{clienttest.WithBeaconID("quicknet")}, []Option{WithBeaconID("quicknet")}},
+		{"v1 relay", []clienttest.Option{clienttest.WithoutV2()}, nil},
+		{"v1 relay by beacon ID", []clienttest.Option{clienttest.WithoutV2(), clienttest.WithBeaconID("quicknet")}, []Option{WithBeaconID("quicknet")}},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			relay := clienttest.NewRelay(t, tt.relay...)
+			c, err := New(ctx, nil, relay.URL(), nil, nil, tt.opts...)
+			require.NoError(t, err)
+			defer c.Close()
+			info, err := c.RefreshChainInfo(ctx)
+			require.NoError(t, err)
+			require.True(t, relay.Info().Equal(info))
+
+			// the relay no longer serves the chain the client was set up with
+			other := clienttest.NewRelay(t, tt.relay...)
+			require.NotEqual(t, relay.Info().Hash(), other.Info().Hash())
+			c, err = NewWithInfo(nil, relay.URL(), other.Info(), nil, tt.opts...)
+			require.NoError(t, err)
+			defer c.Close()
+			info, err = c.RefreshChainInfo(ctx)
+			require.NoError(t, err)
+			require.True(t, relay.Info().Equal(info))
+			cached, err := c.Info(ctx)
+			require.NoError(t, err)
+			require.True(t, other.Info().Equal(cached), "the info of the client is kept")
+		})
+	}
+}
+
+func TestHTTPProxy(t *testing.T) {
+	ctx := context.Background()
+	relay := clienttest.NewRelay(t)
+	var lk sync.Mutex
+	var hosts []string
+	proxy := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		lk.Lock()
+		hosts = append(hosts, r.URL.Host)
+		lk.Unlock()
+		relay.ServeHTTP(w, r)
+	}))
+	defer proxy.Close()
+	proxyURL, err := url.Parse(proxy.URL)
+	require.NoError(t, err)
+
+	// the relay can only be reached through the proxy
+	c, err := New(ctx, nil, "http://relay.invalid", relay.Info().Hash(), nil, WithProxy(proxyURL))
+	require.NoError(t, err)
+	defer c.Close()
+	_, err = c.Get(ctx, 1)
+	require.NoError(t, err)
+
+	lk.Lock()
+	require.NotEmpty(t, hosts)
+	for _, h := range hosts {
+		require.Equal(t, "relay.invalid", h)
+	}
+	lk.Unlock()
+
+	_, err = NewWithInfo(nil, "http://relay.invalid", relay.Info(), roundTripperFunc(http.DefaultTransport.RoundTrip), WithProxy(proxyURL))
+	require.Error(t, err, "a proxy requires an *http.Transport")
+}
+
+func TestHTTPUnixSocket(t *testing.T) {
+	ctx := context.Background()
+	relay := clienttest.NewRelay(t)
+	socket := filepath.Join(t.TempDir(), "relay.sock")
+	lis, err := net.Listen("unix", socket)
+	require.NoError(t, err)
+	srv := httptest.NewUnstartedServer(relay)
+	srv.Listener = lis
+	srv.Start()
+	defer srv.Close()
+
+	c, err := New(ctx, nil, "unix://"+socket, relay.Info().Hash(), nil)
+	require.NoError(t, err)
+	defer c.Close()
+	require.Equal(t, fmt.Sprintf("HTTP(%q)", "unix://"+socket), c.String())
+	r, err := c.Get(ctx, 1)
+	require.NoError(t, err)
+	require.Equal(t, uint64(1), r.GetRound())
+
+	c2, err := NewWithInfo(nil, "unix://"+socket, relay.Info(), nil)
+	require.NoError(t, err)
+	defer c2.Close()
+	_, err = c2.Get(ctx, 2)
+	require.NoError(t, err)
+
+	_, err = NewWithInfo(nil, "unix://", relay.Info(), nil)
+	require.Error(t, err, "a unix socket requires a path")
+	_, err = NewWithInfo(nil, "unix://"+socket, relay.Info(), roundTripperFunc(http.DefaultTransport.RoundTrip))
+	require.Error(t, err, "a unix socket requires an *http.Transport")
+}
+
+func TestHTTPHosts(t *testing.T) {
+	ctx := context.Background()
+	relay := clienttest.NewRelay(t)
+	srv := httptest.NewServer(relay)
+	defer srv.Close()
+	u, err := url.Parse(srv.URL)
+	require.NoError(t, err)
+
+	// the relay can only be reached through its pinned IP
+	hosts := map[string][]netip.Addr{"relay.invalid": {netip.MustParseAddr("::1"), netip.MustParseAddr("127.0.0.1")}}
+	c, err := New(ctx, nil, "http://relay.invalid:"+u.Port(), relay.Info().Hash(), nil,
+		WithHosts(hosts), WithIPPreference(PreferIPv4))
+	require.NoError(t, err)
+	defer c.Close()
+	_, err = c.Get(ctx, 1)
+	require.NoError(t, err)
+
+	c2, err := NewWithInfo(nil, "http://relay.invalid:"+u.Port(), relay.Info(), nil, WithHosts(hosts), WithIPPreference(IPv6Only))
+	require.NoError(t, err)
+	defer c2.Close()
+	_, err = c2.Get(ctx, 1)
+	require.Error(t, err, "the relay does not listen on IPv6")
+}
+
+func TestResolver(t *testing.T) {
+	ctx := context.Background()
+	v4, v6 := netip.MustParseAddr("192.0.2.1"), netip.MustParseAddr("2001:db8::1")
+	now := time.Now()
+	var lookups []string
+	r := newResolver()
+	r.now = func() time.Time { return now }
+	r.lookup = func(_ context.Context, network, host string) ([]netip.Addr, error) {
+		lookups = append(lookups, network+" "+host)
+		if host == "missing.invalid" {
+			return nil, errors.New("no such host")
+		}
+		return []netip.Addr{v6, v4}, nil
+	}
+
+	addrs, err := r.resolve(ctx, "relay.invalid")
+	require.NoError(t, err)
+	require.Equal(t, []netip.Addr{v6, v4}, addrs)
+	r.preference = PreferIPv4
+	addrs, err = r.resolve(ctx, "relay.invalid")
+	require.NoError(t, err)
+	require.Equal(t, []netip.Addr{v4, v6}, addrs)
+	r.preference = IPv6Only
+	addrs, err = r.resolve(ctx, "relay.invalid")
+	require.NoError(t, err)
+	require.Equal(t, []netip.Addr{v6}, addrs)
+	require.Equal(t, []string{"ip relay.invalid", "ip relay.invalid", "ip6 relay.invalid"}, lookups)
+
+	// addresses are cached for the resolve interval
+	lookups = nil
+	r.preference = IPAny
+	r.interval = time.Minute
+	for range 3 {
+		_, err = r.resolve(ctx, "relay.invalid")
+		require.NoError(t, err)
+	}
+	require.Len(t, lookups, 1)
+	now = now.Add(time.Minute)
+	_, err = r.resolve(ctx, "relay.invalid")
+	require.NoError(t, err)
+	require.Len(t, lookups, 2)
+
+	// IPs and pinned hosts are not looked up
+	r.hosts = map[string][]netip.Addr{"pinned.invalid": {v4}}
+	addrs, err = r.resolve(ctx, "pinned.invalid")
+	require.NoError(t, err)
+	require.Equal(t, []netip.Addr{v4}, addrs)
+	addrs, err = r.resolve(ctx, "2001:db8::1")
+	require.NoError(t, err)
+	require.Equal(t, []netip.Addr{v6}, addrs)
+	require.Len(t, lookups, 2)
+	_, err = r.resolve(ctx, "missing.invalid")
+	require.Error(t, err)
+
+	_, err = ParseIPPreference("prefer-ipv6")
+	require.NoError(t, err)
+	_, err = ParseIPPreference("ipv5")
+	require.Error(t, err)
+}
+
+func TestHTTPTLS(t *testing.T) {
+	ctx := context.Background()
+	relay := clienttest.NewRelay(t)
+	srv := httptest.NewUnstartedServer(relay)
+	srv.TLS = &tls.Config{MaxVersion: tls.VersionTLS12}
+	srv.StartTLS()
+	defer srv.Close()
+	roots := x509.NewCertPool()
+	roots.AddCert(srv.Certificate())
+
+	newClient := func(opts ...Option) error {
+		c, err := NewWithInfo(nil, srv.URL, relay.Info(), nil, opts...)
+		require.NoError(t, err)
+		defer c.Close()
+		_, err = c.Get(ctx, 1)
+		return err
+	}
+	require.Error(t, newClient(), "the relay certificate is not trusted by default")
+	require.NoError(t, newClient(WithTLSConfig(&tls.Config{RootCAs: roots, MinVersion: tls.VersionTLS12})))
+	require.Error(t, newClient(WithTLSConfig(&tls.Config{RootCAs: roots, MinVersion: tls.VersionTLS13})))
+
+	trusted := WithTLSConfig(&tls.Config{RootCAs: roots, MinVersion: tls.VersionTLS12})
+	require.NoError(t, newClient(trusted, WithPinnedSPKI([]byte("other"), SPKIHash(srv.Certificate()))))
+	require.ErrorIs(t, newClient(trusted, WithPinnedSPKI(make([]byte, 32))), ErrPinMismatch)
+	require.Error(t, newClient(WithPinnedSPKI(SPKIHash(srv.Certificate()))), "pins do not replace the verification of the chain")
+
+	_, err := NewWithInfo(nil, srv.URL, relay.Info(), roundTripperFunc(http.DefaultTransport.RoundTrip), trusted)
+	require.Error(t, err, "a TLS configuration requires an *http.Transport")
+}
+
+type roundTripperFunc func(*http.Request) (*http.Response, error)
+
+func (f roundTripperFunc) RoundTrip(req *http.Request) (*http.Response, error) {
+	return f(req)
+}
+
+func TestFetchRelayList(t *testing.T) {
+	pub, priv, err := ed25519.GenerateKey(nil)
+	require.NoError(t, err)
+	body, err := json.Marshal(SignRelayList(priv, []string{"https://api.drand.sh", "http://127.0.0.1:8080"}))
+	require.NoError(t, err)
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		_, _ = w.Write(body)
+	}))
+	defer srv.Close()
+	ctx := context.Background()
+
+	relays, err := FetchRelayList(ctx, srv.URL, RelayListSignedBy(pub))
+	require.NoError(t, err)
+	require.Equal(t, []string{"https://api.drand.sh", "http://127.0.0.1:8080"}, relays)
+
+	other, _, err := ed25519.GenerateKey(nil)
+	require.NoError(t, err)
+	_, err = FetchRelayList(ctx, srv.URL, RelayListSignedBy(other))
+	require.ErrorIs(t, err, ErrRelayListRejected)
+
+	digest := sha256.Sum256(body)
+	_, err = FetchRelayList(ctx, srv.URL, RelayListPinned(digest[:]))
+	require.NoError(t, err)
+	_, err = FetchRelayList(ctx, srv.URL, RelayListPinned(make([]byte, sha256.Size)))
+	require.ErrorIs(t, err, ErrRelayListRejected)
+
+	body = []byte(`{"relays":["ftp://api.drand.sh"]}`)
+	_, err = FetchRelayList(ctx, srv.URL, nil)
+	var merr *MalformedResponseError
+	require.ErrorAs(t, err, &merr)
+}
+
+func TestRelayListRefresh(t *testing.T) {
+	relay := clienttest.NewRelay(t)
+	// another relay of the same chain
+	mirror := httptest.NewServer(relay)
+	defer mirror.Close()
+
+	pub, priv, err := ed25519.GenerateKey(nil)
+	require.NoError(t, err)
+	var lk sync.Mutex
+	relays := []string{relay.URL()}
+	list := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		lk.Lock()
+		defer lk.Unlock()
+		require.NoError(t, json.NewEncoder(w).Encode(SignRelayList(priv, relays)))
+	}))
+	defer list.Close()
+
+	c, err := client.New(
+		client.WithChainHash(relay.Info().Hash()),
+		WithRelayList(list.URL, RelayListSignedBy(pub), 20*time.Millisecond),
+	)
+	require.NoError(t, err)
+	defer c.Close()
+
+	upstreams := func() []string {
+		var names []string
+		for _, st := range c.(drand.UpstreamReporter).UpstreamStats() {
+			names = append(names, st.Upstream)
+		}
+		return names
+	}
+	require.Equal(t, []string{fmt.Sprintf("HTTP(%q)", relay.URL()+"/")}, upstreams())
+
+	lk.Lock()
+	relays = []string{mirror.URL}
+	lk.Unlock()
+	require.Eventually(t, func() bool {
+		names := upstreams()
+		return len(names) == 1 && names[0] == fmt.Sprintf("HTTP(%q)", mirror.URL+"/")
+	}, 5*time.Second, 10*time.Millisecond)
+
+	r, err := c.Get(context.Background(), 3)
+	require.NoError(t, err)
+	require.Equal(t, uint64(3), r.GetRound())
+}
+
+func TestHTTPGroupHistory(t *testing.T) {
+	ctx := context.Background()
+	relay := clienttest.NewRelay(t)
+	info := relay.Info()
+	sch, err := crypto.SchemeFromName(info.Scheme)
+	require.NoError(t, err)
+	pair, err := key.NewKeyPair("history.test:1234", sch)
+	require.NoError(t, err)
+
+	var files []string
+	for _, transition := range []int64{info.GenesisTime, info.GenesisTime + 100} {
+		g := &key.Group{
+			Threshold:      1,
+			Period:         info.Period,
+			Scheme:         sch,
+			ID:             info.ID,
+			GenesisTime:    info.GenesisTime,
+			GenesisSeed:    info.GenesisSeed,
+			TransitionTime: transition,
+			Nodes:          []*key.Node{{Identity: pair.Public}},
+			PublicKey:      &key.DistPublic{Coefficients: []kyber.Point{info.PublicKey}},
+		}
+		var buf bytes.Buffer
+		require.NoError(t, toml.NewEncoder(&buf).Encode(g.TOML()))
+		files = append(files, buf.String())
+	}
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/"+info.HashString()+"/groups" || r.URL.Path == "/v2/chains/"+info.HashString()+"/groups" {
+			_ = json.NewEncoder(w).Encode(files)
+			return
+		}
+		relay.ServeHTTP(w, r)
+	}))
+	defer server.Close()
+
+	for _, v := range []APIVersion{APIv1, APIv2} {
+		c, err := NewWithInfo(nil, server.URL, info, nil, WithAPIVersion(v))
+		require.NoError(t, err)
+		h, err := client.FetchGroupHistory(ctx, c)
+		require.NoError(t, err)
+		require.Len(t, h.Groups(), 2)
+		require.Equal(t, info.GenesisTime+100, h.GroupAt(1000).TransitionTime)
+		require.True(t, h.PublicKeyAt(1).Equal(info.PublicKey))
+		require.NoError(t, c.Close())
+	}
+
+	c, err := NewWithInfo(nil, relay.URL(), info, nil)
+	require.NoError(t, err)
+	defer c.Close()
+	_, err = client.FetchGroupHistory(ctx, c)
+	require.ErrorIs(t, err, client.ErrGroupHistoryUnsupported)
+}
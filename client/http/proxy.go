@@ -0,0 +1,68 @@
+package http
+
+import (
+	"errors"
+	nhttp "net/http"
+	"net/url"
+	"os"
+	"sync"
+
+	"golang.org/x/net/http/httpproxy"
+)
+
+// WithProxy sends the requests of the client through the proxy at u, e.g.
+// "http://proxy.example.com:3128" or "socks5://127.0.0.1:9050" to use Tor,
+// instead of the proxy configured by the environment, see ProxyFromEnvironment.
+// It requires the transport of the client to be an *http.Transport.
+func WithProxy(u *url.URL) Option {
+	return func(h *httpClient) {
+		h.proxy = u
+	}
+}
+
+var envProxy = sync.OnceValue(func() func(*url.URL) (*url.URL, error) {
+	cfg := httpproxy.FromEnvironment()
+	all := os.Getenv("ALL_PROXY")
+	if all == "" {
+		all = os.Getenv("all_proxy")
+	}
+	if cfg.HTTPProxy == "" {
+		cfg.HTTPProxy = all
+	}
+	if cfg.HTTPSProxy == "" {
+		cfg.HTTPSProxy = all
+	}
+	return cfg.ProxyFunc()
+})
+
+// ProxyFromEnvironment returns the proxy to use for req as configured by the
+// environment: the HTTP_PROXY, HTTPS_PROXY and NO_PROXY variables, as for
+// http.ProxyFromEnvironment, and ALL_PROXY, used by default for both schemes.
+// The proxy can be a SOCKS5 one, e.g. "socks5://127.0.0.1:9050". The
+// environment is read once, on first use.
+func ProxyFromEnvironment(req *nhttp.Request) (*url.URL, error) {
+	return envProxy()(req.URL)
+}
+
+// defaultTransport is the transport of the clients created without one: the
+// default transport of net/http, honoring ALL_PROXY as well.
+var defaultTransport = sync.OnceValue(func() nhttp.RoundTripper {
+	t, ok := nhttp.DefaultTransport.(*nhttp.Transport)
+	if !ok {
+		return nhttp.DefaultTransport
+	}
+	t = t.Clone()
+	t.Proxy = ProxyFromEnvironment
+	return t
+})
+
+// proxiedTransport returns a copy of transport using the proxy at u.
+func proxiedTransport(transport nhttp.RoundTripper, u *url.URL) (nhttp.RoundTripper, error) {
+	t, ok := transport.(*nhttp.Transport)
+	if !ok {
+		return nil, errors.New("a proxy can only be set on an *http.Transport")
+	}
+	t = t.Clone()
+	t.Proxy = nhttp.ProxyURL(u)
+	return t, nil
+}
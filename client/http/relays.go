@@ -0,0 +1,138 @@
+package http
+
+import (
+	"bytes"
+	"context"
+	"crypto/ed25519"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	nhttp "net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	json "github.com/nikkolasg/hexjson"
+
+	"github.com/drand/drand/v2/common/log"
+	"github.com/drand/go-clients/client"
+	"github.com/drand/go-clients/drand"
+)
+
+// relayListDomain prefixes the message signed in relay lists, so that their
+// signatures cannot be mistaken for signatures of other documents.
+const relayListDomain = "drand relay list v1\n"
+
+// ErrRelayListRejected means a relay list is not signed by the expected key,
+// or does not have the pinned digest.
+var ErrRelayListRejected = errors.New("relay list rejected")
+
+// RelayList is the JSON document listing the relays of an operator, fetched
+// by FetchRelayList, e.g.
+//
+//	{"relays":["https://api.drand.sh","https://api2.drand.sh"],"signature":"…"}
+type RelayList struct {
+	// Relays are the root URLs of the HTTP relays.
+	Relays []string `json:"relays"`
+	// Signature is the hex encoded ed25519 signature of the relays by the
+	// operator, for signed lists, see SignRelayList.
+	Signature string `json:"signature,omitempty"`
+}
+
+// RelayListVerifier checks a relay list before its relays are used. body is
+// the document the list was decoded from.
+type RelayListVerifier func(body []byte, list *RelayList) error
+
+// RelayListSignedBy accepts the relay lists signed by key with SignRelayList,
+// so that the lists can change without changing the configuration of the
+// clients.
+func RelayListSignedBy(key ed25519.PublicKey) RelayListVerifier {
+	return func(_ []byte, list *RelayList) error {
+		sig, err := hex.DecodeString(list.Signature)
+		if err != nil || !ed25519.Verify(key, relayListMessage(list.Relays), sig) {
+			return fmt.Errorf("%w: invalid signature", ErrRelayListRejected)
+		}
+		return nil
+	}
+}
+
+// RelayListPinned only accepts the relay list document whose SHA-256 digest
+// is given, so that the list can only change with the configuration of the
+// clients.
+func RelayListPinned(digest []byte) RelayListVerifier {
+	return func(body []byte, _ *RelayList) error {
+		if sum := sha256.Sum256(body); !bytes.Equal(sum[:], digest) {
+			return fmt.Errorf("%w: digest %x does not match the pinned one", ErrRelayListRejected, sum)
+		}
+		return nil
+	}
+}
+
+// SignRelayList returns the list of the relays signed with key, to be served
+// to clients checking it with RelayListSignedBy.
+func SignRelayList(key ed25519.PrivateKey, relays []string) *RelayList {
+	return &RelayList{
+		Relays:    relays,
+		Signature: hex.EncodeToString(ed25519.Sign(key, relayListMessage(relays))),
+	}
+}
+
+func relayListMessage(relays []string) []byte {
+	return []byte(relayListDomain + strings.Join(relays, "\n"))
+}
+
+// FetchRelayList gets the relay list at listURL, checks it with verify, if not
+// nil, and returns its relays. Unchecked lists can only make the clients use
+// other relays, whose beacons are still verified against the trusted chain.
+func FetchRelayList(ctx context.Context, listURL string, verify RelayListVerifier) ([]string, error) {
+	req, err := nhttp.NewRequestWithContext(ctx, nhttp.MethodGet, listURL, nhttp.NoBody)
+	if err != nil {
+		return nil, fmt.Errorf("creating request: %w", err)
+	}
+	resp, err := nhttp.DefaultClient.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("doing request: %w", err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != nhttp.StatusOK {
+		return nil, fmt.Errorf("got invalid status %d doing GET request to %q", resp.StatusCode, listURL)
+	}
+	body, err := io.ReadAll(io.LimitReader(resp.Body, defaultMaxResponseSize))
+	if err != nil {
+		return nil, fmt.Errorf("reading response: %w", err)
+	}
+
+	var list RelayList
+	if err := json.Unmarshal(body, &list); err != nil {
+		return nil, &MalformedResponseError{URL: listURL, Err: fmt.Errorf("decoding relay list: %w", err)}
+	}
+	if verify != nil {
+		if err := verify(body, &list); err != nil {
+			return nil, err
+		}
+	}
+	for _, relay := range list.Relays {
+		u, err := url.Parse(relay)
+		if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+			return nil, &MalformedResponseError{URL: listURL, Err: fmt.Errorf("invalid relay URL %q", relay)}
+		}
+	}
+	return list.Relays, nil
+}
+
+// WithRelayList makes a client use the HTTP relays of the relay list at
+// listURL, checked with verify, see FetchRelayList, in addition to the clients
+// given with client.From. The list is fetched again every interval, if
+// positive, to follow the changes of the relays, see client.WithRelayList.
+// The relay clients are made with the given options.
+func WithRelayList(listURL string, verify RelayListVerifier, interval time.Duration, opts ...Option) client.Option {
+	list := func(ctx context.Context) ([]string, error) {
+		return FetchRelayList(ctx, listURL, verify)
+	}
+	ctor := func(ctx context.Context, l log.Logger, url string, chainHash []byte) (drand.Client, error) {
+		return New(ctx, l, url, chainHash, nil, opts...)
+	}
+	return client.WithRelayList(list, ctor, interval)
+}
@@ -0,0 +1,217 @@
+package http
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	nhttp "net/http"
+	"net/netip"
+	"slices"
+	"sync"
+	"time"
+)
+
+// IPPreference selects the IP versions of the addresses a client connects to.
+type IPPreference int
+
+const (
+	// IPAny tries the addresses of a host in the order of the resolver.
+	IPAny IPPreference = iota
+	// PreferIPv4 tries the IPv4 addresses of a host before its IPv6 ones.
+	PreferIPv4
+	// PreferIPv6 tries the IPv6 addresses of a host before its IPv4 ones.
+	PreferIPv6
+	// IPv4Only only connects to the IPv4 addresses of a host.
+	IPv4Only
+	// IPv6Only only connects to the IPv6 addresses of a host.
+	IPv6Only
+)
+
+var ipPreferences = map[string]IPPreference{
+	"any":         IPAny,
+	"prefer-ipv4": PreferIPv4,
+	"prefer-ipv6": PreferIPv6,
+	"ipv4":        IPv4Only,
+	"ipv6":        IPv6Only,
+}
+
+// ParseIPPreference parses the name of an IP preference, one of "any",
+// "prefer-ipv4", "prefer-ipv6", "ipv4" and "ipv6".
+func ParseIPPreference(s string) (IPPreference, error) {
+	p, ok := ipPreferences[s]
+	if !ok {
+		return IPAny, fmt.Errorf("unknown IP preference %q", s)
+	}
+	return p, nil
+}
+
+// WithHosts connects to the given IPs for the given hostnames instead of
+// resolving them, as /etc/hosts would, e.g. to pin relays in environments
+// with flaky or split-horizon DNS. TLS certificates are still checked against
+// the hostnames. It requires the transport of the client to be an
+// *http.Transport.
+func WithHosts(hosts map[string][]netip.Addr) Option {
+	return func(h *httpClient) {
+		h.resolverConfig().hosts = hosts
+	}
+}
+
+// WithIPPreference selects the IP versions of the addresses the client
+// connects to, IPAny by default. It requires the transport of the client to
+// be an *http.Transport.
+func WithIPPreference(p IPPreference) Option {
+	return func(h *httpClient) {
+		h.resolverConfig().preference = p
+	}
+}
+
+// WithResolveInterval caches the addresses of the hostnames resolved by the
+// client for d, so that the client keeps connecting to the same addresses
+// until they are resolved again, instead of resolving the hostnames for every
+// new connection. It requires the transport of the client to be an
+// *http.Transport.
+func WithResolveInterval(d time.Duration) Option {
+	return func(h *httpClient) {
+		h.resolverConfig().interval = d
+	}
+}
+
+// resolver resolves the hostnames dialed by a client, see WithHosts,
+// WithIPPreference and WithResolveInterval.
+type resolver struct {
+	hosts      map[string][]netip.Addr
+	preference IPPreference
+	interval   time.Duration
+
+	lookup func(ctx context.Context, network, host string) ([]netip.Addr, error)
+	now    func() time.Time
+
+	lk    sync.Mutex
+	cache map[string]resolved
+}
+
+// resolved are the addresses of a hostname, resolved at a given time.
+type resolved struct {
+	addrs []netip.Addr
+	at    time.Time
+}
+
+func newResolver() *resolver {
+	return &resolver{
+		lookup: net.DefaultResolver.LookupNetIP,
+		now:    time.Now,
+		cache:  make(map[string]resolved),
+	}
+}
+
+// resolve returns the addresses to connect to for host, in the order they
+// should be tried.
+func (r *resolver) resolve(ctx context.Context, host string) ([]netip.Addr, error) {
+	if ip, err := netip.ParseAddr(host); err == nil {
+		return r.order([]netip.Addr{ip}), nil
+	}
+	if addrs, ok := r.hosts[host]; ok {
+		return r.order(addrs), nil
+	}
+
+	r.lk.Lock()
+	cached, ok := r.cache[host]
+	r.lk.Unlock()
+	if ok && r.now().Sub(cached.at) < r.interval {
+		return r.order(cached.addrs), nil
+	}
+
+	network := "ip"
+	switch r.preference {
+	case IPv4Only:
+		network = "ip4"
+	case IPv6Only:
+		network = "ip6"
+	}
+	addrs, err := r.lookup(ctx, network, host)
+	if err != nil {
+		return nil, err
+	}
+	if r.interval > 0 {
+		r.lk.Lock()
+		r.cache[host] = resolved{addrs: addrs, at: r.now()}
+		r.lk.Unlock()
+	}
+	return r.order(addrs), nil
+}
+
+// order filters and sorts addrs according to the IP preference.
+func (r *resolver) order(addrs []netip.Addr) []netip.Addr {
+	ordered := make([]netip.Addr, 0, len(addrs))
+	for _, a := range addrs {
+		a = a.Unmap()
+		if (r.preference == IPv4Only && !a.Is4()) || (r.preference == IPv6Only && !a.Is6()) {
+			continue
+		}
+		ordered = append(ordered, a)
+	}
+	switch r.preference {
+	case PreferIPv4:
+		slices.SortStableFunc(ordered, func(a, b netip.Addr) int { return compareIs4(b, a) })
+	case PreferIPv6:
+		slices.SortStableFunc(ordered, compareIs4)
+	}
+	return ordered
+}
+
+// compareIs4 sorts IPv6 addresses before IPv4 ones.
+func compareIs4(a, b netip.Addr) int {
+	switch {
+	case a.Is4() == b.Is4():
+		return 0
+	case a.Is4():
+		return 1
+	default:
+		return -1
+	}
+}
+
+// dialContext wraps dial so that it connects to the addresses resolved by r,
+// trying them in order until one accepts the connection.
+func (r *resolver) dialContext(dial func(ctx context.Context, network, addr string) (net.Conn, error)) func(
+	ctx context.Context, network, addr string) (net.Conn, error) {
+	return func(ctx context.Context, network, addr string) (net.Conn, error) {
+		host, port, err := net.SplitHostPort(addr)
+		if err != nil {
+			return nil, err
+		}
+		addrs, err := r.resolve(ctx, host)
+		if err != nil {
+			return nil, err
+		}
+		if len(addrs) == 0 {
+			return nil, &net.DNSError{Err: "no address of the allowed IP versions", Name: host, IsNotFound: true}
+		}
+		var errs error
+		for _, a := range addrs {
+			conn, err := dial(ctx, network, net.JoinHostPort(a.String(), port))
+			if err == nil {
+				return conn, nil
+			}
+			errs = errors.Join(errs, err)
+		}
+		return nil, errs
+	}
+}
+
+// resolvingTransport returns a copy of transport dialing the addresses
+// resolved by r.
+func resolvingTransport(transport nhttp.RoundTripper, r *resolver) (nhttp.RoundTripper, error) {
+	t, ok := transport.(*nhttp.Transport)
+	if !ok {
+		return nil, errors.New("a resolution strategy can only be set on an *http.Transport")
+	}
+	t = t.Clone()
+	dial := t.DialContext
+	if dial == nil {
+		dial = (&net.Dialer{Timeout: 30 * time.Second, KeepAlive: 30 * time.Second}).DialContext
+	}
+	t.DialContext = r.dialContext(dial)
+	return t, nil
+}
@@ -0,0 +1,116 @@
+package http
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+
+	json "github.com/nikkolasg/hexjson"
+
+	"github.com/drand/drand/v2/common"
+	"github.com/drand/drand/v2/crypto"
+	"github.com/drand/go-clients/client"
+	"github.com/drand/go-clients/drand"
+)
+
+// defaultMaxResponseSize bounds the size of the relay responses read by the
+// client, which are a few hundred bytes in practice.
+const defaultMaxResponseSize = 64 * 1024
+
+var (
+	// ErrResponseTooLarge means the relay response exceeded the maximum response size.
+	ErrResponseTooLarge = errors.New("response too large")
+	// ErrUnknownField means the relay response has fields that are not part of the
+	// drand HTTP API, when strict decoding is enabled.
+	ErrUnknownField = errors.New("unknown field")
+	// ErrMissingField means a required field is absent from the relay response.
+	ErrMissingField = errors.New("missing field")
+	// ErrImplausibleRound means the relay answered with a round that cannot have
+	// been produced yet according to the chain info or, when strict decoding is
+	// enabled, with another round than the requested one.
+	ErrImplausibleRound = errors.New("implausible round")
+)
+
+// MalformedResponseError is returned when a relay response cannot be decoded
+// or fails validation. It wraps the cause, e.g. ErrMissingField.
+type MalformedResponseError struct {
+	URL string
+	Err error
+}
+
+func (e *MalformedResponseError) Error() string {
+	return fmt.Sprintf("malformed response from %q: %v", e.URL, e.Err)
+}
+
+func (e *MalformedResponseError) Unwrap() error {
+	return e.Err
+}
+
+// beaconResponse mirrors the beacon format of the drand HTTP API, to detect
+// unknown fields when decoding strictly.
+type beaconResponse struct {
+	Round             uint64 `json:"round"`
+	Randomness        []byte `json:"randomness"`
+	Signature         []byte `json:"signature"`
+	PreviousSignature []byte `json:"previous_signature"`
+	BeaconID          string `json:"beacon_id"`
+}
+
+// readBody reads a response body up to the maximum response size of the client.
+func (h *httpClient) readBody(url string, body io.Reader) ([]byte, error) {
+	return readBodyLimit(url, body, h.maxResponseSize)
+}
+
+// readBodyLimit reads a response body up to limit bytes.
+func readBodyLimit(url string, body io.Reader, limit int64) ([]byte, error) {
+	b, err := io.ReadAll(io.LimitReader(body, limit+1))
+	if err != nil {
+		return nil, fmt.Errorf("reading response: %w", err)
+	}
+	if int64(len(b)) > limit {
+		return nil, &MalformedResponseError{URL: url, Err: fmt.Errorf("%w: more than %d bytes", ErrResponseTooLarge, limit)}
+	}
+	return b, nil
+}
+
+// decodeBeacon decodes and validates a beacon served for the given round, 0 meaning the latest one.
+func (h *httpClient) decodeBeacon(url string, body []byte, round uint64) (*client.RandomData, error) {
+	malformed := func(err error) error {
+		return &MalformedResponseError{URL: url, Err: err}
+	}
+
+	if h.strict {
+		dec := json.NewDecoder(bytes.NewReader(body))
+		dec.DisallowUnknownFields()
+		if err := dec.Decode(&beaconResponse{}); err != nil {
+			return nil, malformed(fmt.Errorf("%w: %w", ErrUnknownField, err))
+		}
+	}
+
+	rd := client.RandomData{}
+	if err := json.Unmarshal(body, &rd); err != nil {
+		return nil, malformed(fmt.Errorf("decoding response: %w", err))
+	}
+
+	switch {
+	case rd.Rnd == 0:
+		return nil, malformed(fmt.Errorf("%w: round", ErrMissingField))
+	case len(rd.Sig) == 0:
+		return nil, malformed(fmt.Errorf("%w: signature", ErrMissingField))
+	case h.chainInfo.Scheme == crypto.DefaultSchemeID && len(rd.PreviousSignature) == 0:
+		return nil, malformed(fmt.Errorf("%w: previous_signature", ErrMissingField))
+	case rd.BeaconID != "" && !common.CompareBeaconIDs(rd.BeaconID, h.chainInfo.ID):
+		return nil, malformed(fmt.Errorf("%w: got beacon %q instead of %q", drand.ErrBeaconIDMismatch, rd.BeaconID, h.chainInfo.ID))
+	case h.strict && round != 0 && rd.Rnd != round:
+		return nil, malformed(fmt.Errorf("%w: got round %d instead of %d", ErrImplausibleRound, rd.Rnd, round))
+	}
+	// allow for a period of clock drift between the relay and us
+	next, _ := common.NextRound(h.clock.Now().Unix(), h.chainInfo.Period, h.chainInfo.GenesisTime)
+	if rd.Rnd > next {
+		return nil, malformed(fmt.Errorf("%w: round %d is not due before round %d", ErrImplausibleRound, rd.Rnd, next))
+	}
+
+	rd.Random = crypto.RandomnessFromSignature(rd.GetSignature())
+	return &rd, nil
+}
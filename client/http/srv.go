@@ -0,0 +1,112 @@
+package http
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"net/url"
+	"slices"
+	"strconv"
+	"strings"
+
+	"github.com/drand/drand/v2/common/log"
+)
+
+// srvPrefix prefixes the scheme of the URLs naming SRV records, e.g.
+// "srv+https://_drand._tcp.example.org".
+const srvPrefix = "srv+"
+
+// lookupSRV resolves SRV records, replaced in tests.
+var lookupSRV = net.DefaultResolver.LookupSRV
+
+// SRVTarget is a relay listed in an SRV record, see ExpandSRV.
+type SRVTarget struct {
+	URL      string
+	Priority uint16
+	Weight   uint16
+}
+
+// Option returns the option giving the client of the relay the priority and
+// weight of its SRV record.
+func (t SRVTarget) Option() Option {
+	return WithWeight(t.Priority, t.Weight)
+}
+
+// IsSRV reports whether url names SRV records rather than a relay, e.g.
+// "srv+https://_drand._tcp.example.org".
+func IsSRV(url string) bool {
+	return strings.HasPrefix(url, srvPrefix)
+}
+
+// ExpandSRV returns the relays listed in the SRV records named by url, e.g.
+// "srv+https://_drand._tcp.example.org", in the order of their priorities,
+// with the scheme following "srv+" and the path of url, if any. Fleet
+// operators can thus manage the set of relays used by the clients in DNS.
+func ExpandSRV(ctx context.Context, srvURL string) ([]SRVTarget, error) {
+	if !IsSRV(srvURL) {
+		return nil, fmt.Errorf("%q is not an SRV URL", srvURL)
+	}
+	u, err := url.Parse(strings.TrimPrefix(srvURL, srvPrefix))
+	if err != nil {
+		return nil, fmt.Errorf("invalid SRV URL %q: %w", srvURL, err)
+	}
+	if u.Scheme != "http" && u.Scheme != "https" || u.Hostname() == "" || u.Port() != "" {
+		return nil, fmt.Errorf("invalid SRV URL %q: srv+http(s)://NAME is required", srvURL)
+	}
+	_, records, err := lookupSRV(ctx, "", "", u.Hostname())
+	if err != nil {
+		return nil, fmt.Errorf("looking up SRV records of %s: %w", u.Hostname(), err)
+	}
+
+	targets := make([]SRVTarget, 0, len(records))
+	for _, r := range records {
+		host := strings.TrimSuffix(r.Target, ".")
+		// a target of "." means that the service is not available
+		if host == "" {
+			continue
+		}
+		if !(u.Scheme == "http" && r.Port == 80 || u.Scheme == "https" && r.Port == 443) {
+			host = net.JoinHostPort(host, strconv.Itoa(int(r.Port)))
+		}
+		target := url.URL{Scheme: u.Scheme, Host: host, Path: u.Path}
+		targets = append(targets, SRVTarget{URL: target.String(), Priority: r.Priority, Weight: r.Weight})
+	}
+	if len(targets) == 0 {
+		return nil, fmt.Errorf("no relay listed in the SRV records of %s", u.Hostname())
+	}
+	return targets, nil
+}
+
+// RelayURL is the URL of a relay, see ExpandURLs.
+type RelayURL struct {
+	URL  string
+	opts []Option
+}
+
+// Options returns opts followed by the options of the client of the relay,
+// e.g. the priority and weight of its SRV record.
+func (r RelayURL) Options(opts ...Option) []Option {
+	return append(slices.Clone(opts), r.opts...)
+}
+
+// ExpandURLs expands the URLs of urls naming SRV records into the URLs of the
+// relays they list, see ExpandSRV, logging and skipping those which cannot be
+// looked up.
+func ExpandURLs(ctx context.Context, l log.Logger, urls []string) []RelayURL {
+	relays := make([]RelayURL, 0, len(urls))
+	for _, u := range urls {
+		if !IsSRV(u) {
+			relays = append(relays, RelayURL{URL: u})
+			continue
+		}
+		targets, err := ExpandSRV(ctx, u)
+		if err != nil {
+			l.Warnw("", "http_client", "failed to expand SRV URL", "url", u, "err", err)
+			continue
+		}
+		for _, t := range targets {
+			relays = append(relays, RelayURL{URL: t.URL, opts: []Option{t.Option()}})
+		}
+	}
+	return relays
+}
@@ -0,0 +1,90 @@
+package http
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"crypto/tls"
+	"crypto/x509"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	nhttp "net/http"
+)
+
+// ErrPinMismatch means a relay presented a certificate chain none of whose
+// public keys is pinned, see WithPinnedSPKI.
+var ErrPinMismatch = errors.New("no pinned public key in certificate chain")
+
+// WithTLSConfig sets the TLS configuration of the connections to the relay,
+// e.g. to require TLS 1.3 or a private certificate authority, instead of the
+// one of the transport of the client. It requires the transport of the client
+// to be an *http.Transport.
+func WithTLSConfig(cfg *tls.Config) Option {
+	return func(h *httpClient) {
+		h.tlsConfig = cfg.Clone()
+	}
+}
+
+// WithPinnedSPKI only accepts the relays presenting a certificate chain with
+// one of the given public keys, identified by the SHA-256 digest of their
+// DER encoded SubjectPublicKeyInfo, see SPKIHash, in addition to the usual
+// verification of the chain. It requires the transport of the client to be
+// an *http.Transport.
+func WithPinnedSPKI(pins ...[]byte) Option {
+	return func(h *httpClient) {
+		h.spkiPins = append(h.spkiPins, pins...)
+	}
+}
+
+// SPKIHash returns the SHA-256 digest of the SubjectPublicKeyInfo of cert, as
+// pinned with WithPinnedSPKI, e.g. the digest printed by
+//
+//	openssl x509 -in cert.pem -pubkey -noout | openssl pkey -pubin -outform der | openssl dgst -sha256
+func SPKIHash(cert *x509.Certificate) []byte {
+	h := sha256.Sum256(cert.RawSubjectPublicKeyInfo)
+	return h[:]
+}
+
+// verifyPins returns a tls.Config.VerifyConnection function checking that the
+// certificate chain of a connection has one of the pinned public keys, and
+// then calling next, if any.
+func verifyPins(pins [][]byte, next func(tls.ConnectionState) error) func(tls.ConnectionState) error {
+	return func(cs tls.ConnectionState) error {
+		for _, cert := range cs.PeerCertificates {
+			spki := SPKIHash(cert)
+			for _, pin := range pins {
+				if bytes.Equal(spki, pin) {
+					if next != nil {
+						return next(cs)
+					}
+					return nil
+				}
+			}
+		}
+		var leaf string
+		if len(cs.PeerCertificates) > 0 {
+			leaf = hex.EncodeToString(SPKIHash(cs.PeerCertificates[0]))
+		}
+		return fmt.Errorf("%w: %s presented %s", ErrPinMismatch, cs.ServerName, leaf)
+	}
+}
+
+// tlsTransport returns a copy of transport using cfg, or its own TLS
+// configuration if nil, with the given public keys pinned, if any.
+func tlsTransport(transport nhttp.RoundTripper, cfg *tls.Config, pins [][]byte) (nhttp.RoundTripper, error) {
+	t, ok := transport.(*nhttp.Transport)
+	if !ok {
+		return nil, errors.New("a TLS configuration can only be set on an *http.Transport")
+	}
+	t = t.Clone()
+	if cfg != nil {
+		t.TLSClientConfig = cfg.Clone()
+	}
+	if len(pins) > 0 {
+		if t.TLSClientConfig == nil {
+			t.TLSClientConfig = &tls.Config{MinVersion: tls.VersionTLS12}
+		}
+		t.TLSClientConfig.VerifyConnection = verifyPins(pins, t.TLSClientConfig.VerifyConnection)
+	}
+	return t, nil
+}
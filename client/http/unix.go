@@ -0,0 +1,46 @@
+package http
+
+import (
+	"context"
+	"errors"
+	"net"
+	nhttp "net/http"
+	"strings"
+)
+
+// unixScheme prefixes the URLs of relays listening on a unix domain socket,
+// e.g. "unix:///run/drand/relay.sock".
+const unixScheme = "unix://"
+
+// unixRoot is the root of the requests sent over a unix domain socket, whose
+// host is ignored.
+const unixRoot = "http://unix/"
+
+// unixSocket returns the path of the socket of url if it is the URL of a relay
+// listening on a unix domain socket.
+func unixSocket(url string) (string, bool, error) {
+	path, ok := strings.CutPrefix(url, unixScheme)
+	if !ok {
+		return "", false, nil
+	}
+	if path == "" {
+		return "", false, errors.New("missing unix socket path")
+	}
+	return path, true, nil
+}
+
+// unixTransport returns a copy of transport connecting to the unix domain
+// socket at path for all requests, without any proxy.
+func unixTransport(transport nhttp.RoundTripper, path string) (nhttp.RoundTripper, error) {
+	t, ok := transport.(*nhttp.Transport)
+	if !ok {
+		return nil, errors.New("a unix socket can only be used with an *http.Transport")
+	}
+	t = t.Clone()
+	t.Proxy = nil
+	var d net.Dialer
+	t.DialContext = func(ctx context.Context, _, _ string) (net.Conn, error) {
+		return d.DialContext(ctx, "unix", path)
+	}
+	return t, nil
+}
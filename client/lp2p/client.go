@@ -1,8 +1,11 @@
+//go:build !nolibp2p
+
 package lp2p
 
 import (
 	"context"
 	"encoding/hex"
+	"errors"
 	"fmt"
 	"sync"
 
@@ -10,6 +13,7 @@ import (
 	pubsub "github.com/libp2p/go-libp2p-pubsub"
 	"github.com/libp2p/go-libp2p/core/host"
 	"github.com/libp2p/go-libp2p/core/peer"
+	"github.com/libp2p/go-libp2p/core/pnet"
 	"github.com/multiformats/go-multiaddr"
 	dnsaddr "github.com/multiformats/go-multiaddr-dns"
 	"google.golang.org/protobuf/proto"
@@ -20,12 +24,14 @@ import (
 	"github.com/drand/drand/v2/protobuf/drand"
 	"github.com/drand/go-clients/client"
 	drandi "github.com/drand/go-clients/drand"
+	"github.com/drand/go-clients/internal/lp2p"
+	"github.com/drand/go-clients/internal/metrics"
 )
 
 var _ drandi.LoggingClient = &Client{}
 
 // WatchBufferSize controls how many incoming messages can be in-flight until they start
-// to be dropped by the library when using Client.Watch
+// to be dropped by the library when using Client.Watch, the oldest ones first.
 var WatchBufferSize = 100
 
 // Client is a concrete pubsub client implementation
@@ -35,6 +41,19 @@ type Client struct {
 	cache  client.Cache
 	log    log.Logger
 
+	// done is closed once the topic is left, with the errors in closeErr.
+	done     chan struct{}
+	closeErr error
+
+	// maxBeaconSize bounds the size of the gossiped beacons, if positive.
+	maxBeaconSize int
+	// strict rejects the beacons with unknown fields or foreign metadata.
+	strict bool
+	// topic is the pubsub topic of the client, if not the one of the chain.
+	topic string
+	// namespace is the prefix of the topic of the chain, if not the default one.
+	namespace string
+
 	subs struct {
 		sync.Mutex
 		M map[*int]chan drand.PublicRandResponse
@@ -47,10 +66,10 @@ func (c *Client) SetLog(l log.Logger) {
 }
 
 // WithPubsub provides an option for integrating pubsub notification
-// into a drand client.
-func WithPubsub(ps *pubsub.PubSub) client.Option {
+// into a drand client, configured by the given options.
+func WithPubsub(ps *pubsub.PubSub, opts ...Option) client.Option {
 	return client.WithWatcher(func(l log.Logger, info *chain.Info, cache client.Cache) (client.Watcher, error) {
-		c, err := NewWithPubsub(l, ps, info, cache)
+		c, err := NewWithPubsub(l, ps, info, cache, opts...)
 		if err != nil {
 			return nil, err
 		}
@@ -58,16 +77,20 @@ func WithPubsub(ps *pubsub.PubSub) client.Option {
 	})
 }
 
+// DefaultTopicNamespace is the prefix of the pubsub topics of the public
+// drand chains, see WithTopicNamespace.
+const DefaultTopicNamespace = lp2p.DefaultTopicNamespace
+
 // PubSubTopic generates a drand pubsub topic from a chain hash.
 func PubSubTopic(h string) string {
-	return fmt.Sprintf("/drand/pubsub/v0.0.0/%s", h)
+	return lp2p.PubSubTopic(h)
 }
 
-// NewWithPubsub creates a gossip randomness client. If the logger l is nil, it will default to
-// a default Logger,
+// NewWithPubsub creates a gossip randomness client, configured by the given options. If the logger
+// l is nil, it will default to a default Logger,
 //
 //nolint:funlen,gocyclo // This is a long line
-func NewWithPubsub(l log.Logger, ps *pubsub.PubSub, info *chain.Info, cache client.Cache) (*Client, error) {
+func NewWithPubsub(l log.Logger, ps *pubsub.PubSub, info *chain.Info, cache client.Cache, opts ...Option) (*Client, error) {
 	if info == nil {
 		return nil, fmt.Errorf("no chain supplied for joining")
 	}
@@ -86,12 +109,20 @@ func NewWithPubsub(l log.Logger, ps *pubsub.PubSub, info *chain.Info, cache clie
 	ctx, cancel := context.WithCancel(context.Background())
 	c := &Client{
 		cancel: cancel,
+		done:   make(chan struct{}),
 		cache:  cache,
 		log:    l,
+
+		maxBeaconSize: DefaultMaxBeaconSize,
+	}
+	for _, opt := range opts {
+		opt(c)
 	}
 
-	chainHash := hex.EncodeToString(info.Hash())
-	topic := PubSubTopic(chainHash)
+	topic := c.topic
+	if topic == "" {
+		topic = lp2p.NamespacedTopic(c.namespace, hex.EncodeToString(info.Hash()))
+	}
 	if err := ps.RegisterTopicValidator(topic, randomnessValidator(info, cache, c)); err != nil {
 		cancel()
 		return nil, fmt.Errorf("creating topic: %w", err)
@@ -101,6 +132,11 @@ func NewWithPubsub(l log.Logger, ps *pubsub.PubSub, info *chain.Info, cache clie
 		cancel()
 		return nil, fmt.Errorf("joining pubsub: %w", err)
 	}
+	// this fails when peer scoring was not enabled on the pubsub, or if the
+	// topic was joined before, in which case its parameters are left as is
+	if err := t.SetScoreParams(TopicScoreParams(info.Period)); err != nil {
+		l.Debugw("", "gossip_client", "topic scoring not enabled", "topic", topic, "err", err)
+	}
 	s, err := t.Subscribe()
 	if err != nil {
 		cancel()
@@ -116,9 +152,9 @@ func NewWithPubsub(l log.Logger, ps *pubsub.PubSub, info *chain.Info, cache clie
 				c.log.Debugw("NewPubSub closing because context was canceled", "msg", msg, "err", ctx.Err())
 
 				s.Cancel()
-				err := t.Close()
-				if err != nil {
-					c.log.Errorw("NewPubSub closing goroutine for topic", "err", err)
+				c.closeErr = errors.Join(t.Close(), ps.UnregisterTopicValidator(topic))
+				if c.closeErr != nil {
+					c.log.Errorw("NewPubSub closing goroutine for topic", "err", c.closeErr)
 				}
 
 				c.subs.Lock()
@@ -127,6 +163,7 @@ func NewWithPubsub(l log.Logger, ps *pubsub.PubSub, info *chain.Info, cache clie
 				}
 				c.subs.M = make(map[*int]chan drand.PublicRandResponse)
 				c.subs.Unlock()
+				close(c.done)
 				return
 			}
 			if err != nil {
@@ -216,21 +253,12 @@ func (c *Client) Watch(ctx context.Context) <-chan drandi.Result {
 					c.log.Debugw("innerCh closed")
 					return
 				}
-				dat := &client.RandomData{
-					Rnd:               resp.GetRound(),
-					Random:            crypto.RandomnessFromSignature(resp.GetSignature()),
-					Sig:               resp.GetSignature(),
-					PreviousSignature: resp.GetPreviousSignature(),
-				}
+				dat := client.RandomDataFromProto(&resp)
 				if c.cache != nil {
 					c.cache.Add(resp.GetRound(), dat)
 				}
-				select {
-				case outerCh <- dat:
-					c.log.Debugw("processed random beacon", "round", dat.GetRound())
-				default:
-					c.log.Warnw("", "gossip client", "randomness notification dropped due to a full channel", "round", dat.GetRound())
-				}
+				c.push(outerCh, dat)
+				c.log.Debugw("processed random beacon", "round", dat.GetRound())
 			case <-ctx.Done():
 				c.log.Debugw("client.Watch done")
 				end()
@@ -245,16 +273,53 @@ func (c *Client) Watch(ctx context.Context) <-chan drandi.Result {
 	return outerCh
 }
 
-// Close stops Client, cancels PubSub subscription and closes the topic.
+// push sends a result without blocking, dropping the oldest buffered result
+// when the consumer is not keeping up, as the gRPC client does.
+func (c *Client) push(out chan drandi.Result, r drandi.Result) {
+	for {
+		select {
+		case out <- r:
+			return
+		default:
+		}
+		select {
+		case old := <-out:
+			metrics.ClientWatchDropped.WithLabelValues("gossip").Inc()
+			c.log.Warnw("", "gossip client", "randomness notification dropped due to a full channel", "round", old.GetRound())
+		default:
+		}
+	}
+}
+
+// Transport returns the name of the transport of the client.
+func (c *Client) Transport() string {
+	return "gossip"
+}
+
+// Close stops Client, cancels PubSub subscription, closes the topic and
+// unregisters its validator, returning the errors encountered doing so.
 func (c *Client) Close() error {
 	c.cancel()
-	return nil
+	<-c.done
+	return c.closeErr
 }
 
 // NewPubsub constructs a basic libp2p pubsub module for use with the drand client.
 // The local libp2p host is returned as well to allow to properly close it once done.
-func NewPubsub(ctx context.Context, listenAddr string, relayAddrs []string) (*pubsub.PubSub, host.Host, error) {
-	h, err := libp2p.New(libp2p.ListenAddrStrings(listenAddr))
+// Peer scoring is enabled with the default drand parameters, see PeerScoreParams,
+// messages are signed and their signatures strictly verified, see WithMessageSigning,
+// and the RPCs larger than DefaultMaxMessageSize are rejected, which can all be
+// overridden by the given pubsub options, e.g. pubsub.WithMaxMessageSize.
+func NewPubsub(ctx context.Context, listenAddr string, relayAddrs []string, opts ...pubsub.Option) (*pubsub.PubSub, host.Host, error) {
+	return NewPubsubWithHostOptions(ctx, listenAddr, relayAddrs, nil, opts...)
+}
+
+// NewPubsubWithHostOptions is like NewPubsub, with the libp2p host configured
+// by the given host options as well, e.g. WithPNet to join a private network.
+//
+//nolint:lll // This function has nicely named parameters, so it's long.
+func NewPubsubWithHostOptions(ctx context.Context, listenAddr string, relayAddrs []string, hostOpts []libp2p.Option, opts ...pubsub.Option) (*pubsub.PubSub, host.Host, error) {
+	h, err := libp2p.New(append([]libp2p.Option{libp2p.ListenAddrStrings(listenAddr)}, hostOpts...)...)
 	if err != nil {
 		return nil, nil, err
 	}
@@ -276,6 +341,33 @@ func NewPubsub(ctx context.Context, listenAddr string, relayAddrs []string) (*pu
 		}
 	}
 
-	ps, err := pubsub.NewGossipSub(ctx, h, pubsub.WithDirectPeers(peers))
+	defaults := append(append(defaultPubsubOptions(), pubsub.WithDirectPeers(peers)), lp2p.ScoringOptions()...)
+	ps, err := pubsub.NewGossipSub(ctx, h, append(defaults, opts...)...)
 	return ps, h, err
 }
+
+// WithPNet is a host option making the host join the libp2p private network
+// of psk, so that it only connects to the relays having the same key, see
+// LoadPNetKey. Private networks only support the TCP and WebSocket transports.
+func WithPNet(psk pnet.PSK) libp2p.Option {
+	return libp2p.PrivateNetwork(psk)
+}
+
+// WithNATTraversal is a host option making a host behind NAT reachable without
+// port forwarding: it opens a port with UPnP or NAT-PMP if possible, and
+// otherwise reserves a slot on the given circuit relays, e.g. relays run with
+// --relay-service, upgrading the relayed connections to direct ones with hole
+// punching.
+func WithNATTraversal(relays ...peer.AddrInfo) libp2p.Option {
+	opts := []libp2p.Option{libp2p.NATPortMap(), libp2p.EnableRelay(), libp2p.EnableHolePunching()}
+	if len(relays) > 0 {
+		opts = append(opts, libp2p.EnableAutoRelayWithStaticRelays(relays))
+	}
+	return libp2p.ChainOptions(opts...)
+}
+
+// LoadPNetKey reads the pre-shared key of a libp2p private network from a
+// swarm key file, as used by the --pnet-key flag of the relays.
+func LoadPNetKey(keyPath string) (pnet.PSK, error) {
+	return lp2p.LoadPNetKey(keyPath)
+}
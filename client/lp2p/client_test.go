@@ -1,7 +1,10 @@
+//go:build !nolibp2p
+
 package lp2p
 
 import (
 	"context"
+	"encoding/hex"
 	"fmt"
 	"net/http"
 	"path"
@@ -232,3 +235,30 @@ func waitForConnection(h host.Host, id peer.ID, timeout time.Duration) error {
 		time.Sleep(time.Millisecond * 100)
 	}
 }
+
+func TestTopicNamespace(t *testing.T) {
+	ctx := context.Background()
+	ps, h, err := NewPubsub(ctx, "/ip4/127.0.0.1/tcp/0", nil)
+	require.NoError(t, err)
+	defer h.Close()
+	info := fakeChainInfo()
+	hash := hex.EncodeToString(info.Hash())
+
+	c, err := NewWithPubsub(nil, ps, info, nil, WithTopicNamespace("/example/drand/"))
+	require.NoError(t, err)
+	require.Contains(t, ps.GetTopics(), "/example/drand/"+hash)
+	require.NotContains(t, ps.GetTopics(), PubSubTopic(hash))
+	require.NoError(t, c.Close())
+	require.NotContains(t, ps.GetTopics(), "/example/drand/"+hash, "closing the client leaves its topic")
+
+	// the validator of the topic is unregistered as well
+	c, err = NewWithPubsub(nil, ps, info, nil, WithTopicNamespace("/example/drand/"))
+	require.NoError(t, err)
+	require.NoError(t, c.Close())
+
+	// an explicit topic takes precedence over the namespace
+	c, err = NewWithPubsub(nil, ps, info, nil, WithTopicNamespace("/example/drand"), WithTopic("/example/other"))
+	require.NoError(t, err)
+	require.Contains(t, ps.GetTopics(), "/example/other")
+	require.NoError(t, c.Close())
+}
@@ -0,0 +1,25 @@
+//go:build !nolibp2p
+
+package lp2p
+
+import (
+	"context"
+	"time"
+
+	"github.com/libp2p/go-libp2p/core/host"
+
+	"github.com/drand/drand/v2/common/log"
+	"github.com/drand/go-clients/internal/lp2p"
+)
+
+// WatchRelaysDNS connects h to the relays listed in the TXT records of name,
+// e.g. "_drand-relays.example.org", each record holding one or more relay
+// multiaddrs. The records are looked up again every refresh, 10 minutes if
+// zero, until ctx is done, so that operators can rotate their relays without
+// reconfiguring clients. It blocks, and is meant to be run in a goroutine.
+func WatchRelaysDNS(ctx context.Context, l log.Logger, h host.Host, name string, refresh time.Duration) {
+	if l == nil {
+		l = log.DefaultLogger()
+	}
+	lp2p.WatchDNSPeers(ctx, l, h, nil, name, refresh)
+}
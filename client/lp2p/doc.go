@@ -13,5 +13,11 @@ validate randomness rounds it receives, or "WithChainHash()" and be combined
 with the HTTP client implementations so that chain information can be fetched from them.
 
 It is particularly important that rounds are verified since they can be delivered by any peer in the network.
+Oversized beacons are rejected before being verified, see WithMaxBeaconSize, as are beacons with unknown fields
+with WithStrictValidation, and the pubsub of NewPubsub bounds the size of the messages it accepts and requires them
+to be signed by their author, see WithMessageSigning.
+
+This package, the gossip relay and the libp2p support of the CLI are excluded
+from builds using the "nolibp2p" build tag, for HTTP/gRPC-only deployments.
 */
 package lp2p
@@ -0,0 +1,84 @@
+//go:build !nolibp2p
+
+package lp2p
+
+import (
+	pubsub "github.com/libp2p/go-libp2p-pubsub"
+)
+
+// DefaultMaxMessageSize bounds the size of the pubsub RPCs accepted by the
+// pubsub of NewPubsub: far above the size of a beacon and of the gossip of a
+// single topic, far below the default of libp2p.
+const DefaultMaxMessageSize = 64 << 10
+
+// DefaultMaxBeaconSize bounds the size of the gossiped beacons accepted by the
+// clients of NewWithPubsub, a few times the size of a beacon of any scheme.
+const DefaultMaxBeaconSize = 1 << 10
+
+// WithMessageSigning is a pubsub option setting whether the messages published
+// are signed by their author, and whether the signatures of the messages
+// received are strictly enforced, in which case unsigned messages are rejected
+// when signing, and signed ones when not signing. NewPubsub signs and strictly
+// verifies the messages by default, as drand relays do.
+func WithMessageSigning(sign, strict bool) pubsub.Option {
+	var policy pubsub.MessageSignaturePolicy
+	switch {
+	case sign && strict:
+		policy = pubsub.StrictSign
+	case sign:
+		policy = pubsub.LaxSign
+	case strict:
+		policy = pubsub.StrictNoSign
+	default:
+		policy = pubsub.LaxNoSign
+	}
+	return pubsub.WithMessageSignaturePolicy(policy)
+}
+
+// defaultPubsubOptions are the options of the pubsub of NewPubsub, before the
+// peer scoring ones and the ones given by the caller.
+func defaultPubsubOptions() []pubsub.Option {
+	return []pubsub.Option{
+		WithMessageSigning(true, true),
+		pubsub.WithMaxMessageSize(DefaultMaxMessageSize),
+	}
+}
+
+// Option configures a client created with NewWithPubsub.
+type Option func(c *Client)
+
+// WithMaxBeaconSize rejects the gossiped beacons larger than n bytes,
+// DefaultMaxBeaconSize by default.
+func WithMaxBeaconSize(n int) Option {
+	return func(c *Client) {
+		c.maxBeaconSize = n
+	}
+}
+
+// WithStrictValidation rejects the gossiped beacons having fields unknown to
+// the drand protocol, or metadata for another chain, instead of ignoring them.
+func WithStrictValidation() Option {
+	return func(c *Client) {
+		c.strict = true
+	}
+}
+
+// WithTopicNamespace subscribes the client to the topic of its chain in the
+// namespace prefix instead of DefaultTopicNamespace, e.g. "/example/drand"
+// for "/example/drand/<chain hash>", so that private networks relaying
+// chains, whose hashes may be reused in test environments, do not collide
+// with the public mesh. Relays must publish in the same namespace.
+func WithTopicNamespace(prefix string) Option {
+	return func(c *Client) {
+		c.namespace = prefix
+	}
+}
+
+// WithTopic subscribes the client to the given pubsub topic instead of the
+// topic of its chain, see PubSubTopic, and of WithTopicNamespace, e.g. to a topic relays publish to in
+// addition while migrating the mesh to a new topic.
+func WithTopic(topic string) Option {
+	return func(c *Client) {
+		c.topic = topic
+	}
+}
@@ -0,0 +1,34 @@
+//go:build !nolibp2p
+
+package lp2p
+
+import (
+	"time"
+
+	pubsub "github.com/libp2p/go-libp2p-pubsub"
+
+	"github.com/drand/go-clients/internal/lp2p"
+)
+
+// PeerScoreParams returns the default gossipsub peer scoring parameters of
+// drand nodes, without topic parameters, which are set by NewWithPubsub when
+// joining the topic of a chain.
+func PeerScoreParams() *pubsub.PeerScoreParams {
+	return lp2p.PeerScoreParams()
+}
+
+// PeerScoreThresholds returns the default gossipsub score thresholds of drand nodes.
+func PeerScoreThresholds() *pubsub.PeerScoreThresholds {
+	return lp2p.PeerScoreThresholds()
+}
+
+// TopicScoreParams returns the default scoring parameters of the topic of a
+// chain with the given period.
+func TopicScoreParams(period time.Duration) *pubsub.TopicScoreParams {
+	return lp2p.TopicScoreParams(period)
+}
+
+// GossipSubParams returns the default gossipsub router parameters of drand nodes.
+func GossipSubParams() pubsub.GossipSubParams {
+	return lp2p.GossipSubParams()
+}
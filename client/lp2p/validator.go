@@ -1,8 +1,12 @@
+//go:build !nolibp2p
+
 package lp2p
 
 import (
 	"bytes"
 	"context"
+	"errors"
+	"fmt"
 	"time"
 
 	commonutils "github.com/drand/drand/v2/common"
@@ -23,12 +27,22 @@ func randomnessValidator(info *chain2.Info, cache client.Cache, c *Client) pubsu
 		scheme, _ = crypto.GetSchemeByID(info.Scheme)
 	}
 	return func(_ context.Context, p peer.ID, m *pubsub.Message) pubsub.ValidationResult {
+		if c.maxBeaconSize > 0 && len(m.Data) > c.maxBeaconSize {
+			c.log.Warnw("", "gossip validator", "reject oversized beacon", "size", len(m.Data), "fromPeerID", p.String())
+			return pubsub.ValidationReject
+		}
 		rand := &drand.PublicRandResponse{}
 		err := proto.Unmarshal(m.Data, rand)
 		if err != nil {
 			c.log.Warnw("", "gossip validator", "Not validating received randomness due to proto.Unmarshal error", "err", err)
 			return pubsub.ValidationReject
 		}
+		if c.strict {
+			if err := strictCheck(info, rand); err != nil {
+				c.log.Warnw("", "gossip validator", "reject", "err", err, "fromPeerID", p.String())
+				return pubsub.ValidationReject
+			}
+		}
 
 		c.log.Debugw("", "gossip validator", "Received new round", "round", rand.GetRound(), "fromPeerID", p.String())
 
@@ -87,3 +101,18 @@ func randomnessValidator(info *chain2.Info, cache client.Cache, c *Client) pubsu
 		return pubsub.ValidationAccept
 	}
 }
+
+// strictCheck checks that rand has no fields unknown to the drand protocol,
+// and no metadata for another chain than the one of info, if known.
+func strictCheck(info *chain2.Info, rand *drand.PublicRandResponse) error {
+	if len(rand.ProtoReflect().GetUnknown()) > 0 {
+		return errors.New("beacon has unknown fields")
+	}
+	if m := rand.GetMetadata(); m != nil && len(m.ProtoReflect().GetUnknown()) > 0 {
+		return errors.New("beacon metadata has unknown fields")
+	}
+	if hash := rand.GetMetadata().GetChainHash(); info != nil && len(hash) > 0 && !bytes.Equal(hash, info.Hash()) {
+		return fmt.Errorf("beacon is for chain %x instead of %x", hash, info.Hash())
+	}
+	return nil
+}
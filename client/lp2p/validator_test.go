@@ -1,3 +1,5 @@
+//go:build !nolibp2p
+
 package lp2p
 
 import (
@@ -18,6 +20,7 @@ import (
 	pb "github.com/libp2p/go-libp2p-pubsub/pb"
 	"github.com/libp2p/go-libp2p/core/crypto"
 	"github.com/libp2p/go-libp2p/core/peer"
+	"google.golang.org/protobuf/encoding/protowire"
 	"google.golang.org/protobuf/proto"
 
 	chain2 "github.com/drand/drand/v2/common/chain"
@@ -260,3 +263,67 @@ func TestRejectsCachedEqualNonRandomDataBeacon(t *testing.T) {
 		t.Fatal(errors.New("expected reject for cached beacon"))
 	}
 }
+
+func TestRejectsOversizedBeacon(t *testing.T) {
+	c := Client{log: log.New(nil, log.DebugLevel, true), maxBeaconSize: DefaultMaxBeaconSize}
+	validate := randomnessValidator(nil, nil, &c)
+
+	resp := drand.PublicRandResponse{Signature: make([]byte, DefaultMaxBeaconSize)}
+	data, err := proto.Marshal(&resp)
+	if err != nil {
+		t.Fatal(err)
+	}
+	msg := pubsub.Message{Message: &pb.Message{Data: data}}
+	if res := validate(context.Background(), randomPeerID(t), &msg); res != pubsub.ValidationReject {
+		t.Fatal(errors.New("expected reject for oversized beacon"))
+	}
+
+	WithMaxBeaconSize(2 * DefaultMaxBeaconSize)(&c)
+	if res := validate(context.Background(), randomPeerID(t), &msg); res != pubsub.ValidationAccept {
+		t.Fatal(errors.New("expected accept below the maximum size"))
+	}
+}
+
+func TestStrictValidation(t *testing.T) {
+	c := Client{log: log.New(nil, log.DebugLevel, true)}
+	validate := randomnessValidator(nil, nil, &c)
+
+	data, err := proto.Marshal(&drand.PublicRandResponse{Round: 1})
+	if err != nil {
+		t.Fatal(err)
+	}
+	data = protowire.AppendTag(data, 99, protowire.BytesType)
+	data = protowire.AppendBytes(data, []byte("unknown"))
+	msg := pubsub.Message{Message: &pb.Message{Data: data}}
+	if res := validate(context.Background(), randomPeerID(t), &msg); res != pubsub.ValidationAccept {
+		t.Fatal(errors.New("expected accept of unknown fields without strict validation"))
+	}
+
+	WithStrictValidation()(&c)
+	if res := validate(context.Background(), randomPeerID(t), &msg); res != pubsub.ValidationReject {
+		t.Fatal(errors.New("expected reject of unknown fields with strict validation"))
+	}
+
+	info := fakeChainInfo()
+	if err := strictCheck(info, &drand.PublicRandResponse{Metadata: &drand.Metadata{ChainHash: info.Hash()}}); err != nil {
+		t.Fatal(err)
+	}
+	if err := strictCheck(info, &drand.PublicRandResponse{Metadata: &drand.Metadata{ChainHash: []byte{1}}}); err == nil {
+		t.Fatal(errors.New("expected error for metadata of another chain"))
+	}
+}
+
+func TestNewPubsubSigning(t *testing.T) {
+	for _, sign := range []bool{true, false} {
+		for _, strict := range []bool{true, false} {
+			ps, h, err := NewPubsub(context.Background(), "/ip4/127.0.0.1/tcp/0", nil, WithMessageSigning(sign, strict))
+			if err != nil {
+				t.Fatal(err)
+			}
+			if ps == nil {
+				t.Fatal(errors.New("expected a pubsub"))
+			}
+			_ = h.Close()
+		}
+	}
+}
@@ -0,0 +1,42 @@
+package client
+
+import (
+	"time"
+
+	"github.com/drand/go-clients/drand"
+)
+
+// TransportClient is implemented by clients naming the transport they fetch
+// results over, e.g. "http", as reported by ResultMeta.
+type TransportClient interface {
+	Transport() string
+}
+
+// ResultMetadata describes how a result delivered by a client made with New
+// was obtained, see ResultMeta.
+type ResultMetadata struct {
+	// Source is the name of the source which served the result, as reported
+	// by UpstreamStats, and Transport the name of its transport, e.g.
+	// "http", "grpc" or "gossip", if the source names it.
+	Source    string
+	Transport string
+	// Received is when the result was received from the source, and Latency
+	// how long after the time of its round that was, i.e. the age of the
+	// result for past rounds.
+	Received time.Time
+	Latency  time.Duration
+	// Verification is how long verifying the result took.
+	Verification time.Duration
+}
+
+// ResultMeta returns the metadata of a result delivered by a client made with
+// New, e.g. to track the latency of the sources from the application, and
+// whether the result has any. Only verified results have metadata, and
+// results served from the cache keep the metadata of their first delivery.
+func ResultMeta(r drand.Result) (ResultMetadata, bool) {
+	rd, ok := r.(*RandomData)
+	if !ok || rd.meta == nil {
+		return ResultMetadata{}, false
+	}
+	return *rd.meta, true
+}
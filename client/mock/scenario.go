@@ -0,0 +1,241 @@
+package mock
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+
+	clock "github.com/jonboulle/clockwork"
+
+	commonutils "github.com/drand/drand/v2/common"
+	"github.com/drand/drand/v2/common/chain"
+	"github.com/drand/go-clients/client/test/result/mock"
+	"github.com/drand/go-clients/drand"
+)
+
+var (
+	// ErrScripted is the error of the failures scripted with FailOnce.
+	ErrScripted = errors.New("mock: scripted failure")
+	// ErrScenarioEnded is returned by Get once all the steps of a scenario
+	// are played.
+	ErrScenarioEnded = errors.New("mock: scenario ended")
+)
+
+// scenarioStep is a step of a scenario: a result or an error, delivered after
+// a delay.
+type scenarioStep struct {
+	result drand.Result
+	err    error
+	delay  time.Duration
+}
+
+// ScenarioBuilder scripts the sequence of results and failures of a
+// ScenarioClient, e.g.
+//
+//	c := mock.Scenario().Round(1).Delay(time.Second).FailOnce().Round(2).Client()
+//
+// makes a client whose first call returns round 1, whose second call fails
+// after a second, and whose third call returns round 2.
+type ScenarioBuilder struct {
+	steps   []scenarioStep
+	delay   time.Duration
+	name    string
+	info    *chain.Info
+	results map[uint64]mock.Result
+	clock   clock.Clock
+}
+
+// Scenario returns an empty scenario.
+func Scenario() *ScenarioBuilder {
+	return &ScenarioBuilder{name: "Scenario", clock: clock.NewRealClock()}
+}
+
+// Named sets the name of the client, as returned by its String method, e.g.
+// to tell the sources of a client apart in its UpstreamStats.
+func (s *ScenarioBuilder) Named(name string) *ScenarioBuilder {
+	s.name = name
+	return s
+}
+
+// WithResults makes the client serve the chain of info, and the rounds
+// scripted with Round the given results, e.g. the ones of
+// mock.VerifiableResults, rather than results failing verification.
+func (s *ScenarioBuilder) WithResults(info *chain.Info, results []mock.Result) *ScenarioBuilder {
+	s.info = info
+	s.results = make(map[uint64]mock.Result, len(results))
+	for _, r := range results {
+		s.results[r.GetRound()] = r
+	}
+	return s
+}
+
+// WithClock sets the clock the delays are waited on, e.g. a fake clock for
+// the scenario to be deterministic.
+func (s *ScenarioBuilder) WithClock(clk clock.Clock) *ScenarioBuilder {
+	s.clock = clk
+	return s
+}
+
+// Round adds a step returning the result of round.
+func (s *ScenarioBuilder) Round(round uint64) *ScenarioBuilder {
+	r, ok := s.results[round]
+	if !ok {
+		r = mock.NewMockResult(round)
+	}
+	return s.add(scenarioStep{result: &r})
+}
+
+// Rounds adds a step for each round from from to to, included.
+func (s *ScenarioBuilder) Rounds(from, to uint64) *ScenarioBuilder {
+	for round := from; round <= to; round++ {
+		s.Round(round)
+	}
+	return s
+}
+
+// Delay delays the next step by d.
+func (s *ScenarioBuilder) Delay(d time.Duration) *ScenarioBuilder {
+	s.delay += d
+	return s
+}
+
+// FailOnce adds a step failing with ErrScripted.
+func (s *ScenarioBuilder) FailOnce() *ScenarioBuilder {
+	return s.Fail(ErrScripted)
+}
+
+// Fail adds a step failing with err.
+func (s *ScenarioBuilder) Fail(err error) *ScenarioBuilder {
+	return s.add(scenarioStep{err: err})
+}
+
+func (s *ScenarioBuilder) add(step scenarioStep) *ScenarioBuilder {
+	step.delay, s.delay = s.delay, 0
+	s.steps = append(s.steps, step)
+	return s
+}
+
+// Client returns a client playing the scenario.
+func (s *ScenarioBuilder) Client() *ScenarioClient {
+	return &ScenarioClient{
+		name:  s.name,
+		info:  s.info,
+		clock: s.clock,
+		steps: append([]scenarioStep(nil), s.steps...),
+	}
+}
+
+// ScenarioClient is a client playing the steps of a scenario, in order, across
+// its calls to Get and Watch. Each call to Get plays the next step, whatever
+// the round requested, and fails with ErrScenarioEnded once all the steps are
+// played. Each call to Watch plays the next steps until a failure, which ends
+// the watch, or the end of the scenario.
+type ScenarioClient struct {
+	name  string
+	info  *chain.Info
+	clock clock.Clock
+
+	lk    sync.Mutex
+	steps []scenarioStep
+}
+
+var _ drand.Client = (*ScenarioClient)(nil)
+
+func (c *ScenarioClient) String() string {
+	return c.name
+}
+
+// Remaining returns the number of steps not played yet.
+func (c *ScenarioClient) Remaining() int {
+	c.lk.Lock()
+	defer c.lk.Unlock()
+	return len(c.steps)
+}
+
+// next pops the next step, if any.
+func (c *ScenarioClient) next() (scenarioStep, bool) {
+	c.lk.Lock()
+	defer c.lk.Unlock()
+	if len(c.steps) == 0 {
+		return scenarioStep{}, false
+	}
+	step := c.steps[0]
+	c.steps = c.steps[1:]
+	return step, true
+}
+
+// wait waits for the delay of a step, or for ctx to be done.
+func (c *ScenarioClient) wait(ctx context.Context, step scenarioStep) error {
+	if step.delay <= 0 {
+		return nil
+	}
+	t := c.clock.NewTimer(step.delay)
+	defer t.Stop()
+	select {
+	case <-t.Chan():
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// Get plays the next step of the scenario.
+func (c *ScenarioClient) Get(ctx context.Context, _ uint64) (drand.Result, error) {
+	step, ok := c.next()
+	if !ok {
+		return nil, ErrScenarioEnded
+	}
+	if err := c.wait(ctx, step); err != nil {
+		return nil, err
+	}
+	if step.err != nil {
+		return nil, step.err
+	}
+	return step.result, nil
+}
+
+// Watch plays the next steps of the scenario until a failure.
+func (c *ScenarioClient) Watch(ctx context.Context) <-chan drand.Result {
+	ch := make(chan drand.Result)
+	go func() {
+		defer close(ch)
+		for {
+			step, ok := c.next()
+			if !ok {
+				return
+			}
+			if err := c.wait(ctx, step); err != nil || step.err != nil {
+				return
+			}
+			select {
+			case ch <- step.result:
+			case <-ctx.Done():
+				return
+			}
+		}
+	}()
+	return ch
+}
+
+// Info returns the chain info set with WithResults.
+func (c *ScenarioClient) Info(_ context.Context) (*chain.Info, error) {
+	if c.info == nil {
+		return nil, fmt.Errorf("not supported (%s has no chain info)", c.name)
+	}
+	return c.info, nil
+}
+
+// RoundAt returns the round at time t of the chain set with WithResults, or 0.
+func (c *ScenarioClient) RoundAt(t time.Time) uint64 {
+	if c.info == nil {
+		return 0
+	}
+	return commonutils.CurrentRound(t.Unix(), c.info.Period, c.info.GenesisTime)
+}
+
+// Close does nothing.
+func (c *ScenarioClient) Close() error {
+	return nil
+}
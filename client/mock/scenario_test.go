@@ -0,0 +1,68 @@
+package mock
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	clock "github.com/jonboulle/clockwork"
+	"github.com/stretchr/testify/require"
+
+	"github.com/drand/drand/v2/crypto"
+	"github.com/drand/go-clients/client/test/result/mock"
+)
+
+func TestScenario(t *testing.T) {
+	ctx := context.Background()
+	clk := clock.NewFakeClock()
+	c := Scenario().WithClock(clk).Round(1).Delay(time.Second).FailOnce().Rounds(2, 3).Client()
+
+	r, err := c.Get(ctx, 0)
+	require.NoError(t, err)
+	require.Equal(t, uint64(1), r.GetRound())
+
+	// the failure is delayed on the clock
+	errs := make(chan error, 1)
+	go func() {
+		_, err := c.Get(ctx, 0)
+		errs <- err
+	}()
+	require.NoError(t, clk.BlockUntilContext(ctx, 1))
+	clk.Advance(time.Second)
+	require.ErrorIs(t, <-errs, ErrScripted)
+
+	var rounds []uint64
+	for r := range c.Watch(ctx) {
+		rounds = append(rounds, r.GetRound())
+	}
+	require.Equal(t, []uint64{2, 3}, rounds)
+	require.Zero(t, c.Remaining())
+	_, err = c.Get(ctx, 0)
+	require.ErrorIs(t, err, ErrScenarioEnded)
+	_, err = c.Info(ctx)
+	require.Error(t, err)
+}
+
+func TestScenarioResults(t *testing.T) {
+	ctx := context.Background()
+	sch, err := crypto.GetSchemeFromEnv()
+	require.NoError(t, err)
+	info, results := mock.VerifiableResults(3, sch)
+	boom := errors.New("boom")
+	c := Scenario().Named("flaky").WithResults(info, results).Round(1).Fail(boom).Round(3).Client()
+	require.Equal(t, "flaky", c.String())
+
+	got, err := c.Info(ctx)
+	require.NoError(t, err)
+	require.Equal(t, info, got)
+
+	// a failure ends the watch, the next one continues after it
+	w := c.Watch(ctx)
+	r := <-w
+	require.Equal(t, results[0].GetSignature(), r.GetSignature())
+	_, ok := <-w
+	require.False(t, ok)
+	r = <-c.Watch(ctx)
+	require.Equal(t, results[2].GetSignature(), r.GetSignature())
+}
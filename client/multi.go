@@ -0,0 +1,49 @@
+package client
+
+import (
+	"errors"
+	"sort"
+
+	"github.com/drand/go-clients/drand"
+)
+
+// MultiClient holds a client for each of a set of chains, e.g. every chain
+// advertised by a set of relays, for mirrors and monitoring tools.
+type MultiClient struct {
+	clients map[string]drand.Client
+}
+
+// NewMultiClient makes a MultiClient from clients indexed by the hex encoded
+// hash of their chain.
+func NewMultiClient(clients map[string]drand.Client) *MultiClient {
+	m := &MultiClient{clients: make(map[string]drand.Client, len(clients))}
+	for hash, c := range clients {
+		m.clients[hash] = c
+	}
+	return m
+}
+
+// Chains returns the hex encoded hashes of the chains of the client, sorted.
+func (m *MultiClient) Chains() []string {
+	hashes := make([]string, 0, len(m.clients))
+	for hash := range m.clients {
+		hashes = append(hashes, hash)
+	}
+	sort.Strings(hashes)
+	return hashes
+}
+
+// Client returns the client of a chain, given its hex encoded hash.
+func (m *MultiClient) Client(chainHash string) (drand.Client, bool) {
+	c, ok := m.clients[chainHash]
+	return c, ok
+}
+
+// Close closes the clients of all the chains.
+func (m *MultiClient) Close() error {
+	var err error
+	for _, c := range m.clients {
+		err = errors.Join(err, c.Close())
+	}
+	return err
+}
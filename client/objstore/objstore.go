@@ -0,0 +1,263 @@
+/*
+Package objstore reads, and optionally writes, drand beacons stored as objects
+in an S3-compatible object storage, so that serverless consumers can fetch
+historical rounds cheaply, without a drand node nor relay.
+
+Beacons are laid out by chain and round, as served by the drand HTTP API:
+
+	<prefix><chain hash>/info.json
+	<prefix><chain hash>/latest.json
+	<prefix><chain hash>/<round>.json
+
+A bucket can be kept up to date by mirroring the beacons watched from a client
+to it, e.g. with the --mirror-bucket flag of the gossip relay:
+
+	b, _ := objstore.NewS3Bucket(objstore.S3Config{Endpoint: endpoint, Bucket: "drand", ...})
+	store, _ := objstore.New(ctx, b, objstore.Config{Info: info})
+	go objstore.Mirror(ctx, c, store)
+
+Beacons are stored as given: the ones read from a bucket should be verified,
+e.g. by using the client as a source of a client made with client.New. The
+latest beacon is only served while it is the current round of the chain, so
+that such a client does not take the round of a stale bucket for the latest
+one, and asks its other sources instead.
+*/
+package objstore
+
+import (
+	"bytes"
+	"context"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strconv"
+	"sync"
+	"time"
+
+	clock "github.com/jonboulle/clockwork"
+
+	"github.com/drand/drand/v2/common"
+	"github.com/drand/drand/v2/common/chain"
+	"github.com/drand/drand/v2/common/log"
+	"github.com/drand/drand/v2/crypto"
+	"github.com/drand/go-clients/client"
+	"github.com/drand/go-clients/drand"
+)
+
+// ErrNotFound means the requested object is not in the bucket.
+var ErrNotFound = errors.New("object not found")
+
+// Bucket is an object storage bucket.
+type Bucket interface {
+	// GetObject returns the content of the object key, or an error wrapping
+	// ErrNotFound if there is no such object.
+	GetObject(ctx context.Context, key string) ([]byte, error)
+	// PutObject writes the object key.
+	PutObject(ctx context.Context, key string, body []byte) error
+}
+
+// Config configures a Client.
+type Config struct {
+	// Prefix is prepended to the keys of the objects, e.g. "drand/".
+	Prefix string
+	// Info is the info of the chain. If nil, the info stored in the bucket
+	// under ChainHash is used.
+	Info *chain.Info
+	// ChainHash is the hash of the chain, required when Info is nil.
+	ChainHash []byte
+	// Logger defaults to the drand default logger.
+	Logger log.Logger
+	// Clock tells the current round of the chain, and defaults to the real clock.
+	Clock clock.Clock
+}
+
+// Client reads and writes the beacons of a chain in a bucket.
+type Client struct {
+	b      Bucket
+	info   *chain.Info
+	prefix string
+	log    log.Logger
+	clock  clock.Clock
+
+	// putLk serializes the updates of the latest beacon by Put.
+	putLk sync.Mutex
+}
+
+// New returns a client of the beacons of a chain stored in b.
+func New(ctx context.Context, b Bucket, cfg Config) (*Client, error) {
+	if b == nil {
+		return nil, errors.New("a bucket is required")
+	}
+	if cfg.Logger == nil {
+		cfg.Logger = log.DefaultLogger()
+	}
+	if cfg.Clock == nil {
+		cfg.Clock = clock.NewRealClock()
+	}
+	c := &Client{b: b, info: cfg.Info, prefix: cfg.Prefix, log: cfg.Logger.Named("objstoreClient"), clock: cfg.Clock}
+	if c.info != nil {
+		if cfg.ChainHash != nil && !bytes.Equal(cfg.ChainHash, c.info.Hash()) {
+			return nil, fmt.Errorf("%w: chain info does not match %x", drand.ErrInvalidChainHash, cfg.ChainHash)
+		}
+		return c, nil
+	}
+	if len(cfg.ChainHash) == 0 {
+		return nil, errors.New("a chain info or chain hash is required")
+	}
+
+	body, err := b.GetObject(ctx, c.prefix+hex.EncodeToString(cfg.ChainHash)+"/info.json")
+	if err != nil {
+		return nil, fmt.Errorf("getting chain info: %w", err)
+	}
+	info, err := chain.InfoFromJSON(bytes.NewReader(body))
+	if err != nil {
+		return nil, fmt.Errorf("decoding chain info: %w", err)
+	}
+	if !bytes.Equal(info.Hash(), cfg.ChainHash) {
+		return nil, fmt.Errorf("%w: stored chain info has hash %x, expected %x", drand.ErrInvalidChainHash, info.Hash(), cfg.ChainHash)
+	}
+	c.info = info
+	return c, nil
+}
+
+// key returns the key of the object name of the chain of c.
+func (c *Client) key(name string) string {
+	return c.prefix + hex.EncodeToString(c.info.Hash()) + "/" + name
+}
+
+// RoundKey returns the key of the object of round, "latest.json" for round 0.
+func (c *Client) RoundKey(round uint64) string {
+	if round == 0 {
+		return c.key("latest.json")
+	}
+	return c.key(strconv.FormatUint(round, 10) + ".json")
+}
+
+// Get returns the stored beacon of round, or the latest stored one if round
+// is 0. It fails with an error wrapping ErrNotFound if the round is not stored
+// or, for round 0, if the latest stored beacon is behind the current round of
+// the chain.
+func (c *Client) Get(ctx context.Context, round uint64) (drand.Result, error) {
+	r, err := c.get(ctx, round)
+	if err != nil {
+		return nil, err
+	}
+	if current := c.RoundAt(c.clock.Now()); round == 0 && r.GetRound() < current {
+		return nil, fmt.Errorf("%w: latest stored round %d is behind round %d", ErrNotFound, r.GetRound(), current)
+	}
+	return r, nil
+}
+
+// get returns the stored beacon of round, or the latest stored one if round is 0.
+func (c *Client) get(ctx context.Context, round uint64) (*client.RandomData, error) {
+	body, err := c.b.GetObject(ctx, c.RoundKey(round))
+	if err != nil {
+		return nil, fmt.Errorf("getting round %d: %w", round, err)
+	}
+	r := new(client.RandomData)
+	if err := json.Unmarshal(body, r); err != nil {
+		return nil, fmt.Errorf("decoding round %d: %w", round, err)
+	}
+	if round != 0 && r.GetRound() != round {
+		return nil, fmt.Errorf("object of round %d holds round %d", round, r.GetRound())
+	}
+	if r.Random == nil {
+		r.Random = crypto.RandomnessFromSignature(r.GetSignature())
+	}
+	return r, nil
+}
+
+// Put writes the beacon r, and updates the latest beacon if r is not older.
+// The Puts of a client are serialized, but buckets have no atomic updates:
+// concurrent writers of the same bucket can move the latest beacon back, in
+// which case Get stops serving it until a current beacon is written.
+func (c *Client) Put(ctx context.Context, r drand.Result) error {
+	if r.GetRound() == 0 {
+		return errors.New("cannot store round 0")
+	}
+	rd := &client.RandomData{
+		Rnd:               r.GetRound(),
+		Random:            r.GetRandomness(),
+		Sig:               r.GetSignature(),
+		PreviousSignature: r.GetPreviousSignature(),
+	}
+	body, err := json.Marshal(rd)
+	if err != nil {
+		return err
+	}
+	if err := c.b.PutObject(ctx, c.RoundKey(rd.Rnd), body); err != nil {
+		return err
+	}
+
+	c.putLk.Lock()
+	defer c.putLk.Unlock()
+	latest, err := c.get(ctx, 0)
+	if err != nil && !errors.Is(err, ErrNotFound) {
+		return err
+	}
+	if latest != nil && latest.GetRound() > rd.Rnd {
+		return nil
+	}
+	return c.b.PutObject(ctx, c.RoundKey(0), body)
+}
+
+// PutInfo writes the chain info, so that clients can be made from the chain hash only.
+func (c *Client) PutInfo(ctx context.Context) error {
+	var buf bytes.Buffer
+	if err := c.info.ToJSON(&buf, nil); err != nil {
+		return err
+	}
+	return c.b.PutObject(ctx, c.key("info.json"), buf.Bytes())
+}
+
+// Watch returns a closed channel: a bucket does not produce new beacons.
+func (c *Client) Watch(_ context.Context) <-chan drand.Result {
+	ch := make(chan drand.Result)
+	close(ch)
+	return ch
+}
+
+// Info returns the info of the chain of the client.
+func (c *Client) Info(_ context.Context) (*chain.Info, error) {
+	return c.info, nil
+}
+
+// RoundAt returns the round of the chain at time t.
+func (c *Client) RoundAt(t time.Time) uint64 {
+	return common.CurrentRound(t.Unix(), c.info.Period, c.info.GenesisTime)
+}
+
+// Transport returns the name of the transport of the client.
+func (c *Client) Transport() string {
+	return "objstore"
+}
+
+// String returns the name of the client.
+func (c *Client) String() string {
+	if s, ok := c.b.(fmt.Stringer); ok {
+		return "ObjStore(" + s.String() + ")"
+	}
+	return "ObjStore"
+}
+
+// Close does nothing.
+func (c *Client) Close() error {
+	return nil
+}
+
+// Mirror writes the chain info and every beacon watched from src to dst,
+// until ctx is done or the watch ends. Failed writes are logged and skipped.
+func Mirror(ctx context.Context, src drand.Client, dst *Client) error {
+	if err := dst.PutInfo(ctx); err != nil {
+		return fmt.Errorf("writing chain info: %w", err)
+	}
+	for r := range src.Watch(ctx) {
+		if err := dst.Put(ctx, r); err != nil {
+			dst.log.Warnw("", "objstore", "failed to mirror round", "round", r.GetRound(), "err", err)
+		}
+	}
+	return ctx.Err()
+}
+
+var _ drand.Client = (*Client)(nil)
@@ -0,0 +1,122 @@
+package objstore_test
+
+import (
+	"context"
+	"io"
+	nhttp "net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	clock "github.com/jonboulle/clockwork"
+	"github.com/stretchr/testify/require"
+
+	"github.com/drand/drand/v2/common"
+	"github.com/drand/drand/v2/crypto"
+	"github.com/drand/go-clients/client"
+	clientMock "github.com/drand/go-clients/client/mock"
+	"github.com/drand/go-clients/client/objstore"
+	"github.com/drand/go-clients/client/test/result/mock"
+	"github.com/drand/go-clients/drand"
+)
+
+// fakeS3 serves the objects of a bucket from memory, and requires signed writes.
+type fakeS3 struct {
+	sync.Mutex
+	objects map[string][]byte
+}
+
+func (f *fakeS3) ServeHTTP(w nhttp.ResponseWriter, r *nhttp.Request) {
+	f.Lock()
+	defer f.Unlock()
+	switch r.Method {
+	case nhttp.MethodGet:
+		b, ok := f.objects[r.URL.Path]
+		if !ok {
+			w.WriteHeader(nhttp.StatusNotFound)
+			return
+		}
+		_, _ = w.Write(b)
+	case nhttp.MethodPut:
+		if !strings.HasPrefix(r.Header.Get("Authorization"), "AWS4-HMAC-SHA256 Credential=key-id/") ||
+			r.Header.Get("X-Amz-Content-Sha256") == "" {
+			w.WriteHeader(nhttp.StatusForbidden)
+			return
+		}
+		b, _ := io.ReadAll(r.Body)
+		f.objects[r.URL.Path] = b
+	default:
+		w.WriteHeader(nhttp.StatusMethodNotAllowed)
+	}
+}
+
+func TestObjStore(t *testing.T) {
+	ctx := context.Background()
+	sch, err := crypto.GetSchemeFromEnv()
+	require.NoError(t, err)
+	info, results := mock.VerifiableResults(3, sch)
+
+	s3 := &fakeS3{objects: make(map[string][]byte)}
+	srv := httptest.NewServer(s3)
+	defer srv.Close()
+
+	b, err := objstore.NewS3Bucket(objstore.S3Config{
+		Endpoint: srv.URL, Bucket: "beacons", AccessKeyID: "key-id", SecretAccessKey: "secret",
+	})
+	require.NoError(t, err)
+	w, err := objstore.New(ctx, b, objstore.Config{Info: info, Prefix: "drand/"})
+	require.NoError(t, err)
+
+	watch := make(chan drand.Result, len(results))
+	for i := range results {
+		watch <- &results[i]
+	}
+	close(watch)
+	require.NoError(t, objstore.Mirror(ctx, &clientMock.Client{OptionalInfo: info, WatchCh: watch}, w))
+	require.Contains(t, s3.objects, "/beacons/drand/"+info.HashString()+"/2.json")
+
+	// anonymous readers only need the chain hash
+	anon, err := objstore.NewS3Bucket(objstore.S3Config{Endpoint: srv.URL, Bucket: "beacons"})
+	require.NoError(t, err)
+	clk := clock.NewFakeClockAt(time.Unix(common.TimeOfRound(info.Period, info.GenesisTime, 3), 0))
+	r, err := objstore.New(ctx, anon, objstore.Config{ChainHash: info.Hash(), Prefix: "drand/", Clock: clk})
+	require.NoError(t, err)
+	require.Error(t, r.Put(ctx, &results[0]), "anonymous writes are refused")
+
+	res, err := r.Get(ctx, 2)
+	require.NoError(t, err)
+	require.Equal(t, results[1].GetSignature(), res.GetSignature())
+	latest, err := r.Get(ctx, 0)
+	require.NoError(t, err)
+	require.Equal(t, uint64(3), latest.GetRound())
+
+	// an older round does not replace the latest one
+	require.NoError(t, w.Put(ctx, &results[0]))
+	latest, err = r.Get(ctx, 0)
+	require.NoError(t, err)
+	require.Equal(t, uint64(3), latest.GetRound())
+
+	_, err = r.Get(ctx, 4)
+	require.ErrorIs(t, err, objstore.ErrNotFound)
+
+	// a stale latest beacon is not served as the latest round
+	clk.Advance(info.Period)
+	_, err = r.Get(ctx, 0)
+	require.ErrorIs(t, err, objstore.ErrNotFound)
+	res, err = r.Get(ctx, 3)
+	require.NoError(t, err)
+	require.Equal(t, uint64(3), res.GetRound())
+
+	// the stored beacons are verified by a client using the bucket as source
+	c, err := client.New(client.From(r), client.WithChainInfo(info))
+	require.NoError(t, err)
+	defer c.Close()
+	res, err = c.Get(ctx, 3)
+	require.NoError(t, err)
+	require.Equal(t, results[2].GetSignature(), res.GetSignature())
+
+	_, err = objstore.New(ctx, anon, objstore.Config{ChainHash: []byte("unknown")})
+	require.ErrorIs(t, err, objstore.ErrNotFound)
+}
@@ -0,0 +1,229 @@
+package objstore
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	nhttp "net/http"
+	"net/url"
+	"sort"
+	"strings"
+	"time"
+)
+
+// maxObjectSize bounds the size of the objects read from a bucket.
+const maxObjectSize = 1 << 20
+
+// S3Config configures an S3-compatible bucket, e.g. AWS S3, Cloudflare R2 or MinIO.
+type S3Config struct {
+	// Endpoint is the URL of the S3 API, e.g. "https://s3.eu-west-1.amazonaws.com".
+	// Objects are addressed path-style, as Endpoint/Bucket/key.
+	Endpoint string
+	// Bucket is the name of the bucket.
+	Bucket string
+	// Region is the region of the bucket, used to sign requests. It defaults to "us-east-1".
+	Region string
+	// AccessKeyID and SecretAccessKey are the credentials requests are signed
+	// with. Requests are anonymous without them, which is enough to read
+	// public buckets.
+	AccessKeyID     string
+	SecretAccessKey string
+	// SessionToken is the token of temporary credentials, if any.
+	SessionToken string
+	// HTTPClient is the client requests are made with. It defaults to http.DefaultClient.
+	HTTPClient *nhttp.Client
+}
+
+type s3Bucket struct {
+	cfg      S3Config
+	endpoint *url.URL
+	now      func() time.Time
+}
+
+// NewS3Bucket returns a bucket of an S3-compatible object storage.
+func NewS3Bucket(cfg S3Config) (Bucket, error) {
+	u, err := url.Parse(cfg.Endpoint)
+	if err != nil {
+		return nil, fmt.Errorf("invalid endpoint %q: %w", cfg.Endpoint, err)
+	}
+	if u.Scheme != "http" && u.Scheme != "https" || u.Host == "" {
+		return nil, fmt.Errorf("invalid endpoint %q: an http(s) URL is required", cfg.Endpoint)
+	}
+	if cfg.Bucket == "" {
+		return nil, errors.New("a bucket name is required")
+	}
+	if (cfg.AccessKeyID == "") != (cfg.SecretAccessKey == "") {
+		return nil, errors.New("both an access key ID and a secret access key are required")
+	}
+	if cfg.Region == "" {
+		cfg.Region = "us-east-1"
+	}
+	if cfg.HTTPClient == nil {
+		cfg.HTTPClient = nhttp.DefaultClient
+	}
+	return &s3Bucket{cfg: cfg, endpoint: u, now: time.Now}, nil
+}
+
+func (b *s3Bucket) String() string {
+	return b.endpoint.String() + "/" + b.cfg.Bucket
+}
+
+// GetObject returns the content of the object key.
+func (b *s3Bucket) GetObject(ctx context.Context, key string) ([]byte, error) {
+	resp, err := b.do(ctx, nhttp.MethodGet, key, nil)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	switch resp.StatusCode {
+	case nhttp.StatusOK:
+	case nhttp.StatusNotFound:
+		return nil, fmt.Errorf("object %q: %w", key, ErrNotFound)
+	default:
+		return nil, fmt.Errorf("getting object %q: unexpected status %s", key, resp.Status)
+	}
+	body, err := io.ReadAll(io.LimitReader(resp.Body, maxObjectSize+1))
+	if err != nil {
+		return nil, fmt.Errorf("reading object %q: %w", key, err)
+	}
+	if len(body) > maxObjectSize {
+		return nil, fmt.Errorf("object %q exceeds %d bytes", key, maxObjectSize)
+	}
+	return body, nil
+}
+
+// PutObject writes the object key.
+func (b *s3Bucket) PutObject(ctx context.Context, key string, body []byte) error {
+	resp, err := b.do(ctx, nhttp.MethodPut, key, body)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	_, _ = io.Copy(io.Discard, io.LimitReader(resp.Body, maxObjectSize))
+	if resp.StatusCode != nhttp.StatusOK {
+		return fmt.Errorf("putting object %q: unexpected status %s", key, resp.Status)
+	}
+	return nil
+}
+
+func (b *s3Bucket) do(ctx context.Context, method, key string, body []byte) (*nhttp.Response, error) {
+	u := *b.endpoint
+	u.Path = strings.TrimSuffix(u.Path, "/") + "/" + b.cfg.Bucket + "/" + strings.TrimPrefix(key, "/")
+	u.RawPath = uriEncode(u.Path, true)
+
+	req, err := nhttp.NewRequestWithContext(ctx, method, u.String(), bytes.NewReader(body))
+	if err != nil {
+		return nil, err
+	}
+	if body != nil {
+		req.Header.Set("Content-Type", "application/json")
+	}
+	if b.cfg.AccessKeyID != "" {
+		b.sign(req, body)
+	}
+	resp, err := b.cfg.HTTPClient.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("%s object %q: %w", method, key, err)
+	}
+	return resp, nil
+}
+
+// sign adds an AWS Signature Version 4 to req.
+func (b *s3Bucket) sign(req *nhttp.Request, body []byte) {
+	now := b.now().UTC()
+	amzDate := now.Format("20060102T150405Z")
+	date := now.Format("20060102")
+	payloadHash := sha256.Sum256(body)
+	payload := hex.EncodeToString(payloadHash[:])
+
+	req.Header.Set("X-Amz-Date", amzDate)
+	req.Header.Set("X-Amz-Content-Sha256", payload)
+	headers := []string{"host", "x-amz-content-sha256", "x-amz-date"}
+	values := map[string]string{"host": req.URL.Host, "x-amz-content-sha256": payload, "x-amz-date": amzDate}
+	if b.cfg.SessionToken != "" {
+		req.Header.Set("X-Amz-Security-Token", b.cfg.SessionToken)
+		headers = append(headers, "x-amz-security-token")
+		values["x-amz-security-token"] = b.cfg.SessionToken
+	}
+
+	var canonicalHeaders strings.Builder
+	for _, h := range headers {
+		canonicalHeaders.WriteString(h + ":" + values[h] + "\n")
+	}
+	signedHeaders := strings.Join(headers, ";")
+	canonicalRequest := strings.Join([]string{
+		req.Method,
+		req.URL.EscapedPath(),
+		canonicalQuery(req.URL.Query()),
+		canonicalHeaders.String(),
+		signedHeaders,
+		payload,
+	}, "\n")
+
+	scope := date + "/" + b.cfg.Region + "/s3/aws4_request"
+	requestHash := sha256.Sum256([]byte(canonicalRequest))
+	stringToSign := "AWS4-HMAC-SHA256\n" + amzDate + "\n" + scope + "\n" + hex.EncodeToString(requestHash[:])
+
+	key := hmacSHA256([]byte("AWS4"+b.cfg.SecretAccessKey), date)
+	key = hmacSHA256(key, b.cfg.Region)
+	key = hmacSHA256(key, "s3")
+	key = hmacSHA256(key, "aws4_request")
+	signature := hex.EncodeToString(hmacSHA256(key, stringToSign))
+
+	req.Header.Set("Authorization", "AWS4-HMAC-SHA256 Credential="+b.cfg.AccessKeyID+"/"+scope+
+		", SignedHeaders="+signedHeaders+", Signature="+signature)
+}
+
+func hmacSHA256(key []byte, data string) []byte {
+	h := hmac.New(sha256.New, key)
+	h.Write([]byte(data))
+	return h.Sum(nil)
+}
+
+// canonicalQuery returns the query of a request as required by AWS
+// signatures: its parameters sorted by name and value, and escaped.
+func canonicalQuery(query url.Values) string {
+	var params [][2]string
+	for name, values := range query {
+		for _, v := range values {
+			params = append(params, [2]string{uriEncode(name, false), uriEncode(v, false)})
+		}
+	}
+	sort.Slice(params, func(i, j int) bool {
+		if params[i][0] != params[j][0] {
+			return params[i][0] < params[j][0]
+		}
+		return params[i][1] < params[j][1]
+	})
+	var b strings.Builder
+	for i, p := range params {
+		if i > 0 {
+			b.WriteByte('&')
+		}
+		b.WriteString(p[0] + "=" + p[1])
+	}
+	return b.String()
+}
+
+// uriEncode escapes a string as required by AWS signatures: every byte but the
+// unreserved characters, and the slashes of paths, is percent-encoded.
+func uriEncode(s string, path bool) string {
+	var b strings.Builder
+	for i := 0; i < len(s); i++ {
+		c := s[i]
+		switch {
+		case 'A' <= c && c <= 'Z', 'a' <= c && c <= 'z', '0' <= c && c <= '9',
+			c == '-', c == '_', c == '.', c == '~', c == '/' && path:
+			b.WriteByte(c)
+		default:
+			fmt.Fprintf(&b, "%%%02X", c)
+		}
+	}
+	return b.String()
+}
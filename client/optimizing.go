@@ -12,6 +12,7 @@ import (
 	"time"
 
 	"github.com/hashicorp/go-multierror"
+	clock "github.com/jonboulle/clockwork"
 
 	"github.com/drand/go-clients/drand"
 
@@ -32,23 +33,61 @@ const (
 	// is re-open when no context error occurred.
 	defaultWatchRetryInterval = time.Second * 30
 	defaultChannelBuffer      = 5
+	// defaultHedgeDelay is how long a hedged Get waits for a client whose
+	// latency is not known yet before asking the next one.
+	defaultHedgeDelay = time.Second
 
 	maxUnixTime = 1<<63 - 62135596801
 	maxNanoSec  = 999999999
 )
 
 type optimizingClient struct {
+	// RWMutex guards clients and stats.
 	sync.RWMutex
 	clients            []drand.Client
 	passiveClients     []drand.Client
 	stats              []*requestStat
 	requestTimeout     time.Duration
+	infoTimeout        time.Duration
 	requestConcurrency int
 	speedTestInterval  time.Duration
 	watchRetryInterval time.Duration
+	clock              clock.Clock
 	log                log.Logger
 	done               chan struct{}
-}
+
+	// watchFailoverGrace, if positive, makes watches only poll the active
+	// clients once the passive ones are late by more than this duration.
+	watchFailoverGrace time.Duration
+	// breakerThreshold, if positive, is the number of consecutive failures
+	// after which an upstream is skipped for breakerCooldown.
+	breakerThreshold int
+	breakerCooldown  time.Duration
+	// hedging makes Get ask the clients one at a time, moving on to the next
+	// one after hedgeDelay, or the 95th percentile of the latency of the
+	// client if 0, instead of racing them.
+	hedging    bool
+	hedgeDelay time.Duration
+	// onWatchSource, if set, is notified of the client which delivered each
+	// new round to the watches first.
+	onWatchSource WatchSourceFunc
+
+	// upstreams holds the statistics of each client, guarded by upstreamsLk.
+	upstreams   map[drand.Client]*upstreamStats
+	upstreamsLk sync.Mutex
+
+	// closeLk guards closed, so that no goroutine is tracked by wg once the
+	// client is closing.
+	closeLk sync.Mutex
+	closed  bool
+	// wg tracks the background goroutines, waited for by Close.
+	wg sync.WaitGroup
+}
+
+// WatchSourceFunc is notified of each new round received by the watches of a
+// client, with the name of the source which delivered it first and whether
+// it is a passive one, e.g. libp2p pubsub, or a polled one.
+type WatchSourceFunc func(round uint64, source string, passive bool)
 
 // newOptimizingClient creates a drand client that measures the speed of clients
 // and uses the fastest ones.
@@ -80,9 +119,10 @@ func newOptimizingClient(
 		return nil, errors.New("missing clients")
 	}
 	stats := make([]*requestStat, len(clients))
-	now := time.Now()
+	upstreams := make(map[drand.Client]*upstreamStats, len(clients))
 	for i, c := range clients {
-		stats[i] = &requestStat{client: c, rtt: 0, startTime: now}
+		stats[i] = &requestStat{client: c, rtt: 0}
+		upstreams[c] = newUpstreamStats(c)
 	}
 	done := make(chan struct{})
 	if requestTimeout <= 0 {
@@ -97,27 +137,51 @@ func newOptimizingClient(
 	if watchRetryInterval == 0 {
 		watchRetryInterval = defaultWatchRetryInterval
 	}
+	// until they are speed tested, clients are tried by priority
+	ranks := rankSources(clients)
+	sort.SliceStable(stats, func(i, j int) bool {
+		return ranks.less(stats[i], stats[j])
+	})
 	oc := &optimizingClient{
 		clients:            clients,
 		stats:              stats,
 		requestTimeout:     requestTimeout,
+		infoTimeout:        requestTimeout,
 		requestConcurrency: requestConcurrency,
 		speedTestInterval:  speedTestInterval,
 		watchRetryInterval: watchRetryInterval,
+		clock:              clock.NewRealClock(),
 		log:                l,
 		done:               done,
+		upstreams:          upstreams,
 	}
 	return oc, nil
 }
 
 // Start starts the background speed measurements of the optimizing client.Start
-// SetLog should not be called after Start.
+// SetLog and SetClock should not be called after Start.
 func (oc *optimizingClient) Start() {
 	if oc.speedTestInterval > 0 {
-		go oc.testSpeed()
+		oc.goTracked(oc.testSpeed)
 	}
 }
 
+// goTracked runs f in a goroutine waited for by Close. It returns false,
+// without running f, if the client is closed.
+func (oc *optimizingClient) goTracked(f func()) bool {
+	oc.closeLk.Lock()
+	defer oc.closeLk.Unlock()
+	if oc.closed {
+		return false
+	}
+	oc.wg.Add(1)
+	go func() {
+		defer oc.wg.Done()
+		f()
+	}()
+	return true
+}
+
 // MarkPassive tags a client as 'passive' - a generalization of the libp2p style gossip client.
 // These clients will not participate in the speed test horse race, and will be protected from
 // being stopped by the optimized watcher.
@@ -137,8 +201,9 @@ func (oc *optimizingClient) MarkPassive(c drand.Client) {
 
 // String returns the name of this client.
 func (oc *optimizingClient) String() string {
-	names := make([]string, len(oc.clients))
-	for i, c := range oc.clients {
+	clients := oc.currentClients()
+	names := make([]string, len(clients))
+	for i, c := range clients {
 		names[i] = fmt.Sprint(c)
 	}
 	return fmt.Sprintf("OptimizingClient(%s)", strings.Join(names, ", "))
@@ -170,41 +235,15 @@ func (oc *optimizingClient) markedPassive(c drand.Client) bool {
 }
 
 func (oc *optimizingClient) testSpeed() {
-	clients := make([]drand.Client, 0, len(oc.clients))
-	for _, c := range oc.clients {
-		if !oc.markedPassive(c) {
-			clients = append(clients, c)
-		}
-	}
-
 	for {
-		var stats []*requestStat
-		ctx, cancel := context.WithCancel(context.Background())
-		ch := parallelGet(ctx, clients, 1, oc.requestTimeout, oc.requestConcurrency)
-
-	LOOP:
-		for {
-			select {
-			case rr, ok := <-ch:
-				if !ok {
-					cancel()
-					break LOOP
-				}
-				if rr.err != nil && !errors.Is(rr.err, drand.ErrEmptyClientUnsupportedGet) {
-					oc.log.Infow("", "optimizing_client", "endpoint down when speed tested", "client", fmt.Sprintf("%s", rr.client), "err", rr.err)
-				}
-				stats = append(stats, rr.stat)
-			case <-oc.done:
-				cancel()
-				return
-			}
+		// the clients can be replaced between two speed tests
+		if !oc.speedTest(oc.currentClients()) {
+			return
 		}
 
-		oc.updateStats(stats)
-
-		t := time.NewTimer(oc.speedTestInterval)
+		t := oc.clock.NewTimer(oc.speedTestInterval)
 		select {
-		case <-t.C:
+		case <-t.Chan():
 		case <-oc.done:
 			t.Stop()
 			return
@@ -212,11 +251,139 @@ func (oc *optimizingClient) testSpeed() {
 	}
 }
 
+// speedTest measures the time the active clients among clients take to get
+// the first round, and reorders the clients accordingly. It returns false if
+// the client was closed meanwhile.
+func (oc *optimizingClient) speedTest(clients []drand.Client) bool {
+	clients = slices.DeleteFunc(clients, oc.markedPassive)
+	if len(clients) == 0 {
+		return true
+	}
+
+	var stats []*requestStat
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	ch := parallelGet(ctx, oc.clock, oc.availableClients(clients), 1, oc.requestTimeout, oc.requestConcurrency)
+
+	for {
+		select {
+		case rr, ok := <-ch:
+			if !ok {
+				oc.updateStats(stats)
+				return true
+			}
+			if rr.err != nil && !errors.Is(rr.err, drand.ErrEmptyClientUnsupportedGet) {
+				oc.log.Infow("", "optimizing_client", "endpoint down when speed tested", "client", fmt.Sprintf("%s", rr.client), "err", rr.err)
+			}
+			oc.recordGet(rr)
+			stats = append(stats, rr.stat)
+		case <-oc.done:
+			return false
+		}
+	}
+}
+
 // SetLog configures the client log output.
 func (oc *optimizingClient) SetLog(l log.Logger) {
 	oc.log = l
 }
 
+// SetClock configures the clock used to time requests and schedule speed tests.
+func (oc *optimizingClient) SetClock(clk clock.Clock) {
+	oc.clock = clk
+}
+
+// currentClients returns the clients, in the order they were given.
+func (oc *optimizingClient) currentClients() []drand.Client {
+	oc.RLock()
+	defer oc.RUnlock()
+	return slices.Clone(oc.clients)
+}
+
+// replaceClients adds clients to the optimizing client and removes others,
+// e.g. when the list of relays of the client changes. The removed clients
+// are not closed, and are no longer watched once they are. It fails, without
+// changing the clients, if none would be left or the client is closed.
+func (oc *optimizingClient) replaceClients(add, remove []drand.Client) error {
+	oc.closeLk.Lock()
+	defer oc.closeLk.Unlock()
+	if oc.closed {
+		return errors.New("client closed")
+	}
+
+	oc.Lock()
+	clients := make([]drand.Client, 0, len(oc.clients)+len(add))
+	for _, c := range oc.clients {
+		if !slices.Contains(remove, c) {
+			clients = append(clients, c)
+		}
+	}
+	if len(clients)+len(add) == 0 {
+		oc.Unlock()
+		return errors.New("missing clients")
+	}
+	oc.clients = append(clients, add...)
+	oc.stats = slices.DeleteFunc(oc.stats, func(s *requestStat) bool {
+		return slices.Contains(remove, s.client)
+	})
+	// new clients are tried first, until they are speed tested
+	for _, c := range add {
+		oc.stats = slices.Insert(oc.stats, 0, &requestStat{client: c})
+	}
+	oc.Unlock()
+
+	oc.upstreamsLk.Lock()
+	defer oc.upstreamsLk.Unlock()
+	for _, c := range remove {
+		delete(oc.upstreams, c)
+	}
+	for _, c := range add {
+		oc.upstreams[c] = newUpstreamStats(c)
+	}
+	return nil
+}
+
+// AddSource adds c to the clients, trying it first for Gets until it is speed
+// tested, right away unless speed tests are disabled.
+func (oc *optimizingClient) AddSource(c drand.Client) error {
+	name := upstreamName(c)
+	if slices.ContainsFunc(oc.currentClients(), func(o drand.Client) bool { return upstreamName(o) == name }) {
+		return fmt.Errorf("source %s already added", name)
+	}
+	if err := oc.replaceClients([]drand.Client{c}, nil); err != nil {
+		return err
+	}
+	if oc.speedTestInterval > 0 {
+		oc.goTracked(func() { oc.speedTest([]drand.Client{c}) })
+	}
+	return nil
+}
+
+// RemoveSource removes the clients named name, as reported by UpstreamStats,
+// without closing them. Passive clients cannot be removed. It returns
+// drand.ErrUnknownSource if there is no such client.
+func (oc *optimizingClient) RemoveSource(name string) error {
+	_, err := oc.removeSource(name)
+	return err
+}
+
+// removeSource removes the clients named name, and returns them.
+func (oc *optimizingClient) removeSource(name string) ([]drand.Client, error) {
+	var removed []drand.Client
+	for _, c := range oc.currentClients() {
+		if upstreamName(c) == name && !oc.markedPassive(c) {
+			removed = append(removed, c)
+		}
+	}
+	if len(removed) == 0 {
+		return nil, fmt.Errorf("%w: %s", drand.ErrUnknownSource, name)
+	}
+	if err := oc.replaceClients(nil, removed); err != nil {
+		return nil, err
+	}
+	return removed, nil
+}
+
 // fastestClients returns a ordered slice of clients - fastest first.
 func (oc *optimizingClient) fastestClients() []drand.Client {
 	oc.RLock()
@@ -231,13 +398,23 @@ func (oc *optimizingClient) fastestClients() []drand.Client {
 
 // Get returns the randomness at `round` or an error.
 func (oc *optimizingClient) Get(ctx context.Context, round uint64) (res drand.Result, err error) {
-	clients := oc.fastestClients()
+	clients := oc.availableClients(oc.fastestClients())
 	// no need to race clients when we have only one
 	if len(clients) == 1 {
-		return clients[0].Get(ctx, round)
+		rr := get(ctx, oc.clock, clients[0], round)
+		if rr == nil {
+			return nil, ctx.Err()
+		}
+		oc.recordGet(rr)
+		return rr.result, rr.err
 	}
 	var stats []*requestStat
-	ch := raceGet(ctx, clients, round, oc.requestTimeout, oc.requestConcurrency)
+	var ch <-chan *requestResult
+	if oc.hedging {
+		ch = oc.hedgedGet(ctx, clients, round)
+	} else {
+		ch = raceGet(ctx, oc.clock, clients, round, oc.requestTimeout, oc.requestConcurrency)
+	}
 	err = errors.New("no valid clients")
 
 LOOP:
@@ -247,6 +424,7 @@ LOOP:
 			if !ok {
 				break LOOP
 			}
+			oc.recordGet(rr)
 			stats = append(stats, rr.stat)
 			res = rr.result
 			if rr.err != nil && !errors.Is(rr.err, drand.ErrEmptyClientUnsupportedGet) {
@@ -268,10 +446,11 @@ LOOP:
 }
 
 // get calls Get on the passed client and returns a requestResult or nil if the context was canceled.
-func get(ctx context.Context, c drand.Client, round uint64) *requestResult {
-	start := time.Now()
+// Clients are expected to give up once the context is done, which bounds the call by its timeout.
+func get(ctx context.Context, clk clock.Clock, c drand.Client, round uint64) *requestResult {
+	start := clk.Now()
 	res, err := c.Get(ctx, round)
-	rtt := time.Since(start)
+	rtt := clk.Since(start)
 	var stat requestStat
 
 	// c failure, set a large RTT so it is sent to the back of the list
@@ -288,14 +467,15 @@ func get(ctx context.Context, c drand.Client, round uint64) *requestResult {
 	return &requestResult{c, res, err, &stat}
 }
 
-func raceGet(ctx context.Context, clients []drand.Client, round uint64, timeout time.Duration, concurrency int) <-chan *requestResult {
+//nolint:lll // This function has nicely named parameters, so it's long.
+func raceGet(ctx context.Context, clk clock.Clock, clients []drand.Client, round uint64, timeout time.Duration, concurrency int) <-chan *requestResult {
 	results := make(chan *requestResult, len(clients))
 
 	go func() {
 		rctx, cancel := context.WithCancel(ctx)
 		defer cancel()
 		defer close(results)
-		ch := parallelGet(rctx, clients, round, timeout, concurrency)
+		ch := parallelGet(rctx, clk, clients, round, timeout, concurrency)
 
 		for {
 			select {
@@ -316,7 +496,70 @@ func raceGet(ctx context.Context, clients []drand.Client, round uint64, timeout
 	return results
 }
 
-func parallelGet(ctx context.Context, clients []drand.Client, round uint64, timeout time.Duration, concurrency int) <-chan *requestResult {
+// hedgedGet gets round from the clients one at a time, in order, moving on to
+// the next client when the previous one fails or has not answered within its
+// hedge delay, until one of them succeeds. The other requests are canceled
+// once the race is won.
+func (oc *optimizingClient) hedgedGet(ctx context.Context, clients []drand.Client, round uint64) <-chan *requestResult {
+	results := make(chan *requestResult, len(clients))
+
+	go func() {
+		rctx, cancel := context.WithCancel(ctx)
+		defer cancel()
+		defer close(results)
+
+		ch := make(chan *requestResult, len(clients))
+		next, pending := 0, 0
+		var hedgeAt time.Time
+		ask := func() {
+			c := clients[next]
+			next++
+			pending++
+			hedgeAt = oc.clock.Now().Add(oc.hedgeDelayOf(c))
+			go func() {
+				gctx, gcancel := context.WithTimeout(rctx, oc.requestTimeout)
+				defer gcancel()
+				ch <- get(gctx, oc.clock, c, round)
+			}()
+		}
+
+		ask()
+		for pending > 0 {
+			var hedge <-chan time.Time
+			stop := func() bool { return false }
+			if next < len(clients) {
+				t := oc.clock.NewTimer(hedgeAt.Sub(oc.clock.Now()))
+				hedge, stop = t.Chan(), t.Stop
+			}
+			select {
+			case rr := <-ch:
+				stop()
+				pending--
+				// rr is nil when the request timed out
+				if rr != nil {
+					results <- rr
+					if rr.err == nil { // race is won
+						return
+					}
+				}
+				if next < len(clients) {
+					ask()
+				}
+			case <-hedge:
+				oc.log.Debugw("", "optimizing_client", "hedging get", "round", round, "client", clients[next])
+				ask()
+			case <-rctx.Done():
+				stop()
+				return
+			}
+		}
+	}()
+
+	return results
+}
+
+//nolint:lll // This function has nicely named parameters, so it's long.
+func parallelGet(ctx context.Context, clk clock.Clock, clients []drand.Client, round uint64, timeout time.Duration, concurrency int) <-chan *requestResult {
 	results := make(chan *requestResult, len(clients))
 	token := make(chan struct{}, concurrency)
 
@@ -333,7 +576,7 @@ func parallelGet(ctx context.Context, clients []drand.Client, round uint64, time
 				wg.Add(1)
 				go func(c drand.Client) {
 					gctx, cancel := context.WithTimeout(ctx, timeout)
-					rr := get(gctx, c, round)
+					rr := get(gctx, clk, c, round)
 					cancel()
 					if rr != nil {
 						results <- rr
@@ -369,9 +612,10 @@ func (oc *optimizingClient) updateStats(stats []*requestStat) {
 		}
 	}
 
-	// sort by fastest
+	// sort by fastest, honoring the priorities and weights of the clients
+	ranks := rankSources(oc.clients)
 	sort.Slice(oc.stats, func(i, j int) bool {
-		return oc.stats[i].rtt < oc.stats[j].rtt
+		return ranks.less(oc.stats[i], oc.stats[j])
 	})
 }
 
@@ -380,22 +624,33 @@ type watchResult struct {
 	drand.Client
 }
 
-func (oc *optimizingClient) trackWatchResults(info *chain.Info, in chan watchResult, out chan drand.Result) {
+func (oc *optimizingClient) trackWatchResults(ctx context.Context, info *chain.Info, in chan watchResult, out chan drand.Result) {
 	defer close(out)
 
 	latest := uint64(0)
 	for r := range in {
+		if ctx.Err() != nil {
+			// drain the watchers until they are all stopped
+			continue
+		}
 		round := r.Result.GetRound()
 		timeOfRound := time.Unix(common.TimeOfRound(info.Period, info.GenesisTime, round), 0)
 		stat := requestStat{
 			client:    r.Client,
-			rtt:       time.Since(timeOfRound),
+			rtt:       oc.clock.Since(timeOfRound),
 			startTime: timeOfRound,
 		}
 		oc.updateStats([]*requestStat{&stat})
+		oc.recordServed(r.Client)
 		if round > latest {
 			latest = round
-			out <- r.Result
+			if oc.onWatchSource != nil {
+				oc.onWatchSource(round, upstreamName(r.Client), oc.markedPassive(r.Client))
+			}
+			select {
+			case out <- r.Result:
+			case <-ctx.Done():
+			}
 		}
 	}
 }
@@ -405,9 +660,24 @@ func (oc *optimizingClient) Watch(ctx context.Context) <-chan drand.Result {
 	outChan := make(chan drand.Result, defaultChannelBuffer)
 	inChan := make(chan watchResult, defaultChannelBuffer)
 
+	// watches end when the client is closed
+	ctx, cancel := context.WithCancel(ctx)
+	if !oc.goTracked(func() {
+		select {
+		case <-oc.done:
+			cancel()
+		case <-ctx.Done():
+		}
+	}) {
+		cancel()
+		close(outChan)
+		return outChan
+	}
+
 	info, err := oc.Info(ctx)
 	if err != nil {
 		oc.log.Errorw("", "optimizing_client", "failed to learn info", "err", err)
+		cancel()
 		close(outChan)
 		return outChan
 	}
@@ -419,16 +689,26 @@ func (oc *optimizingClient) Watch(ctx context.Context) <-chan drand.Result {
 		protected:     make([]watchingClient, 0),
 		failed:        make([]failedClient, 0),
 		retryInterval: oc.watchRetryInterval,
+		info:          info,
+		grace:         oc.watchFailoverGrace,
+		passiveAt:     oc.clock.Now(),
 	}
 
 	closingClients := make(chan drand.Client, 1)
-	for _, c := range oc.passiveClients {
-		go state.watchNext(ctx, c, inChan, closingClients)
-		state.protected = append(state.protected, watchingClient{c, nil})
+	// the results are tracked until the dispatcher has seen all the watchers end
+	started := oc.goTracked(func() {
+		for _, c := range oc.passiveClients {
+			go state.watchNext(ctx, c, inChan, closingClients)
+			state.protected = append(state.protected, watchingClient{c, nil})
+		}
+		go state.dispatchWatchingClients(inChan, closingClients)
+		oc.trackWatchResults(ctx, info, inChan, outChan)
+		cancel()
+	})
+	if !started {
+		cancel()
+		close(outChan)
 	}
-
-	go state.dispatchWatchingClients(inChan, closingClients)
-	go oc.trackWatchResults(info, inChan, outChan)
 	return outChan
 }
 
@@ -449,6 +729,19 @@ type watchState struct {
 	protected     []watchingClient
 	failed        []failedClient
 	retryInterval time.Duration
+
+	info *chain.Info
+	// grace is how late the passive clients can be before the active ones
+	// are watched, see optimizingClient.watchFailoverGrace.
+	grace time.Duration
+	// failedOver is set while the active clients are watched despite the
+	// grace period, the passive ones being late.
+	failedOver bool
+	// passiveRound is the latest round delivered by a passive client, at
+	// passiveAt, guarded by passiveLk.
+	passiveLk    sync.Mutex
+	passiveRound uint64
+	passiveAt    time.Time
 }
 
 func (ws *watchState) dispatchWatchingClients(resultChan chan watchResult, closingClients chan drand.Client) {
@@ -457,10 +750,18 @@ func (ws *watchState) dispatchWatchingClients(resultChan chan watchResult, closi
 	// spin up initial watcher(s)
 	ws.tryRepopulate(resultChan, closingClients)
 
-	ticker := time.NewTicker(ws.optimizer.watchRetryInterval)
+	ticker := ws.optimizer.clock.NewTicker(ws.optimizer.watchRetryInterval)
 	defer ticker.Stop()
+	var passiveCheck <-chan time.Time
+	if ws.grace > 0 && len(ws.protected) > 0 {
+		t := ws.optimizer.clock.NewTicker(min(ws.grace, ws.info.Period))
+		defer t.Stop()
+		passiveCheck = t.Chan()
+	}
 	for {
 		select {
+		case <-passiveCheck:
+			ws.checkPassive(resultChan, closingClients)
 		case c := <-closingClients:
 			// replace failed watchers
 			ws.done(c)
@@ -470,7 +771,7 @@ func (ws *watchState) dispatchWatchingClients(resultChan chan watchResult, closi
 			if len(ws.active) == 0 && len(ws.protected) == 0 {
 				return
 			}
-		case <-ticker.C:
+		case <-ticker.Chan():
 			// periodically cycle to fastest client.
 			clients := ws.optimizer.fastestClients()
 			if len(clients) == 0 {
@@ -492,6 +793,9 @@ func (ws *watchState) dispatchWatchingClients(resultChan chan watchResult, closi
 
 func (ws *watchState) tryRepopulate(results chan watchResult, done chan drand.Client) {
 	ws.clean()
+	if ws.passiveOnly() {
+		return
+	}
 
 	for {
 		if len(ws.active) >= ws.optimizer.requestConcurrency {
@@ -512,17 +816,72 @@ func (ws *watchState) tryRepopulate(results chan watchResult, done chan drand.Cl
 func (ws *watchState) watchNext(ctx context.Context, c drand.Client, out chan watchResult, done chan drand.Client) {
 	defer func() { done <- c }()
 
+	// the stream is abandoned once the watch is canceled, so that clients
+	// not closing their stream on cancellation cannot block the dispatcher
 	resultStream := c.Watch(ctx)
-	for r := range resultStream {
-		out <- watchResult{r, c}
+	for {
+		select {
+		case r, ok := <-resultStream:
+			if !ok {
+				ws.optimizer.log.Infow("", "optimizing_client", "watch ended", "client", fmt.Sprintf("%s", c))
+				return
+			}
+			if ws.grace > 0 && ws.optimizer.markedPassive(c) {
+				ws.passiveDelivered(r.GetRound())
+			}
+			select {
+			case out <- watchResult{r, c}:
+			case <-ctx.Done():
+				return
+			}
+		case <-ctx.Done():
+			return
+		}
+	}
+}
+
+// passiveOnly returns whether only the passive clients are to be watched,
+// the active ones being kept for when the former are late.
+func (ws *watchState) passiveOnly() bool {
+	return ws.grace > 0 && len(ws.protected) > 0 && !ws.failedOver
+}
+
+func (ws *watchState) passiveDelivered(round uint64) {
+	ws.passiveLk.Lock()
+	defer ws.passiveLk.Unlock()
+	if round > ws.passiveRound {
+		ws.passiveRound = round
+		ws.passiveAt = ws.optimizer.clock.Now()
+	}
+}
+
+// checkPassive fails over to the active clients when the passive ones did not
+// deliver a new round for a period and the grace period, and stops watching
+// the active clients once the passive ones deliver the current round again.
+func (ws *watchState) checkPassive(results chan watchResult, done chan drand.Client) {
+	ws.passiveLk.Lock()
+	round, at := ws.passiveRound, ws.passiveAt
+	ws.passiveLk.Unlock()
+
+	now := ws.optimizer.clock.Now()
+	switch {
+	case !ws.failedOver && now.Sub(at) > ws.info.Period+ws.grace:
+		ws.optimizer.log.Warnw("", "optimizing_client", "passive watch is late, watching active clients", "last_round", round)
+		ws.failedOver = true
+		ws.tryRepopulate(results, done)
+	case ws.failedOver && round >= common.CurrentRound(now.Unix(), ws.info.Period, ws.info.GenesisTime):
+		ws.optimizer.log.Infow("", "optimizing_client", "passive watch caught up, unwatching active clients", "round", round)
+		ws.failedOver = false
+		for len(ws.active) > 0 {
+			ws.close(0)
+		}
 	}
-	ws.optimizer.log.Infow("", "optimizing_client", "watch ended", "client", fmt.Sprintf("%s", c))
 }
 
 func (ws *watchState) clean() {
 	nf := make([]failedClient, 0, len(ws.failed))
 	for _, f := range ws.failed {
-		if f.backoffUntil.After(time.Now()) {
+		if f.backoffUntil.After(ws.optimizer.clock.Now()) {
 			nf = append(nf, f)
 		}
 	}
@@ -540,7 +899,7 @@ func (ws *watchState) done(c drand.Client) {
 	idx := ws.hasActive(c)
 	if idx > -1 {
 		ws.close(idx)
-		ws.failed = append(ws.failed, failedClient{c, time.Now().Add(ws.retryInterval)})
+		ws.failed = append(ws.failed, failedClient{c, ws.optimizer.clock.Now().Add(ws.retryInterval)})
 	} else if i := ws.hasProtected(c); i > -1 {
 		ws.protected[i] = ws.protected[len(ws.protected)-1]
 		ws.protected = ws.protected[:len(ws.protected)-1]
@@ -611,7 +970,7 @@ ClientLoop:
 func (oc *optimizingClient) Info(ctx context.Context) (chainInfo *chain.Info, err error) {
 	clients := oc.fastestClients()
 	for _, c := range clients {
-		ctx, cancel := context.WithTimeout(ctx, oc.requestTimeout)
+		ctx, cancel := context.WithTimeout(ctx, oc.infoTimeout)
 		chainInfo, err = c.Info(ctx)
 		cancel()
 		if err == nil {
@@ -624,17 +983,33 @@ func (oc *optimizingClient) Info(ctx context.Context) (chainInfo *chain.Info, er
 // RoundAt will return the most recent round of randomness that will be available
 // at time for the current client.
 func (oc *optimizingClient) RoundAt(t time.Time) uint64 {
+	oc.RLock()
+	defer oc.RUnlock()
 	return oc.clients[0].RoundAt(t)
 }
 
-// Close stops the background speed tests and closes the client and it's
-// underlying clients for further use.
+// Close stops the background speed tests and watches, closes the underlying
+// clients and waits for the background goroutines to exit, returning the
+// errors of the clients, which are logged as well. Calls after the first one
+// do nothing.
 func (oc *optimizingClient) Close() error {
-	var errs *multierror.Error
-	for _, c := range oc.clients {
-		errs = multierror.Append(errs, c.Close())
+	oc.closeLk.Lock()
+	if oc.closed {
+		oc.closeLk.Unlock()
+		return nil
 	}
+	oc.closed = true
 	close(oc.done)
+	oc.closeLk.Unlock()
+
+	var errs *multierror.Error
+	for _, c := range oc.currentClients() {
+		if err := c.Close(); err != nil {
+			oc.log.Warnw("", "optimizing_client", "failed to close client", "client", fmt.Sprint(c), "err", err)
+			errs = multierror.Append(errs, err)
+		}
+	}
+	oc.wg.Wait()
 
 	return errs.ErrorOrNil()
 }
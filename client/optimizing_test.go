@@ -3,10 +3,15 @@ package client
 import (
 	"context"
 	"errors"
+	"math"
 	"sync"
 	"testing"
 	"time"
 
+	clock "github.com/jonboulle/clockwork"
+	"github.com/stretchr/testify/require"
+
+	"github.com/drand/drand/v2/common"
 	"github.com/drand/drand/v2/common/log"
 	clientMock "github.com/drand/go-clients/client/mock"
 	"github.com/drand/go-clients/client/test/result/mock"
@@ -90,6 +95,35 @@ func TestOptimizingGet(t *testing.T) {
 	expectRound(t, latestResult(t, oc), 4) // round 4 from c0
 }
 
+func TestOptimizingSpeedTestWithClock(t *testing.T) {
+	ctx := t.Context()
+	c0 := clientMock.ClientWithResults(0, 5)
+	c1 := clientMock.ClientWithResults(5, 10)
+	remaining := func() int {
+		c0.Lock()
+		defer c0.Unlock()
+		c1.Lock()
+		defer c1.Unlock()
+		return len(c0.Results) + len(c1.Results)
+	}
+
+	clk := clock.NewFakeClock()
+	lg := log.New(nil, log.DebugLevel, true)
+	oc, err := newOptimizingClient(lg, []drand.Client{c0, c1}, time.Second*5, 2, time.Minute*5, 0)
+	require.NoError(t, err)
+	oc.SetClock(clk)
+	oc.Start()
+	defer closeClient(t, oc)
+
+	// the first speed test runs immediately, then waits for the interval
+	require.NoError(t, clk.BlockUntilContext(ctx, 1))
+	require.Equal(t, 8, remaining())
+
+	clk.Advance(time.Minute * 5)
+	require.NoError(t, clk.BlockUntilContext(ctx, 1))
+	require.Equal(t, 6, remaining())
+}
+
 func TestOptimizingWatch(t *testing.T) {
 	ctx := t.Context()
 
@@ -333,3 +367,339 @@ func TestOptimizingGetWithEmpty(t *testing.T) {
 		t.Fatal("expected nil result")
 	}
 }
+
+func TestOptimizingUpstreamStats(t *testing.T) {
+	c0 := clientMock.ClientWithResults(1, 4)
+	c1 := clientMock.ClientWithResults(1, 1) // no result, every Get fails
+	// c1 fails before c0 answers, so that its failure is not cut off by the race
+	c0.Delay = 10 * time.Millisecond
+
+	lg := log.New(nil, log.DebugLevel, true)
+	oc, err := newOptimizingClient(lg, []drand.Client{c0, c1}, time.Second*5, 2, -1, 0)
+	require.NoError(t, err)
+	defer closeClient(t, oc)
+
+	for range 2 {
+		_, err := oc.Get(context.Background(), 0)
+		require.NoError(t, err)
+	}
+
+	stats := oc.UpstreamStats()
+	require.Len(t, stats, 2)
+	require.Equal(t, "Mock", stats[0].Upstream)
+	require.Equal(t, uint64(2), stats[0].RoundsServed)
+	require.Equal(t, uint64(0), stats[0].Failures)
+	require.InDelta(t, 1, stats[0].SuccessRate(), 0)
+	require.Positive(t, stats[0].LatencyP95)
+	require.GreaterOrEqual(t, stats[0].LatencyP95, stats[0].LatencyP50)
+
+	require.Positive(t, stats[1].Failures)
+	require.Equal(t, stats[1].Requests, stats[1].Failures)
+	require.Zero(t, stats[1].RoundsServed)
+	require.Contains(t, stats[1].LastFailure, "no result available")
+	require.False(t, stats[1].LastFailureTime.IsZero())
+}
+
+func TestOptimizingWatchFailoverGracePeriod(t *testing.T) {
+	ctx := t.Context()
+	chainInfo := fakeChainInfo(t)
+	clk := clock.NewFakeClockAt(time.Unix(chainInfo.GenesisTime, 0).Add(10 * time.Second))
+
+	passiveCh := make(chan drand.Result, 1)
+	passive := &clientMock.Client{WatchCh: passiveCh, OptionalInfo: chainInfo}
+	var activeLk sync.Mutex
+	activeWatching := false
+	active := &clientMock.Client{
+		OptionalInfo: chainInfo,
+		WatchF: func(ctx context.Context) <-chan drand.Result {
+			activeLk.Lock()
+			activeWatching = true
+			activeLk.Unlock()
+			ch := make(chan drand.Result)
+			go func() {
+				<-ctx.Done()
+				activeLk.Lock()
+				activeWatching = false
+				activeLk.Unlock()
+				close(ch)
+			}()
+			return ch
+		},
+	}
+	isWatching := func() bool {
+		activeLk.Lock()
+		defer activeLk.Unlock()
+		return activeWatching
+	}
+
+	var sourcesLk sync.Mutex
+	sources := make(map[uint64]bool)
+	lg := log.New(nil, log.DebugLevel, true)
+	oc, err := newOptimizingClient(lg, []drand.Client{active, passive}, 0, 0, -1, 0)
+	require.NoError(t, err)
+	oc.MarkPassive(passive)
+	oc.SetClock(clk)
+	oc.watchFailoverGrace = 500 * time.Millisecond
+	oc.onWatchSource = func(round uint64, _ string, isPassive bool) {
+		sourcesLk.Lock()
+		defer sourcesLk.Unlock()
+		sources[round] = isPassive
+	}
+	oc.Start()
+	defer closeClient(t, oc)
+
+	ch := oc.Watch(ctx)
+	// the passive client delivers the current round, the active one is not watched
+	passiveCh <- &mock.Result{Rnd: 11}
+	r := <-ch
+	expectRound(t, r, 11)
+	require.False(t, isWatching())
+
+	// the passive client is late, the active one is watched
+	require.Eventually(t, func() bool {
+		clk.Advance(500 * time.Millisecond)
+		return isWatching()
+	}, 5*time.Second, 10*time.Millisecond)
+
+	// the passive client caught up, the active one is unwatched
+	passiveCh <- &mock.Result{Rnd: common.CurrentRound(clk.Now().Unix(), chainInfo.Period, chainInfo.GenesisTime) + 1}
+	<-ch
+	require.Eventually(t, func() bool {
+		clk.Advance(500 * time.Millisecond)
+		return !isWatching()
+	}, 5*time.Second, 10*time.Millisecond)
+
+	sourcesLk.Lock()
+	defer sourcesLk.Unlock()
+	require.True(t, sources[11])
+}
+
+// stuckClient is a client whose Gets hang until their context is done.
+type stuckClient struct {
+	*clientMock.Client
+}
+
+func (s *stuckClient) Get(ctx context.Context, _ uint64) (drand.Result, error) {
+	<-ctx.Done()
+	return nil, ctx.Err()
+}
+
+func (s *stuckClient) String() string {
+	return "Stuck"
+}
+
+func TestOptimizingGetStuckUpstream(t *testing.T) {
+	c1 := &stuckClient{&clientMock.Client{}}
+	c2 := &stuckClient{&clientMock.Client{}}
+
+	lg := log.New(nil, log.DebugLevel, true)
+	oc, err := newOptimizingClient(lg, []drand.Client{c1, c2}, 50*time.Millisecond, 0, -1, 0)
+	require.NoError(t, err)
+	oc.Start()
+	defer closeClient(t, oc)
+
+	start := time.Now()
+	_, err = oc.Get(t.Context(), 1)
+	require.Error(t, err)
+	require.Less(t, time.Since(start), time.Second)
+}
+
+func TestOptimizingCircuitBreaker(t *testing.T) {
+	ctx := t.Context()
+	clk := clock.NewFakeClock()
+	failing := &clientMock.Client{}
+	slow := clientMock.ClientWithResults(0, 10)
+	slow.Delay = 50 * time.Millisecond
+
+	lg := log.New(nil, log.DebugLevel, true)
+	oc, err := newOptimizingClient(lg, []drand.Client{failing, slow}, 0, 2, -1, 0)
+	require.NoError(t, err)
+	oc.SetClock(clk)
+	oc.breakerThreshold = 2
+	oc.breakerCooldown = time.Minute
+	oc.Start()
+	defer closeClient(t, oc)
+
+	circuit := func() (drand.CircuitState, uint64) {
+		st := oc.UpstreamStats()[0]
+		return st.Circuit, st.Requests
+	}
+
+	for range 2 {
+		_, err = oc.Get(ctx, 0)
+		require.NoError(t, err)
+	}
+	state, requests := circuit()
+	require.Equal(t, drand.CircuitOpen, state)
+	require.Equal(t, uint64(2), requests)
+
+	// the failing upstream is skipped during its cool-down period
+	_, err = oc.Get(ctx, 0)
+	require.NoError(t, err)
+	_, requests = circuit()
+	require.Equal(t, uint64(2), requests)
+
+	// then tried again, and skipped for another period as it still fails
+	clk.Advance(time.Minute)
+	_, err = oc.Get(ctx, 0)
+	require.NoError(t, err)
+	state, requests = circuit()
+	require.Equal(t, drand.CircuitOpen, state)
+	require.Equal(t, uint64(3), requests)
+	require.Equal(t, drand.CircuitClosed, oc.UpstreamStats()[1].Circuit)
+}
+
+func TestOptimizingHedging(t *testing.T) {
+	ctx := t.Context()
+	lg := log.New(nil, log.DebugLevel, true)
+	newHedging := func(delay time.Duration, clients ...drand.Client) *optimizingClient {
+		oc, err := newOptimizingClient(lg, clients, time.Second*5, 2, -1, 0)
+		require.NoError(t, err)
+		oc.hedging = true
+		oc.hedgeDelay = delay
+		t.Cleanup(func() { closeClient(t, oc) })
+		return oc
+	}
+
+	// the next client is only asked when the first one is late
+	fast, next := clientMock.ClientWithResults(0, 10), clientMock.ClientWithResults(0, 10)
+	oc := newHedging(time.Minute, fast, next)
+	_, err := oc.Get(ctx, 0)
+	require.NoError(t, err)
+	stats := oc.UpstreamStats()
+	require.Equal(t, uint64(1), stats[0].Requests)
+	require.Zero(t, stats[1].Requests)
+
+	slow, next := clientMock.ClientWithResults(0, 10), clientMock.ClientWithResults(0, 10)
+	slow.Delay = time.Minute
+	oc = newHedging(50*time.Millisecond, slow, next)
+	start := time.Now()
+	_, err = oc.Get(ctx, 0)
+	require.NoError(t, err)
+	require.Less(t, time.Since(start), time.Second)
+	require.Equal(t, uint64(1), oc.UpstreamStats()[1].RoundsServed)
+
+	// a failure moves on to the next client right away
+	failing, next := clientMock.ClientWithResults(0, 0), clientMock.ClientWithResults(0, 10)
+	oc = newHedging(time.Minute, failing, next)
+	start = time.Now()
+	_, err = oc.Get(ctx, 0)
+	require.NoError(t, err)
+	require.Less(t, time.Since(start), time.Second)
+
+	// the hedge delay follows the latency of the client
+	oc = newHedging(0, clientMock.ClientWithResults(0, 10), clientMock.ClientWithResults(0, 10))
+	require.Equal(t, defaultHedgeDelay, oc.hedgeDelayOf(oc.clients[0]))
+	_, err = oc.Get(ctx, 0)
+	require.NoError(t, err)
+	require.Less(t, oc.hedgeDelayOf(oc.clients[0]), defaultHedgeDelay)
+}
+
+func TestOptimizingReplaceClients(t *testing.T) {
+	ctx := t.Context()
+	failing := &clientMock.Client{}
+	working := clientMock.ClientWithResults(0, 10)
+
+	lg := log.New(nil, log.DebugLevel, true)
+	oc, err := newOptimizingClient(lg, []drand.Client{failing}, 0, 1, -1, 0)
+	require.NoError(t, err)
+	oc.Start()
+	defer closeClient(t, oc)
+
+	_, err = oc.Get(ctx, 0)
+	require.Error(t, err)
+
+	require.NoError(t, oc.replaceClients([]drand.Client{working}, []drand.Client{failing}))
+	require.Equal(t, []drand.Client{working}, oc.currentClients())
+	require.Len(t, oc.UpstreamStats(), 1)
+	_, err = oc.Get(ctx, 0)
+	require.NoError(t, err)
+
+	// the last client is never removed
+	require.Error(t, oc.replaceClients(nil, []drand.Client{working}))
+	require.Equal(t, []drand.Client{working}, oc.fastestClients())
+}
+
+// namedClient gives a name to a mock client, as reported by UpstreamStats.
+type namedClient struct {
+	*clientMock.Client
+	name string
+}
+
+func (n *namedClient) String() string {
+	return n.name
+}
+
+func TestOptimizingAddRemoveSource(t *testing.T) {
+	ctx := t.Context()
+	first := &namedClient{clientMock.ClientWithResults(0, 10), "first"}
+	passive := &namedClient{clientMock.ClientWithResults(0, 10), "passive"}
+
+	lg := log.New(nil, log.DebugLevel, true)
+	oc, err := newOptimizingClient(lg, []drand.Client{first, passive}, 0, 1, time.Hour, 0)
+	require.NoError(t, err)
+	oc.MarkPassive(passive)
+	oc.Start()
+	defer closeClient(t, oc)
+
+	added := &namedClient{clientMock.ClientWithResults(0, 10), "added"}
+	require.NoError(t, oc.AddSource(added))
+	require.Error(t, oc.AddSource(&namedClient{clientMock.ClientWithResults(0, 10), "added"}))
+	// the new source is speed tested right away
+	require.Eventually(t, func() bool {
+		for _, st := range oc.UpstreamStats() {
+			if st.Upstream == "added" {
+				return st.Requests > 0
+			}
+		}
+		return false
+	}, 5*time.Second, 10*time.Millisecond)
+
+	require.ErrorIs(t, oc.RemoveSource("passive"), drand.ErrUnknownSource)
+	require.NoError(t, oc.RemoveSource("first"))
+	require.Equal(t, []drand.Client{passive, added}, oc.currentClients())
+	_, err = oc.Get(ctx, 0)
+	require.NoError(t, err)
+}
+
+// weightedClient gives a priority and a weight to a mock client.
+type weightedClient struct {
+	*clientMock.Client
+	priority, weight uint16
+}
+
+func (w *weightedClient) Weight() (priority, weight uint16, ok bool) {
+	return w.priority, w.weight, true
+}
+
+func TestOptimizingWeights(t *testing.T) {
+	backup := &weightedClient{&clientMock.Client{}, 20, 10}
+	light := &weightedClient{&clientMock.Client{}, 10, 10}
+	heavy := &weightedClient{&clientMock.Client{}, 10, 30}
+
+	lg := log.New(nil, log.DebugLevel, true)
+	oc, err := newOptimizingClient(lg, []drand.Client{backup, light, heavy}, 0, 1, -1, 0)
+	require.NoError(t, err)
+	defer closeClient(t, oc)
+
+	// untested clients are ordered by priority, then weight
+	require.Equal(t, []drand.Client{heavy, light, backup}, oc.fastestClients())
+
+	// the heavy client is preferred unless it is more than ~3 times slower
+	now := time.Now()
+	oc.updateStats([]*requestStat{
+		{client: backup, rtt: time.Millisecond, startTime: now},
+		{client: light, rtt: 20 * time.Millisecond, startTime: now},
+		{client: heavy, rtt: 30 * time.Millisecond, startTime: now},
+	})
+	require.Equal(t, []drand.Client{heavy, light, backup}, oc.fastestClients())
+	oc.updateStats([]*requestStat{{client: heavy, rtt: 90 * time.Millisecond, startTime: now.Add(time.Second)}})
+	require.Equal(t, []drand.Client{light, heavy, backup}, oc.fastestClients())
+
+	// failed clients come last, whatever their priority
+	oc.updateStats([]*requestStat{
+		{client: light, rtt: math.MaxInt64, startTime: now.Add(2 * time.Second)},
+		{client: heavy, rtt: math.MaxInt64, startTime: now.Add(2 * time.Second)},
+	})
+	require.Equal(t, backup, oc.fastestClients()[0])
+}
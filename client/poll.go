@@ -4,6 +4,8 @@ import (
 	"context"
 	"time"
 
+	clock "github.com/jonboulle/clockwork"
+
 	commonutils "github.com/drand/drand/v2/common"
 	"github.com/drand/drand/v2/common/chain"
 	"github.com/drand/drand/v2/common/log"
@@ -13,8 +15,13 @@ import (
 // PollingWatcher generalizes the `Watch` interface for clients which learn new values
 // by asking for them once each group period.
 func PollingWatcher(ctx context.Context, c drand.Client, chainInfo *chain.Info, l log.Logger) <-chan drand.Result {
+	return PollingWatcherWithClock(ctx, c, chainInfo, l, clock.NewRealClock())
+}
+
+// PollingWatcherWithClock is a PollingWatcher timed by the given clock.
+func PollingWatcherWithClock(ctx context.Context, c drand.Client, chainInfo *chain.Info, l log.Logger, clk clock.Clock) <-chan drand.Result {
 	ch := make(chan drand.Result, 1)
-	r := c.RoundAt(time.Now())
+	r := c.RoundAt(clk.Now())
 	val, err := c.Get(ctx, r)
 	if err != nil {
 		l.Errorw("", "polling_client", "failed synchronous get", "from", c, "err", err)
@@ -27,14 +34,14 @@ func PollingWatcher(ctx context.Context, c drand.Client, chainInfo *chain.Info,
 		defer close(ch)
 
 		// Initially, wait to synchronize to the round boundary.
-		_, nextTime := commonutils.NextRound(time.Now().Unix(), chainInfo.Period, chainInfo.GenesisTime)
+		_, nextTime := commonutils.NextRound(clk.Now().Unix(), chainInfo.Period, chainInfo.GenesisTime)
 		select {
 		case <-ctx.Done():
 			return
-		case <-time.After(time.Duration(nextTime-time.Now().Unix()) * time.Second):
+		case <-clk.After(time.Duration(nextTime-clk.Now().Unix()) * time.Second):
 		}
 
-		r, err := c.Get(ctx, c.RoundAt(time.Now()))
+		r, err := c.Get(ctx, c.RoundAt(clk.Now()))
 		if err == nil {
 			ch <- r
 		} else {
@@ -42,12 +49,12 @@ func PollingWatcher(ctx context.Context, c drand.Client, chainInfo *chain.Info,
 		}
 
 		// Then tick each period.
-		t := time.NewTicker(chainInfo.Period)
+		t := clk.NewTicker(chainInfo.Period)
 		defer t.Stop()
 		for {
 			select {
-			case <-t.C:
-				r, err := c.Get(ctx, c.RoundAt(time.Now()))
+			case <-t.Chan():
+				r, err := c.Get(ctx, c.RoundAt(clk.Now()))
 				if err == nil {
 					ch <- r
 				} else {
@@ -0,0 +1,38 @@
+package client
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	clock "github.com/jonboulle/clockwork"
+	"github.com/stretchr/testify/require"
+
+	"github.com/drand/drand/v2/common/chain"
+	"github.com/drand/drand/v2/common/log"
+	clientMock "github.com/drand/go-clients/client/mock"
+)
+
+func TestPollingWatcherWithClock(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	info := &chain.Info{Period: 30 * time.Second, GenesisTime: 1_000_000}
+	clk := clock.NewFakeClockAt(time.Unix(info.GenesisTime+5, 0))
+	m := clientMock.ClientWithResults(1, 5)
+
+	ch := PollingWatcherWithClock(ctx, m, info, log.New(nil, log.DebugLevel, true), clk)
+	require.Equal(t, uint64(1), (<-ch).GetRound())
+
+	// the watcher waits for the next round boundary, then ticks each period
+	for round := uint64(2); round < 5; round++ {
+		require.NoError(t, clk.BlockUntilContext(ctx, 1))
+		select {
+		case <-ch:
+			t.Fatal("polled before the end of the period")
+		default:
+		}
+		clk.Advance(info.Period)
+		require.Equal(t, round, (<-ch).GetRound())
+	}
+}
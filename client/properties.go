@@ -0,0 +1,72 @@
+package client
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/drand/drand/v2/common/chain"
+	"github.com/drand/drand/v2/crypto"
+	"github.com/drand/go-clients/drand"
+)
+
+// SignatureGroup is the group of the pairing the signatures of a chain are on.
+type SignatureGroup string
+
+const (
+	// G1 signatures are short, with public keys on G2.
+	G1 SignatureGroup = "G1"
+	// G2 signatures are long, with public keys on G1.
+	G2 SignatureGroup = "G2"
+)
+
+// Properties describes how the beacons of a chain are produced, as derived
+// from its scheme.
+type Properties struct {
+	// Scheme is the ID of the scheme of the chain.
+	Scheme string
+	// Chained is true when each round signs the signature of the previous
+	// one, so that the chain can only be verified from a trusted round on.
+	Chained bool
+	// SignatureGroup is the group the signatures are on.
+	SignatureGroup SignatureGroup
+	// SignatureSize is the size of the signatures, in bytes.
+	SignatureSize int
+	// Period is the time between two rounds.
+	Period time.Duration
+	// PreviousSignature is true when beacons carry the signature of the
+	// previous round, which is required to verify them.
+	PreviousSignature bool
+}
+
+// PropertiesOf returns the properties of the chain with the given info.
+func PropertiesOf(info *chain.Info) (*Properties, error) {
+	sch, err := crypto.SchemeFromName(info.Scheme)
+	if err != nil {
+		return nil, fmt.Errorf("invalid scheme in chain info: %w", err)
+	}
+
+	group := G2
+	if sch.SigGroup.PointLen() < sch.KeyGroup.PointLen() {
+		group = G1
+	}
+	chained := sch.Name == crypto.DefaultSchemeID
+	return &Properties{
+		Scheme:            sch.Name,
+		Chained:           chained,
+		SignatureGroup:    group,
+		SignatureSize:     sch.SigGroup.PointLen(),
+		Period:            info.Period,
+		PreviousSignature: chained,
+	}, nil
+}
+
+// ChainProperties returns the properties of the chain of c, e.g. to decide
+// how to verify or store its beacons.
+func ChainProperties(ctx context.Context, c drand.Client) (*Properties, error) {
+	info, err := c.Info(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("getting chain info: %w", err)
+	}
+	return PropertiesOf(info)
+}
@@ -1,7 +1,12 @@
 package client
 
 import (
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+
 	"github.com/drand/drand/v2/crypto"
+	protod "github.com/drand/drand/v2/protobuf/drand"
 )
 
 // RandomData holds the full random response from the server, including data needed
@@ -11,6 +16,11 @@ type RandomData struct {
 	Random            []byte `json:"randomness,omitempty"`
 	Sig               []byte `json:"signature,omitempty"`
 	PreviousSignature []byte `json:"previous_signature,omitempty"`
+	// BeaconID is the ID of the beacon that produced this result, if the source advertised it.
+	BeaconID string `json:"beacon_id,omitempty"`
+
+	// meta is set once the result is verified by a client made with New, see ResultMeta.
+	meta *ResultMetadata
 }
 
 // GetRound provides access to the round associated with this random data.
@@ -29,10 +39,94 @@ func (r *RandomData) GetPreviousSignature() []byte {
 	return r.PreviousSignature
 }
 
-// GetRandomness exports the randomness using the legacy SHA256 derivation path
+// GetBeaconID provides the ID of the beacon that produced this result, empty
+// if the source did not advertise it.
+func (r *RandomData) GetBeaconID() string {
+	return r.BeaconID
+}
+
+// GetRandomness exports the randomness using the legacy SHA256 derivation path,
+// see DeriveRandomness for other derivations.
 func (r *RandomData) GetRandomness() []byte {
 	if r.Random != nil {
 		return r.Random
 	}
 	return crypto.RandomnessFromSignature(r.GetSignature())
 }
+
+// randomDataJSON is the wire format of the drand HTTP API, with binary values hex encoded.
+type randomDataJSON struct {
+	Round             uint64 `json:"round,omitempty"`
+	Randomness        string `json:"randomness,omitempty"`
+	Signature         string `json:"signature,omitempty"`
+	PreviousSignature string `json:"previous_signature,omitempty"`
+	BeaconID          string `json:"beacon_id,omitempty"`
+}
+
+// MarshalJSON encodes the random data exactly as served by the drand HTTP API.
+func (r *RandomData) MarshalJSON() ([]byte, error) {
+	return json.Marshal(randomDataJSON{
+		Round:             r.Rnd,
+		Randomness:        hex.EncodeToString(r.Random),
+		Signature:         hex.EncodeToString(r.Sig),
+		PreviousSignature: hex.EncodeToString(r.PreviousSignature),
+		BeaconID:          r.BeaconID,
+	})
+}
+
+// UnmarshalJSON decodes random data as served by the drand HTTP API.
+func (r *RandomData) UnmarshalJSON(b []byte) error {
+	var raw randomDataJSON
+	if err := json.Unmarshal(b, &raw); err != nil {
+		return err
+	}
+	fields := []struct {
+		name string
+		in   string
+		out  *[]byte
+	}{
+		{"randomness", raw.Randomness, &r.Random},
+		{"signature", raw.Signature, &r.Sig},
+		{"previous_signature", raw.PreviousSignature, &r.PreviousSignature},
+	}
+	for _, f := range fields {
+		if f.in == "" {
+			*f.out = nil
+			continue
+		}
+		v, err := hex.DecodeString(f.in)
+		if err != nil {
+			return fmt.Errorf("decoding %s: %w", f.name, err)
+		}
+		*f.out = v
+	}
+	r.Rnd = raw.Round
+	r.BeaconID = raw.BeaconID
+	return nil
+}
+
+// ToProto converts the random data to its drand protobuf representation.
+func (r *RandomData) ToProto() *protod.PublicRandResponse {
+	p := &protod.PublicRandResponse{
+		Round:             r.GetRound(),
+		Signature:         r.GetSignature(),
+		PreviousSignature: r.GetPreviousSignature(),
+		Randomness:        r.GetRandomness(),
+	}
+	if r.BeaconID != "" {
+		p.Metadata = &protod.Metadata{BeaconID: r.BeaconID}
+	}
+	return p
+}
+
+// RandomDataFromProto converts a drand protobuf beacon to random data. The
+// randomness is always derived from the signature rather than trusted as is.
+func RandomDataFromProto(p *protod.PublicRandResponse) *RandomData {
+	return &RandomData{
+		Rnd:               p.GetRound(),
+		Random:            crypto.RandomnessFromSignature(p.GetSignature()),
+		Sig:               p.GetSignature(),
+		PreviousSignature: p.GetPreviousSignature(),
+		BeaconID:          p.GetMetadata().GetBeaconID(),
+	}
+}
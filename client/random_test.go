@@ -0,0 +1,106 @@
+package client_test
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/drand/drand/v2/crypto"
+	"github.com/drand/go-clients/client"
+)
+
+func TestRandomDataJSON(t *testing.T) {
+	sig := []byte{0x01, 0x02, 0x03}
+	rd := &client.RandomData{
+		Rnd:               42,
+		Random:            crypto.RandomnessFromSignature(sig),
+		Sig:               sig,
+		PreviousSignature: []byte{0xaa, 0xbb},
+	}
+
+	b, err := json.Marshal(rd)
+	require.NoError(t, err)
+	require.JSONEq(t, `{
+		"round": 42,
+		"randomness": "`+"039058c6f2c0cb492c533b0a4d14ef77cc0f78abccced5287d84a1a2011cfb81"+`",
+		"signature": "010203",
+		"previous_signature": "aabb"
+	}`, string(b))
+
+	var decoded client.RandomData
+	require.NoError(t, json.Unmarshal(b, &decoded))
+	require.Equal(t, rd, &decoded)
+
+	var unchained client.RandomData
+	require.NoError(t, json.Unmarshal([]byte(`{"round":1,"signature":"ff"}`), &unchained))
+	require.Nil(t, unchained.PreviousSignature)
+	require.Equal(t, crypto.RandomnessFromSignature([]byte{0xff}), unchained.GetRandomness())
+
+	require.Error(t, json.Unmarshal([]byte(`{"round":1,"signature":"zz"}`), &unchained))
+}
+
+func TestRandomDataProto(t *testing.T) {
+	sig := []byte{0x01, 0x02, 0x03}
+	rd := &client.RandomData{
+		Rnd:               7,
+		Random:            crypto.RandomnessFromSignature(sig),
+		Sig:               sig,
+		PreviousSignature: []byte{0xaa},
+	}
+	p := rd.ToProto()
+	require.Equal(t, rd.GetRound(), p.GetRound())
+	require.Equal(t, rd.GetRandomness(), p.GetRandomness())
+	require.Equal(t, rd, client.RandomDataFromProto(p))
+}
+
+func TestRandomDataBeaconID(t *testing.T) {
+	rd := &client.RandomData{Rnd: 3, Sig: []byte{0x01}, BeaconID: "quicknet"}
+
+	b, err := json.Marshal(rd)
+	require.NoError(t, err)
+	var decoded client.RandomData
+	require.NoError(t, json.Unmarshal(b, &decoded))
+	require.Equal(t, "quicknet", decoded.GetBeaconID())
+
+	p := rd.ToProto()
+	require.Equal(t, "quicknet", p.GetMetadata().GetBeaconID())
+	require.Equal(t, "quicknet", client.RandomDataFromProto(p).GetBeaconID())
+}
+
+func TestDeriveRandomness(t *testing.T) {
+	sig := []byte{0x01, 0x02, 0x03}
+	rd := &client.RandomData{Rnd: 42, Sig: sig}
+
+	legacy, err := client.DeriveRandomness(rd, client.SHA256Derivation, nil, 0)
+	require.NoError(t, err)
+	require.Equal(t, rd.GetRandomness(), legacy)
+
+	truncated, err := client.DeriveRandomness(rd, client.SHA256Derivation, nil, 16)
+	require.NoError(t, err)
+	require.Equal(t, legacy[:16], truncated)
+
+	long, err := client.DeriveRandomness(rd, client.SHA512Derivation, nil, 0)
+	require.NoError(t, err)
+	require.Len(t, long, 64)
+
+	_, err = client.DeriveRandomness(rd, client.SHA256Derivation, nil, 64)
+	require.Error(t, err)
+
+	a, err := rd.DeriveRandomness([]byte("lottery"), 100)
+	require.NoError(t, err)
+	require.Len(t, a, 100)
+	again, err := client.DeriveRandomness(rd, client.HKDFDerivation, []byte("lottery"), 100)
+	require.NoError(t, err)
+	require.Equal(t, a, again)
+	b, err := rd.DeriveRandomness([]byte("raffle"), 100)
+	require.NoError(t, err)
+	require.NotEqual(t, a, b)
+
+	_, err = rd.DeriveRandomness(nil, 0)
+	require.Error(t, err)
+	_, err = rd.DeriveRandomness(nil, 255*32+1)
+	require.Error(t, err)
+	_, err = client.DeriveRandomness(&client.RandomData{Rnd: 1}, client.SHA256Derivation, nil, 0)
+	require.Error(t, err)
+}
@@ -0,0 +1,74 @@
+package client
+
+import (
+	"context"
+	"fmt"
+	"iter"
+	"sync"
+
+	"github.com/drand/go-clients/drand"
+)
+
+// rangeConcurrency bounds how many rounds GetRange fetches ahead of the one it
+// yields.
+const rangeConcurrency = defaultCatchUpConcurrency
+
+// GetRange returns the rounds from to to (both included) of c, in order, as Get
+// does for each of them. Up to a few rounds are fetched concurrently ahead of
+// the one yielded, so that long ranges do not wait for a round trip per round,
+// without buffering the whole range. The iteration stops after yielding the
+// first error, with a nil result.
+func GetRange(ctx context.Context, c drand.Client, from, to uint64, opts ...CallOption) iter.Seq2[drand.Result, error] {
+	return func(yield func(drand.Result, error) bool) {
+		if from > to {
+			yield(nil, fmt.Errorf("invalid range: round %d is after round %d", from, to))
+			return
+		}
+
+		ctx, cancel := context.WithCancel(ctx)
+		var wg sync.WaitGroup
+		defer func() {
+			cancel()
+			wg.Wait()
+		}()
+
+		type fetched struct {
+			round  uint64
+			result drand.Result
+			err    error
+		}
+		pending := make([]chan fetched, 0, rangeConcurrency)
+		next, more := from, true
+		for more || len(pending) > 0 {
+			for more && len(pending) < rangeConcurrency {
+				ch := make(chan fetched, 1)
+				pending = append(pending, ch)
+				wg.Add(1)
+				go func(round uint64) {
+					defer wg.Done()
+					r, err := Get(ctx, c, round, opts...)
+					ch <- fetched{round: round, result: r, err: err}
+				}(next)
+				// to may be the last uint64, so stop before next overflows
+				if next == to {
+					more = false
+				} else {
+					next++
+				}
+			}
+
+			f := <-pending[0]
+			pending = pending[1:]
+			if f.err == nil && f.result.GetRound() != f.round {
+				f.err = fmt.Errorf("round mismatch: got %d", f.result.GetRound())
+			}
+			if f.err != nil {
+				yield(nil, fmt.Errorf("getting round %d: %w", f.round, f.err))
+				return
+			}
+			if !yield(f.result, nil) {
+				return
+			}
+		}
+	}
+}
@@ -0,0 +1,95 @@
+package client_test
+
+import (
+	"context"
+	"errors"
+	"math"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/drand/go-clients/client"
+	clientMock "github.com/drand/go-clients/client/mock"
+	"github.com/drand/go-clients/client/test/result/mock"
+	"github.com/drand/go-clients/drand"
+)
+
+// rangeClient serves every round after a delay decreasing with the round, so
+// that concurrent Gets complete out of order.
+type rangeClient struct {
+	clientMock.Client
+	inFlight, maxInFlight atomic.Int32
+	failAt                uint64
+}
+
+func (c *rangeClient) Get(ctx context.Context, round uint64) (drand.Result, error) {
+	n := c.inFlight.Add(1)
+	defer c.inFlight.Add(-1)
+	for m := c.maxInFlight.Load(); n > m && !c.maxInFlight.CompareAndSwap(m, n); m = c.maxInFlight.Load() {
+	}
+	select {
+	case <-time.After(time.Duration(round%4) * time.Millisecond):
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	}
+	if round == c.failAt {
+		return nil, errors.New("unavailable")
+	}
+	r := mock.NewMockResult(round)
+	return &r, nil
+}
+
+func TestGetRange(t *testing.T) {
+	ctx := context.Background()
+	c := &rangeClient{}
+
+	var rounds []uint64
+	for r, err := range client.GetRange(ctx, c, 1, 50) {
+		require.NoError(t, err)
+		rounds = append(rounds, r.GetRound())
+	}
+	require.Len(t, rounds, 50)
+	for i, round := range rounds {
+		require.Equal(t, uint64(i+1), round)
+	}
+	require.Greater(t, c.maxInFlight.Load(), int32(1))
+	require.LessOrEqual(t, c.maxInFlight.Load(), int32(8))
+
+	// stopping the iteration early waits for the rounds fetched ahead
+	for r := range client.GetRange(ctx, c, 1, 50) {
+		if r.GetRound() == 3 {
+			break
+		}
+	}
+	require.Zero(t, c.inFlight.Load())
+
+	// the last round does not overflow
+	rounds = rounds[:0]
+	for r, err := range client.GetRange(ctx, c, math.MaxUint64-1, math.MaxUint64) {
+		require.NoError(t, err)
+		rounds = append(rounds, r.GetRound())
+	}
+	require.Equal(t, []uint64{math.MaxUint64 - 1, math.MaxUint64}, rounds)
+
+	c.failAt = 5
+	rounds = rounds[:0]
+	var err error
+	for r, rerr := range client.GetRange(ctx, c, 1, 10) {
+		if rerr != nil {
+			err = rerr
+			break
+		}
+		rounds = append(rounds, r.GetRound())
+	}
+	require.ErrorContains(t, err, "getting round 5")
+	require.Equal(t, []uint64{1, 2, 3, 4}, rounds)
+
+	n := 0
+	for _, err := range client.GetRange(ctx, c, 3, 2) {
+		require.ErrorContains(t, err, "invalid range")
+		n++
+	}
+	require.Equal(t, 1, n)
+}
@@ -0,0 +1,89 @@
+package client
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"time"
+
+	clock "github.com/jonboulle/clockwork"
+
+	"github.com/drand/go-clients/drand"
+)
+
+// tokenBucket is a token bucket rate limiter refilled at `rate` tokens per
+// second, holding at most `burst` tokens.
+type tokenBucket struct {
+	sync.Mutex
+	rate   float64
+	burst  float64
+	tokens float64
+	last   time.Time
+	clock  clock.Clock
+}
+
+func newTokenBucket(clk clock.Clock, rate float64, burst int) *tokenBucket {
+	return &tokenBucket{
+		rate:   rate,
+		burst:  float64(burst),
+		tokens: float64(burst),
+		last:   clk.Now(),
+		clock:  clk,
+	}
+}
+
+// wait blocks until a token is available or the context is done.
+func (b *tokenBucket) wait(ctx context.Context) error {
+	for {
+		b.Lock()
+		now := b.clock.Now()
+		b.tokens = min(b.burst, b.tokens+now.Sub(b.last).Seconds()*b.rate)
+		b.last = now
+		if b.tokens >= 1 {
+			b.tokens--
+			b.Unlock()
+			return nil
+		}
+		delay := time.Duration((1 - b.tokens) / b.rate * float64(time.Second))
+		b.Unlock()
+
+		t := b.clock.NewTimer(delay)
+		select {
+		case <-t.Chan():
+		case <-ctx.Done():
+			t.Stop()
+			return ctx.Err()
+		}
+	}
+}
+
+// rateLimitedClient throttles the Get requests made to an upstream. Info is
+// not throttled: the verifier asks for it on every Get, and the sources keep
+// it in memory once fetched, so it would spend a token per Get without
+// reaching the network.
+type rateLimitedClient struct {
+	drand.Client
+	bucket *tokenBucket
+}
+
+// newRateLimitedClient wraps a client so that it makes at most rps requests
+// per second on average, with bursts of up to burst requests.
+func newRateLimitedClient(clk clock.Clock, c drand.Client, rps float64, burst int) drand.Client {
+	return &rateLimitedClient{
+		Client: c,
+		bucket: newTokenBucket(clk, rps, burst),
+	}
+}
+
+// Get returns the randomness at `round` or an error.
+func (r *rateLimitedClient) Get(ctx context.Context, round uint64) (drand.Result, error) {
+	if err := r.bucket.wait(ctx); err != nil {
+		return nil, err
+	}
+	return r.Client.Get(ctx, round)
+}
+
+// String returns the name of this client.
+func (r *rateLimitedClient) String() string {
+	return fmt.Sprintf("%s.(+ratelimit)", r.Client)
+}
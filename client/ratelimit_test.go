@@ -0,0 +1,88 @@
+package client
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	clock "github.com/jonboulle/clockwork"
+	"github.com/stretchr/testify/require"
+
+	"github.com/drand/drand/v2/crypto"
+	clientMock "github.com/drand/go-clients/client/mock"
+	"github.com/drand/go-clients/client/test/result/mock"
+)
+
+func TestRateLimitedClientBurst(t *testing.T) {
+	ctx := context.Background()
+	clk := clock.NewFakeClock()
+	m := clientMock.ClientWithResults(1, 10)
+	c := newRateLimitedClient(clk, m, 10, 3)
+
+	// the burst is served without waiting
+	for i := 0; i < 3; i++ {
+		_, err := c.Get(ctx, 0)
+		require.NoError(t, err)
+	}
+
+	// the bucket is now empty, the next token comes after 1/rps
+	done := make(chan error)
+	go func() {
+		_, err := c.Get(ctx, 0)
+		done <- err
+	}()
+	require.NoError(t, clk.BlockUntilContext(ctx, 1))
+	select {
+	case <-done:
+		t.Fatal("request should be throttled")
+	default:
+	}
+	clk.Advance(100 * time.Millisecond)
+	require.NoError(t, <-done)
+}
+
+func TestRateLimitedClientContext(t *testing.T) {
+	m := clientMock.ClientWithResults(1, 10)
+	c := newRateLimitedClient(clock.NewRealClock(), m, 0.1, 1)
+
+	_, err := c.Get(context.Background(), 0)
+	require.NoError(t, err)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+	defer cancel()
+	_, err = c.Get(ctx, 0)
+	require.True(t, errors.Is(err, context.DeadlineExceeded))
+}
+
+func TestRateLimitedClientBurstThroughNew(t *testing.T) {
+	ctx := context.Background()
+	sch, err := crypto.GetSchemeFromEnv()
+	require.NoError(t, err)
+	info, results := mock.VerifiableResults(3, sch)
+	source := &clientMock.Client{OptionalInfo: info, Results: results, StrictRounds: true}
+
+	// the clock is never advanced, so that no token is refilled
+	c, err := New(From(source), WithChainInfo(info), WithCacheSize(0), WithSpeedTestInterval(-1),
+		WithRateLimit(1, 2), WithClock(clock.NewFakeClock()))
+	require.NoError(t, err)
+	defer c.Close()
+
+	get := func(round uint64) error {
+		ctx, cancel := context.WithTimeout(ctx, 100*time.Millisecond)
+		defer cancel()
+		_, err := c.Get(ctx, round)
+		return err
+	}
+	// the chain info asked for by the verifier does not spend the burst
+	require.NoError(t, get(1))
+	require.NoError(t, get(2))
+	require.Error(t, get(3))
+}
+
+func TestWithRateLimitInvalid(t *testing.T) {
+	cfg := &clientConfig{}
+	require.Error(t, WithRateLimit(0, 1)(cfg))
+	require.Error(t, WithRateLimit(1, 0)(cfg))
+	require.NoError(t, WithRateLimit(1, 1)(cfg))
+}
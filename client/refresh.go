@@ -0,0 +1,106 @@
+package client
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/drand/drand/v2/common/chain"
+	"github.com/drand/go-clients/drand"
+)
+
+// InfoRefresher is implemented by the clients able to fetch the chain info
+// currently served by their source, bypassing the info they were set up with.
+type InfoRefresher interface {
+	RefreshChainInfo(ctx context.Context) (*chain.Info, error)
+}
+
+// ChainChangedError means a source serves another chain than the one the
+// client trusts, e.g. after a reshare or a migration of the relay. It wraps
+// drand.ErrInvalidChainHash.
+type ChainChangedError struct {
+	// Source is the name of the client serving the other chain.
+	Source string
+	// Expected is the info of the chain trusted by the client.
+	Expected *chain.Info
+	// Served is the info of the chain now served by the source.
+	Served *chain.Info
+}
+
+func (e *ChainChangedError) Error() string {
+	return fmt.Sprintf("%s: %s serves chain %x instead of %x", drand.ErrInvalidChainHash, e.Source, e.Served.Hash(), e.Expected.Hash())
+}
+
+func (e *ChainChangedError) Unwrap() error {
+	return drand.ErrInvalidChainHash
+}
+
+// refreshInfo fetches the chain info served by the source of v, and compares
+// it to the trusted one. Until the source serves the trusted chain again, Get
+// fails with the resulting ChainChangedError. The error is only returned when
+// the source starts serving another chain, not while it keeps doing so.
+func (v *verifyingClient) refreshInfo(ctx context.Context) (*ChainChangedError, error) {
+	if v.refresher == nil {
+		return nil, nil
+	}
+	expected, err := v.indirectClient.Info(ctx)
+	if err != nil {
+		return nil, err
+	}
+	served, err := v.refresher.RefreshChainInfo(ctx)
+	if err != nil {
+		return nil, err
+	}
+	if bytes.Equal(served.Hash(), expected.Hash()) && (v.requiredScheme == "" || served.Scheme == v.requiredScheme) {
+		if v.chainChanged.Swap(nil) != nil {
+			v.log.Infow("", "verifying_client", "source serves the trusted chain again", "source", fmt.Sprint(v.Client))
+		}
+		return nil, nil
+	}
+	cerr := &ChainChangedError{Source: fmt.Sprint(v.Client), Expected: expected, Served: served}
+	if prev := v.chainChanged.Swap(cerr); prev != nil && bytes.Equal(prev.Served.Hash(), served.Hash()) {
+		return nil, nil
+	}
+	return cerr, nil
+}
+
+// startInfoRefresh periodically checks that the sources of the client still
+// serve the trusted chain, until the client is stopped.
+func (c *watchAggregator) startInfoRefresh(interval time.Duration, onChange func(*ChainChangedError)) {
+	ctx, cancel := context.WithCancel(context.Background())
+	c.wg.Add(2)
+	go func() {
+		defer c.wg.Done()
+		<-c.stopping
+		cancel()
+	}()
+	go func() {
+		defer c.wg.Done()
+		for {
+			t := c.clock.NewTimer(interval)
+			select {
+			case <-t.Chan():
+			case <-ctx.Done():
+				t.Stop()
+				return
+			}
+			for _, v := range c.currentVerifiers() {
+				rctx, rcancel := context.WithTimeout(ctx, interval)
+				cerr, err := v.refreshInfo(rctx)
+				rcancel()
+				if err != nil {
+					c.log.Warnw("", "watch_aggregator", "failed to refresh chain info", "source", fmt.Sprint(v.Client), "err", err)
+					continue
+				}
+				if cerr == nil {
+					continue
+				}
+				c.log.Errorw("", "watch_aggregator", "source serves another chain", "err", cerr)
+				if onChange != nil {
+					onChange(cerr)
+				}
+			}
+		}
+	}()
+}
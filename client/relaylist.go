@@ -0,0 +1,154 @@
+package client
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"maps"
+	"slices"
+	"time"
+
+	"github.com/drand/drand/v2/common/log"
+	"github.com/drand/go-clients/drand"
+)
+
+// RelayListFunc returns the URLs of the relays a client should use, e.g. from
+// a list published by the operator of a relay fleet, see WithRelayList.
+type RelayListFunc func(ctx context.Context) ([]string, error)
+
+// RelayCtor creates a client for a relay of the list given to WithRelayList.
+// The relay must serve the chain of the given hash, which is nil when the
+// client is insecure and its chain info not known yet.
+type RelayCtor func(ctx context.Context, l log.Logger, url string, chainHash []byte) (drand.Client, error)
+
+// relayList is the configuration of WithRelayList.
+type relayList struct {
+	list     RelayListFunc
+	ctor     RelayCtor
+	interval time.Duration
+}
+
+// fetch creates a client for each relay of the list. The relays which cannot
+// be reached are logged and skipped.
+func (rl *relayList) fetch(ctx context.Context, l log.Logger, chainHash []byte) ([]drand.Client, []string, error) {
+	urls, err := rl.list(ctx)
+	if err != nil {
+		return nil, nil, fmt.Errorf("fetching relay list: %w", err)
+	}
+	clients := make([]drand.Client, 0, len(urls))
+	created := make([]string, 0, len(urls))
+	for _, url := range urls {
+		if slices.Contains(created, url) {
+			continue
+		}
+		c, err := rl.ctor(ctx, l, url, chainHash)
+		if err != nil {
+			l.Warnw("", "relay_list", "skipping relay", "url", url, "err", err)
+			continue
+		}
+		clients = append(clients, c)
+		created = append(created, url)
+	}
+	return clients, created, nil
+}
+
+// startRelayListRefresh periodically fetches the relay list, and replaces the
+// relays of the optimizing client accordingly, until the client is stopped.
+func (c *watchAggregator) startRelayListRefresh() {
+	ctx, cancel := context.WithCancel(context.Background())
+	c.wg.Add(2)
+	go func() {
+		defer c.wg.Done()
+		<-c.stopping
+		cancel()
+	}()
+	go func() {
+		defer c.wg.Done()
+		for {
+			t := c.clock.NewTimer(c.relayList.interval)
+			select {
+			case <-t.Chan():
+			case <-ctx.Done():
+				t.Stop()
+				return
+			}
+			rctx, rcancel := context.WithTimeout(ctx, c.relayList.interval)
+			err := c.refreshRelays(rctx)
+			rcancel()
+			if err != nil {
+				c.log.Warnw("", "watch_aggregator", "failed to refresh relay list", "err", err)
+			}
+		}
+	}()
+}
+
+// refreshRelays adds the relays of the list the client does not use yet, and
+// removes and closes the ones which are no longer listed. Relays which cannot
+// be reached are retried at the next refresh.
+func (c *watchAggregator) refreshRelays(ctx context.Context) error {
+	c.verifiersLk.RLock()
+	current := maps.Clone(c.relays)
+	c.verifiersLk.RUnlock()
+
+	urls, err := c.relayList.list(ctx)
+	if err != nil {
+		return fmt.Errorf("fetching relay list: %w", err)
+	}
+	var added []*verifyingClient
+	var addedURLs []string
+	for _, url := range urls {
+		if _, ok := current[url]; ok || slices.Contains(addedURLs, url) {
+			continue
+		}
+		source, err := c.relayList.ctor(ctx, c.log, url, c.info.Hash())
+		if err != nil {
+			c.log.Warnw("", "watch_aggregator", "skipping new relay", "url", url, "err", err)
+			continue
+		}
+		added = append(added, c.newVerifier(source))
+		addedURLs = append(addedURLs, url)
+	}
+	var removed []*verifyingClient
+	var removedURLs []string
+	for url, v := range current {
+		if !slices.Contains(urls, url) {
+			removed = append(removed, v)
+			removedURLs = append(removedURLs, url)
+		}
+	}
+	if len(added) == 0 && len(removed) == 0 {
+		return nil
+	}
+
+	if err := c.optimizer.replaceClients(asClients(added), asClients(removed)); err != nil {
+		for _, v := range added {
+			err = errors.Join(err, v.Close())
+		}
+		return err
+	}
+	c.verifiersLk.Lock()
+	for i, v := range added {
+		c.relays[addedURLs[i]] = v
+		c.verifiers = append(c.verifiers, v)
+	}
+	for i, v := range removed {
+		delete(c.relays, removedURLs[i])
+		c.verifiers = slices.DeleteFunc(c.verifiers, func(o *verifyingClient) bool { return o == v })
+	}
+	c.verifiersLk.Unlock()
+
+	c.log.Infow("", "watch_aggregator", "relay list changed", "added", addedURLs, "removed", removedURLs)
+	var errs error
+	for _, v := range removed {
+		errs = errors.Join(errs, v.Close())
+	}
+	return errs
+}
+
+func asClients(verifiers []*verifyingClient) []drand.Client {
+	clients := make([]drand.Client, len(verifiers))
+	for i, v := range verifiers {
+		clients[i] = v
+	}
+	return clients
+}
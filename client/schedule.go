@@ -0,0 +1,56 @@
+package client
+
+import (
+	"context"
+	"time"
+
+	"github.com/drand/drand/v2/common"
+	"github.com/drand/go-clients/drand"
+)
+
+// NextRoundTime returns the first round of the chain starting after now, and
+// its time, without fetching anything.
+func (c *watchAggregator) NextRoundTime(now time.Time) (uint64, time.Time) {
+	round, t := common.NextRound(now.Unix(), c.info.Period, c.info.GenesisTime)
+	return round, time.Unix(t, 0)
+}
+
+// Schedule returns a channel receiving a tick at the start of each round,
+// according to the clock of the client, without fetching the rounds. Ticks are
+// dropped rather than delayed when the receiver lags behind, so that the ones
+// received are always on time. The channel is closed once ctx is done or the
+// client is stopped.
+func (c *watchAggregator) Schedule(ctx context.Context) <-chan drand.RoundTick {
+	ch := make(chan drand.RoundTick, 1)
+	c.subscriberLock.Lock()
+	defer c.subscriberLock.Unlock()
+	if c.closed {
+		close(ch)
+		return ch
+	}
+
+	c.wg.Add(1)
+	go func() {
+		defer c.wg.Done()
+		defer close(ch)
+		for {
+			round, at := c.NextRoundTime(c.clock.Now())
+			t := c.clock.NewTimer(at.Sub(c.clock.Now()))
+			select {
+			case <-t.Chan():
+			case <-ctx.Done():
+				t.Stop()
+				return
+			case <-c.stopping:
+				t.Stop()
+				return
+			}
+			select {
+			case ch <- drand.RoundTick{Round: round, Time: at}:
+			default:
+				c.log.Debugw("", "watch_aggregator", "dropped round tick", "round", round)
+			}
+		}
+	}()
+	return ch
+}
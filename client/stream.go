@@ -0,0 +1,71 @@
+package client
+
+import (
+	"context"
+	"encoding/binary"
+	"math/rand/v2"
+
+	"github.com/drand/go-clients/drand"
+)
+
+// Map watches c, and returns the values f computes from the new results, e.g.
+// their randomness with drand.Result.GetRandomness, or integers with IntN:
+//
+//	dice := client.Map(ctx, c, client.IntN(6))
+//
+// The channel is closed when the watch ends, or ctx is done.
+func Map[T any](ctx context.Context, c drand.Client, f func(drand.Result) T) <-chan T {
+	return Reduce(ctx, c, *new(T), func(_ T, r drand.Result) T {
+		return f(r)
+	})
+}
+
+// Reduce watches c, and returns the values accumulated by f from initial and
+// the new results, one value for each result, e.g. a running count or digest
+// of the rounds watched:
+//
+//	counts := client.Reduce(ctx, c, 0, func(n int, _ drand.Result) int { return n + 1 })
+//
+// The channel is closed when the watch ends, or ctx is done.
+func Reduce[T any](ctx context.Context, c drand.Client, initial T, f func(T, drand.Result) T) <-chan T {
+	in := c.Watch(ctx)
+	out := make(chan T, aggregatorWatchBuffer)
+	go func() {
+		defer close(out)
+		acc := initial
+		for r := range in {
+			acc = f(acc, r)
+			select {
+			case out <- acc:
+			case <-ctx.Done():
+				return
+			}
+		}
+	}()
+	return out
+}
+
+// Uint64 returns the first 8 bytes of the randomness of r, as a big endian
+// integer, or 0 if r has less randomness.
+func Uint64(r drand.Result) uint64 {
+	rnd := r.GetRandomness()
+	if len(rnd) < 8 {
+		return 0
+	}
+	return binary.BigEndian.Uint64(rnd)
+}
+
+// IntN returns a function deriving from the randomness of a result an integer
+// uniformly distributed in [0, n), without modulo bias. The integer is drawn
+// from a ChaCha8 generator seeded with the randomness, so it is the same for
+// every consumer of the result. It panics if n <= 0.
+func IntN(n int) func(drand.Result) int {
+	if n <= 0 {
+		panic("client: invalid argument to IntN")
+	}
+	return func(r drand.Result) int {
+		var seed [32]byte
+		copy(seed[:], r.GetRandomness())
+		return rand.New(rand.NewChaCha8(seed)).IntN(n) //nolint:gosec // seeded with the randomness of the beacon
+	}
+}
@@ -0,0 +1,52 @@
+package client
+
+import (
+	"bytes"
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	clientMock "github.com/drand/go-clients/client/mock"
+	"github.com/drand/go-clients/drand"
+)
+
+// collect returns the values of ch, until it is closed.
+func collect[T any](ch <-chan T) []T {
+	var values []T
+	for v := range ch {
+		values = append(values, v)
+	}
+	return values
+}
+
+func TestMapReduce(t *testing.T) {
+	ctx := context.Background()
+	c := clientMock.Scenario().Rounds(1, 4).Client()
+	rnd := collect(Map(ctx, c, drand.Result.GetRandomness))
+	require.Len(t, rnd, 4)
+	require.False(t, bytes.Equal(rnd[0], rnd[1]))
+
+	c = clientMock.Scenario().Rounds(1, 4).Client()
+	sums := collect(Reduce(ctx, c, uint64(0), func(sum uint64, r drand.Result) uint64 { return sum + r.GetRound() }))
+	require.Equal(t, []uint64{1, 3, 6, 10}, sums)
+
+	// the derived integers are deterministic, and in range
+	c = clientMock.Scenario().Rounds(1, 64).Client()
+	dice := collect(Map(ctx, c, IntN(6)))
+	require.Len(t, dice, 64)
+	seen := make(map[int]bool)
+	for _, d := range dice {
+		require.True(t, d >= 0 && d < 6)
+		seen[d] = true
+	}
+	require.Len(t, seen, 6)
+	c = clientMock.Scenario().Rounds(1, 64).Client()
+	require.Equal(t, dice, collect(Map(ctx, c, IntN(6))))
+
+	c = clientMock.Scenario().Round(1).Client()
+	r, err := c.Get(ctx, 1)
+	require.NoError(t, err)
+	require.NotZero(t, Uint64(r))
+	require.Panics(t, func() { IntN(0) })
+}
@@ -10,6 +10,7 @@ import (
 
 	"github.com/drand/drand/v2/common/chain"
 	"github.com/drand/drand/v2/crypto"
+	"github.com/drand/kyber"
 	"github.com/drand/kyber/share"
 	"github.com/drand/kyber/sign/tbls"
 	"github.com/drand/kyber/util/random"
@@ -88,12 +89,26 @@ func roundToBytes(r int) []byte {
 // VerifiableResults creates a set of results that will pass a `chain.Verify` check.
 func VerifiableResults(count int, sch *crypto.Scheme) (*chain.Info, []Result) {
 	secret := sch.KeyGroup.Scalar().Pick(random.New())
-	public := sch.KeyGroup.Point().Mul(secret, nil)
 	previous := make([]byte, 32)
 	if _, err := rand.Reader.Read(previous); err != nil {
 		panic(err)
 	}
 
+	out := SignResults(sch, secret, previous, count)
+	info := chain.Info{
+		PublicKey:   sch.KeyGroup.Point().Mul(secret, nil),
+		Period:      time.Second,
+		GenesisTime: time.Now().Unix() - int64(count),
+		GenesisSeed: out[0].PSig,
+		Scheme:      sch.Name,
+	}
+
+	return &info, out
+}
+
+// SignResults signs the first count rounds of a chain of the scheme sch, whose
+// group secret key is secret and genesis seed is previous.
+func SignResults(sch *crypto.Scheme, secret kyber.Scalar, previous []byte, count int) []Result {
 	out := make([]Result, count)
 	for i := range out {
 		var msg []byte
@@ -128,13 +143,5 @@ func VerifiableResults(count int, sch *crypto.Scheme) (*chain.Info, []Result) {
 			previous = nil
 		}
 	}
-	info := chain.Info{
-		PublicKey:   public,
-		Period:      time.Second,
-		GenesisTime: time.Now().Unix() - int64(count),
-		GenesisSeed: out[0].PSig,
-		Scheme:      sch.Name,
-	}
-
-	return &info, out
+	return out
 }
@@ -0,0 +1,233 @@
+package client
+
+import (
+	"errors"
+	"fmt"
+	"slices"
+	"time"
+
+	"github.com/drand/go-clients/drand"
+	"github.com/drand/go-clients/internal/metrics"
+)
+
+// latencyWindow is the number of latency samples percentiles are computed from.
+const latencyWindow = 128
+
+// upstreamStats accumulates the statistics of an upstream of the optimizing client.
+type upstreamStats struct {
+	name      string
+	requests  uint64
+	failures  uint64
+	served    uint64
+	latencies []time.Duration
+	next      int
+	lastErr   error
+	lastErrAt time.Time
+
+	// consecutive is the number of failed Gets since the last successful one.
+	consecutive int
+	// circuit is the state of the circuit breaker, open until openUntil.
+	circuit   drand.CircuitState
+	openUntil time.Time
+}
+
+func newUpstreamStats(c drand.Client) *upstreamStats {
+	return &upstreamStats{
+		name:      upstreamName(c),
+		latencies: make([]time.Duration, 0, latencyWindow),
+		circuit:   drand.CircuitClosed,
+	}
+}
+
+// upstreamName returns the name of the source behind the layers added by client.New.
+func upstreamName(c drand.Client) string {
+	if v, ok := c.(*verifyingClient); ok {
+		return v.source
+	}
+	return fmt.Sprint(upstream(c))
+}
+
+// upstreamTransport returns the name of the transport of the source behind
+// the layers added by client.New, if it names it.
+func upstreamTransport(c drand.Client) string {
+	if tc, ok := upstream(c).(TransportClient); ok {
+		return tc.Transport()
+	}
+	return ""
+}
+
+// upstream returns the source behind the layers added by client.New.
+func upstream(c drand.Client) drand.Client {
+	for {
+		switch cc := c.(type) {
+		case *verifyingClient:
+			c = cc.Client
+		case *rateLimitedClient:
+			c = cc.Client
+		default:
+			return c
+		}
+	}
+}
+
+// observe records the outcome of a Get.
+func (s *upstreamStats) observe(rr *requestResult) {
+	if errors.Is(rr.err, drand.ErrEmptyClientUnsupportedGet) {
+		return
+	}
+	s.requests++
+	if rr.err != nil {
+		s.failures++
+		s.consecutive++
+		s.lastErr = rr.err
+		s.lastErrAt = rr.stat.startTime
+		return
+	}
+	s.consecutive = 0
+	s.served++
+	if len(s.latencies) < latencyWindow {
+		s.latencies = append(s.latencies, rr.stat.rtt)
+		return
+	}
+	s.latencies[s.next] = rr.stat.rtt
+	s.next = (s.next + 1) % latencyWindow
+}
+
+func (s *upstreamStats) snapshot() drand.UpstreamStats {
+	st := drand.UpstreamStats{
+		Upstream:        s.name,
+		Requests:        s.requests,
+		Failures:        s.failures,
+		RoundsServed:    s.served,
+		LastFailureTime: s.lastErrAt,
+		Circuit:         s.circuit,
+	}
+	if s.lastErr != nil {
+		st.LastFailure = s.lastErr.Error()
+	}
+	st.LatencyP50 = s.latency(50)
+	st.LatencyP95 = s.latency(95)
+	return st
+}
+
+// latency returns the given percentile of the latencies, 0 if there is none.
+func (s *upstreamStats) latency(percentile int) time.Duration {
+	if len(s.latencies) == 0 {
+		return 0
+	}
+	sorted := slices.Clone(s.latencies)
+	slices.Sort(sorted)
+	return sorted[(len(sorted)-1)*percentile/100]
+}
+
+// recordGet records the outcome of a Get made to an upstream.
+func (oc *optimizingClient) recordGet(rr *requestResult) {
+	oc.upstreamsLk.Lock()
+	defer oc.upstreamsLk.Unlock()
+	if s, ok := oc.upstreams[rr.client]; ok {
+		s.observe(rr)
+		oc.updateCircuit(s, rr)
+	}
+}
+
+// updateCircuit opens the circuit of an upstream after breakerThreshold
+// consecutive failures, or a failure while half-open, and closes it after a
+// success. It must be called with upstreamsLk held.
+func (oc *optimizingClient) updateCircuit(s *upstreamStats, rr *requestResult) {
+	if oc.breakerThreshold <= 0 || errors.Is(rr.err, drand.ErrEmptyClientUnsupportedGet) {
+		return
+	}
+	switch {
+	case rr.err == nil:
+		oc.setCircuit(s, drand.CircuitClosed)
+	case s.circuit == drand.CircuitHalfOpen || s.consecutive >= oc.breakerThreshold:
+		s.openUntil = oc.clock.Now().Add(oc.breakerCooldown)
+		oc.setCircuit(s, drand.CircuitOpen)
+	}
+}
+
+func (oc *optimizingClient) setCircuit(s *upstreamStats, state drand.CircuitState) {
+	if s.circuit == state {
+		return
+	}
+	s.circuit = state
+	switch state {
+	case drand.CircuitOpen:
+		oc.log.Warnw("", "optimizing_client", "skipping failing upstream", "upstream", s.name,
+			"failures", s.consecutive, "until", s.openUntil)
+		metrics.ClientUpstreamCircuit.WithLabelValues(s.name).Set(2)
+	case drand.CircuitHalfOpen:
+		oc.log.Infow("", "optimizing_client", "retrying upstream", "upstream", s.name)
+		metrics.ClientUpstreamCircuit.WithLabelValues(s.name).Set(1)
+	default:
+		oc.log.Infow("", "optimizing_client", "upstream recovered", "upstream", s.name)
+		metrics.ClientUpstreamCircuit.WithLabelValues(s.name).Set(0)
+	}
+}
+
+// availableClients filters out the clients whose circuit is open, unless all
+// of them are. The circuits at the end of their cool-down period become
+// half-open.
+func (oc *optimizingClient) availableClients(clients []drand.Client) []drand.Client {
+	if oc.breakerThreshold <= 0 {
+		return clients
+	}
+	oc.upstreamsLk.Lock()
+	defer oc.upstreamsLk.Unlock()
+	now := oc.clock.Now()
+	available := make([]drand.Client, 0, len(clients))
+	for _, c := range clients {
+		if s, ok := oc.upstreams[c]; ok && s.circuit == drand.CircuitOpen {
+			if now.Before(s.openUntil) {
+				continue
+			}
+			oc.setCircuit(s, drand.CircuitHalfOpen)
+		}
+		available = append(available, c)
+	}
+	if len(available) == 0 {
+		return clients
+	}
+	return available
+}
+
+// hedgeDelayOf returns how long Get waits for c before asking the next
+// client, when hedging.
+func (oc *optimizingClient) hedgeDelayOf(c drand.Client) time.Duration {
+	if oc.hedgeDelay > 0 {
+		return oc.hedgeDelay
+	}
+	oc.upstreamsLk.Lock()
+	defer oc.upstreamsLk.Unlock()
+	if s, ok := oc.upstreams[c]; ok {
+		if d := s.latency(95); d > 0 {
+			return d
+		}
+	}
+	return defaultHedgeDelay
+}
+
+// recordServed records a result received from an upstream by Watch.
+func (oc *optimizingClient) recordServed(c drand.Client) {
+	oc.upstreamsLk.Lock()
+	defer oc.upstreamsLk.Unlock()
+	if s, ok := oc.upstreams[c]; ok {
+		s.served++
+	}
+}
+
+// UpstreamStats returns the statistics of the Gets made to each upstream, in
+// the order the upstreams were given.
+func (oc *optimizingClient) UpstreamStats() []drand.UpstreamStats {
+	clients := oc.currentClients()
+	oc.upstreamsLk.Lock()
+	defer oc.upstreamsLk.Unlock()
+	stats := make([]drand.UpstreamStats, 0, len(clients))
+	for _, c := range clients {
+		// the client may have been removed meanwhile
+		if s, ok := oc.upstreams[c]; ok {
+			stats = append(stats, s.snapshot())
+		}
+	}
+	return stats
+}
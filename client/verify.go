@@ -4,12 +4,17 @@ import (
 	"context"
 	"fmt"
 	"sync"
+	"sync/atomic"
+	"time"
+
+	clock "github.com/jonboulle/clockwork"
 
 	"github.com/drand/drand/v2/common"
 	chain2 "github.com/drand/drand/v2/common/chain"
 	"github.com/drand/drand/v2/common/log"
 	"github.com/drand/drand/v2/crypto"
 	"github.com/drand/go-clients/drand"
+	"github.com/drand/kyber"
 )
 
 type verifyingClient struct {
@@ -24,19 +29,76 @@ type verifyingClient struct {
 	potLk        sync.Mutex
 	strict       bool
 
+	// catchUpConcurrency bounds how many rounds are fetched at once when
+	// walking the chain back to the point of trust.
+	catchUpConcurrency int
+	// catchUpProgress, if set, is notified after each verified catch-up batch.
+	catchUpProgress CatchUpProgressFunc
+
+	// clock and clockSkew bound the rounds accepted to the ones already produced.
+	clock     clock.Clock
+	clockSkew time.Duration
+
+	// refresher fetches the chain info currently served by the source, if it can.
+	refresher InfoRefresher
+	// requiredScheme is the scheme the refreshed chain info must use, if set.
+	requiredScheme string
+	// chainChanged is set while the source serves another chain than the trusted one.
+	chainChanged atomic.Pointer[ChainChangedError]
+
+	// audit, if set, records the outcome of the verification of each result.
+	audit *auditLog
+
+	// verifyConcurrency is the number of results verified in parallel by
+	// Watch and while catching up, 1 verifying them inline.
+	verifyConcurrency int
+
+	// upstreamTimeout bounds the Gets of the wrapped client, and
+	// verifyTimeout the verification of each result, if positive.
+	upstreamTimeout time.Duration
+	verifyTimeout   time.Duration
+
+	// source and transport name the source behind the layers added by
+	// client.New, computed once since formatting it races with its updates.
+	source    string
+	transport string
+
 	scheme *crypto.Scheme
-	log    log.Logger
+	// key is the public key of the chain the results are verified against.
+	key atomic.Pointer[verificationKey]
+	log log.Logger
 }
 
+const defaultCatchUpConcurrency = 8
+
+// DefaultClockSkew is how far ahead of the local clock the time of a round can
+// be by default before its beacons are rejected, see WithClockSkew.
+const DefaultClockSkew = 2 * time.Second
+
+// CatchUpProgressFunc is called while the verifying client catches up on a
+// chained scheme, with the last round verified so far and the round it is
+// catching up to.
+type CatchUpProgressFunc func(verified, target uint64)
+
+// catchUpKey marks contexts of Get calls issued while catching up, whose
+// results are chain-verified by the caller.
+type catchUpKey struct{}
+
 // newVerifyingClient wraps a client to perform `chain.Verify` on emitted results.
-func newVerifyingClient(c drand.Client, previousResult drand.Result, strict bool, sch *crypto.Scheme) drand.Client {
+func newVerifyingClient(c drand.Client, previousResult drand.Result, strict bool, sch *crypto.Scheme) *verifyingClient {
 	return &verifyingClient{
-		Client:         c,
-		indirectClient: c,
-		pointOfTrust:   previousResult,
-		strict:         strict,
-		scheme:         sch,
-		log:            log.DefaultLogger(),
+		Client:             c,
+		indirectClient:     c,
+		pointOfTrust:       previousResult,
+		strict:             strict,
+		catchUpConcurrency: defaultCatchUpConcurrency,
+		verifyConcurrency:  1,
+		clock:              clock.NewRealClock(),
+		clockSkew:          DefaultClockSkew,
+		source:             fmt.Sprint(upstream(c)),
+		transport:          upstreamTransport(c),
+		scheme:             sch,
+		log:                log.DefaultLogger(),
 	}
 }
 
@@ -47,17 +109,26 @@ func (v *verifyingClient) SetLog(l log.Logger) {
 
 // Get returns a requested round of randomness
 func (v *verifyingClient) Get(ctx context.Context, round uint64) (drand.Result, error) {
+	if cerr := v.chainChanged.Load(); cerr != nil {
+		return nil, cerr
+	}
 	info, err := v.indirectClient.Info(ctx)
 	if err != nil {
 		return nil, err
 	}
-	r, err := v.Client.Get(ctx, round)
+	r, err := v.getUpstream(ctx, round)
 	if err != nil {
 		return nil, err
 	}
 	rd := asRandomData(r)
-	if err := v.verify(ctx, info, rd); err != nil {
+	if callOptions(ctx).skipVerification {
+		v.log.Debugw("", "verifying_client", "skipping verification", "round", rd.GetRound())
+		v.recordAudit(info, rd, AuditSkipped, nil)
+	} else if err := v.verifyWithin(ctx, info, rd); err != nil {
+		v.recordAudit(info, rd, AuditFailed, err)
 		return nil, err
+	} else {
+		v.recordAudit(info, rd, AuditVerified, nil)
 	}
 	if round != 0 && rd.GetRound() != round {
 		return nil, fmt.Errorf("round mismatch (malicious relay): %d != %d", rd.GetRound(), round)
@@ -67,6 +138,23 @@ func (v *verifyingClient) Get(ctx context.Context, round uint64) (drand.Result,
 
 // Watch returns new randomness as it becomes available.
 func (v *verifyingClient) Watch(ctx context.Context) <-chan drand.Result {
+	return v.verifyWatch(ctx, v.Client.Watch)
+}
+
+// watchFrom verifies the rounds from round streamed by the source, if it is
+// a drand.ResumableWatcher, and returns false otherwise.
+func (v *verifyingClient) watchFrom(ctx context.Context, round uint64) (<-chan drand.Result, bool) {
+	rw, ok := upstream(v).(drand.ResumableWatcher)
+	if !ok {
+		return nil, false
+	}
+	return v.verifyWatch(ctx, func(ctx context.Context) <-chan drand.Result {
+		return rw.WatchFrom(ctx, round)
+	}), true
+}
+
+// verifyWatch verifies the results of the watch started by watch.
+func (v *verifyingClient) verifyWatch(ctx context.Context, watch func(context.Context) <-chan drand.Result) <-chan drand.Result {
 	outCh := make(chan drand.Result, 1)
 
 	info, err := v.indirectClient.Info(ctx)
@@ -76,32 +164,98 @@ func (v *verifyingClient) Watch(ctx context.Context) <-chan drand.Result {
 		return outCh
 	}
 
-	inCh := v.Client.Watch(ctx)
+	// rounds can only be skipped when they are not needed to verify the next ones
+	filter, _ := ctx.Value(roundFilterKey{}).(drand.RoundFilter)
+	if v.strict {
+		filter = nil
+	}
+
+	inCh := watch(ctx)
+	if v.verifyConcurrency > 1 && !v.strict {
+		go v.verifyPipelined(ctx, info, filter, inCh, outCh)
+		return outCh
+	}
 	go func() {
 		defer close(outCh)
 		for r := range inCh {
-			if err := v.verify(ctx, info, asRandomData(r)); err != nil {
+			if filter != nil && !filter(r.GetRound()) {
+				continue
+			}
+			rd := asRandomData(r)
+			if err := v.verifyWithin(ctx, info, rd); err != nil {
 				v.log.Errorw("failed signature verification, something nefarious could be going on!",
 					"round", r.GetRound(), "signature", r.GetSignature(), "err", err)
+				v.recordAudit(info, r, AuditFailed, err)
 				continue
 			}
-			outCh <- r
+			v.recordAudit(info, rd, AuditVerified, nil)
+			outCh <- rd
 		}
 	}()
 	return outCh
 }
 
+// verifyPipelined verifies the results of in with up to verifyConcurrency
+// workers, and sends the valid ones to out in the order they were received.
+// Results do not depend on each other without full chain verification, so
+// that they can be verified in any order.
+//
+//nolint:lll // This function has nicely named parameters, so it's long.
+func (v *verifyingClient) verifyPipelined(ctx context.Context, info *chain2.Info, filter drand.RoundFilter, in <-chan drand.Result, out chan drand.Result) {
+	type verification struct {
+		r    *RandomData
+		err  error
+		done chan struct{}
+	}
+	// the queue bounds the results being verified or waiting for the
+	// previous ones to be delivered
+	queue := make(chan *verification, v.verifyConcurrency)
+	go func() {
+		defer close(queue)
+		for r := range in {
+			if filter != nil && !filter(r.GetRound()) {
+				continue
+			}
+			p := &verification{r: asRandomData(r), done: make(chan struct{})}
+			queue <- p
+			go func() {
+				defer close(p.done)
+				p.err = v.verifyWithin(ctx, info, p.r)
+			}()
+		}
+	}()
+
+	defer close(out)
+	for p := range queue {
+		<-p.done
+		if p.err != nil {
+			v.log.Errorw("failed signature verification, something nefarious could be going on!",
+				"round", p.r.GetRound(), "signature", p.r.GetSignature(), "err", p.err)
+			v.recordAudit(info, p.r, AuditFailed, p.err)
+			continue
+		}
+		v.recordAudit(info, p.r, AuditVerified, nil)
+		out <- p.r
+	}
+}
+
 type resultWithPreviousSignature interface {
 	GetPreviousSignature() []byte
 }
 
+type resultWithBeaconID interface {
+	GetBeaconID() string
+}
+
 func asRandomData(r drand.Result) *RandomData {
-	rd, ok := r.(*RandomData)
-	if ok {
-		rd.Random = crypto.RandomnessFromSignature(rd.GetSignature())
-		return rd
+	if rd, ok := r.(*RandomData); ok {
+		// the result may be shared by the source, e.g. cached, so that it is
+		// copied before being annotated
+		cp := *rd
+		cp.Random = crypto.RandomnessFromSignature(rd.GetSignature())
+		return &cp
 	}
-	rd = &RandomData{
+	rd := &RandomData{
 		Rnd:    r.GetRound(),
 		Random: crypto.RandomnessFromSignature(r.GetSignature()),
 		Sig:    r.GetSignature(),
@@ -109,6 +263,9 @@ func asRandomData(r drand.Result) *RandomData {
 	if rp, ok := r.(resultWithPreviousSignature); ok {
 		rd.PreviousSignature = rp.GetPreviousSignature()
 	}
+	if rb, ok := r.(resultWithBeaconID); ok {
+		rd.BeaconID = rb.GetBeaconID()
+	}
 
 	return rd
 }
@@ -141,29 +298,34 @@ func (v *verifyingClient) getTrustedPreviousSignature(ctx context.Context, round
 		v.potLk.Unlock()
 	}
 	initialTrustRound := trustRound
+	target := round - 1
 
 	var next drand.Result
-	for trustRound < round-1 {
-		trustRound++
-		v.log.Warnw("", "verifying_client", "loading round to verify", "round", trustRound)
-		next, err = v.indirectClient.Get(ctx, trustRound)
+	for trustRound < target {
+		batch, err := v.fetchRounds(ctx, trustRound+1, min(target, trustRound+uint64(v.catchUpConcurrency)))
 		if err != nil {
-			return []byte{}, fmt.Errorf("could not get round %d: %w", trustRound, err)
+			return []byte{}, err
 		}
-		b := &common.Beacon{
-			PreviousSig: trustPrevSig,
-			Round:       trustRound,
-			Signature:   next.GetSignature(),
+		// each result chains on the signature of the previous one, which is
+		// known from the batch, so that they can be verified in parallel
+		beacons := make([]*common.Beacon, len(batch))
+		for i, next := range batch {
+			beacons[i] = &common.Beacon{
+				PreviousSig: trustPrevSig,
+				Round:       trustRound + uint64(i) + 1,
+				Signature:   next.GetSignature(),
+			}
+			trustPrevSig = next.GetSignature()
 		}
-
-		ipk := info.PublicKey.Clone()
-
-		err = v.scheme.VerifyBeacon(b, ipk)
-		if err != nil {
-			v.log.Warnw("", "verifying_client", "failed to verify value", "b", b, "err", err)
-			return []byte{}, fmt.Errorf("verifying beacon: %w", err)
+		if err := v.verifyBeacons(info, beacons); err != nil {
+			return []byte{}, err
+		}
+		trustRound += uint64(len(batch))
+		next = batch[len(batch)-1]
+		v.log.Infow("", "verifying_client", "caught up to round", "round", trustRound, "target", target)
+		if v.catchUpProgress != nil {
+			v.catchUpProgress(trustRound, target)
 		}
-		trustPrevSig = next.GetSignature()
 	}
 	if trustRound == round-1 && trustRound > initialTrustRound {
 		v.potLk.Lock()
@@ -177,8 +339,119 @@ func (v *verifyingClient) getTrustedPreviousSignature(ctx context.Context, round
 	return trustPrevSig, nil
 }
 
+// verifyBeacons verifies beacons with up to verifyConcurrency workers,
+// returning the error of the first invalid one.
+func (v *verifyingClient) verifyBeacons(info *chain2.Info, beacons []*common.Beacon) error {
+	errs := make([]error, len(beacons))
+	sem := make(chan struct{}, max(v.verifyConcurrency, 1))
+	var wg sync.WaitGroup
+	for i, b := range beacons {
+		sem <- struct{}{}
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			errs[i] = v.scheme.VerifyBeacon(b, v.publicKey(info))
+			<-sem
+		}()
+	}
+	wg.Wait()
+
+	for i, err := range errs {
+		if err != nil {
+			v.log.Warnw("", "verifying_client", "failed to verify value", "b", beacons[i], "err", err)
+			return fmt.Errorf("verifying beacon: %w", err)
+		}
+	}
+	return nil
+}
+
+// fetchRounds concurrently gets the rounds from `from` to `to` included, returning them in order.
+func (v *verifyingClient) fetchRounds(ctx context.Context, from, to uint64) ([]drand.Result, error) {
+	// the call options of the Get being verified do not apply to the rounds it depends on
+	ctx = context.WithValue(ctx, callConfigKey{}, &callConfig{})
+	ctx, cancel := context.WithCancel(context.WithValue(ctx, catchUpKey{}, true))
+	defer cancel()
+
+	results := make([]drand.Result, to-from+1)
+	errs := make([]error, len(results))
+	wg := sync.WaitGroup{}
+	for i := range results {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			rnd := from + uint64(i)
+			v.log.Debugw("", "verifying_client", "loading round to verify", "round", rnd)
+			r, err := v.indirectClient.Get(ctx, rnd)
+			switch {
+			case err != nil:
+				errs[i] = fmt.Errorf("could not get round %d: %w", rnd, err)
+				cancel()
+			case r.GetRound() != rnd:
+				errs[i] = fmt.Errorf("round mismatch (malicious relay): %d != %d", r.GetRound(), rnd)
+				cancel()
+			default:
+				results[i] = r
+			}
+		}(i)
+	}
+	wg.Wait()
+
+	for _, err := range errs {
+		if err != nil {
+			return nil, err
+		}
+	}
+	return results, nil
+}
+
+// getUpstream gets a round from the wrapped client, within the upstream timeout if any.
+func (v *verifyingClient) getUpstream(ctx context.Context, round uint64) (drand.Result, error) {
+	if v.upstreamTimeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, v.upstreamTimeout)
+		defer cancel()
+	}
+	return v.Client.Get(ctx, round)
+}
+
+// verifyWithin verifies r within the verify timeout, if any.
+func (v *verifyingClient) verifyWithin(ctx context.Context, info *chain2.Info, r *RandomData) error {
+	if v.verifyTimeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, v.verifyTimeout)
+		defer cancel()
+	}
+	received := v.clock.Now()
+	if err := v.verify(ctx, info, r); err != nil {
+		return err
+	}
+	timeOfRound := time.Unix(common.TimeOfRound(info.Period, info.GenesisTime, r.GetRound()), 0)
+	r.meta = &ResultMetadata{
+		Source:       v.source,
+		Transport:    v.transport,
+		Received:     received,
+		Latency:      received.Sub(timeOfRound),
+		Verification: v.clock.Since(received),
+	}
+	return nil
+}
+
 func (v *verifyingClient) verify(ctx context.Context, info *chain2.Info, r *RandomData) (err error) {
-	fetchPrevSignature := v.strict // only useful for chained schemes
+	if r.BeaconID != "" && !common.CompareBeaconIDs(r.BeaconID, info.ID) {
+		return fmt.Errorf("%w: round %d is from beacon %q instead of %q", drand.ErrBeaconIDMismatch, r.GetRound(), r.BeaconID, info.ID)
+	}
+
+	// a valid signature of a round that should not exist yet means the chain
+	// info, or the clock of the source, cannot be trusted
+	timeOfRound := time.Unix(common.TimeOfRound(info.Period, info.GenesisTime, r.GetRound()), 0)
+	if now := v.clock.Now(); timeOfRound.After(now.Add(v.clockSkew)) {
+		return fmt.Errorf("%w: round %d is due at %s, in %s", drand.ErrFutureRound, r.GetRound(),
+			timeOfRound.UTC().Format(time.RFC3339), timeOfRound.Sub(now).Truncate(time.Second))
+	}
+
+	// only useful for chained schemes. Rounds fetched while catching up are
+	// checked against the chain by the catch-up loop itself.
+	fetchPrevSignature := v.strict && ctx.Value(catchUpKey{}) == nil
 	ps := r.GetPreviousSignature()
 
 	if fetchPrevSignature {
@@ -194,9 +467,7 @@ func (v *verifyingClient) verify(ctx context.Context, info *chain2.Info, r *Rand
 		Signature:   r.GetSignature(),
 	}
 
-	ipk := info.PublicKey.Clone()
-
-	err = v.scheme.VerifyBeacon(b, ipk)
+	err = v.scheme.VerifyBeacon(b, v.publicKey(info))
 	if err != nil {
 		return fmt.Errorf("verification of %v failed: %w", b, err)
 	}
@@ -205,6 +476,67 @@ func (v *verifyingClient) verify(ctx context.Context, info *chain2.Info, r *Rand
 	return nil
 }
 
+// verificationKey is the public key of a chain, prepared once to verify all
+// of its beacons.
+type verificationKey struct {
+	info *chain2.Info
+	key  kyber.Point
+}
+
+// publicKey returns the public key of the chain described by info. It is only
+// cloned when the chain info changes, rather than for each verification: the
+// pairing checks copy the points they are given, so that a single key can be
+// shared by concurrent verifications.
+func (v *verifyingClient) publicKey(info *chain2.Info) kyber.Point {
+	if k := v.key.Load(); k != nil && k.info == info {
+		return k.key
+	}
+	k := &verificationKey{info: info, key: info.PublicKey.Clone()}
+	v.key.Store(k)
+	return k.key
+}
+
+// verifyCached checks a result found in a cache shared with other clients:
+// it must be a valid beacon of the trusted chain. Chained rounds are checked
+// against the previous signature they carry.
+func (v *verifyingClient) verifyCached(ctx context.Context, r drand.Result) error {
+	info, err := v.indirectClient.Info(ctx)
+	if err != nil {
+		return err
+	}
+	if rd, ok := r.(*RandomData); ok && rd.BeaconID != "" && !common.CompareBeaconIDs(rd.BeaconID, info.ID) {
+		return fmt.Errorf("%w: round %d is from beacon %q instead of %q", drand.ErrBeaconIDMismatch, r.GetRound(), rd.BeaconID, info.ID)
+	}
+	b := &common.Beacon{
+		Round:     r.GetRound(),
+		Signature: r.GetSignature(),
+	}
+	if rp, ok := r.(resultWithPreviousSignature); ok {
+		b.PreviousSig = rp.GetPreviousSignature()
+	}
+	if err := v.scheme.VerifyBeacon(b, v.publicKey(info)); err != nil {
+		return fmt.Errorf("verification of %v failed: %w", b, err)
+	}
+	return nil
+}
+
+// recordAudit records the outcome of the verification of r in the audit log, if any.
+func (v *verifyingClient) recordAudit(info *chain2.Info, r drand.Result, outcome string, err error) {
+	if v.audit != nil {
+		v.audit.record(info, v.source, r, outcome, err)
+	}
+}
+
+// checkpoint returns the round of the current point of trust, 0 if none.
+func (v *verifyingClient) checkpoint() uint64 {
+	v.potLk.Lock()
+	defer v.potLk.Unlock()
+	if v.pointOfTrust == nil {
+		return 0
+	}
+	return v.pointOfTrust.GetRound()
+}
+
 // String returns the name of this client.
 func (v *verifyingClient) String() string {
 	return fmt.Sprintf("%s.(+verifier)", v.Client)
@@ -0,0 +1,102 @@
+package client
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/drand/drand/v2/common"
+	"github.com/drand/drand/v2/common/log"
+	"github.com/drand/drand/v2/crypto"
+	clientMock "github.com/drand/go-clients/client/mock"
+	"github.com/drand/go-clients/client/test/result/mock"
+)
+
+func TestVerifyingClientPublicKey(t *testing.T) {
+	sch, err := crypto.GetSchemeFromEnv()
+	require.NoError(t, err)
+	info, _ := mock.VerifiableResults(1, sch)
+	other, _ := mock.VerifiableResults(1, sch)
+
+	v := newVerifyingClient(nil, nil, false, sch)
+	key := v.publicKey(info)
+	require.True(t, key.Equal(info.PublicKey))
+	require.NotSame(t, info.PublicKey, key)
+	require.Same(t, key, v.publicKey(info))
+
+	// the key follows the chain info
+	require.True(t, v.publicKey(other).Equal(other.PublicKey))
+}
+
+func BenchmarkVerify(b *testing.B) {
+	sch, err := crypto.GetSchemeFromEnv()
+	require.NoError(b, err)
+	info, results := mock.VerifiableResults(1, sch)
+	beacon := &common.Beacon{
+		PreviousSig: results[0].GetPreviousSignature(),
+		Round:       results[0].GetRound(),
+		Signature:   results[0].GetSignature(),
+	}
+
+	// the public key used to be cloned for each verification
+	b.Run("cloned key", func(b *testing.B) {
+		b.ReportAllocs()
+		for range b.N {
+			if err := sch.VerifyBeacon(beacon, info.PublicKey.Clone()); err != nil {
+				b.Fatal(err)
+			}
+		}
+	})
+	b.Run("cached key", func(b *testing.B) {
+		v := newVerifyingClient(nil, nil, false, sch)
+		b.ReportAllocs()
+		for range b.N {
+			if err := sch.VerifyBeacon(beacon, v.publicKey(info)); err != nil {
+				b.Fatal(err)
+			}
+		}
+	})
+	b.Run("client", func(b *testing.B) {
+		v := newVerifyingClient(nil, nil, false, sch)
+		ctx := context.Background()
+		b.ReportAllocs()
+		for range b.N {
+			if err := v.verify(ctx, info, asRandomData(&results[0])); err != nil {
+				b.Fatal(err)
+			}
+		}
+	})
+}
+
+func BenchmarkVerifyingClient(b *testing.B) {
+	sch, err := crypto.GetSchemeFromEnv()
+	require.NoError(b, err)
+	info, results := mock.VerifiableResults(16, sch)
+	upstream := &clientMock.Client{OptionalInfo: info, Results: results, StrictRounds: true}
+	v := newVerifyingClient(upstream, nil, false, sch)
+	v.SetLog(log.New(nil, log.ErrorLevel, false))
+	ctx := context.Background()
+
+	b.Run("get", func(b *testing.B) {
+		b.ReportAllocs()
+		for i := range b.N {
+			if _, err := v.Get(ctx, uint64(i%len(results))+1); err != nil {
+				b.Fatal(err)
+			}
+		}
+	})
+	b.Run("parallel get", func(b *testing.B) {
+		b.ReportAllocs()
+		b.RunParallel(func(pb *testing.PB) {
+			i := 0
+			for pb.Next() {
+				if _, err := v.Get(ctx, uint64(i%len(results))+1); err != nil {
+					b.Error(err)
+					return
+				}
+				i++
+			}
+		})
+	})
+}
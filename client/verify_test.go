@@ -4,7 +4,9 @@ import (
 	"context"
 	"fmt"
 	"testing"
+	"time"
 
+	clock "github.com/jonboulle/clockwork"
 	"github.com/stretchr/testify/require"
 
 	"github.com/drand/go-clients/drand"
@@ -69,3 +71,177 @@ func TestGetWithRoundMismatch(t *testing.T) {
 	_, err := c.Get(context.Background(), 3)
 	require.ErrorContains(t, err, "round mismatch (malicious relay): 1 != 3")
 }
+
+func TestVerifyCatchUpProgress(t *testing.T) {
+	sch, err := crypto.GetSchemeFromEnv()
+	require.NoError(t, err)
+
+	info, results := mock.VerifiableResults(20, sch)
+	mc := clientMock.Client{Results: results, StrictRounds: true, OptionalInfo: info}
+
+	var progress []uint64
+	c, err := client.Wrap(
+		[]drand.Client{&mc},
+		client.WithChainInfo(info),
+		client.WithTrustedResult(&results[0]),
+		client.WithFullChainVerification(),
+		client.WithCatchUpConcurrency(4),
+		client.WithVerificationConcurrency(4),
+		client.WithCatchUpProgress(func(verified, target uint64) {
+			require.Equal(t, results[18].GetRound(), target)
+			progress = append(progress, verified)
+		}),
+	)
+	require.NoError(t, err)
+
+	res, err := c.Get(context.Background(), results[19].GetRound())
+	require.NoError(t, err)
+	require.Equal(t, results[19].GetRound(), res.GetRound())
+	require.Equal(t, []uint64{5, 9, 13, 17, 19}, progress)
+}
+
+// beaconIDClient tags the results of the wrapped client with a beacon ID.
+type beaconIDClient struct {
+	drand.Client
+	id string
+}
+
+func (b *beaconIDClient) Get(ctx context.Context, round uint64) (drand.Result, error) {
+	r, err := b.Client.Get(ctx, round)
+	if err != nil {
+		return nil, err
+	}
+	return &client.RandomData{
+		Rnd:               r.GetRound(),
+		Sig:               r.GetSignature(),
+		PreviousSignature: r.GetPreviousSignature(),
+		BeaconID:          b.id,
+	}, nil
+}
+
+func TestVerifyBeaconIDMismatch(t *testing.T) {
+	ctx := context.Background()
+	sch, err := crypto.GetSchemeFromEnv()
+	require.NoError(t, err)
+	info, results := mock.VerifiableResults(2, sch)
+
+	for _, tc := range []struct {
+		id       string
+		expected error
+	}{
+		{"", nil},
+		{"default", nil},
+		{"quicknet", drand.ErrBeaconIDMismatch},
+	} {
+		mc := &clientMock.Client{Results: results, StrictRounds: true, OptionalInfo: info}
+		c, err := client.Wrap([]drand.Client{&beaconIDClient{mc, tc.id}}, client.WithChainInfo(info), client.WithCacheSize(0))
+		require.NoError(t, err)
+		_, err = c.Get(ctx, 2)
+		if tc.expected == nil {
+			require.NoError(t, err, tc.id)
+		} else {
+			require.ErrorIs(t, err, tc.expected, tc.id)
+		}
+		_ = c.Close()
+	}
+}
+
+func TestVerifyFutureRound(t *testing.T) {
+	ctx := context.Background()
+	sch, err := crypto.GetSchemeFromEnv()
+	require.NoError(t, err)
+	info, results := mock.VerifiableResults(3, sch)
+
+	// round 3 is due 1 second after the clock
+	clk := clock.NewFakeClockAt(time.Unix(info.GenesisTime+1, 0))
+	newClient := func(opts ...client.Option) drand.Client {
+		mc := &clientMock.Client{Results: results, StrictRounds: true, OptionalInfo: info}
+		c, err := client.New(append(opts, client.From(mc), client.WithChainInfo(info), client.WithClock(clk))...)
+		require.NoError(t, err)
+		t.Cleanup(func() { _ = c.Close() })
+		return c
+	}
+
+	c := newClient(client.WithClockSkew(0))
+	_, err = c.Get(ctx, 2)
+	require.NoError(t, err)
+	_, err = c.Get(ctx, 3)
+	require.ErrorIs(t, err, drand.ErrFutureRound)
+
+	c = newClient()
+	_, err = c.Get(ctx, 3)
+	require.NoError(t, err, "the default clock skew tolerates a second")
+
+	_, err = client.New(client.WithClockSkew(-time.Second))
+	require.Error(t, err)
+}
+
+func TestVerifyWatchConcurrency(t *testing.T) {
+	sch, err := crypto.GetSchemeFromEnv()
+	require.NoError(t, err)
+	info, results := mock.VerifiableResults(12, sch)
+
+	// the result of the 5th round is invalid, and must be dropped
+	invalid := results[4]
+	invalid.Sig = results[5].Sig
+	var expected []uint64
+	for i := range results {
+		if i != 4 {
+			expected = append(expected, results[i].GetRound())
+		}
+	}
+
+	watch := func(context.Context) <-chan drand.Result {
+		ch := make(chan drand.Result, len(results))
+		for i := range results {
+			if i == 4 {
+				ch <- &invalid
+				continue
+			}
+			ch <- &results[i]
+		}
+		close(ch)
+		return ch
+	}
+	c, err := client.Wrap(
+		[]drand.Client{&clientMock.Client{WatchF: watch, OptionalInfo: info}},
+		client.WithChainInfo(info),
+		client.WithVerificationConcurrency(4),
+	)
+	require.NoError(t, err)
+	defer c.Close()
+
+	var rounds []uint64
+	for r := range c.Watch(context.Background()) {
+		rounds = append(rounds, r.GetRound())
+	}
+	require.Equal(t, expected, rounds)
+
+	_, err = client.Wrap([]drand.Client{&clientMock.Client{}}, client.WithVerificationConcurrency(0))
+	require.Error(t, err)
+}
+
+func TestVerifyDoesNotAnnotateSourceResults(t *testing.T) {
+	sch, err := crypto.GetSchemeFromEnv()
+	require.NoError(t, err)
+	info, results := mock.VerifiableResults(1, sch)
+	// the source shares its result, as caching sources do
+	shared := &client.RandomData{Rnd: results[0].GetRound(), Sig: results[0].GetSignature(),
+		PreviousSignature: results[0].GetPreviousSignature()}
+	watch := make(chan drand.Result, 1)
+	watch <- shared
+	close(watch)
+
+	c, err := client.New(client.From(&clientMock.Client{OptionalInfo: info, WatchCh: watch}), client.WithChainInfo(info))
+	require.NoError(t, err)
+	defer c.Close()
+
+	r, ok := <-c.Watch(context.Background())
+	require.True(t, ok)
+	meta, ok := client.ResultMeta(r)
+	require.True(t, ok)
+	require.Equal(t, "Mock", meta.Source)
+	_, ok = client.ResultMeta(shared)
+	require.False(t, ok)
+	require.Nil(t, shared.Random)
+}
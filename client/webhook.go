@@ -0,0 +1,130 @@
+package client
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	nhttp "net/http"
+	"time"
+
+	"github.com/drand/drand/v2/common/log"
+	"github.com/drand/go-clients/drand"
+)
+
+const (
+	// WebhookSignatureHeader holds the hex encoded HMAC-SHA256 of the request
+	// body, keyed with the webhook secret, when one is configured.
+	WebhookSignatureHeader = "X-Drand-Signature"
+
+	defaultWebhookRetries = 3
+	defaultWebhookBackoff = time.Second
+	defaultWebhookTimeout = 10 * time.Second
+)
+
+// WebhookNotifier POSTs beacons as JSON, in the format of the drand HTTP API,
+// to a set of HTTP endpoints.
+type WebhookNotifier struct {
+	urls    []string
+	secret  []byte
+	client  *nhttp.Client
+	retries int
+	backoff time.Duration
+	log     log.Logger
+}
+
+// NewWebhookNotifier creates a notifier posting to the given URLs. If secret
+// is not empty, requests are signed with it, see WebhookSignatureHeader.
+func NewWebhookNotifier(l log.Logger, secret []byte, urls ...string) *WebhookNotifier {
+	if l == nil {
+		l = log.DefaultLogger()
+	}
+	return &WebhookNotifier{
+		urls:    urls,
+		secret:  secret,
+		client:  &nhttp.Client{Timeout: defaultWebhookTimeout},
+		retries: defaultWebhookRetries,
+		backoff: defaultWebhookBackoff,
+		log:     l,
+	}
+}
+
+// Sign computes the value of the WebhookSignatureHeader for a body.
+func (n *WebhookNotifier) Sign(body []byte) string {
+	mac := hmac.New(sha256.New, n.secret)
+	mac.Write(body)
+	return "sha256=" + hex.EncodeToString(mac.Sum(nil))
+}
+
+// Notify posts the result to all the endpoints, retrying failed deliveries
+// with an exponential backoff.
+func (n *WebhookNotifier) Notify(ctx context.Context, r drand.Result) error {
+	body, err := json.Marshal(asRandomData(r))
+	if err != nil {
+		return fmt.Errorf("encoding result: %w", err)
+	}
+
+	var errs error
+	for _, url := range n.urls {
+		if err := n.deliver(ctx, url, body); err != nil {
+			n.log.Warnw("", "webhook", "failed to deliver beacon", "url", url, "round", r.GetRound(), "err", err)
+			errs = errors.Join(errs, err)
+		}
+	}
+	return errs
+}
+
+func (n *WebhookNotifier) deliver(ctx context.Context, url string, body []byte) error {
+	backoff := n.backoff
+	var err error
+	for attempt := 0; attempt < n.retries; attempt++ {
+		if attempt > 0 {
+			t := time.NewTimer(backoff)
+			select {
+			case <-t.C:
+			case <-ctx.Done():
+				t.Stop()
+				return ctx.Err()
+			}
+			backoff *= 2
+		}
+
+		var retry bool
+		retry, err = n.post(ctx, url, body)
+		if err == nil || !retry {
+			return err
+		}
+	}
+	return err
+}
+
+// post sends the body once, reporting whether a failure is worth retrying.
+func (n *WebhookNotifier) post(ctx context.Context, url string, body []byte) (bool, error) {
+	req, err := nhttp.NewRequestWithContext(ctx, nhttp.MethodPost, url, bytes.NewReader(body))
+	if err != nil {
+		return false, fmt.Errorf("creating request: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+	if len(n.secret) > 0 {
+		req.Header.Set(WebhookSignatureHeader, n.Sign(body))
+	}
+
+	resp, err := n.client.Do(req)
+	if err != nil {
+		return true, fmt.Errorf("posting to %q: %w", url, err)
+	}
+	resp.Body.Close()
+
+	switch {
+	case resp.StatusCode >= 200 && resp.StatusCode < 300:
+		return false, nil
+	case resp.StatusCode >= 500 || resp.StatusCode == nhttp.StatusTooManyRequests:
+		return true, fmt.Errorf("got status %d posting to %q", resp.StatusCode, url)
+	default:
+		return false, fmt.Errorf("got status %d posting to %q", resp.StatusCode, url)
+	}
+}
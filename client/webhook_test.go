@@ -0,0 +1,127 @@
+package client
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"io"
+	nhttp "net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/drand/drand/v2/common/log"
+	clientMock "github.com/drand/go-clients/client/mock"
+	"github.com/drand/go-clients/client/test/result/mock"
+)
+
+func TestWebhookNotifier(t *testing.T) {
+	secret := []byte("secret")
+	var calls atomic.Int32
+	received := make(chan RandomData, 1)
+	srv := httptest.NewServer(nhttp.HandlerFunc(func(w nhttp.ResponseWriter, r *nhttp.Request) {
+		// fail the first delivery to exercise retries
+		if calls.Add(1) == 1 {
+			w.WriteHeader(nhttp.StatusServiceUnavailable)
+			return
+		}
+		body, err := io.ReadAll(r.Body)
+		require.NoError(t, err)
+
+		mac := hmac.New(sha256.New, secret)
+		mac.Write(body)
+		require.Equal(t, "sha256="+hex.EncodeToString(mac.Sum(nil)), r.Header.Get(WebhookSignatureHeader))
+
+		var rd RandomData
+		require.NoError(t, json.Unmarshal(body, &rd))
+		received <- rd
+	}))
+	defer srv.Close()
+
+	n := NewWebhookNotifier(log.New(nil, log.DebugLevel, true), secret, srv.URL)
+	n.backoff = time.Millisecond
+
+	res := mock.NewMockResult(3)
+	require.NoError(t, n.Notify(context.Background(), &res))
+	rd := <-received
+	require.Equal(t, uint64(3), rd.GetRound())
+	require.Equal(t, res.GetSignature(), rd.GetSignature())
+	require.Equal(t, int32(2), calls.Load())
+}
+
+func TestWebhookNotifierNoRetryOnClientError(t *testing.T) {
+	var calls atomic.Int32
+	srv := httptest.NewServer(nhttp.HandlerFunc(func(w nhttp.ResponseWriter, _ *nhttp.Request) {
+		calls.Add(1)
+		w.WriteHeader(nhttp.StatusBadRequest)
+	}))
+	defer srv.Close()
+
+	n := NewWebhookNotifier(log.New(nil, log.DebugLevel, true), nil, srv.URL)
+	n.backoff = time.Millisecond
+
+	res := mock.NewMockResult(3)
+	require.Error(t, n.Notify(context.Background(), &res))
+	require.Equal(t, int32(1), calls.Load())
+}
+
+func TestAggregatorWebhook(t *testing.T) {
+	received := make(chan uint64, 1)
+	srv := httptest.NewServer(nhttp.HandlerFunc(func(_ nhttp.ResponseWriter, r *nhttp.Request) {
+		var rd RandomData
+		require.NoError(t, json.NewDecoder(r.Body).Decode(&rd))
+		received <- rd.GetRound()
+	}))
+	defer srv.Close()
+
+	lg := log.New(nil, log.DebugLevel, true)
+	c := clientMock.ClientWithResults(1, 2)
+	ac := newWatchAggregator(lg, c, nil, false, 0)
+	ac.webhooks = []*WebhookNotifier{NewWebhookNotifier(lg, nil, srv.URL)}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	r := nextResult(t, ac.Watch(ctx))
+	require.Equal(t, uint64(1), r.GetRound())
+
+	select {
+	case round := <-received:
+		require.Equal(t, uint64(1), round)
+	case <-time.After(time.Second):
+		t.Fatal("webhook not notified")
+	}
+}
+
+func TestAggregatorWebhookStop(t *testing.T) {
+	delivering := make(chan struct{}, 1)
+	srv := httptest.NewServer(nhttp.HandlerFunc(func(_ nhttp.ResponseWriter, r *nhttp.Request) {
+		// the body is read so that the request is canceled with the connection
+		_, _ = io.Copy(io.Discard, r.Body)
+		select {
+		case delivering <- struct{}{}:
+		default:
+		}
+		<-r.Context().Done()
+	}))
+	defer srv.Close()
+
+	lg := log.New(nil, log.DebugLevel, true)
+	c := clientMock.ClientWithResults(1, 2)
+	ac := newWatchAggregator(lg, c, nil, false, 0)
+	ac.webhooks = []*WebhookNotifier{NewWebhookNotifier(lg, nil, srv.URL)}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	nextResult(t, ac.Watch(ctx))
+	<-delivering
+
+	// the delivery in progress is canceled, and its worker waited for
+	start := time.Now()
+	require.NoError(t, ac.Close())
+	require.Less(t, time.Since(start), time.Second)
+}
@@ -0,0 +1,93 @@
+package client
+
+import (
+	"math"
+	"slices"
+
+	"github.com/drand/go-clients/drand"
+)
+
+// WeightedClient is implemented by clients with a priority and a weight, such
+// as the HTTP clients of the relays listed in DNS SRV records. The clients
+// made with New prefer the working sources of lowest priority, as SRV clients
+// do, and rank the sources of a same priority by their latency divided by
+// their relative weight, so that a source twice as heavy as another one is
+// preferred unless it is more than twice as slow. Weight returns false when
+// the client has no priority and weight, in which case it ranks with the
+// sources of lowest priority and average weight.
+type WeightedClient interface {
+	Weight() (priority, weight uint16, ok bool)
+}
+
+// sourceRank is the rank of a source among the sources of a client, derived
+// from their priorities and weights.
+type sourceRank struct {
+	// tier is the index of the priority of the source among the distinct
+	// priorities of the sources.
+	tier int
+	// weight is the weight of the source relative to the average one.
+	weight float64
+}
+
+// sourceRanks are the ranks of the weighted sources of a client.
+type sourceRanks map[drand.Client]sourceRank
+
+// rankSources returns the rank of each weighted source of clients, the other
+// ones ranking in the first tier with an average weight.
+func rankSources(clients []drand.Client) sourceRanks {
+	type weighted struct {
+		c                drand.Client
+		priority, weight uint16
+	}
+	var ws []weighted
+	var priorities []uint16
+	total := 0.0
+	for _, c := range clients {
+		if wc, ok := upstream(c).(WeightedClient); ok {
+			if p, w, ok := wc.Weight(); ok {
+				ws = append(ws, weighted{c, p, w})
+				priorities = append(priorities, p)
+				total += float64(w)
+			}
+		}
+	}
+	if len(ws) == 0 {
+		return nil
+	}
+	slices.Sort(priorities)
+	priorities = slices.Compact(priorities)
+	// weights are smoothed by one, so that sources of weight 0 are merely
+	// the least preferred ones, as with SRV records
+	mean := total/float64(len(ws)) + 1
+	ranks := make(sourceRanks, len(ws))
+	for _, w := range ws {
+		tier, _ := slices.BinarySearch(priorities, w.priority)
+		ranks[w.c] = sourceRank{tier: tier, weight: (float64(w.weight) + 1) / mean}
+	}
+	return ranks
+}
+
+// less reports whether the source a, with stat sa, should be tried before the
+// source b, with stat sb: working sources before failed or passive ones, then
+// by tier, by latency divided by weight, and by weight.
+func (ranks sourceRanks) less(sa, sb *requestStat) bool {
+	failedA, failedB := sa.rtt == math.MaxInt64, sb.rtt == math.MaxInt64
+	if failedA != failedB {
+		return failedB
+	}
+	ra, ok := ranks[sa.client]
+	if !ok {
+		ra = sourceRank{weight: 1}
+	}
+	rb, ok := ranks[sb.client]
+	if !ok {
+		rb = sourceRank{weight: 1}
+	}
+	if ra.tier != rb.tier {
+		return ra.tier < rb.tier
+	}
+	if a, b := float64(sa.rtt)/ra.weight, float64(sb.rtt)/rb.weight; a != b {
+		return a < b
+	}
+	return ra.weight > rb.weight
+}
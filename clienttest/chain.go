@@ -0,0 +1,42 @@
+package clienttest
+
+import (
+	"io"
+	"time"
+
+	"github.com/drand/drand/v2/common/chain"
+	"github.com/drand/drand/v2/crypto"
+	"github.com/drand/go-clients/client/test/result/mock"
+	"github.com/drand/kyber/xof/blake2xb"
+)
+
+// GenesisTime is the genesis time of the chains made with NewChain, 2024-01-01
+// at midnight UTC.
+const GenesisTime int64 = 1704067200
+
+// NewChain returns the info of a chain of the scheme sch, and the results of
+// its first n rounds, all derived from seed: the same seed always yields the
+// same group key, genesis seed and beacons.
+//
+// The chain has a period of a second from GenesisTime. Its results remain
+// valid for another genesis time or period, which only change the hash of the
+// chain, e.g. to make the latest round current:
+//
+//	info.GenesisTime = time.Now().Unix() - int64(n)
+func NewChain(seed []byte, sch *crypto.Scheme, n int) (*chain.Info, []mock.Result) {
+	stream := blake2xb.New(seed)
+	secret := sch.KeyGroup.Scalar().Pick(stream)
+	previous := make([]byte, 32)
+	if _, err := io.ReadFull(stream, previous); err != nil {
+		panic(err)
+	}
+
+	info := &chain.Info{
+		PublicKey:   sch.KeyGroup.Point().Mul(secret, nil),
+		Period:      time.Second,
+		GenesisTime: GenesisTime,
+		GenesisSeed: previous,
+		Scheme:      sch.Name,
+	}
+	return info, mock.SignResults(sch, secret, previous, n)
+}
@@ -0,0 +1,358 @@
+/*
+Package clienttest provides a fake drand HTTP relay, to test drand integrations
+without standing up a real node.
+
+The relay serves a chain of valid beacons, generated for a fresh key, through
+the same routes as the drand HTTP API, and can be scripted to reveal rounds
+progressively, respond slowly, fail or serve invalid signatures, e.g.
+
+	relay := clienttest.NewRelay(t, clienttest.WithRounds(5), clienttest.WithBadSignatures(3))
+	hc, _ := http.NewWithInfo(nil, relay.URL(), relay.Info(), nil)
+	c, _ := client.New(client.From(hc), client.WithChainInfo(relay.Info()))
+
+NewChain generates a chain of verifiable beacons deterministically from a seed
+instead, so that test fixtures such as golden files, or the fixtures shared
+with the tests of clients in other languages, are reproducible:
+
+	info, results := clienttest.NewChain([]byte("fixture"), crypto.NewPedersenBLSChained(), 10)
+*/
+package clienttest
+
+import (
+	"encoding/hex"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/drand/drand/v2/common"
+	"github.com/drand/drand/v2/common/chain"
+	"github.com/drand/drand/v2/crypto"
+	"github.com/drand/go-clients/client"
+	"github.com/drand/go-clients/client/test/result/mock"
+	"github.com/drand/go-clients/drand"
+)
+
+const defaultRounds = 10
+
+type config struct {
+	scheme   *crypto.Scheme
+	beaconID string
+	rounds   int
+	latency  time.Duration
+	failing  []uint64
+	bad      []uint64
+	noV2     bool
+}
+
+// Option configures a Relay.
+type Option func(cfg *config)
+
+// WithScheme sets the signature scheme of the chain served by the relay. It
+// defaults to the drand default (chained) scheme.
+func WithScheme(sch *crypto.Scheme) Option {
+	return func(cfg *config) {
+		cfg.scheme = sch
+	}
+}
+
+// WithBeaconID sets the ID of the beacon whose chain is served by the relay.
+// It defaults to the default beacon.
+func WithBeaconID(id string) Option {
+	return func(cfg *config) {
+		cfg.beaconID = id
+	}
+}
+
+// WithRounds sets the number of rounds of the chain, all of which are
+// initially published. It defaults to 10.
+func WithRounds(n int) Option {
+	return func(cfg *config) {
+		cfg.rounds = n
+	}
+}
+
+// WithoutV2 makes the relay only serve the v1 HTTP API, like older relays.
+func WithoutV2() Option {
+	return func(cfg *config) {
+		cfg.noV2 = true
+	}
+}
+
+// WithLatency delays every response of the relay.
+func WithLatency(d time.Duration) Option {
+	return func(cfg *config) {
+		cfg.latency = d
+	}
+}
+
+// WithFailingRounds makes requests for the given rounds fail with an internal server error.
+func WithFailingRounds(rounds ...uint64) Option {
+	return func(cfg *config) {
+		cfg.failing = append(cfg.failing, rounds...)
+	}
+}
+
+// WithBadSignatures makes the relay serve invalid signatures for the given rounds.
+func WithBadSignatures(rounds ...uint64) Option {
+	return func(cfg *config) {
+		cfg.bad = append(cfg.bad, rounds...)
+	}
+}
+
+// Relay is a fake drand HTTP relay backed by an httptest.Server.
+type Relay struct {
+	server  *httptest.Server
+	info    *chain.Info
+	results []mock.Result
+	noV2    bool
+
+	lk      sync.Mutex
+	latest  uint64
+	latency time.Duration
+	down    bool
+	failing map[uint64]bool
+	bad     map[uint64]bool
+}
+
+// NewRelay starts a fake relay, which is closed at the end of the test.
+func NewRelay(tb testing.TB, opts ...Option) *Relay {
+	tb.Helper()
+
+	cfg := config{rounds: defaultRounds}
+	for _, opt := range opts {
+		opt(&cfg)
+	}
+	if cfg.rounds < 1 {
+		tb.Fatalf("clienttest: invalid number of rounds %d", cfg.rounds)
+	}
+	if cfg.scheme == nil {
+		sch, err := crypto.GetSchemeByID(crypto.DefaultSchemeID)
+		if err != nil {
+			tb.Fatalf("clienttest: loading default scheme: %v", err)
+		}
+		cfg.scheme = sch
+	}
+
+	info, results := mock.VerifiableResults(cfg.rounds, cfg.scheme)
+	if cfg.beaconID != "" {
+		info.ID = cfg.beaconID
+	}
+	r := &Relay{
+		info:    info,
+		results: results,
+		noV2:    cfg.noV2,
+		latest:  uint64(cfg.rounds),
+		latency: cfg.latency,
+		failing: make(map[uint64]bool),
+		bad:     make(map[uint64]bool),
+	}
+	for _, round := range cfg.failing {
+		r.failing[round] = true
+	}
+	for _, round := range cfg.bad {
+		r.bad[round] = true
+	}
+
+	r.server = httptest.NewServer(r)
+	tb.Cleanup(r.Close)
+	return r
+}
+
+// URL returns the root URL of the relay.
+func (r *Relay) URL() string {
+	return r.server.URL
+}
+
+// Info returns the chain info of the relay, to use as a root of trust.
+func (r *Relay) Info() *chain.Info {
+	return r.info
+}
+
+// Result returns the valid beacon of a round of the chain, or nil if the
+// round is not part of it.
+func (r *Relay) Result(round uint64) drand.Result {
+	if round == 0 || round > uint64(len(r.results)) {
+		return nil
+	}
+	return &r.results[round-1]
+}
+
+// SetLatest sets the latest published round: later rounds are not found, as
+// if they had not been produced yet.
+func (r *Relay) SetLatest(round uint64) {
+	r.lk.Lock()
+	defer r.lk.Unlock()
+	r.latest = min(round, uint64(len(r.results)))
+}
+
+// SetLatency delays every subsequent response of the relay.
+func (r *Relay) SetLatency(d time.Duration) {
+	r.lk.Lock()
+	defer r.lk.Unlock()
+	r.latency = d
+}
+
+// SetDown makes every request fail with a service unavailable error while down is true.
+func (r *Relay) SetDown(down bool) {
+	r.lk.Lock()
+	defer r.lk.Unlock()
+	r.down = down
+}
+
+// Close shuts the relay down.
+func (r *Relay) Close() {
+	r.server.Close()
+}
+
+// ServeHTTP serves the info, health and beacon routes of the drand HTTP API,
+// with or without the chain hash prefix, and the equivalent v2 API routes
+// unless the relay was created WithoutV2.
+func (r *Relay) ServeHTTP(w http.ResponseWriter, req *http.Request) {
+	r.lk.Lock()
+	latency, down, latest := r.latency, r.down, r.latest
+	r.lk.Unlock()
+
+	if latency > 0 {
+		t := time.NewTimer(latency)
+		select {
+		case <-t.C:
+		case <-req.Context().Done():
+			t.Stop()
+			return
+		}
+	}
+	if down {
+		http.Error(w, "relay is down", http.StatusServiceUnavailable)
+		return
+	}
+
+	parts := strings.Split(strings.Trim(req.URL.Path, "/"), "/")
+	if parts[0] == "v2" && !r.noV2 {
+		r.serveV2(w, req, parts[1:], latest)
+		return
+	}
+	if parts[0] == r.info.HashString() {
+		parts = parts[1:]
+	}
+	switch {
+	case len(parts) == 1 && parts[0] == "info":
+		w.Header().Set("Content-Type", "application/json")
+		_ = r.info.ToJSON(w, nil)
+	case len(parts) == 1 && parts[0] == "chains":
+		writeJSON(w, []string{r.info.HashString()})
+	case len(parts) == 1 && parts[0] == "health":
+		writeJSON(w, map[string]uint64{"current": latest, "expected": latest})
+	case len(parts) == 2 && parts[0] == "public":
+		r.serveBeacon(w, req, parts[1], latest, false)
+	default:
+		http.NotFound(w, req)
+	}
+}
+
+// serveV2 serves the routes of the v2 API, under /v2/chains/<hash> and /v2/beacons/<id>.
+func (r *Relay) serveV2(w http.ResponseWriter, req *http.Request, parts []string, latest uint64) {
+	switch {
+	case len(parts) == 1 && parts[0] == "chains":
+		writeJSON(w, []string{r.info.HashString()})
+		return
+	case len(parts) == 1 && parts[0] == "beacons":
+		writeJSON(w, []string{common.GetCanonicalBeaconID(r.info.ID)})
+		return
+	case len(parts) < 3:
+		http.NotFound(w, req)
+		return
+	}
+
+	switch parts[0] {
+	case "chains":
+		if parts[1] != r.info.HashString() {
+			http.NotFound(w, req)
+			return
+		}
+	case "beacons":
+		if !common.CompareBeaconIDs(parts[1], r.info.ID) {
+			http.NotFound(w, req)
+			return
+		}
+	default:
+		http.NotFound(w, req)
+		return
+	}
+
+	switch {
+	case len(parts) == 3 && parts[2] == "info":
+		writeJSON(w, r.info)
+	case len(parts) == 3 && parts[2] == "health":
+		writeJSON(w, map[string]uint64{"current": latest, "expected": latest})
+	case len(parts) == 4 && parts[2] == "rounds":
+		r.serveBeacon(w, req, parts[3], latest, true)
+	default:
+		http.NotFound(w, req)
+	}
+}
+
+func (r *Relay) serveBeacon(w http.ResponseWriter, req *http.Request, param string, latest uint64, v2 bool) {
+	round := latest
+	if param != "latest" {
+		var err error
+		round, err = strconv.ParseUint(param, 10, 64)
+		if err != nil {
+			http.Error(w, "invalid round", http.StatusBadRequest)
+			return
+		}
+	}
+	if round == 0 || round > latest {
+		http.NotFound(w, req)
+		return
+	}
+
+	r.lk.Lock()
+	failing, bad := r.failing[round], r.bad[round]
+	r.lk.Unlock()
+	if failing {
+		http.Error(w, "scripted failure", http.StatusInternalServerError)
+		return
+	}
+
+	res := r.results[round-1]
+	rd := client.RandomData{
+		Rnd:               res.GetRound(),
+		Random:            res.GetRandomness(),
+		Sig:               res.GetSignature(),
+		PreviousSignature: res.GetPreviousSignature(),
+	}
+	if bad {
+		sig := make([]byte, len(rd.Sig))
+		copy(sig, rd.Sig)
+		sig[len(sig)-1] ^= 0xff
+		rd.Sig = sig
+		rd.Random = crypto.RandomnessFromSignature(sig)
+	}
+	if v2 {
+		// the v2 API does not serve the randomness, derived from the signature
+		writeJSON(w, &v2Beacon{
+			Round:             rd.Rnd,
+			Signature:         hex.EncodeToString(rd.Sig),
+			PreviousSignature: hex.EncodeToString(rd.PreviousSignature),
+		})
+		return
+	}
+	writeJSON(w, &rd)
+}
+
+// v2Beacon is the beacon format of the v2 API.
+type v2Beacon struct {
+	Round             uint64 `json:"round"`
+	Signature         string `json:"signature"`
+	PreviousSignature string `json:"previous_signature,omitempty"`
+}
+
+func writeJSON(w http.ResponseWriter, v any) {
+	w.Header().Set("Content-Type", "application/json")
+	_ = json.NewEncoder(w).Encode(v)
+}
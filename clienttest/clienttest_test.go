@@ -0,0 +1,118 @@
+package clienttest_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/drand/drand/v2/common"
+	"github.com/drand/drand/v2/crypto"
+	"github.com/drand/go-clients/client"
+	"github.com/drand/go-clients/client/http"
+	"github.com/drand/go-clients/clienttest"
+	"github.com/drand/go-clients/drand"
+)
+
+func newClient(t *testing.T, relay *clienttest.Relay) drand.Client {
+	t.Helper()
+	hc, err := http.NewWithInfo(nil, relay.URL(), relay.Info(), nil)
+	require.NoError(t, err)
+	c, err := client.New(client.From(hc), client.WithChainInfo(relay.Info()), client.WithCacheSize(0))
+	require.NoError(t, err)
+	t.Cleanup(func() { _ = c.Close() })
+	return c
+}
+
+func TestRelayServesVerifiableChain(t *testing.T) {
+	ctx := context.Background()
+	relay := clienttest.NewRelay(t, clienttest.WithRounds(5))
+	c := newClient(t, relay)
+
+	info, err := c.Info(ctx)
+	require.NoError(t, err)
+	require.True(t, info.Equal(relay.Info()))
+
+	r, err := c.Get(ctx, 0)
+	require.NoError(t, err)
+	require.Equal(t, uint64(5), r.GetRound())
+	require.Equal(t, relay.Result(5).GetRandomness(), r.GetRandomness())
+
+	r, err = c.Get(ctx, 2)
+	require.NoError(t, err)
+	require.Equal(t, relay.Result(2).GetSignature(), r.GetSignature())
+}
+
+func TestRelayScriptedRounds(t *testing.T) {
+	ctx := context.Background()
+	relay := clienttest.NewRelay(t, clienttest.WithRounds(5))
+	c := newClient(t, relay)
+
+	relay.SetLatest(3)
+	r, err := c.Get(ctx, 0)
+	require.NoError(t, err)
+	require.Equal(t, uint64(3), r.GetRound())
+
+	_, err = c.Get(ctx, 4)
+	require.Error(t, err)
+
+	relay.SetLatest(4)
+	r, err = c.Get(ctx, 4)
+	require.NoError(t, err)
+	require.Equal(t, uint64(4), r.GetRound())
+}
+
+func TestRelayFailures(t *testing.T) {
+	ctx := context.Background()
+	relay := clienttest.NewRelay(t,
+		clienttest.WithRounds(5),
+		clienttest.WithFailingRounds(2),
+		clienttest.WithBadSignatures(3),
+	)
+	c := newClient(t, relay)
+
+	_, err := c.Get(ctx, 2)
+	require.Error(t, err)
+
+	_, err = c.Get(ctx, 3)
+	require.ErrorContains(t, err, "verification")
+
+	relay.SetDown(true)
+	_, err = c.Get(ctx, 4)
+	require.Error(t, err)
+
+	relay.SetDown(false)
+	_, err = c.Get(ctx, 4)
+	require.NoError(t, err)
+}
+
+func TestRelayLatency(t *testing.T) {
+	relay := clienttest.NewRelay(t, clienttest.WithLatency(100*time.Millisecond))
+	c := newClient(t, relay)
+
+	start := time.Now()
+	_, err := c.Get(context.Background(), 1)
+	require.NoError(t, err)
+	require.GreaterOrEqual(t, time.Since(start), 100*time.Millisecond)
+}
+
+func TestNewChain(t *testing.T) {
+	for _, sch := range []*crypto.Scheme{crypto.NewPedersenBLSChained(), crypto.NewPedersenBLSUnchainedG1()} {
+		t.Run(sch.Name, func(t *testing.T) {
+			info, results := clienttest.NewChain([]byte("seed"), sch, 3)
+			require.Len(t, results, 3)
+			for _, r := range results {
+				beacon := &common.Beacon{PreviousSig: r.GetPreviousSignature(), Round: r.GetRound(), Signature: r.GetSignature()}
+				require.NoError(t, sch.VerifyBeacon(beacon, info.PublicKey))
+			}
+
+			// the same seed yields the same chain, another seed another one
+			again, same := clienttest.NewChain([]byte("seed"), sch, 3)
+			require.Equal(t, info.Hash(), again.Hash())
+			require.Equal(t, results, same)
+			other, _ := clienttest.NewChain([]byte("other"), sch, 3)
+			require.NotEqual(t, info.Hash(), other.Hash())
+		})
+	}
+}
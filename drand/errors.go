@@ -7,5 +7,26 @@ import (
 // ErrInvalidChainHash means there was an error or a mismatch with the chain hash
 var ErrInvalidChainHash = errors.New("incorrect chain hash")
 
+// ErrBeaconIDMismatch means a chain or a result belongs to another beacon than the expected one
+var ErrBeaconIDMismatch = errors.New("beacon ID mismatch")
+
+// ErrSchemeMismatch means a chain uses another signature scheme than the required one
+var ErrSchemeMismatch = errors.New("scheme mismatch")
+
+// ErrInconsistentChainInfo means the sources of a client advertise different chains
+var ErrInconsistentChainInfo = errors.New("inconsistent chain info")
+
+// ErrForkedRound means the sources of a client serve different beacons for the same round
+var ErrForkedRound = errors.New("forked round")
+
 // ErrEmptyClientUnsupportedGet means this client does not support Get
 var ErrEmptyClientUnsupportedGet = errors.New("unsupported method Get was used")
+
+// ErrStaleResult means a result was produced longer ago than required
+var ErrStaleResult = errors.New("stale result")
+
+// ErrFutureRound means a result is for a round which should not have been produced yet
+var ErrFutureRound = errors.New("round from the future")
+
+// ErrUnknownSource means a client has no source of the given name
+var ErrUnknownSource = errors.New("unknown source")
@@ -41,6 +41,155 @@ type Result interface {
 	GetSignature() []byte
 }
 
+// Status is a snapshot of the synchronization state of a client.
+type Status struct {
+	// LatestRound is the latest round known locally, 0 if none was fetched yet.
+	LatestRound uint64
+	// LastFetch is when a result was last fetched or received, the zero time if never.
+	LastFetch time.Time
+	// Checkpoint is the round of the result the client trusts to verify the
+	// chain from, 0 if it has none.
+	Checkpoint uint64
+}
+
+// StatusProvider is implemented by clients able to report their
+// synchronization state, such as the clients built by client.New.
+type StatusProvider interface {
+	Status() Status
+}
+
+// UpstreamStats are statistics on the requests made by a client to one of its
+// upstreams.
+type UpstreamStats struct {
+	// Upstream is the name of the upstream, e.g. the URL of a relay.
+	Upstream string
+	// Requests is the number of Gets made to the upstream.
+	Requests uint64
+	// Failures is the number of failed Gets.
+	Failures uint64
+	// RoundsServed is the number of results received from the upstream, by
+	// Get or Watch.
+	RoundsServed uint64
+	// LatencyP50 and LatencyP95 are the median and 95th percentile of the
+	// latency of the last successful Gets, 0 if there was none.
+	LatencyP50 time.Duration
+	LatencyP95 time.Duration
+	// LastFailure is the error of the last failed Get, empty if none failed.
+	LastFailure string
+	// LastFailureTime is when the last failed Get was made.
+	LastFailureTime time.Time
+	// Circuit is the state of the circuit breaker of the upstream.
+	Circuit CircuitState
+}
+
+// CircuitState is the state of the circuit breaker of an upstream.
+type CircuitState string
+
+const (
+	// CircuitClosed means the upstream is used normally.
+	CircuitClosed CircuitState = "closed"
+	// CircuitOpen means the upstream failed repeatedly and is skipped until
+	// the end of its cool-down period.
+	CircuitOpen CircuitState = "open"
+	// CircuitHalfOpen means the cool-down period of the upstream is over, and
+	// the outcome of the next request decides whether it is used again.
+	CircuitHalfOpen CircuitState = "half-open"
+)
+
+// SuccessRate returns the fraction of the Gets made to the upstream which
+// succeeded, 1 if none was made.
+func (s UpstreamStats) SuccessRate() float64 {
+	if s.Requests == 0 {
+		return 1
+	}
+	return float64(s.Requests-s.Failures) / float64(s.Requests)
+}
+
+// UpstreamReporter is implemented by clients collecting statistics on their
+// upstreams, such as the clients built by client.New.
+type UpstreamReporter interface {
+	// UpstreamStats returns the statistics of each upstream of the client.
+	UpstreamStats() []UpstreamStats
+}
+
+// SourceManager is implemented by clients whose sources can be added and
+// removed while they run, such as the clients built by client.New, e.g. to
+// follow a service discovery system.
+type SourceManager interface {
+	// AddSource adds a source to the client, and speed tests it right away.
+	AddSource(c Client) error
+	// RemoveSource removes the sources of the given name, as reported by
+	// UpstreamStats, and closes them. It returns ErrUnknownSource if the
+	// client has no such source.
+	RemoveSource(name string) error
+}
+
+// ForkChecker is implemented by clients able to check that their sources
+// serve the same beacon for a round, such as the clients built by client.New.
+type ForkChecker interface {
+	// CheckRound fetches a round from every source of the client, bypassing
+	// verification and caching, and returns how many sources served it. It
+	// fails with an error wrapping ErrForkedRound when the sources serve
+	// different signatures for the round.
+	CheckRound(ctx context.Context, round uint64) (int, error)
+}
+
+// Stopper is implemented by clients able to bound the time spent waiting for
+// their background goroutines to exit when stopped, such as the clients built
+// by client.New.
+type Stopper interface {
+	// Stop stops the client as Close does, but returns ctx.Err() if ctx is done
+	// before the background goroutines of the client have exited.
+	Stop(ctx context.Context) error
+}
+
+// ReadyWaiter is implemented by clients set up in the background, such as the
+// clients built by client.NewAsync.
+type ReadyWaiter interface {
+	// WaitReady waits for the client to be set up, and returns the error of
+	// its setup, or ctx.Err() if ctx is done first.
+	WaitReady(ctx context.Context) error
+}
+
+// ResumableWatcher is implemented by clients able to stream the rounds of the
+// chain from a given one, such as the gRPC clients, so that a watch can be
+// resumed after missing rounds without fetching them one by one.
+type ResumableWatcher interface {
+	// WatchFrom returns the rounds from round, the past ones first, then the
+	// new ones as they become available, as Watch does.
+	WatchFrom(ctx context.Context, round uint64) <-chan Result
+}
+
+// RoundTick marks the start of a round.
+type RoundTick struct {
+	// Round is the round starting.
+	Round uint64
+	// Time is when the round starts, according to the chain info.
+	Time time.Time
+}
+
+// Scheduler is implemented by clients able to tell when rounds start without
+// fetching them, such as the clients built by client.New.
+type Scheduler interface {
+	// NextRoundTime returns the first round starting after now, and its time.
+	NextRoundTime(now time.Time) (uint64, time.Time)
+	// Schedule returns a channel receiving a tick at the start of each round,
+	// closed once ctx is done or the client is closed.
+	Schedule(ctx context.Context) <-chan RoundTick
+}
+
+// RoundFilter selects rounds by their number.
+type RoundFilter func(round uint64) bool
+
+// FilteredWatcher is implemented by clients able to only watch some rounds,
+// skipping the others before verifying them, such as the clients built by
+// client.New.
+type FilteredWatcher interface {
+	// WatchFiltered returns new randomness as it becomes available, for the
+	// rounds selected by filter.
+	WatchFiltered(ctx context.Context, filter RoundFilter) <-chan Result
+}
+
 // LoggingClient sets the logger for use by clients that support it
 type LoggingClient interface {
 	SetLog(log.Logger)
@@ -0,0 +1,244 @@
+//go:build !nolibp2p
+
+package main
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+
+	"github.com/libp2p/go-libp2p/core/crypto"
+	"github.com/libp2p/go-libp2p/core/peer"
+	ma "github.com/multiformats/go-multiaddr"
+	manet "github.com/multiformats/go-multiaddr/net"
+	"github.com/urfave/cli/v2"
+
+	"github.com/drand/drand/v2/common/log"
+	"github.com/drand/go-clients/internal/lp2p"
+)
+
+var (
+	passphraseFileFlag = &cli.PathFlag{
+		Name:    "identity-passphrase-file",
+		Usage:   "path to a file holding the passphrase the identity file is encrypted with (optional)",
+		EnvVars: []string{"DRAND_GOSSIP_IDENTITY_PASSPHRASE_FILE"},
+	}
+	keyTypeFlag = &cli.StringFlag{
+		Name:  "key-type",
+		Usage: fmt.Sprintf("type of the generated identity, %s or %s", lp2p.KeyTypeEd25519, lp2p.KeyTypeSecp256k1),
+		Value: lp2p.KeyTypeEd25519,
+	}
+	forceFlag = &cli.BoolFlag{
+		Name:  "force",
+		Usage: "overwrite an existing identity file",
+	}
+	exportOutFlag = &cli.PathFlag{
+		Name:  "out",
+		Usage: "file to export the identity to, instead of the standard output",
+	}
+	importInFlag = &cli.PathFlag{
+		Name:  "in",
+		Usage: "file to import the identity from, instead of the standard input",
+	}
+	addrsListenFlag = &cli.StringSliceFlag{
+		Name:  "listen",
+		Usage: "listening address(es) for libp2p, as given to the run command",
+		Value: cli.NewStringSlice("/ip4/0.0.0.0/tcp/44544"),
+	}
+)
+
+var identityCmd = &cli.Command{
+	Name:  "identity",
+	Usage: "manages the libp2p identity of the relay",
+	Subcommands: []*cli.Command{
+		{
+			Name:   "peerid",
+			Usage:  "prints the libp2p peer ID or creates one if it does not exist",
+			Flags:  []cli.Flag{idFlag, passphraseFileFlag},
+			Action: peerIDAction,
+		},
+		{
+			Name:   "generate",
+			Usage:  "generates a new identity and prints its peer ID",
+			Flags:  []cli.Flag{idFlag, passphraseFileFlag, keyTypeFlag, forceFlag},
+			Action: generateIdentityAction,
+		},
+		{
+			Name:   "export",
+			Usage:  "exports the identity in the base64 encoded libp2p protobuf format",
+			Flags:  []cli.Flag{idFlag, passphraseFileFlag, exportOutFlag},
+			Action: exportIdentityAction,
+		},
+		{
+			Name:   "import",
+			Usage:  "imports an identity exported in the base64 encoded libp2p protobuf format",
+			Flags:  []cli.Flag{idFlag, passphraseFileFlag, importInFlag, forceFlag},
+			Action: importIdentityAction,
+		},
+		{
+			Name:   "addrs",
+			Usage:  "prints the multiaddrs peers can connect to the relay on, given its listening addresses",
+			Flags:  []cli.Flag{idFlag, passphraseFileFlag, addrsListenFlag},
+			Action: identityAddrsAction,
+		},
+	},
+}
+
+// idCmd is the former name of "identity peerid", kept for compatibility.
+var idCmd = &cli.Command{
+	Name:   "peerid",
+	Usage:  "prints the libp2p peer ID or creates one if it does not exist (deprecated, use identity peerid)",
+	Hidden: true,
+	Flags:  []cli.Flag{idFlag, passphraseFileFlag},
+	Action: peerIDAction,
+}
+
+// readPassphrase returns the passphrase given with passphraseFileFlag, if any.
+func readPassphrase(cctx *cli.Context) ([]byte, error) {
+	p := cctx.Path(passphraseFileFlag.Name)
+	if p == "" {
+		return nil, nil
+	}
+	b, err := os.ReadFile(p)
+	if err != nil {
+		return nil, fmt.Errorf("reading passphrase: %w", err)
+	}
+	b = bytes.TrimRight(b, "\r\n")
+	if len(b) == 0 {
+		return nil, fmt.Errorf("passphrase file %s is empty", p)
+	}
+	return b, nil
+}
+
+// loadIdentity loads the identity given with idFlag, creating it if needed.
+func loadIdentity(cctx *cli.Context, lg log.Logger) (crypto.PrivKey, error) {
+	passphrase, err := readPassphrase(cctx)
+	if err != nil {
+		return nil, err
+	}
+	priv, err := lp2p.LoadOrCreateEncryptedPrivKey(cctx.String(idFlag.Name), passphrase, lg)
+	if err != nil {
+		return nil, fmt.Errorf("loading p2p key: %w", err)
+	}
+	return priv, nil
+}
+
+// writeIdentity writes priv to the identity file given with idFlag, unless
+// it exists and forceFlag is not set, and prints its peer ID.
+func writeIdentity(cctx *cli.Context, priv crypto.PrivKey) error {
+	identityPath := cctx.String(idFlag.Name)
+	if _, err := os.Stat(identityPath); err == nil && !cctx.Bool(forceFlag.Name) {
+		return fmt.Errorf("identity file %s already exists, use --%s to overwrite it", identityPath, forceFlag.Name)
+	} else if err != nil && !errors.Is(err, os.ErrNotExist) {
+		return err
+	}
+	passphrase, err := readPassphrase(cctx)
+	if err != nil {
+		return err
+	}
+	if err := lp2p.WritePrivKey(identityPath, priv, passphrase); err != nil {
+		return err
+	}
+	return printPeerID(cctx, priv)
+}
+
+func printPeerID(cctx *cli.Context, priv crypto.PrivKey) error {
+	peerID, err := peer.IDFromPrivateKey(priv)
+	if err != nil {
+		return fmt.Errorf("computing peerid: %w", err)
+	}
+	fmt.Fprintf(cctx.App.Writer, "%s\n", peerID)
+	return nil
+}
+
+func peerIDAction(cctx *cli.Context) error {
+	priv, err := loadIdentity(cctx, log.New(nil, log.DefaultLevel, false))
+	if err != nil {
+		return err
+	}
+	return printPeerID(cctx, priv)
+}
+
+func generateIdentityAction(cctx *cli.Context) error {
+	priv, err := lp2p.GeneratePrivKey(cctx.String(keyTypeFlag.Name))
+	if err != nil {
+		return err
+	}
+	return writeIdentity(cctx, priv)
+}
+
+func exportIdentityAction(cctx *cli.Context) error {
+	passphrase, err := readPassphrase(cctx)
+	if err != nil {
+		return err
+	}
+	priv, err := lp2p.ReadPrivKey(cctx.String(idFlag.Name), passphrase)
+	if err != nil {
+		return fmt.Errorf("loading p2p key: %w", err)
+	}
+	exported, err := lp2p.ExportPrivKey(priv)
+	if err != nil {
+		return err
+	}
+	if out := cctx.Path(exportOutFlag.Name); out != "" {
+		return os.WriteFile(out, []byte(exported+"\n"), 0o600)
+	}
+	_, err = fmt.Fprintln(cctx.App.Writer, exported)
+	return err
+}
+
+func importIdentityAction(cctx *cli.Context) error {
+	var in io.Reader = os.Stdin
+	if p := cctx.Path(importInFlag.Name); p != "" {
+		f, err := os.Open(p)
+		if err != nil {
+			return err
+		}
+		defer f.Close()
+		in = f
+	}
+	exported, err := io.ReadAll(in)
+	if err != nil {
+		return fmt.Errorf("reading identity: %w", err)
+	}
+	priv, err := lp2p.ImportPrivKey(string(exported))
+	if err != nil {
+		return err
+	}
+	return writeIdentity(cctx, priv)
+}
+
+func identityAddrsAction(cctx *cli.Context) error {
+	priv, err := loadIdentity(cctx, log.New(nil, log.DefaultLevel, false))
+	if err != nil {
+		return err
+	}
+	peerID, err := peer.IDFromPrivateKey(priv)
+	if err != nil {
+		return fmt.Errorf("computing peerid: %w", err)
+	}
+	listen, err := lp2p.ParseMultiaddrSlice(cctx.StringSlice(addrsListenFlag.Name))
+	if err != nil {
+		return fmt.Errorf("parsing listening addresses: %w", err)
+	}
+	ifaceAddrs, err := manet.InterfaceMultiaddrs()
+	if err != nil {
+		return fmt.Errorf("listing interface addresses: %w", err)
+	}
+	p2p, err := ma.NewComponent("p2p", peerID.String())
+	if err != nil {
+		return err
+	}
+	for _, l := range listen {
+		addrs, err := manet.ResolveUnspecifiedAddress(l, ifaceAddrs)
+		if err != nil {
+			return fmt.Errorf("resolving %s: %w", l, err)
+		}
+		for _, a := range addrs {
+			fmt.Fprintln(cctx.App.Writer, a.Encapsulate(p2p))
+		}
+	}
+	return nil
+}
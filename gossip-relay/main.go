@@ -1,17 +1,38 @@
+//go:build !nolibp2p
+
 package main
 
 import (
+	"context"
+	"crypto/tls"
 	"encoding/hex"
+	"errors"
 	"fmt"
+	"net"
+	"net/url"
 	"os"
-	"path"
+	"strings"
+	"sync"
 
-	"github.com/libp2p/go-libp2p/core/peer"
+	grpcProm "github.com/grpc-ecosystem/go-grpc-prometheus"
+	"github.com/libp2p/go-libp2p/core/pnet"
 	"github.com/urfave/cli/v2"
+	"google.golang.org/grpc"
 
 	"github.com/drand/drand/v2/common/log"
+	proto "github.com/drand/drand/v2/protobuf/drand"
+	"github.com/drand/go-clients/archive/ipfs"
+	"github.com/drand/go-clients/bridge"
+	mqttbridge "github.com/drand/go-clients/bridge/mqtt"
+	natsbridge "github.com/drand/go-clients/bridge/nats"
+	"github.com/drand/go-clients/client"
+	"github.com/drand/go-clients/client/objstore"
+	"github.com/drand/go-clients/drand"
+	"github.com/drand/go-clients/grpcserve"
 	"github.com/drand/go-clients/internal/lib"
 	"github.com/drand/go-clients/internal/lp2p"
+	"github.com/drand/go-clients/internal/metrics"
+	"github.com/drand/go-clients/relay"
 )
 
 // Automatically set through -ldflags
@@ -26,7 +47,7 @@ func main() {
 		Name:     "drand-relay-gossip-relay",
 		Version:  "2.0.0",
 		Usage:    "pubsub relay for drand randomness beacon",
-		Commands: []*cli.Command{runCmd, clientCmd, idCmd},
+		Commands: []*cli.Command{runCmd, clientCmd, identityCmd, idCmd},
 	}
 
 	// See https://cli.urfave.org/v2/examples/bash-completions/#enabling for how to turn on.
@@ -55,9 +76,14 @@ var (
 		Usage:   "peer multiaddr(s) for the relay to direct connect with",
 		EnvVars: []string{"DRAND_GOSSIP_PEER_WITH"},
 	}
+	peerDNSFlag = &cli.StringFlag{
+		Name:    "peer-dns",
+		Usage:   "domain name whose TXT records list peer multiaddr(s) for the relay to connect with, looked up periodically",
+		EnvVars: []string{"DRAND_GOSSIP_PEER_DNS"},
+	}
 	storeFlag = &cli.StringFlag{
 		Name:    "store",
-		Usage:   "datastore directory",
+		Usage:   "unused: the relay keeps no datastore, see -mirror-bucket to persist beacons",
 		Value:   "./datastore",
 		EnvVars: []string{"DRAND_RELAY_STORE"},
 	}
@@ -67,11 +93,145 @@ var (
 		Value:   "/ip4/0.0.0.0/tcp/44544",
 		EnvVars: []string{"DRAND_RELAY_LISTEN"},
 	}
+	extraListenFlag = &cli.StringSliceFlag{
+		Name: "extra-listen",
+		Usage: "additional listening address(es) for libp2p, e.g. /ip4/0.0.0.0/udp/44544/quic-v1 for QUIC," +
+			" /ip4/0.0.0.0/tcp/44545/ws for WebSocket or /ip4/0.0.0.0/tcp/443/wss for secure WebSocket",
+		EnvVars: []string{"DRAND_RELAY_EXTRA_LISTEN"},
+	}
+	announceFlag = &cli.StringSliceFlag{
+		Name: "announce",
+		Usage: "address(es) advertised to peers instead of the listening ones," +
+			" e.g. /dns4/relay.example.org/tcp/443/wss behind a reverse proxy terminating TLS",
+		EnvVars: []string{"DRAND_RELAY_ANNOUNCE"},
+	}
+	announceAppendFlag = &cli.StringSliceFlag{
+		Name: "announce-append",
+		Usage: "address(es) advertised to peers in addition to the listening or announced ones," +
+			" e.g. the external IP or DNS multiaddr of a load balancer",
+		EnvVars: []string{"DRAND_RELAY_ANNOUNCE_APPEND"},
+	}
+	noAnnounceFlag = &cli.StringSliceFlag{
+		Name:    "no-announce",
+		Usage:   "IP range(s), e.g. 10.0.0.0/8, of the addresses not advertised to peers, such as a Kubernetes pod network",
+		EnvVars: []string{"DRAND_RELAY_NO_ANNOUNCE"},
+	}
+	wssCertFlag = &cli.PathFlag{
+		Name:    "wss-cert",
+		Usage:   "path to the PEM encoded TLS certificate served on /wss listening addresses",
+		EnvVars: []string{"DRAND_RELAY_WSS_CERT"},
+	}
+	wssKeyFlag = &cli.PathFlag{
+		Name:    "wss-key",
+		Usage:   "path to the PEM encoded TLS key of the certificate served on /wss listening addresses",
+		EnvVars: []string{"DRAND_RELAY_WSS_KEY"},
+	}
+	autoNATFlag = &cli.BoolFlag{
+		Name:    "autonat",
+		Usage:   "help peers find out whether they are reachable, for publicly reachable relays",
+		EnvVars: []string{"DRAND_RELAY_AUTONAT"},
+	}
+	relayServiceFlag = &cli.BoolFlag{
+		Name:    "relay-service",
+		Usage:   "run a libp2p circuit relay, so that peers behind NAT can be reached through this relay, if publicly reachable",
+		EnvVars: []string{"DRAND_RELAY_SERVICE"},
+	}
+	extraTopicFlag = &cli.StringSliceFlag{
+		Name: "extra-topic",
+		Usage: "pubsub topic(s) each beacon is published to in addition to the topic of its chain," +
+			" e.g. /drand/pubsub/v1.0.0/{hash} to migrate the mesh, {hash} being replaced by the chain hash",
+		EnvVars: []string{"DRAND_RELAY_EXTRA_TOPIC"},
+	}
+	dedupFlag = &cli.BoolFlag{
+		Name: "dedup",
+		Usage: "skip publishing the beacons another peer of the mesh published within the last period," +
+			" e.g. when several relays follow the chain from independent upstreams",
+		EnvVars: []string{"DRAND_RELAY_DEDUP"},
+	}
 	metricsFlag = &cli.StringFlag{
 		Name:    "metrics",
-		Usage:   "local host:port to bind a metrics servlet (optional)",
+		Usage:   "local host:port to bind a metrics servlet, also serving the /healthz and /readyz probes (optional)",
 		EnvVars: []string{"DRAND_RELAY_METRICS"},
 	}
+	webhookURLFlag = &cli.StringSliceFlag{
+		Name:    "webhook-url",
+		Usage:   "URL(s) to POST each new beacon to, as JSON (optional)",
+		EnvVars: []string{"DRAND_RELAY_WEBHOOK_URL"},
+	}
+	graylistThresholdFlag = &cli.Uint64Flag{
+		Name:    "graylist-threshold",
+		Usage:   "number of invalid messages after which a peer is graylisted for an hour",
+		Value:   10,
+		EnvVars: []string{"DRAND_RELAY_GRAYLIST_THRESHOLD"},
+	}
+	webhookSecretFlag = &cli.StringFlag{
+		Name:    "webhook-secret",
+		Usage:   "secret used to sign webhook requests with HMAC-SHA256 (optional)",
+		EnvVars: []string{"DRAND_RELAY_WEBHOOK_SECRET"},
+	}
+	grpcListenFlag = &cli.StringFlag{
+		Name: "grpc-listen",
+		Usage: "local host:port to serve the drand public gRPC API on, in plaintext, so that consumers of the cluster " +
+			"can use the gRPC transport against the relay rather than going upstream (optional)",
+		EnvVars: []string{"DRAND_RELAY_GRPC_LISTEN"},
+	}
+	mirrorBucketFlag = &cli.StringFlag{
+		Name: "mirror-bucket",
+		Usage: "S3-compatible URL, as https://endpoint/bucket[/prefix], to mirror each new beacon to (optional). " +
+			"Credentials and region are read from AWS_ACCESS_KEY_ID, AWS_SECRET_ACCESS_KEY, AWS_SESSION_TOKEN and AWS_REGION",
+		EnvVars: []string{"DRAND_RELAY_MIRROR_BUCKET"},
+	}
+	bridgeURLFlag = &cli.StringFlag{
+		Name: "bridge-url",
+		Usage: "message queue to publish each new beacon to (optional): a NATS server, as nats://[user:password@]host:port " +
+			"or tls://, or a Kafka REST Proxy, as kafka-rest+http(s)://[user:password@]host:port",
+		EnvVars: []string{"DRAND_RELAY_BRIDGE_URL"},
+	}
+	bridgeTopicFlag = &cli.StringFlag{
+		Name:    "bridge-topic",
+		Usage:   "NATS subject or Kafka topic to publish the beacons to, drand.<chain hash> by default",
+		EnvVars: []string{"DRAND_RELAY_BRIDGE_TOPIC"},
+	}
+	bridgeEncodingFlag = &cli.StringFlag{
+		Name: "bridge-encoding",
+		Usage: "encoding of the beacons published to the message queue and to the MQTT broker: json, as the HTTP API, " +
+			"or proto, as the gRPC API",
+		Value:   string(bridge.JSON),
+		EnvVars: []string{"DRAND_RELAY_BRIDGE_ENCODING"},
+	}
+	ipfsAPIFlag = &cli.StringFlag{
+		Name: "ipfs-api",
+		Usage: "URL of the RPC API of an IPFS node, e.g. http://127.0.0.1:5001, to add and pin each new beacon to, " +
+			"and a manifest of the beacons of each day, whose latest CID is reported on the /status endpoint of --metrics (optional)",
+		EnvVars: []string{"DRAND_RELAY_IPFS_API"},
+	}
+	ipfsStateFlag = &cli.StringFlag{
+		Name:    "ipfs-state",
+		Usage:   "file the reference of the latest IPFS manifest is saved to, and resumed from, to link the manifests across restarts (optional)",
+		EnvVars: []string{"DRAND_RELAY_IPFS_STATE"},
+	}
+	mqttBrokerFlag = &cli.StringFlag{
+		Name: "mqtt-broker",
+		Usage: "MQTT broker to publish each new beacon to, e.g. for IoT devices (optional), " +
+			"as mqtt://[user:password@]host:port or mqtts:// for TLS",
+		EnvVars: []string{"DRAND_RELAY_MQTT_BROKER"},
+	}
+	mqttTopicFlag = &cli.StringFlag{
+		Name:    "mqtt-topic",
+		Usage:   "MQTT topic to publish the beacons to, drand/<chain hash> by default",
+		EnvVars: []string{"DRAND_RELAY_MQTT_TOPIC"},
+	}
+	mqttQoSFlag = &cli.UintFlag{
+		Name:    "mqtt-qos",
+		Usage:   "MQTT quality of service of the beacons published: 0 (at most once), 1 (at least once) or 2 (exactly once)",
+		Value:   1,
+		EnvVars: []string{"DRAND_RELAY_MQTT_QOS"},
+	}
+	mqttRetainFlag = &cli.BoolFlag{
+		Name:    "mqtt-retain",
+		Usage:   "make the MQTT broker retain the latest beacon, sent to the devices as soon as they subscribe",
+		EnvVars: []string{"DRAND_RELAY_MQTT_RETAIN"},
+	}
 )
 
 var runCmd = &cli.Command{
@@ -79,12 +239,42 @@ var runCmd = &cli.Command{
 	Usage: "starts a drand gossip-relay relay process",
 	Flags: append(lib.ClientFlags, []cli.Flag{
 		idFlag,
+		passphraseFileFlag,
 		peerWithFlag,
+		peerDNSFlag,
 		storeFlag,
 		listenFlag,
+		extraListenFlag,
+		announceFlag,
+		announceAppendFlag,
+		noAnnounceFlag,
+		wssCertFlag,
+		wssKeyFlag,
+		autoNATFlag,
+		relayServiceFlag,
+		extraTopicFlag,
+		dedupFlag,
 		metricsFlag,
+		graylistThresholdFlag,
+		webhookURLFlag,
+		webhookSecretFlag,
+		mirrorBucketFlag,
+		ipfsAPIFlag,
+		ipfsStateFlag,
+		bridgeURLFlag,
+		bridgeTopicFlag,
+		bridgeEncodingFlag,
+		mqttBrokerFlag,
+		mqttTopicFlag,
+		mqttQoSFlag,
+		mqttRetainFlag,
+		grpcListenFlag,
 		lib.GRPCConnectFlag,
+		lib.GRPCBeaconIDFlag,
+		lib.GRPCMetadataFlag,
+		lib.LogLevelFlag,
 	}...),
+	Before: lib.LoadConfig,
 	Action: func(cctx *cli.Context) error {
 		if cctx.IsSet(lib.HashFlag.Name) || cctx.IsSet(lib.GroupConfFlag.Name) {
 			fmt.Printf("--%s and --%s are deprecated. Use --%s or --%s instead\n",
@@ -94,13 +284,28 @@ var runCmd = &cli.Command{
 				lib.GroupConfListFlag.Name)
 		}
 
+		lg, err := lib.NewLogger(cctx, log.DefaultLevel)
+		if err != nil {
+			return err
+		}
+		if cctx.IsSet(metricsFlag.Name) {
+			metrics.Start(lg.Named("metrics"), cctx.String(metricsFlag.Name), nil, nil)
+		}
+		var gs *grpcserve.Server
+		if cctx.IsSet(grpcListenFlag.Name) {
+			gs = grpcserve.New(grpcserve.WithLogger(lg.Named("grpc")))
+		}
+
 		switch {
 		case cctx.IsSet(lib.GroupConfListFlag.Name) && cctx.IsSet(lib.HashListFlag.Name):
 			return fmt.Errorf("only one of --%s and --%s are allowed", lib.GroupConfListFlag.Name, lib.HashListFlag.Name)
 		case cctx.IsSet(lib.GroupConfListFlag.Name):
-			groupConfs := cctx.StringSlice(lib.GroupConfListFlag.Name)
+			groupConfs, err := lib.GroupConfFiles(cctx.StringSlice(lib.GroupConfListFlag.Name))
+			if err != nil {
+				return err
+			}
 			for _, groupConf := range groupConfs {
-				err := boostrapGossipRelayNode(cctx, groupConf, "")
+				err := boostrapGossipRelayNode(cctx, lg, gs, groupConf, "")
 				if err != nil {
 					return err
 				}
@@ -112,7 +317,7 @@ var runCmd = &cli.Command{
 			}
 
 			for _, hash := range hashes {
-				err := boostrapGossipRelayNode(cctx, "", hash)
+				err := boostrapGossipRelayNode(cctx, lg, gs, "", hash)
 				if err != nil {
 					return err
 				}
@@ -123,12 +328,18 @@ var runCmd = &cli.Command{
 				return fmt.Errorf("decoding hash %q: %w", hash, err)
 			}
 
-			err := boostrapGossipRelayNode(cctx, "", hash)
+			err := boostrapGossipRelayNode(cctx, lg, gs, "", hash)
 			if err != nil {
 				return err
 			}
 		default:
-			if err := boostrapGossipRelayNode(cctx, "", ""); err != nil {
+			if err := boostrapGossipRelayNode(cctx, lg, gs, "", ""); err != nil {
+				return err
+			}
+		}
+
+		if gs != nil {
+			if err := serveGRPC(cctx, lg, gs); err != nil {
 				return err
 			}
 		}
@@ -140,7 +351,10 @@ var runCmd = &cli.Command{
 	},
 }
 
-func boostrapGossipRelayNode(cctx *cli.Context, groupConf, chainHash string) error {
+// boostrapGossipRelayNode starts a relay node for a chain, also served by gs
+// if not nil. Its pubsub host, upstream client and bucket mirror log through
+// loggers named after them.
+func boostrapGossipRelayNode(cctx *cli.Context, lg log.Logger, gs *grpcserve.Server, groupConf, chainHash string) error {
 	err := cctx.Set(lib.GroupConfFlag.Name, groupConf)
 	if err != nil {
 		return err
@@ -151,7 +365,13 @@ func boostrapGossipRelayNode(cctx *cli.Context, groupConf, chainHash string) err
 		return err
 	}
 
-	c, err := lib.Create(cctx, cctx.IsSet(metricsFlag.Name))
+	opts := []client.Option{client.WithLogger(lg.Named("upstream"))}
+	if urls := cctx.StringSlice(webhookURLFlag.Name); len(urls) > 0 {
+		n := client.NewWebhookNotifier(lg.Named("webhook"), []byte(cctx.String(webhookSecretFlag.Name)), urls...)
+		opts = append(opts, client.WithWebhook(n))
+	}
+
+	c, err := lib.Create(cctx, cctx.IsSet(metricsFlag.Name), opts...)
 	if err != nil {
 		return fmt.Errorf("constructing client: %w", err)
 	}
@@ -161,30 +381,215 @@ func boostrapGossipRelayNode(cctx *cli.Context, groupConf, chainHash string) err
 		return fmt.Errorf("cannot retrieve chain info: %w", err)
 	}
 
-	if chainHash == "" {
-		chainHash = hex.EncodeToString(chainInfo.Hash())
+	wssTLS, err := webSocketTLS(cctx)
+	if err != nil {
+		return err
+	}
+	var psk pnet.PSK
+	if keyPath := cctx.Path(lib.PNetKeyFlag.Name); keyPath != "" {
+		if psk, err = lp2p.LoadPNetKey(keyPath); err != nil {
+			return err
+		}
+	}
+	priv, err := loadIdentity(cctx, lg)
+	if err != nil {
+		return err
+	}
+	nat := lib.NATConfig(cctx)
+	nat.AutoNAT = cctx.Bool(autoNATFlag.Name)
+	nat.RelayService = cctx.Bool(relayServiceFlag.Name)
+	r, err := relay.New(cctx.Context, relay.Config{
+		Client:                  c,
+		ChainHash:               chainHash,
+		ListenAddr:              cctx.String(listenFlag.Name),
+		ListenAddrs:             cctx.StringSlice(extraListenFlag.Name),
+		AnnounceAddrs:           cctx.StringSlice(announceFlag.Name),
+		AppendAnnounceAddrs:     cctx.StringSlice(announceAppendFlag.Name),
+		NoAnnounceCIDRs:         cctx.StringSlice(noAnnounceFlag.Name),
+		WebSocketTLS:            wssTLS,
+		PSK:                     psk,
+		NAT:                     nat,
+		PeerWith:                cctx.StringSlice(peerWithFlag.Name),
+		PeerDNS:                 cctx.String(peerDNSFlag.Name),
+		PrivKey:                 priv,
+		InvalidMessageThreshold: cctx.Uint64(graylistThresholdFlag.Name),
+		TopicNamespace:          cctx.String(lib.TopicNamespaceFlag.Name),
+		ExtraTopics:             cctx.StringSlice(extraTopicFlag.Name),
+		Deduplicate:             cctx.Bool(dedupFlag.Name),
+		Logger:                  lg.With("beaconID", chainInfo.ID),
+	})
+	if err != nil {
+		return fmt.Errorf("could not initialize a new gossip-relay relay node %w", err)
+	}
+	if gs != nil {
+		if err := gs.Add(cctx.Context, c); err != nil {
+			return fmt.Errorf("serving the chain over gRPC: %w", err)
+		}
+	}
+	name := "relay " + hex.EncodeToString(chainInfo.Hash())
+	metrics.AddLivenessCheck(name, r.Healthy)
+	metrics.AddReadinessCheck(name, r.Ready)
+
+	if u := cctx.String(mirrorBucketFlag.Name); u != "" {
+		b, prefix, err := mirrorBucket(u)
+		if err != nil {
+			return err
+		}
+		mirrorLog := lg.Named("datastore").With("beaconID", chainInfo.ID)
+		store, err := objstore.New(cctx.Context, b, objstore.Config{Info: chainInfo, Prefix: prefix, Logger: mirrorLog})
+		if err != nil {
+			return fmt.Errorf("mirroring to %s: %w", redactURL(u), err)
+		}
+		go func() {
+			if err := objstore.Mirror(cctx.Context, c, store); err != nil && cctx.Context.Err() == nil {
+				mirrorLog.Errorw("", "relay", "mirroring stopped", "bucket", redactURL(u), "err", err)
+			}
+		}()
+	}
+
+	if u := cctx.String(ipfsAPIFlag.Name); u != "" {
+		ipfsLog := lg.Named("ipfs").With("beaconID", chainInfo.ID)
+		a, err := ipfs.New(ipfs.Config{API: u, Logger: ipfsLog, StatePath: cctx.String(ipfsStateFlag.Name)})
+		if err != nil {
+			return err
+		}
+		metrics.AddStatus("ipfs "+chainInfo.HashString(), func() any { return a.LatestManifest() })
+		go func() {
+			if err := a.Run(cctx.Context, c); err != nil && cctx.Context.Err() == nil {
+				ipfsLog.Errorw("", "relay", "archiving to IPFS stopped", "api", redactURL(u), "err", err)
+			}
+		}()
+	}
+
+	enc, err := bridge.ParseEncoding(cctx.String(bridgeEncodingFlag.Name))
+	if err != nil {
+		return fmt.Errorf("invalid --%s: %w", bridgeEncodingFlag.Name, err)
+	}
+	bridgeLog := lg.Named("bridge").With("beaconID", chainInfo.ID)
+	if u := cctx.String(bridgeURLFlag.Name); u != "" {
+		pub, err := dialBridge(cctx.Context, u)
+		if err != nil {
+			return fmt.Errorf("bridging to %s: %w", redactURL(u), err)
+		}
+		go publishBeacons(cctx, c, pub, bridge.Config{
+			Topic:    cctx.String(bridgeTopicFlag.Name),
+			Encoding: enc,
+			Logger:   bridgeLog,
+		})
+	}
+	if u := cctx.String(mqttBrokerFlag.Name); u != "" {
+		qos := cctx.Uint(mqttQoSFlag.Name)
+		if qos > 2 {
+			return fmt.Errorf("invalid --%s %d: expected 0, 1 or 2", mqttQoSFlag.Name, qos)
+		}
+		pub, err := mqttbridge.Dial(cctx.Context, u, mqttbridge.Config{QoS: byte(qos), Retain: cctx.Bool(mqttRetainFlag.Name)})
+		if err != nil {
+			return fmt.Errorf("publishing to %s: %w", redactURL(u), err)
+		}
+		topic := cctx.String(mqttTopicFlag.Name)
+		if topic == "" {
+			topic = "drand/" + chainInfo.HashString()
+		}
+		go publishBeacons(cctx, c, pub, bridge.Config{Topic: topic, Encoding: enc, Logger: bridgeLog})
+	}
+	return nil
+}
+
+// dialBridge returns the publisher of the message queue at rawURL, a NATS
+// server for "nats://" and "tls://" URLs, or the Kafka REST Proxy of the
+// URLs of bridge.Dial.
+func dialBridge(ctx context.Context, rawURL string) (bridge.Publisher, error) {
+	if strings.HasPrefix(rawURL, "nats://") || strings.HasPrefix(rawURL, "tls://") {
+		return natsbridge.Dial(ctx, rawURL)
 	}
+	return bridge.Dial(ctx, rawURL)
+}
 
-	// Set the path to be desired 'storage path / beaconID'.
-	// This allows running multiple networks via the same beacon.
-	dataDir := path.Join(cctx.String(storeFlag.Name), chainInfo.ID)
+// publishBeacons publishes the beacons of c to pub until the context of the
+// command is done, and closes pub.
+func publishBeacons(cctx *cli.Context, c drand.Client, pub bridge.Publisher, cfg bridge.Config) {
+	defer pub.Close()
+	if err := bridge.Run(cctx.Context, c, pub, cfg); err != nil && cctx.Context.Err() == nil {
+		cfg.Logger.Errorw("", "relay", "publishing stopped", "publisher", fmt.Sprint(pub), "err", err)
+	}
+}
 
-	cfg := &lp2p.GossipRelayConfig{
-		ChainHash:    chainHash,
-		PeerWith:     cctx.StringSlice(peerWithFlag.Name),
-		Addr:         cctx.String(listenFlag.Name),
-		DataDir:      dataDir,
-		IdentityPath: cctx.String(idFlag.Name),
-		Client:       c,
+// serveGRPC serves the chains of gs on the gRPC listening address until the
+// context of the command is done.
+func serveGRPC(cctx *cli.Context, lg log.Logger, gs *grpcserve.Server) error {
+	addr := cctx.String(grpcListenFlag.Name)
+	l, err := net.Listen("tcp", addr)
+	if err != nil {
+		return fmt.Errorf("listening for gRPC on %s: %w", addr, err)
+	}
+	srv := grpc.NewServer(
+		grpc.UnaryInterceptor(grpcProm.UnaryServerInterceptor),
+		grpc.StreamInterceptor(grpcProm.StreamServerInterceptor),
+	)
+	proto.RegisterPublicServer(srv, gs)
+	go func() {
+		<-cctx.Context.Done()
+		srv.Stop()
+	}()
+	go func() {
+		if err := srv.Serve(l); err != nil {
+			lg.Errorw("", "relay", "gRPC server stopped", "addr", addr, "err", err)
+		}
+	}()
+	lg.Infow("", "relay", "serving gRPC", "addr", l.Addr().String())
+	return nil
+}
+
+// webSocketTLS returns the TLS configuration of the /wss listening addresses,
+// if a certificate is given.
+func webSocketTLS(cctx *cli.Context) (*tls.Config, error) {
+	certPath, keyPath := cctx.Path(wssCertFlag.Name), cctx.Path(wssKeyFlag.Name)
+	if certPath == "" && keyPath == "" {
+		return nil, nil
+	}
+	if certPath == "" || keyPath == "" {
+		return nil, fmt.Errorf("--%s and --%s must be given together", wssCertFlag.Name, wssKeyFlag.Name)
+	}
+	cert, err := tls.LoadX509KeyPair(certPath, keyPath)
+	if err != nil {
+		return nil, fmt.Errorf("loading WebSocket certificate: %w", err)
 	}
+	return &tls.Config{Certificates: []tls.Certificate{cert}, MinVersion: tls.VersionTLS12}, nil
+}
 
-	l := log.DefaultLogger().With("beaconID", chainInfo.ID)
+// mirrorBucket returns the bucket and key prefix of a mirror URL, as
+// https://endpoint/bucket[/prefix], with the credentials of the environment.
+func mirrorBucket(rawURL string) (objstore.Bucket, string, error) {
+	u, err := url.Parse(rawURL)
+	if err != nil {
+		return nil, "", fmt.Errorf("invalid --%s: %w", mirrorBucketFlag.Name, errors.Unwrap(err))
+	}
+	bucket, prefix, _ := strings.Cut(strings.TrimPrefix(u.Path, "/"), "/")
+	if prefix != "" && !strings.HasSuffix(prefix, "/") {
+		prefix += "/"
+	}
+	u.Path = ""
+	b, err := objstore.NewS3Bucket(objstore.S3Config{
+		Endpoint:        u.String(),
+		Bucket:          bucket,
+		Region:          os.Getenv("AWS_REGION"),
+		AccessKeyID:     os.Getenv("AWS_ACCESS_KEY_ID"),
+		SecretAccessKey: os.Getenv("AWS_SECRET_ACCESS_KEY"),
+		SessionToken:    os.Getenv("AWS_SESSION_TOKEN"),
+	})
+	if err != nil {
+		return nil, "", fmt.Errorf("invalid --%s %q: %w", mirrorBucketFlag.Name, redactURL(rawURL), err)
+	}
+	return b, prefix, nil
+}
 
-	_, err = lp2p.NewGossipRelayNode(l, cfg)
+// redactURL returns rawURL with its password, if any, masked, to be logged.
+func redactURL(rawURL string) string {
+	u, err := url.Parse(rawURL)
 	if err != nil {
-		err = fmt.Errorf("could not initialize a new gossip-relay relay node %w", err)
+		return "invalid URL"
 	}
-	return err
+	return u.Redacted()
 }
 
 func computeHashes(cctx *cli.Context) ([]string, error) {
@@ -203,25 +608,36 @@ func computeHashes(cctx *cli.Context) ([]string, error) {
 }
 
 var clientCmd = &cli.Command{
-	Name:  "client",
-	Flags: lib.ClientFlags,
+	Name:   "client",
+	Flags:  append([]cli.Flag{lib.MetricsFlag, lib.LogLevelFlag}, lib.ClientFlags...),
+	Before: lib.LoadConfig,
 	Action: func(cctx *cli.Context) error {
-		lg := log.New(nil, log.DefaultLevel, false)
+		lg, err := lib.NewLogger(cctx, log.DefaultLevel)
+		if err != nil {
+			return err
+		}
 		cctx.Context = log.ToContext(cctx.Context, lg)
+		instrumented, err := lib.StartMetrics(cctx)
+		if err != nil {
+			return err
+		}
 		if cctx.IsSet(lib.GroupConfListFlag.Name) {
-			groupConfs := cctx.StringSlice(lib.GroupConfListFlag.Name)
-			if len(groupConfs) != 1 {
-				return fmt.Errorf("please specify a single valid chain using the --%s flag with the client command", lib.GroupConfListFlag.Name)
-			}
-
 			if cctx.IsSet(lib.GroupConfFlag.Name) {
 				return fmt.Errorf("please do not use both --%s and --%s at the same time", lib.GroupConfFlag.Name, lib.GroupConfListFlag.Name)
 			}
+			groupConfs, err := lib.GroupConfFiles(cctx.StringSlice(lib.GroupConfListFlag.Name))
+			if err != nil {
+				return err
+			}
+			if len(groupConfs) != 1 {
+				// several chains are followed over HTTP only, each from the URLs serving it
+				return watchChains(cctx, lg, instrumented)
+			}
 			if err := cctx.Set(lib.GroupConfFlag.Name, groupConfs[0]); err != nil {
 				return fmt.Errorf("unable to set GroupConfFlag: %w", err)
 			}
 		}
-		c, err := lib.Create(cctx, false)
+		c, err := lib.Create(cctx, instrumented)
 		if err != nil {
 			return fmt.Errorf("constructing client: %w", err)
 		}
@@ -234,21 +650,27 @@ var clientCmd = &cli.Command{
 	},
 }
 
-var idCmd = &cli.Command{
-	Name:  "peerid",
-	Usage: "prints the libp2p peer ID or creates one if it does not exist",
-	Flags: []cli.Flag{idFlag},
-	Action: func(cctx *cli.Context) error {
-		lg := log.New(nil, log.DefaultLevel, false)
-		priv, err := lp2p.LoadOrCreatePrivKey(cctx.String(idFlag.Name), lg)
-		if err != nil {
-			return fmt.Errorf("loading p2p key: %w", err)
-		}
-		peerID, err := peer.IDFromPrivateKey(priv)
-		if err != nil {
-			return fmt.Errorf("computing peerid: %w", err)
-		}
-		fmt.Printf("%s\n", peerID)
-		return nil
-	},
+// watchChains logs the randomness of every chain given with the group-conf-list
+// flag, as received from the relays serving it.
+func watchChains(cctx *cli.Context, lg log.Logger, instrumented bool) error {
+	mc, err := lib.CreateMulti(cctx, instrumented)
+	if err != nil {
+		return fmt.Errorf("constructing clients: %w", err)
+	}
+	defer mc.Close()
+
+	var wg sync.WaitGroup
+	for _, hash := range mc.Chains() {
+		c, _ := mc.Client(hash)
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for rand := range c.Watch(cctx.Context) {
+				lg.Infow("", "client", "got randomness", "chainHash", hash,
+					"round", rand.GetRound(), "signature", hex.EncodeToString(rand.GetSignature()))
+			}
+		}()
+	}
+	wg.Wait()
+	return nil
 }
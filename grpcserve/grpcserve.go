@@ -0,0 +1,283 @@
+/*
+Package grpcserve serves the verified randomness of drand clients over the
+public gRPC API of drand nodes, so that relays can offer the more compact gRPC
+transport, and its streams, to the consumers of their cluster, e.g.
+
+	s := grpcserve.New()
+	if err := s.Add(ctx, c); err != nil {
+		return err
+	}
+	srv := grpc.NewServer()
+	proto.RegisterPublicServer(srv, s)
+	return srv.Serve(listener)
+
+The server serves the chains of all the clients added, selected by the chain
+hash in the metadata of the requests. Requests without a chain hash are served
+the chain of the default beacon, or the only chain added. It should be given
+clients verifying the beacons, such as the clients made with client.New, whose
+cache then spares the upstream relays repeated requests.
+*/
+package grpcserve
+
+import (
+	"context"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+
+	clock "github.com/jonboulle/clockwork"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+
+	"github.com/drand/drand/v2/common"
+	"github.com/drand/drand/v2/common/chain"
+	"github.com/drand/drand/v2/common/log"
+	proto "github.com/drand/drand/v2/protobuf/drand"
+	"github.com/drand/go-clients/client"
+	"github.com/drand/go-clients/drand"
+)
+
+// DefaultTimeout bounds the time spent getting a round from a client.
+const DefaultTimeout = 10 * time.Second
+
+// DefaultMaxCatchUp bounds the number of past rounds streamed to a client
+// requesting a stream from a past round.
+const DefaultMaxCatchUp = 1000
+
+// Option configures a Server.
+type Option func(s *Server)
+
+// WithTimeout bounds the time spent getting a round from a client, or its
+// chain info, for each request, DefaultTimeout by default. Streams are not
+// bounded.
+func WithTimeout(d time.Duration) Option {
+	return func(s *Server) {
+		s.timeout = d
+	}
+}
+
+// WithMaxCatchUp bounds the number of past rounds streamed to a client
+// requesting a stream from a past round, DefaultMaxCatchUp by default, since
+// each of them is fetched from the clients served. Streams from an older
+// round are rejected.
+func WithMaxCatchUp(n uint64) Option {
+	return func(s *Server) {
+		s.maxCatchUp = n
+	}
+}
+
+// WithLogger sets the logger of the errors of the clients.
+func WithLogger(l log.Logger) Option {
+	return func(s *Server) {
+		s.log = l
+	}
+}
+
+// Server is a drand public gRPC service serving the randomness of clients.
+type Server struct {
+	proto.UnimplementedPublicServer
+
+	timeout    time.Duration
+	maxCatchUp uint64
+	log        log.Logger
+	clock      clock.Clock
+
+	lk     sync.RWMutex
+	chains map[string]*served
+}
+
+var _ proto.PublicServer = (*Server)(nil)
+
+// served is a chain served by a Server.
+type served struct {
+	client drand.Client
+	info   *chain.Info
+}
+
+// New returns a Server serving no chain, see Add.
+func New(opts ...Option) *Server {
+	s := &Server{
+		timeout:    DefaultTimeout,
+		maxCatchUp: DefaultMaxCatchUp,
+		log:        log.DefaultLogger(),
+		clock:      clock.NewRealClock(),
+		chains:     make(map[string]*served),
+	}
+	for _, opt := range opts {
+		opt(s)
+	}
+	return s
+}
+
+// Add serves the chain of c, replacing the client previously serving it, if
+// any.
+func (s *Server) Add(ctx context.Context, c drand.Client) error {
+	info, err := c.Info(ctx)
+	if err != nil {
+		return fmt.Errorf("getting chain info: %w", err)
+	}
+	s.lk.Lock()
+	defer s.lk.Unlock()
+	s.chains[info.HashString()] = &served{client: c, info: info}
+	return nil
+}
+
+// chain returns the chain requested by metadata.
+func (s *Server) chain(metadata *proto.Metadata) (*served, error) {
+	s.lk.RLock()
+	defer s.lk.RUnlock()
+	if hash := metadata.GetChainHash(); len(hash) > 0 {
+		if c, ok := s.chains[hex.EncodeToString(hash)]; ok {
+			return c, nil
+		}
+		return nil, status.Errorf(codes.NotFound, "chain %x is not served", hash)
+	}
+	var found *served
+	for _, c := range s.chains {
+		if len(s.chains) == 1 || common.IsDefaultBeaconID(c.info.ID) {
+			found = c
+		}
+	}
+	if found == nil {
+		return nil, status.Error(codes.InvalidArgument, "no chain hash given, and no default chain served")
+	}
+	return found, nil
+}
+
+// PublicRand returns the requested round, or the latest one for round 0.
+func (s *Server) PublicRand(ctx context.Context, req *proto.PublicRandRequest) (*proto.PublicRandResponse, error) {
+	c, err := s.chain(req.GetMetadata())
+	if err != nil {
+		return nil, err
+	}
+	if req.GetRound() > s.latest(c) {
+		return nil, status.Errorf(codes.NotFound, "round %d not produced yet", req.GetRound())
+	}
+	ctx, cancel := context.WithTimeout(ctx, s.timeout)
+	defer cancel()
+	res, err := c.client.Get(ctx, req.GetRound())
+	if err != nil {
+		return nil, s.fail("PublicRand", err)
+	}
+	return toProto(c.info, res), nil
+}
+
+// PublicRandStream streams the new rounds as they are produced, after the
+// rounds from the requested one to the latest one, if a round is requested.
+// Requests for rounds older than the last maxCatchUp ones are rejected.
+func (s *Server) PublicRandStream(req *proto.PublicRandRequest, stream proto.Public_PublicRandStreamServer) error {
+	c, err := s.chain(req.GetMetadata())
+	if err != nil {
+		return err
+	}
+	ctx := stream.Context()
+	next := req.GetRound()
+	if latest := s.latest(c); next > 0 && next <= latest && latest-next >= s.maxCatchUp {
+		return status.Errorf(codes.OutOfRange, "cannot stream more than the last %d rounds, round %d is too old", s.maxCatchUp, next)
+	}
+	// watch before catching up, so that no round is missed in between
+	results := c.client.Watch(ctx)
+
+	var last uint64
+	if next > 0 {
+		// the rounds produced while catching up are caught up too
+		last = next - 1
+		for latest := s.latest(c); last < latest; latest = s.latest(c) {
+			if err := s.sendRounds(ctx, stream, c, last+1, latest); err != nil {
+				return err
+			}
+			last = latest
+		}
+	}
+
+	for res := range results {
+		if res.GetRound() <= last {
+			continue
+		}
+		// the rounds the watch dropped, e.g. while catching up, are fetched
+		if next > 0 && res.GetRound() > last+1 {
+			from := max(last+1, res.GetRound()-min(res.GetRound(), s.maxCatchUp))
+			if err := s.sendRounds(ctx, stream, c, from, res.GetRound()-1); err != nil {
+				return err
+			}
+		}
+		if err := stream.Send(toProto(c.info, res)); err != nil {
+			return err
+		}
+		last = res.GetRound()
+	}
+	if ctx.Err() != nil {
+		return status.FromContextError(ctx.Err()).Err()
+	}
+	return status.Error(codes.Unavailable, "upstream stream closed")
+}
+
+// latest returns the current round of the chain of c.
+func (s *Server) latest(c *served) uint64 {
+	return common.CurrentRound(s.clock.Now().Unix(), c.info.Period, c.info.GenesisTime)
+}
+
+// sendRounds gets the rounds from from to to from c, and sends them on stream.
+func (s *Server) sendRounds(ctx context.Context, stream proto.Public_PublicRandStreamServer, c *served, from, to uint64) error {
+	for round := from; round <= to; round++ {
+		gctx, cancel := context.WithTimeout(ctx, s.timeout)
+		res, err := c.client.Get(gctx, round)
+		cancel()
+		if err != nil {
+			return s.fail("PublicRandStream", err)
+		}
+		if err := stream.Send(toProto(c.info, res)); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// ChainInfo returns the chain info of the requested chain.
+func (s *Server) ChainInfo(_ context.Context, req *proto.ChainInfoRequest) (*proto.ChainInfoPacket, error) {
+	c, err := s.chain(req.GetMetadata())
+	if err != nil {
+		return nil, err
+	}
+	return c.info.ToProto(metadata(c.info)), nil
+}
+
+// ListBeaconIDs returns the beacon IDs of the chains served.
+func (s *Server) ListBeaconIDs(_ context.Context, _ *proto.ListBeaconIDsRequest) (*proto.ListBeaconIDsResponse, error) {
+	s.lk.RLock()
+	defer s.lk.RUnlock()
+	resp := &proto.ListBeaconIDsResponse{}
+	for _, c := range s.chains {
+		resp.Ids = append(resp.Ids, common.GetCanonicalBeaconID(c.info.ID))
+		resp.Metadatas = append(resp.Metadatas, metadata(c.info))
+	}
+	return resp, nil
+}
+
+// fail reports an error of a client as an unavailable upstream, or a deadline
+// exceeded.
+func (s *Server) fail(method string, err error) error {
+	s.log.Warnw("", "grpcserve", "failed to serve request", "method", method, "err", err)
+	if errors.Is(err, context.DeadlineExceeded) {
+		return status.Error(codes.DeadlineExceeded, "timed out getting randomness")
+	}
+	return status.Error(codes.Unavailable, "failed to get randomness")
+}
+
+func metadata(info *chain.Info) *proto.Metadata {
+	return &proto.Metadata{BeaconID: common.GetCanonicalBeaconID(info.ID), ChainHash: info.Hash()}
+}
+
+func toProto(info *chain.Info, res drand.Result) *proto.PublicRandResponse {
+	rd := &client.RandomData{
+		Rnd:               res.GetRound(),
+		Random:            res.GetRandomness(),
+		Sig:               res.GetSignature(),
+		PreviousSignature: res.GetPreviousSignature(),
+	}
+	p := rd.ToProto()
+	p.Metadata = metadata(info)
+	return p
+}
@@ -0,0 +1,133 @@
+package grpcserve
+
+import (
+	"context"
+	"net"
+	"testing"
+	"time"
+
+	clock "github.com/jonboulle/clockwork"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	grpcInsec "google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/grpc/status"
+
+	"github.com/drand/drand/v2/common/chain"
+	"github.com/drand/drand/v2/crypto"
+	proto "github.com/drand/drand/v2/protobuf/drand"
+	"github.com/drand/go-clients/client"
+	"github.com/drand/go-clients/client/http"
+	clientMock "github.com/drand/go-clients/client/mock"
+	"github.com/drand/go-clients/client/test/result/mock"
+	"github.com/drand/go-clients/clienttest"
+	"github.com/drand/go-clients/drand"
+)
+
+func TestServer(t *testing.T) {
+	ctx := context.Background()
+	relay := clienttest.NewRelay(t, clienttest.WithRounds(10))
+	hc, err := http.NewWithInfo(nil, relay.URL(), relay.Info(), nil)
+	require.NoError(t, err)
+	c, err := client.New(client.From(hc), client.WithChainInfo(relay.Info()))
+	require.NoError(t, err)
+	defer c.Close()
+
+	s := New()
+	require.NoError(t, s.Add(ctx, c))
+	// round 6 is the current one
+	genesis := time.Unix(relay.Info().GenesisTime, 0)
+	s.clock = clock.NewFakeClockAt(genesis.Add(5 * relay.Info().Period))
+
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+	srv := grpc.NewServer()
+	proto.RegisterPublicServer(srv, s)
+	go func() { _ = srv.Serve(l) }()
+	defer srv.Stop()
+	conn, err := grpc.NewClient(l.Addr().String(), grpc.WithTransportCredentials(grpcInsec.NewCredentials()))
+	require.NoError(t, err)
+	defer conn.Close()
+	pc := proto.NewPublicClient(conn)
+	hash := &proto.Metadata{ChainHash: relay.Info().Hash()}
+
+	p, err := pc.ChainInfo(ctx, &proto.ChainInfoRequest{Metadata: hash})
+	require.NoError(t, err)
+	info, err := chain.InfoFromProto(p)
+	require.NoError(t, err)
+	require.True(t, info.Equal(relay.Info()))
+
+	// the only chain served is served by default
+	r, err := pc.PublicRand(ctx, &proto.PublicRandRequest{Round: 3})
+	require.NoError(t, err)
+	require.Equal(t, uint64(3), r.GetRound())
+	require.Equal(t, relay.Result(3).GetSignature(), r.GetSignature())
+	require.Equal(t, relay.Info().Hash(), r.GetMetadata().GetChainHash())
+
+	_, err = pc.PublicRand(ctx, &proto.PublicRandRequest{Round: 7, Metadata: hash})
+	require.Equal(t, codes.NotFound, status.Code(err))
+	_, err = pc.PublicRand(ctx, &proto.PublicRandRequest{Round: 3, Metadata: &proto.Metadata{ChainHash: []byte("other")}})
+	require.Equal(t, codes.NotFound, status.Code(err))
+
+	// the stream catches up from the requested round to the current one
+	sctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+	stream, err := pc.PublicRandStream(sctx, &proto.PublicRandRequest{Round: 4, Metadata: hash})
+	require.NoError(t, err)
+	for round := uint64(4); round <= 6; round++ {
+		r, err := stream.Recv()
+		require.NoError(t, err)
+		require.Equal(t, round, r.GetRound())
+		require.Equal(t, relay.Result(round).GetSignature(), r.GetSignature())
+	}
+
+	// streams catching up on more rounds than allowed are rejected
+	s.maxCatchUp = 3
+	stream, err = pc.PublicRandStream(sctx, &proto.PublicRandRequest{Round: 3, Metadata: hash})
+	require.NoError(t, err)
+	_, err = stream.Recv()
+	require.Equal(t, codes.OutOfRange, status.Code(err))
+
+	ids, err := pc.ListBeaconIDs(ctx, &proto.ListBeaconIDsRequest{})
+	require.NoError(t, err)
+	require.Len(t, ids.GetIds(), 1)
+	require.Equal(t, relay.Info().Hash(), ids.GetMetadatas()[0].GetChainHash())
+}
+
+// recordingStream records the rounds sent on a stream.
+type recordingStream struct {
+	grpc.ServerStream
+	ctx    context.Context
+	rounds []uint64
+}
+
+func (s *recordingStream) Context() context.Context {
+	return s.ctx
+}
+
+func (s *recordingStream) Send(r *proto.PublicRandResponse) error {
+	s.rounds = append(s.rounds, r.GetRound())
+	return nil
+}
+
+func TestStreamFillsDroppedRounds(t *testing.T) {
+	ctx := context.Background()
+	sch, err := crypto.GetSchemeFromEnv()
+	require.NoError(t, err)
+	info, results := mock.VerifiableResults(8, sch)
+	// the watch dropped round 7, produced while catching up
+	watch := make(chan drand.Result, 1)
+	watch <- &results[7]
+	close(watch)
+	c := &clientMock.Client{OptionalInfo: info, Results: results, StrictRounds: true, WatchCh: watch}
+
+	s := New()
+	require.NoError(t, s.Add(ctx, c))
+	// round 6 is the current one
+	s.clock = clock.NewFakeClockAt(time.Unix(info.GenesisTime, 0).Add(5 * info.Period))
+
+	stream := &recordingStream{ctx: ctx}
+	err = s.PublicRandStream(&proto.PublicRandRequest{Round: 4}, stream)
+	require.Equal(t, codes.Unavailable, status.Code(err))
+	require.Equal(t, []uint64{4, 5, 6, 7, 8}, stream.rounds)
+}
@@ -0,0 +1,191 @@
+/*
+Package httpserve serves the verified randomness of a drand client over HTTP,
+so that Go services can re-expose drand internally, behind their own
+authentication and network policies, e.g.
+
+	c, err := client.New(client.From(http.ForURLs(ctx, nil, urls, chainHash)...), client.WithChainHash(chainHash))
+	if err != nil {
+		return err
+	}
+	defer c.Close()
+	mux := nhttp.NewServeMux()
+	mux.Handle("/drand/", nhttp.StripPrefix("/drand", httpserve.New(c)))
+	return nhttp.ListenAndServe(":8080", authenticate(mux))
+
+The handler serves the chain info on /info, the latest round on /latest and
+the round n on /round/{n}, in the JSON format of the drand HTTP API. It should
+be given a client verifying the beacons, such as the clients made with
+client.New, whose cache then spares the relays repeated requests.
+*/
+package httpserve
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	nhttp "net/http"
+	"strconv"
+	"sync"
+	"time"
+
+	clock "github.com/jonboulle/clockwork"
+
+	"github.com/drand/drand/v2/common"
+	"github.com/drand/drand/v2/common/chain"
+	"github.com/drand/drand/v2/common/log"
+	"github.com/drand/go-clients/client"
+	"github.com/drand/go-clients/drand"
+)
+
+// DefaultTimeout bounds the time spent getting a round from the client.
+const DefaultTimeout = 10 * time.Second
+
+// Option configures a Handler.
+type Option func(h *Handler)
+
+// WithTimeout bounds the time spent getting a round from the client, or the
+// chain info, for each request, DefaultTimeout by default.
+func WithTimeout(d time.Duration) Option {
+	return func(h *Handler) {
+		h.timeout = d
+	}
+}
+
+// WithLogger sets the logger of the errors of the client.
+func WithLogger(l log.Logger) Option {
+	return func(h *Handler) {
+		h.log = l
+	}
+}
+
+// Handler is an http.Handler serving the randomness of a client.
+type Handler struct {
+	client  drand.Client
+	timeout time.Duration
+	log     log.Logger
+	clock   clock.Clock
+	mux     *nhttp.ServeMux
+
+	// info is the chain info of the client, fetched on first use.
+	infoLk sync.Mutex
+	info   *chain.Info
+}
+
+// New returns a Handler serving the randomness of c.
+func New(c drand.Client, opts ...Option) *Handler {
+	h := &Handler{
+		client:  c,
+		timeout: DefaultTimeout,
+		log:     log.DefaultLogger(),
+		clock:   clock.NewRealClock(),
+		mux:     nhttp.NewServeMux(),
+	}
+	for _, opt := range opts {
+		opt(h)
+	}
+	h.mux.HandleFunc("GET /info", h.serveInfo)
+	h.mux.HandleFunc("GET /latest", h.serveLatest)
+	h.mux.HandleFunc("GET /round/{n}", h.serveRound)
+	return h
+}
+
+// ServeHTTP implements http.Handler.
+func (h *Handler) ServeHTTP(w nhttp.ResponseWriter, r *nhttp.Request) {
+	h.mux.ServeHTTP(w, r)
+}
+
+// chainInfo returns the chain info of the client, which never changes.
+func (h *Handler) chainInfo(ctx context.Context) (*chain.Info, error) {
+	h.infoLk.Lock()
+	defer h.infoLk.Unlock()
+	if h.info != nil {
+		return h.info, nil
+	}
+	info, err := h.client.Info(ctx)
+	if err != nil {
+		return nil, err
+	}
+	h.info = info
+	return info, nil
+}
+
+func (h *Handler) serveInfo(w nhttp.ResponseWriter, r *nhttp.Request) {
+	ctx, cancel := context.WithTimeout(r.Context(), h.timeout)
+	defer cancel()
+	info, err := h.chainInfo(ctx)
+	if err != nil {
+		h.fail(w, r, err)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	w.Header().Set("Cache-Control", "public, max-age=604800")
+	_ = info.ToJSON(w, nil)
+}
+
+func (h *Handler) serveLatest(w nhttp.ResponseWriter, r *nhttp.Request) {
+	ctx, cancel := context.WithTimeout(r.Context(), h.timeout)
+	defer cancel()
+	info, err := h.chainInfo(ctx)
+	if err != nil {
+		h.fail(w, r, err)
+		return
+	}
+	res, err := h.client.Get(ctx, 0)
+	if err != nil {
+		h.fail(w, r, err)
+		return
+	}
+	// the latest round can be cached until the next one is due
+	_, next := common.NextRound(h.clock.Now().Unix(), info.Period, info.GenesisTime)
+	maxAge := max(next-h.clock.Now().Unix(), 0)
+	writeResult(w, res, fmt.Sprintf("public, max-age=%d", maxAge))
+}
+
+func (h *Handler) serveRound(w nhttp.ResponseWriter, r *nhttp.Request) {
+	round, err := strconv.ParseUint(r.PathValue("n"), 10, 64)
+	if err != nil || round == 0 {
+		nhttp.Error(w, "invalid round", nhttp.StatusBadRequest)
+		return
+	}
+	ctx, cancel := context.WithTimeout(r.Context(), h.timeout)
+	defer cancel()
+	info, err := h.chainInfo(ctx)
+	if err != nil {
+		h.fail(w, r, err)
+		return
+	}
+	if round > common.CurrentRound(h.clock.Now().Unix(), info.Period, info.GenesisTime) {
+		nhttp.Error(w, "round not produced yet", nhttp.StatusNotFound)
+		return
+	}
+	res, err := h.client.Get(ctx, round)
+	if err != nil {
+		h.fail(w, r, err)
+		return
+	}
+	// past rounds never change
+	writeResult(w, res, "public, max-age=31536000, immutable")
+}
+
+// fail reports an error of the client as a bad gateway, or a gateway timeout.
+func (h *Handler) fail(w nhttp.ResponseWriter, r *nhttp.Request, err error) {
+	h.log.Warnw("", "httpserve", "failed to serve request", "path", r.URL.Path, "err", err)
+	if errors.Is(err, context.DeadlineExceeded) {
+		nhttp.Error(w, "timed out getting randomness", nhttp.StatusGatewayTimeout)
+		return
+	}
+	nhttp.Error(w, "failed to get randomness", nhttp.StatusBadGateway)
+}
+
+func writeResult(w nhttp.ResponseWriter, res drand.Result, cacheControl string) {
+	rd := &client.RandomData{
+		Rnd:               res.GetRound(),
+		Random:            res.GetRandomness(),
+		Sig:               res.GetSignature(),
+		PreviousSignature: res.GetPreviousSignature(),
+	}
+	w.Header().Set("Content-Type", "application/json")
+	w.Header().Set("Cache-Control", cacheControl)
+	_ = json.NewEncoder(w).Encode(rd)
+}
@@ -0,0 +1,70 @@
+package httpserve
+
+import (
+	"encoding/json"
+	nhttp "net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	clock "github.com/jonboulle/clockwork"
+	"github.com/stretchr/testify/require"
+
+	"github.com/drand/drand/v2/common/chain"
+	"github.com/drand/go-clients/client"
+	"github.com/drand/go-clients/client/http"
+	"github.com/drand/go-clients/clienttest"
+)
+
+func TestHandler(t *testing.T) {
+	relay := clienttest.NewRelay(t, clienttest.WithRounds(10))
+	hc, err := http.NewWithInfo(nil, relay.URL(), relay.Info(), nil)
+	require.NoError(t, err)
+	c, err := client.New(client.From(hc), client.WithChainInfo(relay.Info()))
+	require.NoError(t, err)
+	defer c.Close()
+
+	h := New(c)
+	// round 6 is the current one
+	genesis := time.Unix(relay.Info().GenesisTime, 0)
+	h.clock = clock.NewFakeClockAt(genesis.Add(5*relay.Info().Period + time.Second))
+	srv := httptest.NewServer(h)
+	defer srv.Close()
+
+	get := func(path string) *nhttp.Response {
+		resp, err := nhttp.Get(srv.URL + path)
+		require.NoError(t, err)
+		t.Cleanup(func() { resp.Body.Close() })
+		return resp
+	}
+
+	resp := get("/info")
+	require.Equal(t, nhttp.StatusOK, resp.StatusCode)
+	info, err := chain.InfoFromJSON(resp.Body)
+	require.NoError(t, err)
+	require.True(t, info.Equal(relay.Info()))
+
+	resp = get("/round/3")
+	require.Equal(t, nhttp.StatusOK, resp.StatusCode)
+	require.Contains(t, resp.Header.Get("Cache-Control"), "immutable")
+	var beacon client.RandomData
+	require.NoError(t, json.NewDecoder(resp.Body).Decode(&beacon))
+	require.Equal(t, uint64(3), beacon.GetRound())
+	require.Equal(t, relay.Result(3).GetSignature(), beacon.GetSignature())
+
+	resp = get("/latest")
+	require.Equal(t, nhttp.StatusOK, resp.StatusCode)
+	require.NoError(t, json.NewDecoder(resp.Body).Decode(&beacon))
+	require.Equal(t, relay.Result(10).GetSignature(), beacon.GetSignature())
+
+	require.Equal(t, nhttp.StatusNotFound, get("/round/8").StatusCode)
+	require.Equal(t, nhttp.StatusBadRequest, get("/round/0").StatusCode)
+	require.Equal(t, nhttp.StatusBadRequest, get("/round/latest").StatusCode)
+	resp, err = nhttp.Post(srv.URL+"/latest", "", nil)
+	require.NoError(t, err)
+	resp.Body.Close()
+	require.Equal(t, nhttp.StatusMethodNotAllowed, resp.StatusCode)
+
+	relay.SetDown(true)
+	require.Equal(t, nhttp.StatusBadGateway, get("/round/5").StatusCode)
+}
@@ -0,0 +1,178 @@
+package drand
+
+import (
+	"context"
+	"fmt"
+	"math/rand"
+	"slices"
+	"sync"
+	"text/tabwriter"
+	"time"
+
+	"github.com/urfave/cli/v2"
+
+	"github.com/drand/drand/v2/common"
+	"github.com/drand/go-clients/client"
+	"github.com/drand/go-clients/internal/lib"
+)
+
+var (
+	benchConcurrencyFlag = &cli.IntFlag{
+		Name:  "concurrency",
+		Usage: "Number of workers getting rounds in parallel",
+		Value: 64,
+	}
+	benchDurationFlag = &cli.DurationFlag{
+		Name:  "duration",
+		Usage: "How long to load the relays for",
+		Value: time.Minute,
+	}
+	benchRateFlag = &cli.Float64Flag{
+		Name: "rate",
+		Usage: "Maximum number of rounds requested per second by all the workers, so as not to overload " +
+			"the relays. Only raise it against relays you operate",
+		Value: 100,
+	}
+	benchHistoryFlag = &cli.BoolFlag{
+		Name:  "history",
+		Usage: "Get random past rounds instead of the latest one, which the relays are more likely to have cached",
+	}
+	benchWatchersFlag = &cli.IntFlag{
+		Name:  "watchers",
+		Usage: "Number of watches run alongside the workers, measuring how late the new rounds are received",
+	}
+)
+
+// maxBenchRate is the highest rate --rate accepts.
+const maxBenchRate = 10_000
+
+// benchStats records the outcome of the operations of a benchmark.
+type benchStats struct {
+	lk        sync.Mutex
+	latencies []time.Duration
+	errors    int
+	lastErr   error
+}
+
+func (s *benchStats) record(latency time.Duration, err error) {
+	s.lk.Lock()
+	defer s.lk.Unlock()
+	if err != nil {
+		s.errors++
+		s.lastErr = err
+		return
+	}
+	s.latencies = append(s.latencies, latency)
+}
+
+// percentile returns the p-th percentile of sorted latencies.
+func percentile(sorted []time.Duration, p int) time.Duration {
+	if len(sorted) == 0 {
+		return 0
+	}
+	return sorted[(len(sorted)-1)*p/100]
+}
+
+// row prints the throughput and latency distribution of the operations, over
+// elapsed.
+func (s *benchStats) row(w *tabwriter.Writer, op string, elapsed time.Duration) {
+	s.lk.Lock()
+	defer s.lk.Unlock()
+	sorted := slices.Clone(s.latencies)
+	slices.Sort(sorted)
+	n := len(sorted) + s.errors
+	fmt.Fprintf(w, "%s\t%d\t%d\t%.1f/s\t%s\t%s\t%s\t%s\n", op, n, s.errors, float64(len(sorted))/elapsed.Seconds(),
+		percentile(sorted, 50).Round(time.Microsecond), percentile(sorted, 90).Round(time.Microsecond),
+		percentile(sorted, 99).Round(time.Microsecond), percentile(sorted, 100).Round(time.Microsecond))
+}
+
+func benchRelays(cctx *cli.Context) error {
+	concurrency := cctx.Int(benchConcurrencyFlag.Name)
+	rate := cctx.Float64(benchRateFlag.Name)
+	if concurrency < 1 {
+		return fmt.Errorf("--%s must be at least 1", benchConcurrencyFlag.Name)
+	}
+	if rate <= 0 || rate > maxBenchRate {
+		return fmt.Errorf("--%s must be positive, and at most %d", benchRateFlag.Name, maxBenchRate)
+	}
+
+	// the client must not serve the rounds from its own cache, unless asked to
+	c, err := lib.Create(cctx, false, client.WithCacheSize(0))
+	if err != nil {
+		return fmt.Errorf("constructing client: %w", err)
+	}
+	defer c.Close()
+	info, err := c.Info(cctx.Context)
+	if err != nil {
+		return fmt.Errorf("cannot retrieve chain info from relay: %w", err)
+	}
+	latest, err := c.Get(cctx.Context, 0)
+	if err != nil {
+		return fmt.Errorf("getting the latest round: %w", err)
+	}
+
+	ctx, cancel := context.WithTimeout(cctx.Context, cctx.Duration(benchDurationFlag.Name))
+	defer cancel()
+	start := time.Now()
+
+	// the workers share a single ticker pacing their requests
+	ticker := time.NewTicker(time.Duration(float64(time.Second) / rate))
+	defer ticker.Stop()
+	history := cctx.Bool(benchHistoryFlag.Name)
+	var gets benchStats
+	var wg sync.WaitGroup
+	for range concurrency {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for {
+				select {
+				case <-ticker.C:
+				case <-ctx.Done():
+					return
+				}
+				round := uint64(0)
+				if history {
+					//nolint:gosec // the rounds do not need to be cryptographically random
+					round = 1 + rand.Uint64()%latest.GetRound()
+				}
+				t := time.Now()
+				_, err := c.Get(ctx, round)
+				if ctx.Err() != nil {
+					// the request was cut short by the end of the benchmark
+					return
+				}
+				gets.record(time.Since(t), err)
+			}
+		}()
+	}
+
+	var watches benchStats
+	for range cctx.Int(benchWatchersFlag.Name) {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for r := range c.Watch(ctx) {
+				expected := time.Unix(common.TimeOfRound(info.Period, info.GenesisTime, r.GetRound()), 0)
+				watches.record(time.Since(expected), nil)
+			}
+		}()
+	}
+	wg.Wait()
+	elapsed := time.Since(start)
+
+	w := tabwriter.NewWriter(cctx.App.Writer, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, "OP\tCOUNT\tERRORS\tTHROUGHPUT\tP50\tP90\tP99\tMAX")
+	gets.row(w, "get", elapsed)
+	if cctx.Int(benchWatchersFlag.Name) > 0 {
+		// the latency of a watched round is its delay after the time of the round
+		watches.row(w, "watch", elapsed)
+	}
+	if err := w.Flush(); err != nil {
+		return err
+	}
+	if gets.lastErr != nil {
+		fmt.Fprintf(cctx.App.ErrWriter, "last error: %v\n", gets.lastErr)
+	}
+	return nil
+}
@@ -1,18 +1,36 @@
 package drand
 
 import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/hex"
+	"errors"
 	"fmt"
-	"log"
+	"io"
+	"iter"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"runtime"
+	"sort"
 	"strconv"
+	"strings"
 	"sync"
+	"text/tabwriter"
+	"time"
 
 	json "github.com/nikkolasg/hexjson"
 	"github.com/urfave/cli/v2"
 
+	"github.com/drand/go-clients/archive"
+	"github.com/drand/go-clients/client"
 	"github.com/drand/go-clients/drand"
 
 	"github.com/drand/drand/v2/common"
+	"github.com/drand/drand/v2/common/chain"
 	"github.com/drand/go-clients/internal/lib"
+	"github.com/drand/go-clients/verify"
 )
 
 // Automatically set through -ldflags
@@ -24,6 +42,105 @@ var (
 
 var SetVersionPrinter sync.Once
 
+var chainInfoFlag = &cli.PathFlag{
+	Name:     "chain-info",
+	Usage:    "Path to the chain info (JSON encoded) of the chain the beacons belong to",
+	Required: true,
+}
+
+var (
+	archiveFromFlag = &cli.Uint64Flag{
+		Name:  "from",
+		Usage: "First round to export",
+		Value: 1,
+	}
+	archiveToFlag = &cli.Uint64Flag{
+		Name:  "to",
+		Usage: "Last round to export, the latest round if 0",
+	}
+	archiveOutFlag = &cli.PathFlag{
+		Name:  "out",
+		Usage: "Path of the archive (newline delimited JSON) to write, standard output if empty",
+	}
+	archiveFormatFlag = &cli.StringFlag{
+		Name: "format",
+		Usage: fmt.Sprintf("Format of the export, %s, %s or %s. Only %s archives can be imported",
+			archive.FormatNDJSON, archive.FormatCSV, archive.FormatParquet, archive.FormatNDJSON),
+		Value: string(archive.FormatNDJSON),
+	}
+	archiveInFlag = &cli.PathFlag{
+		Name:     "in",
+		Usage:    "Path of the archive (newline delimited JSON) to read",
+		Required: true,
+	}
+	archiveOutDirFlag = &cli.PathFlag{
+		Name: "out-dir",
+		Usage: "Directory to write each imported beacon to, as ROUND.json. " +
+			"If empty, the archive is only verified",
+	}
+)
+
+var (
+	publicRangeFlag = &cli.StringFlag{
+		Name:  "range",
+		Usage: "Range of rounds to get, as FROM-TO (both included), after the ROUND arguments if any",
+	}
+	publicNDJSONFlag = &cli.BoolFlag{
+		Name:  "ndjson",
+		Usage: "Print several rounds as newline delimited JSON, one round per line, instead of a JSON array",
+	}
+	publicConcurrencyFlag = &cli.IntFlag{
+		Name:  "concurrency",
+		Usage: "Number of rounds fetched in parallel when getting several rounds",
+		Value: 8,
+	}
+)
+
+var checkAllRelaysFlag = &cli.BoolFlag{
+	Name: "all-relays",
+	Usage: "Also fetch the rounds from every relay and fail if the relays serve different signatures, " +
+		"which means a relay serves a fork of the chain or corrupted beacons",
+}
+
+var (
+	watchExecFlag = &cli.StringFlag{
+		Name: "exec",
+		Usage: "Command run by the shell for each new round, instead of printing the round, with {{round}}, " +
+			"{{randomness}}, {{signature}} and {{previous_signature}} replaced by the values of the round, " +
+			"also set in the DRAND_ROUND, DRAND_RANDOMNESS, DRAND_SIGNATURE and DRAND_PREVIOUS_SIGNATURE variables",
+	}
+	watchExecConcurrencyFlag = &cli.IntFlag{
+		Name:  "exec-concurrency",
+		Usage: "Maximum number of commands run at once with --exec, the next rounds waiting for one to finish",
+		Value: 1,
+	}
+)
+
+var (
+	proofOutFlag = &cli.PathFlag{
+		Name:  "out",
+		Usage: "Path of the proof bundle (JSON encoded) to write, standard output if empty",
+	}
+	proofChainHashFlag = &cli.StringFlag{
+		Name:     "chain-hash",
+		Usage:    "Hash (hex encoded) of the chain the proof bundles must belong to",
+		Required: true,
+	}
+)
+
+var (
+	reportDurationFlag = &cli.DurationFlag{
+		Name:  "duration",
+		Usage: "How long to probe the relays for",
+		Value: 10 * time.Minute,
+	}
+	reportIntervalFlag = &cli.DurationFlag{
+		Name:  "interval",
+		Usage: "Interval between two probes of each relay",
+		Value: 10 * time.Second,
+	}
+)
+
 var appCommands = []*cli.Command{
 	{
 		Name: "get",
@@ -35,19 +152,132 @@ var appCommands = []*cli.Command{
 				Usage: "Get the latest public randomness from the drand " +
 					"relay and verify it against the collective public key " +
 					"as specified in the chain-info.\n",
-				Flags:     toArray(lib.URLFlag, lib.JSONFlag, lib.InsecureFlag, lib.HashListFlag, lib.VerboseFlag),
-				ArgsUsage: "--url url1 --url url2 ROUND... uses the first working relay to query round number ROUND",
-				Action:    getPublicRandomness,
+				Flags: toArray(lib.URLFlag, lib.JSONFlag, lib.InsecureFlag, lib.HashListFlag, lib.VerboseFlag, lib.ConfigFlag,
+					publicRangeFlag, publicNDJSONFlag, publicConcurrencyFlag),
+				ArgsUsage: "--url url1 --url url2 [--range FROM-TO] [ROUND...] uses the first working relay to query " +
+					"the given rounds, or the latest one",
+				Before: lib.LoadConfig,
+				Action: getPublicRandomness,
 			},
 			{
 				Name:      "chain-info",
 				Usage:     "Get beacon information",
 				ArgsUsage: "--url url1 --url url2 ... uses the first working relay",
-				Flags:     toArray(lib.URLFlag, lib.JSONFlag, lib.InsecureFlag, lib.HashListFlag, lib.VerboseFlag),
+				Flags:     toArray(lib.URLFlag, lib.JSONFlag, lib.InsecureFlag, lib.HashListFlag, lib.VerboseFlag, lib.ConfigFlag),
+				Before:    lib.LoadConfig,
 				Action:    getChainInfo,
 			},
 		},
 	},
+	{
+		Name: "watch",
+		Usage: "Watch new public randomness from the drand relays as it is produced, " +
+			"printing each verified round as a line of JSON.\n",
+		Flags:     append(toArray(lib.MetricsFlag, watchExecFlag, watchExecConcurrencyFlag), lib.ClientFlags...),
+		ArgsUsage: "--url url1 --relay multiaddr1 ... watches the chain, optionally serving metrics with --metrics",
+		Before:    lib.LoadConfig,
+		Action:    watchRandomness,
+	},
+	{
+		Name: "verify",
+		Usage: "verify beacons (JSON encoded, as served by the drand HTTP API) " +
+			"against the chain info, without any network access.\n",
+		Flags: toArray(chainInfoFlag),
+		ArgsUsage: "--chain-info info.json BEACON_FILE... verifies each beacon file, or each line of the " +
+			"standard input (newline delimited JSON) if no file is given",
+		Action: verifyBeacons,
+	},
+	{
+		Name:  "proof",
+		Usage: "create and verify self-contained proofs of the randomness of a round.\n",
+		Subcommands: []*cli.Command{
+			{
+				Name: "create",
+				Usage: "Get a round from the drand relays, and write a proof bundle holding the beacon, " +
+					"its chain info and how to verify it, for third parties to verify it without any relay.\n",
+				Flags: toArray(lib.URLFlag, lib.InsecureFlag, lib.HashListFlag, lib.VerboseFlag, lib.ConfigFlag,
+					proofOutFlag),
+				ArgsUsage: "--url url1 --out proof.json [ROUND] bundles the given round, or the latest one",
+				Before:    lib.LoadConfig,
+				Action:    createProof,
+			},
+			{
+				Name:      "verify",
+				Usage:     "Verify proof bundles against the hash of the trusted chain, without any network access.\n",
+				Flags:     toArray(proofChainHashFlag),
+				ArgsUsage: "--chain-hash HASH BUNDLE_FILE... verifies each proof bundle",
+				Action:    verifyProofs,
+			},
+		},
+	},
+	{
+		Name: "check",
+		Usage: "Check rounds, the latest one by default, verifying them from the first working relay, and " +
+			"comparing the signatures served by all the relays with --all-relays.\n",
+		Flags:     append(toArray(checkAllRelaysFlag, publicRangeFlag), lib.ClientFlags...),
+		ArgsUsage: "--url url1 --url url2 --all-relays [--range FROM-TO] [ROUND...]",
+		Before:    lib.LoadConfig,
+		Action:    checkRounds,
+	},
+	{
+		Name:  "relays",
+		Usage: "inspect the drand relays.\n",
+		Subcommands: []*cli.Command{
+			{
+				Name: "report",
+				Usage: "Probe the relays for a while, and print a table ranking them by availability " +
+					"and latency.\n",
+				Flags:     append(toArray(reportDurationFlag, reportIntervalFlag), lib.ClientFlags...),
+				ArgsUsage: "--url url1 --url url2 ... --duration 10m probes each relay every --interval",
+				Before:    lib.LoadConfig,
+				Action:    reportRelays,
+			},
+		},
+	},
+	{
+		Name: "bench",
+		Usage: "Load the drand relays with concurrent requests for a while, and print the throughput and latency " +
+			"distribution of the Get requests and, with --watchers, of the watched rounds.\n",
+		Flags: append(toArray(benchConcurrencyFlag, benchDurationFlag, benchRateFlag, benchHistoryFlag,
+			benchWatchersFlag), lib.ClientFlags...),
+		ArgsUsage: "--url url1 --concurrency 64 --duration 1m --rate 100 gets the latest round, or random past " +
+			"rounds with --history",
+		Before: lib.LoadConfig,
+		Action: benchRelays,
+	},
+	{
+		Name: "doctor",
+		Usage: "Diagnose the connectivity to the drand relays: DNS resolution, TLS handshake, chain info, latency, " +
+			"chain hash and clock skew, printing a report with hints to fix the failed checks.\n",
+		Flags: append(toArray(lib.GRPCConnectFlag, lib.GRPCBeaconIDFlag, lib.GRPCMetadataFlag, doctorTimeoutFlag,
+			doctorNoColorFlag), lib.ClientFlags...),
+		ArgsUsage: "--url url1 --grpc-connect host:port --relay multiaddr1 ... checks each transport",
+		Before:    lib.LoadConfig,
+		Action:    diagnoseTransports,
+	},
+	{
+		Name:  "archive",
+		Usage: "export and import verified beacons as newline delimited JSON.\n",
+		Subcommands: []*cli.Command{
+			{
+				Name:  "export",
+				Usage: "Export a range of rounds fetched from the drand relays, verified against their chain info.\n",
+				Flags: toArray(lib.URLFlag, lib.InsecureFlag, lib.HashListFlag, lib.VerboseFlag, lib.ConfigFlag,
+					archiveFromFlag, archiveToFlag, archiveOutFlag, archiveFormatFlag),
+				ArgsUsage: "--url url1 --from N --to M --out beacons.ndjson [--format csv|parquet]",
+				Before:    lib.LoadConfig,
+				Action:    exportArchive,
+			},
+			{
+				Name: "import",
+				Usage: "Verify the beacons of an archive against the chain info without any network access, " +
+					"and write them to a directory.\n",
+				Flags:     toArray(chainInfoFlag, archiveInFlag, archiveOutDirFlag),
+				ArgsUsage: "--chain-info info.json --in beacons.ndjson --out-dir beacons/",
+				Action:    importArchive,
+			},
+		},
+	},
 }
 
 // CLI runs the drand app
@@ -106,27 +336,272 @@ func instantiateClient(cctx *cli.Context) (drand.Client, error) {
 }
 
 func getPublicRandomness(cctx *cli.Context) error {
+	rounds, err := parseRounds(cctx.Args().Slice(), cctx.String(publicRangeFlag.Name))
+	if err != nil {
+		return err
+	}
 	c, err := instantiateClient(cctx)
 	if err != nil {
 		return err
 	}
-	if cctx.Args().Len() > 1 {
-		log.Fatal("please specify a single round as positional argument")
+	defer c.Close()
+
+	if rounds.Len() <= 1 {
+		var r uint64
+		for round := range rounds.All() {
+			r = round
+		}
+		round, err := c.Get(cctx.Context, r)
+		if err != nil {
+			return err
+		}
+		return json.NewEncoder(cctx.App.Writer).Encode(round)
+	}
+
+	concurrency := cctx.Int(publicConcurrencyFlag.Name)
+	if concurrency < 1 {
+		return fmt.Errorf("invalid --%s %d, expected at least 1", publicConcurrencyFlag.Name, concurrency)
+	}
+	ndjson := cctx.Bool(publicNDJSONFlag.Name)
+	w := bufio.NewWriter(cctx.App.Writer)
+	i := 0
+	err = getRounds(cctx.Context, c, rounds.All(), concurrency, func(r drand.Result) error {
+		b, err := json.Marshal(r)
+		if err != nil {
+			return err
+		}
+		switch {
+		case ndjson:
+		case i == 0:
+			w.WriteString("[\n")
+		default:
+			w.WriteString(",\n")
+		}
+		i++
+		w.Write(b)
+		if ndjson {
+			w.WriteByte('\n')
+			// the rounds are printed as they are fetched
+			return w.Flush()
+		}
+		return nil
+	})
+	if !ndjson {
+		// the array is closed even after an error, so that the rounds printed
+		// are still valid JSON
+		if i == 0 {
+			w.WriteString("[")
+		}
+		w.WriteString("\n]\n")
+	}
+	if err != nil {
+		w.Flush()
+		return err
+	}
+	return w.Flush()
+}
+
+// roundList is the rounds given as arguments, followed by the ones of the
+// FROM-TO span, if any, which are iterated without being listed.
+type roundList struct {
+	args     []uint64
+	from, to uint64
+}
+
+// Len returns the number of rounds of the list.
+func (l roundList) Len() uint64 {
+	n := uint64(len(l.args))
+	if l.from != 0 {
+		n += l.to - l.from + 1
 	}
+	return n
+}
 
-	var r uint64
-	if val := cctx.Args().Get(0); val != "" {
-		r, err = strconv.ParseUint(val, 10, 64)
+// All iterates over the rounds of the list.
+func (l roundList) All() iter.Seq[uint64] {
+	return func(yield func(uint64) bool) {
+		for _, r := range l.args {
+			if !yield(r) {
+				return
+			}
+		}
+		if l.from == 0 {
+			return
+		}
+		for r := l.from; ; r++ {
+			if !yield(r) || r == l.to {
+				return
+			}
+		}
+	}
+}
+
+// parseRounds returns the rounds given as arguments, followed by the ones of
+// the FROM-TO span, if any.
+func parseRounds(args []string, span string) (roundList, error) {
+	var rounds roundList
+	for _, arg := range args {
+		r, err := strconv.ParseUint(arg, 10, 64)
 		if err != nil {
+			return roundList{}, fmt.Errorf("invalid round %q: %w", arg, err)
+		}
+		rounds.args = append(rounds.args, r)
+	}
+	if span == "" {
+		return rounds, nil
+	}
+
+	fromTo := strings.SplitN(span, "-", 2)
+	if len(fromTo) != 2 {
+		return roundList{}, fmt.Errorf("invalid range %q, expected FROM-TO", span)
+	}
+	from, err := strconv.ParseUint(strings.TrimSpace(fromTo[0]), 10, 64)
+	if err != nil {
+		return roundList{}, fmt.Errorf("invalid range %q: %w", span, err)
+	}
+	to, err := strconv.ParseUint(strings.TrimSpace(fromTo[1]), 10, 64)
+	if err != nil {
+		return roundList{}, fmt.Errorf("invalid range %q: %w", span, err)
+	}
+	if from == 0 || from > to {
+		return roundList{}, fmt.Errorf("invalid range %q: rounds start at 1 and FROM cannot be after TO", span)
+	}
+	rounds.from, rounds.to = from, to
+	return rounds, nil
+}
+
+// getRounds gets the rounds from c with up to concurrency Gets in flight, and
+// calls fn with each of them in order. It stops at the first error.
+func getRounds(ctx context.Context, c drand.Client, rounds iter.Seq[uint64], concurrency int, fn func(drand.Result) error) error {
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	type get struct {
+		round  uint64
+		result drand.Result
+		err    error
+		done   chan struct{}
+	}
+	// the queue bounds the Gets in flight, or waiting for the previous rounds
+	queue := make(chan *get, concurrency)
+	go func() {
+		defer close(queue)
+		for round := range rounds {
+			g := &get{round: round, done: make(chan struct{})}
+			select {
+			case queue <- g:
+			case <-ctx.Done():
+				return
+			}
+			go func() {
+				defer close(g.done)
+				g.result, g.err = c.Get(ctx, g.round)
+			}()
+		}
+	}()
+
+	for g := range queue {
+		<-g.done
+		if g.err != nil {
+			return fmt.Errorf("getting round %d: %w", g.round, g.err)
+		}
+		if err := fn(g.result); err != nil {
 			return err
 		}
 	}
+	return ctx.Err()
+}
 
-	round, err := c.Get(cctx.Context, r)
+func watchRandomness(cctx *cli.Context) error {
+	concurrency := cctx.Int(watchExecConcurrencyFlag.Name)
+	if concurrency < 1 {
+		return fmt.Errorf("invalid --%s %d, expected at least 1", watchExecConcurrencyFlag.Name, concurrency)
+	}
+	instrumented, err := lib.StartMetrics(cctx)
 	if err != nil {
 		return err
 	}
-	return json.NewEncoder(cctx.App.Writer).Encode(round)
+	c, err := lib.Create(cctx, instrumented)
+	if err != nil {
+		return fmt.Errorf("constructing client: %w", err)
+	}
+	defer c.Close()
+
+	if command := cctx.String(watchExecFlag.Name); command != "" {
+		execRounds(cctx.Context, c.Watch(cctx.Context), command, concurrency, cctx.App.Writer, cctx.App.ErrWriter)
+		return cctx.Context.Err()
+	}
+	enc := json.NewEncoder(cctx.App.Writer)
+	for r := range c.Watch(cctx.Context) {
+		if err := enc.Encode(r); err != nil {
+			return err
+		}
+	}
+	return cctx.Context.Err()
+}
+
+// execRounds runs command for each of the rounds, at most concurrency at once,
+// until the rounds channel is closed and the commands are done. The failures of
+// the commands are reported to stderr.
+func execRounds(ctx context.Context, rounds <-chan drand.Result, command string, concurrency int, stdout, stderr io.Writer) {
+	stdout, stderr = &lockedWriter{w: stdout}, &lockedWriter{w: stderr}
+	slots := make(chan struct{}, concurrency)
+	var wg sync.WaitGroup
+	defer wg.Wait()
+	for r := range rounds {
+		slots <- struct{}{}
+		wg.Add(1)
+		go func() {
+			defer func() {
+				<-slots
+				wg.Done()
+			}()
+			cmd := roundCommand(ctx, command, r)
+			cmd.Stdout, cmd.Stderr = stdout, stderr
+			if err := cmd.Run(); err != nil {
+				fmt.Fprintf(stderr, "round %d: command failed: %v\n", r.GetRound(), err)
+			}
+		}()
+	}
+}
+
+// roundCommand returns the shell command running command for the round r.
+func roundCommand(ctx context.Context, command string, r drand.Result) *exec.Cmd {
+	values := []string{
+		"round", strconv.FormatUint(r.GetRound(), 10),
+		"randomness", hex.EncodeToString(r.GetRandomness()),
+		"signature", hex.EncodeToString(r.GetSignature()),
+		"previous_signature", hex.EncodeToString(r.GetPreviousSignature()),
+	}
+	placeholders := make([]string, 0, len(values))
+	env := os.Environ()
+	for i := 0; i < len(values); i += 2 {
+		placeholders = append(placeholders, "{{"+values[i]+"}}", values[i+1])
+		env = append(env, "DRAND_"+strings.ToUpper(values[i])+"="+values[i+1])
+	}
+	// the values are only digits and hex, which need no quoting
+	line := strings.NewReplacer(placeholders...).Replace(command)
+
+	var cmd *exec.Cmd
+	if runtime.GOOS == "windows" {
+		cmd = exec.CommandContext(ctx, "cmd", "/C", line)
+	} else {
+		cmd = exec.CommandContext(ctx, "sh", "-c", line)
+	}
+	cmd.Env = env
+	return cmd
+}
+
+// lockedWriter serializes the writes of concurrent commands.
+type lockedWriter struct {
+	lk sync.Mutex
+	w  io.Writer
+}
+
+func (l *lockedWriter) Write(p []byte) (int, error) {
+	l.lk.Lock()
+	defer l.lk.Unlock()
+	return l.w.Write(p)
 }
 
 func getChainInfo(cctx *cli.Context) error {
@@ -142,3 +617,316 @@ func getChainInfo(cctx *cli.Context) error {
 
 	return info.ToJSON(cctx.App.Writer, nil)
 }
+
+func verifyBeacons(cctx *cli.Context) error {
+	infoJSON, err := os.ReadFile(cctx.Path(chainInfoFlag.Name))
+	if err != nil {
+		return fmt.Errorf("reading chain info: %w", err)
+	}
+	info, err := chain.InfoFromJSON(bytes.NewReader(infoJSON))
+	if err != nil {
+		return fmt.Errorf("decoding chain info: %w", err)
+	}
+	v, err := verify.NewVerifier(info)
+	if err != nil {
+		return err
+	}
+	if cctx.Args().Len() == 0 {
+		return verifyBeaconStream(cctx, v)
+	}
+
+	var failed int
+	for _, p := range cctx.Args().Slice() {
+		beacon, err := os.ReadFile(p)
+		if err == nil {
+			err = v.BeaconJSON(beacon)
+		}
+		if err != nil {
+			failed++
+			fmt.Fprintf(cctx.App.Writer, "%s: FAIL: %v\n", p, err)
+			continue
+		}
+		fmt.Fprintf(cctx.App.Writer, "%s: OK\n", p)
+	}
+	if failed > 0 {
+		return fmt.Errorf("%d of %d beacons failed verification", failed, cctx.Args().Len())
+	}
+	return nil
+}
+
+func createProof(cctx *cli.Context) error {
+	var round uint64
+	switch cctx.Args().Len() {
+	case 0:
+	case 1:
+		r, err := strconv.ParseUint(cctx.Args().First(), 10, 64)
+		if err != nil {
+			return fmt.Errorf("invalid round %q: %w", cctx.Args().First(), err)
+		}
+		round = r
+	default:
+		return errors.New("please specify at most one round")
+	}
+	c, err := instantiateClient(cctx)
+	if err != nil {
+		return err
+	}
+	defer c.Close()
+
+	info, err := c.Info(cctx.Context)
+	if err != nil {
+		return err
+	}
+	r, err := c.Get(cctx.Context, round)
+	if err != nil {
+		return err
+	}
+	bundle, err := verify.NewBundle(info, r)
+	if err != nil {
+		return err
+	}
+	b, err := json.MarshalIndent(bundle, "", "  ")
+	if err != nil {
+		return err
+	}
+	b = append(b, '\n')
+	if p := cctx.Path(proofOutFlag.Name); p != "" {
+		return os.WriteFile(p, b, 0o644)
+	}
+	_, err = cctx.App.Writer.Write(b)
+	return err
+}
+
+func verifyProofs(cctx *cli.Context) error {
+	if cctx.Args().Len() == 0 {
+		return errors.New("please specify at least one proof bundle to verify")
+	}
+	chainHash, err := hex.DecodeString(cctx.String(proofChainHashFlag.Name))
+	if err != nil || len(chainHash) == 0 {
+		return fmt.Errorf("invalid --%s %q", proofChainHashFlag.Name, cctx.String(proofChainHashFlag.Name))
+	}
+
+	var failed int
+	for _, p := range cctx.Args().Slice() {
+		var bundle verify.Bundle
+		b, err := os.ReadFile(p)
+		if err == nil {
+			err = json.Unmarshal(b, &bundle)
+		}
+		if err == nil {
+			err = bundle.Verify(chainHash)
+		}
+		if err != nil {
+			failed++
+			fmt.Fprintf(cctx.App.Writer, "%s: FAIL: %v\n", p, err)
+			continue
+		}
+		fmt.Fprintf(cctx.App.Writer, "%s: OK, round %d\n", p, bundle.Beacon.GetRound())
+	}
+	if failed > 0 {
+		return fmt.Errorf("%d of %d proof bundles failed verification", failed, cctx.Args().Len())
+	}
+	return nil
+}
+
+// verifyBeaconStream verifies the beacons read from the standard input, one
+// per line, and reports the outcome of each line.
+func verifyBeaconStream(cctx *cli.Context, v *verify.Verifier) error {
+	sc := bufio.NewScanner(cctx.App.Reader)
+	sc.Buffer(make([]byte, 0, 4096), 64*1024)
+	w := bufio.NewWriter(cctx.App.Writer)
+	defer w.Flush()
+
+	var line, n, failed int
+	for sc.Scan() {
+		line++
+		b := bytes.TrimSpace(sc.Bytes())
+		if len(b) == 0 {
+			continue
+		}
+		n++
+		if err := v.BeaconJSON(b); err != nil {
+			failed++
+			fmt.Fprintf(w, "line %d: FAIL: %v\n", line, err)
+			continue
+		}
+		fmt.Fprintf(w, "line %d: OK\n", line)
+	}
+	if err := sc.Err(); err != nil {
+		return fmt.Errorf("line %d: reading beacons: %w", line+1, err)
+	}
+	if failed > 0 {
+		return fmt.Errorf("%d of %d beacons failed verification", failed, n)
+	}
+	fmt.Fprintf(w, "verified %d beacons: OK\n", n)
+	return nil
+}
+
+func checkRounds(cctx *cli.Context) error {
+	rounds, err := parseRounds(cctx.Args().Slice(), cctx.String(publicRangeFlag.Name))
+	if err != nil {
+		return err
+	}
+	c, err := instantiateClient(cctx)
+	if err != nil {
+		return err
+	}
+	defer c.Close()
+	checker, ok := c.(drand.ForkChecker)
+	if !ok && cctx.Bool(checkAllRelaysFlag.Name) {
+		return errors.New("the client cannot compare its relays")
+	}
+
+	if rounds.Len() == 0 {
+		rounds.args = []uint64{0}
+	}
+	w := cctx.App.Writer
+	failed := 0
+	for r := range rounds.All() {
+		if r == 0 {
+			latest, err := c.Get(cctx.Context, 0)
+			if err != nil {
+				return fmt.Errorf("getting the latest round: %w", err)
+			}
+			r = latest.GetRound()
+		}
+		if _, err := c.Get(cctx.Context, r); err != nil {
+			fmt.Fprintf(w, "round %d: %v\n", r, err)
+			failed++
+			continue
+		}
+		if !cctx.Bool(checkAllRelaysFlag.Name) {
+			fmt.Fprintf(w, "round %d: OK\n", r)
+			continue
+		}
+		// the round verifies, compare the signatures of the relays
+		n, err := checker.CheckRound(cctx.Context, r)
+		if err != nil {
+			fmt.Fprintf(w, "round %d: %v\n", r, err)
+			failed++
+			continue
+		}
+		fmt.Fprintf(w, "round %d: OK, same signature from %d relays\n", r, n)
+	}
+	if failed > 0 {
+		return fmt.Errorf("%d of %d rounds failed the check", failed, rounds.Len())
+	}
+	return nil
+}
+
+func reportRelays(cctx *cli.Context) error {
+	c, err := lib.Create(cctx, false, client.WithSpeedTestInterval(cctx.Duration(reportIntervalFlag.Name)))
+	if err != nil {
+		return fmt.Errorf("constructing client: %w", err)
+	}
+	defer c.Close()
+	reporter, ok := c.(drand.UpstreamReporter)
+	if !ok {
+		return errors.New("the client does not report on its relays")
+	}
+
+	t := time.NewTimer(cctx.Duration(reportDurationFlag.Name))
+	defer t.Stop()
+	select {
+	case <-t.C:
+	case <-cctx.Context.Done():
+		return cctx.Context.Err()
+	}
+
+	stats := reporter.UpstreamStats()
+	sort.SliceStable(stats, func(i, j int) bool {
+		if a, b := stats[i].SuccessRate(), stats[j].SuccessRate(); a != b {
+			return a > b
+		}
+		return stats[i].LatencyP50 < stats[j].LatencyP50
+	})
+
+	w := tabwriter.NewWriter(cctx.App.Writer, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, "RANK\tRELAY\tSUCCESS\tP50\tP95\tROUNDS\tCIRCUIT\tLAST FAILURE")
+	for i, s := range stats {
+		lastFailure := "-"
+		if s.LastFailure != "" {
+			lastFailure = s.LastFailureTime.UTC().Format(time.RFC3339) + " " + s.LastFailure
+		}
+		fmt.Fprintf(w, "%d\t%s\t%.1f%%\t%s\t%s\t%d\t%s\t%s\n", i+1, s.Upstream, 100*s.SuccessRate(),
+			s.LatencyP50.Round(time.Millisecond), s.LatencyP95.Round(time.Millisecond), s.RoundsServed, s.Circuit, lastFailure)
+	}
+	return w.Flush()
+}
+
+func exportArchive(cctx *cli.Context) error {
+	// checked before creating the output file
+	format := archive.Format(cctx.String(archiveFormatFlag.Name))
+	if format != archive.FormatNDJSON && format != archive.FormatCSV && format != archive.FormatParquet {
+		return fmt.Errorf("unsupported --%s %q, expected %s, %s or %s", archiveFormatFlag.Name, format,
+			archive.FormatNDJSON, archive.FormatCSV, archive.FormatParquet)
+	}
+	c, err := instantiateClient(cctx)
+	if err != nil {
+		return err
+	}
+	defer c.Close()
+
+	var f *os.File
+	w := cctx.App.Writer
+	if p := cctx.Path(archiveOutFlag.Name); p != "" {
+		if f, err = os.Create(p); err != nil {
+			return fmt.Errorf("creating archive: %w", err)
+		}
+		w = f
+	}
+
+	n, err := archive.ExportFormat(cctx.Context, c, w, cctx.Uint64(archiveFromFlag.Name), cctx.Uint64(archiveToFlag.Name), format)
+	if f != nil {
+		if cerr := f.Close(); err == nil {
+			err = cerr
+		}
+	}
+	if err != nil {
+		return fmt.Errorf("exported %d beacons: %w", n, err)
+	}
+	if f != nil {
+		fmt.Fprintf(cctx.App.ErrWriter, "exported %d beacons to %s\n", n, f.Name())
+	}
+	return nil
+}
+
+func importArchive(cctx *cli.Context) error {
+	f, err := os.Open(cctx.Path(chainInfoFlag.Name))
+	if err != nil {
+		return fmt.Errorf("reading chain info: %w", err)
+	}
+	info, err := chain.InfoFromJSON(f)
+	f.Close()
+	if err != nil {
+		return fmt.Errorf("decoding chain info: %w", err)
+	}
+
+	in, err := os.Open(cctx.Path(archiveInFlag.Name))
+	if err != nil {
+		return fmt.Errorf("opening archive: %w", err)
+	}
+	defer in.Close()
+
+	dir := cctx.Path(archiveOutDirFlag.Name)
+	if dir != "" {
+		if err := os.MkdirAll(dir, 0o755); err != nil {
+			return fmt.Errorf("creating output directory: %w", err)
+		}
+	}
+	n, err := archive.Read(in, info, func(beacon *client.RandomData) error {
+		if dir == "" {
+			return nil
+		}
+		b, err := json.Marshal(beacon)
+		if err != nil {
+			return err
+		}
+		return os.WriteFile(filepath.Join(dir, fmt.Sprintf("%d.json", beacon.GetRound())), b, 0o644)
+	})
+	if err != nil {
+		return fmt.Errorf("imported %d beacons: %w", n, err)
+	}
+	fmt.Fprintf(cctx.App.Writer, "imported %d beacons: OK\n", n)
+	return nil
+}
@@ -2,10 +2,29 @@ package drand
 
 import (
 	"bytes"
+	"context"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"math"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"runtime"
+	"slices"
 	"strings"
 	"testing"
+	"time"
 
 	"github.com/stretchr/testify/require"
+
+	"github.com/drand/drand/v2/common/chain"
+	"github.com/drand/drand/v2/crypto"
+	"github.com/drand/go-clients/client"
+	"github.com/drand/go-clients/client/test/result/mock"
+	"github.com/drand/go-clients/clienttest"
+	"github.com/drand/go-clients/drand"
 )
 
 func TestClientTLS(t *testing.T) {
@@ -34,3 +53,302 @@ func testCommand(t *testing.T, args []string, exp string) {
 	t.Logf("RUNNING: %v\n", args)
 	require.Contains(t, strings.Trim(buff.String(), "\n"), exp)
 }
+
+func TestVerifyCommand(t *testing.T) {
+	sch, err := crypto.GetSchemeFromEnv()
+	require.NoError(t, err)
+	info, results := mock.VerifiableResults(2, sch)
+
+	dir := t.TempDir()
+	infoPath := filepath.Join(dir, "info.json")
+	f, err := os.Create(infoPath)
+	require.NoError(t, err)
+	require.NoError(t, info.ToJSON(f, nil))
+	require.NoError(t, f.Close())
+
+	beaconPath := filepath.Join(dir, "beacon.json")
+	beacon, err := json.Marshal(&client.RandomData{
+		Rnd:               results[1].GetRound(),
+		Sig:               results[1].GetSignature(),
+		PreviousSignature: results[1].GetPreviousSignature(),
+	})
+	require.NoError(t, err)
+	require.NoError(t, os.WriteFile(beaconPath, beacon, 0o600))
+
+	testCommand(t, []string{"drand", "verify", "--chain-info", infoPath, beaconPath}, beaconPath+": OK")
+
+	badPath := filepath.Join(dir, "bad.json")
+	require.NoError(t, os.WriteFile(badPath, []byte(`{"round":5,"signature":"00"}`), 0o600))
+	require.Error(t, CLI().Run([]string{"drand", "verify", "--chain-info", infoPath, beaconPath, badPath}))
+
+	// the beacons of the standard input are verified line by line
+	var buff bytes.Buffer
+	app := CLI()
+	app.Writer = &buff
+	app.Reader = strings.NewReader(string(beacon) + "\n\n" + string(beacon) + "\n")
+	require.NoError(t, app.Run([]string{"drand", "verify", "--chain-info", infoPath}))
+	require.Equal(t, "line 1: OK\nline 3: OK\nverified 2 beacons: OK\n", buff.String())
+
+	buff.Reset()
+	app.Reader = strings.NewReader(string(beacon) + "\n" + `{"round":5,"signature":"00"}` + "\n")
+	require.Error(t, app.Run([]string{"drand", "verify", "--chain-info", infoPath}))
+	require.Contains(t, buff.String(), "line 1: OK\nline 2: FAIL")
+}
+
+func TestArchiveImportCommand(t *testing.T) {
+	sch, err := crypto.GetSchemeFromEnv()
+	require.NoError(t, err)
+	info, results := mock.VerifiableResults(3, sch)
+
+	dir := t.TempDir()
+	infoPath := filepath.Join(dir, "info.json")
+	f, err := os.Create(infoPath)
+	require.NoError(t, err)
+	require.NoError(t, info.ToJSON(f, nil))
+	require.NoError(t, f.Close())
+
+	var archive bytes.Buffer
+	for _, r := range results {
+		beacon, err := json.Marshal(&client.RandomData{
+			Rnd:               r.GetRound(),
+			Sig:               r.GetSignature(),
+			PreviousSignature: r.GetPreviousSignature(),
+		})
+		require.NoError(t, err)
+		archive.Write(beacon)
+		archive.WriteString("\n")
+	}
+	archivePath := filepath.Join(dir, "beacons.ndjson")
+	require.NoError(t, os.WriteFile(archivePath, archive.Bytes(), 0o600))
+
+	outDir := filepath.Join(dir, "beacons")
+	testCommand(t, []string{"drand", "archive", "import", "--chain-info", infoPath, "--in", archivePath, "--out-dir", outDir},
+		"imported 3 beacons: OK")
+	testCommand(t, []string{"drand", "verify", "--chain-info", infoPath, filepath.Join(outDir, "2.json")}, "OK")
+
+	badPath := filepath.Join(dir, "bad.ndjson")
+	require.NoError(t, os.WriteFile(badPath, []byte(`{"round":5,"signature":"00"}`+"\n"), 0o600))
+	require.Error(t, CLI().Run([]string{"drand", "archive", "import", "--chain-info", infoPath, "--in", badPath}))
+}
+
+func TestProofCommands(t *testing.T) {
+	relay := clienttest.NewRelay(t)
+	dir := t.TempDir()
+	proofPath := filepath.Join(dir, "proof.json")
+	require.NoError(t, CLI().Run([]string{"drand", "proof", "create", "--url", relay.URL(), "--insecure", "--out", proofPath, "3"}))
+
+	chainHash := hex.EncodeToString(relay.Info().Hash())
+	testCommand(t, []string{"drand", "proof", "verify", "--chain-hash", chainHash, proofPath}, proofPath+": OK, round 3")
+
+	otherHash := hex.EncodeToString(make([]byte, 32))
+	require.Error(t, CLI().Run([]string{"drand", "proof", "verify", "--chain-hash", otherHash, proofPath}))
+	require.Error(t, CLI().Run([]string{"drand", "proof", "create", "--url", relay.URL(), "--insecure", "1", "2"}))
+}
+
+func TestWatchExec(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("the test commands need a POSIX shell")
+	}
+	sch, err := crypto.GetSchemeFromEnv()
+	require.NoError(t, err)
+	_, results := mock.VerifiableResults(3, sch)
+
+	rounds := make(chan drand.Result, len(results))
+	for i := range results {
+		rounds <- &results[i]
+	}
+	close(rounds)
+	var stdout, stderr bytes.Buffer
+	command := `[ {{round}} -ne 2 ] && echo {{round}} {{randomness}} $DRAND_ROUND`
+	execRounds(context.Background(), rounds, command, 2, &stdout, &stderr)
+
+	lines := strings.Split(strings.TrimSpace(stdout.String()), "\n")
+	slices.Sort(lines)
+	require.Equal(t, []string{
+		fmt.Sprintf("1 %x 1", results[0].GetRandomness()),
+		fmt.Sprintf("3 %x 3", results[2].GetRandomness()),
+	}, lines)
+	require.Contains(t, stderr.String(), "round 2: command failed")
+}
+
+func TestRelaysReportCommand(t *testing.T) {
+	relay := clienttest.NewRelay(t)
+	// a relay serving the chain info, but no round
+	broken := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if !strings.HasSuffix(r.URL.Path, "/info") {
+			http.Error(w, "unavailable", http.StatusServiceUnavailable)
+			return
+		}
+		relay.ServeHTTP(w, r)
+	}))
+	defer broken.Close()
+
+	var buff bytes.Buffer
+	app := CLI()
+	app.Writer = &buff
+	require.NoError(t, app.Run([]string{"drand", "relays", "report", "--url", broken.URL, "--url", relay.URL(),
+		"--hash", relay.Info().HashString(), "--duration", "200ms", "--interval", "20ms"}))
+
+	lines := strings.Split(strings.TrimSpace(buff.String()), "\n")
+	require.Len(t, lines, 3)
+	require.Contains(t, lines[0], "RANK")
+	require.Contains(t, lines[1], relay.URL())
+	require.Contains(t, lines[1], "100.0%")
+	require.Contains(t, lines[2], broken.URL)
+	require.Contains(t, lines[2], "0.0%")
+	require.Contains(t, lines[2], "503")
+}
+
+func TestBenchCommand(t *testing.T) {
+	relay := clienttest.NewRelay(t)
+
+	var buff bytes.Buffer
+	app := CLI()
+	app.Writer = &buff
+	require.NoError(t, app.Run([]string{"drand", "bench", "--url", relay.URL(), "--hash", relay.Info().HashString(),
+		"--duration", "200ms", "--concurrency", "4", "--rate", "100", "--history", "--watchers", "1"}))
+
+	lines := strings.Split(strings.TrimSpace(buff.String()), "\n")
+	require.Len(t, lines, 3)
+	require.Contains(t, lines[0], "THROUGHPUT")
+	fields := strings.Fields(lines[1])
+	require.Equal(t, "get", fields[0])
+	require.NotEqual(t, "0", fields[1])
+	require.Equal(t, "0", fields[2])
+	require.True(t, strings.HasPrefix(lines[2], "watch"))
+
+	err := app.Run([]string{"drand", "bench", "--url", relay.URL(), "--rate", "0"})
+	require.ErrorContains(t, err, "--rate must be positive")
+}
+
+func TestDoctorCommand(t *testing.T) {
+	relay := clienttest.NewRelay(t)
+	other := clienttest.NewRelay(t)
+
+	var buff bytes.Buffer
+	app := CLI()
+	app.Writer = &buff
+	require.NoError(t, app.Run([]string{"drand", "doctor", "--url", relay.URL(), "--hash-list", relay.Info().HashString()}))
+	out := buff.String()
+	require.Contains(t, out, "HTTP "+relay.URL())
+	require.Regexp(t, `SKIP  dns +IP address`, out)
+	require.Regexp(t, `OK    info`, out)
+	require.Regexp(t, `OK    chain +`+relay.Info().HashString(), out)
+	require.Regexp(t, `OK    latest +round 10 `, out)
+	require.NotContains(t, out, "\x1b[", "no colors outside of terminals")
+
+	buff.Reset()
+	err := app.Run([]string{"drand", "doctor", "--url", relay.URL(), "--url", other.URL(), "--url", "http://127.0.0.1:1"})
+	require.ErrorContains(t, err, "2 checks failed")
+	out = buff.String()
+	require.Regexp(t, `WARN  chain +`+relay.Info().HashString()+", not checked", out)
+	require.Regexp(t, `FAIL  chain +`+other.Info().HashString()+", other transports serve "+relay.Info().HashString(), out)
+	require.Contains(t, out, "hint: the transports serve different chains")
+	require.Regexp(t, `FAIL  info +.*connection refused`, out)
+}
+
+func TestCheckSchedule(t *testing.T) {
+	info := &chain.Info{Period: 3 * time.Second, GenesisTime: 1_000_000}
+	now := time.Unix(1_000_000+30, 0)
+	for _, tc := range []struct {
+		latest uint64
+		status checkStatus
+		detail string
+	}{
+		{latest: 11, status: checkOK},
+		{latest: 10, status: checkOK},
+		{latest: 12, status: checkWarn, detail: "the local clock is at least 3s behind"},
+		{latest: 9, status: checkWarn, detail: "the latest round is 6s old"},
+	} {
+		diag := &diagnosis{}
+		checkSchedule(diag, info, tc.latest, now)
+		require.Len(t, diag.checks, 1)
+		require.Equal(t, tc.status, diag.checks[0].status, "round %d", tc.latest)
+		require.Contains(t, diag.checks[0].detail, tc.detail)
+	}
+}
+
+func TestGetPublicRounds(t *testing.T) {
+	relay := clienttest.NewRelay(t)
+	args := []string{"drand", "get", "public", "--url", relay.URL(), "--insecure"}
+
+	var buff bytes.Buffer
+	app := CLI()
+	app.Writer = &buff
+	require.NoError(t, app.Run(append(args, "--range", "4-6", "2")))
+	var beacons []client.RandomData
+	require.NoError(t, json.Unmarshal(buff.Bytes(), &beacons))
+	require.Len(t, beacons, 4)
+	for i, round := range []uint64{2, 4, 5, 6} {
+		require.Equal(t, round, beacons[i].GetRound())
+	}
+
+	buff.Reset()
+	require.NoError(t, app.Run(append(args, "--ndjson", "--concurrency", "2", "3", "1")))
+	lines := strings.Split(strings.TrimSpace(buff.String()), "\n")
+	require.Len(t, lines, 2)
+	for i, round := range []uint64{3, 1} {
+		var beacon client.RandomData
+		require.NoError(t, json.Unmarshal([]byte(lines[i]), &beacon))
+		require.Equal(t, round, beacon.GetRound())
+	}
+
+	require.Error(t, CLI().Run(append(args, "--range", "6-4")))
+
+	// the rounds printed before an error are still a JSON array
+	buff.Reset()
+	require.Error(t, app.Run(append(args, "--concurrency", "1", "--range", "4-20")))
+	beacons = nil
+	require.NoError(t, json.Unmarshal(buff.Bytes(), &beacons))
+	for i, beacon := range beacons {
+		require.Equal(t, uint64(4+i), beacon.GetRound())
+	}
+}
+
+func TestParseRounds(t *testing.T) {
+	rounds, err := parseRounds([]string{"7", "1"}, "3-5")
+	require.NoError(t, err)
+	require.Equal(t, uint64(5), rounds.Len())
+	require.Equal(t, []uint64{7, 1, 3, 4, 5}, slices.Collect(rounds.All()))
+
+	rounds, err = parseRounds(nil, "")
+	require.NoError(t, err)
+	require.Zero(t, rounds.Len())
+	require.Empty(t, slices.Collect(rounds.All()))
+
+	// the rounds of a span are not listed
+	rounds, err = parseRounds(nil, "1-18446744073709551615")
+	require.NoError(t, err)
+	require.Equal(t, uint64(math.MaxUint64), rounds.Len())
+	var first []uint64
+	for r := range rounds.All() {
+		if first = append(first, r); len(first) == 3 {
+			break
+		}
+	}
+	require.Equal(t, []uint64{1, 2, 3}, first)
+
+	for _, span := range []string{"5", "0-3", "5-3", "a-3", "3-"} {
+		_, err := parseRounds(nil, span)
+		require.Error(t, err, span)
+	}
+	_, err = parseRounds([]string{"x"}, "")
+	require.Error(t, err)
+}
+
+func TestCheckCommand(t *testing.T) {
+	relay := clienttest.NewRelay(t)
+	// the same relay twice, as two relays serving the same chain
+	alias := strings.Replace(relay.URL(), "127.0.0.1", "localhost", 1)
+
+	var buff bytes.Buffer
+	app := CLI()
+	app.Writer = &buff
+	require.NoError(t, app.Run([]string{"drand", "check", "--url", relay.URL(), "--url", alias,
+		"--hash", relay.Info().HashString(), "--all-relays", "3", "5"}))
+	require.Equal(t, "round 3: OK, same signature from 2 relays\nround 5: OK, same signature from 2 relays\n", buff.String())
+
+	buff.Reset()
+	err := app.Run([]string{"drand", "check", "--url", relay.URL(), "--hash", relay.Info().HashString(), "1000"})
+	require.ErrorContains(t, err, "1 of 1 rounds failed the check")
+}
@@ -0,0 +1,470 @@
+package drand
+
+import (
+	"bytes"
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/url"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/urfave/cli/v2"
+
+	"github.com/drand/drand/v2/common"
+	"github.com/drand/drand/v2/common/chain"
+	"github.com/drand/drand/v2/common/log"
+	http2 "github.com/drand/go-clients/client/http"
+	"github.com/drand/go-clients/drand"
+	"github.com/drand/go-clients/internal/grpc"
+	"github.com/drand/go-clients/internal/lib"
+)
+
+var (
+	doctorTimeoutFlag = &cli.DurationFlag{
+		Name:  "timeout",
+		Usage: "How long each check can take",
+		Value: 10 * time.Second,
+	}
+	doctorNoColorFlag = &cli.BoolFlag{
+		Name:    "no-color",
+		Usage:   "Print the report without colors, which are only used on terminals",
+		EnvVars: []string{"NO_COLOR"},
+	}
+)
+
+// certExpiryWarning is how close to its expiry a certificate is reported.
+const certExpiryWarning = 14 * 24 * time.Hour
+
+// checkStatus is the outcome of a diagnostic check.
+type checkStatus int
+
+const (
+	checkOK checkStatus = iota
+	checkSkip
+	checkWarn
+	checkFail
+)
+
+func (s checkStatus) String() string {
+	switch s {
+	case checkOK:
+		return "OK"
+	case checkSkip:
+		return "SKIP"
+	case checkWarn:
+		return "WARN"
+	default:
+		return "FAIL"
+	}
+}
+
+// color is the ANSI escape sequence coloring the status on terminals.
+func (s checkStatus) color() string {
+	switch s {
+	case checkOK:
+		return "\x1b[32m"
+	case checkSkip:
+		return "\x1b[90m"
+	case checkWarn:
+		return "\x1b[33m"
+	default:
+		return "\x1b[31m"
+	}
+}
+
+// check is a diagnostic check of a transport, with a hint to fix it when it
+// did not pass.
+type check struct {
+	name   string
+	status checkStatus
+	detail string
+	hint   string
+}
+
+// diagnosis are the checks of a transport.
+type diagnosis struct {
+	transport string
+	checks    []check
+}
+
+// add records a check, with its detail on a single line.
+func (d *diagnosis) add(name string, status checkStatus, detail, hint string) {
+	detail = strings.ReplaceAll(strings.TrimSpace(detail), "\n", "; ")
+	d.checks = append(d.checks, check{name: name, status: status, detail: detail, hint: hint})
+}
+
+// doctor holds the state shared by the checks of all the transports.
+type doctor struct {
+	cctx    *cli.Context
+	log     log.Logger
+	timeout time.Duration
+	// expected are the hashes of the chains given with the flags, if any.
+	expected [][]byte
+	// reference is the chain of the first transport, to compare the others
+	// with when no chain is expected.
+	reference *chain.Info
+}
+
+func diagnoseTransports(cctx *cli.Context) error {
+	d := &doctor{
+		cctx:    cctx,
+		log:     log.New(nil, log.ErrorLevel, false),
+		timeout: cctx.Duration(doctorTimeoutFlag.Name),
+	}
+	hashes := cctx.StringSlice(lib.HashListFlag.Name)
+	if h := cctx.String(lib.HashFlag.Name); h != "" {
+		hashes = append(hashes, h)
+	}
+	for _, h := range hashes {
+		hash, err := hex.DecodeString(h)
+		if err != nil {
+			return fmt.Errorf("invalid chain hash %q: %w", h, err)
+		}
+		d.expected = append(d.expected, hash)
+	}
+	if paths := cctx.StringSlice(lib.GroupConfListFlag.Name); len(paths) > 0 {
+		infos, err := lib.ChainInfosFromGroupConfs(d.log, paths)
+		if err != nil {
+			return err
+		}
+		for _, info := range infos {
+			d.expected = append(d.expected, info.Hash())
+		}
+	}
+
+	var diagnoses []*diagnosis
+	for _, u := range cctx.StringSlice(lib.URLFlag.Name) {
+		diagnoses = append(diagnoses, d.diagnoseHTTP(u))
+	}
+	if addr := cctx.String(lib.GRPCConnectFlag.Name); addr != "" {
+		diagnoses = append(diagnoses, d.diagnoseGRPC(addr))
+	}
+	for _, addr := range cctx.StringSlice(lib.RelayFlag.Name) {
+		diagnoses = append(diagnoses, d.diagnoseRelay(addr))
+	}
+	if len(diagnoses) == 0 {
+		return fmt.Errorf("nothing to diagnose: use --%s, --%s or --%s",
+			lib.URLFlag.Name, lib.GRPCConnectFlag.Name, lib.RelayFlag.Name)
+	}
+
+	colored := !cctx.Bool(doctorNoColorFlag.Name) && isTerminal(cctx.App.Writer)
+	failed, warned := printDiagnoses(cctx.App.Writer, diagnoses, colored)
+	if failed > 0 {
+		return fmt.Errorf("%d checks failed", failed)
+	}
+	if warned > 0 {
+		fmt.Fprintf(cctx.App.Writer, "all checks passed, %d with warnings\n", warned)
+		return nil
+	}
+	fmt.Fprintln(cctx.App.Writer, "all checks passed")
+	return nil
+}
+
+// checkContext returns a context bounding a check by the timeout.
+func (d *doctor) checkContext() (context.Context, context.CancelFunc) {
+	return context.WithTimeout(d.cctx.Context, d.timeout)
+}
+
+func (d *doctor) diagnoseHTTP(rawURL string) *diagnosis {
+	diag := &diagnosis{transport: "HTTP " + rawURL}
+	if path, ok := strings.CutPrefix(rawURL, "unix://"); ok {
+		if _, err := os.Stat(path); err != nil {
+			diag.add("socket", checkFail, err.Error(), "check that the relay is running and listening on this socket")
+			return diag
+		}
+		diag.add("socket", checkOK, path, "")
+	} else {
+		u, err := url.Parse(rawURL)
+		if err != nil || u.Host == "" {
+			diag.add("url", checkFail, fmt.Sprintf("invalid URL %q", rawURL), "use the root URL of the relay, e.g. https://api.drand.sh")
+			return diag
+		}
+		addrs := d.checkDNS(diag, u.Hostname())
+		if addrs == nil {
+			return diag
+		}
+		if u.Scheme == "https" && !d.checkTLS(diag, u, addrs[0]) {
+			return diag
+		}
+	}
+
+	hopts, err := lib.HTTPOptions(d.cctx)
+	if err != nil {
+		diag.add("info", checkFail, err.Error(), "fix the HTTP flags")
+		return diag
+	}
+	ctx, cancel := d.checkContext()
+	defer cancel()
+	hc, err := http2.New(ctx, d.log, rawURL, nil, nil, hopts...)
+	if err != nil {
+		diag.add("info", checkFail, err.Error(), "check that the URL is the root of a drand HTTP relay, e.g. https://api.drand.sh")
+		return diag
+	}
+	defer hc.Close()
+	d.checkClient(diag, hc, "check that the URL is the root of a drand HTTP relay, e.g. https://api.drand.sh")
+	return diag
+}
+
+// checkDNS resolves host, unless it is an IP or pinned with --resolve, and
+// returns the addresses to connect to, or nil if it cannot be resolved.
+func (d *doctor) checkDNS(diag *diagnosis, host string) []string {
+	if net.ParseIP(host) != nil {
+		diag.add("dns", checkSkip, "IP address", "")
+		return []string{host}
+	}
+	var pinned []string
+	for _, v := range d.cctx.StringSlice(lib.ResolveFlag.Name) {
+		if h, ip, ok := strings.Cut(v, "="); ok && h == host {
+			pinned = append(pinned, ip)
+		}
+	}
+	if len(pinned) > 0 {
+		diag.add("dns", checkSkip, "pinned to "+strings.Join(pinned, ", ")+" with --"+lib.ResolveFlag.Name, "")
+		return pinned
+	}
+
+	ctx, cancel := d.checkContext()
+	defer cancel()
+	start := time.Now()
+	addrs, err := net.DefaultResolver.LookupHost(ctx, host)
+	if err != nil {
+		diag.add("dns", checkFail, err.Error(),
+			fmt.Sprintf("check the hostname and the DNS configuration, or pin the host with --%s HOST=IP", lib.ResolveFlag.Name))
+		return nil
+	}
+	diag.add("dns", checkOK, fmt.Sprintf("%s in %s", strings.Join(addrs, ", "), since(start)), "")
+	return addrs
+}
+
+// checkTLS performs a TLS handshake with the relay of u at addr, checks its
+// certificate, and reports whether the handshake succeeded.
+func (d *doctor) checkTLS(diag *diagnosis, u *url.URL, addr string) bool {
+	if d.cctx.String(lib.ProxyFlag.Name) != "" {
+		diag.add("tls", checkSkip, "connecting through --"+lib.ProxyFlag.Name, "")
+		return true
+	}
+	port := u.Port()
+	if port == "" {
+		port = "443"
+	}
+	ctx, cancel := d.checkContext()
+	defer cancel()
+	dialer := &tls.Dialer{Config: &tls.Config{ServerName: u.Hostname(), MinVersion: tls.VersionTLS12}}
+	start := time.Now()
+	conn, err := dialer.DialContext(ctx, "tcp", net.JoinHostPort(addr, port))
+	if err != nil {
+		diag.add("tls", checkFail, err.Error(), tlsHint(err))
+		return false
+	}
+	defer conn.Close()
+	state := conn.(*tls.Conn).ConnectionState()
+	cert := state.PeerCertificates[0]
+	detail := fmt.Sprintf("%s in %s, certificate valid until %s", tls.VersionName(state.Version), since(start),
+		cert.NotAfter.UTC().Format(time.DateOnly))
+	if left := time.Until(cert.NotAfter); left < certExpiryWarning {
+		diag.add("tls", checkWarn, detail, "the certificate of the relay expires soon: renew it")
+		return true
+	}
+	diag.add("tls", checkOK, detail, "")
+	return true
+}
+
+// tlsHint explains the failure of a TLS handshake.
+func tlsHint(err error) string {
+	var unknown x509.UnknownAuthorityError
+	var hostname x509.HostnameError
+	var invalid x509.CertificateInvalidError
+	switch {
+	case errors.As(err, &unknown):
+		return "the certificate is not signed by a trusted authority: install the CA of the relay in the system trust store"
+	case errors.As(err, &hostname):
+		return "the certificate is for other hostnames: check the URL"
+	case errors.As(err, &invalid) && invalid.Reason == x509.Expired:
+		return "the certificate expired, or the local clock is wrong: check both"
+	default:
+		return "check that the relay serves HTTPS on this port, and that no firewall blocks it"
+	}
+}
+
+func (d *doctor) diagnoseGRPC(addr string) *diagnosis {
+	diag := &diagnosis{transport: "gRPC " + addr}
+	if !grpc.IsUnix(addr) {
+		host, _, err := net.SplitHostPort(addr)
+		if err != nil {
+			diag.add("address", checkFail, err.Error(), "use host:port, or unix:///path/to.sock")
+			return diag
+		}
+		if d.checkDNS(diag, host) == nil {
+			return diag
+		}
+	}
+
+	opts, err := lib.GRPCOptions(d.cctx)
+	if err != nil {
+		diag.add("probe", checkFail, err.Error(), "fix the gRPC flags")
+		return diag
+	}
+	gc, err := grpc.New(addr, d.cctx.Bool(lib.InsecureFlag.Name), nil, opts...)
+	if err != nil {
+		diag.add("probe", checkFail, err.Error(), "check the address of the gRPC endpoint")
+		return diag
+	}
+	defer gc.Close()
+	ctx, cancel := d.checkContext()
+	defer cancel()
+	start := time.Now()
+	if err := grpc.Probe(ctx, gc); err != nil {
+		diag.add("probe", checkFail, err.Error(),
+			fmt.Sprintf("check that the endpoint serves gRPC, and use --%s for endpoints without TLS", lib.InsecureFlag.Name))
+		return diag
+	}
+	diag.add("probe", checkOK, "healthy in "+since(start), "")
+	d.checkClient(diag, gc, "check that the endpoint is a drand node or gRPC relay")
+	return diag
+}
+
+// checkClient fetches the chain info and the latest round from c, and checks
+// that they match the expected chain and the round schedule.
+func (d *doctor) checkClient(diag *diagnosis, c drand.Client, infoHint string) {
+	ctx, cancel := d.checkContext()
+	defer cancel()
+	start := time.Now()
+	info, err := c.Info(ctx)
+	if err != nil {
+		diag.add("info", checkFail, err.Error(), infoHint)
+		return
+	}
+	diag.add("info", checkOK, fmt.Sprintf("beacon %q, scheme %s, in %s", info.ID, info.Scheme, since(start)), "")
+
+	d.checkChain(diag, info)
+
+	start = time.Now()
+	latest, err := c.Get(ctx, 0)
+	if err != nil {
+		diag.add("latest", checkFail, err.Error(), "the chain info is served but not the beacons: check the relay logs")
+		return
+	}
+	diag.add("latest", checkOK, fmt.Sprintf("round %d in %s", latest.GetRound(), since(start)), "")
+	checkSchedule(diag, info, latest.GetRound(), time.Now())
+}
+
+// checkChain checks that info is the one of an expected chain, or of the
+// first transport if none is.
+func (d *doctor) checkChain(diag *diagnosis, info *chain.Info) {
+	hash := info.Hash()
+	if len(d.expected) > 0 {
+		for _, h := range d.expected {
+			if bytes.Equal(h, hash) {
+				diag.add("chain", checkOK, info.HashString(), "")
+				return
+			}
+		}
+		diag.add("chain", checkFail, info.HashString()+" is not an expected chain",
+			fmt.Sprintf("check the URL, or the hashes given with --%s", lib.HashListFlag.Name))
+		return
+	}
+	if d.reference == nil {
+		d.reference = info
+		diag.add("chain", checkWarn, info.HashString()+", not checked",
+			fmt.Sprintf("give the hash of the expected chain with --%s", lib.HashListFlag.Name))
+		return
+	}
+	if !bytes.Equal(d.reference.Hash(), hash) {
+		diag.add("chain", checkFail, fmt.Sprintf("%s, other transports serve %s", info.HashString(), d.reference.HashString()),
+			"the transports serve different chains: check their addresses")
+		return
+	}
+	diag.add("chain", checkOK, info.HashString()+", same as the other transports", "")
+}
+
+// checkSchedule compares the latest round of a transport with the round
+// expected at now according to the local clock: a round from the future
+// means the local clock is behind, a round late by more than a period means
+// the transport is lagging or the local clock is ahead.
+func checkSchedule(diag *diagnosis, info *chain.Info, latest uint64, now time.Time) {
+	expected := common.CurrentRound(now.Unix(), info.Period, info.GenesisTime)
+	emitted := time.Unix(common.TimeOfRound(info.Period, info.GenesisTime, latest), 0)
+	switch {
+	case latest > expected:
+		diag.add("clock", checkWarn,
+			fmt.Sprintf("round %d is expected, the local clock is at least %s behind", expected, emitted.Sub(now).Round(time.Second)),
+			"synchronize the local clock, e.g. with NTP")
+	case now.Sub(emitted) > info.Period+info.Period/2:
+		diag.add("clock", checkWarn,
+			fmt.Sprintf("round %d is expected, the latest round is %s old", expected, now.Sub(emitted).Round(time.Second)),
+			"the transport is lagging, or the local clock is ahead: check the relay and synchronize the local clock")
+	default:
+		diag.add("clock", checkOK, fmt.Sprintf("round %d is expected", expected), "")
+	}
+}
+
+func (d *doctor) diagnoseRelay(addr string) *diagnosis {
+	diag := &diagnosis{transport: "libp2p " + addr}
+	ctx, cancel := d.checkContext()
+	defer cancel()
+	start := time.Now()
+	addrs, err := lib.ResolveRelay(ctx, addr)
+	if err != nil {
+		diag.add("dns", checkFail, err.Error(), "check the multiaddr of the relay, e.g. /dnsaddr/example.com/p2p/<peer ID>")
+		return diag
+	}
+	diag.add("dns", checkOK, fmt.Sprintf("%d addresses in %s", len(addrs), since(start)), "")
+
+	ctx, cancel = d.checkContext()
+	defer cancel()
+	latency, err := lib.DialRelay(ctx, d.cctx, d.log, addrs)
+	if err != nil {
+		diag.add("connect", checkFail, err.Error(),
+			fmt.Sprintf("check that the relay is reachable, and its peer ID, or the key given with --%s", lib.PNetKeyFlag.Name))
+		return diag
+	}
+	diag.add("connect", checkOK, "connected in "+latency.Round(time.Millisecond).String(), "")
+	return diag
+}
+
+// printDiagnoses writes a report of the diagnoses, and returns the number of
+// failed checks and of warnings.
+func printDiagnoses(w io.Writer, diagnoses []*diagnosis, colored bool) (failed, warned int) {
+	for _, diag := range diagnoses {
+		fmt.Fprintln(w, diag.transport)
+		for _, c := range diag.checks {
+			status := fmt.Sprintf("%-4s", c.status)
+			if colored {
+				status = c.status.color() + status + "\x1b[0m"
+			}
+			fmt.Fprintf(w, "  %s  %-8s %s\n", status, c.name, c.detail)
+			if c.status >= checkWarn && c.hint != "" {
+				fmt.Fprintf(w, "        %-8s hint: %s\n", "", c.hint)
+			}
+			switch c.status {
+			case checkFail:
+				failed++
+			case checkWarn:
+				warned++
+			}
+		}
+	}
+	return failed, warned
+}
+
+// isTerminal reports whether w is a terminal.
+func isTerminal(w io.Writer) bool {
+	f, ok := w.(*os.File)
+	if !ok {
+		return false
+	}
+	fi, err := f.Stat()
+	return err == nil && fi.Mode()&os.ModeCharDevice != 0
+}
+
+// since returns the time elapsed since start, rounded to the millisecond.
+func since(start time.Time) string {
+	return time.Since(start).Round(time.Millisecond).String()
+}
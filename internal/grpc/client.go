@@ -5,61 +5,90 @@ import (
 	"crypto/tls"
 	"errors"
 	"fmt"
+	"strings"
 	"time"
 
 	grpcProm "github.com/grpc-ecosystem/go-grpc-prometheus"
 	"google.golang.org/grpc"
 	"google.golang.org/grpc/credentials"
 	grpcInsec "google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/grpc/metadata"
 
 	"github.com/drand/go-clients/drand"
 
-	"github.com/drand/drand/v2/crypto"
-
 	commonutils "github.com/drand/drand/v2/common"
 	"github.com/drand/drand/v2/common/chain"
 	"github.com/drand/drand/v2/common/log"
 	proto "github.com/drand/drand/v2/protobuf/drand"
 	"github.com/drand/go-clients/client"
+	"github.com/drand/go-clients/internal/metrics"
 )
 
 const grpcDefaultTimeout = 5 * time.Second
 
+// WatchBufferSize controls how many results can be buffered for a slow
+// consumer of Watch before the oldest ones start to be dropped, so that the
+// stream keeps being read.
+var WatchBufferSize = 100
+
+// userAgentKey is the metadata key identifying the client, since gRPC
+// reserves the user-agent header for itself.
+const userAgentKey = "x-user-agent"
+
 type grpcClient struct {
 	address   string
 	chainHash []byte
+	beaconID  string
+	md        metadata.MD
+	dialOpts  []grpc.DialOption
 	client    proto.PublicClient
 	conn      *grpc.ClientConn
 	l         log.Logger
+	userAgent string
 }
 
-// New creates a drand client backed by a GRPC connection.
-func New(address string, insecure bool, chainHash []byte) (drand.Client, error) {
-	var opts []grpc.DialOption
-	if insecure {
-		opts = append(opts, grpc.WithTransportCredentials(grpcInsec.NewCredentials()))
+var _ client.UserAgentClient = &grpcClient{}
+var _ drand.ResumableWatcher = &grpcClient{}
+
+// New creates a drand client backed by a GRPC connection, configured with
+// opts, e.g. to connect through a proxy, see WithDialOptions and WithProxy.
+// The address of a node listening on a unix domain socket is the path of the
+// socket prefixed with "unix://", e.g. "unix:///run/drand.sock", and its
+// connection is never encrypted.
+func New(address string, insecure bool, chainHash []byte, opts ...Option) (drand.Client, error) {
+	g := &grpcClient{address: address, chainHash: chainHash, l: log.DefaultLogger()}
+	for _, opt := range opts {
+		opt(g)
+	}
+
+	var dialOpts []grpc.DialOption
+	if insecure || IsUnix(address) {
+		dialOpts = append(dialOpts, grpc.WithTransportCredentials(grpcInsec.NewCredentials()))
 	} else {
-		opts = append(opts, grpc.WithTransportCredentials(credentials.NewTLS(&tls.Config{MinVersion: tls.VersionTLS12})))
+		dialOpts = append(dialOpts, grpc.WithTransportCredentials(credentials.NewTLS(&tls.Config{MinVersion: tls.VersionTLS12})))
 	}
-	opts = append(opts,
+	dialOpts = append(dialOpts,
 		grpc.WithUnaryInterceptor(grpcProm.UnaryClientInterceptor),
 		grpc.WithStreamInterceptor(grpcProm.StreamClientInterceptor),
 	)
-	conn, err := grpc.NewClient(address, opts...)
+	dialOpts = append(dialOpts, g.dialOpts...)
+	conn, err := grpc.NewClient(address, dialOpts...)
 	if err != nil {
 		return nil, err
 	}
+	g.conn = conn
+	g.client = proto.NewPublicClient(conn)
+	return g, nil
+}
 
-	return &grpcClient{address, chainHash, proto.NewPublicClient(conn), conn, log.DefaultLogger()}, nil
+// IsUnix tells whether address is the one of a unix domain socket, see New.
+func IsUnix(address string) bool {
+	return strings.HasPrefix(address, "unix:")
 }
 
-func asRD(r *proto.PublicRandResponse) *client.RandomData {
-	return &client.RandomData{
-		Rnd:               r.GetRound(),
-		Random:            crypto.RandomnessFromSignature(r.GetSignature()),
-		Sig:               r.GetSignature(),
-		PreviousSignature: r.GetPreviousSignature(),
-	}
+// Transport returns the name of the transport of this client.
+func (g *grpcClient) Transport() string {
+	return "grpc"
 }
 
 // String returns the name of this client.
@@ -69,7 +98,7 @@ func (g *grpcClient) String() string {
 
 // Get returns a the randomness at `round` or an error.
 func (g *grpcClient) Get(ctx context.Context, round uint64) (drand.Result, error) {
-	curr, err := g.client.PublicRand(ctx, &proto.PublicRandRequest{Round: round, Metadata: g.getMetadata()})
+	curr, err := g.client.PublicRand(g.outgoing(ctx), &proto.PublicRandRequest{Round: round, Metadata: g.getMetadata()})
 	if err != nil {
 		return nil, err
 	}
@@ -77,13 +106,20 @@ func (g *grpcClient) Get(ctx context.Context, round uint64) (drand.Result, error
 		return nil, errors.New("no received randomness - unexpected gPRC response")
 	}
 
-	return asRD(curr), nil
+	return client.RandomDataFromProto(curr), nil
 }
 
 // Watch returns new randomness as it becomes available.
 func (g *grpcClient) Watch(ctx context.Context) <-chan drand.Result {
-	stream, err := g.client.PublicRandStream(ctx, &proto.PublicRandRequest{Round: 0, Metadata: g.getMetadata()})
-	ch := make(chan drand.Result, 1)
+	return g.WatchFrom(ctx, 0)
+}
+
+// WatchFrom returns the randomness of the rounds from round, streamed by the
+// node from its store, then new randomness as it becomes available. A round
+// of 0 only streams the new randomness, as Watch does.
+func (g *grpcClient) WatchFrom(ctx context.Context, round uint64) <-chan drand.Result {
+	stream, err := g.client.PublicRandStream(g.outgoing(ctx), &proto.PublicRandRequest{Round: round, Metadata: g.getMetadata()})
+	ch := make(chan drand.Result, max(WatchBufferSize, 1))
 	if err != nil {
 		close(ch)
 		return ch
@@ -94,7 +130,7 @@ func (g *grpcClient) Watch(ctx context.Context) <-chan drand.Result {
 
 // Info returns information about the chain.
 func (g *grpcClient) Info(ctx context.Context) (*chain.Info, error) {
-	p, err := g.client.ChainInfo(ctx, &proto.ChainInfoRequest{Metadata: g.getMetadata()})
+	p, err := g.client.ChainInfo(g.outgoing(ctx), &proto.ChainInfoRequest{Metadata: g.getMetadata()})
 	if err != nil {
 		return nil, err
 	}
@@ -104,7 +140,7 @@ func (g *grpcClient) Info(ctx context.Context) (*chain.Info, error) {
 	return chain.InfoFromProto(p)
 }
 
-func (g *grpcClient) translate(stream proto.Public_PublicRandStreamClient, out chan<- drand.Result) {
+func (g *grpcClient) translate(stream proto.Public_PublicRandStreamClient, out chan drand.Result) {
 	defer close(out)
 	for {
 		next, err := stream.Recv()
@@ -114,19 +150,37 @@ func (g *grpcClient) translate(stream proto.Public_PublicRandStreamClient, out c
 			}
 			return
 		}
-		out <- asRD(next)
+		g.push(out, client.RandomDataFromProto(next))
+	}
+}
+
+// push sends a result without blocking, dropping the oldest buffered result
+// when the consumer is not keeping up.
+func (g *grpcClient) push(out chan drand.Result, r drand.Result) {
+	for {
+		select {
+		case out <- r:
+			return
+		default:
+		}
+		select {
+		case old := <-out:
+			metrics.ClientWatchDropped.WithLabelValues("grpc").Inc()
+			g.l.Warnw("", "grpc_client", "dropped result due to a full channel", "round", old.GetRound())
+		default:
+		}
 	}
 }
 
 func (g *grpcClient) getMetadata() *proto.Metadata {
-	return &proto.Metadata{ChainHash: g.chainHash}
+	return &proto.Metadata{ChainHash: g.chainHash, BeaconID: g.beaconID}
 }
 
 func (g *grpcClient) RoundAt(t time.Time) uint64 {
 	ctx, cancel := context.WithTimeout(context.Background(), grpcDefaultTimeout)
 	defer cancel()
 
-	info, err := g.client.ChainInfo(ctx, &proto.ChainInfoRequest{Metadata: g.getMetadata()})
+	info, err := g.client.ChainInfo(g.outgoing(ctx), &proto.ChainInfoRequest{Metadata: g.getMetadata()})
 	if err != nil {
 		return 0
 	}
@@ -138,6 +192,29 @@ func (g *grpcClient) SetLog(l log.Logger) {
 	g.l = l
 }
 
+// SetUserAgent sets the user agent sent as metadata of every call.
+func (g *grpcClient) SetUserAgent(ua string) {
+	g.userAgent = ua
+}
+
+// outgoing adds the metadata of the client, and the one identifying it, to a
+// call context.
+func (g *grpcClient) outgoing(ctx context.Context) context.Context {
+	kv := make([]string, 0, 2*g.md.Len()+2)
+	for k, vs := range g.md {
+		for _, v := range vs {
+			kv = append(kv, k, v)
+		}
+	}
+	if g.userAgent != "" {
+		kv = append(kv, userAgentKey, g.userAgent)
+	}
+	if len(kv) == 0 {
+		return ctx
+	}
+	return metadata.AppendToOutgoingContext(ctx, kv...)
+}
+
 // Close tears down the gRPC connection and all underlying connections.
 func (g *grpcClient) Close() error {
 	return g.conn.Close()
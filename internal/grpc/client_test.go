@@ -3,6 +3,7 @@ package grpc
 import (
 	"bytes"
 	"context"
+	"net"
 	"sync"
 	"testing"
 	"time"
@@ -12,11 +13,16 @@ import (
 	"github.com/drand/drand/v2/common/log"
 
 	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc"
 	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
 	"google.golang.org/grpc/status"
 
 	"github.com/drand/drand/v2/crypto"
+	proto "github.com/drand/drand/v2/protobuf/drand"
 	"github.com/drand/drand/v2/test/mock"
+	"github.com/drand/go-clients/client"
+	"github.com/drand/go-clients/drand"
 )
 
 func TestClient(t *testing.T) {
@@ -108,3 +114,47 @@ func TestClientClose(t *testing.T) {
 
 	wg.Wait() // wait for the watch to close
 }
+
+func TestClientWatchDropsOldest(t *testing.T) {
+	g := &grpcClient{l: log.New(nil, log.DebugLevel, true)}
+	out := make(chan drand.Result, 2)
+	for round := uint64(1); round <= 3; round++ {
+		g.push(out, &client.RandomData{Rnd: round})
+	}
+	require.Equal(t, uint64(2), (<-out).GetRound())
+	require.Equal(t, uint64(3), (<-out).GetRound())
+}
+
+// metadataServer records the metadata of the requests it fails.
+type metadataServer struct {
+	proto.UnimplementedPublicServer
+	beaconID string
+	md       metadata.MD
+}
+
+func (s *metadataServer) ChainInfo(ctx context.Context, req *proto.ChainInfoRequest) (*proto.ChainInfoPacket, error) {
+	s.beaconID = req.GetMetadata().GetBeaconID()
+	s.md, _ = metadata.FromIncomingContext(ctx)
+	return nil, status.Error(codes.NotFound, "no chain")
+}
+
+func TestClientMetadata(t *testing.T) {
+	lis, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+	srv := grpc.NewServer()
+	fake := &metadataServer{}
+	proto.RegisterPublicServer(srv, fake)
+	go func() { _ = srv.Serve(lis) }()
+	defer srv.Stop()
+
+	c, err := New(lis.Addr().String(), true, nil, WithBeaconID("quicknet"),
+		WithMetadata(metadata.Pairs("authorization", "Bearer token")), WithMetadata(metadata.Pairs("x-relay", "a")))
+	require.NoError(t, err)
+	defer c.Close()
+
+	_, err = c.Info(context.Background())
+	require.Equal(t, codes.NotFound, status.Code(err))
+	require.Equal(t, "quicknet", fake.beaconID)
+	require.Equal(t, []string{"Bearer token"}, fake.md.Get("authorization"))
+	require.Equal(t, []string{"a"}, fake.md.Get("x-relay"))
+}
@@ -8,5 +8,13 @@ on-the-wire and supports streaming and authentication.
 A path to a file that holds TLS credentials for the drand server is required
 to validate server connections. Alternatively set the final parameter to
 `true` to enable _insecure_ connections (not recommended).
+
+Clients behind a proxy can reach the endpoint through it with the dial option
+returned by WithProxy, which supports HTTP CONNECT and SOCKS5 proxies, given
+with WithDialOptions.
+
+Endpoints serving several beacons can be told the beacon requested with
+WithBeaconID, and the calls can carry additional metadata, e.g. the
+authorization token of a relay, with WithMetadata.
 */
 package grpc
@@ -0,0 +1,35 @@
+package grpc
+
+import (
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/metadata"
+)
+
+// Option configures a client made with New.
+type Option func(g *grpcClient)
+
+// WithDialOptions applies opts to the connection of the client, after the
+// default ones, e.g. to connect through a proxy, see WithProxy.
+func WithDialOptions(opts ...grpc.DialOption) Option {
+	return func(g *grpcClient) {
+		g.dialOpts = append(g.dialOpts, opts...)
+	}
+}
+
+// WithBeaconID sets the beacon ID in the drand metadata of the requests, for
+// the nodes and relays serving several beacons which select the chain by its
+// ID rather than by its hash.
+func WithBeaconID(id string) Option {
+	return func(g *grpcClient) {
+		g.beaconID = id
+	}
+}
+
+// WithMetadata adds md to the gRPC metadata of every call, e.g. the
+// authorization token required by a relay, or the headers routing the calls
+// of a relay multiplexing the nodes of several beacons.
+func WithMetadata(md metadata.MD) Option {
+	return func(g *grpcClient) {
+		g.md = metadata.Join(g.md, md)
+	}
+}
@@ -0,0 +1,64 @@
+package grpc
+
+import (
+	"bytes"
+	"context"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"strings"
+
+	"google.golang.org/grpc/codes"
+	healthpb "google.golang.org/grpc/health/grpc_health_v1"
+	"google.golang.org/grpc/status"
+
+	proto "github.com/drand/drand/v2/protobuf/drand"
+	"github.com/drand/go-clients/drand"
+)
+
+// Probe checks that the endpoint of a client created with New is healthy,
+// according to the gRPC health service, and that it serves the chain of the
+// client, if any, so that misconfigurations are reported with clear errors
+// when the client is created rather than by its first requests. Endpoints not
+// implementing the health service, or not advertising their chains, are
+// assumed to be healthy and to serve the chain.
+func Probe(ctx context.Context, c drand.Client) error {
+	g, ok := c.(*grpcClient)
+	if !ok {
+		return errors.New("not a gRPC client")
+	}
+
+	resp, err := healthpb.NewHealthClient(g.conn).Check(g.outgoing(ctx), &healthpb.HealthCheckRequest{})
+	switch {
+	case status.Code(err) == codes.Unimplemented:
+	case err != nil:
+		return fmt.Errorf("endpoint %s is unreachable: %w", g.address, err)
+	case resp.GetStatus() != healthpb.HealthCheckResponse_SERVING:
+		return fmt.Errorf("endpoint %s is not serving: health status is %s", g.address, resp.GetStatus())
+	}
+	if len(g.chainHash) == 0 {
+		return nil
+	}
+
+	ids, err := g.client.ListBeaconIDs(g.outgoing(ctx), &proto.ListBeaconIDsRequest{})
+	if status.Code(err) == codes.Unimplemented {
+		return nil
+	} else if err != nil {
+		return fmt.Errorf("listing the chains of endpoint %s: %w", g.address, err)
+	}
+	var chains []string
+	for _, m := range ids.GetMetadatas() {
+		if len(m.GetChainHash()) == 0 {
+			continue
+		}
+		if bytes.Equal(m.GetChainHash(), g.chainHash) {
+			return nil
+		}
+		chains = append(chains, hex.EncodeToString(m.GetChainHash()))
+	}
+	if len(chains) == 0 {
+		return nil
+	}
+	return fmt.Errorf("%w: endpoint %s serves chains %s; requested %x",
+		drand.ErrInvalidChainHash, g.address, strings.Join(chains, ","), g.chainHash)
+}
@@ -0,0 +1,80 @@
+package grpc
+
+import (
+	"context"
+	"net"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/health"
+	healthpb "google.golang.org/grpc/health/grpc_health_v1"
+
+	proto "github.com/drand/drand/v2/protobuf/drand"
+	"github.com/drand/go-clients/drand"
+)
+
+// chainsServer lists the chains of the given hashes.
+type chainsServer struct {
+	proto.UnimplementedPublicServer
+	hashes [][]byte
+}
+
+func (s *chainsServer) ListBeaconIDs(context.Context, *proto.ListBeaconIDsRequest) (*proto.ListBeaconIDsResponse, error) {
+	resp := &proto.ListBeaconIDsResponse{}
+	for _, h := range s.hashes {
+		resp.Metadatas = append(resp.Metadatas, &proto.Metadata{ChainHash: h})
+	}
+	return resp, nil
+}
+
+func TestProbe(t *testing.T) {
+	ctx := context.Background()
+	lis, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+	srv := grpc.NewServer()
+	hs := health.NewServer()
+	healthpb.RegisterHealthServer(srv, hs)
+	proto.RegisterPublicServer(srv, &chainsServer{hashes: [][]byte{{1, 1}, {2, 2}}})
+	go func() { _ = srv.Serve(lis) }()
+	defer srv.Stop()
+
+	probe := func(chainHash []byte) error {
+		c, err := New(lis.Addr().String(), true, chainHash)
+		require.NoError(t, err)
+		defer c.Close()
+		return Probe(ctx, c)
+	}
+	require.NoError(t, probe(nil))
+	require.NoError(t, probe([]byte{2, 2}))
+	err = probe([]byte{3, 3})
+	require.ErrorIs(t, err, drand.ErrInvalidChainHash)
+	require.ErrorContains(t, err, "serves chains 0101,0202; requested 0303")
+
+	hs.SetServingStatus("", healthpb.HealthCheckResponse_NOT_SERVING)
+	require.ErrorContains(t, probe([]byte{2, 2}), "is not serving")
+
+	srv.Stop()
+	require.ErrorContains(t, probe(nil), "is unreachable")
+}
+
+func TestUnixSocket(t *testing.T) {
+	ctx := context.Background()
+	socket := filepath.Join(t.TempDir(), "node.sock")
+	lis, err := net.Listen("unix", socket)
+	require.NoError(t, err)
+	srv := grpc.NewServer()
+	healthpb.RegisterHealthServer(srv, health.NewServer())
+	proto.RegisterPublicServer(srv, &chainsServer{hashes: [][]byte{{1, 1}}})
+	go func() { _ = srv.Serve(lis) }()
+	defer srv.Stop()
+
+	require.True(t, IsUnix("unix://"+socket))
+	require.False(t, IsUnix("127.0.0.1:4444"))
+	// unix sockets are never encrypted, even without insecure
+	c, err := New("unix://"+socket, false, []byte{1, 1})
+	require.NoError(t, err)
+	defer c.Close()
+	require.NoError(t, Probe(ctx, c))
+}
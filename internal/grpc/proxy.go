@@ -0,0 +1,98 @@
+package grpc
+
+import (
+	"bufio"
+	"context"
+	"encoding/base64"
+	"fmt"
+	"net"
+	nhttp "net/http"
+	"net/url"
+	"time"
+
+	"golang.org/x/net/proxy"
+	"google.golang.org/grpc"
+)
+
+// WithProxy returns a dial option connecting to the drand node through the
+// proxy at u, either an HTTP one using CONNECT, e.g.
+// "http://proxy.example.com:3128", or a SOCKS5 one, e.g.
+// "socks5://127.0.0.1:9050" to use Tor. Without it, gRPC uses the HTTPS_PROXY
+// configured by the environment.
+func WithProxy(u *url.URL) (grpc.DialOption, error) {
+	switch u.Scheme {
+	case "http":
+		return grpc.WithContextDialer(func(ctx context.Context, addr string) (net.Conn, error) {
+			return dialConnect(ctx, u, addr)
+		}), nil
+	case "socks5", "socks5h":
+		d, err := proxy.FromURL(u, proxy.Direct)
+		if err != nil {
+			return nil, err
+		}
+		cd, ok := d.(proxy.ContextDialer)
+		if !ok {
+			return nil, fmt.Errorf("proxy %s does not support contexts", u.Redacted())
+		}
+		return grpc.WithContextDialer(func(ctx context.Context, addr string) (net.Conn, error) {
+			return cd.DialContext(ctx, "tcp", addr)
+		}), nil
+	default:
+		return nil, fmt.Errorf("unsupported proxy scheme %q", u.Scheme)
+	}
+}
+
+// dialConnect opens a tunnel to addr through the HTTP proxy at u.
+func dialConnect(ctx context.Context, u *url.URL, addr string) (net.Conn, error) {
+	var d net.Dialer
+	conn, err := d.DialContext(ctx, "tcp", u.Host)
+	if err != nil {
+		return nil, err
+	}
+	if deadline, ok := ctx.Deadline(); ok {
+		_ = conn.SetDeadline(deadline)
+	}
+
+	req := &nhttp.Request{
+		Method: nhttp.MethodConnect,
+		URL:    &url.URL{Host: addr},
+		Host:   addr,
+		Header: make(nhttp.Header),
+	}
+	if u.User != nil {
+		password, _ := u.User.Password()
+		auth := u.User.Username() + ":" + password
+		req.Header.Set("Proxy-Authorization", "Basic "+base64.StdEncoding.EncodeToString([]byte(auth)))
+	}
+	if err := req.Write(conn); err != nil {
+		conn.Close()
+		return nil, err
+	}
+	br := bufio.NewReader(conn)
+	resp, err := nhttp.ReadResponse(br, req)
+	if err != nil {
+		conn.Close()
+		return nil, err
+	}
+	resp.Body.Close()
+	if resp.StatusCode != nhttp.StatusOK {
+		conn.Close()
+		return nil, fmt.Errorf("proxy %s refused to connect to %s: %s", u.Redacted(), addr, resp.Status)
+	}
+	_ = conn.SetDeadline(time.Time{})
+	if br.Buffered() > 0 {
+		// the server already sent data through the tunnel
+		return &bufferedConn{Conn: conn, r: br}, nil
+	}
+	return conn, nil
+}
+
+// bufferedConn is a connection whose first bytes were already read in r.
+type bufferedConn struct {
+	net.Conn
+	r *bufio.Reader
+}
+
+func (c *bufferedConn) Read(b []byte) (int, error) {
+	return c.r.Read(b)
+}
@@ -0,0 +1,80 @@
+package grpc
+
+import (
+	"bufio"
+	"context"
+	"io"
+	"net"
+	"net/http"
+	"net/url"
+	"sync/atomic"
+	"testing"
+
+	clock "github.com/jonboulle/clockwork"
+	"github.com/stretchr/testify/require"
+
+	"github.com/drand/drand/v2/common/log"
+	"github.com/drand/drand/v2/crypto"
+	"github.com/drand/drand/v2/test/mock"
+)
+
+// connectProxy serves HTTP CONNECT tunnels on l, counting them.
+func connectProxy(l net.Listener, tunnels *atomic.Int32) {
+	for {
+		conn, err := l.Accept()
+		if err != nil {
+			return
+		}
+		go func() {
+			defer conn.Close()
+			req, err := http.ReadRequest(bufio.NewReader(conn))
+			if err != nil || req.Method != http.MethodConnect {
+				return
+			}
+			target, err := net.Dial("tcp", req.Host)
+			if err != nil {
+				_, _ = io.WriteString(conn, "HTTP/1.1 502 Bad Gateway\r\n\r\n")
+				return
+			}
+			defer target.Close()
+			tunnels.Add(1)
+			if _, err := io.WriteString(conn, "HTTP/1.1 200 OK\r\n\r\n"); err != nil {
+				return
+			}
+			go func() {
+				_, _ = io.Copy(target, conn)
+				target.Close()
+			}()
+			_, _ = io.Copy(conn, target)
+		}()
+	}
+}
+
+func TestClientProxy(t *testing.T) {
+	sch, err := crypto.GetSchemeFromEnv()
+	require.NoError(t, err)
+	l, _ := mock.NewMockGRPCPublicServer(t, log.DefaultLogger(), "localhost:0", false, sch, clock.NewFakeClock())
+	go l.Start()
+	defer l.Stop(context.Background())
+
+	pl, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+	defer pl.Close()
+	var tunnels atomic.Int32
+	go connectProxy(pl, &tunnels)
+
+	opt, err := WithProxy(&url.URL{Scheme: "http", Host: pl.Addr().String()})
+	require.NoError(t, err)
+	c, err := New(l.Addr(), true, []byte(""), WithDialOptions(opt))
+	require.NoError(t, err)
+	defer c.Close()
+	result, err := c.Get(context.Background(), 1969)
+	require.NoError(t, err)
+	require.Equal(t, uint64(1969), result.GetRound())
+	require.Positive(t, tunnels.Load())
+
+	_, err = WithProxy(&url.URL{Scheme: "ftp", Host: pl.Addr().String()})
+	require.Error(t, err)
+	_, err = WithProxy(&url.URL{Scheme: "socks5", Host: pl.Addr().String()})
+	require.NoError(t, err)
+}
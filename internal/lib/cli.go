@@ -6,42 +6,54 @@ import (
 	"encoding/json"
 	"errors"
 	"fmt"
-	"net"
-	nhttp "net/http"
+	"net/netip"
+	"net/url"
 	"os"
-	"path"
+	"path/filepath"
 	"strings"
 
 	"github.com/BurntSushi/toml"
-	"github.com/google/uuid"
-	pubsub "github.com/libp2p/go-libp2p-pubsub"
-	ma "github.com/multiformats/go-multiaddr"
 	"github.com/urfave/cli/v2"
+	"google.golang.org/grpc/metadata"
 
 	"github.com/drand/go-clients/drand"
 
+	"github.com/drand/drand/v2/common"
 	"github.com/drand/drand/v2/common/key"
 
 	chainCommon "github.com/drand/drand/v2/common/chain"
 	"github.com/drand/drand/v2/common/log"
 	"github.com/drand/go-clients/client"
 	http2 "github.com/drand/go-clients/client/http"
-	gclient "github.com/drand/go-clients/client/lp2p"
 	"github.com/drand/go-clients/internal/grpc"
-	"github.com/drand/go-clients/internal/lp2p"
+	"github.com/drand/go-clients/internal/metrics"
 )
 
 var (
 	// URLFlag is the CLI flag for root URL(s) for fetching randomness.
 	URLFlag = &cli.StringSliceFlag{
-		Name:  "url",
-		Usage: "root URL(s) for fetching randomness",
+		Name: "url",
+		Usage: "root URL(s) for fetching randomness, unix:///path/to.sock for a relay listening on a unix socket, " +
+			"or srv+https://_drand._tcp.example.org for the relays listed in the SRV records of a domain",
 	}
 	// GRPCConnectFlag is the CLI flag for host:port to dial a gRPC randomness
 	// provider.
 	GRPCConnectFlag = &cli.StringFlag{
 		Name:  "grpc-connect",
-		Usage: "host:port to dial a gRPC randomness provider",
+		Usage: "host:port, or unix:///path/to.sock, to dial a gRPC randomness provider",
+	}
+	// GRPCBeaconIDFlag is the CLI flag for the beacon ID sent in the metadata
+	// of the gRPC requests.
+	GRPCBeaconIDFlag = &cli.StringFlag{
+		Name:  "grpc-beacon-id",
+		Usage: "ID of the beacon requested from the gRPC randomness provider, for providers serving several beacons",
+	}
+	// GRPCMetadataFlag is the CLI flag for the metadata sent with every gRPC
+	// call.
+	GRPCMetadataFlag = &cli.StringSliceFlag{
+		Name: "grpc-metadata",
+		Usage: "KEY=VALUE metadata sent with every call to the gRPC randomness provider, e.g. an authorization token," +
+			" can be repeated",
 	}
 	// HashFlag is the CLI flag for the hash (in hex) of the targeted chain.
 	HashFlag = &cli.StringFlag{
@@ -66,8 +78,38 @@ var (
 	GroupConfListFlag = &cli.StringSliceFlag{
 		Name: "group-conf-list",
 		Usage: "Paths to at least one drand group configuration (TOML encoded) or chain info (JSON encoded)," +
+			" or to directories of such files," +
 			fmt.Sprintf(" can be used instead of `-%s` flag to verify the chain.", HashListFlag.Name),
 	}
+	// ProxyFlag is the CLI flag for the proxy to reach the drand endpoints
+	// through.
+	ProxyFlag = &cli.StringFlag{
+		Name: "proxy",
+		Usage: "URL of an HTTP or SOCKS5 proxy to reach the drand endpoints through, e.g. socks5://127.0.0.1:9050 for Tor," +
+			" instead of the one configured by the HTTPS_PROXY or ALL_PROXY environment variables",
+		EnvVars: []string{"DRAND_PROXY"},
+	}
+	// ResolveFlag is the CLI flag pinning the hostnames of the HTTP relays to
+	// IPs.
+	ResolveFlag = &cli.StringSliceFlag{
+		Name: "resolve",
+		Usage: "HOST=IP to connect to the given IP for the HTTP relays of the given hostname instead of resolving it," +
+			" can be repeated, including for several IPs of a hostname",
+	}
+	// IPPreferenceFlag is the CLI flag selecting the IP versions used to
+	// reach the HTTP relays.
+	IPPreferenceFlag = &cli.StringFlag{
+		Name:  "ip-preference",
+		Usage: "IP versions used to reach the HTTP relays: any, prefer-ipv4, prefer-ipv6, ipv4 or ipv6",
+		Value: "any",
+	}
+	// ResolveIntervalFlag is the CLI flag for how long the resolved addresses
+	// of the HTTP relays are used before resolving them again.
+	ResolveIntervalFlag = &cli.DurationFlag{
+		Name: "resolve-interval",
+		Usage: "How long the resolved addresses of the HTTP relays are used before resolving them again," +
+			" 0 to resolve them for every connection",
+	}
 	// InsecureFlag is the CLI flag to allow autodetection of the chain
 	// information.
 	InsecureFlag = &cli.BoolFlag{
@@ -79,12 +121,72 @@ var (
 		Name:  "relay",
 		Usage: "relay peer multiaddr(s) to connect with",
 	}
+	// RelayDNSFlag is the CLI flag for a domain name whose TXT records list
+	// relay peer multiaddr(s) to connect with.
+	RelayDNSFlag = &cli.StringFlag{
+		Name:  "relay-dns",
+		Usage: "domain name whose TXT records list relay peer multiaddr(s) to connect with, looked up periodically",
+	}
+	// TopicNamespaceFlag is the CLI flag for the prefix of the pubsub topics
+	// of the chains, for private gossip networks.
+	TopicNamespaceFlag = &cli.StringFlag{
+		Name: "topic-namespace",
+		Usage: "prefix of the pubsub topics of the chains, e.g. /example/drand for a private network gossiping on" +
+			" /example/drand/<chain hash> (default: /drand/pubsub/v0.0.0)",
+		EnvVars: []string{"DRAND_TOPIC_NAMESPACE"},
+	}
 	// PortFlag is the CLI flag for local address for client to bind to, when
 	// connecting to relays. (specified as a numeric port, or a host:port)
 	PortFlag = &cli.StringFlag{
 		Name:  "port",
 		Usage: "Local (host:)port for constructed libp2p host to listen on",
 	}
+	// ClientListenFlag is the CLI flag for the libp2p multiaddr(s) the gossip
+	// client host listens on, in addition to PortFlag.
+	ClientListenFlag = &cli.StringSliceFlag{
+		Name: "client-listen",
+		Usage: "libp2p multiaddr(s) for the gossip client to listen on, e.g. /ip4/0.0.0.0/udp/4453/quic-v1 for QUIC" +
+			" or /ip4/0.0.0.0/tcp/4454/ws for WebSocket",
+	}
+	// PNetKeyFlag is the CLI flag for the pre-shared key file of the libp2p
+	// private network to join.
+	PNetKeyFlag = &cli.PathFlag{
+		Name:    "pnet-key",
+		Usage:   "path to the pre-shared key file of a libp2p private network, to only gossip with the peers having the same key",
+		EnvVars: []string{"DRAND_PNET_KEY"},
+	}
+	// NATPortMapFlag is the CLI flag to open a port on the NAT device of the
+	// libp2p host with UPnP or NAT-PMP.
+	NATPortMapFlag = &cli.BoolFlag{
+		Name:  "nat-portmap",
+		Usage: "Try to open a port for libp2p on the NAT device with UPnP or NAT-PMP",
+	}
+	// HolePunchingFlag is the CLI flag to traverse NATs with hole punching.
+	HolePunchingFlag = &cli.BoolFlag{
+		Name:  "hole-punching",
+		Usage: "Upgrade relayed libp2p connections to direct ones by punching holes in NATs",
+	}
+	// CircuitRelayFlag is the CLI flag for the circuit relay multiaddr(s) to
+	// be reachable through when behind NAT.
+	CircuitRelayFlag = &cli.StringSliceFlag{
+		Name:  "circuit-relay",
+		Usage: "libp2p circuit relay multiaddr(s) to be reachable through when behind NAT, without port forwarding",
+	}
+
+	// MetricsFlag is the CLI flag for the local address to serve the client
+	// metrics on, for long-running commands.
+	MetricsFlag = &cli.StringFlag{
+		Name:    "metrics",
+		Usage:   "local host:port to bind a metrics servlet serving the client metrics on /metrics (optional)",
+		EnvVars: []string{"DRAND_CLIENT_METRICS"},
+	}
+
+	// CacheSizeFlag is the CLI flag for the number of beacons cached by the client.
+	CacheSizeFlag = &cli.IntFlag{
+		Name:  "cache-size",
+		Usage: "Number of beacons cached in memory by the client",
+		Value: 32,
+	}
 
 	// JSONFlag is the value of the CLI flag `json` enabling JSON output of the loggers
 	JSONFlag = &cli.BoolFlag{
@@ -97,8 +199,47 @@ var (
 		Usage:   "If set, verbosity is at the debug level",
 		EnvVars: []string{"DRAND_VERBOSE"},
 	}
+
+	// LogLevelFlag is the CLI flag setting the level of the loggers, taking
+	// precedence over VerboseFlag.
+	LogLevelFlag = &cli.StringFlag{
+		Name:    "log-level",
+		Usage:   "Level of the logs: debug, info, warn or error",
+		EnvVars: []string{"DRAND_LOG_LEVEL"},
+	}
 )
 
+// NewLogger returns a logger honoring JSONFlag, VerboseFlag and LogLevelFlag,
+// logging at defaultLevel when none of the latter is set.
+func NewLogger(c *cli.Context, defaultLevel int) (log.Logger, error) {
+	level, err := logLevel(c, defaultLevel)
+	if err != nil {
+		return nil, err
+	}
+	return log.New(nil, level, c.Bool(JSONFlag.Name)), nil
+}
+
+func logLevel(c *cli.Context, defaultLevel int) (int, error) {
+	level := defaultLevel
+	if c.Bool(VerboseFlag.Name) {
+		level = log.DebugLevel
+	}
+	switch l := strings.ToLower(c.String(LogLevelFlag.Name)); l {
+	case "":
+	case "debug":
+		level = log.DebugLevel
+	case "info":
+		level = log.InfoLevel
+	case "warn", "warning":
+		level = log.WarnLevel
+	case "error":
+		level = log.ErrorLevel
+	default:
+		return 0, fmt.Errorf("invalid --%s %q, expected debug, info, warn or error", LogLevelFlag.Name, l)
+	}
+	return level, nil
+}
+
 // ClientFlags is a list of common flags for client creation
 var ClientFlags = []cli.Flag{
 	URLFlag,
@@ -107,9 +248,36 @@ var ClientFlags = []cli.Flag{
 	GroupConfListFlag,
 	GroupConfFlag,
 	InsecureFlag,
+	ProxyFlag,
+	ResolveFlag,
+	IPPreferenceFlag,
+	ResolveIntervalFlag,
 	RelayFlag,
+	RelayDNSFlag,
+	TopicNamespaceFlag,
+	ClientListenFlag,
+	PNetKeyFlag,
+	NATPortMapFlag,
+	HolePunchingFlag,
+	CircuitRelayFlag,
+	CacheSizeFlag,
 	JSONFlag,
 	VerboseFlag,
+	ConfigFlag,
+}
+
+// StartMetrics serves the client metrics on the address given with
+// MetricsFlag, if any, and returns whether they are served. Clients should
+// then be created with instrumentation.
+func StartMetrics(c *cli.Context) (bool, error) {
+	addr := c.String(MetricsFlag.Name)
+	if addr == "" {
+		return false, nil
+	}
+	if metrics.Start(log.DefaultLogger(), addr, nil, nil) == nil {
+		return false, fmt.Errorf("could not serve metrics on %q", addr)
+	}
+	return true, nil
 }
 
 // Create builds a client, and can be invoked from a cli action supplied
@@ -131,13 +299,9 @@ func Create(c *cli.Context, withInstrumentation bool, opts ...client.Option) (dr
 	var hash []byte
 	if groupPath := c.Path(GroupConfFlag.Name); groupPath != "" {
 		l.Debugw("parsing group-conf file")
-		info, err = chainInfoFromGroupTOML(groupPath)
+		info, err = chainInfoFromFile(l, groupPath)
 		if err != nil {
-			l.Infow("Got a group conf file that is not a toml file. Trying it as a ChainInfo json file.", "path", groupPath)
-			info, err = chainInfoFromChainInfoJSON(groupPath)
-			if info == nil || err != nil {
-				return nil, fmt.Errorf("failed to decode group (%s) : %w", groupPath, err)
-			}
+			return nil, err
 		}
 		l.Debugw("parsing group-conf file, successful")
 
@@ -175,7 +339,8 @@ func Create(c *cli.Context, withInstrumentation bool, opts ...client.Option) (dr
 	}
 
 	if c.Bool(InsecureFlag.Name) {
-		opts = append(opts, client.Insecurely())
+		// without a root of trust, at least make sure all the relays agree on the chain
+		opts = append(opts, client.Insecurely(), client.WithInfoCrossCheck())
 	}
 
 	gc, info, err := buildHTTPClients(c, l, hash, withInstrumentation)
@@ -197,6 +362,10 @@ func Create(c *cli.Context, withInstrumentation bool, opts ...client.Option) (dr
 		)
 	}
 
+	if c.IsSet(CacheSizeFlag.Name) {
+		opts = append(opts, client.WithCacheSize(c.Int(CacheSizeFlag.Name)))
+	}
+
 	gopt, err := buildGossipClient(c, l)
 	if err != nil {
 		return nil, err
@@ -225,30 +394,146 @@ func buildGrpcClient(c *cli.Context, info *chainCommon.Info) ([]drand.Client, *c
 		hash = info.Hash()
 	}
 
-	gc, err := grpc.New(c.String(GRPCConnectFlag.Name), c.Bool(InsecureFlag.Name), hash)
+	opts, err := GRPCOptions(c)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	gc, err := grpc.New(c.String(GRPCConnectFlag.Name), c.Bool(InsecureFlag.Name), hash, opts...)
 	if err != nil {
 		return nil, nil, err
 	}
+	if err := grpc.Probe(c.Context, gc); err != nil {
+		gc.Close()
+		return nil, nil, err
+	}
 
 	if info == nil {
 		info, err = gc.Info(c.Context)
 		if err != nil {
-			return nil, nil, err
+			return nil, nil, fmt.Errorf("getting chain info from %s: %w", c.String(GRPCConnectFlag.Name), err)
 		}
 	}
 
 	return []drand.Client{gc}, info, nil
 }
 
+// GRPCOptions returns the options of the gRPC client built from the flags.
+func GRPCOptions(c *cli.Context) ([]grpc.Option, error) {
+	var opts []grpc.Option
+	// unix domain sockets are local, and never proxied
+	if u, err := proxyURL(c); err != nil {
+		return nil, err
+	} else if u != nil && !grpc.IsUnix(c.String(GRPCConnectFlag.Name)) {
+		opt, err := grpc.WithProxy(u)
+		if err != nil {
+			return nil, err
+		}
+		opts = append(opts, grpc.WithDialOptions(opt))
+	}
+
+	if id := c.String(GRPCBeaconIDFlag.Name); id != "" {
+		opts = append(opts, grpc.WithBeaconID(id))
+	}
+	if c.IsSet(GRPCMetadataFlag.Name) {
+		md, err := parseMetadata(c.StringSlice(GRPCMetadataFlag.Name))
+		if err != nil {
+			return nil, err
+		}
+		opts = append(opts, grpc.WithMetadata(md))
+	}
+	return opts, nil
+}
+
+// parseMetadata parses the KEY=VALUE values of GRPCMetadataFlag.
+func parseMetadata(values []string) (metadata.MD, error) {
+	md := make(metadata.MD, len(values))
+	for _, v := range values {
+		key, value, ok := strings.Cut(v, "=")
+		if !ok || key == "" {
+			return nil, fmt.Errorf("invalid --%s %q: expected KEY=VALUE", GRPCMetadataFlag.Name, v)
+		}
+		if strings.HasPrefix(strings.ToLower(key), "grpc-") {
+			return nil, fmt.Errorf("invalid --%s %q: the grpc- keys are reserved by gRPC", GRPCMetadataFlag.Name, v)
+		}
+		md.Append(key, value)
+	}
+	return md, nil
+}
+
+// proxyURL returns the proxy given with ProxyFlag, if any.
+func proxyURL(c *cli.Context) (*url.URL, error) {
+	p := c.String(ProxyFlag.Name)
+	if p == "" {
+		return nil, nil
+	}
+	u, err := url.Parse(p)
+	if err != nil {
+		return nil, fmt.Errorf("invalid --%s: %w", ProxyFlag.Name, err)
+	}
+	return u, nil
+}
+
+// HTTPOptions returns the options of the HTTP clients built from the flags.
+func HTTPOptions(c *cli.Context) ([]http2.Option, error) {
+	var opts []http2.Option
+	u, err := proxyURL(c)
+	if err != nil {
+		return nil, err
+	} else if u != nil {
+		opts = append(opts, http2.WithProxy(u))
+	}
+
+	if c.IsSet(ResolveFlag.Name) {
+		hosts, err := parseHosts(c.StringSlice(ResolveFlag.Name))
+		if err != nil {
+			return nil, err
+		}
+		opts = append(opts, http2.WithHosts(hosts))
+	}
+	if c.IsSet(IPPreferenceFlag.Name) {
+		p, err := http2.ParseIPPreference(c.String(IPPreferenceFlag.Name))
+		if err != nil {
+			return nil, fmt.Errorf("invalid --%s: %w", IPPreferenceFlag.Name, err)
+		}
+		opts = append(opts, http2.WithIPPreference(p))
+	}
+	if c.IsSet(ResolveIntervalFlag.Name) {
+		opts = append(opts, http2.WithResolveInterval(c.Duration(ResolveIntervalFlag.Name)))
+	}
+	return opts, nil
+}
+
+// parseHosts parses the HOST=IP values of ResolveFlag.
+func parseHosts(values []string) (map[string][]netip.Addr, error) {
+	hosts := make(map[string][]netip.Addr, len(values))
+	for _, v := range values {
+		host, ip, ok := strings.Cut(v, "=")
+		if !ok || host == "" {
+			return nil, fmt.Errorf("invalid --%s %q: expected HOST=IP", ResolveFlag.Name, v)
+		}
+		addr, err := netip.ParseAddr(ip)
+		if err != nil {
+			return nil, fmt.Errorf("invalid --%s %q: %w", ResolveFlag.Name, v, err)
+		}
+		hosts[host] = append(hosts[host], addr)
+	}
+	return hosts, nil
+}
+
 func buildHTTPClients(c *cli.Context, l log.Logger, hash []byte, withInstrumentation bool) ([]drand.Client, *chainCommon.Info, error) {
 	ctx := c.Context
 	clients := make([]drand.Client, 0)
 	var err error
-	var skipped []string
+	var skipped []http2.RelayURL
 	var hc drand.Client
 	var info *chainCommon.Info
 
 	urls := c.StringSlice(URLFlag.Name)
+	hopts, err := HTTPOptions(c)
+	if err != nil {
+		return nil, nil, err
+	}
 
 	l.Infow("Building HTTP clients", "hash", len(hash), "urls", len(urls))
 
@@ -257,17 +542,18 @@ func buildHTTPClients(c *cli.Context, l log.Logger, hash []byte, withInstrumenta
 		return clients, nil, nil
 	}
 
-	for _, url := range urls {
-		l.Debugw("trying to instantiate http client", "url", url)
-		hc, err = http2.New(ctx, l, url, hash, nhttp.DefaultTransport)
+	relays := http2.ExpandURLs(ctx, l, urls)
+	for _, r := range relays {
+		l.Debugw("trying to instantiate http client", "url", r.URL)
+		hc, err = http2.New(ctx, l, r.URL, hash, nil, r.Options(hopts...)...)
 		if err != nil {
-			l.Warnw("", "client", "failed to load URL", "url", url, "err", err)
-			skipped = append(skipped, url)
+			l.Warnw("", "client", "failed to load URL", "url", r.URL, "err", err)
+			skipped = append(skipped, r)
 			continue
 		}
 		info, err = hc.Info(ctx)
 		if err != nil {
-			l.Warnw("", "client", "failed to load Info from URL", "url", url, "err", err)
+			l.Warnw("", "client", "failed to load Info from URL", "url", r.URL, "err", err)
 			continue
 		}
 
@@ -275,7 +561,7 @@ func buildHTTPClients(c *cli.Context, l log.Logger, hash []byte, withInstrumenta
 	}
 
 	// do we want to error out or not if all provided URL failed to instantiate a client?
-	if len(skipped) == len(c.StringSlice(URLFlag.Name)) {
+	if len(skipped) == len(relays) {
 		return nil, nil, errors.New("all URLs failed to be used for creating a http client")
 	}
 
@@ -287,10 +573,10 @@ func buildHTTPClients(c *cli.Context, l log.Logger, hash []byte, withInstrumenta
 
 		// we re-try dialing the skipped remotes, just in case, but that's the last time, we won't be dialing these again
 		// later in case they fail.
-		for _, url := range skipped {
-			hc, err = http2.NewWithInfo(l, url, info, nhttp.DefaultTransport)
+		for _, r := range skipped {
+			hc, err = http2.NewWithInfo(l, r.URL, info, nil, r.Options(hopts...)...)
 			if err != nil {
-				l.Warnw("", "client", "failed to load URL again", "url", url, "err", err)
+				l.Warnw("", "client", "failed to load URL again", "url", r.URL, "err", err)
 				continue
 			}
 			clients = append(clients, hc)
@@ -304,54 +590,147 @@ func buildHTTPClients(c *cli.Context, l log.Logger, hash []byte, withInstrumenta
 	return clients, info, nil
 }
 
-func buildGossipClient(c *cli.Context, l log.Logger) ([]client.Option, error) {
-	if c.IsSet(RelayFlag.Name) {
-		addrs := c.StringSlice(RelayFlag.Name)
-		if len(addrs) > 0 {
-			relayPeers, err := lp2p.ParseMultiaddrSlice(addrs)
+// CreateMulti builds a client for each of the chains of the group
+// configuration or chain info files given with GroupConfListFlag, directories
+// included, and can be invoked from a cli action supplied with ClientFlags.
+// Each chain is fetched from the URLs serving it, matched by chain hash and
+// beacon ID: URLs which do not serve a chain, or are unreachable at setup, are
+// not used for it. Chains which no URL serves are skipped, and an error is only
+// returned when no client can be made at all.
+func CreateMulti(c *cli.Context, withInstrumentation bool, opts ...client.Option) (*client.MultiClient, error) {
+	level := log.WarnLevel
+	if c.Bool(VerboseFlag.Name) {
+		level = log.DebugLevel
+	}
+	l := log.New(nil, level, false)
+
+	infos, err := ChainInfosFromGroupConfs(l, c.StringSlice(GroupConfListFlag.Name))
+	if err != nil {
+		return nil, err
+	}
+	if len(infos) == 0 {
+		return nil, fmt.Errorf("no chain info found in --%s", GroupConfListFlag.Name)
+	}
+	urls := c.StringSlice(URLFlag.Name)
+	if len(urls) == 0 {
+		return nil, fmt.Errorf("at least one --%s is required", URLFlag.Name)
+	}
+	if c.IsSet(CacheSizeFlag.Name) {
+		opts = append(opts, client.WithCacheSize(c.Int(CacheSizeFlag.Name)))
+	}
+	hopts, err := HTTPOptions(c)
+	if err != nil {
+		return nil, err
+	}
+
+	relays := http2.ExpandURLs(c.Context, l, urls)
+	clients := make(map[string]drand.Client, len(infos))
+	var errs error
+	for _, info := range infos {
+		hcs := make([]drand.Client, 0, len(relays))
+		for _, r := range relays {
+			bopts := append([]http2.Option{http2.WithBeaconID(info.ID)}, hopts...)
+			hc, err := http2.New(c.Context, l, r.URL, info.Hash(), nil, r.Options(bopts...)...)
 			if err != nil {
-				return nil, err
-			}
-			listen := ""
-			if c.IsSet(PortFlag.Name) {
-				listen = c.String(PortFlag.Name)
+				l.Debugw("", "client", "URL does not serve chain", "url", r.URL, "beaconID", info.ID, "err", err)
+				continue
 			}
-			ps, err := buildClientHost(l, listen, relayPeers)
-			if err != nil {
-				return nil, err
+			hcs = append(hcs, hc)
+		}
+		if len(hcs) == 0 {
+			l.Warnw("", "client", "no URL serves chain", "beaconID", info.ID, "chainHash", info.HashString())
+			errs = errors.Join(errs, fmt.Errorf("no URL serves beacon %q (%s)", info.ID, info.HashString()))
+			continue
+		}
+		if withInstrumentation {
+			http2.MeasureHeartbeats(c.Context, hcs)
+		}
+
+		copts := append([]client.Option{client.WithChainInfo(info)}, opts...)
+		cc, err := client.Wrap(hcs, copts...)
+		if err != nil {
+			errs = errors.Join(errs, fmt.Errorf("beacon %q: %w", info.ID, err))
+			continue
+		}
+		clients[info.HashString()] = cc
+	}
+
+	if len(clients) == 0 {
+		return nil, fmt.Errorf("no chain could be followed: %w", errs)
+	}
+	return client.NewMultiClient(clients), nil
+}
+
+// GroupConfFiles expands the given group configuration paths: directories
+// are replaced by the files they contain, sorted by name, leaving out hidden
+// files and subdirectories.
+func GroupConfFiles(paths []string) ([]string, error) {
+	files := make([]string, 0, len(paths))
+	for _, p := range paths {
+		fi, err := os.Stat(p)
+		if err != nil {
+			return nil, err
+		}
+		if !fi.IsDir() {
+			files = append(files, p)
+			continue
+		}
+		entries, err := os.ReadDir(p)
+		if err != nil {
+			return nil, err
+		}
+		for _, e := range entries {
+			if e.IsDir() || strings.HasPrefix(e.Name(), ".") {
+				continue
 			}
-			return []client.Option{gclient.WithPubsub(ps)}, nil
+			files = append(files, filepath.Join(p, e.Name()))
 		}
 	}
-	return []client.Option{}, nil
+	return files, nil
 }
 
-func buildClientHost(l log.Logger, clientListenAddr string, relayMultiaddr []ma.Multiaddr) (*pubsub.PubSub, error) {
-	clientID := uuid.New().String()
-	priv, err := lp2p.LoadOrCreatePrivKey(path.Join(os.TempDir(), "drand-"+clientID+"-id"), l)
+// ChainInfosFromGroupConfs reads the chain info of each of the group
+// configuration (TOML encoded) or chain info (JSON encoded) files of paths,
+// directories included, see GroupConfFiles. A chain given by several files is
+// only returned once, and different chains of the same beacon ID are rejected,
+// since they could not be told apart by relays.
+func ChainInfosFromGroupConfs(l log.Logger, paths []string) ([]*chainCommon.Info, error) {
+	files, err := GroupConfFiles(paths)
 	if err != nil {
 		return nil, err
 	}
-
-	listen := ""
-	if clientListenAddr != "" {
-		bindHost := "0.0.0.0"
-		if strings.Contains(clientListenAddr, ":") {
-			host, port, err := net.SplitHostPort(clientListenAddr)
-			if err != nil {
-				return nil, err
+	infos := make([]*chainCommon.Info, 0, len(files))
+	byID := make(map[string]*chainCommon.Info, len(files))
+	for _, f := range files {
+		info, err := chainInfoFromFile(l, f)
+		if err != nil {
+			return nil, err
+		}
+		id := common.GetCanonicalBeaconID(info.ID)
+		if prev, ok := byID[id]; ok {
+			if bytes.Equal(prev.Hash(), info.Hash()) {
+				continue
 			}
-			bindHost = host
-			clientListenAddr = port
+			return nil, fmt.Errorf("%w: %s is another chain of beacon %q", drand.ErrInvalidChainHash, f, id)
 		}
-		listen = fmt.Sprintf("/ip4/%s/tcp/%s", bindHost, clientListenAddr)
+		byID[id] = info
+		infos = append(infos, info)
 	}
+	return infos, nil
+}
 
-	_, ps, err := lp2p.ConstructHost(priv, listen, relayMultiaddr, l)
-	if err != nil {
-		return nil, err
+// chainInfoFromFile reads a drand group TOML file or a chain info JSON file.
+func chainInfoFromFile(l log.Logger, path string) (*chainCommon.Info, error) {
+	info, err := chainInfoFromGroupTOML(path)
+	if err == nil {
+		return info, nil
+	}
+	l.Infow("Got a group conf file that is not a toml file. Trying it as a ChainInfo json file.", "path", path)
+	info, err = chainInfoFromChainInfoJSON(path)
+	if info == nil || err != nil {
+		return nil, fmt.Errorf("failed to decode group (%s) : %w", path, err)
 	}
-	return ps, nil
+	return info, nil
 }
 
 // chainInfoFromGroupTOML reads a drand group TOML file and returns the chain info.
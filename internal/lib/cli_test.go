@@ -2,8 +2,10 @@ package lib
 
 import (
 	"bytes"
+	"context"
 	"encoding/hex"
 	"errors"
+	"net/netip"
 	"os"
 	"path/filepath"
 	"runtime"
@@ -13,13 +15,17 @@ import (
 	clock "github.com/jonboulle/clockwork"
 	"github.com/stretchr/testify/require"
 	"github.com/urfave/cli/v2"
+	"google.golang.org/grpc/metadata"
 
 	"github.com/drand/go-clients/drand"
 
+	"github.com/drand/drand/v2/common/chain"
+	"github.com/drand/drand/v2/common/key"
 	"github.com/drand/drand/v2/common/log"
 	"github.com/drand/drand/v2/crypto"
 	"github.com/drand/go-clients/client"
 	httpmock "github.com/drand/go-clients/client/test/http/mock"
+	"github.com/drand/go-clients/clienttest"
 )
 
 var (
@@ -158,3 +164,157 @@ func groupTOMLPath() string {
 	}
 	return filepath.Join(filepath.Dir(file), "..", "..", "internal", "testdata", "default.toml")
 }
+
+func TestStartMetrics(t *testing.T) {
+	var served []bool
+	app := cli.NewApp()
+	app.Name = "mock-client"
+	app.Flags = []cli.Flag{MetricsFlag}
+	app.Action = func(c *cli.Context) error {
+		ok, err := StartMetrics(c)
+		served = append(served, ok)
+		return err
+	}
+
+	require.NoError(t, app.Run([]string{"mock-client"}))
+	require.NoError(t, app.Run([]string{"mock-client", "--metrics", "127.0.0.1:0"}))
+	require.Equal(t, []bool{false, true}, served)
+	require.Error(t, app.Run([]string{"mock-client", "--metrics", "256.0.0.1:0"}))
+}
+
+func TestHTTPOptions(t *testing.T) {
+	var counts []int
+	app := cli.NewApp()
+	app.Name = "mock-client"
+	app.Flags = []cli.Flag{ProxyFlag, ResolveFlag, IPPreferenceFlag, ResolveIntervalFlag}
+	app.Action = func(c *cli.Context) error {
+		opts, err := HTTPOptions(c)
+		counts = append(counts, len(opts))
+		return err
+	}
+
+	require.NoError(t, app.Run([]string{"mock-client"}))
+	require.NoError(t, app.Run([]string{"mock-client", "--resolve", "api.drand.sh=192.0.2.1",
+		"--resolve", "api.drand.sh=2001:db8::1", "--resolve", "api2.drand.sh=192.0.2.2", "--ip-preference", "prefer-ipv6", "--resolve-interval", "5m"}))
+	require.Equal(t, []int{0, 3}, counts)
+	require.Error(t, app.Run([]string{"mock-client", "--resolve", "api.drand.sh"}))
+	require.Error(t, app.Run([]string{"mock-client", "--resolve", "api.drand.sh=localhost"}))
+	require.Error(t, app.Run([]string{"mock-client", "--ip-preference", "ipv5"}))
+
+	hosts, err := parseHosts([]string{"a.invalid=192.0.2.1", "b.invalid=192.0.2.2", "a.invalid=2001:db8::1"})
+	require.NoError(t, err)
+	require.Equal(t, map[string][]netip.Addr{
+		"a.invalid": {netip.MustParseAddr("192.0.2.1"), netip.MustParseAddr("2001:db8::1")},
+		"b.invalid": {netip.MustParseAddr("192.0.2.2")},
+	}, hosts)
+}
+
+func TestGRPCOptions(t *testing.T) {
+	var counts []int
+	app := cli.NewApp()
+	app.Name = "mock-client"
+	app.Flags = []cli.Flag{GRPCConnectFlag, GRPCBeaconIDFlag, GRPCMetadataFlag, ProxyFlag}
+	app.Action = func(c *cli.Context) error {
+		opts, err := GRPCOptions(c)
+		counts = append(counts, len(opts))
+		return err
+	}
+
+	require.NoError(t, app.Run([]string{"mock-client"}))
+	require.NoError(t, app.Run([]string{"mock-client", "--grpc-connect", "127.0.0.1:4444", "--proxy", "socks5://127.0.0.1:9050",
+		"--grpc-beacon-id", "quicknet", "--grpc-metadata", "authorization=Bearer a=b", "--grpc-metadata", "X-Relay=a"}))
+	require.Equal(t, []int{0, 3}, counts)
+	require.Error(t, app.Run([]string{"mock-client", "--grpc-metadata", "authorization"}))
+	require.Error(t, app.Run([]string{"mock-client", "--grpc-metadata", "grpc-timeout=1S"}))
+
+	md, err := parseMetadata([]string{"authorization=Bearer a=b", "X-Relay=a", "x-relay=b"})
+	require.NoError(t, err)
+	require.Equal(t, metadata.MD{"authorization": {"Bearer a=b"}, "x-relay": {"a", "b"}}, md)
+}
+
+func TestNewLogger(t *testing.T) {
+	var levels []int
+	app := cli.NewApp()
+	app.Name = "mock-client"
+	app.Flags = []cli.Flag{JSONFlag, VerboseFlag, LogLevelFlag}
+	app.Action = func(c *cli.Context) error {
+		if _, err := NewLogger(c, log.InfoLevel); err != nil {
+			return err
+		}
+		level, _ := logLevel(c, log.InfoLevel)
+		levels = append(levels, level)
+		return nil
+	}
+
+	require.NoError(t, app.Run([]string{"mock-client"}))
+	require.NoError(t, app.Run([]string{"mock-client", "--verbose"}))
+	require.NoError(t, app.Run([]string{"mock-client", "--verbose", "--log-level", "warn"}))
+	require.NoError(t, app.Run([]string{"mock-client", "--json", "--log-level", "DEBUG"}))
+	require.Equal(t, []int{log.InfoLevel, log.DebugLevel, log.WarnLevel, log.DebugLevel}, levels)
+	require.Error(t, app.Run([]string{"mock-client", "--log-level", "loud"}))
+}
+
+func TestCreateMulti(t *testing.T) {
+	defaultRelay := clienttest.NewRelay(t)
+	quicknet := clienttest.NewRelay(t, clienttest.WithBeaconID("quicknet"), clienttest.WithoutV2())
+
+	dir := t.TempDir()
+	for name, info := range map[string]*chain.Info{
+		"default.json":  defaultRelay.Info(),
+		"quicknet.json": quicknet.Info(),
+		".hidden.json":  fakeChainInfo(t),
+	} {
+		var b bytes.Buffer
+		require.NoError(t, info.ToJSON(&b, nil))
+		require.NoError(t, os.WriteFile(filepath.Join(dir, name), b.Bytes(), 0o644))
+	}
+	require.NoError(t, os.Mkdir(filepath.Join(dir, "sub"), 0o755))
+
+	files, err := GroupConfFiles([]string{dir, groupTOMLPath()})
+	require.NoError(t, err)
+	require.Equal(t, []string{filepath.Join(dir, "default.json"), filepath.Join(dir, "quicknet.json"), groupTOMLPath()}, files)
+
+	var mc *client.MultiClient
+	app := cli.NewApp()
+	app.Name = "mock-client"
+	app.Flags = ClientFlags
+	app.Action = func(c *cli.Context) (err error) {
+		mc, err = CreateMulti(c, false)
+		return err
+	}
+	require.NoError(t, app.Run([]string{"mock-client", "--url", defaultRelay.URL(), "--url", quicknet.URL(), "--group-conf-list", dir}))
+	defer mc.Close()
+	require.ElementsMatch(t, []string{defaultRelay.Info().HashString(), quicknet.Info().HashString()}, mc.Chains())
+
+	ctx := context.Background()
+	for _, relay := range []*clienttest.Relay{defaultRelay, quicknet} {
+		c, ok := mc.Client(relay.Info().HashString())
+		require.True(t, ok)
+		r, err := c.Get(ctx, 2)
+		require.NoError(t, err)
+		require.Equal(t, relay.Result(2).GetRandomness(), r.GetRandomness())
+	}
+
+	// another chain of the quicknet beacon cannot be matched to relays
+	other := clienttest.NewRelay(t, clienttest.WithBeaconID("quicknet"))
+	var b bytes.Buffer
+	require.NoError(t, other.Info().ToJSON(&b, nil))
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "other.json"), b.Bytes(), 0o644))
+	_, err = ChainInfosFromGroupConfs(log.DefaultLogger(), []string{dir})
+	require.ErrorIs(t, err, drand.ErrInvalidChainHash)
+}
+
+// fakeChainInfo creates a chain info object for use in tests.
+func fakeChainInfo(t *testing.T) *chain.Info {
+	t.Helper()
+	sch, err := crypto.GetSchemeFromEnv()
+	require.NoError(t, err)
+	pair, err := key.NewKeyPair("fakeChainInfo.test:1234", sch)
+	require.NoError(t, err)
+	return &chain.Info{
+		Period:      time.Second,
+		GenesisTime: time.Now().Unix(),
+		PublicKey:   pair.Public.Key,
+		Scheme:      sch.Name,
+	}
+}
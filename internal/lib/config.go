@@ -0,0 +1,134 @@
+package lib
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/BurntSushi/toml"
+	"github.com/urfave/cli/v2"
+	"gopkg.in/yaml.v3"
+)
+
+// ConfigFlag is the CLI flag for a configuration file setting the values of
+// the other flags of a command, see LoadConfig.
+var ConfigFlag = &cli.PathFlag{
+	Name: "config",
+	Usage: "Path to a configuration file (TOML, or YAML with a .yaml/.yml extension) whose keys are flag names, " +
+		"used for the flags not set on the command line or from the environment",
+	EnvVars: []string{"DRAND_CLIENT_CONFIG"},
+}
+
+// LoadConfig sets the flags of the command from the configuration file given
+// with ConfigFlag, if any. It is meant to be used as the Before function of
+// commands having ConfigFlag.
+//
+// The keys of the file are the names of the flags of the command, and its
+// values are strings, numbers, booleans, or lists of them for flags that can
+// be repeated, e.g.
+//
+//	url = ["https://api.drand.sh", "https://drand.cloudflare.com"]
+//	hash-list = ["52db9ba70e0cc0f6eaf7803dd07447a1f5477735fd3f661792ba94600c84e971"]
+//	cache-size = 64
+//
+// Flags set on the command line or from the environment take precedence over
+// the file.
+func LoadConfig(c *cli.Context) error {
+	path := c.Path(ConfigFlag.Name)
+	if path == "" {
+		return nil
+	}
+	values, err := readConfig(path)
+	if err != nil {
+		return fmt.Errorf("reading config %q: %w", path, err)
+	}
+
+	flags := c.App.Flags
+	if c.Command != nil && len(c.Command.Flags) > 0 {
+		flags = c.Command.Flags
+	}
+	known := make(map[string]bool)
+	for _, f := range flags {
+		for _, name := range f.Names() {
+			known[name] = true
+		}
+	}
+
+	// sorted, so that errors do not depend on the map order
+	keys := make([]string, 0, len(values))
+	for k := range values {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+
+	for _, name := range keys {
+		if !known[name] || name == ConfigFlag.Name {
+			return fmt.Errorf("config %q: unknown flag %q", path, name)
+		}
+		if c.IsSet(name) {
+			continue
+		}
+		vals, err := configValues(values[name])
+		if err != nil {
+			return fmt.Errorf("config %q: flag %q: %w", path, name, err)
+		}
+		for _, v := range vals {
+			if err := c.Set(name, v); err != nil {
+				return fmt.Errorf("config %q: setting flag %q: %w", path, name, err)
+			}
+		}
+	}
+	return nil
+}
+
+// readConfig decodes a configuration file, as YAML or TOML depending on its extension.
+func readConfig(path string) (map[string]any, error) {
+	b, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	values := make(map[string]any)
+	switch strings.ToLower(filepath.Ext(path)) {
+	case ".yaml", ".yml":
+		err = yaml.Unmarshal(b, &values)
+	default:
+		err = toml.Unmarshal(b, &values)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("decoding: %w", err)
+	}
+	return values, nil
+}
+
+// configValues formats the value of a flag, one string per repetition of the flag.
+func configValues(v any) ([]string, error) {
+	switch v := v.(type) {
+	case []any:
+		out := make([]string, 0, len(v))
+		for _, e := range v {
+			s, err := configValue(e)
+			if err != nil {
+				return nil, err
+			}
+			out = append(out, s)
+		}
+		return out, nil
+	default:
+		s, err := configValue(v)
+		if err != nil {
+			return nil, err
+		}
+		return []string{s}, nil
+	}
+}
+
+func configValue(v any) (string, error) {
+	switch v := v.(type) {
+	case string, bool, int, int64, uint64, float64:
+		return fmt.Sprint(v), nil
+	default:
+		return "", fmt.Errorf("unsupported value of type %T", v)
+	}
+}
@@ -0,0 +1,97 @@
+package lib
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	"github.com/urfave/cli/v2"
+)
+
+type configResult struct {
+	urls      []string
+	cacheSize int
+	insecure  bool
+}
+
+func runWithConfig(t *testing.T, args ...string) (configResult, error) {
+	t.Helper()
+	var res configResult
+	app := cli.NewApp()
+	app.Name = "mock-client"
+	app.Flags = ClientFlags
+	app.Before = LoadConfig
+	app.Action = func(c *cli.Context) error {
+		res = configResult{
+			urls:      c.StringSlice(URLFlag.Name),
+			cacheSize: c.Int(CacheSizeFlag.Name),
+			insecure:  c.Bool(InsecureFlag.Name),
+		}
+		return nil
+	}
+	err := app.Run(append([]string{"mock-client"}, args...))
+	return res, err
+}
+
+func writeConfig(t *testing.T, name, content string) string {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), name)
+	require.NoError(t, os.WriteFile(path, []byte(content), 0o600))
+	return path
+}
+
+func TestLoadConfig(t *testing.T) {
+	expected := configResult{
+		urls:      []string{"http://a.example.org", "http://b.example.org"},
+		cacheSize: 64,
+		insecure:  true,
+	}
+
+	toml := writeConfig(t, "client.toml", `
+url = ["http://a.example.org", "http://b.example.org"]
+cache-size = 64
+insecure = true
+`)
+	res, err := runWithConfig(t, "--config", toml)
+	require.NoError(t, err)
+	require.Equal(t, expected, res)
+
+	yaml := writeConfig(t, "client.yaml", `
+url:
+  - http://a.example.org
+  - http://b.example.org
+cache-size: 64
+insecure: true
+`)
+	res, err = runWithConfig(t, "--config", yaml)
+	require.NoError(t, err)
+	require.Equal(t, expected, res)
+
+	// flags set on the command line take precedence
+	res, err = runWithConfig(t, "--config", toml, "--url", "http://c.example.org", "--cache-size", "8")
+	require.NoError(t, err)
+	require.Equal(t, []string{"http://c.example.org"}, res.urls)
+	require.Equal(t, 8, res.cacheSize)
+	require.True(t, res.insecure)
+
+	t.Setenv("DRAND_CLIENT_CONFIG", yaml)
+	res, err = runWithConfig(t)
+	require.NoError(t, err)
+	require.Equal(t, expected, res)
+}
+
+func TestLoadConfigErrors(t *testing.T) {
+	for name, content := range map[string]string{
+		"unknown.toml":   `unknown-flag = "value"`,
+		"table.toml":     "[url]\nhost = \"a.example.org\"",
+		"invalid.yaml":   "url: [",
+		"recursive.toml": `config = "other.toml"`,
+	} {
+		_, err := runWithConfig(t, "--config", writeConfig(t, name, content))
+		require.Error(t, err, name)
+	}
+
+	_, err := runWithConfig(t, "--config", filepath.Join(t.TempDir(), "missing.toml"))
+	require.Error(t, err)
+}
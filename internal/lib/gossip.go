@@ -0,0 +1,156 @@
+//go:build !nolibp2p
+
+package lib
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"os"
+	"path"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	pubsub "github.com/libp2p/go-libp2p-pubsub"
+	"github.com/libp2p/go-libp2p/core/host"
+	"github.com/libp2p/go-libp2p/core/peer"
+	ma "github.com/multiformats/go-multiaddr"
+	madns "github.com/multiformats/go-multiaddr-dns"
+	"github.com/urfave/cli/v2"
+
+	"github.com/drand/drand/v2/common/log"
+	"github.com/drand/go-clients/client"
+	gclient "github.com/drand/go-clients/client/lp2p"
+	"github.com/drand/go-clients/internal/lp2p"
+)
+
+func buildGossipClient(c *cli.Context, l log.Logger) ([]client.Option, error) {
+	var relayPeers []ma.Multiaddr
+	if c.IsSet(RelayFlag.Name) {
+		addrs := c.StringSlice(RelayFlag.Name)
+		peers, err := lp2p.ParseMultiaddrSlice(addrs)
+		if err != nil {
+			return nil, err
+		}
+		relayPeers = peers
+	}
+	relayDNS := c.String(RelayDNSFlag.Name)
+	if len(relayPeers) == 0 && relayDNS == "" {
+		return []client.Option{}, nil
+	}
+
+	listen := ""
+	if c.IsSet(PortFlag.Name) {
+		listen = c.String(PortFlag.Name)
+	}
+	cfg := &lp2p.HostConfig{
+		ListenAddrs: c.StringSlice(ClientListenFlag.Name),
+		NAT:         NATConfig(c),
+	}
+	if keyPath := c.Path(PNetKeyFlag.Name); keyPath != "" {
+		psk, err := lp2p.LoadPNetKey(keyPath)
+		if err != nil {
+			return nil, err
+		}
+		cfg.PSK = psk
+	}
+	h, ps, err := buildClientHost(l, listen, cfg, relayPeers)
+	if err != nil {
+		return nil, err
+	}
+	if relayDNS != "" {
+		go lp2p.WatchDNSPeers(c.Context, l, h, nil, relayDNS, lp2p.DefaultDNSPeersRefresh)
+	}
+	return []client.Option{gclient.WithPubsub(ps, gclient.WithTopicNamespace(c.String(TopicNamespaceFlag.Name)))}, nil
+}
+
+// NATConfig returns the NAT traversal configuration given with the
+// NATPortMapFlag, HolePunchingFlag and CircuitRelayFlag flags.
+func NATConfig(c *cli.Context) lp2p.NATConfig {
+	return lp2p.NATConfig{
+		PortMap:      c.Bool(NATPortMapFlag.Name),
+		HolePunching: c.Bool(HolePunchingFlag.Name),
+		StaticRelays: c.StringSlice(CircuitRelayFlag.Name),
+	}
+}
+
+//nolint:lll // This function has nicely named parameters, so it's long.
+func buildClientHost(l log.Logger, clientListenAddr string, cfg *lp2p.HostConfig, relayMultiaddr []ma.Multiaddr) (host.Host, *pubsub.PubSub, error) {
+	clientID := uuid.New().String()
+	priv, err := lp2p.LoadOrCreatePrivKey(path.Join(os.TempDir(), "drand-"+clientID+"-id"), l)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	if clientListenAddr != "" {
+		bindHost := "0.0.0.0"
+		if strings.Contains(clientListenAddr, ":") {
+			bindAddr, port, err := net.SplitHostPort(clientListenAddr)
+			if err != nil {
+				return nil, nil, err
+			}
+			bindHost = bindAddr
+			clientListenAddr = port
+		}
+		cfg.ListenAddrs = append([]string{fmt.Sprintf("/ip4/%s/tcp/%s", bindHost, clientListenAddr)}, cfg.ListenAddrs...)
+	}
+
+	return lp2p.ConstructHostWithConfig(priv, cfg, relayMultiaddr, l)
+}
+
+// ResolveRelay resolves the DNS components of the multiaddr of a relay peer,
+// e.g. /dnsaddr/, and returns the resulting multiaddrs.
+func ResolveRelay(ctx context.Context, addr string) ([]string, error) {
+	m, err := ma.NewMultiaddr(addr)
+	if err != nil {
+		return nil, err
+	}
+	resolved, err := madns.DefaultResolver.Resolve(ctx, m)
+	if err != nil {
+		return nil, err
+	}
+	addrs := make([]string, 0, len(resolved))
+	for _, r := range resolved {
+		addrs = append(addrs, r.String())
+	}
+	return addrs, nil
+}
+
+// DialRelay connects to the relay peer of the given multiaddrs, as resolved
+// by ResolveRelay, from a libp2p host configured by the flags like the one of
+// the gossip client, and returns how long connecting took.
+func DialRelay(ctx context.Context, c *cli.Context, l log.Logger, addrs []string) (time.Duration, error) {
+	maddrs, err := lp2p.ParseMultiaddrSlice(addrs)
+	if err != nil {
+		return 0, err
+	}
+	infos, err := peer.AddrInfosFromP2pAddrs(maddrs...)
+	if err != nil {
+		return 0, err
+	}
+	if len(infos) == 0 {
+		return 0, errors.New("no peer address")
+	}
+
+	cfg := &lp2p.HostConfig{NAT: NATConfig(c)}
+	if keyPath := c.Path(PNetKeyFlag.Name); keyPath != "" {
+		psk, err := lp2p.LoadPNetKey(keyPath)
+		if err != nil {
+			return 0, err
+		}
+		cfg.PSK = psk
+	}
+	h, _, err := buildClientHost(l, "", cfg, nil)
+	if err != nil {
+		return 0, err
+	}
+	defer h.Close()
+
+	start := time.Now()
+	if err := h.Connect(ctx, infos[0]); err != nil {
+		return 0, err
+	}
+	return time.Since(start), nil
+}
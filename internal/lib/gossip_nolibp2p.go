@@ -0,0 +1,35 @@
+//go:build nolibp2p
+
+package lib
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/urfave/cli/v2"
+
+	"github.com/drand/drand/v2/common/log"
+	"github.com/drand/go-clients/client"
+)
+
+// buildGossipClient refuses relay peers, since this binary was built without libp2p support.
+func buildGossipClient(c *cli.Context, _ log.Logger) ([]client.Option, error) {
+	if c.IsSet(RelayFlag.Name) && len(c.StringSlice(RelayFlag.Name)) > 0 {
+		return nil, fmt.Errorf("--%s is not supported: built with the nolibp2p tag", RelayFlag.Name)
+	}
+	if c.String(RelayDNSFlag.Name) != "" {
+		return nil, fmt.Errorf("--%s is not supported: built with the nolibp2p tag", RelayDNSFlag.Name)
+	}
+	return []client.Option{}, nil
+}
+
+// ResolveRelay fails, since this binary was built without libp2p support.
+func ResolveRelay(context.Context, string) ([]string, error) {
+	return nil, fmt.Errorf("--%s is not supported: built with the nolibp2p tag", RelayFlag.Name)
+}
+
+// DialRelay fails, since this binary was built without libp2p support.
+func DialRelay(context.Context, *cli.Context, log.Logger, []string) (time.Duration, error) {
+	return 0, fmt.Errorf("--%s is not supported: built with the nolibp2p tag", RelayFlag.Name)
+}
@@ -1,13 +1,15 @@
+//go:build !nolibp2p
+
 package lp2p
 
 import (
 	"context"
-	"crypto/rand"
-	"encoding/base64"
+	"crypto/tls"
 	"fmt"
 	mrand "math/rand"
+	"net"
 	"os"
-	"path"
+	"strings"
 	"time"
 
 	"github.com/libp2p/go-libp2p"
@@ -16,10 +18,16 @@ import (
 	"github.com/libp2p/go-libp2p/core/crypto"
 	"github.com/libp2p/go-libp2p/core/host"
 	"github.com/libp2p/go-libp2p/core/peer"
+	"github.com/libp2p/go-libp2p/core/pnet"
 	"github.com/libp2p/go-libp2p/p2p/host/peerstore/pstoremem"
 	"github.com/libp2p/go-libp2p/p2p/net/connmgr"
 	"github.com/libp2p/go-libp2p/p2p/security/noise"
 	libp2ptls "github.com/libp2p/go-libp2p/p2p/security/tls"
+	quic "github.com/libp2p/go-libp2p/p2p/transport/quic"
+	"github.com/libp2p/go-libp2p/p2p/transport/tcp"
+	libp2pwebrtc "github.com/libp2p/go-libp2p/p2p/transport/webrtc"
+	"github.com/libp2p/go-libp2p/p2p/transport/websocket"
+	webtransport "github.com/libp2p/go-libp2p/p2p/transport/webtransport"
 	ma "github.com/multiformats/go-multiaddr"
 	"github.com/pkg/errors"
 	"golang.org/x/crypto/blake2b"
@@ -40,13 +48,166 @@ const (
 	identityFilePerm          = 0600
 )
 
+// DefaultTopicNamespace is the prefix of the pubsub topics of the public
+// drand chains.
+const DefaultTopicNamespace = "/drand/pubsub/v0.0.0"
+
 // PubSubTopic generates a drand pubsub topic from a chain hash.
 func PubSubTopic(h string) string {
-	return fmt.Sprintf("/drand/pubsub/v0.0.0/%s", h)
+	return NamespacedTopic(DefaultTopicNamespace, h)
+}
+
+// NamespacedTopic returns the pubsub topic of the chain of hash h in the
+// namespace ns, or in DefaultTopicNamespace if ns is empty.
+func NamespacedTopic(ns, h string) string {
+	if ns == "" {
+		ns = DefaultTopicNamespace
+	}
+	return strings.TrimSuffix(ns, "/") + "/" + h
+}
+
+// HostConfig configures the addresses and transports of a libp2p host.
+type HostConfig struct {
+	// ListenAddrs are the multiaddrs the host listens on, e.g.
+	// "/ip4/0.0.0.0/tcp/44544" for TCP, "/ip4/0.0.0.0/udp/44544/quic-v1" for
+	// QUIC or "/ip4/0.0.0.0/tcp/44545/ws" for WebSocket. The host does not
+	// listen when there are none.
+	ListenAddrs []string
+	// AnnounceAddrs, if set, are advertised to peers instead of the listen
+	// addrs, e.g. "/dns4/relay.example.org/tcp/443/wss" for a host behind a
+	// reverse proxy terminating TLS for its domain.
+	AnnounceAddrs []string
+	// AppendAnnounceAddrs are advertised to peers in addition to the listen
+	// addrs, or to AnnounceAddrs, e.g. the external address of a load
+	// balancer in front of the host.
+	AppendAnnounceAddrs []string
+	// NoAnnounceCIDRs are the IP ranges, e.g. "10.0.0.0/8", of the addrs not
+	// advertised to peers, e.g. the pod network of a Kubernetes cluster.
+	NoAnnounceCIDRs []string
+	// WebSocketTLS, if set, is used to serve the "/wss" listen addrs, e.g.
+	// "/ip4/0.0.0.0/tcp/443/wss", with a certificate for the domain of the host.
+	WebSocketTLS *tls.Config
+	// PSK, if set, is the pre-shared key of the private network the host
+	// joins: it only connects to the peers having the same key. Private
+	// networks only support the TCP and WebSocket transports.
+	PSK pnet.PSK
+	// NAT configures the traversal of the NAT the host may be behind.
+	NAT NATConfig
+}
+
+// NATConfig configures the NAT traversal of a libp2p host, so that hosts behind
+// NAT can still be reached, and reach each other without port forwarding.
+type NATConfig struct {
+	// PortMap tries to open a port on the NAT device with UPnP or NAT-PMP.
+	PortMap bool
+	// AutoNAT makes the host help its peers find out whether they are
+	// reachable, by dialing them back. Only publicly reachable hosts should
+	// enable it.
+	AutoNAT bool
+	// RelayService makes the host a circuit relay v2, relaying connections to
+	// the peers behind NAT which reserved a slot on it, once it finds out it is
+	// publicly reachable.
+	RelayService bool
+	// HolePunching upgrades the relayed connections of the host to direct
+	// ones, by punching holes in the NATs of both ends.
+	HolePunching bool
+	// StaticRelays are the multiaddrs of the circuit relays the host reserves
+	// a slot on when it is not publicly reachable, so that its peers can reach
+	// it through them.
+	StaticRelays []string
+}
+
+// options returns the libp2p options of the NAT traversal. The relay
+// transport is only enabled when used, i.e. to run or use circuit relays.
+func (n *NATConfig) options(ctx context.Context) ([]libp2p.Option, error) {
+	var opts []libp2p.Option
+	if n.PortMap {
+		opts = append(opts, libp2p.NATPortMap())
+	}
+	if n.AutoNAT {
+		opts = append(opts, libp2p.EnableNATService(), libp2p.EnableAutoNATv2())
+	}
+	if !n.RelayService && !n.HolePunching && len(n.StaticRelays) == 0 {
+		return append(opts, libp2p.DisableRelay()), nil
+	}
+	opts = append(opts, libp2p.EnableRelay())
+	if n.RelayService {
+		opts = append(opts, libp2p.EnableRelayService())
+	}
+	if n.HolePunching {
+		opts = append(opts, libp2p.EnableHolePunching())
+	}
+	if len(n.StaticRelays) > 0 {
+		addrs, err := ParseMultiaddrSlice(n.StaticRelays)
+		if err != nil {
+			return nil, fmt.Errorf("parsing static relays: %w", err)
+		}
+		relays, err := resolveAddresses(ctx, addrs, nil)
+		if err != nil {
+			return nil, fmt.Errorf("resolving static relays: %w", err)
+		}
+		opts = append(opts, libp2p.EnableAutoRelayWithStaticRelays(relays))
+	}
+	return opts, nil
+}
+
+// addrsFactory returns the function computing the addrs advertised to peers
+// from the listen addrs, or nil to advertise the listen addrs.
+func (cfg *HostConfig) addrsFactory() (func([]ma.Multiaddr) []ma.Multiaddr, error) {
+	if len(cfg.AnnounceAddrs) == 0 && len(cfg.AppendAnnounceAddrs) == 0 && len(cfg.NoAnnounceCIDRs) == 0 {
+		return nil, nil
+	}
+	announce, err := ParseMultiaddrSlice(cfg.AnnounceAddrs)
+	if err != nil {
+		return nil, fmt.Errorf("parsing announce addrs: %w", err)
+	}
+	appendAnnounce, err := ParseMultiaddrSlice(cfg.AppendAnnounceAddrs)
+	if err != nil {
+		return nil, fmt.Errorf("parsing append announce addrs: %w", err)
+	}
+	filters := ma.NewFilters()
+	for _, cidr := range cfg.NoAnnounceCIDRs {
+		_, ipnet, err := net.ParseCIDR(cidr)
+		if err != nil {
+			return nil, fmt.Errorf("parsing no announce range: %w", err)
+		}
+		filters.AddFilter(*ipnet, ma.ActionDeny)
+	}
+
+	return func(listen []ma.Multiaddr) []ma.Multiaddr {
+		addrs := listen
+		if len(announce) > 0 {
+			addrs = announce
+		}
+		advertised := make([]ma.Multiaddr, 0, len(addrs)+len(appendAnnounce))
+		for _, a := range addrs {
+			if !filters.AddrBlocked(a) {
+				advertised = append(advertised, a)
+			}
+		}
+		return append(advertised, appendAnnounce...)
+	}, nil
 }
 
 // ConstructHost build a libp2p host configured for relaying drand randomness over pubsub.
-func ConstructHost(priv crypto.PrivKey, listenAddr string, bootstrap []ma.Multiaddr, log dlog.Logger) (host.Host, *pubsub.PubSub, error) {
+// Peer scoring is enabled with the default drand parameters, see ScoringOptions.
+// Additional pubsub options are applied after the default ones, and can override them.
+//
+//nolint:lll // This function has nicely named parameters, so it's long.
+func ConstructHost(priv crypto.PrivKey, listenAddr string, bootstrap []ma.Multiaddr, log dlog.Logger, psOpts ...pubsub.Option) (host.Host, *pubsub.PubSub, error) {
+	var cfg HostConfig
+	if listenAddr != "" {
+		cfg.ListenAddrs = []string{listenAddr}
+	}
+	return ConstructHostWithConfig(priv, &cfg, bootstrap, log, psOpts...)
+}
+
+// ConstructHostWithConfig is like ConstructHost, with the addresses and
+// transports of the host configured by cfg, e.g. to listen on QUIC and
+// WebSocket in addition to TCP.
+//
+//nolint:lll // This function has nicely named parameters, so it's long.
+func ConstructHostWithConfig(priv crypto.PrivKey, cfg *HostConfig, bootstrap []ma.Multiaddr, log dlog.Logger, psOpts ...pubsub.Option) (host.Host, *pubsub.PubSub, error) {
 	ctx := context.Background()
 
 	pstore, err := pstoremem.NewPeerstore()
@@ -77,24 +238,53 @@ func ConstructHost(priv crypto.PrivKey, listenAddr string, bootstrap []ma.Multia
 		libp2p.ChainOptions(
 			libp2p.Security(libp2ptls.ID, libp2ptls.New),
 			libp2p.Security(noise.ID, noise.New)),
-		libp2p.DisableRelay(),
 		libp2p.Peerstore(pstore),
 		libp2p.UserAgent(userAgent),
 		libp2p.ConnectionManager(cmgr),
 	}
 
-	if listenAddr != "" {
-		opts = append(opts, libp2p.ListenAddrStrings(listenAddr))
+	natOpts, err := cfg.NAT.options(ctx)
+	if err != nil {
+		return nil, nil, err
+	}
+	opts = append(opts, natOpts...)
+
+	if len(cfg.ListenAddrs) > 0 {
+		opts = append(opts, libp2p.ListenAddrStrings(cfg.ListenAddrs...))
 	} else {
 		opts = append(opts, libp2p.NoListenAddrs)
 	}
+	addrsFactory, err := cfg.addrsFactory()
+	if err != nil {
+		return nil, nil, err
+	}
+	if addrsFactory != nil {
+		opts = append(opts, libp2p.AddrsFactory(addrsFactory))
+	}
+	if len(cfg.PSK) > 0 {
+		opts = append(opts, libp2p.PrivateNetwork(cfg.PSK))
+	}
+	if cfg.WebSocketTLS != nil {
+		// the default transports, with TLS for WebSocket
+		opts = append(opts,
+			libp2p.Transport(tcp.NewTCPTransport),
+			libp2p.Transport(websocket.New, websocket.WithTLSConfig(cfg.WebSocketTLS)),
+		)
+		if len(cfg.PSK) == 0 {
+			opts = append(opts,
+				libp2p.Transport(quic.NewTransport),
+				libp2p.Transport(webtransport.New),
+				libp2p.Transport(libp2pwebrtc.New),
+			)
+		}
+	}
 
 	h, err := libp2p.New(opts...)
 	if err != nil {
 		return nil, nil, fmt.Errorf("constructing host: %w", err)
 	}
 
-	p, err := pubsub.NewGossipSub(ctx, h,
+	defaults := append([]pubsub.Option{
 		pubsub.WithPeerExchange(true),
 		pubsub.WithMessageIdFn(func(pmsg *pubsubpb.Message) string {
 			hash := blake2b.Sum256(pmsg.Data)
@@ -103,7 +293,8 @@ func ConstructHost(priv crypto.PrivKey, listenAddr string, bootstrap []ma.Multia
 		pubsub.WithDirectPeers(addrInfos),
 		pubsub.WithFloodPublish(true),
 		pubsub.WithDirectConnectTicks(directConnectTicks),
-	)
+	}, ScoringOptions()...)
+	p, err := pubsub.NewGossipSub(ctx, h, append(defaults, psOpts...)...)
 	if err != nil {
 		return nil, nil, fmt.Errorf("constructing pubsub: %w", err)
 	}
@@ -124,39 +315,43 @@ func ConstructHost(priv crypto.PrivKey, listenAddr string, bootstrap []ma.Multia
 	return h, p, nil
 }
 
+// LoadPNetKey reads the pre-shared key of a libp2p private network from a file
+// in the usual swarm key format, as "/key/swarm/psk/1.0.0/", "/base16/" and the
+// hex encoded 32 bytes key on three lines.
+func LoadPNetKey(keyPath string) (pnet.PSK, error) {
+	f, err := os.Open(keyPath)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	psk, err := pnet.DecodeV1PSK(f)
+	if err != nil {
+		return nil, fmt.Errorf("decoding private network key %s: %w", keyPath, err)
+	}
+	return psk, nil
+}
+
 // LoadOrCreatePrivKey loads a base64 encoded libp2p private key from a file or creates one if it does not exist.
 func LoadOrCreatePrivKey(identityPath string, log dlog.Logger) (crypto.PrivKey, error) {
-	privB64, err := os.ReadFile(identityPath)
+	return LoadOrCreateEncryptedPrivKey(identityPath, nil, log)
+}
 
-	var priv crypto.PrivKey
+// LoadOrCreateEncryptedPrivKey is like LoadOrCreatePrivKey, for identity files
+// encrypted with a passphrase, see WritePrivKey. The identity file is only
+// encrypted when a passphrase is given.
+func LoadOrCreateEncryptedPrivKey(identityPath string, passphrase []byte, log dlog.Logger) (crypto.PrivKey, error) {
+	priv, err := ReadPrivKey(identityPath, passphrase)
 	switch {
 	case err == nil:
-		privBytes, err := base64.RawStdEncoding.DecodeString(string(privB64))
-		if err != nil {
-			return nil, fmt.Errorf("decoding base64 key: %w", err)
-		}
-		priv, err = crypto.UnmarshalEd25519PrivateKey(privBytes)
-		if err != nil {
-			return nil, fmt.Errorf("unmarshaling ed25519 key: %w", err)
-		}
 		log.Infow("", "load_or_create_priv_key", "loaded private key")
 
 	case errors.Is(err, os.ErrNotExist):
-		priv, _, err = crypto.GenerateEd25519Key(rand.Reader)
-		if err != nil {
-			return nil, fmt.Errorf("generating private key: %w", err)
-		}
-		b, err := priv.Raw()
-		if err != nil {
-			return nil, fmt.Errorf("marshaling private key: %w", err)
-		}
-		err = os.MkdirAll(path.Dir(identityPath), allDirPerm)
+		priv, err = GeneratePrivKey(KeyTypeEd25519)
 		if err != nil {
-			return nil, fmt.Errorf("creating identity directory and parents: %w", err)
+			return nil, err
 		}
-		err = os.WriteFile(identityPath, []byte(base64.RawStdEncoding.EncodeToString(b)), identityFilePerm)
-		if err != nil {
-			return nil, fmt.Errorf("writing identity file: %w", err)
+		if err := WritePrivKey(identityPath, priv, passphrase); err != nil {
+			return nil, err
 		}
 
 	default:
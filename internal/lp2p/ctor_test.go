@@ -1,9 +1,24 @@
+//go:build !nolibp2p
+
 package lp2p
 
 import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
 	"fmt"
+	"os"
 	"path"
+	"slices"
 	"testing"
+	"time"
+
+	"github.com/libp2p/go-libp2p/core/crypto"
+	"github.com/libp2p/go-libp2p/core/host"
+	"github.com/libp2p/go-libp2p/core/peer"
+	"github.com/libp2p/go-libp2p/core/pnet"
+	ma "github.com/multiformats/go-multiaddr"
+	"github.com/stretchr/testify/require"
 
 	"github.com/drand/drand/v2/common/log"
 )
@@ -53,3 +68,132 @@ func TestCreatePrivKeyMkdirp(t *testing.T) {
 		t.Fatal(fmt.Errorf("private key not persisted and/or not read back properly"))
 	}
 }
+
+func TestConstructHostTransports(t *testing.T) {
+	lg := log.New(nil, log.DebugLevel, true)
+	priv, _, err := crypto.GenerateEd25519Key(rand.Reader)
+	require.NoError(t, err)
+	h, _, err := ConstructHostWithConfig(priv, &HostConfig{
+		ListenAddrs: []string{"/ip4/127.0.0.1/udp/0/quic-v1", "/ip4/127.0.0.1/tcp/0/ws"},
+	}, nil, lg)
+	require.NoError(t, err)
+	defer h.Close()
+
+	for _, proto := range []int{ma.P_QUIC_V1, ma.P_WS} {
+		var addrs []ma.Multiaddr
+		for _, a := range h.Addrs() {
+			if _, err := a.ValueForProtocol(proto); err == nil {
+				addrs = append(addrs, a)
+			}
+		}
+		require.Len(t, addrs, 1, "listening on %s", ma.ProtocolWithCode(proto).Name)
+
+		cpriv, _, err := crypto.GenerateEd25519Key(rand.Reader)
+		require.NoError(t, err)
+		c, _, err := ConstructHost(cpriv, "", nil, lg)
+		require.NoError(t, err)
+		ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+		err = c.Connect(ctx, peer.AddrInfo{ID: h.ID(), Addrs: addrs})
+		cancel()
+		require.NoError(t, err, "connecting over %s", ma.ProtocolWithCode(proto).Name)
+		c.Close()
+	}
+}
+
+func TestConstructHostAnnounceAddrs(t *testing.T) {
+	priv, _, err := crypto.GenerateEd25519Key(rand.Reader)
+	require.NoError(t, err)
+	announce := "/dns4/relay.example.org/tcp/443/wss"
+	h, _, err := ConstructHostWithConfig(priv, &HostConfig{
+		ListenAddrs:   []string{"/ip4/127.0.0.1/tcp/0/ws"},
+		AnnounceAddrs: []string{announce},
+	}, nil, log.New(nil, log.DebugLevel, true))
+	require.NoError(t, err)
+	defer h.Close()
+	require.Len(t, h.Addrs(), 1)
+	require.Equal(t, announce, h.Addrs()[0].String())
+}
+
+func TestConstructHostPNet(t *testing.T) {
+	lg := log.New(nil, log.DebugLevel, true)
+	key := make([]byte, 32)
+	_, err := rand.Read(key)
+	require.NoError(t, err)
+	keyPath := path.Join(t.TempDir(), "swarm.key")
+	require.NoError(t, os.WriteFile(keyPath, []byte("/key/swarm/psk/1.0.0/\n/base16/\n"+hex.EncodeToString(key)+"\n"), 0o600))
+	psk, err := LoadPNetKey(keyPath)
+	require.NoError(t, err)
+	require.Equal(t, key, []byte(psk))
+
+	newHost := func(listen []string, psk pnet.PSK) host.Host {
+		priv, _, err := crypto.GenerateEd25519Key(rand.Reader)
+		require.NoError(t, err)
+		h, _, err := ConstructHostWithConfig(priv, &HostConfig{ListenAddrs: listen, PSK: psk}, nil, lg)
+		require.NoError(t, err)
+		t.Cleanup(func() { h.Close() })
+		return h
+	}
+	relay := newHost([]string{"/ip4/127.0.0.1/tcp/0"}, psk)
+	ai := peer.AddrInfo{ID: relay.ID(), Addrs: relay.Addrs()}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	require.NoError(t, newHost(nil, psk).Connect(ctx, ai), "peers with the key can connect")
+	ctx, cancel = context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+	require.Error(t, newHost(nil, nil).Connect(ctx, ai), "public peers cannot connect")
+}
+
+func TestConstructHostNAT(t *testing.T) {
+	lg := log.New(nil, log.DebugLevel, true)
+	const circuitStop = "/libp2p/circuit/relay/0.2.0/stop"
+	relayed := func(nat NATConfig) bool {
+		priv, _, err := crypto.GenerateEd25519Key(rand.Reader)
+		require.NoError(t, err)
+		h, _, err := ConstructHostWithConfig(priv, &HostConfig{ListenAddrs: []string{"/ip4/127.0.0.1/tcp/0"}, NAT: nat}, nil, lg)
+		require.NoError(t, err)
+		defer h.Close()
+		return slices.Contains(h.Mux().Protocols(), circuitStop)
+	}
+
+	require.False(t, relayed(NATConfig{}), "the relay transport is disabled by default")
+	require.False(t, relayed(NATConfig{AutoNAT: true}))
+	require.True(t, relayed(NATConfig{AutoNAT: true, RelayService: true}))
+	require.True(t, relayed(NATConfig{HolePunching: true}))
+
+	// a peer behind NAT reachable through a relay
+	rpriv, _, err := crypto.GenerateEd25519Key(rand.Reader)
+	require.NoError(t, err)
+	r, _, err := ConstructHostWithConfig(rpriv, &HostConfig{
+		ListenAddrs: []string{"/ip4/127.0.0.1/tcp/0"},
+		NAT:         NATConfig{RelayService: true},
+	}, nil, lg)
+	require.NoError(t, err)
+	defer r.Close()
+	require.True(t, relayed(NATConfig{HolePunching: true, StaticRelays: []string{
+		fmt.Sprintf("%s/p2p/%s", r.Addrs()[0], r.ID()),
+	}}))
+
+	priv, _, err := crypto.GenerateEd25519Key(rand.Reader)
+	require.NoError(t, err)
+	_, _, err = ConstructHostWithConfig(priv, &HostConfig{NAT: NATConfig{StaticRelays: []string{"not a multiaddr"}}}, nil, lg)
+	require.Error(t, err)
+}
+
+func TestConstructHostAppendAnnounceAddrs(t *testing.T) {
+	priv, _, err := crypto.GenerateEd25519Key(rand.Reader)
+	require.NoError(t, err)
+	external := "/dns4/relay.example.org/tcp/44544"
+	h, _, err := ConstructHostWithConfig(priv, &HostConfig{
+		ListenAddrs:         []string{"/ip4/127.0.0.1/tcp/0"},
+		AppendAnnounceAddrs: []string{external},
+		NoAnnounceCIDRs:     []string{"127.0.0.0/8"},
+	}, nil, log.New(nil, log.DebugLevel, true))
+	require.NoError(t, err)
+	defer h.Close()
+	require.Len(t, h.Addrs(), 1, "the loopback listen addr is not advertised")
+	require.Equal(t, external, h.Addrs()[0].String())
+
+	_, _, err = ConstructHostWithConfig(priv, &HostConfig{NoAnnounceCIDRs: []string{"10.0.0.0"}}, nil, log.DefaultLogger())
+	require.Error(t, err)
+}
@@ -0,0 +1,83 @@
+//go:build !nolibp2p
+
+package lp2p
+
+import (
+	"bytes"
+	"context"
+	"sync"
+	"time"
+
+	pubsub "github.com/libp2p/go-libp2p-pubsub"
+	"google.golang.org/protobuf/proto"
+
+	drandpb "github.com/drand/drand/v2/protobuf/drand"
+)
+
+// observedRounds is how many of the latest rounds observed on the mesh are
+// remembered.
+const observedRounds = 16
+
+// observed is a beacon published on the mesh by another peer.
+type observed struct {
+	sig []byte
+	at  time.Time
+}
+
+// meshRounds records the beacons published on the topic of a relay by the
+// other peers of the mesh, so that the relay can skip publishing the beacons
+// the mesh already carries.
+type meshRounds struct {
+	lk     sync.Mutex
+	rounds map[uint64]observed
+}
+
+func newMeshRounds() *meshRounds {
+	return &meshRounds{rounds: make(map[uint64]observed)}
+}
+
+// observe records that the beacon of round, with signature sig, was received
+// at the given time, forgetting the rounds too old to be remembered.
+func (m *meshRounds) observe(round uint64, sig []byte, at time.Time) {
+	m.lk.Lock()
+	defer m.lk.Unlock()
+	if _, ok := m.rounds[round]; ok {
+		return
+	}
+	m.rounds[round] = observed{sig: sig, at: at}
+	for r := range m.rounds {
+		if r+observedRounds <= round {
+			delete(m.rounds, r)
+		}
+	}
+}
+
+// seenSince tells whether the beacon of round was received with signature sig
+// since the given time. Beacons with another signature, which the relay did
+// not verify, never prevent it from publishing its own.
+func (m *meshRounds) seenSince(round uint64, sig []byte, since time.Time) bool {
+	m.lk.Lock()
+	defer m.lk.Unlock()
+	o, ok := m.rounds[round]
+	return ok && !o.at.Before(since) && bytes.Equal(o.sig, sig)
+}
+
+// observeMesh records the beacons received on sub from the other peers of the
+// mesh until ctx is done.
+func (g *GossipRelayNode) observeMesh(ctx context.Context, sub *pubsub.Subscription) {
+	defer sub.Cancel()
+	for {
+		msg, err := sub.Next(ctx)
+		if err != nil {
+			return
+		}
+		if msg.ReceivedFrom == g.h.ID() {
+			continue
+		}
+		var rand drandpb.PublicRandResponse
+		if err := proto.Unmarshal(msg.Data, &rand); err != nil {
+			continue
+		}
+		g.mesh.observe(rand.GetRound(), rand.GetSignature(), time.Now())
+	}
+}
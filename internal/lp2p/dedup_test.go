@@ -0,0 +1,107 @@
+//go:build !nolibp2p
+
+package lp2p
+
+import (
+	"context"
+	"encoding/hex"
+	"path"
+	"slices"
+	"testing"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/stretchr/testify/require"
+
+	"github.com/drand/drand/v2/common/chain"
+	"github.com/drand/drand/v2/common/key"
+	"github.com/drand/drand/v2/common/log"
+	"github.com/drand/drand/v2/crypto"
+
+	"github.com/drand/go-clients/client/test/result/mock"
+	"github.com/drand/go-clients/drand"
+	"github.com/drand/go-clients/internal/metrics"
+)
+
+func TestMeshRounds(t *testing.T) {
+	now := time.Now()
+	m := newMeshRounds()
+	m.observe(1, []byte("sig1"), now)
+	require.True(t, m.seenSince(1, []byte("sig1"), now.Add(-time.Second)))
+	require.False(t, m.seenSince(1, []byte("sig1"), now.Add(time.Second)), "observed too long ago")
+	require.False(t, m.seenSince(1, []byte("other"), now.Add(-time.Second)), "another signature")
+	require.False(t, m.seenSince(2, []byte("sig1"), now.Add(-time.Second)), "not observed")
+
+	m.observe(1+observedRounds, []byte("sig"), now)
+	require.False(t, m.seenSince(1, []byte("sig1"), now.Add(-time.Second)), "forgotten")
+	require.Len(t, m.rounds, 1)
+}
+
+func TestDeduplicate(t *testing.T) {
+	sch, err := crypto.GetSchemeFromEnv()
+	require.NoError(t, err)
+	pair, err := key.NewKeyPair("fakeChainInfo.test:1234", sch)
+	require.NoError(t, err)
+	chainInfo := &chain.Info{
+		Period:      time.Minute,
+		GenesisTime: time.Now().Unix(),
+		PublicKey:   pair.Public.Key,
+	}
+	chainHash := hex.EncodeToString(chainInfo.Hash())
+	results := toRandomDataChain(mock.NewMockResult(0), mock.NewMockResult(1))
+
+	// watch returns a watch function sending the beacon once released
+	watch := func(release chan struct{}) func(ctx context.Context) <-chan drand.Result {
+		return func(ctx context.Context) <-chan drand.Result {
+			ch := make(chan drand.Result, 1)
+			go func() {
+				select {
+				case <-release:
+					ch <- &results[0]
+				case <-ctx.Done():
+					close(ch)
+				}
+			}()
+			return ch
+		}
+	}
+
+	td := t.TempDir()
+	lg := log.New(nil, log.DebugLevel, true)
+	releaseFirst := make(chan struct{})
+	first, err := NewGossipRelayNode(lg, &GossipRelayConfig{
+		ChainHash:    chainHash,
+		Addr:         "/ip4/127.0.0.1/tcp/0",
+		IdentityPath: path.Join(td, "first.key"),
+		Client:       &mockClient{chainInfo, watch(releaseFirst)},
+	})
+	require.NoError(t, err)
+	defer first.Close()
+
+	releaseSecond := make(chan struct{})
+	second, err := NewGossipRelayNode(lg, &GossipRelayConfig{
+		ChainHash:    chainHash,
+		Addr:         "/ip4/127.0.0.1/tcp/0",
+		IdentityPath: path.Join(td, "second.key"),
+		PeerWith:     []string{first.Multiaddrs()[0].String()},
+		Client:       &mockClient{chainInfo, watch(releaseSecond)},
+		Deduplicate:  true,
+	})
+	require.NoError(t, err)
+	defer second.Close()
+
+	// the first relay publishes once it knows the second one subscribes
+	require.Eventually(t, func() bool {
+		return slices.Contains(first.t.ListPeers(), second.h.ID())
+	}, 10*time.Second, 10*time.Millisecond)
+	close(releaseFirst)
+	require.Eventually(t, func() bool {
+		return second.mesh.seenSince(results[0].Rnd, results[0].Sig, time.Now().Add(-time.Minute))
+	}, 10*time.Second, 10*time.Millisecond)
+
+	skipped := testutil.ToFloat64(metrics.RelayPublishSkipped.WithLabelValues(chainHash))
+	close(releaseSecond)
+	require.Eventually(t, func() bool {
+		return testutil.ToFloat64(metrics.RelayPublishSkipped.WithLabelValues(chainHash)) == skipped+1
+	}, 5*time.Second, 10*time.Millisecond)
+}
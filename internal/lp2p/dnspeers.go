@@ -0,0 +1,131 @@
+//go:build !nolibp2p
+
+package lp2p
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"strings"
+	"time"
+
+	"github.com/libp2p/go-libp2p/core/host"
+	"github.com/libp2p/go-libp2p/core/peer"
+	ma "github.com/multiformats/go-multiaddr"
+	madns "github.com/multiformats/go-multiaddr-dns"
+
+	dlog "github.com/drand/drand/v2/common/log"
+)
+
+const (
+	// DefaultDNSPeersRefresh is how often the peers listed in DNS are looked up again by default.
+	DefaultDNSPeersRefresh = 10 * time.Minute
+	// dnsPeersTag protects the connections to the peers listed in DNS from the connection manager.
+	dnsPeersTag = "drand-dns-peers"
+	// dnsaddrPrefix is the prefix of dnsaddr TXT records, accepted for convenience.
+	dnsaddrPrefix = "dnsaddr="
+)
+
+// LookupDNSPeers returns the multiaddrs listed in the TXT records of name, e.g.
+// "_drand-relays.example.org". Each record holds one or more multiaddrs separated
+// by spaces, optionally prefixed with "dnsaddr=". Invalid multiaddrs are skipped.
+func LookupDNSPeers(ctx context.Context, r madns.BasicResolver, name string) ([]ma.Multiaddr, error) {
+	if r == nil {
+		r = net.DefaultResolver
+	}
+	ctx, cancel := context.WithTimeout(ctx, dnsResolveTimeout)
+	defer cancel()
+
+	records, err := r.LookupTXT(ctx, name)
+	if err != nil {
+		return nil, fmt.Errorf("looking up TXT records of %q: %w", name, err)
+	}
+
+	var addrs []ma.Multiaddr
+	for _, record := range records {
+		for _, s := range strings.Fields(record) {
+			m, err := ma.NewMultiaddr(strings.TrimPrefix(s, dnsaddrPrefix))
+			if err != nil {
+				continue
+			}
+			addrs = append(addrs, m)
+		}
+	}
+	return addrs, nil
+}
+
+// dnsPeers keeps a host connected to the peers listed in the TXT records of a name.
+type dnsPeers struct {
+	l    dlog.Logger
+	h    host.Host
+	r    madns.BasicResolver
+	name string
+	// protected are the peers currently listed, protected from the connection manager.
+	protected map[peer.ID]struct{}
+}
+
+// WatchDNSPeers connects h to the peers listed in the TXT records of name, see
+// LookupDNSPeers, and looks them up again every refresh until ctx is done, so
+// that relay sets can be rotated without reconfiguring every node. Connections
+// to the listed peers are protected from the connection manager, until they are
+// removed from the records. A nil resolver uses the system one.
+func WatchDNSPeers(ctx context.Context, l dlog.Logger, h host.Host, r madns.BasicResolver, name string, refresh time.Duration) {
+	if refresh <= 0 {
+		refresh = DefaultDNSPeersRefresh
+	}
+	d := &dnsPeers{l: l, h: h, r: r, name: name, protected: make(map[peer.ID]struct{})}
+
+	ticker := time.NewTicker(refresh)
+	defer ticker.Stop()
+	for {
+		if err := d.refresh(ctx); err != nil {
+			l.Warnw("", "dns_peers", "failed to refresh peers", "name", name, "err", err)
+		}
+		select {
+		case <-ticker.C:
+		case <-ctx.Done():
+			return
+		}
+	}
+}
+
+// refresh looks up the listed peers, connects to the new ones and unprotects
+// the ones no longer listed. On lookup errors, the current peers are kept.
+func (d *dnsPeers) refresh(ctx context.Context) error {
+	addrs, err := LookupDNSPeers(ctx, d.r, d.name)
+	if err != nil {
+		return err
+	}
+
+	listed := make(map[peer.ID]struct{}, len(addrs))
+	for _, addr := range addrs {
+		ais, err := resolveAddresses(ctx, []ma.Multiaddr{addr}, nil)
+		if err != nil {
+			d.l.Warnw("", "dns_peers", "failed to resolve peer", "addr", addr, "err", err)
+			continue
+		}
+		for _, ai := range ais {
+			if ai.ID == d.h.ID() {
+				continue
+			}
+			listed[ai.ID] = struct{}{}
+			d.h.ConnManager().Protect(ai.ID, dnsPeersTag)
+
+			cctx, cancel := context.WithTimeout(ctx, bootstrapTimeout)
+			err := d.h.Connect(cctx, ai)
+			cancel()
+			if err != nil {
+				d.l.Warnw("", "dns_peers", "could not connect to peer", "addr", ai, "err", err)
+			}
+		}
+	}
+
+	for id := range d.protected {
+		if _, ok := listed[id]; !ok {
+			d.h.ConnManager().Unprotect(id, dnsPeersTag)
+			d.l.Infow("", "dns_peers", "peer no longer listed", "peer", id)
+		}
+	}
+	d.protected = listed
+	return nil
+}
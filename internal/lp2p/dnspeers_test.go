@@ -0,0 +1,90 @@
+//go:build !nolibp2p
+
+package lp2p
+
+import (
+	"context"
+	"crypto/rand"
+	"errors"
+	"fmt"
+	"net"
+	"testing"
+
+	"github.com/libp2p/go-libp2p/core/crypto"
+	"github.com/libp2p/go-libp2p/core/host"
+	"github.com/libp2p/go-libp2p/core/network"
+	"github.com/libp2p/go-libp2p/core/peer"
+	"github.com/stretchr/testify/require"
+
+	"github.com/drand/drand/v2/common/log"
+)
+
+func TestLookupDNSPeers(t *testing.T) {
+	r := mockResolver(t, map[string][]string{
+		"_drand-relays.example.org": {
+			p2pIP4Addr0,
+			"dnsaddr=" + p2pIP4Addr1 + " " + dnsaddr0,
+			"not a multiaddr",
+		},
+	})
+
+	addrs, err := LookupDNSPeers(context.Background(), r, "_drand-relays.example.org")
+	require.NoError(t, err)
+	require.Len(t, addrs, 3)
+	require.Equal(t, p2pIP4Addr0, addrs[0].String())
+	require.Equal(t, p2pIP4Addr1, addrs[1].String())
+	require.Equal(t, dnsaddr0, addrs[2].String())
+
+	_, err = LookupDNSPeers(context.Background(), failingResolver{}, "_drand-relays.example.org")
+	require.Error(t, err)
+}
+
+type failingResolver struct{}
+
+func (failingResolver) LookupIPAddr(context.Context, string) ([]net.IPAddr, error) {
+	return nil, errors.New("lookup failed")
+}
+
+func (failingResolver) LookupTXT(context.Context, string) ([]string, error) {
+	return nil, errors.New("lookup failed")
+}
+
+func TestDNSPeersRefresh(t *testing.T) {
+	lg := log.New(nil, log.DebugLevel, true)
+	newHost := func() host.Host {
+		priv, _, err := crypto.GenerateEd25519Key(rand.Reader)
+		require.NoError(t, err)
+		h, _, err := ConstructHost(priv, "/ip4/127.0.0.1/tcp/0", nil, lg)
+		require.NoError(t, err)
+		t.Cleanup(func() { h.Close() })
+		return h
+	}
+	h, relay := newHost(), newHost()
+	relayAddr := fmt.Sprintf("%s/p2p/%s", relay.Addrs()[0], relay.ID())
+	selfAddr := fmt.Sprintf("%s/p2p/%s", h.Addrs()[0], h.ID())
+
+	records := map[string][]string{"_drand-relays.example.org": {relayAddr, selfAddr}}
+	d := &dnsPeers{
+		l:         lg,
+		h:         h,
+		r:         mockResolver(t, records),
+		name:      "_drand-relays.example.org",
+		protected: make(map[peer.ID]struct{}),
+	}
+
+	require.NoError(t, d.refresh(context.Background()))
+	require.Equal(t, network.Connected, h.Network().Connectedness(relay.ID()))
+	require.True(t, h.ConnManager().IsProtected(relay.ID(), dnsPeersTag))
+	require.Len(t, d.protected, 1)
+
+	// failed lookups keep the current peers
+	d.r = failingResolver{}
+	require.Error(t, d.refresh(context.Background()))
+	require.True(t, h.ConnManager().IsProtected(relay.ID(), dnsPeersTag))
+
+	// peers removed from the records are no longer protected
+	d.r = mockResolver(t, map[string][]string{"_drand-relays.example.org": {}})
+	require.NoError(t, d.refresh(context.Background()))
+	require.False(t, h.ConnManager().IsProtected(relay.ID(), dnsPeersTag))
+	require.Empty(t, d.protected)
+}
@@ -0,0 +1,231 @@
+//go:build !nolibp2p
+
+package lp2p
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"os"
+	"path"
+	"strings"
+
+	"github.com/libp2p/go-libp2p/core/crypto"
+	"golang.org/x/crypto/scrypt"
+)
+
+const (
+	// KeyTypeEd25519 is the default type of the libp2p identities.
+	KeyTypeEd25519 = "ed25519"
+	// KeyTypeSecp256k1 is the type of the secp256k1 libp2p identities.
+	KeyTypeSecp256k1 = "secp256k1"
+
+	pemKeyType          = "LIBP2P PRIVATE KEY"
+	pemEncryptedKeyType = "ENCRYPTED LIBP2P PRIVATE KEY"
+
+	// scrypt parameters of the encrypted identity files
+	scryptN      = 1 << 15
+	scryptR      = 8
+	scryptP      = 1
+	scryptKeyLen = 32
+	saltLen      = 16
+)
+
+// ErrPassphraseRequired is returned when reading an encrypted identity file
+// without passphrase.
+var ErrPassphraseRequired = errors.New("identity file is encrypted, a passphrase is required")
+
+// GeneratePrivKey generates a libp2p identity of the given type, KeyTypeEd25519
+// or KeyTypeSecp256k1.
+func GeneratePrivKey(keyType string) (crypto.PrivKey, error) {
+	var priv crypto.PrivKey
+	var err error
+	switch strings.ToLower(keyType) {
+	case KeyTypeEd25519, "":
+		priv, _, err = crypto.GenerateEd25519Key(rand.Reader)
+	case KeyTypeSecp256k1:
+		priv, _, err = crypto.GenerateSecp256k1Key(rand.Reader)
+	default:
+		return nil, fmt.Errorf("unsupported key type %q, expected %s or %s", keyType, KeyTypeEd25519, KeyTypeSecp256k1)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("generating private key: %w", err)
+	}
+	return priv, nil
+}
+
+// ReadPrivKey reads a libp2p identity from a file written by WritePrivKey.
+// The passphrase is only required for encrypted files.
+func ReadPrivKey(identityPath string, passphrase []byte) (crypto.PrivKey, error) {
+	b, err := os.ReadFile(identityPath)
+	if err != nil {
+		return nil, err
+	}
+	return decodePrivKey(b, passphrase)
+}
+
+// decodePrivKey decodes an identity file: a PEM block holding the key in the
+// libp2p protobuf encoding, encrypted or not, or the base64 encoded raw
+// ed25519 key of the files written by older versions.
+func decodePrivKey(b []byte, passphrase []byte) (crypto.PrivKey, error) {
+	block, _ := pem.Decode(b)
+	if block == nil {
+		privBytes, err := base64.RawStdEncoding.DecodeString(strings.TrimSpace(string(b)))
+		if err != nil {
+			return nil, fmt.Errorf("decoding base64 key: %w", err)
+		}
+		priv, err := crypto.UnmarshalEd25519PrivateKey(privBytes)
+		if err != nil {
+			return nil, fmt.Errorf("unmarshaling ed25519 key: %w", err)
+		}
+		return priv, nil
+	}
+
+	der := block.Bytes
+	switch block.Type {
+	case pemKeyType:
+	case pemEncryptedKeyType:
+		if len(passphrase) == 0 {
+			return nil, ErrPassphraseRequired
+		}
+		var err error
+		der, err = decryptKey(block, passphrase)
+		if err != nil {
+			return nil, err
+		}
+	default:
+		return nil, fmt.Errorf("unexpected PEM block %q in identity file", block.Type)
+	}
+	priv, err := crypto.UnmarshalPrivateKey(der)
+	if err != nil {
+		return nil, fmt.Errorf("unmarshaling private key: %w", err)
+	}
+	return priv, nil
+}
+
+// WritePrivKey writes a libp2p identity to a file, encrypted with the
+// passphrase if any. Unencrypted ed25519 identities are written in the format
+// of older versions, so that they can still read them.
+func WritePrivKey(identityPath string, priv crypto.PrivKey, passphrase []byte) error {
+	b, err := encodePrivKey(priv, passphrase)
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(path.Dir(identityPath), allDirPerm); err != nil {
+		return fmt.Errorf("creating identity directory and parents: %w", err)
+	}
+	if err := os.WriteFile(identityPath, b, identityFilePerm); err != nil {
+		return fmt.Errorf("writing identity file: %w", err)
+	}
+	return nil
+}
+
+func encodePrivKey(priv crypto.PrivKey, passphrase []byte) ([]byte, error) {
+	if priv.Type() == crypto.Ed25519 && len(passphrase) == 0 {
+		b, err := priv.Raw()
+		if err != nil {
+			return nil, fmt.Errorf("marshaling private key: %w", err)
+		}
+		return []byte(base64.RawStdEncoding.EncodeToString(b)), nil
+	}
+
+	der, err := crypto.MarshalPrivateKey(priv)
+	if err != nil {
+		return nil, fmt.Errorf("marshaling private key: %w", err)
+	}
+	block := &pem.Block{Type: pemKeyType, Bytes: der}
+	if len(passphrase) > 0 {
+		if block, err = encryptKey(der, passphrase); err != nil {
+			return nil, err
+		}
+	}
+	return pem.EncodeToMemory(block), nil
+}
+
+// encryptKey encrypts a marshaled key with AES-GCM, under a key derived from
+// the passphrase with scrypt.
+func encryptKey(der, passphrase []byte) (*pem.Block, error) {
+	salt := make([]byte, saltLen)
+	if _, err := rand.Read(salt); err != nil {
+		return nil, err
+	}
+	aead, err := keyAEAD(passphrase, salt)
+	if err != nil {
+		return nil, err
+	}
+	nonce := make([]byte, aead.NonceSize())
+	if _, err := rand.Read(nonce); err != nil {
+		return nil, err
+	}
+	return &pem.Block{
+		Type: pemEncryptedKeyType,
+		Headers: map[string]string{
+			"KDF":  "scrypt",
+			"Salt": hex.EncodeToString(salt),
+		},
+		Bytes: aead.Seal(nonce, nonce, der, nil),
+	}, nil
+}
+
+func decryptKey(block *pem.Block, passphrase []byte) ([]byte, error) {
+	if kdf := block.Headers["KDF"]; kdf != "scrypt" {
+		return nil, fmt.Errorf("unsupported key derivation %q in identity file", kdf)
+	}
+	salt, err := hex.DecodeString(block.Headers["Salt"])
+	if err != nil {
+		return nil, fmt.Errorf("decoding salt: %w", err)
+	}
+	aead, err := keyAEAD(passphrase, salt)
+	if err != nil {
+		return nil, err
+	}
+	if len(block.Bytes) < aead.NonceSize() {
+		return nil, errors.New("encrypted identity is too short")
+	}
+	nonce, ciphertext := block.Bytes[:aead.NonceSize()], block.Bytes[aead.NonceSize():]
+	der, err := aead.Open(nil, nonce, ciphertext, nil)
+	if err != nil {
+		return nil, errors.New("decrypting identity: wrong passphrase or corrupted file")
+	}
+	return der, nil
+}
+
+func keyAEAD(passphrase, salt []byte) (cipher.AEAD, error) {
+	key, err := scrypt.Key(passphrase, salt, scryptN, scryptR, scryptP, scryptKeyLen)
+	if err != nil {
+		return nil, fmt.Errorf("deriving key: %w", err)
+	}
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, err
+	}
+	return cipher.NewGCM(block)
+}
+
+// ExportPrivKey encodes a libp2p identity in the base64 encoded libp2p
+// protobuf format, understood by other libp2p implementations.
+func ExportPrivKey(priv crypto.PrivKey) (string, error) {
+	b, err := crypto.MarshalPrivateKey(priv)
+	if err != nil {
+		return "", fmt.Errorf("marshaling private key: %w", err)
+	}
+	return base64.StdEncoding.EncodeToString(b), nil
+}
+
+// ImportPrivKey decodes a libp2p identity exported by ExportPrivKey.
+func ImportPrivKey(exported string) (crypto.PrivKey, error) {
+	b, err := base64.StdEncoding.DecodeString(strings.TrimSpace(exported))
+	if err != nil {
+		return nil, fmt.Errorf("decoding base64 key: %w", err)
+	}
+	priv, err := crypto.UnmarshalPrivateKey(b)
+	if err != nil {
+		return nil, fmt.Errorf("unmarshaling private key: %w", err)
+	}
+	return priv, nil
+}
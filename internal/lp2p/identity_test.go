@@ -0,0 +1,60 @@
+//go:build !nolibp2p
+
+package lp2p
+
+import (
+	"path"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/drand/drand/v2/common/log"
+)
+
+func TestPrivKeyFiles(t *testing.T) {
+	dir := t.TempDir()
+	for _, keyType := range []string{KeyTypeEd25519, KeyTypeSecp256k1} {
+		for _, passphrase := range [][]byte{nil, []byte("correct horse battery staple")} {
+			priv, err := GeneratePrivKey(keyType)
+			require.NoError(t, err)
+			identityPath := path.Join(dir, keyType+string(passphrase), "identity.key")
+			require.NoError(t, WritePrivKey(identityPath, priv, passphrase))
+
+			read, err := ReadPrivKey(identityPath, passphrase)
+			require.NoError(t, err)
+			require.True(t, priv.Equals(read), "%s key read back", keyType)
+			loaded, err := LoadOrCreateEncryptedPrivKey(identityPath, passphrase, log.DefaultLogger())
+			require.NoError(t, err)
+			require.True(t, priv.Equals(loaded))
+
+			if passphrase != nil {
+				_, err = ReadPrivKey(identityPath, nil)
+				require.ErrorIs(t, err, ErrPassphraseRequired)
+				_, err = ReadPrivKey(identityPath, []byte("wrong"))
+				require.Error(t, err)
+			}
+
+			exported, err := ExportPrivKey(priv)
+			require.NoError(t, err)
+			imported, err := ImportPrivKey(exported)
+			require.NoError(t, err)
+			require.True(t, priv.Equals(imported))
+		}
+	}
+
+	_, err := GeneratePrivKey("rsa")
+	require.Error(t, err)
+}
+
+func TestLoadOrCreateEncryptedPrivKey(t *testing.T) {
+	identityPath := path.Join(t.TempDir(), "identity.key")
+	passphrase := []byte("passphrase")
+	priv, err := LoadOrCreateEncryptedPrivKey(identityPath, passphrase, log.DefaultLogger())
+	require.NoError(t, err)
+
+	_, err = LoadOrCreatePrivKey(identityPath, log.DefaultLogger())
+	require.ErrorIs(t, err, ErrPassphraseRequired, "the created identity is encrypted")
+	loaded, err := LoadOrCreateEncryptedPrivKey(identityPath, passphrase, log.DefaultLogger())
+	require.NoError(t, err)
+	require.True(t, priv.Equals(loaded))
+}
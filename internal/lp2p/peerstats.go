@@ -0,0 +1,194 @@
+//go:build !nolibp2p
+
+package lp2p
+
+import (
+	"sync"
+	"time"
+
+	pubsub "github.com/libp2p/go-libp2p-pubsub"
+	"github.com/libp2p/go-libp2p/core/peer"
+	"github.com/libp2p/go-libp2p/core/protocol"
+
+	"github.com/drand/drand/v2/common/log"
+	"github.com/drand/go-clients/internal/metrics"
+)
+
+const (
+	// defaultInvalidThreshold is the number of invalid messages after which a peer is graylisted.
+	defaultInvalidThreshold = 10
+	// defaultGraylistDuration is how long graylisted peers are refused.
+	defaultGraylistDuration = time.Hour
+)
+
+// PeerStats holds the statistics of the messages received from a gossipsub peer.
+type PeerStats struct {
+	Valid      uint64 `json:"valid"`
+	Invalid    uint64 `json:"invalid"`
+	Ignored    uint64 `json:"ignored"`
+	Duplicate  uint64 `json:"duplicate"`
+	Bytes      uint64 `json:"bytes"`
+	Graylisted uint64 `json:"graylisted"`
+}
+
+// peerTracker is a pubsub tracer collecting per peer message statistics, and
+// graylisting the peers exceeding a number of invalid messages.
+type peerTracker struct {
+	l         log.Logger
+	self      peer.ID
+	threshold uint64
+	// graylist is called with the peers exceeding the threshold.
+	graylist func(peer.ID)
+	// known are the peers whose metrics are labeled with their ID, those of
+	// the other peers being aggregated so that the series stay bounded.
+	known map[peer.ID]struct{}
+
+	lk    sync.Mutex
+	stats map[peer.ID]*PeerStats
+	// strikes counts the invalid messages of a peer since it was last graylisted.
+	strikes map[peer.ID]uint64
+	// mesh holds the mesh peers of each topic.
+	mesh map[string]map[peer.ID]struct{}
+}
+
+var _ pubsub.RawTracer = (*peerTracker)(nil)
+
+// otherPeers is the peer label of the metrics of the peers which are not known.
+const otherPeers = "other"
+
+func newPeerTracker(l log.Logger, self peer.ID, threshold uint64, known ...peer.ID) *peerTracker {
+	if threshold == 0 {
+		threshold = defaultInvalidThreshold
+	}
+	knownSet := make(map[peer.ID]struct{}, len(known))
+	for _, p := range known {
+		knownSet[p] = struct{}{}
+	}
+	return &peerTracker{
+		l:         l,
+		self:      self,
+		threshold: threshold,
+		known:     knownSet,
+		stats:     make(map[peer.ID]*PeerStats),
+		strikes:   make(map[peer.ID]uint64),
+		mesh:      make(map[string]map[peer.ID]struct{}),
+	}
+}
+
+// MeshPeers returns the number of peers in the mesh of topic.
+func (t *peerTracker) MeshPeers(topic string) int {
+	t.lk.Lock()
+	defer t.lk.Unlock()
+	return len(t.mesh[topic])
+}
+
+// Stats returns a snapshot of the statistics of all the peers seen so far.
+func (t *peerTracker) Stats() map[peer.ID]PeerStats {
+	t.lk.Lock()
+	defer t.lk.Unlock()
+	out := make(map[peer.ID]PeerStats, len(t.stats))
+	for p, s := range t.stats {
+		out[p] = *s
+	}
+	return out
+}
+
+// record updates the statistics of the peer a message was received from.
+func (t *peerTracker) record(msg *pubsub.Message, status string, update func(s *PeerStats)) {
+	p := msg.ReceivedFrom
+	if p == t.self {
+		return
+	}
+	size := uint64(len(msg.GetData()))
+
+	t.lk.Lock()
+	s, ok := t.stats[p]
+	if !ok {
+		s = &PeerStats{}
+		t.stats[p] = s
+	}
+	update(s)
+	s.Bytes += size
+
+	graylist := false
+	if status == "invalid" {
+		t.strikes[p]++
+		if t.strikes[p] >= t.threshold {
+			delete(t.strikes, p)
+			s.Graylisted++
+			graylist = true
+		}
+	}
+	t.lk.Unlock()
+
+	label := otherPeers
+	if _, ok := t.known[p]; ok {
+		label = p.String()
+	}
+	metrics.RelayPeerMessages.WithLabelValues(label, status).Inc()
+	metrics.RelayPeerBytes.WithLabelValues(label).Add(float64(size))
+
+	if graylist && t.graylist != nil {
+		t.l.Warnw("", "relay_node", "graylisting peer", "peer", p, "invalid_messages", t.threshold)
+		metrics.RelayGraylistedPeers.Inc()
+		// the tracer is called from the pubsub event loop, which must not block on itself
+		go t.graylist(p)
+	}
+}
+
+func (t *peerTracker) DeliverMessage(msg *pubsub.Message) {
+	t.record(msg, "valid", func(s *PeerStats) { s.Valid++ })
+}
+
+func (t *peerTracker) RejectMessage(msg *pubsub.Message, reason string) {
+	switch reason {
+	case pubsub.RejectValidationIgnored, pubsub.RejectValidationQueueFull, pubsub.RejectValidationThrottled,
+		pubsub.RejectBlacklstedPeer, pubsub.RejectBlacklistedSource:
+		t.record(msg, "ignored", func(s *PeerStats) { s.Ignored++ })
+	case pubsub.RejectSelfOrigin:
+	default:
+		t.record(msg, "invalid", func(s *PeerStats) { s.Invalid++ })
+	}
+}
+
+func (t *peerTracker) DuplicateMessage(msg *pubsub.Message) {
+	t.record(msg, "duplicate", func(s *PeerStats) { s.Duplicate++ })
+}
+
+func (t *peerTracker) Graft(p peer.ID, topic string) {
+	t.lk.Lock()
+	defer t.lk.Unlock()
+	if t.mesh[topic] == nil {
+		t.mesh[topic] = make(map[peer.ID]struct{})
+	}
+	t.mesh[topic][p] = struct{}{}
+}
+
+func (t *peerTracker) Prune(p peer.ID, topic string) {
+	t.lk.Lock()
+	defer t.lk.Unlock()
+	delete(t.mesh[topic], p)
+}
+
+func (t *peerTracker) RemovePeer(p peer.ID) {
+	t.lk.Lock()
+	defer t.lk.Unlock()
+	for _, peers := range t.mesh {
+		delete(peers, p)
+	}
+}
+
+func (t *peerTracker) Leave(topic string) {
+	t.lk.Lock()
+	defer t.lk.Unlock()
+	delete(t.mesh, topic)
+}
+
+func (t *peerTracker) AddPeer(peer.ID, protocol.ID)         {}
+func (t *peerTracker) Join(string)                          {}
+func (t *peerTracker) ValidateMessage(*pubsub.Message)      {}
+func (t *peerTracker) ThrottlePeer(peer.ID)                 {}
+func (t *peerTracker) RecvRPC(*pubsub.RPC)                  {}
+func (t *peerTracker) SendRPC(*pubsub.RPC, peer.ID)         {}
+func (t *peerTracker) DropRPC(*pubsub.RPC, peer.ID)         {}
+func (t *peerTracker) UndeliverableMessage(*pubsub.Message) {}
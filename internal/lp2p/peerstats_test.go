@@ -0,0 +1,83 @@
+//go:build !nolibp2p
+
+package lp2p
+
+import (
+	"testing"
+	"time"
+
+	pubsub "github.com/libp2p/go-libp2p-pubsub"
+	pubsubpb "github.com/libp2p/go-libp2p-pubsub/pb"
+	"github.com/libp2p/go-libp2p/core/peer"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/stretchr/testify/require"
+
+	"github.com/drand/drand/v2/common/log"
+	"github.com/drand/go-clients/internal/metrics"
+)
+
+func TestPeerTracker(t *testing.T) {
+	self, good, bad := peer.ID("self"), peer.ID("good"), peer.ID("bad")
+	msg := func(from peer.ID) *pubsub.Message {
+		return &pubsub.Message{Message: &pubsubpb.Message{Data: []byte("beacon")}, ReceivedFrom: from}
+	}
+
+	graylisted := make(chan peer.ID, 1)
+	tracker := newPeerTracker(log.New(nil, log.DebugLevel, true), self, 3)
+	tracker.graylist = func(p peer.ID) { graylisted <- p }
+
+	tracker.DeliverMessage(msg(self))
+	tracker.DeliverMessage(msg(good))
+	tracker.DuplicateMessage(msg(good))
+	tracker.RejectMessage(msg(good), pubsub.RejectValidationIgnored)
+	for range 2 {
+		tracker.RejectMessage(msg(bad), pubsub.RejectValidationFailed)
+	}
+	// messages dropped because the peer is graylisted do not count as invalid
+	tracker.RejectMessage(msg(bad), pubsub.RejectBlacklstedPeer)
+
+	stats := tracker.Stats()
+	require.NotContains(t, stats, self)
+	require.Equal(t, PeerStats{Valid: 1, Duplicate: 1, Ignored: 1, Bytes: 18}, stats[good])
+	require.Equal(t, PeerStats{Invalid: 2, Ignored: 1, Bytes: 18}, stats[bad])
+	require.Empty(t, graylisted)
+
+	tracker.RejectMessage(msg(bad), pubsub.RejectInvalidSignature)
+	select {
+	case p := <-graylisted:
+		require.Equal(t, bad, p)
+	case <-time.After(time.Second):
+		t.Fatal("peer should be graylisted")
+	}
+	require.Equal(t, uint64(1), tracker.Stats()[bad].Graylisted)
+}
+
+func TestPeerTrackerMetricsLabels(t *testing.T) {
+	known, unknown := peer.ID("known"), peer.ID("unknown")
+	msg := func(from peer.ID) *pubsub.Message {
+		return &pubsub.Message{Message: &pubsubpb.Message{Data: []byte("beacon")}, ReceivedFrom: from}
+	}
+	tracker := newPeerTracker(log.New(nil, log.DebugLevel, true), peer.ID("self"), 0, known)
+
+	tracker.DeliverMessage(msg(known))
+	tracker.DeliverMessage(msg(unknown))
+	require.InDelta(t, 1, testutil.ToFloat64(metrics.RelayPeerMessages.WithLabelValues(known.String(), "valid")), 0)
+	require.GreaterOrEqual(t, testutil.ToFloat64(metrics.RelayPeerMessages.WithLabelValues(otherPeers, "valid")), 1.0)
+	require.Zero(t, testutil.ToFloat64(metrics.RelayPeerMessages.WithLabelValues(unknown.String(), "valid")))
+}
+
+func TestPeerTrackerMesh(t *testing.T) {
+	a, b := peer.ID("a"), peer.ID("b")
+	tracker := newPeerTracker(log.New(nil, log.DebugLevel, true), peer.ID("self"), 0)
+
+	tracker.Graft(a, "topic")
+	tracker.Graft(b, "topic")
+	tracker.Graft(a, "other")
+	require.Equal(t, 2, tracker.MeshPeers("topic"))
+
+	tracker.Prune(b, "topic")
+	require.Equal(t, 1, tracker.MeshPeers("topic"))
+	tracker.RemovePeer(a)
+	require.Equal(t, 0, tracker.MeshPeers("topic"))
+	require.Equal(t, 0, tracker.MeshPeers("other"))
+}
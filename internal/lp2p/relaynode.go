@@ -1,21 +1,34 @@
+//go:build !nolibp2p
+
 package lp2p
 
 import (
 	"context"
+	"crypto/tls"
 	"fmt"
+	"slices"
+	"strings"
+	"sync"
+	"sync/atomic"
 	"time"
 
 	pubsub "github.com/libp2p/go-libp2p-pubsub"
+	pubsubpb "github.com/libp2p/go-libp2p-pubsub/pb"
 	"github.com/libp2p/go-libp2p/core/crypto"
 	"github.com/libp2p/go-libp2p/core/host"
+	"github.com/libp2p/go-libp2p/core/peer"
+	"github.com/libp2p/go-libp2p/core/pnet"
 	ma "github.com/multiformats/go-multiaddr"
+	"golang.org/x/crypto/blake2b"
 	"google.golang.org/protobuf/proto"
 
 	"github.com/drand/go-clients/drand"
 
+	"github.com/drand/drand/v2/common"
+	"github.com/drand/drand/v2/common/chain"
 	"github.com/drand/drand/v2/common/log"
-	protod "github.com/drand/drand/v2/protobuf/drand"
 	"github.com/drand/go-clients/client"
+	"github.com/drand/go-clients/internal/metrics"
 )
 
 // GossipRelayConfig configures a gossip-relay relay node.
@@ -26,9 +39,59 @@ type GossipRelayConfig struct {
 	Addr         string
 	DataDir      string
 	IdentityPath string
-	CertPath     string
-	Insecure     bool
-	Client       drand.Client
+	// ListenAddrs are multiaddrs to listen on in addition to Addr, e.g. for
+	// QUIC or WebSocket, see HostConfig.
+	ListenAddrs []string
+	// AnnounceAddrs, if set, are advertised to peers instead of the listen
+	// addrs, e.g. a "/dns4/.../wss" address of a relay behind a reverse proxy.
+	AnnounceAddrs []string
+	// AppendAnnounceAddrs are advertised to peers in addition to the listen
+	// addrs, or to AnnounceAddrs.
+	AppendAnnounceAddrs []string
+	// NoAnnounceCIDRs are the IP ranges of the addrs not advertised to peers.
+	NoAnnounceCIDRs []string
+	// WebSocketTLS, if set, is used to serve the "/wss" listen addrs.
+	WebSocketTLS *tls.Config
+	// PSK, if set, is the pre-shared key of the private network the node
+	// joins, see HostConfig.
+	PSK pnet.PSK
+	// NAT configures the NAT traversal of the node, e.g. to relay connections
+	// to the peers behind NAT, see NATConfig.
+	NAT NATConfig
+	// PeerDNS is a domain name whose TXT records list the multiaddrs of peers
+	// to connect to, in addition to PeerWith, see WatchDNSPeers.
+	PeerDNS string
+	// PeerDNSRefresh is how often PeerDNS is looked up. It defaults to DefaultDNSPeersRefresh.
+	PeerDNSRefresh time.Duration
+	// PrivKey is the libp2p identity of the node. If nil, it is loaded from,
+	// or created at, IdentityPath.
+	PrivKey  crypto.PrivKey
+	CertPath string
+	Insecure bool
+	Client   drand.Client
+	// InvalidMessageThreshold is the number of invalid messages after which a
+	// peer is graylisted. It defaults to 10.
+	InvalidMessageThreshold uint64
+	// GraylistDuration is how long graylisted peers are refused. It defaults to an hour.
+	GraylistDuration time.Duration
+	// PubsubOptions are applied after the default pubsub options, e.g. to
+	// override the peer scoring parameters.
+	PubsubOptions []pubsub.Option
+	// TopicScoreParams overrides the scoring parameters of the chain topic,
+	// which default to TopicScoreParams for the period of the chain.
+	TopicScoreParams *pubsub.TopicScoreParams
+	// TopicNamespace is the prefix of the topic of the chain, which defaults
+	// to DefaultTopicNamespace, see NamespacedTopic.
+	TopicNamespace string
+	// ExtraTopics are pubsub topics each beacon is published to in addition
+	// to the topic of the chain, e.g. to migrate the mesh to a new topic
+	// without breaking the clients of the current one. "{hash}" is replaced
+	// by the chain hash.
+	ExtraTopics []string
+	// Deduplicate skips publishing the beacons already published on the
+	// topic of the chain by another peer of the mesh within the last period,
+	// e.g. by other relays following the chain from their own upstreams.
+	Deduplicate bool
 }
 
 // GossipRelayNode is a gossip-relay relay runtime.
@@ -39,8 +102,24 @@ type GossipRelayNode struct {
 	h         host.Host
 	ps        *pubsub.PubSub
 	t         *pubsub.Topic
+	extra     []*pubsub.Topic
+	tracker   *peerTracker
+	chainHash string
 	addrs     []ma.Multiaddr
 	done      chan struct{}
+	closeOnce sync.Once
+
+	// mesh records the beacons published by the other peers of the mesh, if
+	// deduplicating, see GossipRelayConfig.Deduplicate.
+	mesh *meshRounds
+
+	// client supplies the randomness relayed by the node.
+	client  drand.Client
+	started time.Time
+	// info is the chain info of the client, once fetched successfully.
+	info atomic.Pointer[chain.Info]
+	// lastBeacon is when a beacon was last received from the client, in unix nanoseconds.
+	lastBeacon atomic.Int64
 }
 
 // NewGossipRelayNode starts a new gossip-relay relay node.
@@ -54,15 +133,53 @@ func NewGossipRelayNode(l log.Logger, cfg *GossipRelayConfig) (*GossipRelayNode,
 		return nil, fmt.Errorf("parsing peer-with: %w", err)
 	}
 
-	priv, err := LoadOrCreatePrivKey(cfg.IdentityPath, l)
+	priv := cfg.PrivKey
+	if priv == nil {
+		priv, err = LoadOrCreatePrivKey(cfg.IdentityPath, l)
+		if err != nil {
+			return nil, fmt.Errorf("loading p2p key: %w", err)
+		}
+	}
+
+	self, err := peer.IDFromPrivateKey(priv)
+	if err != nil {
+		return nil, fmt.Errorf("computing peerid: %w", err)
+	}
+	graylistDuration := cfg.GraylistDuration
+	if graylistDuration <= 0 {
+		graylistDuration = defaultGraylistDuration
+	}
+	graylist, err := pubsub.NewTimeCachedBlacklist(graylistDuration)
 	if err != nil {
-		return nil, fmt.Errorf("loading p2p key: %w", err)
+		return nil, fmt.Errorf("creating graylist: %w", err)
+	}
+	// the metrics of the bootstrap peers are kept apart from the others
+	var known []peer.ID
+	if infos, err := peer.AddrInfosFromP2pAddrs(bootstrap...); err == nil {
+		for _, info := range infos {
+			known = append(known, info.ID)
+		}
 	}
+	tracker := newPeerTracker(l, self, cfg.InvalidMessageThreshold, known...)
 
-	h, ps, err := ConstructHost(priv, cfg.Addr, bootstrap, l)
+	psOpts := append([]pubsub.Option{pubsub.WithRawTracer(tracker), pubsub.WithBlacklist(graylist)}, cfg.PubsubOptions...)
+	hostCfg := &HostConfig{
+		ListenAddrs:         cfg.ListenAddrs,
+		AnnounceAddrs:       cfg.AnnounceAddrs,
+		AppendAnnounceAddrs: cfg.AppendAnnounceAddrs,
+		NoAnnounceCIDRs:     cfg.NoAnnounceCIDRs,
+		WebSocketTLS:        cfg.WebSocketTLS,
+		PSK:                 cfg.PSK,
+		NAT:                 cfg.NAT,
+	}
+	if cfg.Addr != "" {
+		hostCfg.ListenAddrs = append([]string{cfg.Addr}, cfg.ListenAddrs...)
+	}
+	h, ps, err := ConstructHostWithConfig(priv, hostCfg, bootstrap, l.Named("pubsub"), psOpts...)
 	if err != nil {
 		return nil, fmt.Errorf("constructing host: %w", err)
 	}
+	tracker.graylist = ps.BlacklistPeer
 
 	addrs, err := h.Network().InterfaceListenAddresses()
 	if err != nil {
@@ -72,11 +189,21 @@ func NewGossipRelayNode(l log.Logger, cfg *GossipRelayConfig) (*GossipRelayNode,
 	for _, a := range addrs {
 		l.Infow("", "relay_node", "has addr", "addr", fmt.Sprintf("%s/p2p/%s", a, h.ID()))
 	}
-	l.Infow("Joining PubSubTopic", "chainhash", cfg.ChainHash)
-	t, err := ps.Join(PubSubTopic(cfg.ChainHash))
+	for _, a := range h.Addrs() {
+		l.Infow("", "relay_node", "advertises addr", "addr", fmt.Sprintf("%s/p2p/%s", a, h.ID()))
+	}
+	l.Infow("Joining PubSubTopic", "chainhash", cfg.ChainHash, "namespace", cfg.TopicNamespace)
+	t, err := ps.Join(NamespacedTopic(cfg.TopicNamespace, cfg.ChainHash))
 	if err != nil {
 		return nil, fmt.Errorf("joining topic: %w", err)
 	}
+	if err := setTopicScoreParams(cfg, t); err != nil {
+		l.Warnw("", "relay_node", "failed to set topic score parameters", "err", err)
+	}
+	extra, err := joinExtraTopics(l, cfg, ps, t.String())
+	if err != nil {
+		return nil, err
+	}
 
 	g := &GossipRelayNode{
 		l:         l,
@@ -85,15 +212,88 @@ func NewGossipRelayNode(l log.Logger, cfg *GossipRelayConfig) (*GossipRelayNode,
 		h:         h,
 		ps:        ps,
 		t:         t,
+		extra:     extra,
+		tracker:   tracker,
+		chainHash: cfg.ChainHash,
 		addrs:     addrs,
 		done:      make(chan struct{}),
+		client:    cfg.Client,
+		started:   time.Now(),
 	}
 
-	go g.background(cfg.Client)
+	if cfg.Deduplicate {
+		sub, err := t.Subscribe()
+		if err != nil {
+			return nil, fmt.Errorf("subscribing to topic: %w", err)
+		}
+		ctx, cancel := context.WithCancel(context.Background())
+		go func() {
+			<-g.done
+			cancel()
+		}()
+		g.mesh = newMeshRounds()
+		go g.observeMesh(ctx, sub)
+	}
+
+	go g.background()
+	if cfg.PeerDNS != "" {
+		ctx, cancel := context.WithCancel(context.Background())
+		go func() {
+			<-g.done
+			cancel()
+		}()
+		go WatchDNSPeers(ctx, l, h, nil, cfg.PeerDNS, cfg.PeerDNSRefresh)
+	}
 
 	return g, nil
 }
 
+// joinExtraTopics joins the extra topics of cfg, skipping the duplicates and
+// the topic of the chain.
+func joinExtraTopics(l log.Logger, cfg *GossipRelayConfig, ps *pubsub.PubSub, chainTopic string) ([]*pubsub.Topic, error) {
+	joined := []string{chainTopic}
+	var topics []*pubsub.Topic
+	for _, name := range cfg.ExtraTopics {
+		name = strings.ReplaceAll(name, "{hash}", cfg.ChainHash)
+		if slices.Contains(joined, name) {
+			continue
+		}
+		l.Infow("Joining extra PubSubTopic", "topic", name)
+		t, err := ps.Join(name, pubsub.WithTopicMessageIdFn(extraTopicMessageID))
+		if err != nil {
+			return nil, fmt.Errorf("joining topic %s: %w", name, err)
+		}
+		if err := setTopicScoreParams(cfg, t); err != nil {
+			l.Warnw("", "relay_node", "failed to set topic score parameters", "topic", name, "err", err)
+		}
+		joined = append(joined, name)
+		topics = append(topics, t)
+	}
+	return topics, nil
+}
+
+// extraTopicMessageID identifies the messages of the extra topics by their
+// topic as well as their data, since the same beacons are published on the
+// topic of the chain, whose messages would otherwise be seen already.
+func extraTopicMessageID(pmsg *pubsubpb.Message) string {
+	h, _ := blake2b.New256(nil)
+	_, _ = h.Write([]byte(pmsg.GetTopic()))
+	_, _ = h.Write(pmsg.Data)
+	return string(h.Sum(nil))
+}
+
+func setTopicScoreParams(cfg *GossipRelayConfig, t *pubsub.Topic) error {
+	params := cfg.TopicScoreParams
+	if params == nil {
+		info, err := cfg.Client.Info(context.Background())
+		if err != nil {
+			return fmt.Errorf("getting chain info: %w", err)
+		}
+		params = TopicScoreParams(info.Period)
+	}
+	return t.SetScoreParams(params)
+}
+
 // Multiaddrs returns the gossipsub multiaddresses of this relay node.
 func (g *GossipRelayNode) Multiaddrs() []ma.Multiaddr {
 	base := g.h.Addrs()
@@ -108,9 +308,48 @@ func (g *GossipRelayNode) Multiaddrs() []ma.Multiaddr {
 	return b
 }
 
-// Shutdown stops the relay node.
+// PeerStats returns the statistics of the messages received from each gossipsub peer.
+func (g *GossipRelayNode) PeerStats() map[peer.ID]PeerStats {
+	return g.tracker.Stats()
+}
+
+// Healthy returns an error if no beacon was received from the client within
+// the last two periods of the chain, or since the node started, or if the chain
+// info of the client could not be fetched yet.
+func (g *GossipRelayNode) Healthy() error {
+	info, err := g.fetchInfo(context.Background())
+	if err != nil {
+		return fmt.Errorf("getting chain info: %w", err)
+	}
+	last := g.started
+	if ns := g.lastBeacon.Load(); ns != 0 {
+		last = time.Unix(0, ns)
+	}
+	if since := time.Since(last); since > 2*info.Period {
+		return fmt.Errorf("no beacon received for %s", since.Truncate(time.Second))
+	}
+	return nil
+}
+
+// Ready returns an error if the node has no peer in the mesh of its topic.
+func (g *GossipRelayNode) Ready() error {
+	if g.tracker.MeshPeers(g.t.String()) == 0 {
+		return fmt.Errorf("no mesh peer on topic %s", g.t.String())
+	}
+	return nil
+}
+
+// Shutdown stops relaying randomness.
 func (g *GossipRelayNode) Shutdown() {
-	close(g.done)
+	g.closeOnce.Do(func() {
+		close(g.done)
+	})
+}
+
+// Close stops relaying randomness and closes the libp2p host of the node.
+func (g *GossipRelayNode) Close() error {
+	g.Shutdown()
+	return g.h.Close()
 }
 
 // ParseMultiaddrSlice parses a list of addresses into multiaddrs
@@ -126,11 +365,40 @@ func ParseMultiaddrSlice(peers []string) ([]ma.Multiaddr, error) {
 	return out, nil
 }
 
-func (g *GossipRelayNode) background(w client.Watcher) {
+// infoFetchTimeout bounds the fetches of the chain info of the client.
+const infoFetchTimeout = 5 * time.Second
+
+// chainInfo returns the chain info of the client, fetching it until it was
+// fetched successfully once.
+func (g *GossipRelayNode) chainInfo(ctx context.Context) (*chain.Info, error) {
+	if info := g.info.Load(); info != nil {
+		return info, nil
+	}
+	info, err := g.client.Info(ctx)
+	if err != nil {
+		return nil, err
+	}
+	g.info.Store(info)
+	return info, nil
+}
+
+func (g *GossipRelayNode) background() {
+	c := g.client
 	ctx, cancel := context.WithCancel(context.Background())
 	defer cancel()
+	go func() {
+		<-g.done
+		cancel()
+	}()
+
+	// the chain info is only needed to deduplicate beacons and measure the
+	// publish latency, it is fetched again after publishing until it is known
+	if _, err := g.fetchInfo(ctx); err != nil {
+		g.l.Warnw("", "relay_node", "could not get chain info, publish latency will not be measured yet", "err", err)
+	}
+
 	for {
-		results := w.Watch(ctx)
+		results := c.Watch(ctx)
 	LOOP:
 		for {
 			select {
@@ -139,6 +407,7 @@ func (g *GossipRelayNode) background(w client.Watcher) {
 					g.l.Warnw("", "relay_node", "watch channel closed")
 					break LOOP
 				}
+				g.lastBeacon.Store(time.Now().UnixNano())
 
 				rd, ok := res.(*client.RandomData)
 				if !ok {
@@ -146,34 +415,68 @@ func (g *GossipRelayNode) background(w client.Watcher) {
 					continue
 				}
 
-				randB, err := proto.Marshal(&protod.PublicRandResponse{
-					Round:             res.GetRound(),
-					Signature:         res.GetSignature(),
-					PreviousSignature: rd.GetPreviousSignature(),
-					Randomness:        res.GetRandomness(),
-				})
+				randB, err := proto.Marshal(rd.ToProto())
 				if err != nil {
 					g.l.Errorw("", "relay_node", "err marshaling", "err", err)
 					continue
 				}
 
+				info := g.info.Load()
 				g.l.Debugw("publishing message",
 					"relay_node", "publish",
 					"round", res.GetRound(),
 					"time.Now", time.Now().Unix(),
 				)
 
+				if g.mesh != nil && info != nil && g.mesh.seenSince(res.GetRound(), res.GetSignature(), time.Now().Add(-info.Period)) {
+					metrics.RelayPublishSkipped.WithLabelValues(g.chainHash).Inc()
+					g.l.Debugw("", "relay_node", "randomness already published on pubsub", "round", res.GetRound())
+					g.publishExtra(ctx, res.GetRound(), randB)
+					continue
+				}
+
 				err = g.t.Publish(ctx, randB)
+				g.publishExtra(ctx, res.GetRound(), randB)
+				if info == nil {
+					info, _ = g.fetchInfo(ctx)
+				}
 				if err != nil {
+					metrics.RelayPublishFailures.WithLabelValues(g.chainHash).Inc()
 					g.l.Errorw("", "relay_node", "err publishing on pubsub", "err", err)
 					continue
 				}
+				if info != nil {
+					expected := time.Unix(common.TimeOfRound(info.Period, info.GenesisTime, res.GetRound()), 0)
+					metrics.RelayPublishLatency.WithLabelValues(g.chainHash).Observe(time.Since(expected).Seconds())
+				}
 
 				g.l.Infow("", "relay_node", "Published randomness on pubsub", "round", res.GetRound())
 			case <-g.done:
 				return
 			}
 		}
-		time.Sleep(time.Second)
+		select {
+		case <-time.After(time.Second):
+			metrics.RelayWatchRestarts.WithLabelValues(g.chainHash).Inc()
+		case <-g.done:
+			return
+		}
+	}
+}
+
+// fetchInfo fetches the chain info of the client within infoFetchTimeout.
+func (g *GossipRelayNode) fetchInfo(ctx context.Context) (*chain.Info, error) {
+	ctx, cancel := context.WithTimeout(ctx, infoFetchTimeout)
+	defer cancel()
+	return g.chainInfo(ctx)
+}
+
+// publishExtra publishes a beacon to the extra topics of the node.
+func (g *GossipRelayNode) publishExtra(ctx context.Context, round uint64, data []byte) {
+	for _, t := range g.extra {
+		if err := t.Publish(ctx, data); err != nil {
+			metrics.RelayPublishFailures.WithLabelValues(g.chainHash).Inc()
+			g.l.Errorw("", "relay_node", "err publishing on extra topic", "topic", t.String(), "round", round, "err", err)
+		}
 	}
 }
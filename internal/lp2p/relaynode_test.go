@@ -1,3 +1,5 @@
+//go:build !nolibp2p
+
 package lp2p
 
 import (
@@ -9,16 +11,20 @@ import (
 	"testing"
 	"time"
 
+	"github.com/prometheus/client_golang/prometheus/testutil"
 	"github.com/stretchr/testify/require"
+	"google.golang.org/protobuf/proto"
 
 	"github.com/drand/drand/v2/common/chain"
 	"github.com/drand/drand/v2/common/key"
 	"github.com/drand/drand/v2/common/log"
 	"github.com/drand/drand/v2/crypto"
+	drandpb "github.com/drand/drand/v2/protobuf/drand"
 
 	"github.com/drand/go-clients/client"
 	"github.com/drand/go-clients/client/test/result/mock"
 	"github.com/drand/go-clients/drand"
+	"github.com/drand/go-clients/internal/metrics"
 )
 
 type mockClient struct {
@@ -35,6 +41,9 @@ func (c *mockClient) Watch(ctx context.Context) <-chan drand.Result {
 }
 
 func (c *mockClient) Info(_ context.Context) (*chain.Info, error) {
+	if c.chainInfo == nil {
+		return nil, errors.New("no chain info")
+	}
 	return c.chainInfo, nil
 }
 
@@ -119,4 +128,90 @@ func TestWatchRetryOnClose(t *testing.T) {
 	if len(results) != 0 {
 		t.Fatal("random data items waiting to be consumed", len(results))
 	}
+
+	chainHash := hex.EncodeToString(chainInfo.Hash())
+	require.GreaterOrEqual(t, testutil.ToFloat64(metrics.RelayWatchRestarts.WithLabelValues(chainHash)), 2.0)
+	require.Eventually(t, func() bool {
+		return testutil.CollectAndCount(metrics.RelayPublishLatency) > 0
+	}, 5*time.Second, 10*time.Millisecond)
+
+	require.NoError(t, gr.Healthy())
+	require.Error(t, gr.Ready(), "the relay has no mesh peer")
+}
+
+func TestHealthyFetchesInfoLazily(t *testing.T) {
+	c := &mockClient{}
+	g := &GossipRelayNode{client: c, started: time.Now()}
+	require.Error(t, g.Healthy())
+
+	c.chainInfo = &chain.Info{Period: time.Second}
+	require.NoError(t, g.Healthy())
+	c.chainInfo = nil
+	require.NoError(t, g.Healthy(), "the chain info is cached once fetched")
+}
+
+func TestNamespacedTopic(t *testing.T) {
+	require.Equal(t, "/drand/pubsub/v0.0.0/abcd", PubSubTopic("abcd"))
+	require.Equal(t, PubSubTopic("abcd"), NamespacedTopic("", "abcd"))
+	require.Equal(t, "/example/drand/abcd", NamespacedTopic("/example/drand", "abcd"))
+	require.Equal(t, "/example/drand/abcd", NamespacedTopic("/example/drand/", "abcd"))
+}
+
+func TestExtraTopics(t *testing.T) {
+	sch, err := crypto.GetSchemeFromEnv()
+	require.NoError(t, err)
+	pair, err := key.NewKeyPair("fakeChainInfo.test:1234", sch)
+	require.NoError(t, err)
+	chainInfo := &chain.Info{
+		Period:      time.Second,
+		GenesisTime: time.Now().Unix(),
+		PublicKey:   pair.Public.Key,
+	}
+	chainHash := hex.EncodeToString(chainInfo.Hash())
+
+	// the beacon is only watched once the extra topic is subscribed to
+	release := make(chan struct{})
+	results := toRandomDataChain(mock.NewMockResult(0), mock.NewMockResult(1))
+	watchF := func(ctx context.Context) <-chan drand.Result {
+		ch := make(chan drand.Result, 1)
+		go func() {
+			defer close(ch)
+			select {
+			case <-release:
+			case <-ctx.Done():
+				return
+			}
+			ch <- &results[0]
+			<-ctx.Done()
+		}()
+		return ch
+	}
+
+	td := t.TempDir()
+	gr, err := NewGossipRelayNode(log.New(nil, log.DebugLevel, true), &GossipRelayConfig{
+		ChainHash:      chainHash,
+		Addr:           "/ip4/127.0.0.1/tcp/0",
+		IdentityPath:   path.Join(td, "identity.key"),
+		Client:         &mockClient{chainInfo, watchF},
+		ExtraTopics:    []string{"/drand/test/{hash}", NamespacedTopic("/example/drand", chainHash), "/drand/test/{hash}"},
+		TopicNamespace: "/example/drand",
+	})
+	require.NoError(t, err)
+	defer gr.Close()
+
+	require.Equal(t, "/example/drand/"+chainHash, gr.t.String())
+
+	require.Len(t, gr.extra, 1, "duplicate topics are joined once")
+	require.Equal(t, "/drand/test/"+chainHash, gr.extra[0].String())
+	sub, err := gr.extra[0].Subscribe()
+	require.NoError(t, err)
+	close(release)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	msg, err := sub.Next(ctx)
+	require.NoError(t, err)
+	var rd drandpb.PublicRandResponse
+	require.NoError(t, proto.Unmarshal(msg.Data, &rd))
+	require.Equal(t, results[0].Rnd, rd.GetRound())
 }
@@ -0,0 +1,94 @@
+//go:build !nolibp2p
+
+package lp2p
+
+import (
+	"time"
+
+	pubsub "github.com/libp2p/go-libp2p-pubsub"
+	"github.com/libp2p/go-libp2p/core/peer"
+)
+
+const (
+	// scoreRetention is how long the score of a disconnected peer is kept.
+	scoreRetention = 10 * time.Minute
+	// invalidMessageWeight is squared with the number of invalid messages, so
+	// that a handful of them is enough to graylist a peer.
+	invalidMessageWeight = -100
+)
+
+// PeerScoreParams returns the gossipsub peer scoring parameters used by drand
+// nodes. They do not include topic parameters, which depend on the period of
+// the chain and are set when joining its topic, see TopicScoreParams.
+func PeerScoreParams() *pubsub.PeerScoreParams {
+	return &pubsub.PeerScoreParams{
+		Topics:        make(map[string]*pubsub.TopicScoreParams),
+		TopicScoreCap: 100,
+		AppSpecificScore: func(peer.ID) float64 {
+			return 0
+		},
+		AppSpecificWeight: 1,
+		// relays are often deployed side by side, so only penalize large sybil sets
+		IPColocationFactorWeight:    -10,
+		IPColocationFactorThreshold: 10,
+		BehaviourPenaltyWeight:      -10,
+		BehaviourPenaltyThreshold:   6,
+		BehaviourPenaltyDecay:       pubsub.ScoreParameterDecay(10 * time.Minute),
+		DecayInterval:               pubsub.DefaultDecayInterval,
+		DecayToZero:                 pubsub.DefaultDecayToZero,
+		RetainScore:                 scoreRetention,
+	}
+}
+
+// PeerScoreThresholds returns the gossipsub score thresholds used by drand nodes.
+func PeerScoreThresholds() *pubsub.PeerScoreThresholds {
+	return &pubsub.PeerScoreThresholds{
+		GossipThreshold:             -100,
+		PublishThreshold:            -500,
+		GraylistThreshold:           -1000,
+		AcceptPXThreshold:           10,
+		OpportunisticGraftThreshold: 1,
+	}
+}
+
+// TopicScoreParams returns the scoring parameters of the topic of a chain
+// producing one beacon per period.
+//
+// Mesh delivery penalties are disabled: with a single message per period, a
+// peer cannot be told apart from a slow one before the mesh has been
+// reshuffled several times.
+func TopicScoreParams(period time.Duration) *pubsub.TopicScoreParams {
+	return &pubsub.TopicScoreParams{
+		TopicWeight: 1,
+		// reward peers staying in the mesh, up to a score of 10 after 100 periods
+		TimeInMeshWeight:  0.1,
+		TimeInMeshQuantum: period,
+		TimeInMeshCap:     100,
+		// reward peers delivering beacons first, over the last 100 periods or so
+		FirstMessageDeliveriesWeight: 1,
+		FirstMessageDeliveriesDecay:  pubsub.ScoreParameterDecay(100 * period),
+		FirstMessageDeliveriesCap:    50,
+		// invalid beacons are never expected from honest peers
+		InvalidMessageDeliveriesWeight: invalidMessageWeight,
+		InvalidMessageDeliveriesDecay:  pubsub.ScoreParameterDecay(time.Hour),
+	}
+}
+
+// GossipSubParams returns the gossipsub router parameters used by drand
+// nodes, keeping messages available for gossip for longer than the defaults
+// since a single message is published per period.
+func GossipSubParams() pubsub.GossipSubParams {
+	p := pubsub.DefaultGossipSubParams()
+	p.HistoryLength = 10
+	p.HistoryGossip = 5
+	return p
+}
+
+// ScoringOptions returns the pubsub options enabling peer scoring with the
+// default drand parameters.
+func ScoringOptions() []pubsub.Option {
+	return []pubsub.Option{
+		pubsub.WithPeerScore(PeerScoreParams(), PeerScoreThresholds()),
+		pubsub.WithGossipSubParams(GossipSubParams()),
+	}
+}
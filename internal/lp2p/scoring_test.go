@@ -0,0 +1,28 @@
+//go:build !nolibp2p
+
+package lp2p
+
+import (
+	"crypto/rand"
+	"testing"
+	"time"
+
+	"github.com/libp2p/go-libp2p/core/crypto"
+	"github.com/stretchr/testify/require"
+
+	"github.com/drand/drand/v2/common/log"
+)
+
+func TestScoringParams(t *testing.T) {
+	priv, _, err := crypto.GenerateEd25519Key(rand.Reader)
+	require.NoError(t, err)
+	h, ps, err := ConstructHost(priv, "/ip4/127.0.0.1/tcp/0", nil, log.New(nil, log.DebugLevel, true))
+	require.NoError(t, err)
+	defer h.Close()
+
+	for _, period := range []time.Duration{time.Second, 3 * time.Second, 30 * time.Second} {
+		topic, err := ps.Join(PubSubTopic(period.String()))
+		require.NoError(t, err)
+		require.NoError(t, topic.SetScoreParams(TopicScoreParams(period)))
+	}
+}
@@ -0,0 +1,105 @@
+package metrics
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"sort"
+	"sync"
+)
+
+// HealthCheck returns an error when the component it checks is unhealthy.
+type HealthCheck func() error
+
+var (
+	healthLk        sync.Mutex
+	livenessChecks  = make(map[string]HealthCheck)
+	readinessChecks = make(map[string]HealthCheck)
+)
+
+// AddLivenessCheck registers a check of the /healthz handler of the metrics
+// servlet, replacing any check of the same name. The process is live when
+// all its liveness checks pass.
+func AddLivenessCheck(name string, check HealthCheck) {
+	healthLk.Lock()
+	defer healthLk.Unlock()
+	livenessChecks[name] = check
+}
+
+// AddReadinessCheck registers a check of the /readyz handler of the metrics
+// servlet, replacing any check of the same name. The process is ready when
+// all its readiness checks pass.
+func AddReadinessCheck(name string, check HealthCheck) {
+	healthLk.Lock()
+	defer healthLk.Unlock()
+	readinessChecks[name] = check
+}
+
+// healthHandler runs the given checks, and answers 200 if they all pass, or
+// 503 with the errors of the failing ones.
+func healthHandler(checks map[string]HealthCheck) http.HandlerFunc {
+	return func(w http.ResponseWriter, _ *http.Request) {
+		healthLk.Lock()
+		names := make([]string, 0, len(checks))
+		for name := range checks {
+			names = append(names, name)
+		}
+		run := make([]HealthCheck, len(names))
+		sort.Strings(names)
+		for i, name := range names {
+			run[i] = checks[name]
+		}
+		healthLk.Unlock()
+
+		var failures []string
+		for i, check := range run {
+			if err := check(); err != nil {
+				failures = append(failures, fmt.Sprintf("%s: %v", names[i], err))
+			}
+		}
+
+		w.Header().Set("Content-Type", "text/plain; charset=utf-8")
+		if len(failures) > 0 {
+			w.WriteHeader(http.StatusServiceUnavailable)
+			for _, f := range failures {
+				fmt.Fprintln(w, f)
+			}
+			return
+		}
+		fmt.Fprintln(w, "ok")
+	}
+}
+
+// Status returns the current status of a component, encoded as JSON by the
+// /status handler.
+type Status func() any
+
+var statuses = make(map[string]Status)
+
+// AddStatus registers a status of the /status handler of the metrics servlet,
+// replacing any status of the same name, e.g. to expose the latest state of a
+// background job.
+func AddStatus(name string, status Status) {
+	healthLk.Lock()
+	defer healthLk.Unlock()
+	statuses[name] = status
+}
+
+// statusHandler answers a JSON object holding the statuses, by name.
+func statusHandler(w http.ResponseWriter, _ *http.Request) {
+	healthLk.Lock()
+	run := make(map[string]Status, len(statuses))
+	for name, status := range statuses {
+		run[name] = status
+	}
+	healthLk.Unlock()
+
+	out := make(map[string]any, len(run))
+	for name, status := range run {
+		out[name] = status()
+	}
+	w.Header().Set("Content-Type", "application/json")
+	if err := json.NewEncoder(w).Encode(out); err != nil {
+		w.WriteHeader(http.StatusInternalServerError)
+	}
+}
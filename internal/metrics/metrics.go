@@ -160,6 +160,98 @@ var (
 		[]string{"url"},
 	)
 
+	// ClientLatestLockAcquired counts how many times this client won the shared
+	// lock to refresh the latest round for replicas sharing its cache.
+	ClientLatestLockAcquired = prometheus.NewCounter(prometheus.CounterOpts{
+		Name: "client_latest_lock_acquired",
+		Help: "Number of times the shared lock to refresh the latest round was acquired.",
+	})
+
+	// ClientLatestLockContended counts how many times another replica held the
+	// shared lock to refresh the latest round.
+	ClientLatestLockContended = prometheus.NewCounter(prometheus.CounterOpts{
+		Name: "client_latest_lock_contended",
+		Help: "Number of times the shared lock to refresh the latest round was held by another replica.",
+	})
+
+	// ClientWatchDuplicates counts the rounds delivered more than once to the
+	// watch aggregator, e.g. by both the passive and active sources, and suppressed.
+	ClientWatchDuplicates = prometheus.NewCounter(prometheus.CounterOpts{
+		Name: "client_watch_duplicates",
+		Help: "Number of duplicate rounds suppressed by the watch aggregator.",
+	})
+
+	// ClientWatchDropped counts the results dropped because a watch consumer
+	// was not keeping up, by source.
+	ClientWatchDropped = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Name: "client_watch_dropped",
+		Help: "Number of watch results dropped due to a slow consumer.",
+	}, []string{"source"})
+
+	// ClientUpstreamCircuit is the state of the circuit breaker of each
+	// upstream of the optimizing client: 0 closed, 1 half-open, 2 open.
+	ClientUpstreamCircuit = prometheus.NewGaugeVec(prometheus.GaugeOpts{
+		Name: "client_upstream_circuit",
+		Help: "State of the circuit breaker of an upstream: 0 closed, 1 half-open, 2 open.",
+	}, []string{"upstream"})
+
+	// ClientForkedRounds counts the rounds for which the upstreams of the
+	// client served different signatures.
+	ClientForkedRounds = prometheus.NewCounter(prometheus.CounterOpts{
+		Name: "client_forked_rounds",
+		Help: "Number of rounds served with different signatures by the upstreams of the client.",
+	})
+
+	// Relay metrics
+
+	// RelayPeerMessages counts the gossipsub messages received by the relay from
+	// each bootstrap peer, or from the "other" peers, by validation status:
+	// valid, invalid, ignored or duplicate.
+	RelayPeerMessages = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Name: "relay_peer_messages",
+		Help: "Number of gossipsub messages received from a peer, by validation status.",
+	}, []string{"peer", "status"})
+
+	// RelayPeerBytes counts the bytes of the gossipsub messages received by the
+	// relay from each bootstrap peer, or from the "other" peers.
+	RelayPeerBytes = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Name: "relay_peer_bytes",
+		Help: "Number of bytes of gossipsub messages received from a peer.",
+	}, []string{"peer"})
+
+	// RelayGraylistedPeers counts how many times peers were graylisted for sending invalid messages.
+	RelayGraylistedPeers = prometheus.NewCounter(prometheus.CounterOpts{
+		Name: "relay_graylisted_peers",
+		Help: "Number of times a peer was graylisted for sending too many invalid messages.",
+	})
+
+	// RelayPublishLatency measures how long after the expected time of their
+	// round beacons are published by the relay, by chain hash.
+	RelayPublishLatency = prometheus.NewHistogramVec(prometheus.HistogramOpts{
+		Name:    "relay_publish_latency_seconds",
+		Help:    "Time between the expected time of a round and its publication on pubsub by the relay.",
+		Buckets: []float64{.1, .25, .5, 1, 2, 3, 5, 10, 30},
+	}, []string{"chain"})
+
+	// RelayPublishFailures counts the beacons the relay failed to publish, by chain hash.
+	RelayPublishFailures = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Name: "relay_publish_failures",
+		Help: "Number of beacons that could not be published on pubsub by the relay.",
+	}, []string{"chain"})
+
+	// RelayPublishSkipped counts the beacons the relay did not publish since
+	// another peer of the mesh already had, by chain hash.
+	RelayPublishSkipped = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Name: "relay_publish_skipped",
+		Help: "Number of beacons not published on pubsub by the relay since already published by another peer.",
+	}, []string{"chain"})
+
+	// RelayWatchRestarts counts the restarts of the upstream watch of the relay, by chain hash.
+	RelayWatchRestarts = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Name: "relay_watch_restarts",
+		Help: "Number of times the upstream watch of the relay ended and was restarted.",
+	}, []string{"chain"})
+
 	dkgEpoch = prometheus.NewGaugeVec(
 		prometheus.GaugeOpts{
 			Name: "dkg_epoch",
@@ -311,6 +403,12 @@ func bindMetrics(l log.Logger) {
 		}
 	}
 
+	// Relay metrics
+	if err := RegisterRelayMetrics(PrivateMetrics); err != nil {
+		l.Errorw("error in bindMetrics", "metrics", "bindMetrics", "err", err)
+		return
+	}
+
 	// Client metrics
 	if err := RegisterClientMetrics(ClientMetrics); err != nil {
 		l.Errorw("error in bindMetrics", "metrics", "bindMetrics", "err", err)
@@ -335,6 +433,12 @@ func RegisterClientMetrics(r prometheus.Registerer) error {
 		ClientHTTPHeartbeatSuccess,
 		ClientHTTPHeartbeatFailure,
 		ClientHTTPHeartbeatLatency,
+		ClientLatestLockAcquired,
+		ClientLatestLockContended,
+		ClientWatchDuplicates,
+		ClientWatchDropped,
+		ClientUpstreamCircuit,
+		ClientForkedRounds,
 	}
 	for _, c := range client {
 		if err := r.Register(c); err != nil {
@@ -344,6 +448,25 @@ func RegisterClientMetrics(r prometheus.Registerer) error {
 	return nil
 }
 
+// RegisterRelayMetrics registers gossip relay metrics with the given registry
+func RegisterRelayMetrics(r prometheus.Registerer) error {
+	relay := []prometheus.Collector{
+		RelayPeerMessages,
+		RelayPeerBytes,
+		RelayGraylistedPeers,
+		RelayPublishLatency,
+		RelayPublishFailures,
+		RelayPublishSkipped,
+		RelayWatchRestarts,
+	}
+	for _, c := range relay {
+		if err := r.Register(c); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
 // Handler abstracts a helper for relaying http requests to a group peer
 type Handler func(ctx context.Context, addr string) (http.Handler, error)
 
@@ -352,7 +475,10 @@ type Client interface {
 	GetMetrics(ctx context.Context, p string) (string, error)
 }
 
-// Start starts a prometheus metrics server with debug endpoints. If metricsBind is 0 it will use an available port.
+// Start starts a prometheus metrics server with debug endpoints, the /healthz
+// and /readyz endpoints reporting the checks added with AddLivenessCheck and
+// AddReadinessCheck, and the /status endpoint reporting the statuses added
+// with AddStatus. If metricsBind is 0 it will use an available port.
 func Start(logger log.Logger, metricsBind string, pprof http.Handler, cli Client) net.Listener {
 	logger.Infow("metrics starting", "desired_port", metricsBind)
 
@@ -373,8 +499,13 @@ func Start(logger log.Logger, metricsBind string, pprof http.Handler, cli Client
 
 	mux := http.NewServeMux()
 	mux.Handle("/metrics", promhttp.HandlerFor(PrivateMetrics, promhttp.HandlerOpts{Registry: PrivateMetrics}))
+	mux.Handle("/healthz", healthHandler(livenessChecks))
+	mux.Handle("/readyz", healthHandler(readinessChecks))
+	mux.HandleFunc("/status", statusHandler)
 
-	mux.Handle("/peer/", newRemotePeerHandler(logger, cli))
+	if cli != nil {
+		mux.Handle("/peer/", newRemotePeerHandler(logger, cli))
+	}
 
 	if pprof != nil {
 		mux.Handle("/debug/pprof/", pprof)
@@ -1,6 +1,9 @@
 package metrics
 
 import (
+	"errors"
+	"net/http"
+	"net/http/httptest"
 	"testing"
 	"time"
 )
@@ -21,3 +24,36 @@ func TestBuildTimestamp(t *testing.T) {
 		t.Fatalf("Error converting build timestamp to number. Expected %v, actual %v", expected, actual)
 	}
 }
+
+func TestStatusHandler(t *testing.T) {
+	AddStatus("archive", func() any { return map[string]string{"cid": "bafy"} })
+	rec := httptest.NewRecorder()
+	statusHandler(rec, httptest.NewRequest(http.MethodGet, "/status", nil))
+	if rec.Code != http.StatusOK {
+		t.Fatalf("expected status 200, got %d", rec.Code)
+	}
+	if body := rec.Body.String(); body != `{"archive":{"cid":"bafy"}}`+"\n" {
+		t.Fatalf("unexpected body %q", body)
+	}
+}
+
+func TestHealthHandler(t *testing.T) {
+	checks := map[string]HealthCheck{
+		"ok": func() error { return nil },
+	}
+	rec := httptest.NewRecorder()
+	healthHandler(checks)(rec, httptest.NewRequest(http.MethodGet, "/healthz", nil))
+	if rec.Code != http.StatusOK {
+		t.Fatalf("expected status 200, got %d", rec.Code)
+	}
+
+	checks["failing"] = func() error { return errors.New("no beacon") }
+	rec = httptest.NewRecorder()
+	healthHandler(checks)(rec, httptest.NewRequest(http.MethodGet, "/healthz", nil))
+	if rec.Code != http.StatusServiceUnavailable {
+		t.Fatalf("expected status 503, got %d", rec.Code)
+	}
+	if body := rec.Body.String(); body != "failing: no beacon\n" {
+		t.Fatalf("unexpected body %q", body)
+	}
+}
@@ -0,0 +1,194 @@
+// Package proxy implements an HTTP proxy in front of a drand relay that can
+// record every upstream response to disk and later replay them with their
+// original timing, to reproduce timing-sensitive consumer bugs.
+package proxy
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	nhttp "net/http"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	clock "github.com/jonboulle/clockwork"
+
+	"github.com/drand/drand/v2/common/log"
+)
+
+// maxRecordedBody bounds the size of the responses recorded: the larger ones
+// are answered with an error rather than recorded truncated.
+const maxRecordedBody = 1 << 20
+
+// Entry is a single recorded upstream response.
+type Entry struct {
+	// Offset is the time elapsed since the start of the recording when the response was received.
+	Offset      time.Duration `json:"offset"`
+	Path        string        `json:"path"`
+	Status      int           `json:"status"`
+	ContentType string        `json:"content_type,omitempty"`
+	Body        []byte        `json:"body"`
+}
+
+// Recorder is an HTTP handler forwarding requests to an upstream relay and
+// recording its responses as JSON lines.
+type Recorder struct {
+	upstream string
+	client   *nhttp.Client
+	clock    clock.Clock
+	start    time.Time
+	log      log.Logger
+
+	lk  sync.Mutex
+	enc *json.Encoder
+}
+
+// NewRecorder creates a Recorder proxying to the upstream root URL and writing the recording to w.
+func NewRecorder(l log.Logger, upstream string, w io.Writer, clk clock.Clock) *Recorder {
+	if clk == nil {
+		clk = clock.NewRealClock()
+	}
+	return &Recorder{
+		upstream: strings.TrimSuffix(upstream, "/"),
+		client:   &nhttp.Client{Timeout: time.Minute},
+		clock:    clk,
+		start:    clk.Now(),
+		log:      l,
+		enc:      json.NewEncoder(w),
+	}
+}
+
+// ServeHTTP forwards the request upstream, records the response and writes it back.
+func (r *Recorder) ServeHTTP(w nhttp.ResponseWriter, req *nhttp.Request) {
+	url := r.upstream + req.URL.RequestURI()
+	upReq, err := nhttp.NewRequestWithContext(req.Context(), req.Method, url, nhttp.NoBody)
+	if err != nil {
+		nhttp.Error(w, err.Error(), nhttp.StatusInternalServerError)
+		return
+	}
+	upReq.Header.Set("User-Agent", req.UserAgent())
+
+	resp, err := r.client.Do(upReq)
+	if err != nil {
+		r.log.Warnw("", "proxy", "upstream request failed", "url", url, "err", err)
+		nhttp.Error(w, err.Error(), nhttp.StatusBadGateway)
+		return
+	}
+	defer resp.Body.Close()
+
+	body, err := io.ReadAll(io.LimitReader(resp.Body, maxRecordedBody+1))
+	if err != nil {
+		nhttp.Error(w, err.Error(), nhttp.StatusBadGateway)
+		return
+	}
+	if len(body) > maxRecordedBody {
+		r.log.Warnw("", "proxy", "upstream response too large to record", "url", url, "max_bytes", maxRecordedBody)
+		nhttp.Error(w, fmt.Sprintf("upstream response larger than %d bytes cannot be recorded", maxRecordedBody), nhttp.StatusBadGateway)
+		return
+	}
+
+	e := Entry{
+		Offset:      r.clock.Since(r.start),
+		Path:        req.URL.RequestURI(),
+		Status:      resp.StatusCode,
+		ContentType: resp.Header.Get("Content-Type"),
+		Body:        body,
+	}
+	r.lk.Lock()
+	err = r.enc.Encode(&e)
+	r.lk.Unlock()
+	if err != nil {
+		r.log.Errorw("", "proxy", "failed to record response", "path", e.Path, "err", err)
+	}
+
+	writeEntry(w, &e)
+}
+
+// Replayer is an HTTP handler serving a recording made by a Recorder, with
+// the original timing: each request is answered with the latest response
+// recorded for its path at the same time offset from the start.
+type Replayer struct {
+	clock   clock.Clock
+	start   time.Time
+	entries map[string][]*Entry
+	log     log.Logger
+}
+
+// NewReplayer loads a recording. The replay starts when NewReplayer returns.
+func NewReplayer(l log.Logger, recording io.Reader, clk clock.Clock) (*Replayer, error) {
+	if clk == nil {
+		clk = clock.NewRealClock()
+	}
+	entries := make(map[string][]*Entry)
+	scanner := bufio.NewScanner(recording)
+	scanner.Buffer(make([]byte, 0, 64*1024), 2*maxRecordedBody)
+	for line := 1; scanner.Scan(); line++ {
+		var e Entry
+		if err := json.Unmarshal(scanner.Bytes(), &e); err != nil {
+			return nil, fmt.Errorf("decoding recording line %d: %w", line, err)
+		}
+		entries[e.Path] = append(entries[e.Path], &e)
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("reading recording: %w", err)
+	}
+	if len(entries) == 0 {
+		return nil, errors.New("empty recording")
+	}
+	for _, es := range entries {
+		sort.SliceStable(es, func(i, j int) bool { return es[i].Offset < es[j].Offset })
+	}
+
+	return &Replayer{
+		clock:   clk,
+		start:   clk.Now(),
+		entries: entries,
+		log:     l,
+	}, nil
+}
+
+// ServeHTTP answers with the recorded response matching the request path and
+// the time elapsed since the start of the replay, waiting for the first
+// recorded response of that path if needed.
+func (r *Replayer) ServeHTTP(w nhttp.ResponseWriter, req *nhttp.Request) {
+	es, ok := r.entries[req.URL.RequestURI()]
+	if !ok {
+		nhttp.NotFound(w, req)
+		return
+	}
+
+	e, err := r.entryAt(req.Context(), es)
+	if err != nil {
+		return
+	}
+	writeEntry(w, e)
+}
+
+func (r *Replayer) entryAt(ctx context.Context, es []*Entry) (*Entry, error) {
+	elapsed := r.clock.Since(r.start)
+	idx := sort.Search(len(es), func(i int) bool { return es[i].Offset > elapsed })
+	if idx > 0 {
+		return es[idx-1], nil
+	}
+
+	// nothing was recorded for this path yet at this point of the recording
+	select {
+	case <-r.clock.After(es[0].Offset - elapsed):
+		return es[0], nil
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	}
+}
+
+func writeEntry(w nhttp.ResponseWriter, e *Entry) {
+	if e.ContentType != "" {
+		w.Header().Set("Content-Type", e.ContentType)
+	}
+	w.WriteHeader(e.Status)
+	_, _ = w.Write(e.Body)
+}
@@ -0,0 +1,98 @@
+package proxy
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	nhttp "net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	clock "github.com/jonboulle/clockwork"
+	"github.com/stretchr/testify/require"
+
+	"github.com/drand/drand/v2/common/log"
+)
+
+func get(t *testing.T, url string) (int, string) {
+	t.Helper()
+	req, err := nhttp.NewRequestWithContext(context.Background(), nhttp.MethodGet, url, nhttp.NoBody)
+	require.NoError(t, err)
+	resp, err := nhttp.DefaultClient.Do(req)
+	require.NoError(t, err)
+	defer resp.Body.Close()
+	b, err := io.ReadAll(resp.Body)
+	require.NoError(t, err)
+	return resp.StatusCode, string(b)
+}
+
+func TestRecordReplay(t *testing.T) {
+	lg := log.New(nil, log.DebugLevel, true)
+	var latest atomic.Int64
+	latest.Store(1)
+	upstream := httptest.NewServer(nhttp.HandlerFunc(func(w nhttp.ResponseWriter, r *nhttp.Request) {
+		switch r.URL.Path {
+		case "/public/latest":
+			w.Header().Set("Content-Type", "application/json")
+			fmt.Fprintf(w, `{"round":%d}`, latest.Load())
+		case "/large":
+			_, _ = w.Write(bytes.Repeat([]byte("x"), maxRecordedBody+1))
+		default:
+			nhttp.NotFound(w, r)
+		}
+	}))
+	defer upstream.Close()
+
+	var recording bytes.Buffer
+	recClock := clock.NewFakeClock()
+	recorder := httptest.NewServer(NewRecorder(lg, upstream.URL, &recording, recClock))
+
+	recClock.Advance(time.Second)
+	_, body := get(t, recorder.URL+"/public/latest")
+	require.Equal(t, `{"round":1}`, body)
+	recClock.Advance(3 * time.Second)
+	latest.Store(2)
+	_, body = get(t, recorder.URL+"/public/latest")
+	require.Equal(t, `{"round":2}`, body)
+	status, _ := get(t, recorder.URL+"/info")
+	require.Equal(t, nhttp.StatusNotFound, status)
+	// responses too large to be recorded are not truncated
+	status, _ = get(t, recorder.URL+"/large")
+	require.Equal(t, nhttp.StatusBadGateway, status)
+	recorder.Close()
+
+	playClock := clock.NewFakeClock()
+	replayer, err := NewReplayer(lg, &recording, playClock)
+	require.NoError(t, err)
+	replay := httptest.NewServer(replayer)
+	defer replay.Close()
+
+	// before the first recorded response, requests wait for it
+	done := make(chan string)
+	go func() {
+		_, body := get(t, replay.URL+"/public/latest")
+		done <- body
+	}()
+	require.NoError(t, playClock.BlockUntilContext(context.Background(), 1))
+	playClock.Advance(time.Second)
+	require.Equal(t, `{"round":1}`, <-done)
+
+	playClock.Advance(2 * time.Second)
+	_, body = get(t, replay.URL+"/public/latest")
+	require.Equal(t, `{"round":1}`, body)
+
+	playClock.Advance(time.Second)
+	_, body = get(t, replay.URL+"/public/latest")
+	require.Equal(t, `{"round":2}`, body)
+
+	status, _ = get(t, replay.URL+"/info")
+	require.Equal(t, nhttp.StatusNotFound, status)
+
+	status, _ = get(t, replay.URL+"/unknown")
+	require.Equal(t, nhttp.StatusNotFound, status)
+	status, _ = get(t, replay.URL+"/large")
+	require.Equal(t, nhttp.StatusNotFound, status)
+}
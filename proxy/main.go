@@ -0,0 +1,119 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	nhttp "net/http"
+	"os"
+	"time"
+
+	"github.com/urfave/cli/v2"
+
+	"github.com/drand/drand/v2/common/log"
+	"github.com/drand/go-clients/internal/proxy"
+)
+
+// Automatically set through -ldflags
+// Example: go install -ldflags "-X main.buildDate=`date -u +%d/%m/%Y@%H:%M:%S` -X main.gitCommit=`git rev-parse HEAD`"
+var (
+	gitCommit = "none"
+	buildDate = "unknown"
+)
+
+func main() {
+	app := &cli.App{
+		Name:     "drand-proxy",
+		Version:  "2.0.0",
+		Usage:    "record/replay HTTP proxy for drand relays",
+		Commands: []*cli.Command{recordCmd, replayCmd},
+	}
+
+	// See https://cli.urfave.org/v2/examples/bash-completions/#enabling for how to turn on.
+	app.EnableBashCompletion = true
+
+	cli.VersionPrinter = func(_ *cli.Context) {
+		fmt.Printf("drand proxy %s (date %v, commit %v)\n", app.Version, buildDate, gitCommit)
+	}
+
+	err := app.Run(os.Args)
+	if err != nil {
+		fmt.Printf("error: %+v\n", err)
+		os.Exit(1)
+	}
+}
+
+var (
+	listenFlag = &cli.StringFlag{
+		Name:    "listen",
+		Usage:   "local host:port for the proxy to listen on",
+		Value:   "127.0.0.1:8080",
+		EnvVars: []string{"DRAND_PROXY_LISTEN"},
+	}
+	upstreamFlag = &cli.StringFlag{
+		Name:     "upstream",
+		Usage:    "root URL of the drand HTTP relay to record",
+		Required: true,
+		EnvVars:  []string{"DRAND_PROXY_UPSTREAM"},
+	}
+	fileFlag = &cli.PathFlag{
+		Name:    "file",
+		Usage:   "path of the recording (JSON lines)",
+		Value:   "recording.ndjson",
+		EnvVars: []string{"DRAND_PROXY_FILE"},
+	}
+)
+
+var recordCmd = &cli.Command{
+	Name:  "record",
+	Usage: "proxies requests to an upstream relay, recording every response",
+	Flags: []cli.Flag{listenFlag, upstreamFlag, fileFlag},
+	Action: func(cctx *cli.Context) error {
+		f, err := os.OpenFile(cctx.Path(fileFlag.Name), os.O_CREATE|os.O_WRONLY|os.O_TRUNC, 0o644)
+		if err != nil {
+			return fmt.Errorf("creating recording: %w", err)
+		}
+		defer f.Close()
+
+		lg := log.New(nil, log.DefaultLevel, false)
+		return serve(cctx, lg, proxy.NewRecorder(lg, cctx.String(upstreamFlag.Name), f, nil))
+	},
+}
+
+var replayCmd = &cli.Command{
+	Name:  "replay",
+	Usage: "serves a recording with its original timing",
+	Flags: []cli.Flag{listenFlag, fileFlag},
+	Action: func(cctx *cli.Context) error {
+		f, err := os.Open(cctx.Path(fileFlag.Name))
+		if err != nil {
+			return fmt.Errorf("opening recording: %w", err)
+		}
+		defer f.Close()
+
+		lg := log.New(nil, log.DefaultLevel, false)
+		replayer, err := proxy.NewReplayer(lg, f, nil)
+		if err != nil {
+			return err
+		}
+		return serve(cctx, lg, replayer)
+	},
+}
+
+func serve(cctx *cli.Context, lg log.Logger, h nhttp.Handler) error {
+	l, err := net.Listen("tcp", cctx.String(listenFlag.Name))
+	if err != nil {
+		return fmt.Errorf("listening: %w", err)
+	}
+	s := nhttp.Server{Handler: h, ReadHeaderTimeout: 3 * time.Second}
+	go func() {
+		<-cctx.Context.Done()
+		_ = s.Shutdown(context.Background())
+	}()
+	lg.Infow("", "proxy", "listening", "addr", l.Addr())
+	if err := s.Serve(l); err != nil && !errors.Is(err, nhttp.ErrServerClosed) {
+		return err
+	}
+	return nil
+}
@@ -0,0 +1,113 @@
+/*
+Package randsource adapts drand beacons to the standard Go interfaces of
+random number generators, so that simulations and lotteries can draw from the
+public randomness of a round, e.g.
+
+	src, err := randsource.New(ctx, c, round)
+	if err != nil {
+		return err
+	}
+	winner := participants[rand.New(src).Intn(len(participants))]
+
+Anyone knowing the beacon of the round can reproduce the numbers drawn from
+it, which makes the draws verifiable, but also means that they must never be
+used as secrets, e.g. as keys.
+*/
+package randsource
+
+import (
+	"context"
+	"encoding/binary"
+	"io"
+	mrand "math/rand"
+	"math/rand/v2"
+
+	"github.com/drand/go-clients/client"
+	"github.com/drand/go-clients/drand"
+)
+
+// Domain separation of the keys derived from the signature of a beacon.
+const (
+	sourceDomain = "drand randsource v1 source:"
+	readerDomain = "drand randsource v1 reader:"
+)
+
+var (
+	_ mrand.Source64 = (*Source)(nil)
+	_ rand.Source    = (*Source)(nil)
+)
+
+// Source is a math/rand.Source64, and a math/rand/v2.Source, generating a
+// deterministic stream of numbers from the randomness of a beacon, with
+// ChaCha8 keyed by HKDF-SHA256 of the signature of the beacon. It is not safe
+// for concurrent use.
+type Source struct {
+	beacon drand.Result
+	rng    *rand.ChaCha8
+}
+
+// New returns a Source seeded by the beacon of round, or of the latest round
+// if 0, fetched from c. The client should verify the beacon, as the clients
+// made with client.New do.
+func New(ctx context.Context, c drand.Client, round uint64) (*Source, error) {
+	r, err := c.Get(ctx, round)
+	if err != nil {
+		return nil, err
+	}
+	return NewFromResult(r)
+}
+
+// NewFromResult returns a Source seeded by the beacon r, which should have
+// been verified.
+func NewFromResult(r drand.Result) (*Source, error) {
+	s := &Source{beacon: r}
+	if err := s.seed(0); err != nil {
+		return nil, err
+	}
+	return s, nil
+}
+
+func (s *Source) seed(seed uint64) error {
+	info := binary.BigEndian.AppendUint64([]byte(sourceDomain), seed)
+	key, err := client.DeriveRandomness(s.beacon, client.HKDFDerivation, info, 32)
+	if err != nil {
+		return err
+	}
+	s.rng = rand.NewChaCha8([32]byte(key))
+	return nil
+}
+
+// Round returns the round of the beacon seeding the source.
+func (s *Source) Round() uint64 {
+	return s.beacon.GetRound()
+}
+
+// Uint64 returns a pseudo-random 64-bit value.
+func (s *Source) Uint64() uint64 {
+	return s.rng.Uint64()
+}
+
+// Int63 returns a non-negative pseudo-random 63-bit integer.
+func (s *Source) Int63() int64 {
+	return int64(s.rng.Uint64() >> 1)
+}
+
+// Seed resets the source to the stream of the beacon for the given seed, so
+// that several independent streams can be drawn from a beacon. Sources start
+// with the stream of seed 0.
+func (s *Source) Seed(seed int64) {
+	// the signature of the beacon was checked by NewFromResult
+	_ = s.seed(uint64(seed))
+}
+
+// NewReader returns a reader of an unbounded deterministic stream of bytes
+// derived from the beacon r, with ChaCha8 keyed by HKDF-SHA256 of its
+// signature for the application context info. Readers of different contexts
+// return independent streams.
+func NewReader(r drand.Result, info []byte) (io.Reader, error) {
+	key, err := client.DeriveRandomness(r, client.HKDFDerivation, append([]byte(readerDomain), info...), 32)
+	if err != nil {
+		return nil, err
+	}
+	return rand.NewChaCha8([32]byte(key)), nil
+}
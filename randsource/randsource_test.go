@@ -0,0 +1,76 @@
+package randsource_test
+
+import (
+	"bytes"
+	"context"
+	"io"
+	"math/rand"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/drand/drand/v2/crypto"
+	clientMock "github.com/drand/go-clients/client/mock"
+	"github.com/drand/go-clients/client/test/result/mock"
+	"github.com/drand/go-clients/randsource"
+)
+
+func TestSource(t *testing.T) {
+	sch, err := crypto.GetSchemeFromEnv()
+	require.NoError(t, err)
+	_, results := mock.VerifiableResults(2, sch)
+
+	draw := func(src rand.Source) []int {
+		rng := rand.New(src)
+		return []int{rng.Intn(1000), rng.Intn(1000), rng.Intn(1000), rng.Intn(1000)}
+	}
+	src, err := randsource.NewFromResult(&results[0])
+	require.NoError(t, err)
+	require.Equal(t, results[0].GetRound(), src.Round())
+	first := draw(src)
+	src, err = randsource.NewFromResult(&results[0])
+	require.NoError(t, err)
+	require.Equal(t, first, draw(src))
+	require.GreaterOrEqual(t, src.Int63(), int64(0))
+
+	// each seed gives another stream, and seeding again restarts it
+	src.Seed(1)
+	seeded := draw(src)
+	require.NotEqual(t, first, seeded)
+	src.Seed(1)
+	require.Equal(t, seeded, draw(src))
+	src.Seed(0)
+	require.Equal(t, first, draw(src))
+
+	other, err := randsource.NewFromResult(&results[1])
+	require.NoError(t, err)
+	require.NotEqual(t, first, draw(other))
+
+	c := &clientMock.Client{Results: results[:1], StrictRounds: true}
+	fetched, err := randsource.New(context.Background(), c, results[0].GetRound())
+	require.NoError(t, err)
+	require.Equal(t, first, draw(fetched))
+
+	_, err = randsource.NewFromResult(&mock.Result{Rnd: 1})
+	require.Error(t, err)
+}
+
+func TestReader(t *testing.T) {
+	sch, err := crypto.GetSchemeFromEnv()
+	require.NoError(t, err)
+	_, results := mock.VerifiableResults(1, sch)
+
+	read := func(info string) []byte {
+		r, err := randsource.NewReader(&results[0], []byte(info))
+		require.NoError(t, err)
+		// more than a single HKDF expansion can produce
+		b := make([]byte, 10000)
+		_, err = io.ReadFull(r, b)
+		require.NoError(t, err)
+		return b
+	}
+	lottery := read("lottery")
+	require.Equal(t, lottery, read("lottery"))
+	require.False(t, bytes.Equal(lottery, read("raffle")))
+	require.False(t, bytes.Equal(lottery[:32], lottery[32:64]))
+}
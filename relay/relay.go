@@ -0,0 +1,208 @@
+//go:build !nolibp2p
+
+/*
+Package relay runs a drand gossipsub relay in process, so that applications,
+e.g. IPFS nodes, can relay drand randomness over libp2p from their own binary
+rather than running the gossip-relay command.
+
+A relay publishes the beacons watched from a drand client on the pubsub topic
+of their chain, where they can be received with the lp2p client:
+
+	c, _ := client.New(client.From(http.ForURLs(ctx, nil, urls, chainHash)...), client.WithChainHash(chainHash))
+	r, _ := relay.New(ctx, relay.Config{Client: c, ListenAddr: "/ip4/0.0.0.0/tcp/44544"})
+	defer r.Close()
+*/
+package relay
+
+import (
+	"context"
+	"crypto/tls"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"time"
+
+	pubsub "github.com/libp2p/go-libp2p-pubsub"
+	"github.com/libp2p/go-libp2p/core/crypto"
+	"github.com/libp2p/go-libp2p/core/peer"
+	"github.com/libp2p/go-libp2p/core/pnet"
+	ma "github.com/multiformats/go-multiaddr"
+
+	"github.com/drand/drand/v2/common/log"
+	"github.com/drand/go-clients/drand"
+	"github.com/drand/go-clients/internal/lp2p"
+)
+
+// DefaultListenAddr is the libp2p address relays listen on by default.
+const DefaultListenAddr = "/ip4/0.0.0.0/tcp/44544"
+
+// PeerStats holds the statistics of the messages received from a gossipsub peer.
+type PeerStats = lp2p.PeerStats
+
+// NATConfig configures the NAT traversal of a relay: AutoNAT, circuit relay v2
+// and hole punching.
+type NATConfig = lp2p.NATConfig
+
+// Config configures a relay.
+type Config struct {
+	// Client supplies the beacons to relay. It is required, and should verify
+	// the beacons, e.g. a client made with client.New.
+	Client drand.Client
+	// ChainHash is the hex encoded hash of the chain to relay. It defaults to
+	// the hash of the chain of the client.
+	ChainHash string
+	// ListenAddr is the libp2p multiaddress to listen on. It defaults to DefaultListenAddr.
+	ListenAddr string
+	// ListenAddrs are libp2p multiaddresses to listen on in addition to
+	// ListenAddr, e.g. "/ip4/0.0.0.0/udp/44544/quic-v1" for QUIC or
+	// "/ip4/0.0.0.0/tcp/44545/ws" for WebSocket.
+	ListenAddrs []string
+	// AnnounceAddrs, if set, are advertised to peers instead of the listen
+	// addresses, e.g. "/dns4/relay.example.org/tcp/443/wss" for a relay behind
+	// a reverse proxy terminating TLS for its domain.
+	AnnounceAddrs []string
+	// AppendAnnounceAddrs are advertised to peers in addition to the listen
+	// addresses, or to AnnounceAddrs, e.g. the external address of a load
+	// balancer in front of the relay.
+	AppendAnnounceAddrs []string
+	// NoAnnounceCIDRs are the IP ranges, e.g. "10.0.0.0/8", of the addresses
+	// not advertised to peers, e.g. the pod network of a Kubernetes cluster.
+	NoAnnounceCIDRs []string
+	// WebSocketTLS, if set, is used to serve the "/wss" listen addresses, with
+	// a certificate for the domain of the relay.
+	WebSocketTLS *tls.Config
+	// PSK, if set, is the pre-shared key of the libp2p private network the
+	// relay joins, so that only the peers having the same key can connect to
+	// it. Private networks only support the TCP and WebSocket transports.
+	PSK pnet.PSK
+	// NAT configures the NAT traversal of the relay, so that a relay behind
+	// NAT can still be reached, or that a public relay helps the peers behind
+	// NAT to connect to the mesh.
+	NAT NATConfig
+	// PeerWith are the multiaddresses of the peers to connect to directly.
+	PeerWith []string
+	// PeerDNS is a domain name, e.g. "_drand-relays.example.org", whose TXT
+	// records list the multiaddrs of peers to connect to. It is looked up again
+	// every PeerDNSRefresh, 10 minutes by default.
+	PeerDNS        string
+	PeerDNSRefresh time.Duration
+	// PrivKey is the libp2p identity of the relay. If nil, it is loaded from,
+	// or created at, IdentityPath.
+	PrivKey crypto.PrivKey
+	// IdentityPath is the path of the file holding the libp2p identity of the
+	// relay, base64 encoded. It defaults to "identity.key".
+	IdentityPath string
+	// InvalidMessageThreshold is the number of invalid messages after which a
+	// peer is graylisted. It defaults to 10.
+	InvalidMessageThreshold uint64
+	// GraylistDuration is how long graylisted peers are refused. It defaults to an hour.
+	GraylistDuration time.Duration
+	// PubsubOptions are applied after the default pubsub options, e.g. to
+	// override the peer scoring parameters.
+	PubsubOptions []pubsub.Option
+	// TopicNamespace is the prefix of the pubsub topic of the chain, e.g.
+	// "/example/drand" for a private network gossiping on
+	// "/example/drand/<chain hash>", so that it cannot collide with the
+	// public mesh when chain hashes are reused, e.g. in test environments. It
+	// defaults to "/drand/pubsub/v0.0.0", the namespace of the public chains.
+	TopicNamespace string
+	// ExtraTopics are pubsub topics each beacon is published to in addition
+	// to the topic of its chain, e.g. "/drand/pubsub/v1.0.0/{hash}" to migrate
+	// the mesh to a new topic version without breaking the clients of the
+	// current one, "{hash}" being replaced by the hex encoded chain hash.
+	ExtraTopics []string
+	// Deduplicate skips publishing the beacons another peer of the mesh
+	// already published within the last period, e.g. another relay following
+	// the chain from its own HTTP upstream, to reduce the traffic of the mesh.
+	// Only the beacons having the signature verified by the client count.
+	Deduplicate bool
+	// Logger is the logger of the relay. It defaults to the drand default logger.
+	Logger log.Logger
+}
+
+// Relay is a gossipsub relay running in process.
+type Relay struct {
+	node *lp2p.GossipRelayNode
+}
+
+// New starts a relay publishing the beacons of cfg.Client. The context is only
+// used to fetch the chain info of the client when no chain hash is configured.
+func New(ctx context.Context, cfg Config) (*Relay, error) {
+	if cfg.Client == nil {
+		return nil, errors.New("relay: a client is required")
+	}
+	l := cfg.Logger
+	if l == nil {
+		l = log.DefaultLogger()
+	}
+	if cfg.ChainHash == "" {
+		info, err := cfg.Client.Info(ctx)
+		if err != nil {
+			return nil, fmt.Errorf("relay: getting chain info: %w", err)
+		}
+		cfg.ChainHash = hex.EncodeToString(info.Hash())
+	} else if _, err := hex.DecodeString(cfg.ChainHash); err != nil {
+		return nil, fmt.Errorf("relay: decoding chain hash %q: %w", cfg.ChainHash, err)
+	}
+	if cfg.ListenAddr == "" {
+		cfg.ListenAddr = DefaultListenAddr
+	}
+	if cfg.IdentityPath == "" {
+		cfg.IdentityPath = "identity.key"
+	}
+
+	node, err := lp2p.NewGossipRelayNode(l, &lp2p.GossipRelayConfig{
+		ChainHash:               cfg.ChainHash,
+		PeerWith:                cfg.PeerWith,
+		PeerDNS:                 cfg.PeerDNS,
+		PeerDNSRefresh:          cfg.PeerDNSRefresh,
+		Addr:                    cfg.ListenAddr,
+		ListenAddrs:             cfg.ListenAddrs,
+		AnnounceAddrs:           cfg.AnnounceAddrs,
+		AppendAnnounceAddrs:     cfg.AppendAnnounceAddrs,
+		NoAnnounceCIDRs:         cfg.NoAnnounceCIDRs,
+		WebSocketTLS:            cfg.WebSocketTLS,
+		PSK:                     cfg.PSK,
+		NAT:                     cfg.NAT,
+		IdentityPath:            cfg.IdentityPath,
+		PrivKey:                 cfg.PrivKey,
+		Client:                  cfg.Client,
+		InvalidMessageThreshold: cfg.InvalidMessageThreshold,
+		GraylistDuration:        cfg.GraylistDuration,
+		PubsubOptions:           cfg.PubsubOptions,
+		TopicNamespace:          cfg.TopicNamespace,
+		ExtraTopics:             cfg.ExtraTopics,
+		Deduplicate:             cfg.Deduplicate,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("relay: %w", err)
+	}
+	return &Relay{node: node}, nil
+}
+
+// Multiaddrs returns the multiaddresses peers can connect to the relay on.
+func (r *Relay) Multiaddrs() []ma.Multiaddr {
+	return r.node.Multiaddrs()
+}
+
+// PeerStats returns the statistics of the messages received from each gossipsub peer.
+func (r *Relay) PeerStats() map[peer.ID]PeerStats {
+	return r.node.PeerStats()
+}
+
+// Healthy returns an error if the relay received no beacon from its client
+// within the last two periods of the chain, e.g. for liveness probes.
+func (r *Relay) Healthy() error {
+	return r.node.Healthy()
+}
+
+// Ready returns an error if the relay has no peer in the mesh of the pubsub
+// topic of its chain, e.g. for readiness probes.
+func (r *Relay) Ready() error {
+	return r.node.Ready()
+}
+
+// Close stops the relay. It does not close its client.
+func (r *Relay) Close() error {
+	return r.node.Close()
+}
@@ -0,0 +1,45 @@
+//go:build !nolibp2p
+
+package relay_test
+
+import (
+	"context"
+	"crypto/rand"
+	"testing"
+
+	"github.com/libp2p/go-libp2p/core/crypto"
+	"github.com/stretchr/testify/require"
+
+	"github.com/drand/go-clients/client"
+	"github.com/drand/go-clients/client/http"
+	"github.com/drand/go-clients/clienttest"
+	"github.com/drand/go-clients/relay"
+)
+
+func TestRelayLifecycle(t *testing.T) {
+	ctx := context.Background()
+	_, err := relay.New(ctx, relay.Config{})
+	require.Error(t, err)
+
+	upstream := clienttest.NewRelay(t)
+	hc, err := http.NewWithInfo(nil, upstream.URL(), upstream.Info(), nil)
+	require.NoError(t, err)
+	c, err := client.New(client.From(hc), client.WithChainInfo(upstream.Info()))
+	require.NoError(t, err)
+	defer c.Close()
+
+	_, err = relay.New(ctx, relay.Config{Client: c, ChainHash: "not hex"})
+	require.Error(t, err)
+
+	priv, _, err := crypto.GenerateEd25519Key(rand.Reader)
+	require.NoError(t, err)
+	r, err := relay.New(ctx, relay.Config{
+		Client:     c,
+		ListenAddr: "/ip4/127.0.0.1/tcp/0",
+		PrivKey:    priv,
+	})
+	require.NoError(t, err)
+	require.NotEmpty(t, r.Multiaddrs())
+	require.Empty(t, r.PeerStats())
+	require.NoError(t, r.Close())
+}
@@ -0,0 +1,285 @@
+/*
+Package sql stores drand beacons in a SQL database, e.g. Postgres or SQLite,
+through database/sql. The driver of the database is up to the application.
+
+A Store is both a client.Cache, so that the beacons fetched by a client are
+stored, and a read-only drand.Client serving the stored beacons. Deployments
+already archiving beacons in a database can thus serve Gets locally, and only
+hit the network for the missing rounds:
+
+	// import sqlstore "github.com/drand/go-clients/store/sql"
+	db, _ := sql.Open("postgres", dsn)
+	store, _ := sqlstore.New(ctx, db, info, sqlstore.Config{CreateTable: true})
+	c, _ := client.New(client.From(http.ForURLs(ctx, nil, urls, info.Hash())...),
+		client.WithChainInfo(info), client.WithCache(store))
+
+Beacons are stored as given: they should be verified beforehand, which is the
+case of the results added by a client made with client.New. Used as a source of
+a client, the store only serves the latest round while its highest stored round
+is the current round of the chain, so that the client asks its other sources
+for the latest round of a store which is behind.
+*/
+package sql
+
+import (
+	"context"
+	dbsql "database/sql"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+
+	clock "github.com/jonboulle/clockwork"
+
+	"github.com/drand/drand/v2/common"
+	"github.com/drand/drand/v2/common/chain"
+	"github.com/drand/drand/v2/common/log"
+	"github.com/drand/drand/v2/crypto"
+	"github.com/drand/go-clients/client"
+	"github.com/drand/go-clients/drand"
+)
+
+// Dialect is the SQL dialect of the database.
+type Dialect int
+
+const (
+	// Postgres uses $1 placeholders and BYTEA columns.
+	Postgres Dialect = iota
+	// SQLite uses ? placeholders and BLOB columns.
+	SQLite
+)
+
+// DefaultTable is the name of the table of beacons by default.
+const DefaultTable = "drand_beacons"
+
+// defaultTimeout bounds the queries of TryGet and Add, which have no context.
+const defaultTimeout = 5 * time.Second
+
+// ErrNotFound means the requested round is not in the store.
+var ErrNotFound = errors.New("round not found in store")
+
+var tableName = regexp.MustCompile(`^[A-Za-z_][A-Za-z0-9_]*(\.[A-Za-z_][A-Za-z0-9_]*)?$`)
+
+// Config configures a Store.
+type Config struct {
+	// Dialect is the SQL dialect of the database, Postgres by default.
+	Dialect Dialect
+	// Table is the name of the table of beacons, optionally qualified by its
+	// schema. It defaults to DefaultTable.
+	Table string
+	// CreateTable creates the table of beacons if it does not exist yet.
+	CreateTable bool
+	// Logger logs the errors of TryGet and Add. It defaults to the drand default logger.
+	Logger log.Logger
+	// Clock tells the current round of the chain, and defaults to the real clock.
+	Clock clock.Clock
+}
+
+// Store serves and stores the beacons of a chain in a SQL table. The beacons of
+// several chains can share a table, since rows are keyed by chain hash and round.
+type Store struct {
+	db      *dbsql.DB
+	info    *chain.Info
+	chain   string
+	table   string
+	dialect Dialect
+	log     log.Logger
+	clock   clock.Clock
+}
+
+// New returns a store of the beacons of the chain described by info in db.
+// Closing the store does not close db.
+func New(ctx context.Context, db *dbsql.DB, info *chain.Info, cfg Config) (*Store, error) {
+	if db == nil {
+		return nil, errors.New("a database is required")
+	}
+	if info == nil {
+		return nil, errors.New("chain info is required")
+	}
+	if cfg.Table == "" {
+		cfg.Table = DefaultTable
+	}
+	if !tableName.MatchString(cfg.Table) {
+		return nil, fmt.Errorf("invalid table name %q", cfg.Table)
+	}
+	if cfg.Dialect != Postgres && cfg.Dialect != SQLite {
+		return nil, fmt.Errorf("unknown dialect %d", cfg.Dialect)
+	}
+	if cfg.Logger == nil {
+		cfg.Logger = log.DefaultLogger()
+	}
+	if cfg.Clock == nil {
+		cfg.Clock = clock.NewRealClock()
+	}
+
+	s := &Store{
+		db:      db,
+		info:    info,
+		chain:   hex.EncodeToString(info.Hash()),
+		table:   cfg.Table,
+		dialect: cfg.Dialect,
+		log:     cfg.Logger.Named("sqlStore"),
+		clock:   cfg.Clock,
+	}
+	if cfg.CreateTable {
+		if err := s.createTable(ctx); err != nil {
+			return nil, err
+		}
+	}
+	return s, nil
+}
+
+func (s *Store) createTable(ctx context.Context) error {
+	blob := "BYTEA"
+	if s.dialect == SQLite {
+		blob = "BLOB"
+	}
+	_, err := s.db.ExecContext(ctx, `CREATE TABLE IF NOT EXISTS `+s.table+` (
+	chain_hash TEXT NOT NULL,
+	round BIGINT NOT NULL,
+	signature `+blob+` NOT NULL,
+	previous_signature `+blob+`,
+	PRIMARY KEY (chain_hash, round)
+)`)
+	if err != nil {
+		return fmt.Errorf("creating table %s: %w", s.table, err)
+	}
+	return nil
+}
+
+// query rewrites the ? placeholders of q for the dialect of the store.
+func (s *Store) query(q string) string {
+	if s.dialect != Postgres {
+		return q
+	}
+	var b strings.Builder
+	n := 0
+	for _, c := range q {
+		if c == '?' {
+			n++
+			b.WriteString("$" + strconv.Itoa(n))
+			continue
+		}
+		b.WriteRune(c)
+	}
+	return b.String()
+}
+
+// TryGet returns the stored beacon of round, or nil if it is not stored. As a
+// cache, the store has no round 0: the latest round is not served from it.
+func (s *Store) TryGet(round uint64) drand.Result {
+	if round == 0 {
+		return nil
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), defaultTimeout)
+	defer cancel()
+	r, err := s.get(ctx, round)
+	if err != nil {
+		if !errors.Is(err, ErrNotFound) {
+			s.log.Warnw("", "sql_store", "failed to get round", "round", round, "err", err)
+		}
+		return nil
+	}
+	return r
+}
+
+// Add stores the beacon of round, unless it is already stored.
+func (s *Store) Add(round uint64, r drand.Result) {
+	ctx, cancel := context.WithTimeout(context.Background(), defaultTimeout)
+	defer cancel()
+	if err := s.Put(ctx, round, r); err != nil {
+		s.log.Warnw("", "sql_store", "failed to add round", "round", round, "err", err)
+	}
+}
+
+// Put stores the beacon of round, unless it is already stored.
+func (s *Store) Put(ctx context.Context, round uint64, r drand.Result) error {
+	if round == 0 || round != r.GetRound() {
+		return fmt.Errorf("cannot store round %d as round %d", r.GetRound(), round)
+	}
+	_, err := s.db.ExecContext(ctx, s.query(`INSERT INTO `+s.table+
+		` (chain_hash, round, signature, previous_signature) VALUES (?, ?, ?, ?) ON CONFLICT DO NOTHING`),
+		s.chain, int64(round), r.GetSignature(), r.GetPreviousSignature())
+	if err != nil {
+		return fmt.Errorf("storing round %d: %w", round, err)
+	}
+	return nil
+}
+
+func (s *Store) get(ctx context.Context, round uint64) (*client.RandomData, error) {
+	var row *dbsql.Row
+	if round == 0 {
+		row = s.db.QueryRowContext(ctx, s.query(`SELECT round, signature, previous_signature FROM `+s.table+
+			` WHERE chain_hash = ? ORDER BY round DESC LIMIT 1`), s.chain)
+	} else {
+		row = s.db.QueryRowContext(ctx, s.query(`SELECT round, signature, previous_signature FROM `+s.table+
+			` WHERE chain_hash = ? AND round = ?`), s.chain, int64(round))
+	}
+
+	var (
+		rnd     int64
+		sig     []byte
+		prevSig []byte
+	)
+	if err := row.Scan(&rnd, &sig, &prevSig); err != nil {
+		if errors.Is(err, dbsql.ErrNoRows) {
+			return nil, fmt.Errorf("round %d: %w", round, ErrNotFound)
+		}
+		return nil, fmt.Errorf("getting round %d: %w", round, err)
+	}
+	return &client.RandomData{
+		Rnd:               uint64(rnd),
+		Random:            crypto.RandomnessFromSignature(sig),
+		Sig:               sig,
+		PreviousSignature: prevSig,
+	}, nil
+}
+
+// Get returns the stored beacon of round, or the latest stored one if round
+// is 0. It fails with ErrNotFound if the round is not stored or, for round 0,
+// if the highest stored round is behind the current round of the chain.
+func (s *Store) Get(ctx context.Context, round uint64) (drand.Result, error) {
+	r, err := s.get(ctx, round)
+	if err != nil {
+		return nil, err
+	}
+	if current := s.RoundAt(s.clock.Now()); round == 0 && r.GetRound() < current {
+		return nil, fmt.Errorf("latest stored round %d is behind round %d: %w", r.GetRound(), current, ErrNotFound)
+	}
+	return r, nil
+}
+
+// Watch returns a closed channel: a store does not produce new beacons.
+func (s *Store) Watch(_ context.Context) <-chan drand.Result {
+	ch := make(chan drand.Result)
+	close(ch)
+	return ch
+}
+
+// Info returns the info of the chain of the store.
+func (s *Store) Info(_ context.Context) (*chain.Info, error) {
+	return s.info, nil
+}
+
+// RoundAt returns the round of the chain at time t.
+func (s *Store) RoundAt(t time.Time) uint64 {
+	return common.CurrentRound(t.Unix(), s.info.Period, s.info.GenesisTime)
+}
+
+// String returns the name of the store.
+func (s *Store) String() string {
+	return "SQLStore"
+}
+
+// Close does nothing: the database of the store is owned by the caller.
+func (s *Store) Close() error {
+	return nil
+}
+
+var (
+	_ client.Cache = (*Store)(nil)
+	_ drand.Client = (*Store)(nil)
+)
@@ -0,0 +1,124 @@
+//go:build cgo
+
+// The tests run against SQLite, with a driver requiring cgo which is only a
+// dependency of the tests.
+package sql_test
+
+import (
+	"context"
+	dbsql "database/sql"
+	"path/filepath"
+	"testing"
+	"time"
+
+	clock "github.com/jonboulle/clockwork"
+	_ "github.com/mattn/go-sqlite3"
+	"github.com/stretchr/testify/require"
+
+	"github.com/drand/drand/v2/common"
+	"github.com/drand/drand/v2/crypto"
+	"github.com/drand/go-clients/client"
+	clientMock "github.com/drand/go-clients/client/mock"
+	"github.com/drand/go-clients/client/test/result/mock"
+	sqlstore "github.com/drand/go-clients/store/sql"
+)
+
+func TestStore(t *testing.T) {
+	ctx := context.Background()
+	sch, err := crypto.GetSchemeFromEnv()
+	require.NoError(t, err)
+	info, results := mock.VerifiableResults(3, sch)
+
+	db, err := dbsql.Open("sqlite3", filepath.Join(t.TempDir(), "beacons.db"))
+	require.NoError(t, err)
+	defer db.Close()
+
+	clk := clock.NewFakeClockAt(time.Unix(common.TimeOfRound(info.Period, info.GenesisTime, 2), 0))
+	store, err := sqlstore.New(ctx, db, info, sqlstore.Config{Dialect: sqlstore.SQLite, CreateTable: true, Clock: clk})
+	require.NoError(t, err)
+
+	_, err = store.Get(ctx, 1)
+	require.ErrorIs(t, err, sqlstore.ErrNotFound)
+	require.Nil(t, store.TryGet(1))
+
+	store.Add(1, &results[0])
+	require.NoError(t, store.Put(ctx, 2, &results[1]))
+	// already stored rounds are kept
+	require.NoError(t, store.Put(ctx, 2, &results[1]))
+	require.Error(t, store.Put(ctx, 3, &results[1]))
+
+	r := store.TryGet(2)
+	require.NotNil(t, r)
+	require.Equal(t, results[1].GetSignature(), r.GetSignature())
+	require.Equal(t, results[1].GetPreviousSignature(), r.GetPreviousSignature())
+	require.Equal(t, results[1].GetRandomness(), r.GetRandomness())
+
+	latest, err := store.Get(ctx, 0)
+	require.NoError(t, err)
+	require.Equal(t, uint64(2), latest.GetRound())
+	require.Nil(t, store.TryGet(0))
+
+	// the stored beacons are served, and verified, by a client using the store as source
+	c, err := client.New(client.From(store), client.WithChainInfo(info))
+	require.NoError(t, err)
+	defer c.Close()
+	r, err = c.Get(ctx, 2)
+	require.NoError(t, err)
+	require.Equal(t, results[1].GetSignature(), r.GetSignature())
+
+	_, err = sqlstore.New(ctx, db, info, sqlstore.Config{Table: "beacons; DROP TABLE x"})
+	require.Error(t, err)
+}
+
+func TestStoreStaleLatest(t *testing.T) {
+	ctx := context.Background()
+	sch, err := crypto.GetSchemeFromEnv()
+	require.NoError(t, err)
+	info, results := mock.VerifiableResults(3, sch)
+
+	db, err := dbsql.Open("sqlite3", filepath.Join(t.TempDir(), "beacons.db"))
+	require.NoError(t, err)
+	defer db.Close()
+
+	clk := clock.NewFakeClockAt(time.Unix(common.TimeOfRound(info.Period, info.GenesisTime, 2), 0))
+	store, err := sqlstore.New(ctx, db, info, sqlstore.Config{Dialect: sqlstore.SQLite, CreateTable: true, Clock: clk})
+	require.NoError(t, err)
+	require.NoError(t, store.Put(ctx, 2, &results[1]))
+
+	// once round 3 is produced, round 2 is no longer served as the latest one
+	clk.Advance(info.Period)
+	_, err = store.Get(ctx, 0)
+	require.ErrorIs(t, err, sqlstore.ErrNotFound)
+	r, err := store.Get(ctx, 2)
+	require.NoError(t, err)
+	require.Equal(t, uint64(2), r.GetRound())
+
+	require.NoError(t, store.Put(ctx, 3, &results[2]))
+	r, err = store.Get(ctx, 0)
+	require.NoError(t, err)
+	require.Equal(t, uint64(3), r.GetRound())
+}
+
+func TestStoreAsCache(t *testing.T) {
+	ctx := context.Background()
+	sch, err := crypto.GetSchemeFromEnv()
+	require.NoError(t, err)
+	info, results := mock.VerifiableResults(3, sch)
+
+	db, err := dbsql.Open("sqlite3", filepath.Join(t.TempDir(), "beacons.db"))
+	require.NoError(t, err)
+	defer db.Close()
+	store, err := sqlstore.New(ctx, db, info, sqlstore.Config{Dialect: sqlstore.SQLite, CreateTable: true})
+	require.NoError(t, err)
+
+	source := &clientMock.Client{OptionalInfo: info, Results: results, StrictRounds: true}
+	c, err := client.New(client.From(source), client.WithChainInfo(info), client.WithCache(store))
+	require.NoError(t, err)
+	defer c.Close()
+
+	_, err = c.Get(ctx, 3)
+	require.NoError(t, err)
+	r, err := store.Get(ctx, 3)
+	require.NoError(t, err)
+	require.Equal(t, results[2].GetSignature(), r.GetSignature())
+}
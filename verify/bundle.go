@@ -0,0 +1,96 @@
+package verify
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+
+	"github.com/drand/drand/v2/common/chain"
+	"github.com/drand/drand/v2/crypto"
+	"github.com/drand/go-clients/client"
+	"github.com/drand/go-clients/drand"
+)
+
+// Bundle is a self-contained proof of the randomness of a round, holding all
+// a recipient needs to verify it without contacting any relay. Recipients
+// must check that the chain of the bundle is the one they trust, e.g. with
+// Verify.
+type Bundle struct {
+	// ChainInfo is the chain info, in the JSON format served by the drand
+	// HTTP API.
+	ChainInfo json.RawMessage `json:"chain_info"`
+	// Beacon is the beacon of the round, with its previous signature for
+	// chained schemes.
+	Beacon *client.RandomData `json:"beacon"`
+	// Instructions explain how to verify the beacon, for recipients not using
+	// this package.
+	Instructions []string `json:"instructions"`
+}
+
+// NewBundle returns the proof bundle of beacon, which must be a valid beacon
+// of the chain described by info.
+func NewBundle(info *chain.Info, beacon drand.Result) (*Bundle, error) {
+	v, err := NewVerifier(info)
+	if err != nil {
+		return nil, err
+	}
+	chained := info.Scheme == crypto.DefaultSchemeID
+	b := &client.RandomData{
+		Rnd:    beacon.GetRound(),
+		Random: crypto.RandomnessFromSignature(beacon.GetSignature()),
+		Sig:    beacon.GetSignature(),
+	}
+	if chained {
+		b.PreviousSignature = beacon.GetPreviousSignature()
+	}
+	if err := v.Beacon(b); err != nil {
+		return nil, err
+	}
+
+	var infoJSON bytes.Buffer
+	if err := info.ToJSON(&infoJSON, nil); err != nil {
+		return nil, fmt.Errorf("encoding chain info: %w", err)
+	}
+	return &Bundle{
+		ChainInfo:    bytes.TrimSpace(infoJSON.Bytes()),
+		Beacon:       b,
+		Instructions: instructions(info, chained),
+	}, nil
+}
+
+func instructions(info *chain.Info, chained bool) []string {
+	message := "Compute M = SHA-256(round as 8 big endian bytes)."
+	if chained {
+		message = "Compute M = SHA-256(previous_signature || round as 8 big endian bytes)."
+	}
+	return []string{
+		fmt.Sprintf("Check that the hash of chain_info is %x, and that it is the hash of the chain you trust.", info.Hash()),
+		message,
+		fmt.Sprintf("Check that signature is a valid BLS signature of M by the public_key of chain_info, "+
+			"as specified by the %s scheme.", info.Scheme),
+		"Check that randomness is SHA-256(signature).",
+	}
+}
+
+// Verify checks that the beacon of the bundle is valid for its chain, and
+// that the chain is the one of the given hash. An empty chainHash skips the
+// latter check, so that any chain is accepted: the bundle then only proves
+// that the beacon is consistent with the chain info it came with.
+func (b *Bundle) Verify(chainHash []byte) error {
+	info, err := chain.InfoFromJSON(bytes.NewReader(b.ChainInfo))
+	if err != nil {
+		return fmt.Errorf("decoding chain info: %w", err)
+	}
+	if len(chainHash) > 0 && !bytes.Equal(info.Hash(), chainHash) {
+		return fmt.Errorf("%w: bundle is for chain %x instead of %x", drand.ErrInvalidChainHash, info.Hash(), chainHash)
+	}
+	if b.Beacon == nil {
+		return errors.New("bundle has no beacon")
+	}
+	v, err := NewVerifier(info)
+	if err != nil {
+		return err
+	}
+	return v.Beacon(b.Beacon)
+}
@@ -0,0 +1,43 @@
+package verify_test
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/drand/drand/v2/crypto"
+	"github.com/drand/go-clients/client/test/result/mock"
+	"github.com/drand/go-clients/drand"
+	"github.com/drand/go-clients/verify"
+)
+
+func TestBundle(t *testing.T) {
+	sch, err := crypto.GetSchemeFromEnv()
+	require.NoError(t, err)
+	info, results := mock.VerifiableResults(3, sch)
+
+	bundle, err := verify.NewBundle(info, &results[1])
+	require.NoError(t, err)
+	require.NotEmpty(t, bundle.Instructions)
+	if sch.Name == crypto.DefaultSchemeID {
+		require.Equal(t, results[1].GetPreviousSignature(), bundle.Beacon.GetPreviousSignature())
+	} else {
+		require.Empty(t, bundle.Beacon.GetPreviousSignature())
+	}
+
+	encoded, err := json.Marshal(bundle)
+	require.NoError(t, err)
+	var decoded verify.Bundle
+	require.NoError(t, json.Unmarshal(encoded, &decoded))
+	require.NoError(t, decoded.Verify(info.Hash()))
+	require.NoError(t, decoded.Verify(nil))
+	require.ErrorIs(t, decoded.Verify([]byte("other chain")), drand.ErrInvalidChainHash)
+
+	decoded.Beacon.Rnd++
+	require.Error(t, decoded.Verify(info.Hash()))
+
+	// bundles are only made of valid beacons
+	_, err = verify.NewBundle(info, &mock.Result{Rnd: 2, Sig: results[0].GetSignature()})
+	require.Error(t, err)
+}
@@ -0,0 +1,11 @@
+/*
+Package verify provides offline verification of drand beacons.
+
+It only relies on the chain information and the scheme registry, without any
+client nor network access, which makes it suitable for auditors and for
+verifying stored beacons on air-gapped machines. Both the chain information
+and the beacons are expected in the JSON format served by the drand HTTP API.
+A Bundle packs a beacon with its chain information, so that it can be handed
+to third parties which verify it out-of-band.
+*/
+package verify
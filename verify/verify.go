@@ -0,0 +1,87 @@
+package verify
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+
+	"github.com/drand/drand/v2/common"
+	"github.com/drand/drand/v2/common/chain"
+	"github.com/drand/drand/v2/crypto"
+	"github.com/drand/go-clients/client"
+)
+
+// ErrRandomnessMismatch means the randomness of a beacon is not derived from its signature.
+var ErrRandomnessMismatch = errors.New("randomness does not match signature")
+
+// VerifyBeacon checks that beaconJSON holds a valid beacon of the chain described by chainInfoJSON.
+//
+//nolint:revive // verify.VerifyBeacon stutters, but mirrors the scheme method of the same name
+func VerifyBeacon(chainInfoJSON, beaconJSON []byte) error {
+	info, err := chain.InfoFromJSON(bytes.NewReader(chainInfoJSON))
+	if err != nil {
+		return fmt.Errorf("decoding chain info: %w", err)
+	}
+	v, err := NewVerifier(info)
+	if err != nil {
+		return err
+	}
+	return v.BeaconJSON(beaconJSON)
+}
+
+// Beacon checks that the beacon is valid for the chain described by info.
+// Verifying several beacons of a chain is cheaper with a Verifier.
+func Beacon(info *chain.Info, beacon *client.RandomData) error {
+	v, err := NewVerifier(info)
+	if err != nil {
+		return err
+	}
+	return v.Beacon(beacon)
+}
+
+// Verifier checks beacons of a single chain. The scheme of the chain, with
+// its hash to curve domain separation tags, is only instantiated once, rather
+// than for each beacon. It is safe for concurrent use.
+type Verifier struct {
+	info   *chain.Info
+	scheme *crypto.Scheme
+}
+
+// NewVerifier returns a Verifier of the beacons of the chain described by info.
+func NewVerifier(info *chain.Info) (*Verifier, error) {
+	sch, err := crypto.SchemeFromName(info.Scheme)
+	if err != nil {
+		return nil, fmt.Errorf("invalid scheme in chain info: %w", err)
+	}
+	return &Verifier{info: info, scheme: sch}, nil
+}
+
+// BeaconJSON checks that beaconJSON holds a valid beacon of the chain.
+func (v *Verifier) BeaconJSON(beaconJSON []byte) error {
+	var beacon client.RandomData
+	if err := json.Unmarshal(beaconJSON, &beacon); err != nil {
+		return fmt.Errorf("decoding beacon: %w", err)
+	}
+	return v.Beacon(&beacon)
+}
+
+// Beacon checks that the beacon is valid for the chain.
+func (v *Verifier) Beacon(beacon *client.RandomData) error {
+	if len(beacon.GetSignature()) == 0 {
+		return fmt.Errorf("beacon %d has no signature", beacon.GetRound())
+	}
+	if beacon.Random != nil && !bytes.Equal(beacon.Random, crypto.RandomnessFromSignature(beacon.GetSignature())) {
+		return fmt.Errorf("round %d: %w", beacon.GetRound(), ErrRandomnessMismatch)
+	}
+
+	b := &common.Beacon{
+		PreviousSig: beacon.GetPreviousSignature(),
+		Round:       beacon.GetRound(),
+		Signature:   beacon.GetSignature(),
+	}
+	if err := v.scheme.VerifyBeacon(b, v.info.PublicKey); err != nil {
+		return fmt.Errorf("verification of round %d failed: %w", beacon.GetRound(), err)
+	}
+	return nil
+}
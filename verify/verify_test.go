@@ -0,0 +1,105 @@
+package verify_test
+
+import (
+	"bytes"
+	"encoding/json"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/drand/drand/v2/crypto"
+	"github.com/drand/go-clients/client"
+	"github.com/drand/go-clients/client/test/result/mock"
+	"github.com/drand/go-clients/verify"
+)
+
+func TestVerifyBeacon(t *testing.T) {
+	sch, err := crypto.GetSchemeFromEnv()
+	require.NoError(t, err)
+	info, results := mock.VerifiableResults(3, sch)
+
+	var infoJSON bytes.Buffer
+	require.NoError(t, info.ToJSON(&infoJSON, nil))
+
+	for _, r := range results {
+		beacon, err := json.Marshal(&client.RandomData{
+			Rnd:               r.GetRound(),
+			Random:            r.GetRandomness(),
+			Sig:               r.GetSignature(),
+			PreviousSignature: r.GetPreviousSignature(),
+		})
+		require.NoError(t, err)
+		require.NoError(t, verify.VerifyBeacon(infoJSON.Bytes(), beacon))
+	}
+
+	tampered, err := json.Marshal(&client.RandomData{
+		Rnd:               results[1].GetRound() + 1,
+		Sig:               results[1].GetSignature(),
+		PreviousSignature: results[1].GetPreviousSignature(),
+	})
+	require.NoError(t, err)
+	require.Error(t, verify.VerifyBeacon(infoJSON.Bytes(), tampered))
+
+	badRandomness, err := json.Marshal(&client.RandomData{
+		Rnd:               results[1].GetRound(),
+		Random:            results[0].GetRandomness(),
+		Sig:               results[1].GetSignature(),
+		PreviousSignature: results[1].GetPreviousSignature(),
+	})
+	require.NoError(t, err)
+	require.ErrorIs(t, verify.VerifyBeacon(infoJSON.Bytes(), badRandomness), verify.ErrRandomnessMismatch)
+
+	require.Error(t, verify.VerifyBeacon([]byte("{}"), []byte("{}")))
+}
+
+func TestVerifier(t *testing.T) {
+	sch, err := crypto.GetSchemeFromEnv()
+	require.NoError(t, err)
+	info, results := mock.VerifiableResults(3, sch)
+
+	v, err := verify.NewVerifier(info)
+	require.NoError(t, err)
+	for _, r := range results {
+		require.NoError(t, v.Beacon(&client.RandomData{
+			Rnd:               r.GetRound(),
+			Sig:               r.GetSignature(),
+			PreviousSignature: r.GetPreviousSignature(),
+		}))
+	}
+	require.Error(t, v.BeaconJSON([]byte("{}")))
+
+	other := *info
+	other.Scheme = "unknown"
+	_, err = verify.NewVerifier(&other)
+	require.Error(t, err)
+}
+
+func BenchmarkBeacon(b *testing.B) {
+	sch, err := crypto.GetSchemeFromEnv()
+	require.NoError(b, err)
+	info, results := mock.VerifiableResults(1, sch)
+	beacon := &client.RandomData{
+		Rnd:               results[0].GetRound(),
+		Sig:               results[0].GetSignature(),
+		PreviousSignature: results[0].GetPreviousSignature(),
+	}
+
+	b.Run("per beacon", func(b *testing.B) {
+		b.ReportAllocs()
+		for range b.N {
+			if err := verify.Beacon(info, beacon); err != nil {
+				b.Fatal(err)
+			}
+		}
+	})
+	b.Run("verifier", func(b *testing.B) {
+		v, err := verify.NewVerifier(info)
+		require.NoError(b, err)
+		b.ReportAllocs()
+		for range b.N {
+			if err := v.Beacon(beacon); err != nil {
+				b.Fatal(err)
+			}
+		}
+	})
+}